@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"math"
+)
+
+//Lists the txs affecting an account, newest first, using the account history index of a node (getAccountTxs). Pages
+//end at block boundaries, with --all the following pages are requested until the first tx of the account.
+
+type accountTxsParams struct {
+	Address  string  `json:"address"`
+	ToHeight *uint32 `json:"toHeight,omitempty"`
+	Limit    uint    `json:"limit,omitempty"`
+}
+
+type accountTxsPage struct {
+	Txs []struct {
+		Height uint32 `json:"height"`
+		Hash   string `json:"hash"`
+		Type   string `json:"type"`
+	} `json:"txs"`
+	Next *uint32 `json:"next"`
+}
+
+func GetAccountTxsCommand() cli.Command {
+	return cli.Command {
+		Name:	"account-txs",
+		Usage:	"list the txs affecting an account, newest first",
+		Action:	func(c *cli.Context) error {
+			if err := applyConfigFile(c); err != nil {
+				return err
+			}
+
+			address, err := hex.DecodeString(c.String("address"))
+			if err != nil || len(address) != 32 {
+				return errors.New("invalid argument: address must be a hex encoded address (public key)")
+			}
+
+			if c.Uint("to") > math.MaxUint32 {
+				return errors.New("invalid argument: to must be smaller than 2^32")
+			}
+
+			//Accounts are referenced by the hash of their address (the public key) in the state.
+			var accAddress [32]byte
+			copy(accAddress[:], address)
+			accHash := protocol.SerializeHashContent(accAddress)
+			params := accountTxsParams{Address: hex.EncodeToString(accHash[:]), Limit: c.Uint("limit")}
+			if c.IsSet("to") {
+				to := uint32(c.Uint("to"))
+				params.ToHeight = &to
+			}
+
+			node := newRPCChainSource(c.String("rpc"))
+			for {
+				var page accountTxsPage
+				if err := node.call("getAccountTxs", params, &page); err != nil {
+					return err
+				}
+
+				for _, tx := range page.Txs {
+					fmt.Printf("%v\t%v\t%v\n", tx.Height, tx.Type, tx.Hash)
+				}
+
+				if page.Next == nil {
+					return nil
+				}
+				if !c.Bool("all") {
+					fmt.Printf("More txs at height %v and below, use --to %v or --all.\n", *page.Next, *page.Next)
+					return nil
+				}
+				params.ToHeight = page.Next
+			}
+		},
+		Flags:	[]cli.Flag {
+			configFlag,
+			cli.StringFlag {
+				Name: 	"rpc",
+				Usage: 	"query the node's RPC interface at `IP:PORT`",
+				Value:	"localhost:8001",
+			},
+			cli.StringFlag {
+				Name: 	"address",
+				Usage: 	"the hex encoded `ADDRESS` (public key) of the account",
+			},
+			cli.UintFlag {
+				Name: 	"to",
+				Usage: 	"list the txs at `HEIGHT` and below, default: the newest txs",
+			},
+			cli.UintFlag {
+				Name: 	"limit",
+				Usage: 	"request `N` txs per page, the node's default if 0",
+			},
+			cli.BoolFlag {
+				Name: 	"all",
+				Usage: 	"request all pages",
+			},
+		},
+	}
+}
@@ -0,0 +1,430 @@
+package cli
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//A backup is a directory containing a snapshot of the database, a copy of the sign lock, the node's configuration and
+//a manifest with the checksum of every file. Key files are only referenced (path and address), private keys never
+//end up in a backup.
+
+const (
+	BACKUP_VERSION       = 1
+	BACKUP_MANIFEST_FILE = "manifest.json"
+	BACKUP_DB_FILE       = "store.db"
+	BACKUP_SIGNLOCK_FILE = "signlock.txt"
+	BACKUP_CONFIG_FILE   = "config.json"
+)
+
+type backupManifest struct {
+	Version int            `json:"version"`
+	Created string         `json:"created"`
+	Files   []backupFile   `json:"files"`
+	Keys    []backupKeyRef `json:"keys"`
+}
+
+type backupFile struct {
+	Name   string `json:"name"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+type backupKeyRef struct {
+	Name    string `json:"name"`
+	File    string `json:"file"`
+	Address string `json:"address,omitempty"`
+}
+
+type backupConfig struct {
+	Database           string `json:"database"`
+	WalletFile         string `json:"wallet"`
+	MultisigFile       string `json:"multisig"`
+	CommitmentFile     string `json:"commitment"`
+	RootKeyFile        string `json:"rootwallet"`
+	RootCommitmentFile string `json:"rootcommitment"`
+	SignLockFile       string `json:"signlock"`
+}
+
+func GetBackupCommand() cli.Command {
+	return cli.Command {
+		Name:	"backup",
+		Usage:	"create and restore backups of the node's data",
+		Subcommands: []cli.Command {
+			{
+				Name:	"create",
+				Usage:	"create a backup of the database, sign lock and configuration",
+				Action:	func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					config := backupConfig {
+						Database:			c.String("database"),
+						WalletFile:			c.String("wallet"),
+						MultisigFile:		c.String("multisig"),
+						CommitmentFile:		c.String("commitment"),
+						RootKeyFile:		c.String("rootwallet"),
+						RootCommitmentFile:	c.String("rootcommitment"),
+						SignLockFile:		c.String("signlock"),
+					}
+
+					return createBackup(c.String("output"), c.String("rpc"), config)
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					cli.StringFlag {
+						Name: 	"output, o",
+						Usage: 	"write the backup to directory `DIR`",
+					},
+					cli.StringFlag {
+						Name: 	"rpc",
+						Usage: 	"fetch the database from the running node's RPC interface at `IP:PORT` (hot backup)",
+					},
+					cli.StringFlag {
+						Name: 	"database, d",
+						Usage: 	"back up the database stored in `FILE` (node must be stopped)",
+						Value:	"store.db",
+					},
+					cli.StringFlag {
+						Name: 	"wallet, w",
+						Usage: 	"reference the validator's key `FILE`",
+						Value: 	"wallet.txt",
+					},
+					cli.StringFlag {
+						Name: 	"multisig, m",
+						Usage: 	"reference the multi-signature server's key `FILE`",
+					},
+					cli.StringFlag {
+						Name: 	"commitment, c",
+						Usage: 	"reference the validator's commitment key `FILE`",
+						Value: 	"commitment.txt",
+					},
+					cli.StringFlag {
+						Name: 	"rootwallet",
+						Usage: 	"reference root's key `FILE`",
+						Value: 	"wallet.txt",
+					},
+					cli.StringFlag {
+						Name: 	"rootcommitment",
+						Usage: 	"reference root's commitment key `FILE`",
+						Value: 	"commitment.txt",
+					},
+					cli.StringFlag {
+						Name: 	"signlock",
+						Usage: 	"back up the sign lock stored in `FILE`",
+						Value: 	"signlock.txt",
+					},
+				},
+			},
+			{
+				Name:	"restore",
+				Usage:	"verify a backup and restore the database and sign lock",
+				Action:	func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return restoreBackup(c.String("input"), c.String("database"), c.String("signlock"), c.Bool("force"))
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					cli.StringFlag {
+						Name: 	"input, i",
+						Usage: 	"restore the backup from directory `DIR`",
+					},
+					cli.StringFlag {
+						Name: 	"database, d",
+						Usage: 	"restore the database to `FILE`",
+						Value:	"store.db",
+					},
+					cli.StringFlag {
+						Name: 	"signlock",
+						Usage: 	"restore the sign lock to `FILE` if it does not exist yet",
+						Value: 	"signlock.txt",
+					},
+					cli.BoolFlag {
+						Name: 	"force",
+						Usage: 	"overwrite an existing database",
+					},
+				},
+			},
+		},
+	}
+}
+
+func createBackup(dir string, rpcAddress string, config backupConfig) error {
+	if len(dir) == 0 {
+		return errors.New("argument missing: output")
+	}
+
+	if _, err := os.Stat(dir); err == nil {
+		return errors.New(fmt.Sprintf("Backup directory %v already exists.", dir))
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+
+	manifest := backupManifest{
+		Version: BACKUP_VERSION,
+		Created: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	dbFile, err := os.OpenFile(filepath.Join(dir, BACKUP_DB_FILE), os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	if len(rpcAddress) > 0 {
+		err = fetchBackup(rpcAddress, dbFile)
+	} else {
+		_, err = storage.WriteBackupFromFile(config.Database, dbFile)
+	}
+
+	if closeErr := dbFile.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := storage.VerifyBackup(filepath.Join(dir, BACKUP_DB_FILE)); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(config.SignLockFile); err == nil {
+		if err := copyFile(config.SignLockFile, filepath.Join(dir, BACKUP_SIGNLOCK_FILE)); err != nil {
+			return err
+		}
+	}
+
+	configJson, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, BACKUP_CONFIG_FILE), configJson, 0600); err != nil {
+		return err
+	}
+
+	for _, name := range []string{BACKUP_DB_FILE, BACKUP_SIGNLOCK_FILE, BACKUP_CONFIG_FILE} {
+		if _, err := os.Stat(filepath.Join(dir, name)); os.IsNotExist(err) {
+			continue
+		}
+
+		file, err := checksumFile(dir, name)
+		if err != nil {
+			return err
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	manifest.Keys = referenceKeys(config)
+
+	manifestJson, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, BACKUP_MANIFEST_FILE), manifestJson, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Backup created successfully in %v.\n", dir)
+
+	return nil
+}
+
+func restoreBackup(dir string, dbname string, signLockFile string, force bool) error {
+	if len(dir) == 0 {
+		return errors.New("argument missing: input")
+	}
+
+	manifest, err := verifyBackupDir(dir)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbname); err == nil && !force {
+		return errors.New(fmt.Sprintf("Database %v already exists, use --force to overwrite it.", dbname))
+	}
+
+	if err := storage.CheckNotInUse(dbname); err != nil {
+		return err
+	}
+
+	if err := copyFile(filepath.Join(dir, BACKUP_DB_FILE), dbname); err != nil {
+		return err
+	}
+
+	//An existing sign lock is never replaced, it might record blocks produced after the backup was taken.
+	if _, err := os.Stat(filepath.Join(dir, BACKUP_SIGNLOCK_FILE)); err == nil {
+		if _, err := os.Stat(signLockFile); os.IsNotExist(err) {
+			if err := copyFile(filepath.Join(dir, BACKUP_SIGNLOCK_FILE), signLockFile); err != nil {
+				return err
+			}
+		} else {
+			fmt.Printf("Keeping existing sign lock %v.\n", signLockFile)
+		}
+	}
+
+	for _, key := range manifest.Keys {
+		if _, err := os.Stat(key.File); os.IsNotExist(err) {
+			fmt.Printf("Key file %v (%v) referenced by the backup is missing.\n", key.File, key.Name)
+		}
+	}
+
+	fmt.Printf("Backup from %v restored successfully to %v.\n", manifest.Created, dbname)
+
+	return nil
+}
+
+//Reads the manifest and checks the checksum of every file listed.
+func verifyBackupDir(dir string) (manifest *backupManifest, err error) {
+	manifestJson, err := ioutil.ReadFile(filepath.Join(dir, BACKUP_MANIFEST_FILE))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Could not read backup manifest: %v", err))
+	}
+
+	manifest = new(backupManifest)
+	if err := json.Unmarshal(manifestJson, manifest); err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid backup manifest: %v", err))
+	}
+
+	if manifest.Version != BACKUP_VERSION {
+		return nil, errors.New(fmt.Sprintf("Unsupported backup version: %v", manifest.Version))
+	}
+
+	hasDB := false
+	for _, expected := range manifest.Files {
+		if filepath.Base(expected.Name) != expected.Name {
+			return nil, errors.New(fmt.Sprintf("Invalid file name in backup manifest: %v", expected.Name))
+		}
+
+		actual, err := checksumFile(dir, expected.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		if actual != expected {
+			return nil, errors.New(fmt.Sprintf("Backup file %v is corrupted.", expected.Name))
+		}
+
+		if expected.Name == BACKUP_DB_FILE {
+			hasDB = true
+		}
+	}
+
+	if !hasDB {
+		return nil, errors.New("Backup contains no database.")
+	}
+
+	return manifest, storage.VerifyBackup(filepath.Join(dir, BACKUP_DB_FILE))
+}
+
+func fetchBackup(rpcAddress string, w io.Writer) error {
+	req, err := newRPCRequest(http.MethodGet, "http://"+rpcAddress+"/backup", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.New(fmt.Sprintf("Fetching backup from %v failed: %v", rpcAddress, resp.Status))
+	}
+
+	_, err = io.Copy(w, resp.Body)
+
+	return err
+}
+
+func checksumFile(dir string, name string) (file backupFile, err error) {
+	filehandle, err := os.Open(filepath.Join(dir, name))
+	if err != nil {
+		return file, err
+	}
+	defer filehandle.Close()
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, filehandle)
+	if err != nil {
+		return file, err
+	}
+
+	return backupFile{Name: name, Size: size, SHA256: hex.EncodeToString(hash.Sum(nil))}, nil
+}
+
+//Only the location of key files and the corresponding address is recorded. Wallet files which do not exist are not
+//created (in contrast to starting the node).
+func referenceKeys(config backupConfig) (keys []backupKeyRef) {
+	wallets := []backupKeyRef{
+		{Name: "wallet", File: config.WalletFile},
+		{Name: "multisig", File: config.MultisigFile},
+		{Name: "rootwallet", File: config.RootKeyFile},
+	}
+
+	for _, key := range wallets {
+		if len(key.File) == 0 {
+			continue
+		}
+		if _, err := os.Stat(key.File); err == nil {
+			if pubKey, err := crypto.ExtractEDPublicKeyFromFile(key.File); err == nil {
+				address := crypto.GetAddressFromPubKeyED(pubKey)
+				key.Address = hex.EncodeToString(address[:])
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	for _, key := range []backupKeyRef{{Name: "commitment", File: config.CommitmentFile}, {Name: "rootcommitment", File: config.RootCommitmentFile}} {
+		if len(key.File) > 0 {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+//The destination is written to a temporary file first, so an interrupted copy never leaves a partial file behind.
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmpFile := dst + ".tmp"
+	out, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(tmpFile)
+		return err
+	}
+
+	if err := out.Close(); err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+
+	return os.Rename(tmpFile, dst)
+}
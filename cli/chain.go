@@ -0,0 +1,398 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//Inspects the chain of a running node over its JSON-RPC interface: the sync status, blocks by hash or height, txs by
+//hash and the system parameters. Blocks and txs are looked up in the node's database, which must not be opened
+//directly while it is running.
+
+type chainSyncStatus struct {
+	Height        uint32  `json:"height"`
+	Hash          string  `json:"hash"`
+	Timestamp     int64   `json:"timestamp"`
+	Peers         int     `json:"peers"`
+	NetworkHeight *uint32 `json:"networkHeight"`
+	OpenTxs       int     `json:"openTxs"`
+	Ready         bool    `json:"ready"`
+	NotReady      string  `json:"notReady"`
+}
+
+type chainBlock struct {
+	Hash           string   `json:"hash"`
+	PrevHash       string   `json:"prevHash"`
+	Height         uint32   `json:"height"`
+	Timestamp      int64    `json:"timestamp"`
+	Beneficiary    string   `json:"beneficiary"`
+	MerkleRoot     string   `json:"merkleRoot"`
+	StateRoot      string   `json:"stateRoot"`
+	AccTxData      []string `json:"accTxData"`
+	FundsTxData    []string `json:"fundsTxData"`
+	ConfigTxData   []string `json:"configTxData"`
+	StakeTxData    []string `json:"stakeTxData"`
+	AggTxData      []string `json:"aggTxData"`
+	IoTTxData      []string `json:"iotTxData"`
+	ContractTxData []string `json:"contractTxData"`
+}
+
+type chainParameters struct {
+	Height                uint32 `json:"height"`
+	BlockHash             string `json:"blockHash"`
+	FeeMinimum            uint64 `json:"feeMinimum"`
+	BlockSize             uint64 `json:"blockSize"`
+	DiffInterval          uint64 `json:"diffInterval"`
+	BlockInterval         uint64 `json:"blockInterval"`
+	BlockReward           uint64 `json:"blockReward"`
+	StakingMinimum        uint64 `json:"stakingMinimum"`
+	WaitingMinimum        uint64 `json:"waitingMinimum"`
+	AcceptedTimeDiff      uint64 `json:"acceptedTimeDiff"`
+	SlashingWindowSize    uint64 `json:"slashingWindowSize"`
+	SlashReward           uint64 `json:"slashReward"`
+	BlockTriggerTxs       uint64 `json:"blockTriggerTxs"`
+	BlockTriggerTime      uint64 `json:"blockTriggerTime"`
+	IotRateCapacity       uint64 `json:"iotRateCapacity"`
+	IotRateRefill         uint64 `json:"iotRateRefill"`
+	UnbondingPeriod       uint64 `json:"unbondingPeriod"`
+	RsaCommitmentDeadline uint64 `json:"rsaCommitmentDeadline"`
+	SlashFraction         uint64 `json:"slashFraction"`
+	ConfigQuorum          uint64 `json:"configQuorum"`
+	ConfigActivationDelay uint64 `json:"configActivationDelay"`
+	DiffEmaWindow         uint64 `json:"diffEmaWindow"`
+	StateRootHeight       uint64 `json:"stateRootHeight"`
+	TxOrderHeight         uint64 `json:"txOrderHeight"`
+	AggTxAuthHeight       uint64 `json:"aggTxAuthHeight"`
+	MedianTimeHeight      uint64 `json:"medianTimeHeight"`
+	BlockHashHeight       uint64 `json:"blockHashHeight"`
+	DelegationHeight      uint64 `json:"delegationHeight"`
+	Pending               bool   `json:"pending"`
+}
+
+type chainTx struct {
+	Hash        string   `json:"hash"`
+	Type        string   `json:"type"`
+	Fee         uint64   `json:"fee"`
+	Tx          string   `json:"tx"`
+	Annotations []string `json:"annotations"`
+	Status      string   `json:"status"`
+}
+
+func GetChainCommand() cli.Command {
+	rpcFlag := cli.StringFlag {
+		Name: 	"rpc",
+		Usage: 	"query the node's RPC interface at `IP:PORT`",
+		Value:	"localhost:8001",
+	}
+
+	return cli.Command {
+		Name:	"chain",
+		Usage:	"inspect the chain of a running node",
+		Subcommands: []cli.Command {
+			{
+				Name:	"status",
+				Usage:	"show the tip of the node and whether it is in sync with the network",
+				Action:	func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return printChainStatus(newRPCChainSource(c.String("rpc")))
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					rpcFlag,
+				},
+			},
+			{
+				Name:		"block",
+				Usage:		"show a block by its hash or height",
+				ArgsUsage:	"<hash|height>",
+				Action:		func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return printChainBlock(newRPCChainSource(c.String("rpc")), c.Args().First())
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					rpcFlag,
+				},
+			},
+			{
+				Name:		"tx",
+				Usage:		"show a closed or open tx by its hash",
+				ArgsUsage:	"<hash>",
+				Action:		func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return printChainTx(newRPCChainSource(c.String("rpc")), c.Args().First())
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					rpcFlag,
+				},
+			},
+			{
+				Name:	"parameters",
+				Usage:	"show the system parameters of the next block or, with --history, all changes by config txs",
+				Action:	func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return printChainParameters(newRPCChainSource(c.String("rpc")), c.Bool("history"))
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					rpcFlag,
+					cli.BoolFlag {
+						Name: 	"history",
+						Usage: 	"show the parameter changes since the genesis block (or the node's snapshot)",
+					},
+				},
+			},
+		},
+	}
+}
+
+func printChainStatus(node *rpcChainSource) error {
+	status := new(chainSyncStatus)
+	if err := node.call("getSyncStatus", []string{}, status); err != nil {
+		return err
+	}
+
+	fmt.Printf("Height:\t\t%v\n", status.Height)
+	fmt.Printf("Hash:\t\t%v\n", status.Hash)
+	fmt.Printf("Timestamp:\t%v (%v ago)\n", formatTimestamp(status.Timestamp), time.Since(time.Unix(status.Timestamp, 0)).Truncate(time.Second))
+	fmt.Printf("Peers:\t\t%v\n", status.Peers)
+	if status.NetworkHeight == nil {
+		fmt.Printf("Network:\tunknown, no beacon peers\n")
+	} else if *status.NetworkHeight > status.Height {
+		fmt.Printf("Network:\t%v (%v blocks behind)\n", *status.NetworkHeight, *status.NetworkHeight-status.Height)
+	} else {
+		fmt.Printf("Network:\t%v (in sync)\n", *status.NetworkHeight)
+	}
+	fmt.Printf("Open txs:\t%v\n", status.OpenTxs)
+	if status.Ready {
+		fmt.Printf("Ready:\t\tyes\n")
+	} else {
+		fmt.Printf("Ready:\t\tno, %v\n", status.NotReady)
+	}
+
+	return nil
+}
+
+func printChainBlock(node *rpcChainSource, param string) error {
+	if len(param) == 0 {
+		return errors.New("argument missing: hash or height")
+	}
+
+	//Heights are decimal, hashes hex encoded with 64 digits and therefore too large for a height.
+	method, params := "getBlockByHash", interface{}([]string{param})
+	if height, err := strconv.ParseUint(param, 10, 32); err == nil {
+		method, params = "getBlockByHeight", []uint32{uint32(height)}
+	}
+
+	block := new(chainBlock)
+	if err := node.call(method, params, block); err != nil {
+		return err
+	}
+
+	fmt.Printf("Hash:\t\t%v\n", block.Hash)
+	fmt.Printf("Prev hash:\t%v\n", block.PrevHash)
+	fmt.Printf("Height:\t\t%v\n", block.Height)
+	fmt.Printf("Timestamp:\t%v\n", formatTimestamp(block.Timestamp))
+	fmt.Printf("Beneficiary:\t%v\n", block.Beneficiary)
+	fmt.Printf("Merkle root:\t%v\n", block.MerkleRoot)
+	fmt.Printf("State root:\t%v\n", block.StateRoot)
+
+	for _, txs := range []struct {
+		name   string
+		hashes []string
+	}{
+		{"Acc", block.AccTxData},
+		{"Funds", block.FundsTxData},
+		{"Config", block.ConfigTxData},
+		{"Stake", block.StakeTxData},
+		{"Agg", block.AggTxData},
+		{"IoT", block.IoTTxData},
+		{"Contract", block.ContractTxData},
+	} {
+		if len(txs.hashes) == 0 {
+			continue
+		}
+
+		fmt.Printf("%v txs (%v):\n", txs.name, len(txs.hashes))
+		for _, hash := range txs.hashes {
+			fmt.Printf("\t%v\n", hash)
+		}
+	}
+
+	return nil
+}
+
+func printChainParameters(node *rpcChainSource, history bool) error {
+	if !history {
+		params := new(chainParameters)
+		if err := node.call("getParameters", []string{}, params); err != nil {
+			return err
+		}
+
+		fmt.Printf("%-25v%v\n", "Since height:", params.Height)
+		for _, field := range params.fields() {
+			fmt.Printf("%-25v%v\n", field.name+":", field.value)
+		}
+
+		return nil
+	}
+
+	var changes []chainParameters
+	if err := node.call("getParameterHistory", []string{}, &changes); err != nil {
+		return err
+	}
+
+	//Only the parameters which changed are listed after the first entry.
+	var prev []chainParameterField
+	for _, params := range changes {
+		status := ""
+		if params.Pending {
+			status = ", pending"
+		}
+		fmt.Printf("Height %v (%.16v%v):\n", params.Height, params.BlockHash, status)
+
+		fields := params.fields()
+		for i, field := range fields {
+			if prev == nil || prev[i].value != field.value {
+				fmt.Printf("\t%-25v%v\n", field.name+":", field.value)
+			}
+		}
+		prev = fields
+	}
+
+	return nil
+}
+
+type chainParameterField struct {
+	name  string
+	value uint64
+}
+
+func (params *chainParameters) fields() []chainParameterField {
+	return []chainParameterField{
+		{"Fee minimum", params.FeeMinimum},
+		{"Block size", params.BlockSize},
+		{"Diff interval", params.DiffInterval},
+		{"Block interval", params.BlockInterval},
+		{"Block reward", params.BlockReward},
+		{"Staking minimum", params.StakingMinimum},
+		{"Waiting minimum", params.WaitingMinimum},
+		{"Accepted time diff", params.AcceptedTimeDiff},
+		{"Slashing window size", params.SlashingWindowSize},
+		{"Slash reward", params.SlashReward},
+		{"Block trigger txs", params.BlockTriggerTxs},
+		{"Block trigger time", params.BlockTriggerTime},
+		{"IoT rate capacity", params.IotRateCapacity},
+		{"IoT rate refill", params.IotRateRefill},
+		{"Unbonding period", params.UnbondingPeriod},
+		{"RSA commitment deadline", params.RsaCommitmentDeadline},
+		{"Slash fraction", params.SlashFraction},
+		{"Config quorum", params.ConfigQuorum},
+		{"Config activation delay", params.ConfigActivationDelay},
+		{"Difficulty EMA window", params.DiffEmaWindow},
+		{"State root height", params.StateRootHeight},
+		{"Tx order height", params.TxOrderHeight},
+		{"AggTx authentication height", params.AggTxAuthHeight},
+		{"Median time height", params.MedianTimeHeight},
+		{"Block hash height", params.BlockHashHeight},
+		{"Delegation height", params.DelegationHeight},
+	}
+}
+
+func printChainTx(node *rpcChainSource, param string) error {
+	if len(param) == 0 {
+		return errors.New("argument missing: hash")
+	}
+
+	tx := new(chainTx)
+	if err := node.call("getTx", []string{param}, tx); err != nil {
+		return err
+	}
+
+	fmt.Printf("Hash:\t\t%v\n", tx.Hash)
+	fmt.Printf("Type:\t\t%v\n", tx.Type)
+	fmt.Printf("Status:\t\t%v\n", tx.Status)
+	fmt.Printf("Fee:\t\t%v\n", tx.Fee)
+	if len(tx.Annotations) > 0 {
+		fmt.Printf("Annotations:\t%v\n", strings.Join(tx.Annotations, ", "))
+	}
+
+	payload, err := hex.DecodeString(tx.Tx)
+	if err != nil {
+		return errors.Wrap(err, "invalid tx encoding")
+	}
+
+	contents := decodeChainTx(tx.Type, payload)
+	if contents == nil {
+		return errors.New(fmt.Sprintf("could not decode tx of type %v", tx.Type))
+	}
+	fmt.Printf("%v", contents)
+
+	return nil
+}
+
+//Decodes the tx according to the type names of the RPC interface, nil if the type is unknown or decoding fails.
+func decodeChainTx(txType string, payload []byte) fmt.Stringer {
+	switch txType {
+	case "funds":
+		var fTx *protocol.FundsTx
+		if fTx, _ = fTx.Decode(payload); fTx != nil {
+			return fTx
+		}
+	case "acc":
+		var aTx *protocol.AccTx
+		if aTx, _ = aTx.Decode(payload); aTx != nil {
+			return aTx
+		}
+	case "config":
+		var cTx *protocol.ConfigTx
+		if cTx, _ = cTx.Decode(payload); cTx != nil {
+			return cTx
+		}
+	case "stake":
+		var sTx *protocol.StakeTx
+		if sTx, _ = sTx.Decode(payload); sTx != nil {
+			return sTx
+		}
+	case "agg":
+		var aTx *protocol.AggTx
+		if aTx, _ = aTx.Decode(payload); aTx != nil {
+			return aTx
+		}
+	case "iot":
+		var iTx *protocol.IotTx
+		if iTx, _ = iTx.Decode(payload); iTx != nil {
+			return iTx
+		}
+	case "contract":
+		var cTx *protocol.ContractTx
+		if cTx, _ = cTx.Decode(payload); cTx != nil {
+			return cTx
+		}
+	}
+
+	return nil
+}
+
+func formatTimestamp(timestamp int64) string {
+	return time.Unix(timestamp, 0).UTC().Format(time.RFC3339)
+}
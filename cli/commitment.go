@@ -11,17 +11,24 @@ func GetGenerateCommitmentCommand() cli.Command {
 		Name:	"generate-commitment",
 		Usage:	"generate a new pair of commitment keys",
 		Action:	func(c *cli.Context) error {
+			initPassphraseProvider(c)
+
 			filename := c.String("file")
 			privKey, err := crypto.ExtractRSAKeyFromFile(filename)
+			if err != nil {
+				return err
+			}
 
 			fmt.Printf("Commitment generated successfully.\n")
 			fmt.Printf("PubKeyE: %x\n", privKey.PublicKey.E)
 			fmt.Printf("PubKeyN: %x\n", privKey.PublicKey.N)
 			fmt.Printf("PrivKey: %x\n", privKey.D)
 
-			return err
+			return nil
 		},
 		Flags:	[]cli.Flag {
+			passphraseFileFlag,
+			encryptFlag,
 			cli.StringFlag {
 				Name: 	"file",
 				Usage: 	"the new commitment key's `FILE` name",
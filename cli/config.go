@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"gopkg.in/yaml.v3"
+)
+
+//Node parameters can be set in a YAML file instead of passing them as flags. The keys are the (long) names of the
+//start command's flags, e.g.:
+//
+//	database: store.db
+//	address: localhost:8000
+//	wallet: wallet.txt
+//	mempoolsize: 10485760
+//	iotdedupwindow: 10m
+//	loglevel: info,p2p=debug
+//
+//Every command accepting --config applies the keys matching its own flags, so one file serves the whole node. Flags
+//passed on the command line take precedence over the file, parameters in neither keep their default value.
+
+var configFlag = cli.StringFlag {
+	Name: 	"config",
+	Usage: 	"load node parameters from the YAML `FILE`, flags passed on the command line take precedence",
+}
+
+//Sets all flags of the command which are set in the config file but not on the command line.
+func applyConfigFile(c *cli.Context) error {
+	file := c.String("config")
+	if len(file) == 0 {
+		return nil
+	}
+
+	config, err := loadConfigFile(file)
+	if err != nil {
+		return err
+	}
+
+	for _, flag := range c.Command.Flags {
+		name := flagName(flag)
+		value, exists := config[name]
+		if !exists || c.IsSet(name) {
+			continue
+		}
+
+		if err := c.Set(name, value); err != nil {
+			return errors.New(fmt.Sprintf("invalid value for %v in config file %v: %v", name, file, err))
+		}
+	}
+
+	return nil
+}
+
+//Returns the parameters of the config file as strings, in the format they would be passed as flags. Unknown keys are
+//rejected, so typos do not silently fall back to the default.
+func loadConfigFile(file string) (map[string]string, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("could not read config file %v: %v", file, err))
+	}
+
+	var parsed map[string]interface{}
+	if err := yaml.Unmarshal(data, &parsed); err != nil {
+		return nil, errors.New(fmt.Sprintf("could not parse config file %v: %v", file, err))
+	}
+
+	known := make(map[string]bool)
+	for _, flag := range GetStartCommand(nil).Flags {
+		known[flagName(flag)] = true
+	}
+	delete(known, "confirm")
+
+	config := make(map[string]string)
+	for key, value := range parsed {
+		if !known[key] {
+			return nil, errors.New(fmt.Sprintf("unknown parameter %v in config file %v", key, file))
+		}
+
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+			return nil, errors.New(fmt.Sprintf("invalid value for %v in config file %v: expected a single value", key, file))
+		case nil:
+			continue
+		}
+		config[key] = fmt.Sprint(value)
+	}
+
+	return config, nil
+}
+
+//Flags are named "long, short", the long name is used in the config file.
+func flagName(flag cli.Flag) string {
+	return strings.TrimSpace(strings.Split(flag.GetName(), ",")[0])
+}
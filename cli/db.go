@@ -0,0 +1,167 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/miner"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"os"
+)
+
+func GetDbCommand() cli.Command {
+	return cli.Command {
+		Name:	"db",
+		Usage:	"maintain the node's database",
+		Subcommands: []cli.Command {
+			{
+				Name:		"backup",
+				Usage:		"write a consistent copy of the database to a file, also while the node is running",
+				ArgsUsage:	"PATH",
+				Action:		func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return backupDatabase(c.Args().First(), c.String("rpc"), c.String("database"))
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					cli.StringFlag {
+						Name: 	"rpc",
+						Usage: 	"fetch the database from the running node's RPC interface at `IP:PORT` (hot backup)",
+					},
+					cli.StringFlag {
+						Name: 	"database, d",
+						Usage: 	"back up the database stored in `FILE` (node must be stopped)",
+						Value:	"store.db",
+					},
+				},
+			},
+			{
+				Name:		"restore",
+				Usage:		"verify a database copy and restore it",
+				ArgsUsage:	"PATH",
+				Action:		func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return restoreDatabase(c.Args().First(), c.String("database"), c.Bool("force"))
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					cli.StringFlag {
+						Name: 	"database, d",
+						Usage: 	"restore the database to `FILE` (node must be stopped)",
+						Value:	"store.db",
+					},
+					cli.BoolFlag {
+						Name: 	"force",
+						Usage: 	"overwrite an existing database",
+					},
+				},
+			},
+			{
+				Name:		"compact",
+				Usage:		"remove copies of final blocks which are not part of the chain and rewrite the database to reclaim the space",
+				Action:		func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					removed, before, after, err := storage.Compact(c.String("database"), uint32(c.Uint("maxrollbackdepth")))
+					if err != nil {
+						return err
+					}
+
+					fmt.Printf("Removed %v blocks, compacted %v from %v to %v bytes.\n", removed, c.String("database"), before, after)
+
+					return nil
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					cli.StringFlag {
+						Name: 	"database, d",
+						Usage: 	"compact the database stored in `FILE` (node must be stopped)",
+						Value:	"store.db",
+					},
+					cli.UintFlag {
+						Name: 	"maxrollbackdepth",
+						Usage: 	"keep all blocks of the last `N` blocks, which can still be rolled back",
+						Value: 	miner.MAX_ROLLBACK_DEPTH_DEFAULT,
+					},
+				},
+			},
+		},
+	}
+}
+
+//Unlike "backup create", only the database is copied. The copy is written to a temporary file and verified before it
+//is moved to path, an interrupted backup never leaves a partial file behind.
+func backupDatabase(path string, rpcAddress string, dbname string) error {
+	if len(path) == 0 {
+		return errors.New("argument missing: PATH")
+	}
+
+	if _, err := os.Stat(path); err == nil {
+		return errors.New(fmt.Sprintf("File %v already exists.", path))
+	}
+
+	tmpFile := path + ".tmp"
+	out, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_WRONLY|os.O_EXCL, 0600)
+	if err != nil {
+		return err
+	}
+
+	if len(rpcAddress) > 0 {
+		err = fetchBackup(rpcAddress, out)
+	} else {
+		_, err = storage.WriteBackupFromFile(dbname, out)
+	}
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = storage.VerifyBackup(tmpFile)
+	}
+	if err != nil {
+		os.Remove(tmpFile)
+		return err
+	}
+
+	if err := os.Rename(tmpFile, path); err != nil {
+		return err
+	}
+
+	fmt.Printf("Database backed up to %v.\n", path)
+
+	return nil
+}
+
+func restoreDatabase(path string, dbname string, force bool) error {
+	if len(path) == 0 {
+		return errors.New("argument missing: PATH")
+	}
+
+	if err := storage.VerifyBackup(path); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dbname); err == nil && !force {
+		return errors.New(fmt.Sprintf("Database %v already exists, use --force to overwrite it.", dbname))
+	}
+
+	if err := storage.CheckNotInUse(dbname); err != nil {
+		return err
+	}
+
+	if err := copyFile(path, dbname); err != nil {
+		return err
+	}
+
+	fmt.Printf("Database restored from %v to %v.\n", path, dbname)
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package cli
+
+import (
+	"encoding/hex"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+)
+
+//Decrypts the data of an IotTx encrypted to the receiver's wallet key (see protocol/iotencryption.go). The data is
+//either taken from a hex encoded IotTx or given directly, together with the compression flag of the tx.
+
+func GetDecryptIotCommand() cli.Command {
+	return cli.Command {
+		Name:	"decrypt-iot",
+		Usage:	"decrypt the data of an IoT tx encrypted to the wallet",
+		Action:	func(c *cli.Context) error {
+			tx, err := decryptIotTx(c.String("tx"), c.String("data"), c.Bool("compressed"))
+			if err != nil {
+				return err
+			}
+
+			if _, err := os.Stat(c.String("wallet")); err != nil {
+				return errors.Wrap(err, "could not open wallet")
+			}
+
+			initPassphraseProvider(c)
+
+			privKey, err := crypto.ExtractEDPrivKeyFromFile(c.String("wallet"))
+			if err != nil {
+				return err
+			}
+
+			data, err := tx.DecryptPayload(privKey)
+			if err != nil {
+				return err
+			}
+
+			if len(c.String("out")) > 0 {
+				return ioutil.WriteFile(c.String("out"), data, 0600)
+			}
+
+			_, err = os.Stdout.Write(data)
+			return err
+		},
+		Flags:	[]cli.Flag {
+			passphraseFileFlag,
+			cli.StringFlag {
+				Name: 	"wallet, w",
+				Usage: 	"decrypt with the private key in `FILE`",
+				Value: 	"wallet.txt",
+			},
+			cli.StringFlag {
+				Name: 	"tx",
+				Usage: 	"decrypt the data of the hex encoded IoT `TX`",
+			},
+			cli.StringFlag {
+				Name: 	"data",
+				Usage: 	"decrypt the hex encoded `DATA` of an IoT tx instead of a whole tx",
+			},
+			cli.BoolFlag {
+				Name: 	"compressed",
+				Usage: 	"decompress the data given with --data after decrypting it",
+			},
+			cli.StringFlag {
+				Name: 	"out",
+				Usage: 	"write the decrypted data to `FILE` instead of stdout",
+			},
+		},
+	}
+}
+
+func decryptIotTx(encodedTx string, encodedData string, compressed bool) (*protocol.IotTx, error) {
+	if len(encodedTx) > 0 {
+		encoded, err := hex.DecodeString(encodedTx)
+		if err != nil {
+			return nil, errors.New("invalid argument: tx must be a hex encoded IoT tx")
+		}
+
+		var tx *protocol.IotTx
+		if tx, _ = tx.Decode(encoded); tx == nil {
+			return nil, errors.New("invalid argument: tx could not be decoded")
+		}
+
+		return tx, nil
+	}
+
+	if len(encodedData) == 0 {
+		return nil, errors.New("argument missing: tx or data")
+	}
+
+	data, err := hex.DecodeString(encodedData)
+	if err != nil {
+		return nil, errors.New("invalid argument: data must be hex encoded")
+	}
+
+	tx := &protocol.IotTx{Header: protocol.IOTTX_ENCRYPTED, Data: data}
+	if compressed {
+		tx.Header |= protocol.IOTTX_COMPRESSED
+	}
+
+	return tx, nil
+}
@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"math"
+)
+
+//Onboards an IoT device: creates an accTx tagging the device's account with its metadata (see protocol/device.go),
+//signs it with a root key and submits it to a node over its JSON-RPC interface. The device's key file is created if
+//it does not exist yet, so it can be copied to the device afterwards.
+
+func GetRegisterDeviceCommand() cli.Command {
+	return cli.Command {
+		Name:	"register-device",
+		Usage:	"create the account of an IoT device with its type, owner and rate limit",
+		Action:	func(c *cli.Context) error {
+			if err := applyConfigFile(c); err != nil {
+				return err
+			}
+
+			if len(c.String("rpc")) == 0 {
+				return errors.New("argument missing: rpc")
+			}
+
+			if len(c.String("device")) == 0 {
+				return errors.New("argument missing: device")
+			}
+
+			owner, err := hex.DecodeString(c.String("owner"))
+			if err != nil || len(owner) != 32 {
+				return errors.New("invalid argument: owner must be a hex encoded address (public key)")
+			}
+
+			if c.Uint("ratelimit") > math.MaxUint32 {
+				return errors.New("invalid argument: ratelimit must be smaller than 2^32")
+			}
+
+			//Accounts are referenced by the hash of their address (the public key) in the state.
+			var ownerAddress [32]byte
+			copy(ownerAddress[:], owner)
+			device := protocol.DeviceInfo{
+				Type:      c.String("type"),
+				Owner:     protocol.SerializeHashContent(ownerAddress),
+				RateLimit: uint32(c.Uint("ratelimit")),
+			}
+			if err := device.Check(); err != nil {
+				return errors.Wrap(err, "invalid argument")
+			}
+
+			initPassphraseProvider(c)
+
+			rootPrivKey, err := crypto.ExtractEDPrivKeyFromFile(c.String("rootwallet"))
+			if err != nil {
+				return err
+			}
+
+			devicePubKey, err := crypto.ExtractEDPublicKeyFromFile(c.String("device"))
+			if err != nil {
+				return err
+			}
+
+			tx, err := protocol.ConstrDeviceAccTx(0, c.Uint64("fee"), c.Uint64("amount"), crypto.GetAddressFromPubKeyED(devicePubKey), device, rootPrivKey)
+			if err != nil {
+				return err
+			}
+
+			var txHash string
+			node := newRPCChainSource(c.String("rpc"))
+			if err := node.call("submitTx", sendFundsParams{"acc", hex.EncodeToString(tx.Encode())}, &txHash); err != nil {
+				return errors.Wrap(err, "could not submit tx")
+			}
+
+			fmt.Printf("Device address: %x\n", devicePubKey)
+			fmt.Printf("Tx submitted: %v\n", txHash)
+
+			return nil
+		},
+		Flags:	[]cli.Flag {
+			configFlag,
+			passphraseFileFlag,
+			cli.StringFlag {
+				Name: 	"rpc",
+				Usage: 	"submit the tx to the JSON-RPC interface of the node at `IP:PORT`",
+			},
+			cli.StringFlag {
+				Name: 	"rootwallet",
+				Usage: 	"sign with the root's private key in `FILE`",
+				Value: 	"wallet.txt",
+			},
+			cli.StringFlag {
+				Name: 	"device",
+				Usage: 	"load the device's key from `FILE`, a new key is created if the file does not exist",
+			},
+			cli.StringFlag {
+				Name: 	"type",
+				Usage: 	"the device `TYPE`, e.g., its model",
+			},
+			cli.StringFlag {
+				Name: 	"owner",
+				Usage: 	"the hex encoded `ADDRESS` (public key) of the owner's account",
+			},
+			cli.UintFlag {
+				Name: 	"ratelimit",
+				Usage: 	"allow the device at most `N` IoT txs per block, 0 for no limit",
+			},
+			cli.Uint64Flag {
+				Name: 	"amount",
+				Usage: 	"fund the device with `COINS` from the root account",
+			},
+			cli.Uint64Flag {
+				Name: 	"fee",
+				Usage: 	"pay a fee of `COINS`",
+				Value: 	1,
+			},
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+)
+
+//Migrates wallet and commitment key files written in plaintext to encrypted key files (see crypto/keyfile.go).
+func GetEncryptKeyCommand() cli.Command {
+	return cli.Command {
+		Name:	"encrypt-key",
+		Usage:	"encrypt existing plaintext wallet or commitment key files with a passphrase",
+		Action:	func(c *cli.Context) error {
+			files := c.StringSlice("file")
+			if len(files) == 0 {
+				return errors.New("argument missing: file")
+			}
+
+			initPassphraseProvider(c)
+
+			for _, filename := range files {
+				encrypted, err := crypto.IsKeyFileEncrypted(filename)
+				if err != nil {
+					return err
+				}
+
+				if encrypted {
+					fmt.Printf("%v is already encrypted.\n", filename)
+					continue
+				}
+
+				if err := crypto.EncryptKeyFile(filename); err != nil {
+					return errors.Wrapf(err, "could not encrypt %v", filename)
+				}
+
+				fmt.Printf("%v encrypted successfully.\n", filename)
+			}
+
+			return nil
+		},
+		Flags:	[]cli.Flag {
+			passphraseFileFlag,
+			cli.StringSliceFlag {
+				Name: 	"file",
+				Usage: 	"encrypt the key `FILE`, can be passed multiple times",
+			},
+		},
+	}
+}
@@ -0,0 +1,156 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/miner"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"strconv"
+	"strings"
+)
+
+//Builds the genesis file of a new network (see miner/genesis.go). The first root account stakes with the root
+//commitment key, which also signs the genesis block, so the network has a validator from the start. Missing wallet and
+//commitment files are created.
+
+//Activation heights of checks, which build-genesis sets to 0 (see miner.Genesis).
+var genesisActivationHeights = []string{"stateRootHeight", "txOrderHeight", "aggTxAuthHeight", "medianTimeHeight", "blockHashHeight", "delegationHeight"}
+
+func GetBuildGenesisCommand() cli.Command {
+	return cli.Command {
+		Name:	"build-genesis",
+		Usage:	"build the genesis file of a new network",
+		Action:	func(c *cli.Context) error {
+			initPassphraseProvider(c)
+
+			if len(c.StringSlice("root")) == 0 {
+				return errors.New("argument missing: root")
+			}
+
+			spec, err := buildGenesis(c.StringSlice("root"), c.String("rootcommitment"), c.Uint64("rootbalance"), c.StringSlice("account"), c.StringSlice("param"))
+			if err != nil {
+				return err
+			}
+
+			encoded, err := json.MarshalIndent(spec, "", "  ")
+			if err != nil {
+				return err
+			}
+
+			//The genesis file is checked like at the start of a node.
+			if _, err := miner.ParseGenesis(encoded); err != nil {
+				return errors.Wrap(err, "invalid genesis")
+			}
+
+			if err := ioutil.WriteFile(c.String("output"), append(encoded, '\n'), 0644); err != nil {
+				return err
+			}
+
+			fmt.Printf("Genesis with %v accounts written to %v.\n", len(spec.Accounts), c.String("output"))
+			return nil
+		},
+		Flags:	[]cli.Flag {
+			passphraseFileFlag,
+			encryptFlag,
+			cli.StringSliceFlag {
+				Name: 	"root",
+				Usage: 	"add the root account of the wallet `FILE`, can be given multiple times",
+			},
+			cli.StringFlag {
+				Name: 	"rootcommitment",
+				Usage: 	"the first root account stakes with the RSA key in `FILE`, which also signs the genesis block",
+				Value: 	"commitment.txt",
+			},
+			cli.Uint64Flag {
+				Name: 	"rootbalance",
+				Usage: 	"give every root account `COINS`, the first one needs at least the staking minimum",
+				Value: 	miner.STAKING_MINIMUM,
+			},
+			cli.StringSliceFlag {
+				Name: 	"account",
+				Usage: 	"add the account `ADDRESS=COINS` (hex encoded public key), can be given multiple times",
+			},
+			cli.StringSliceFlag {
+				Name: 	"param",
+				Usage: 	"set the system parameter `NAME=VALUE` (e.g., feeMinimum=5, as named by getParameters), can be given multiple times",
+			},
+			cli.StringFlag {
+				Name: 	"output, o",
+				Usage: 	"write the genesis to `FILE`",
+				Value: 	"genesis.json",
+			},
+		},
+	}
+}
+
+func buildGenesis(rootFiles []string, rootCommitmentFile string, rootBalance uint64, accounts []string, params []string) (*miner.Genesis, error) {
+	spec := &miner.Genesis{Parameters: make(map[string]uint64)}
+
+	commPrivKey, err := crypto.ExtractRSAKeyFromFile(rootCommitmentFile)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := crypto.SignMessageWithRSAKey(commPrivKey, "0")
+	if err != nil {
+		return nil, err
+	}
+	spec.CommitmentProof = hex.EncodeToString(proof[:])
+
+	for i, file := range rootFiles {
+		pubKey, err := crypto.ExtractEDPublicKeyFromFile(file)
+		if err != nil {
+			return nil, err
+		}
+
+		account := miner.GenesisAccount{Address: hex.EncodeToString(pubKey), Balance: rootBalance, Root: true}
+		if i == 0 {
+			account.Staking = true
+			account.CommitmentKey = hex.EncodeToString(commPrivKey.N.Bytes())
+		}
+		spec.Accounts = append(spec.Accounts, account)
+	}
+
+	for _, account := range accounts {
+		address, balance, err := parseGenesisAssignment(account)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid account")
+		}
+		spec.Accounts = append(spec.Accounts, miner.GenesisAccount{Address: address, Balance: balance})
+	}
+
+	//The checks introduced after the first chains were started are disabled by default, a new network enforces them
+	//from the genesis block on.
+	for _, name := range genesisActivationHeights {
+		spec.Parameters[name] = 0
+	}
+
+	for _, param := range params {
+		name, value, err := parseGenesisAssignment(param)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid parameter")
+		}
+		spec.Parameters[name] = value
+	}
+
+	return spec, nil
+}
+
+//Parses NAME=VALUE.
+func parseGenesisAssignment(assignment string) (string, uint64, error) {
+	parts := strings.SplitN(assignment, "=", 2)
+	if len(parts) != 2 {
+		return "", 0, errors.New(fmt.Sprintf("%v is not of the form NAME=VALUE", assignment))
+	}
+
+	value, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return "", 0, errors.New(fmt.Sprintf("%v is not a number", parts[1]))
+	}
+
+	return parts[0], value, nil
+}
@@ -0,0 +1,36 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/urfave/cli"
+)
+
+func GetMigrateCommand() cli.Command {
+	return cli.Command {
+		Name:	"migrate",
+		Usage:	"rewrite gob encoded blocks and txs of a database created by an earlier version with the binary encoding",
+		Action:	func(c *cli.Context) error {
+			if err := applyConfigFile(c); err != nil {
+				return err
+			}
+
+			migrated, err := storage.MigrateEncoding(c.String("database"))
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Migrated %v entries of %v.\n", migrated, c.String("database"))
+
+			return nil
+		},
+		Flags:	[]cli.Flag {
+			configFlag,
+			cli.StringFlag {
+				Name: 	"database, d",
+				Usage: 	"migrate the database stored in `FILE` (node must be stopped)",
+				Value:	"store.db",
+			},
+		},
+	}
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"os"
+)
+
+//Encrypted key files (see crypto/keyfile.go) are unlocked with a passphrase, which is either prompted for on the
+//terminal or, for nodes started unattended, read from a file.
+
+var passphraseFileFlag = cli.StringFlag {
+	Name: 	"passphrasefile",
+	Usage: 	"read the passphrase of encrypted key files from `FILE` instead of prompting for it",
+}
+
+var encryptFlag = cli.BoolFlag {
+	Name: 	"encrypt",
+	Usage: 	"encrypt newly created key files with a passphrase",
+}
+
+func initPassphraseProvider(c *cli.Context) {
+	passphraseFile := c.String("passphrasefile")
+	if len(passphraseFile) == 0 {
+		crypto.SetPassphraseProvider(promptPassphrase, c.Bool("encrypt"))
+		return
+	}
+
+	crypto.SetPassphraseProvider(func(filename string, confirm bool) ([]byte, error) {
+		passphrase, err := ioutil.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not read passphrase file")
+		}
+
+		passphrase = bytes.TrimRight(passphrase, "\r\n")
+		if len(passphrase) == 0 {
+			return nil, errors.New("passphrase file is empty")
+		}
+
+		return passphrase, nil
+	}, c.Bool("encrypt"))
+}
+
+//Passphrases are cached per file, so a key file used twice (e.g., as wallet and root wallet) is only prompted for once.
+var promptedPassphrases = make(map[string][]byte)
+
+func promptPassphrase(filename string, confirm bool) ([]byte, error) {
+	if passphrase, exists := promptedPassphrases[filename]; exists && !confirm {
+		return passphrase, nil
+	}
+
+	fmt.Fprintf(os.Stderr, "Passphrase for %v: ", filename)
+	passphrase, err := readPassword()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase must not be empty")
+	}
+
+	if confirm {
+		fmt.Fprintf(os.Stderr, "Repeat passphrase for %v: ", filename)
+		repeated, err := readPassword()
+		if err != nil {
+			return nil, err
+		}
+
+		if !bytes.Equal(passphrase, repeated) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+
+	promptedPassphrases[filename] = passphrase
+
+	return passphrase, nil
+}
+
+//Reads byte by byte, a buffered reader could consume input meant for a later prompt.
+func readLine() ([]byte, error) {
+	var line []byte
+	var b [1]byte
+	for {
+		n, err := os.Stdin.Read(b[:])
+		if n == 0 || err != nil {
+			if len(line) > 0 {
+				return line, nil
+			}
+			return nil, errors.New("could not read passphrase")
+		}
+
+		if b[0] == '\n' {
+			return bytes.TrimRight(line, "\r"), nil
+		}
+		line = append(line, b[0])
+	}
+}
@@ -0,0 +1,170 @@
+package cli
+
+import (
+	"encoding/hex"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/crypto/ledger"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"math"
+)
+
+//Creates a funds tx, signs it with the key in the wallet file or on a Ledger and submits it to a node over its
+//JSON-RPC interface. With a Ledger, the private key never enters this process.
+
+type sendFundsAccount struct {
+	TxCnt uint32 `json:"txCnt"`
+}
+
+type sendFundsParams struct {
+	Type string `json:"type"`
+	Tx   string `json:"tx"`
+}
+
+func GetSendFundsCommand() cli.Command {
+	return cli.Command {
+		Name:	"send-funds",
+		Usage:	"sign a funds tx with a wallet file or a Ledger and submit it to a node",
+		Action:	func(c *cli.Context) error {
+			if err := applyConfigFile(c); err != nil {
+				return err
+			}
+
+			if len(c.String("rpc")) == 0 {
+				return errors.New("argument missing: rpc")
+			}
+
+			if c.Uint("ledgerindex") > math.MaxInt32 {
+				return errors.New("invalid argument: ledgerIndex must be smaller than 2^31")
+			}
+
+			if c.Bool("delegate") && c.Bool("undelegate") {
+				return errors.New("invalid arguments: delegate and undelegate can not be combined")
+			}
+
+			//Delegation txs are sent to the validator, see miner/delegation.go.
+			var header byte
+			if c.Bool("delegate") {
+				header = protocol.FUNDSTX_DELEGATE
+			}
+			if c.Bool("undelegate") {
+				header = protocol.FUNDSTX_UNDELEGATE
+			}
+
+			to, err := hex.DecodeString(c.String("to"))
+			if err != nil || len(to) != 32 {
+				return errors.New("invalid argument: to must be a hex encoded address (public key)")
+			}
+
+			initPassphraseProvider(c)
+
+			signer, err := newFundsTxSigner(c)
+			if err != nil {
+				return err
+			}
+			if device, ok := signer.(*ledger.Ledger); ok {
+				defer device.Close()
+			}
+
+			//Accounts are referenced by the hash of their address (the public key) in txs and the state.
+			var toAddress [32]byte
+			copy(toAddress[:], to)
+			from := protocol.SerializeHashContent(crypto.GetAddressFromPubKeyED(signer.PublicKey()))
+			toHash := protocol.SerializeHashContent(toAddress)
+
+			node := newRPCChainSource(c.String("rpc"))
+
+			txCnt := uint32(c.Uint("txcnt"))
+			if !c.IsSet("txcnt") {
+				account := new(sendFundsAccount)
+				if err := node.call("getAccount", []string{hex.EncodeToString(from[:])}, account); err != nil {
+					return errors.Wrap(err, "could not load sender account")
+				}
+				txCnt = account.TxCnt
+			}
+
+			if c.Bool("ledger") {
+				fmt.Printf("Confirm the tx on the Ledger.\n")
+			}
+
+			tx, err := protocol.ConstrLockedFundsTx(header, c.Uint64("amount"), c.Uint64("fee"), txCnt, from, toHash, c.Uint64("lockuntil"), signer, nil)
+			if err != nil {
+				return err
+			}
+
+			var txHash string
+			if err := node.call("submitTx", sendFundsParams{"funds", hex.EncodeToString(tx.Encode())}, &txHash); err != nil {
+				return errors.Wrap(err, "could not submit tx")
+			}
+
+			fmt.Printf("Tx submitted: %v\n", txHash)
+
+			return nil
+		},
+		Flags:	[]cli.Flag {
+			configFlag,
+			passphraseFileFlag,
+			cli.StringFlag {
+				Name: 	"rpc",
+				Usage: 	"submit the tx to the JSON-RPC interface of the node at `IP:PORT`",
+			},
+			cli.StringFlag {
+				Name: 	"wallet, w",
+				Usage: 	"sign with the private key in `FILE`",
+				Value: 	"wallet.txt",
+			},
+			cli.BoolFlag {
+				Name: 	"ledger",
+				Usage: 	"sign on a Ledger instead of with the wallet file",
+			},
+			cli.UintFlag {
+				Name: 	"ledgerindex",
+				Usage: 	"sign with the wallet with index `N` on the Ledger",
+			},
+			cli.StringFlag {
+				Name: 	"to",
+				Usage: 	"send the funds to the hex encoded `ADDRESS` (public key) of the receiver",
+			},
+			cli.Uint64Flag {
+				Name: 	"amount",
+				Usage: 	"send `COINS`",
+			},
+			cli.Uint64Flag {
+				Name: 	"fee",
+				Usage: 	"pay a fee of `COINS`",
+				Value: 	1,
+			},
+			cli.UintFlag {
+				Name: 	"txcnt",
+				Usage: 	"use `N` as txCnt instead of the next one of the sender account",
+			},
+			cli.Uint64Flag {
+				Name: 	"lockuntil",
+				Usage: 	"lock the tx until block height `N` (or Unix timestamp if N >= 500000000)",
+			},
+			cli.BoolFlag {
+				Name: 	"delegate",
+				Usage: 	"delegate the amount to the validator instead of sending it",
+			},
+			cli.BoolFlag {
+				Name: 	"undelegate",
+				Usage: 	"take the amount delegated to the validator back",
+			},
+		},
+	}
+}
+
+func newFundsTxSigner(c *cli.Context) (protocol.FundsTxSigner, error) {
+	if c.Bool("ledger") {
+		return ledger.Open(uint32(c.Uint("ledgerindex")))
+	}
+
+	privKey, err := crypto.ExtractEDPrivKeyFromFile(c.String("wallet"))
+	if err != nil {
+		return nil, err
+	}
+
+	return protocol.NewKeySigner(privKey), nil
+}
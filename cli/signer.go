@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"crypto/tls"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/urfave/cli"
+)
+
+//Runs the signing service holding the commitment key, miners started with --remotesigner request their commitment
+//proofs from it (see crypto/remotesigner.go).
+func GetSignerCommand() cli.Command {
+	return cli.Command {
+		Name:	"signer",
+		Usage:	"serve commitment proofs to miners over TLS, so the commitment key never leaves this machine",
+		Action:	func(c *cli.Context) error {
+			if err := applyConfigFile(c); err != nil {
+				return err
+			}
+
+			initPassphraseProvider(c)
+
+			privKey, err := crypto.ExtractRSAKeyFromFile(c.String("commitment"))
+			if err != nil {
+				return err
+			}
+
+			tlsConfig, err := crypto.NewSignerServerTLSConfig(c.String("cert"), c.String("key"), c.String("ca"))
+			if err != nil {
+				return err
+			}
+
+			listener, err := tls.Listen("tcp", c.String("listen"), tlsConfig)
+			if err != nil {
+				return err
+			}
+
+			fmt.Printf("Serving commitment proofs at %v.\n", listener.Addr())
+
+			return crypto.ServeRemoteSigner(listener, privKey)
+		},
+		Flags:	[]cli.Flag {
+			configFlag,
+			passphraseFileFlag,
+			cli.StringFlag {
+				Name: 	"listen",
+				Usage: 	"listen for miners at `IP:PORT`",
+				Value: 	"localhost:8500",
+			},
+			cli.StringFlag {
+				Name: 	"commitment, c",
+				Usage: 	"load validator's RSA public-private key from `FILE`",
+				Value: 	"commitment.txt",
+			},
+			cli.StringFlag {
+				Name: 	"cert",
+				Usage: 	"authenticate to miners with the TLS certificate in `FILE`",
+			},
+			cli.StringFlag {
+				Name: 	"key",
+				Usage: 	"load the private key of the TLS certificate from `FILE`",
+			},
+			cli.StringFlag {
+				Name: 	"ca",
+				Usage: 	"only accept miners with a TLS certificate signed by the CA in `FILE`",
+			},
+		},
+	}
+}
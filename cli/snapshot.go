@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"io/ioutil"
+	"net/http"
+	"os"
+)
+
+func GetSnapshotCommand() cli.Command {
+	return cli.Command {
+		Name:	"snapshot",
+		Usage:	"export and import state snapshots for fast bootstrapping",
+		Subcommands: []cli.Command {
+			{
+				Name:	"export",
+				Usage:	"export a snapshot of the state to a file",
+				Action:	func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return exportSnapshot(c.String("output"), c.String("rpc"), c.String("database"), c.String("dbbackend"))
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					cli.StringFlag {
+						Name: 	"output, o",
+						Usage: 	"write the snapshot to `FILE`",
+					},
+					cli.StringFlag {
+						Name: 	"rpc",
+						Usage: 	"create a snapshot of the current state of the running node's RPC interface at `IP:PORT`",
+					},
+					cli.StringFlag {
+						Name: 	"database, d",
+						Usage: 	"export the latest snapshot stored in database `FILE` (node must be stopped)",
+						Value:	"store.db",
+					},
+					dbBackendFlag,
+				},
+			},
+			{
+				Name:	"import",
+				Usage:	"import a snapshot, the node continues from the snapshot on the next start if its block matches a trusted checkpoint",
+				Action:	func(c *cli.Context) error {
+					if err := applyConfigFile(c); err != nil {
+						return err
+					}
+
+					return importSnapshot(c.String("input"), c.String("database"), c.String("dbbackend"))
+				},
+				Flags:	[]cli.Flag {
+					configFlag,
+					cli.StringFlag {
+						Name: 	"input, i",
+						Usage: 	"import the snapshot from `FILE`",
+					},
+					cli.StringFlag {
+						Name: 	"database, d",
+						Usage: 	"import the snapshot into database `FILE` (node must be stopped)",
+						Value:	"store.db",
+					},
+					dbBackendFlag,
+				},
+			},
+		},
+	}
+}
+
+func exportSnapshot(filename string, rpcAddress string, dbname string, dbBackend string) error {
+	if len(filename) == 0 {
+		return errors.New("argument missing: output")
+	}
+
+	var encodedSnapshot []byte
+	var err error
+	if len(rpcAddress) > 0 {
+		encodedSnapshot, err = fetchSnapshot(rpcAddress)
+		if err != nil {
+			return err
+		}
+	} else {
+		if _, err := os.Stat(dbname); err != nil {
+			return err
+		}
+		if err := storage.SetBackend(dbBackend); err != nil {
+			return err
+		}
+
+		storage.Init(dbname, "")
+		encodedSnapshot = storage.ReadSnapshot()
+		storage.TearDown()
+
+		if encodedSnapshot == nil {
+			return errors.New(fmt.Sprintf("No snapshot stored in %v.", dbname))
+		}
+	}
+
+	var snapshot *protocol.Snapshot
+	if snapshot, _ = snapshot.Decode(encodedSnapshot); snapshot == nil {
+		return errors.New("Snapshot is invalid.")
+	}
+
+	if err := ioutil.WriteFile(filename, encodedSnapshot, 0600); err != nil {
+		return err
+	}
+
+	fmt.Printf("Exported snapshot to %v:%v", filename, snapshot)
+
+	return nil
+}
+
+func importSnapshot(filename string, dbname string, dbBackend string) error {
+	if len(filename) == 0 {
+		return errors.New("argument missing: input")
+	}
+
+	encodedSnapshot, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	var snapshot *protocol.Snapshot
+	if snapshot, _ = snapshot.Decode(encodedSnapshot); snapshot == nil {
+		return errors.New(fmt.Sprintf("Snapshot %v is invalid or corrupted.", filename))
+	}
+
+	if err := storage.SetBackend(dbBackend); err != nil {
+		return err
+	}
+	storage.Init(dbname, "")
+	err = storage.WriteSnapshot(encodedSnapshot)
+	storage.TearDown()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Imported snapshot into %v:%v", dbname, snapshot)
+
+	return nil
+}
+
+func fetchSnapshot(rpcAddress string) ([]byte, error) {
+	req, err := newRPCRequest(http.MethodGet, "http://"+rpcAddress+"/snapshot", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.New(fmt.Sprintf("Fetching snapshot from %v failed: %v", rpcAddress, resp.Status))
+	}
+
+	return ioutil.ReadAll(resp.Body)
+}
@@ -0,0 +1,216 @@
+package cli
+
+import (
+	"crypto/rsa"
+	"encoding/hex"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"golang.org/x/crypto/ed25519"
+	"math/big"
+	"time"
+)
+
+//Enables or disables staking of a validator account. The stake tx is signed with the wallet file and submitted to a
+//node over its JSON-RPC interface. Enabling creates the commitment key file if it does not exist yet and then waits
+//until the tx is included and the waiting minimum has elapsed, from then on a node started with the wallet and
+//commitment files is eligible to validate.
+
+const STAKE_POLL_INTERVAL = 10
+
+type stakeAccount struct {
+	Balance            uint64 `json:"balance"`
+	IsStaking          bool   `json:"isStaking"`
+	StakingBlockHeight uint32 `json:"stakingBlockHeight"`
+}
+
+type stakeParameters struct {
+	StakingMinimum uint64 `json:"stakingMinimum"`
+	WaitingMinimum uint64 `json:"waitingMinimum"`
+}
+
+func GetStakeCommand() cli.Command {
+	flags := []cli.Flag {
+		configFlag,
+		passphraseFileFlag,
+		cli.StringFlag {
+			Name: 	"rpc",
+			Usage: 	"submit the tx to the JSON-RPC interface of the node at `IP:PORT`",
+			Value:	"localhost:8001",
+		},
+		cli.StringFlag {
+			Name: 	"wallet, w",
+			Usage: 	"sign with the private key of the validator account in `FILE`",
+			Value: 	"wallet.txt",
+		},
+		cli.Uint64Flag {
+			Name: 	"fee",
+			Usage: 	"pay a fee of `COINS`",
+			Value: 	1,
+		},
+	}
+
+	return cli.Command {
+		Name:	"stake",
+		Usage:	"enable or disable staking of a validator account",
+		Subcommands: []cli.Command {
+			{
+				Name:	"enable",
+				Usage:	"start staking and wait until the account is eligible to validate",
+				Action:	func(c *cli.Context) error {
+					return stake(c, true)
+				},
+				Flags:	append(flags,
+					encryptFlag,
+					cli.StringFlag {
+						Name: 	"commitment, c",
+						Usage: 	"commit with the key in `FILE`, which is created if it does not exist",
+						Value: 	"commitment.txt",
+					},
+					cli.BoolFlag {
+						Name: 	"vrf",
+						Usage: 	"commit with an ed25519 VRF key instead of an RSA key",
+					},
+					cli.BoolFlag {
+						Name: 	"nowait",
+						Usage: 	"return after submitting the tx instead of waiting for the waiting minimum to elapse",
+					},
+				),
+			},
+			{
+				Name:	"disable",
+				Usage:	"stop staking, the funds are no longer locked by the staking minimum",
+				Action:	func(c *cli.Context) error {
+					return stake(c, false)
+				},
+				Flags:	flags,
+			},
+		},
+	}
+}
+
+func stake(c *cli.Context, isStaking bool) error {
+	if err := applyConfigFile(c); err != nil {
+		return err
+	}
+
+	if len(c.String("rpc")) == 0 {
+		return errors.New("argument missing: rpc")
+	}
+
+	initPassphraseProvider(c)
+
+	privKey, err := crypto.ExtractEDPrivKeyFromFile(c.String("wallet"))
+	if err != nil {
+		return err
+	}
+
+	//Accounts are referenced by the hash of their address (the public key) in txs and the state.
+	address := crypto.GetAddressFromPubKeyED(privKey.Public().(ed25519.PublicKey))
+	accHash := protocol.SerializeHashContent(address)
+
+	node := newRPCChainSource(c.String("rpc"))
+
+	account := new(stakeAccount)
+	if err := node.call("getAccount", []string{hex.EncodeToString(accHash[:])}, account); err != nil {
+		return errors.Wrap(err, "could not load validator account")
+	}
+
+	params := new(stakeParameters)
+	if err := node.call("getParameters", []string{}, params); err != nil {
+		return errors.Wrap(err, "could not load parameters")
+	}
+
+	if account.IsStaking == isStaking {
+		if !isStaking {
+			fmt.Printf("Account is not staking.\n")
+			return nil
+		}
+
+		fmt.Printf("Account is already staking.\n")
+		return waitForEligibility(node, accHash, params.WaitingMinimum)
+	}
+
+	//Disabling clears the commitment key of the account, no key file is needed.
+	commPubKey := &rsa.PublicKey{N: new(big.Int)}
+	var vrfPubKey ed25519.PublicKey
+	if isStaking {
+		if account.Balance <= c.Uint64("fee")+params.StakingMinimum {
+			return errors.New(fmt.Sprintf("balance (%v) must exceed the fee and the staking minimum (%v)", account.Balance, params.StakingMinimum))
+		}
+
+		if c.Bool("vrf") {
+			vrfPrivKey, err := crypto.ExtractEDPrivKeyFromFile(c.String("commitment"))
+			if err != nil {
+				return err
+			}
+			vrfPubKey = vrfPrivKey.Public().(ed25519.PublicKey)
+		} else {
+			commPrivKey, err := crypto.ExtractRSAKeyFromFile(c.String("commitment"))
+			if err != nil {
+				return err
+			}
+			commPubKey = &commPrivKey.PublicKey
+		}
+	}
+
+	var tx *protocol.StakeTx
+	if vrfPubKey != nil {
+		tx, err = protocol.ConstrVRFStakeTx(0, c.Uint64("fee"), isStaking, accHash, privKey, vrfPubKey)
+	} else {
+		tx, err = protocol.ConstrStakeTx(0, c.Uint64("fee"), isStaking, accHash, privKey, commPubKey)
+	}
+	if err != nil {
+		return err
+	}
+
+	var txHash string
+	if err := node.call("submitTx", sendFundsParams{"stake", hex.EncodeToString(tx.Encode())}, &txHash); err != nil {
+		return errors.Wrap(err, "could not submit tx")
+	}
+
+	fmt.Printf("Tx submitted: %v\n", txHash)
+
+	if !isStaking || c.Bool("nowait") {
+		return nil
+	}
+
+	return waitForEligibility(node, accHash, params.WaitingMinimum)
+}
+
+//Polls the node until the account is staking and the tip has reached the height from which the validator may produce
+//blocks, i.e., waitingMinimum blocks after the one including the stake tx.
+func waitForEligibility(node *rpcChainSource, accHash [32]byte, waitingMinimum uint64) error {
+	var eligibleHeight uint32
+	for announced := false; ; time.Sleep(STAKE_POLL_INTERVAL * time.Second) {
+		account := new(stakeAccount)
+		if err := node.call("getAccount", []string{hex.EncodeToString(accHash[:])}, account); err != nil {
+			return errors.Wrap(err, "could not load validator account")
+		}
+
+		if !account.IsStaking {
+			if !announced {
+				fmt.Printf("Waiting for the stake tx to be included in a block...\n")
+				announced = true
+			}
+			continue
+		}
+
+		if height := account.StakingBlockHeight + uint32(waitingMinimum); height != eligibleHeight {
+			eligibleHeight = height
+			fmt.Printf("Staking since block %v, eligible to validate from block %v.\n", account.StakingBlockHeight, eligibleHeight)
+		}
+
+		tip, err := node.tip()
+		if err != nil {
+			return errors.Wrap(err, "could not load tip")
+		}
+
+		if tip.Height+1 >= eligibleHeight {
+			fmt.Printf("Waiting minimum elapsed, the account is eligible to validate.\n")
+			return nil
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/pkg/errors"
+	"github.com/urfave/cli"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+//Compares the tip of the local node with the tips of other nodes, all queried over their JSON-RPC interface. If the
+//tips differ, the chains are walked back block by block (getBlockByHash) until the common ancestor is found, at most
+//depth blocks per chain.
+
+const (
+	STATUS_DEFAULT_DEPTH = 100
+	STATUS_RPC_TIMEOUT   = 10
+
+	//The node's RPC token (see the rpctoken flag of start), sent by every command talking to the RPC interface.
+	RPC_TOKEN_ENV = "BAZO_RPC_TOKEN"
+)
+
+type statusBlock struct {
+	Hash     string `json:"hash"`
+	PrevHash string `json:"prevHash"`
+	Height   uint32 `json:"height"`
+}
+
+type statusRPCRequest struct {
+	JsonRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params"`
+	Id      int         `json:"id"`
+}
+
+type statusRPCResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+//Source of blocks of a single node, so the comparison can be tested without a running node.
+type chainSource interface {
+	tip() (*statusBlock, error)
+	block(hash string) (*statusBlock, error)
+}
+
+type rpcChainSource struct {
+	address string
+	client  *http.Client
+}
+
+const (
+	TIP_IN_SYNC = iota
+	TIP_BEHIND
+	TIP_AHEAD
+	TIP_DIVERGENT
+	TIP_UNKNOWN
+)
+
+type tipComparison struct {
+	status     int
+	localTip   *statusBlock
+	remoteTip  *statusBlock
+	distance   uint32
+	forkHeight uint32
+}
+
+func GetStatusCommand() cli.Command {
+	return cli.Command {
+		Name:	"status",
+		Usage:	"show the chain tip of the node and compare it with other nodes",
+		Action:	func(c *cli.Context) error {
+			if err := applyConfigFile(c); err != nil {
+				return err
+			}
+
+			return status(c.String("rpc"), c.Bool("compare"), c.StringSlice("peer"), c.Uint("depth"))
+		},
+		Flags:	[]cli.Flag {
+			configFlag,
+			cli.StringFlag {
+				Name: 	"rpc",
+				Usage: 	"query the node's RPC interface at `IP:PORT`",
+				Value:	"localhost:8001",
+			},
+			cli.BoolFlag {
+				Name: 	"compare",
+				Usage: 	"compare the tip with the tips of the nodes given with --peer",
+			},
+			cli.StringSliceFlag {
+				Name: 	"peer, p",
+				Usage: 	"compare with the node whose RPC interface is at `IP:PORT`, can be given multiple times",
+			},
+			cli.UintFlag {
+				Name: 	"depth",
+				Usage: 	"walk back at most `N` blocks per chain to find the fork point",
+				Value:	STATUS_DEFAULT_DEPTH,
+			},
+		},
+	}
+}
+
+func status(rpcAddress string, compare bool, peers []string, depth uint) error {
+	local := newRPCChainSource(rpcAddress)
+	localTip, err := local.tip()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Local tip: height %v, hash %v\n", localTip.Height, localTip.Hash)
+
+	if !compare {
+		return nil
+	}
+
+	if len(peers) == 0 {
+		return errors.New("argument missing: peer")
+	}
+
+	for _, peer := range peers {
+		comparison, err := compareTips(local, newRPCChainSource(peer), uint32(depth))
+		if err != nil {
+			fmt.Printf("%v: %v\n", peer, err)
+			continue
+		}
+		fmt.Printf("%v: %v\n", peer, comparison)
+	}
+
+	return nil
+}
+
+func compareTips(local chainSource, remote chainSource, depth uint32) (*tipComparison, error) {
+	localTip, err := local.tip()
+	if err != nil {
+		return nil, err
+	}
+
+	remoteTip, err := remote.tip()
+	if err != nil {
+		return nil, err
+	}
+
+	comparison := &tipComparison{localTip: localTip, remoteTip: remoteTip}
+	if localTip.Hash == remoteTip.Hash {
+		comparison.status = TIP_IN_SYNC
+		return comparison, nil
+	}
+
+	//Bring both chains to the same height. If the block of the longer chain at that height is the tip of the shorter
+	//one, the shorter chain is a prefix of the longer one.
+	localBlock, remoteBlock := localTip, remoteTip
+	if localTip.Height > remoteTip.Height {
+		comparison.distance = localTip.Height - remoteTip.Height
+		if localBlock, err = walkBack(local, localTip, remoteTip.Height, depth); err != nil {
+			return nil, err
+		}
+	} else {
+		comparison.distance = remoteTip.Height - localTip.Height
+		if remoteBlock, err = walkBack(remote, remoteTip, localTip.Height, depth); err != nil {
+			return nil, err
+		}
+	}
+
+	if localBlock == nil || remoteBlock == nil {
+		comparison.status = TIP_UNKNOWN
+		return comparison, nil
+	}
+
+	if localBlock.Hash == remoteBlock.Hash {
+		if localTip.Height > remoteTip.Height {
+			comparison.status = TIP_AHEAD
+		} else {
+			comparison.status = TIP_BEHIND
+		}
+		return comparison, nil
+	}
+
+	comparison.status = TIP_DIVERGENT
+	for steps := comparison.distance; localBlock.Hash != remoteBlock.Hash; steps++ {
+		if steps >= depth || localBlock.Height == 0 {
+			comparison.status = TIP_UNKNOWN
+			return comparison, nil
+		}
+
+		if localBlock, err = local.block(localBlock.PrevHash); err != nil {
+			return nil, err
+		}
+		if remoteBlock, err = remote.block(remoteBlock.PrevHash); err != nil {
+			return nil, err
+		}
+	}
+	comparison.forkHeight = localBlock.Height
+
+	return comparison, nil
+}
+
+//Returns the ancestor of block at the given height or nil if it is more than depth blocks away.
+func walkBack(source chainSource, block *statusBlock, height uint32, depth uint32) (*statusBlock, error) {
+	if block.Height-height > depth {
+		return nil, nil
+	}
+
+	var err error
+	for block.Height > height {
+		if block, err = source.block(block.PrevHash); err != nil {
+			return nil, err
+		}
+	}
+
+	return block, nil
+}
+
+func newRPCChainSource(address string) *rpcChainSource {
+	return &rpcChainSource{address: address, client: &http.Client{Timeout: STATUS_RPC_TIMEOUT * time.Second}}
+}
+
+func (source *rpcChainSource) tip() (*statusBlock, error) {
+	block := new(statusBlock)
+	if err := source.call("getTip", []string{}, block); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+func (source *rpcChainSource) block(hash string) (*statusBlock, error) {
+	block := new(statusBlock)
+	if err := source.call("getBlockByHash", []string{hash}, block); err != nil {
+		return nil, err
+	}
+
+	return block, nil
+}
+
+func (source *rpcChainSource) call(method string, params interface{}, result interface{}) error {
+	request, err := json.Marshal(statusRPCRequest{JsonRPC: "2.0", Method: method, Params: params, Id: 1})
+	if err != nil {
+		return err
+	}
+
+	req, err := newRPCRequest(http.MethodPost, "http://"+source.address+"/", bytes.NewReader(request))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := source.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var response statusRPCResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return errors.New(fmt.Sprintf("Invalid response from %v: %v", source.address, err))
+	}
+
+	if response.Error != nil {
+		return errors.New(fmt.Sprintf("%v failed on %v: %v", method, source.address, response.Error.Message))
+	}
+
+	return json.Unmarshal(response.Result, result)
+}
+
+func (comparison tipComparison) String() string {
+	remote := fmt.Sprintf("tip at height %v (%.16v)", comparison.remoteTip.Height, comparison.remoteTip.Hash)
+
+	switch comparison.status {
+	case TIP_IN_SYNC:
+		return "in sync, " + remote
+	case TIP_BEHIND:
+		return fmt.Sprintf("local node is %v blocks behind, %v", comparison.distance, remote)
+	case TIP_AHEAD:
+		return fmt.Sprintf("local node is %v blocks ahead, %v", comparison.distance, remote)
+	case TIP_DIVERGENT:
+		return fmt.Sprintf("divergent branch, fork point at height %v, %v", comparison.forkHeight, remote)
+	}
+
+	return "fork point not found within the search depth, " + remote
+}
+
+//Creates a request to the node's RPC interface, authorized with the token from the environment if there is one.
+func newRPCRequest(method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	if token := os.Getenv(RPC_TOKEN_ENV); len(token) > 0 {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"github.com/pkg/errors"
+	"testing"
+)
+
+//The blocks of a node, the tip is the last block added.
+type fakeChainSource struct {
+	blocks map[string]*statusBlock
+	last   *statusBlock
+}
+
+func newFakeChainSource() *fakeChainSource {
+	genesis := &statusBlock{Hash: "genesis", Height: 0}
+	return &fakeChainSource{blocks: map[string]*statusBlock{genesis.Hash: genesis}, last: genesis}
+}
+
+func (source *fakeChainSource) tip() (*statusBlock, error) {
+	return source.last, nil
+}
+
+func (source *fakeChainSource) block(hash string) (*statusBlock, error) {
+	if block, exists := source.blocks[hash]; exists {
+		return block, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("Block %v not found.", hash))
+}
+
+//Adds n blocks on top of the block with the given hash, named after the branch.
+func (source *fakeChainSource) extend(from string, branch string, n int) *fakeChainSource {
+	prev := source.blocks[from]
+	for i := 0; i < n; i++ {
+		block := &statusBlock{Hash: fmt.Sprintf("%v-%v", branch, prev.Height+1), PrevHash: prev.Hash, Height: prev.Height + 1}
+		source.blocks[block.Hash] = block
+		prev = block
+	}
+	source.last = prev
+
+	return source
+}
+
+func TestCompareTips(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		local      *fakeChainSource
+		remote     *fakeChainSource
+		depth      uint32
+		status     int
+		distance   uint32
+		forkHeight uint32
+	}{
+		{"equal tips", newFakeChainSource().extend("genesis", "a", 5), newFakeChainSource().extend("genesis", "a", 5), 100, TIP_IN_SYNC, 0, 0},
+		{"behind", newFakeChainSource().extend("genesis", "a", 3), newFakeChainSource().extend("genesis", "a", 5), 100, TIP_BEHIND, 2, 0},
+		{"ahead", newFakeChainSource().extend("genesis", "a", 6), newFakeChainSource().extend("genesis", "a", 3), 100, TIP_AHEAD, 3, 0},
+		{"forked", newFakeChainSource().extend("genesis", "a", 2).extend("a-2", "b", 3), newFakeChainSource().extend("genesis", "a", 2).extend("a-2", "c", 2), 100, TIP_DIVERGENT, 1, 2},
+		{"forked at genesis", newFakeChainSource().extend("genesis", "b", 2), newFakeChainSource().extend("genesis", "c", 2), 100, TIP_DIVERGENT, 0, 0},
+		{"fork point beyond depth", newFakeChainSource().extend("genesis", "a", 2).extend("a-2", "b", 5), newFakeChainSource().extend("genesis", "a", 2).extend("a-2", "c", 5), 3, TIP_UNKNOWN, 0, 0},
+		{"behind beyond depth", newFakeChainSource().extend("genesis", "a", 1), newFakeChainSource().extend("genesis", "a", 10), 5, TIP_UNKNOWN, 9, 0},
+	} {
+		comparison, err := compareTips(test.local, test.remote, test.depth)
+		if err != nil {
+			t.Errorf("%v: Comparison failed: %v\n", test.name, err)
+			continue
+		}
+
+		if comparison.status != test.status {
+			t.Errorf("%v: Status is %v instead of %v.\n", test.name, comparison.status, test.status)
+		}
+		if comparison.distance != test.distance {
+			t.Errorf("%v: Distance is %v instead of %v.\n", test.name, comparison.distance, test.distance)
+		}
+		if comparison.forkHeight != test.forkHeight {
+			t.Errorf("%v: Fork point at height %v instead of %v.\n", test.name, comparison.forkHeight, test.forkHeight)
+		}
+	}
+}
+
+func TestCompareTipsMissingBlock(t *testing.T) {
+	local := newFakeChainSource().extend("genesis", "a", 2)
+	remote := newFakeChainSource().extend("genesis", "a", 2).extend("a-2", "b", 2)
+	delete(remote.blocks, "b-3")
+
+	if _, err := compareTips(local, remote, 100); err == nil {
+		t.Error("Comparison succeeded with a block missing on the remote chain.\n")
+	}
+}
+
+func TestWalkBack(t *testing.T) {
+	source := newFakeChainSource().extend("genesis", "a", 10)
+	tip, _ := source.tip()
+
+	for _, test := range []struct {
+		height uint32
+		depth  uint32
+		hash   string
+	}{
+		{10, 0, "a-10"},
+		{7, 3, "a-7"},
+		{0, 10, "genesis"},
+		//More than depth blocks away.
+		{6, 3, ""},
+	} {
+		block, err := walkBack(source, tip, test.height, test.depth)
+		if err != nil {
+			t.Errorf("Walking back to height %v failed: %v\n", test.height, err)
+			continue
+		}
+
+		if test.hash == "" {
+			if block != nil {
+				t.Errorf("Walking back to height %v with depth %v returned %v.\n", test.height, test.depth, block.Hash)
+			}
+			continue
+		}
+		if block == nil || block.Hash != test.hash {
+			t.Errorf("Walking back to height %v did not return %v: %v\n", test.height, test.hash, block)
+		}
+	}
+
+	delete(source.blocks, "a-8")
+	if _, err := walkBack(source, tip, 5, 10); err == nil {
+		t.Error("Walking back over a missing block succeeded.\n")
+	}
+}
@@ -0,0 +1,9 @@
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package cli
+
+//Echo can't be disabled on this platform, the passphrase is read like any other input.
+func readPassword() ([]byte, error) {
+	return readLine()
+}
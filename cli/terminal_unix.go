@@ -0,0 +1,32 @@
+//go:build linux || darwin || dragonfly || freebsd || netbsd || openbsd
+// +build linux darwin dragonfly freebsd netbsd openbsd
+
+package cli
+
+import (
+	"fmt"
+	"golang.org/x/sys/unix"
+	"os"
+)
+
+//Reads a passphrase from stdin, without echoing it if stdin is a terminal.
+func readPassword() ([]byte, error) {
+	fd := int(os.Stdin.Fd())
+	state, err := unix.IoctlGetTermios(fd, ioctlGetTermios)
+	if err != nil {
+		//Not a terminal, e.g., piped input.
+		return readLine()
+	}
+
+	noEcho := *state
+	noEcho.Lflag &^= unix.ECHO
+	noEcho.Lflag |= unix.ICANON | unix.ISIG
+	noEcho.Iflag |= unix.ICRNL
+	if err := unix.IoctlSetTermios(fd, ioctlSetTermios, &noEcho); err != nil {
+		return nil, err
+	}
+	defer unix.IoctlSetTermios(fd, ioctlSetTermios, state)
+	defer fmt.Fprintln(os.Stderr)
+
+	return readLine()
+}
@@ -3,7 +3,11 @@ package cli
 import (
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/pkg/errors"
 	"github.com/urfave/cli"
+	"golang.org/x/crypto/ed25519"
+	"math"
+	"os"
 )
 
 func GetGenerateWalletCommand() cli.Command {
@@ -11,20 +15,111 @@ func GetGenerateWalletCommand() cli.Command {
 		Name:	"generate-wallet",
 		Usage:	"generate a new pair of wallet keys",
 		Action:	func(c *cli.Context) error {
+			initPassphraseProvider(c)
+
 			filename := c.String("file")
+			if c.Bool("mnemonic") {
+				return generateMnemonicWallet(filename, c.Uint("index"))
+			}
+
 			privKey, err := crypto.ExtractEDPrivKeyFromFile(filename)
+			if err != nil {
+				return err
+			}
 
 			fmt.Printf("Wallet generated successfully.\n")
 			fmt.Printf("PubKey: %x\n", privKey[32:])
 			fmt.Printf("PrivKey: %x\n", privKey)
 
-			return err
+			return nil
 		},
 		Flags:	[]cli.Flag {
+			passphraseFileFlag,
+			encryptFlag,
 			cli.StringFlag {
 				Name: 	"file",
 				Usage: 	"the new key's `FILE` name",
 			},
+			cli.BoolFlag {
+				Name: 	"mnemonic",
+				Usage: 	"derive the key from a new 24-word mnemonic, the wallet can be restored with recover-wallet",
+			},
+			cli.UintFlag {
+				Name: 	"index",
+				Usage: 	"derive the wallet with index `N` from the mnemonic",
+			},
+		},
+	}
+}
+
+func GetRecoverWalletCommand() cli.Command {
+	return cli.Command {
+		Name:	"recover-wallet",
+		Usage:	"restore a wallet key from its 24-word mnemonic",
+		Action:	func(c *cli.Context) error {
+			initPassphraseProvider(c)
+
+			if c.Uint("index") > math.MaxInt32 {
+				return errors.New("invalid argument: index must be smaller than 2^31")
+			}
+
+			fmt.Fprintf(os.Stderr, "Mnemonic: ")
+			mnemonic, err := readPassword()
+			if err != nil {
+				return err
+			}
+
+			privKey, err := crypto.DeriveEDKeyFromMnemonic(string(mnemonic), uint32(c.Uint("index")))
+			if err != nil {
+				return err
+			}
+
+			if err := crypto.WriteEDKeyFile(c.String("file"), privKey); err != nil {
+				return err
+			}
+
+			fmt.Printf("Wallet recovered successfully.\n")
+			fmt.Printf("PubKey: %x\n", privKey[32:])
+
+			return nil
+		},
+		Flags:	[]cli.Flag {
+			passphraseFileFlag,
+			encryptFlag,
+			cli.StringFlag {
+				Name: 	"file",
+				Usage: 	"write the recovered key to `FILE`, must not exist yet",
+			},
+			cli.UintFlag {
+				Name: 	"index",
+				Usage: 	"recover the wallet with index `N` of the mnemonic",
+			},
 		},
 	}
 }
+
+func generateMnemonicWallet(filename string, index uint) error {
+	if index > math.MaxInt32 {
+		return errors.New("invalid argument: index must be smaller than 2^31")
+	}
+
+	mnemonic, err := crypto.NewMnemonic()
+	if err != nil {
+		return err
+	}
+
+	var privKey ed25519.PrivateKey
+	if privKey, err = crypto.DeriveEDKeyFromMnemonic(mnemonic, uint32(index)); err != nil {
+		return err
+	}
+
+	if err = crypto.WriteEDKeyFile(filename, privKey); err != nil {
+		return err
+	}
+
+	fmt.Printf("Wallet generated successfully.\n")
+	fmt.Printf("PubKey: %x\n", privKey[32:])
+	fmt.Printf("Write down the mnemonic and keep it safe, anyone knowing it controls the wallet:\n%v\n", mnemonic)
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package crash
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sync"
+	"time"
+)
+
+//Panics in the p2p handlers, block validation and contract execution are recovered, so a single malformed message or
+//block does not take the whole node down. Every recovered panic is logged and written as a JSON crash report to the
+//crash directory (if set), one file per panic.
+
+const (
+	CRASH_REPORT_PREFIX = "crash-"
+)
+
+type Report struct {
+	Time      string `json:"time"`
+	Component string `json:"component"`
+	Hash      string `json:"hash,omitempty"`
+	Details   string `json:"details,omitempty"`
+	Panic     string `json:"panic"`
+	Stack     string `json:"stack"`
+}
+
+var (
+	crashDir   string
+	crashMutex = &sync.Mutex{}
+	crashCount uint64
+)
+
+//Reports are only logged if dir is empty.
+func Init(dir string) error {
+	crashMutex.Lock()
+	defer crashMutex.Unlock()
+
+	if len(dir) > 0 {
+		if err := os.MkdirAll(dir, 0700); err != nil {
+			return errors.New(fmt.Sprintf("Could not create crash directory %v: %v", dir, err))
+		}
+	}
+	crashDir = dir
+
+	return nil
+}
+
+//Recovers a panic of the calling goroutine and reports it. Must be deferred directly, e.g.,
+//defer crash.Recover("p2p", payloadHash[:], "BLOCK_BRDCST").
+func Recover(component string, hash []byte, details string) {
+	if r := recover(); r != nil {
+		report(component, hash, details, r)
+	}
+}
+
+//Same as Recover, but additionally sets *err, so the function the panic occurred in returns an error.
+func RecoverError(component string, hash []byte, details string, err *error) {
+	if r := recover(); r != nil {
+		report(component, hash, details, r)
+		*err = errors.New(fmt.Sprintf("Recovered from panic in %v: %v", component, r))
+	}
+}
+
+//Returns the number of panics recovered since the node was started.
+func Count() uint64 {
+	crashMutex.Lock()
+	defer crashMutex.Unlock()
+
+	return crashCount
+}
+
+func report(component string, hash []byte, details string, r interface{}) {
+	now := time.Now().UTC()
+	crashReport := Report{
+		Time:      now.Format(time.RFC3339Nano),
+		Component: component,
+		Hash:      hex.EncodeToString(hash),
+		Details:   details,
+		Panic:     fmt.Sprint(r),
+		Stack:     string(debug.Stack()),
+	}
+
+	crashMutex.Lock()
+	defer crashMutex.Unlock()
+
+	crashCount++
+	log.Printf("Recovered from panic in %v (hash: %v, %v): %v\n", component, crashReport.Hash, details, r)
+
+	if len(crashDir) == 0 {
+		return
+	}
+
+	encoded, err := json.MarshalIndent(crashReport, "", "  ")
+	if err != nil {
+		log.Printf("Could not encode crash report: %v\n", err)
+		return
+	}
+
+	file := filepath.Join(crashDir, fmt.Sprintf("%v%v-%v.json", CRASH_REPORT_PREFIX, now.Format("20060102T150405.000000000"), crashCount))
+	if err := ioutil.WriteFile(file, encoded, 0600); err != nil {
+		log.Printf("Could not write crash report %v: %v\n", file, err)
+	}
+}
@@ -0,0 +1,73 @@
+package crash
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func panicWithError() (err error) {
+	defer RecoverError("test", []byte{0x01, 0x02}, "error", &err)
+
+	var block *struct{ Height uint32 }
+	block.Height++
+
+	return nil
+}
+
+func TestRecover(t *testing.T) {
+	dir, err := ioutil.TempDir("", "crashes")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	defer Init("")
+
+	if err := Init(dir); err != nil {
+		t.Fatalf("Could not initialize crash directory: %v\n", err)
+	}
+	count := Count()
+
+	func() {
+		defer Recover("test", nil, "recover")
+		panic("malformed message")
+	}()
+
+	if err := panicWithError(); err == nil || !strings.Contains(err.Error(), "nil pointer") {
+		t.Errorf("Panic not returned as error: %v\n", err)
+	}
+
+	if Count() != count+2 {
+		t.Errorf("%v panics counted instead of %v.\n", Count(), count+2)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, CRASH_REPORT_PREFIX+"*.json"))
+	if len(files) != 2 {
+		t.Fatalf("%v crash reports written instead of 2.\n", len(files))
+	}
+
+	var reports []Report
+	for _, file := range files {
+		encoded, _ := ioutil.ReadFile(file)
+		var report Report
+		if err := json.Unmarshal(encoded, &report); err != nil {
+			t.Fatalf("Invalid crash report %v: %v\n", file, err)
+		}
+		reports = append(reports, report)
+	}
+
+	for _, report := range reports {
+		if report.Component != "test" || !strings.Contains(report.Stack, "crash.") {
+			t.Errorf("Incomplete crash report: %v\n", report)
+		}
+		if report.Details == "error" && report.Hash != "0102" {
+			t.Errorf("Hash %v not reported.\n", report.Hash)
+		}
+		if report.Details == "recover" && report.Panic != "malformed message" {
+			t.Errorf("Panic %v not reported.\n", report.Panic)
+		}
+	}
+}
@@ -31,13 +31,12 @@ func ExtractRSAKeyFromFile(filename string) (privKey *rsa.PrivateKey, err error)
 		}
 	}
 
-	filehandle, err := os.Open(filename)
+	reader, err := readKeyFile(filename)
 	if err != nil {
-		return privKey, errors.New(fmt.Sprintf("%v", err))
+		return privKey, err
 	}
-	defer filehandle.Close()
 
-	scanner := bufio.NewScanner(filehandle)
+	scanner := bufio.NewScanner(reader)
 
 	strModulus := nextLine(scanner)
 	strPrivExponent := nextLine(scanner)
@@ -138,18 +137,12 @@ func nextLine(scanner *bufio.Scanner) string {
 // 2 	Private Exponent D
 // 3+	Private Primes (depending on COMM_NOF_PRIMES)
 func CreateRSAKeyFile(filename string) error {
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
-
 	key, err := rsa.GenerateMultiPrimeKey(rand.Reader, COMM_NOF_PRIMES, COMM_KEY_BITS)
 	if err != nil {
 		return err
 	}
 
-	_, err = file.WriteString(stringifyRSAKey(key))
-	return err
+	return writeKeyFile(filename, stringifyRSAKey(key))
 }
 
 func stringifyRSAKey(key *rsa.PrivateKey) string {
@@ -0,0 +1,94 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"golang.org/x/crypto/ed25519"
+	"golang.org/x/crypto/nacl/box"
+)
+
+//IoT data is public on the chain. A device can encrypt the data of an IotTx to the receiver of the tx, only the holder
+//of the receiver's wallet key can read it. Since account addresses are ed25519 public keys, the receiver's key is
+//converted to its X25519 (Montgomery) form and the data sealed anonymously with a fresh ephemeral key (NaCl sealed
+//box: X25519, XSalsa20 and Poly1305). The wallet key is converted the same way to open the data.
+
+const IOT_ENCRYPTION_OVERHEAD = box.AnonymousOverhead
+
+//p = 2^255 - 19, the prime of the field of both curves.
+var curve25519P, _ = new(big.Int).SetString("7fffffffffffffffffffffffffffffffffffffffffffffffffffffffffffffed", 16)
+
+func EncryptIotData(receiver ed25519.PublicKey, data []byte) ([]byte, error) {
+	receiverKey, err := EDPublicKeyToX25519(receiver)
+	if err != nil {
+		return nil, err
+	}
+
+	return box.SealAnonymous(nil, data, &receiverKey, rand.Reader)
+}
+
+func DecryptIotData(privKey ed25519.PrivateKey, encrypted []byte) ([]byte, error) {
+	if len(privKey) != ed25519.PrivateKeySize {
+		return nil, errors.New(fmt.Sprintf("Invalid private key size: %v", len(privKey)))
+	}
+
+	pubKey, err := EDPublicKeyToX25519(ed25519.PublicKey(privKey[32:]))
+	if err != nil {
+		return nil, err
+	}
+	key := EDPrivateKeyToX25519(privKey)
+
+	data, ok := box.OpenAnonymous(nil, encrypted, &pubKey, &key)
+	if !ok {
+		return nil, errors.New("IoT data could not be decrypted, it is either corrupted or encrypted to another key.")
+	}
+
+	return data, nil
+}
+
+//The ed25519 secret scalar is the clamped first half of the SHA-512 hash of the seed, X25519 uses the same scalar.
+func EDPrivateKeyToX25519(privKey ed25519.PrivateKey) (key [32]byte) {
+	hash := sha512.Sum512(privKey.Seed())
+	copy(key[:], hash[:32])
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+
+	return key
+}
+
+//Maps the Edwards y coordinate to the Montgomery u coordinate: u = (1 + y) / (1 - y) mod p.
+func EDPublicKeyToX25519(pubKey ed25519.PublicKey) (key [32]byte, err error) {
+	if len(pubKey) != ed25519.PublicKeySize {
+		return key, errors.New(fmt.Sprintf("Invalid public key size: %v", len(pubKey)))
+	}
+
+	//The key is the little endian y coordinate, the highest bit is the sign of x.
+	encoded := make([]byte, ed25519.PublicKeySize)
+	for i := range pubKey {
+		encoded[len(pubKey)-1-i] = pubKey[i]
+	}
+	encoded[0] &= 0x7f
+	y := new(big.Int).SetBytes(encoded)
+
+	denominator := new(big.Int).Sub(big.NewInt(1), y)
+	denominator.Mod(denominator, curve25519P)
+	if y.Cmp(curve25519P) >= 0 || denominator.Sign() == 0 {
+		return key, errors.New("Public key is not a valid ed25519 key.")
+	}
+
+	u := new(big.Int).Add(big.NewInt(1), y)
+	u.Mul(u, denominator.ModInverse(denominator, curve25519P))
+	u.Mod(u, curve25519P)
+
+	uBytes := u.Bytes()
+	for i := range uBytes {
+		key[i] = uBytes[len(uBytes)-1-i]
+	}
+
+	return key, nil
+}
+
@@ -0,0 +1,72 @@
+package crypto
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"golang.org/x/crypto/curve25519"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestX25519Conversion(t *testing.T) {
+	for i := 0; i < 10; i++ {
+		pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+
+		convertedPub, err := EDPublicKeyToX25519(pubKey)
+		if err != nil {
+			t.Fatalf("Public key could not be converted: %v", err)
+		}
+
+		convertedPriv := EDPrivateKeyToX25519(privKey)
+		derivedPub, err := curve25519.X25519(convertedPriv[:], curve25519.Basepoint)
+		if err != nil {
+			t.Fatalf("X25519 public key could not be derived: %v", err)
+		}
+
+		if !bytes.Equal(derivedPub, convertedPub[:]) {
+			t.Errorf("Converted public key (%x) does not match the key derived from the private key (%x).", convertedPub, derivedPub)
+		}
+	}
+
+	if _, err := EDPublicKeyToX25519(make([]byte, 16)); err == nil {
+		t.Error("Public key with an invalid size was converted.")
+	}
+}
+
+func TestIotDataEncryption(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, otherPrivKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	data := []byte(`{"temperature":21.5,"humidity":40}`)
+	encrypted, err := EncryptIotData(pubKey, data)
+	if err != nil {
+		t.Fatalf("IoT data could not be encrypted: %v", err)
+	}
+
+	if len(encrypted) != len(data)+IOT_ENCRYPTION_OVERHEAD {
+		t.Errorf("Encrypted size (%v) does not match the data size plus overhead (%v).", len(encrypted), len(data)+IOT_ENCRYPTION_OVERHEAD)
+	}
+
+	if bytes.Contains(encrypted, data) {
+		t.Error("Encrypted data contains the plaintext.")
+	}
+
+	decrypted, err := DecryptIotData(privKey, encrypted)
+	if err != nil || !bytes.Equal(decrypted, data) {
+		t.Errorf("Decrypted data (%s) does not match the original data (%s): %v", decrypted, data, err)
+	}
+
+	if _, err := DecryptIotData(otherPrivKey, encrypted); err == nil {
+		t.Error("Data was decrypted with another key.")
+	}
+
+	encrypted[len(encrypted)-1] ^= 0x01
+	if _, err := DecryptIotData(privKey, encrypted); err == nil {
+		t.Error("Tampered data was decrypted.")
+	}
+
+	if _, err := DecryptIotData(privKey, encrypted[:IOT_ENCRYPTION_OVERHEAD-1]); err == nil {
+		t.Error("Truncated data was decrypted.")
+	}
+}
@@ -0,0 +1,219 @@
+package crypto
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+)
+
+//Key files can be encrypted with a passphrase. The first line (the public key of wallets, the public modulus of
+//commitment keys) stays readable, so public keys can be extracted without the passphrase. The remaining lines are
+//replaced by a single line holding the scrypt salt, the AES-GCM nonce and the ciphertext. The first line is
+//authenticated as additional data, so it can't be swapped without the decryption failing.
+
+const (
+	ENCRYPTED_KEY_PREFIX = "BAZO-ENCRYPTED-KEY-V1:"
+
+	KEY_SALT_LENGTH = 16
+	KEY_SCRYPT_N    = 1 << 15
+	KEY_SCRYPT_R    = 8
+	KEY_SCRYPT_P    = 1
+	KEY_AES_LENGTH  = 32
+)
+
+//Returns the passphrase of the key file, confirm is set if a new passphrase is chosen (e.g., to ask for it twice).
+type PassphraseProvider func(filename string, confirm bool) ([]byte, error)
+
+var (
+	passphraseProvider PassphraseProvider
+	encryptNewKeyFiles bool
+)
+
+//Without a provider, encrypted key files can't be read. If encryptNew is set, newly created key files are encrypted.
+func SetPassphraseProvider(provider PassphraseProvider, encryptNew bool) {
+	passphraseProvider = provider
+	encryptNewKeyFiles = encryptNew
+}
+
+func IsKeyFileEncrypted(filename string) (bool, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return false, err
+	}
+
+	_, rest := splitKeyFile(content)
+	return strings.HasPrefix(rest, ENCRYPTED_KEY_PREFIX), nil
+}
+
+//Encrypts an existing plaintext key file in place.
+func EncryptKeyFile(filename string) error {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	if _, rest := splitKeyFile(content); strings.HasPrefix(rest, ENCRYPTED_KEY_PREFIX) {
+		return errors.New(fmt.Sprintf("Key file %v is already encrypted.", filename))
+	}
+
+	encrypted, err := encryptKeyFileContent(filename, content)
+	if err != nil {
+		return err
+	}
+
+	//Write to a temporary file first, so the key is never lost if writing fails.
+	tmpFilename := filename + ".tmp"
+	if err = ioutil.WriteFile(tmpFilename, encrypted, 0600); err != nil {
+		return err
+	}
+
+	return os.Rename(tmpFilename, filename)
+}
+
+//Returns a reader of the plaintext content of the key file, encrypted key files are decrypted.
+func readKeyFile(filename string) (*bufio.Reader, error) {
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("%v", err))
+	}
+
+	firstLine, rest := splitKeyFile(content)
+	if !strings.HasPrefix(rest, ENCRYPTED_KEY_PREFIX) {
+		return bufio.NewReader(bytes.NewReader(content)), nil
+	}
+
+	if passphraseProvider == nil {
+		return nil, errors.New(fmt.Sprintf("Key file %v is encrypted, but no passphrase is available.", filename))
+	}
+
+	passphrase, err := passphraseProvider(filename, false)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := decryptKeyLine(strings.TrimSpace(rest), firstLine, passphrase)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Could not decrypt key file %v: %v", filename, err))
+	}
+
+	return bufio.NewReader(strings.NewReader(firstLine + "\n" + string(plaintext))), nil
+}
+
+//Writes a newly created key file, encrypted if enabled by SetPassphraseProvider.
+func writeKeyFile(filename string, content string) error {
+	encoded := []byte(content)
+	if encryptNewKeyFiles {
+		var err error
+		if encoded, err = encryptKeyFileContent(filename, encoded); err != nil {
+			return err
+		}
+	}
+
+	return ioutil.WriteFile(filename, encoded, 0600)
+}
+
+func encryptKeyFileContent(filename string, content []byte) ([]byte, error) {
+	if passphraseProvider == nil {
+		return nil, errors.New("No passphrase available to encrypt the key file.")
+	}
+
+	passphrase, err := passphraseProvider(filename, true)
+	if err != nil {
+		return nil, err
+	}
+
+	firstLine, rest := splitKeyFile(content)
+	encryptedLine, err := encryptKeyLine([]byte(rest), firstLine, passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(firstLine + "\n" + encryptedLine + "\n"), nil
+}
+
+func encryptKeyLine(plaintext []byte, firstLine string, passphrase []byte) (string, error) {
+	salt := make([]byte, KEY_SALT_LENGTH)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+
+	aead, err := newKeyFileCipher(passphrase, salt)
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+
+	ciphertext := aead.Seal(nil, nonce, plaintext, []byte(firstLine))
+
+	return ENCRYPTED_KEY_PREFIX + hex.EncodeToString(salt) + ":" + hex.EncodeToString(nonce) + ":" + hex.EncodeToString(ciphertext), nil
+}
+
+func decryptKeyLine(line string, firstLine string, passphrase []byte) ([]byte, error) {
+	parts := strings.Split(strings.TrimPrefix(line, ENCRYPTED_KEY_PREFIX), ":")
+	if len(parts) != 3 {
+		return nil, errors.New("Malformed encrypted key.")
+	}
+
+	var decoded [3][]byte
+	for i, part := range parts {
+		var err error
+		if decoded[i], err = hex.DecodeString(part); err != nil {
+			return nil, errors.New("Malformed encrypted key.")
+		}
+	}
+	salt, nonce, ciphertext := decoded[0], decoded[1], decoded[2]
+
+	aead, err := newKeyFileCipher(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nonce) != aead.NonceSize() {
+		return nil, errors.New("Malformed encrypted key.")
+	}
+
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(firstLine))
+	if err != nil {
+		return nil, errors.New("Wrong passphrase or corrupted key file.")
+	}
+
+	return plaintext, nil
+}
+
+func newKeyFileCipher(passphrase []byte, salt []byte) (cipher.AEAD, error) {
+	key, err := scrypt.Key(passphrase, salt, KEY_SCRYPT_N, KEY_SCRYPT_R, KEY_SCRYPT_P, KEY_AES_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+//Splits the content of a key file into the first (public) line and the rest.
+func splitKeyFile(content []byte) (firstLine string, rest string) {
+	lines := strings.SplitN(string(content), "\n", 2)
+	if len(lines) < 2 {
+		return lines[0], ""
+	}
+
+	return lines[0], lines[1]
+}
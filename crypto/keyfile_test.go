@@ -0,0 +1,99 @@
+package crypto
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+const KEYFILE_TEST_FILE = "test_encrypted_key.txt"
+
+func TestEncryptedKeyFile(t *testing.T) {
+	os.Remove(KEYFILE_TEST_FILE)
+	defer os.Remove(KEYFILE_TEST_FILE)
+	defer SetPassphraseProvider(nil, false)
+
+	passphrase := "correct horse"
+	SetPassphraseProvider(func(filename string, confirm bool) ([]byte, error) {
+		return []byte(passphrase), nil
+	}, false)
+
+	if err := CreateEDKeyFile(KEYFILE_TEST_FILE); err != nil {
+		t.Fatal(err)
+	}
+	privKey, err := ExtractEDPrivKeyFromFile(KEYFILE_TEST_FILE)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	//Migrating the plaintext key.
+	if err := EncryptKeyFile(KEYFILE_TEST_FILE); err != nil {
+		t.Fatalf("Encrypting key file failed: %v\n", err)
+	}
+	if encrypted, _ := IsKeyFileEncrypted(KEYFILE_TEST_FILE); !encrypted {
+		t.Fatal("Key file should be encrypted.")
+	}
+	if err := EncryptKeyFile(KEYFILE_TEST_FILE); err == nil {
+		t.Error("Encrypting an encrypted key file should fail.")
+	}
+
+	content, _ := ioutil.ReadFile(KEYFILE_TEST_FILE)
+	if strings.Contains(string(content), string(privKey[0:32])) {
+		t.Error("Encrypted key file contains the private key.")
+	}
+
+	//The public key can be read without the passphrase.
+	SetPassphraseProvider(nil, false)
+	if pubKey, err := ExtractEDPublicKeyFromFile(KEYFILE_TEST_FILE); err != nil || !pubKey.Equal(privKey.Public()) {
+		t.Errorf("Public key should be readable without passphrase: %v\n", err)
+	}
+	if _, err := ExtractEDPrivKeyFromFile(KEYFILE_TEST_FILE); err == nil {
+		t.Error("Private key should not be readable without passphrase.")
+	}
+
+	SetPassphraseProvider(func(filename string, confirm bool) ([]byte, error) {
+		return []byte("wrong"), nil
+	}, false)
+	if _, err := ExtractEDPrivKeyFromFile(KEYFILE_TEST_FILE); err == nil {
+		t.Error("Private key should not be readable with a wrong passphrase.")
+	}
+
+	SetPassphraseProvider(func(filename string, confirm bool) ([]byte, error) {
+		return []byte(passphrase), nil
+	}, false)
+	if decrypted, err := ExtractEDPrivKeyFromFile(KEYFILE_TEST_FILE); err != nil || !decrypted.Equal(privKey) {
+		t.Errorf("Decrypted private key does not match: %v\n", err)
+	}
+}
+
+func TestEncryptedCommitmentKeyFile(t *testing.T) {
+	os.Remove(KEYFILE_TEST_FILE)
+	defer os.Remove(KEYFILE_TEST_FILE)
+	defer SetPassphraseProvider(nil, false)
+
+	//New key files are encrypted right away.
+	SetPassphraseProvider(func(filename string, confirm bool) ([]byte, error) {
+		return []byte("passphrase"), nil
+	}, true)
+	privKey, err := ExtractRSAKeyFromFile(KEYFILE_TEST_FILE)
+	if err != nil {
+		t.Fatalf("Creating encrypted commitment key failed: %v\n", err)
+	}
+	if encrypted, _ := IsKeyFileEncrypted(KEYFILE_TEST_FILE); !encrypted {
+		t.Fatal("Commitment key file should be encrypted.")
+	}
+
+	decrypted, err := ExtractRSAKeyFromFile(KEYFILE_TEST_FILE)
+	if err != nil || decrypted.D.Cmp(privKey.D) != 0 {
+		t.Errorf("Decrypted commitment key does not match: %v\n", err)
+	}
+
+	SetPassphraseProvider(func(filename string, confirm bool) ([]byte, error) {
+		return nil, errors.New("no passphrase")
+	}, false)
+	if _, err := ExtractRSAKeyFromFile(KEYFILE_TEST_FILE); err == nil {
+		t.Error("Commitment key should not be readable without passphrase.")
+	}
+}
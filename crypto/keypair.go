@@ -39,13 +39,10 @@ func ExtractEDPrivKeyFromFile(filename string) (privKey ed25519.PrivateKey, err
 		}
 	}
 
-	filehandle, err := os.Open(filename)
+	reader, err := readKeyFile(filename)
 	if err != nil {
-		return privKey, errors.New(fmt.Sprintf("%v", err))
+		return privKey, err
 	}
-	defer filehandle.Close()
-
-	reader := bufio.NewReader(filehandle)
 
 	return readEDPrivateKey(reader)
 }
@@ -147,19 +144,24 @@ func CreateEDKeyFile(filename string) (err error) {
 		return err
 	}
 
-	file, err := os.Create(filename)
-	if err != nil {
-		return err
-	}
 	fmt.Print("PUBKEY ->", pubKey)
-	//var pubKey [64]byte
-	_, err1 := file.WriteString(hex.EncodeToString(pubKey)+ "\n")
-	_, err2 := file.WriteString(hex.EncodeToString(privKey[0:32])+ "\n")
-	_, err3 := file.WriteString(hex.EncodeToString(privKey[32:64])+ "\n")
 
+	return WriteEDKeyFile(filename, privKey)
+}
+
+//Writes an existing key (e.g., derived from a mnemonic) to a new key file.
+func WriteEDKeyFile(filename string, privKey ed25519.PrivateKey) error {
+	if _, err := os.Stat(filename); !os.IsNotExist(err) {
+		return errors.New(fmt.Sprintf("key file %v already exists", filename))
+	}
+
+	//The public key stays readable if the file is encrypted, see keyfile.go.
+	content := hex.EncodeToString(privKey[32:64]) + "\n" +
+		hex.EncodeToString(privKey[0:32]) + "\n" +
+		hex.EncodeToString(privKey[32:64]) + "\n"
 
-	if err1 != nil || err2 != nil || err3 != nil {
-		return errors.New("failed to write key to file")
+	if err := writeKeyFile(filename, content); err != nil {
+		return errors.New(fmt.Sprintf("failed to write key to file: %v", err))
 	}
 
 	return nil
@@ -9,24 +9,34 @@ const (
 	KEY_TEST_FILE = "test_key.txt"
 )
 
-func TestExtractAndVerifyEDSAKeyFromNonExistingFile(t *testing.T) {
+func TestExtractAndVerifyEDKeyFromNonExistingFile(t *testing.T) {
 	os.Remove(KEY_TEST_FILE)
 
-	_, err := ExtractECDSAKeyFromFile(KEY_TEST_FILE)
+	privKey, err := ExtractEDPrivKeyFromFile(KEY_TEST_FILE)
 	if err != nil {
-		t.Errorf("Could not extract RSA key from file. Failed with error: %v", err)
+		t.Errorf("Could not extract ED key from file. Failed with error: %v", err)
+	}
+
+	pubKey, err := ExtractEDPublicKeyFromFile(KEY_TEST_FILE)
+	if err != nil {
+		t.Errorf("Could not extract ED public key from file. Failed with error: %v", err)
+	}
+
+	if err = VerifyEDKey(privKey, pubKey); err != nil {
+		t.Errorf("Extracted keys do not match: %v", err)
 	}
 
 	os.Remove(KEY_TEST_FILE)
 }
 
-func TestExtractAndVerifyEDSAKeyFromExistingFile(t *testing.T) {
+func TestExtractAndVerifyEDKeyFromExistingFile(t *testing.T) {
 	os.Remove(KEY_TEST_FILE)
-	err := CreateECDSAKeyFile(KEY_TEST_FILE)
+	if err := CreateEDKeyFile(KEY_TEST_FILE); err != nil {
+		t.Fatalf("Could not create ED key file: %v", err)
+	}
 
-	_, err = ExtractECDSAKeyFromFile(KEY_TEST_FILE)
-	if err != nil {
-		t.Errorf("Could not extract RSA key from file. Failed with error: %v", err)
+	if _, err := ExtractEDPrivKeyFromFile(KEY_TEST_FILE); err != nil {
+		t.Errorf("Could not extract ED key from file. Failed with error: %v", err)
 	}
 
 	os.Remove(KEY_TEST_FILE)
@@ -0,0 +1,68 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+//Ledger devices exchange APDUs in HID reports of 64 bytes. Every report starts with the channel (2 bytes), the tag
+//(1 byte) and a sequence number (2 bytes) starting at 0, the first report of a message additionally holds the length of
+//the whole message (2 bytes). The rest of a report is payload, the last report is padded with zeros. All integers are
+//big endian.
+
+const (
+	HID_REPORT_SIZE = 64
+	HID_CHANNEL     = 0x0101
+	HID_TAG_APDU    = 0x05
+)
+
+func writeHID(device io.Writer, message []byte) error {
+	//Prefixing the message with its length makes it the payload of the first report.
+	payload := make([]byte, 2+len(message))
+	binary.BigEndian.PutUint16(payload, uint16(len(message)))
+	copy(payload[2:], message)
+
+	for seq := uint16(0); len(payload) > 0; seq++ {
+		report := make([]byte, HID_REPORT_SIZE)
+		binary.BigEndian.PutUint16(report[0:2], HID_CHANNEL)
+		report[2] = HID_TAG_APDU
+		binary.BigEndian.PutUint16(report[3:5], seq)
+
+		n := copy(report[5:], payload)
+		payload = payload[n:]
+
+		if _, err := device.Write(report); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func readHID(device io.Reader) ([]byte, error) {
+	var message []byte
+	var length int
+	for seq := uint16(0); seq == 0 || len(message) < length; seq++ {
+		report := make([]byte, HID_REPORT_SIZE)
+		if _, err := io.ReadFull(device, report); err != nil {
+			return nil, err
+		}
+
+		if binary.BigEndian.Uint16(report[0:2]) != HID_CHANNEL || report[2] != HID_TAG_APDU || binary.BigEndian.Uint16(report[3:5]) != seq {
+			return nil, errors.New("Ledger returned an invalid HID report.")
+		}
+
+		payload := report[5:]
+		if seq == 0 {
+			length = int(binary.BigEndian.Uint16(payload[0:2]))
+			payload = payload[2:]
+			if length == 0 {
+				return nil, errors.New("Ledger returned an empty message.")
+			}
+		}
+		message = append(message, payload...)
+	}
+
+	return message[:length], nil
+}
@@ -0,0 +1,183 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestHIDFraming(t *testing.T) {
+	//The first report holds 57 bytes of the message, the following ones 59 bytes.
+	for _, test := range []struct {
+		length  int
+		reports int
+	}{
+		{1, 1},
+		{57, 1},
+		{58, 2},
+		{57 + 59, 2},
+		{57 + 59 + 1, 3},
+		{260, 5},
+	} {
+		message := bytes.Repeat([]byte{0x42}, test.length)
+		var device bytes.Buffer
+		if err := writeHID(&device, message); err != nil {
+			t.Fatalf("Writing %v bytes failed: %v\n", test.length, err)
+		}
+
+		if device.Len() != test.reports*HID_REPORT_SIZE {
+			t.Errorf("Message of %v bytes written in %v bytes instead of %v reports.\n", test.length, device.Len(), test.reports)
+		}
+		for seq := 0; seq < test.reports; seq++ {
+			report := device.Bytes()[seq*HID_REPORT_SIZE:]
+			if binary.BigEndian.Uint16(report[0:2]) != HID_CHANNEL || report[2] != HID_TAG_APDU || int(binary.BigEndian.Uint16(report[3:5])) != seq {
+				t.Errorf("Report %v of a message of %v bytes has an invalid header: %x\n", seq, test.length, report[:5])
+			}
+		}
+
+		read, err := readHID(&device)
+		if err != nil || !bytes.Equal(read, message) {
+			t.Errorf("Message of %v bytes not read back: %v\n", test.length, err)
+		}
+	}
+}
+
+func TestHIDInvalidReports(t *testing.T) {
+	message := bytes.Repeat([]byte{0x42}, 100)
+	var valid bytes.Buffer
+	writeHID(&valid, message)
+
+	for _, test := range []struct {
+		name   string
+		modify func(reports []byte) []byte
+	}{
+		{"wrong channel", func(reports []byte) []byte { reports[0] ^= 0xff; return reports }},
+		{"wrong tag", func(reports []byte) []byte { reports[2] = 0x02; return reports }},
+		{"wrong first sequence", func(reports []byte) []byte { reports[4] = 1; return reports }},
+		{"sequence gap", func(reports []byte) []byte { reports[HID_REPORT_SIZE+4] = 2; return reports }},
+		{"missing report", func(reports []byte) []byte { return reports[:HID_REPORT_SIZE] }},
+		{"truncated report", func(reports []byte) []byte { return reports[:HID_REPORT_SIZE+10] }},
+		{"empty message", func(reports []byte) []byte { reports[5], reports[6] = 0, 0; return reports }},
+	} {
+		reports := test.modify(append([]byte(nil), valid.Bytes()...))
+		if _, err := readHID(bytes.NewReader(reports)); err == nil {
+			t.Errorf("%v: Invalid reports accepted.\n", test.name)
+		}
+	}
+}
+
+//Records the APDUs and answers them with SW_OK and the number of the APDU.
+type apduRecorder struct {
+	in    []byte
+	out   bytes.Buffer
+	apdus [][]byte
+}
+
+func (device *apduRecorder) Write(report []byte) (int, error) {
+	device.in = append(device.in, report...)
+	length := int(binary.BigEndian.Uint16(device.in[5:7]))
+	if len(device.in)/HID_REPORT_SIZE*(HID_REPORT_SIZE-5) < 2+length {
+		return len(report), nil
+	}
+
+	apdu, err := readHID(bytes.NewReader(device.in))
+	device.in = nil
+	if err != nil {
+		return 0, err
+	}
+	device.apdus = append(device.apdus, apdu)
+
+	return len(report), writeHID(&device.out, []byte{byte(len(device.apdus)), 0x90, 0x00})
+}
+
+func (device *apduRecorder) Read(report []byte) (int, error) {
+	return device.out.Read(report)
+}
+
+func (device *apduRecorder) Close() error {
+	return nil
+}
+
+func TestAPDUChaining(t *testing.T) {
+	for _, test := range []struct {
+		length int
+		chunks []int
+	}{
+		{0, []int{0}},
+		{MAX_APDU_DATA, []int{MAX_APDU_DATA}},
+		{MAX_APDU_DATA + 1, []int{MAX_APDU_DATA, 1}},
+		{2*MAX_APDU_DATA + 10, []int{MAX_APDU_DATA, MAX_APDU_DATA, 10}},
+	} {
+		device := &apduRecorder{}
+		ledger := &Ledger{device: device}
+		data := make([]byte, test.length)
+		for i := range data {
+			data[i] = byte(i)
+		}
+
+		response, err := ledger.exchange(INS_SIGN_FUNDSTX, data)
+		if err != nil {
+			t.Fatalf("Exchanging %v bytes failed: %v\n", test.length, err)
+		}
+		//The response to the last APDU is returned.
+		if !bytes.Equal(response, []byte{byte(len(test.chunks))}) {
+			t.Errorf("Exchanging %v bytes returned %x.\n", test.length, response)
+		}
+
+		if len(device.apdus) != len(test.chunks) {
+			t.Fatalf("%v bytes sent in %v APDUs instead of %v.\n", test.length, len(device.apdus), len(test.chunks))
+		}
+		var sent []byte
+		for i, apdu := range device.apdus {
+			p1 := byte(P1_MORE)
+			if i == 0 {
+				p1 = P1_FIRST
+			}
+			if apdu[0] != CLA || apdu[1] != INS_SIGN_FUNDSTX || apdu[2] != p1 || apdu[3] != 0 || int(apdu[4]) != test.chunks[i] || len(apdu) != 5+test.chunks[i] {
+				t.Errorf("APDU %v of %v bytes has an invalid header: %x\n", i, test.length, apdu[:5])
+			}
+			sent = append(sent, apdu[5:]...)
+		}
+		if !bytes.Equal(sent, data) {
+			t.Errorf("APDUs do not carry the %v bytes sent.\n", test.length)
+		}
+	}
+}
+
+func TestAPDUStatus(t *testing.T) {
+	for _, test := range []struct {
+		reply []byte
+		valid bool
+	}{
+		{[]byte{0x01, 0x90, 0x00}, true},
+		{[]byte{0x90, 0x00}, true},
+		{[]byte{0x69, 0x85}, false},
+		{[]byte{0x6d, 0x00}, false},
+		{[]byte{0x90}, false},
+	} {
+		var reply bytes.Buffer
+		writeHID(&reply, test.reply)
+
+		device := &replayDevice{reply: bytes.NewReader(reply.Bytes())}
+		response, err := exchangeAPDU(device, []byte{CLA, INS_GET_PUBLIC_KEY, P1_FIRST, 0, 0})
+		if (err == nil) != test.valid {
+			t.Errorf("Reply %x: accepted %v, expected %v: %v\n", test.reply, err == nil, test.valid, err)
+		}
+		if test.valid && !bytes.Equal(response, test.reply[:len(test.reply)-2]) {
+			t.Errorf("Reply %x: the status word is not stripped: %x\n", test.reply, response)
+		}
+	}
+}
+
+//Answers with a prepared reply, whatever is written.
+type replayDevice struct {
+	reply *bytes.Reader
+}
+
+func (device *replayDevice) Write(report []byte) (int, error) {
+	return len(report), nil
+}
+
+func (device *replayDevice) Read(report []byte) (int, error) {
+	return device.reply.Read(report)
+}
@@ -0,0 +1,214 @@
+package ledger
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/karalabe/usb"
+	"golang.org/x/crypto/ed25519"
+)
+
+//Signs funds transactions on a Ledger hardware wallet running the Bazo app, the private key never leaves the device.
+//The app derives the account key at m/44'/BIP44_COIN_TYPE'/index' (the path used for mnemonic wallets, see
+//crypto/mnemonic.go), so a wallet generated with a mnemonic can be used on the device by importing the mnemonic.
+//The transaction fields are sent to the device (instead of the hash only), so the user can review the receiver, the
+//amount and the fee on the device's screen before confirming.
+//
+//Protocol of the Bazo app. Requests are APDUs: CLA, INS, P1, P2 (always 0), the length of the data (at most
+//MAX_APDU_DATA bytes) and the data. Payloads longer than that are split into several APDUs, the first one with P1 set
+//to P1_FIRST, the following ones with P1_MORE. The device answers all but the last APDU of a payload with an empty
+//response, it knows the end of the payload from the lengths encoded in it. Every response ends with a status word
+//(big endian), SW_OK on success, SW_DENIED if the user rejected the request on the device. The payloads are:
+//
+//	INS_GET_PUBLIC_KEY: path                 -> the ed25519 public key (32 bytes)
+//	INS_SIGN_FUNDSTX:   path, funds tx       -> the ed25519 signature of the tx hash (64 bytes)
+//
+//The path is the number of its elements (1 byte) followed by the hardened elements (4 bytes each, big endian). The
+//funds tx is encoded as the fields covered by FundsTx.Hash(): header (1 byte), amount (8), fee (8), txCnt (4), from
+//(32), to (32), the length of the data (4) and the data, integers big endian. The APDUs are carried in HID reports, see
+//hid.go.
+
+const (
+	LEDGER_VENDOR_ID = 0x2c97
+	//The HID interface carrying the APDUs, the device exposes others (e.g., for U2F).
+	LEDGER_USAGE_PAGE = 0xffa0
+	LEDGER_INTERFACE  = 0
+
+	CLA                = 0xba
+	INS_GET_PUBLIC_KEY = 0x02
+	INS_SIGN_FUNDSTX   = 0x04
+
+	P1_FIRST = 0x00
+	P1_MORE  = 0x80
+
+	SW_OK     = 0x9000
+	SW_DENIED = 0x6985
+
+	MAX_APDU_DATA = 255
+)
+
+type Ledger struct {
+	device io.ReadWriteCloser
+	path   []uint32
+	pubKey ed25519.PublicKey
+}
+
+//Opens the first Ledger connected and loads the public key of the wallet with the given index.
+func Open(index uint32) (*Ledger, error) {
+	if index >= crypto.HARDENED_OFFSET {
+		return nil, errors.New(fmt.Sprintf("Wallet index %v out of range.", index))
+	}
+
+	infos, err := usb.EnumerateHid(LEDGER_VENDOR_ID, 0)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Enumerating USB devices failed: %v", err))
+	}
+
+	//The usage page is only reported on Windows and macOS, the interface number only on Linux.
+	var info *usb.DeviceInfo
+	for i := range infos {
+		if infos[i].UsagePage == LEDGER_USAGE_PAGE || infos[i].Interface == LEDGER_INTERFACE {
+			info = &infos[i]
+			break
+		}
+	}
+	if info == nil {
+		return nil, errors.New("No Ledger connected.")
+	}
+
+	device, err := info.Open()
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Opening Ledger failed: %v", err))
+	}
+
+	ledger, err := newLedger(device, []uint32{crypto.BIP44_PURPOSE, crypto.BIP44_COIN_TYPE, index})
+	if err != nil {
+		device.Close()
+		return nil, err
+	}
+
+	return ledger, nil
+}
+
+//Split from Open for testing without a device.
+func newLedger(device io.ReadWriteCloser, path []uint32) (*Ledger, error) {
+	ledger := &Ledger{device: device, path: path}
+
+	pubKey, err := ledger.exchange(INS_GET_PUBLIC_KEY, ledger.encodePath())
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Loading public key from Ledger failed (is the Bazo app open?): %v", err))
+	}
+
+	if len(pubKey) != ed25519.PublicKeySize {
+		return nil, errors.New(fmt.Sprintf("Ledger returned a public key of invalid length: %v", len(pubKey)))
+	}
+	ledger.pubKey = ed25519.PublicKey(pubKey)
+
+	return ledger, nil
+}
+
+func (ledger *Ledger) PublicKey() ed25519.PublicKey {
+	return ledger.pubKey
+}
+
+//Blocks until the user confirmed or rejected the tx on the device.
+func (ledger *Ledger) SignFundsTx(tx *protocol.FundsTx) (sig [64]byte, err error) {
+	//The app does not know the lock and the gas, it would sign a hash different from the tx's.
+	if tx.LockUntil > 0 {
+		return sig, errors.New("Signing locked txs is not supported on the Ledger.")
+	}
+	if tx.HasGas() {
+		return sig, errors.New("Signing contract calls with gas is not supported on the Ledger.")
+	}
+
+	response, err := ledger.exchange(INS_SIGN_FUNDSTX, append(ledger.encodePath(), encodeFundsTx(tx)...))
+	if err != nil {
+		return sig, errors.New(fmt.Sprintf("Signing tx on Ledger failed: %v", err))
+	}
+
+	if len(response) != len(sig) {
+		return sig, errors.New(fmt.Sprintf("Ledger returned a signature of invalid length: %v", len(response)))
+	}
+	copy(sig[:], response)
+
+	return sig, nil
+}
+
+func (ledger *Ledger) Close() error {
+	return ledger.device.Close()
+}
+
+//Number of path elements followed by the (hardened) elements, big endian.
+func (ledger *Ledger) encodePath() []byte {
+	encoded := make([]byte, 1+4*len(ledger.path))
+	encoded[0] = byte(len(ledger.path))
+	for i, index := range ledger.path {
+		binary.BigEndian.PutUint32(encoded[1+4*i:], index+crypto.HARDENED_OFFSET)
+	}
+
+	return encoded
+}
+
+//The fields covered by FundsTx.Hash(), integers big endian, the data prefixed with its length.
+func encodeFundsTx(tx *protocol.FundsTx) []byte {
+	encoded := make([]byte, 1+8+8+4+32+32+4, 1+8+8+4+32+32+4+len(tx.Data))
+	encoded[0] = tx.Header
+	binary.BigEndian.PutUint64(encoded[1:9], tx.Amount)
+	binary.BigEndian.PutUint64(encoded[9:17], tx.Fee)
+	binary.BigEndian.PutUint32(encoded[17:21], tx.TxCnt)
+	copy(encoded[21:53], tx.From[:])
+	copy(encoded[53:85], tx.To[:])
+	binary.BigEndian.PutUint32(encoded[85:89], uint32(len(tx.Data)))
+
+	return append(encoded, tx.Data...)
+}
+
+//Sends the data in as many APDUs as needed and returns the response to the last one.
+func (ledger *Ledger) exchange(ins byte, data []byte) (response []byte, err error) {
+	p1 := byte(P1_FIRST)
+	for {
+		chunk := data
+		if len(chunk) > MAX_APDU_DATA {
+			chunk = chunk[:MAX_APDU_DATA]
+		}
+		data = data[len(chunk):]
+
+		apdu := append([]byte{CLA, ins, p1, 0x00, byte(len(chunk))}, chunk...)
+		if response, err = exchangeAPDU(ledger.device, apdu); err != nil {
+			return nil, err
+		}
+
+		if len(data) == 0 {
+			return response, nil
+		}
+		p1 = P1_MORE
+	}
+}
+
+func exchangeAPDU(device io.ReadWriter, apdu []byte) ([]byte, error) {
+	if err := writeHID(device, apdu); err != nil {
+		return nil, err
+	}
+
+	reply, err := readHID(device)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(reply) < 2 {
+		return nil, errors.New("Ledger returned a truncated response.")
+	}
+
+	switch status := binary.BigEndian.Uint16(reply[len(reply)-2:]); status {
+	case SW_OK:
+		return reply[:len(reply)-2], nil
+	case SW_DENIED:
+		return nil, errors.New("Rejected on the device.")
+	default:
+		return nil, errors.New(fmt.Sprintf("Ledger returned status %#04x.", status))
+	}
+}
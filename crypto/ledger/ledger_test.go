@@ -0,0 +1,133 @@
+package ledger
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"golang.org/x/crypto/ed25519"
+)
+
+//Simulates the Bazo app on a Ledger.
+type fakeLedger struct {
+	privKey ed25519.PrivateKey
+	deny    bool
+	corrupt bool
+	in      []byte
+	out     bytes.Buffer
+	payload []byte
+	apdus   int
+}
+
+func (device *fakeLedger) Write(report []byte) (int, error) {
+	device.in = append(device.in, report...)
+
+	length := int(binary.BigEndian.Uint16(device.in[5:7]))
+	if len(device.in)/HID_REPORT_SIZE*(HID_REPORT_SIZE-5) < 2+length {
+		return len(report), nil
+	}
+
+	apdu, err := readHID(bytes.NewReader(device.in))
+	device.in = nil
+	if err != nil {
+		return 0, err
+	}
+
+	return len(report), writeHID(&device.out, device.handle(apdu))
+}
+
+func (device *fakeLedger) Read(report []byte) (int, error) {
+	return device.out.Read(report)
+}
+
+func (device *fakeLedger) Close() error {
+	return nil
+}
+
+func (device *fakeLedger) handle(apdu []byte) []byte {
+	device.apdus++
+	if apdu[2] == P1_FIRST {
+		device.payload = nil
+	}
+	device.payload = append(device.payload, apdu[5:5+int(apdu[4])]...)
+
+	switch apdu[1] {
+	case INS_GET_PUBLIC_KEY:
+		return append([]byte(device.privKey.Public().(ed25519.PublicKey)), 0x90, 0x00)
+	case INS_SIGN_FUNDSTX:
+		encodedTx := device.payload[1+4*int(device.payload[0]):]
+		if len(encodedTx) < 89 || len(encodedTx) < 89+int(binary.BigEndian.Uint32(encodedTx[85:89])) {
+			return []byte{0x90, 0x00}
+		}
+
+		if device.deny {
+			return []byte{0x69, 0x85}
+		}
+
+		tx := &protocol.FundsTx{
+			Header: encodedTx[0],
+			Amount: binary.BigEndian.Uint64(encodedTx[1:9]),
+			Fee:    binary.BigEndian.Uint64(encodedTx[9:17]),
+			TxCnt:  binary.BigEndian.Uint32(encodedTx[17:21]),
+			Data:   encodedTx[89:],
+		}
+		copy(tx.From[:], encodedTx[21:53])
+		copy(tx.To[:], encodedTx[53:85])
+		txHash := tx.Hash()
+
+		sig := ed25519.Sign(device.privKey, txHash[:])
+		if device.corrupt {
+			sig[0] ^= 0xff
+		}
+
+		return append(sig, 0x90, 0x00)
+	}
+
+	return []byte{0x6d, 0x00}
+}
+
+func TestLedgerSignFundsTx(t *testing.T) {
+	_, privKey, _ := ed25519.GenerateKey(nil)
+	device := &fakeLedger{privKey: privKey}
+
+	ledger, err := newLedger(device, []uint32{44, 1, 0})
+	if err != nil {
+		t.Fatalf("Opening ledger failed: %v\n", err)
+	}
+
+	if !bytes.Equal(ledger.PublicKey(), privKey.Public().(ed25519.PublicKey)) {
+		t.Error("Ledger returned a wrong public key.")
+	}
+
+	var from [32]byte
+	copy(from[:], ledger.PublicKey())
+
+	//The data does not fit into a single APDU (nor into a single HID report).
+	data := bytes.Repeat([]byte{0x42}, 600)
+	device.apdus = 0
+	tx, err := protocol.ConstrFundsTxWithSigner(0x01, 100, 2, 7, from, [32]byte{0x02}, ledger, data)
+	if err != nil {
+		t.Fatalf("Signing tx on ledger failed: %v\n", err)
+	}
+
+	if device.apdus != 3 {
+		t.Errorf("Payload should have been split into 3 APDUs: %v\n", device.apdus)
+	}
+
+	txHash := tx.Hash()
+	if !ed25519.Verify(ledger.PublicKey(), txHash[:], tx.Sig[:]) {
+		t.Error("Signature of the ledger is invalid.")
+	}
+
+	device.deny = true
+	if _, err := protocol.ConstrFundsTxWithSigner(0x01, 100, 2, 8, from, [32]byte{0x02}, ledger, nil); err == nil {
+		t.Error("Tx rejected on the device should not be signed.")
+	}
+
+	//Invalid signatures of a faulty device are detected.
+	device.deny, device.corrupt = false, true
+	if _, err := protocol.ConstrFundsTxWithSigner(0x01, 100, 2, 8, from, [32]byte{0x02}, ledger, nil); err == nil {
+		t.Error("Invalid signature should be rejected.")
+	}
+}
@@ -0,0 +1,72 @@
+package crypto
+
+import (
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/ed25519"
+)
+
+//Wallets can be generated from a BIP39 mnemonic (24 words), such that they can be backed up by writing down the words.
+//Keys are derived from the mnemonic's seed following SLIP-0010 for ed25519, which only supports hardened derivation.
+//The key of wallet index i is derived at m/44'/BIP44_COIN_TYPE'/i', the same mnemonic always yields the same keys.
+
+const (
+	MNEMONIC_ENTROPY_BITS = 256
+
+	BIP44_PURPOSE = 44
+	//Bazo is not registered in SLIP-0044, the coin type shared by all testnets is used.
+	BIP44_COIN_TYPE = 1
+
+	HARDENED_OFFSET = 0x80000000
+)
+
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(MNEMONIC_ENTROPY_BITS)
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+//Returns the key of the wallet with the given index, the mnemonic's checksum is verified.
+func DeriveEDKeyFromMnemonic(mnemonic string, index uint32) (ed25519.PrivateKey, error) {
+	mnemonic = strings.Join(strings.Fields(mnemonic), " ")
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid mnemonic: %v", err))
+	}
+
+	return DeriveEDKeyFromSeed(seed, []uint32{BIP44_PURPOSE, BIP44_COIN_TYPE, index})
+}
+
+//Derives the key at the path (hardened indexes, without the offset) from the seed according to SLIP-0010.
+func DeriveEDKeyFromSeed(seed []byte, path []uint32) (ed25519.PrivateKey, error) {
+	mac := hmac.New(sha512.New, []byte("ed25519 seed"))
+	mac.Write(seed)
+	digest := mac.Sum(nil)
+	key, chainCode := digest[:32], digest[32:]
+
+	for _, index := range path {
+		if index >= HARDENED_OFFSET {
+			return nil, errors.New(fmt.Sprintf("Index %v out of range, ed25519 only supports hardened derivation.", index))
+		}
+
+		var data [37]byte
+		copy(data[1:33], key)
+		binary.BigEndian.PutUint32(data[33:], index+HARDENED_OFFSET)
+
+		mac = hmac.New(sha512.New, chainCode)
+		mac.Write(data[:])
+		digest = mac.Sum(nil)
+		key, chainCode = digest[:32], digest[32:]
+	}
+
+	return ed25519.NewKeyFromSeed(key), nil
+}
@@ -0,0 +1,68 @@
+package crypto
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+//Test vector 1 of SLIP-0010 for ed25519.
+func TestDeriveEDKeyFromSeed(t *testing.T) {
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	vectors := []struct {
+		path []uint32
+		key  string
+	}{
+		{[]uint32{}, "2b4be7f19ee27bbf30c667b642d5f4aa69fd169872f8fc3059c08ebae2eb19e7"},
+		{[]uint32{0}, "68e0fe46dfb67e368c75379acec591dad19df3cde26e63b93a8e704f1dade7a3"},
+		{[]uint32{0, 1}, "b1d0bad404bf35da785a64ca1ac54b2617211d2777696fbffaf208f746ae84f2"},
+		{[]uint32{0, 1, 2}, "92a5b23c0b8a99e37d07df3fb9966917f5d06e02ddbd909c7e184371463e9fc9"},
+	}
+
+	for _, vector := range vectors {
+		privKey, err := DeriveEDKeyFromSeed(seed, vector.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if key := hex.EncodeToString(privKey.Seed()); key != vector.key {
+			t.Errorf("Derived key at %v does not match: %v vs. %v\n", vector.path, key, vector.key)
+		}
+	}
+
+	if _, err := DeriveEDKeyFromSeed(seed, []uint32{HARDENED_OFFSET}); err == nil {
+		t.Error("Indexes with the hardened offset should be rejected.")
+	}
+}
+
+func TestDeriveEDKeyFromMnemonic(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if words := strings.Fields(mnemonic); len(words) != 24 {
+		t.Errorf("Mnemonic should consist of 24 words: %v\n", mnemonic)
+	}
+
+	first, _ := DeriveEDKeyFromMnemonic(mnemonic, 0)
+	//Whitespace does not matter when the mnemonic is typed in again.
+	recovered, err := DeriveEDKeyFromMnemonic(" "+strings.Replace(mnemonic, " ", "  ", -1)+"\n", 0)
+	if err != nil || !recovered.Equal(first) {
+		t.Errorf("Recovered key does not match: %v\n", err)
+	}
+
+	if second, _ := DeriveEDKeyFromMnemonic(mnemonic, 1); second.Equal(first) {
+		t.Error("Wallets with different indexes should have different keys.")
+	}
+
+	//The valid mnemonic with this entropy ends with "art".
+	invalid := strings.Repeat("abandon ", 24)
+	if _, err := DeriveEDKeyFromMnemonic(invalid, 0); err == nil {
+		t.Error("Mnemonic with invalid checksum should be rejected.")
+	}
+	if _, err := DeriveEDKeyFromMnemonic(strings.Repeat("abandon ", 23)+"art", 0); err != nil {
+		t.Errorf("Valid mnemonic was rejected: %v\n", err)
+	}
+}
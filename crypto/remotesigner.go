@@ -0,0 +1,235 @@
+package crypto
+
+import (
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"strconv"
+	"time"
+)
+
+//The commitment key can be kept on a separate (hardened) machine running a signing service, the miner then requests
+//the commitment proofs over TLS. Both sides authenticate each other with certificates signed by a common CA, so only
+//the miner can request signatures and the miner only accepts signatures from its signing service.
+//Every message consists of a type (request) or status (response) byte, the payload length (4 bytes, big endian) and
+//the payload. A connection serves a single request. The service only signs block heights, it can't be abused to sign
+//arbitrary messages with the commitment key.
+
+const (
+	SIGNER_REQ_PUBKEY = 1
+	SIGNER_REQ_SIGN   = 2
+
+	SIGNER_RES_OK    = 0
+	SIGNER_RES_ERROR = 1
+
+	SIGNER_MAX_PAYLOAD = 1024
+	SIGNER_TIMEOUT     = 10 * time.Second
+)
+
+type RemoteSigner struct {
+	address   string
+	tlsConfig *tls.Config
+	pubKey    *rsa.PublicKey
+}
+
+//Connects to the signing service at address and fetches the public commitment key.
+func NewRemoteSigner(address string, tlsConfig *tls.Config) (*RemoteSigner, error) {
+	signer := &RemoteSigner{address: address, tlsConfig: tlsConfig}
+
+	modulus, err := signer.request(SIGNER_REQ_PUBKEY, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(modulus) != COMM_KEY_LENGTH {
+		return nil, errors.New(fmt.Sprintf("Remote signer returned a public key of invalid length: %v", len(modulus)))
+	}
+
+	var fixedModulus [COMM_KEY_LENGTH]byte
+	copy(fixedModulus[:], modulus)
+	signer.pubKey, _ = CreateRSAPubKeyFromBytes(fixedModulus)
+
+	return signer, nil
+}
+
+func (signer *RemoteSigner) PublicKey() *rsa.PublicKey {
+	return signer.pubKey
+}
+
+func (signer *RemoteSigner) CommitmentKey() (key [COMM_KEY_LENGTH]byte) {
+	copy(key[:], signer.pubKey.N.Bytes())
+
+	return key
+}
+
+//Every signature is verified before it is returned, a misbehaving signing service must not make us produce invalid
+//blocks.
+func (signer *RemoteSigner) Sign(msg string) (fixedSig [COMM_PROOF_LENGTH]byte, err error) {
+	sig, err := signer.request(SIGNER_REQ_SIGN, []byte(msg))
+	if err != nil {
+		return fixedSig, err
+	}
+
+	if len(sig) != COMM_PROOF_LENGTH {
+		return fixedSig, errors.New(fmt.Sprintf("Remote signer returned a signature of invalid length: %v", len(sig)))
+	}
+	copy(fixedSig[:], sig)
+
+	if err = VerifyMessageWithRSAKey(signer.pubKey, msg, fixedSig); err != nil {
+		return fixedSig, errors.New(fmt.Sprintf("Remote signer returned an invalid signature: %v", err))
+	}
+
+	return fixedSig, nil
+}
+
+func (signer *RemoteSigner) request(reqType uint8, payload []byte) ([]byte, error) {
+	dialer := &net.Dialer{Timeout: SIGNER_TIMEOUT}
+	conn, err := tls.DialWithDialer(dialer, "tcp", signer.address, signer.tlsConfig)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Connecting to remote signer %v failed: %v", signer.address, err))
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(SIGNER_TIMEOUT))
+
+	if err = writeSignerMessage(conn, reqType, payload); err != nil {
+		return nil, errors.New(fmt.Sprintf("Sending request to remote signer failed: %v", err))
+	}
+
+	status, response, err := readSignerMessage(conn)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Receiving response from remote signer failed: %v", err))
+	}
+
+	if status != SIGNER_RES_OK {
+		return nil, errors.New(fmt.Sprintf("Remote signer failed: %s", response))
+	}
+
+	return response, nil
+}
+
+//Serves the requests of miners until the listener is closed. The listener is expected to authenticate the clients,
+//see NewSignerServerTLSConfig.
+func ServeRemoteSigner(listener net.Listener, privKey *rsa.PrivateKey) error {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			return err
+		}
+
+		go handleSignerConn(conn, privKey)
+	}
+}
+
+func handleSignerConn(conn net.Conn, privKey *rsa.PrivateKey) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(SIGNER_TIMEOUT))
+
+	reqType, payload, err := readSignerMessage(conn)
+	if err != nil {
+		return
+	}
+
+	switch reqType {
+	case SIGNER_REQ_PUBKEY:
+		writeSignerMessage(conn, SIGNER_RES_OK, privKey.N.Bytes())
+	case SIGNER_REQ_SIGN:
+		if _, err := strconv.ParseUint(string(payload), 10, 32); err != nil {
+			writeSignerMessage(conn, SIGNER_RES_ERROR, []byte("Only block heights are signed."))
+			return
+		}
+
+		sig, err := SignMessageWithRSAKey(privKey, string(payload))
+		if err != nil {
+			writeSignerMessage(conn, SIGNER_RES_ERROR, []byte(err.Error()))
+			return
+		}
+		writeSignerMessage(conn, SIGNER_RES_OK, sig[:])
+	default:
+		writeSignerMessage(conn, SIGNER_RES_ERROR, []byte(fmt.Sprintf("Unknown request type: %v", reqType)))
+	}
+}
+
+func writeSignerMessage(w io.Writer, msgType uint8, payload []byte) error {
+	message := make([]byte, 5+len(payload))
+	message[0] = msgType
+	binary.BigEndian.PutUint32(message[1:5], uint32(len(payload)))
+	copy(message[5:], payload)
+
+	_, err := w.Write(message)
+	return err
+}
+
+func readSignerMessage(r io.Reader) (msgType uint8, payload []byte, err error) {
+	var header [5]byte
+	if _, err = io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+
+	length := binary.BigEndian.Uint32(header[1:5])
+	if length > SIGNER_MAX_PAYLOAD {
+		return 0, nil, errors.New(fmt.Sprintf("Payload of %v bytes exceeds the maximum of %v bytes.", length, SIGNER_MAX_PAYLOAD))
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return 0, nil, err
+	}
+
+	return header[0], payload, nil
+}
+
+//The miner authenticates itself with the certificate in certFile and only accepts signing services with a
+//certificate signed by the CA in caFile.
+func NewSignerClientTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, caPool, err := loadSignerCertificates(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+//The signing service authenticates itself with the certificate in certFile and only accepts miners with a
+//certificate signed by the CA in caFile.
+func NewSignerServerTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, caPool, err := loadSignerCertificates(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+func loadSignerCertificates(certFile, keyFile, caFile string) (cert tls.Certificate, caPool *x509.CertPool, err error) {
+	cert, err = tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return cert, nil, errors.New(fmt.Sprintf("Could not load certificate: %v", err))
+	}
+
+	encodedCA, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return cert, nil, errors.New(fmt.Sprintf("Could not load CA certificate: %v", err))
+	}
+
+	caPool = x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(encodedCA) {
+		return cert, nil, errors.New(fmt.Sprintf("No certificate found in %v.", caFile))
+	}
+
+	return cert, caPool, nil
+}
@@ -0,0 +1,93 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+//Issues a certificate for 127.0.0.1 signed by the CA (self-signed if ca is nil).
+func newTestCertificate(t *testing.T, serial int64, ca *x509.Certificate, caKey *rsa.PrivateKey) (*x509.Certificate, tls.Certificate, *rsa.PrivateKey) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(serial),
+		Subject:               pkix.Name{CommonName: "bazo-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  ca == nil,
+	}
+
+	parent, parentKey := template, key
+	if ca != nil {
+		parent, parentKey = ca, caKey
+	}
+
+	encoded, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cert, _ := x509.ParseCertificate(encoded)
+	return cert, tls.Certificate{Certificate: [][]byte{encoded}, PrivateKey: key}, key
+}
+
+func TestRemoteSigner(t *testing.T) {
+	ca, _, caKey := newTestCertificate(t, 1, nil, nil)
+	_, serverCert, _ := newTestCertificate(t, 2, ca, caKey)
+	_, clientCert, _ := newTestCertificate(t, 3, ca, caKey)
+	caPool := x509.NewCertPool()
+	caPool.AddCert(ca)
+
+	commPrivKey, _ := rsa.GenerateMultiPrimeKey(rand.Reader, COMM_NOF_PRIMES, COMM_KEY_BITS)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{serverCert},
+		ClientCAs:    caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go ServeRemoteSigner(listener, commPrivKey)
+
+	signer, err := NewRemoteSigner(listener.Addr().String(), &tls.Config{Certificates: []tls.Certificate{clientCert}, RootCAs: caPool})
+	if err != nil {
+		t.Fatalf("Connecting to remote signer failed: %v\n", err)
+	}
+
+	if signer.PublicKey().N.Cmp(commPrivKey.N) != 0 {
+		t.Error("Remote signer returned a wrong public key.")
+	}
+
+	sig, err := signer.Sign("42")
+	if err != nil {
+		t.Fatalf("Signing block height failed: %v\n", err)
+	}
+	if localSig, _ := NewLocalSigner(commPrivKey).Sign("42"); sig != localSig {
+		t.Error("Remote signature differs from the local signature.")
+	}
+
+	if _, err = signer.Sign("Test"); err == nil {
+		t.Error("Remote signer should only sign block heights.")
+	}
+
+	//Miners without a certificate signed by the CA are rejected.
+	if _, err = NewRemoteSigner(listener.Addr().String(), &tls.Config{RootCAs: caPool}); err == nil {
+		t.Error("Remote signer should reject miners without a certificate.")
+	}
+}
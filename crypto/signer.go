@@ -0,0 +1,55 @@
+package crypto
+
+import (
+	"crypto/rsa"
+	"golang.org/x/crypto/ed25519"
+)
+
+//Signs messages (block heights) with the commitment key of a validator. The key is either held by the miner itself
+//or by a remote signing service, see remotesigner.go. The commitment key is an RSA key or, since the migration to
+//VRF proofs, an ed25519 VRF key (see vrf.go).
+type CommitmentSigner interface {
+	CommitmentKey() [COMM_KEY_LENGTH]byte
+	Sign(msg string) ([COMM_PROOF_LENGTH]byte, error)
+}
+
+type LocalSigner struct {
+	privKey *rsa.PrivateKey
+}
+
+func NewLocalSigner(privKey *rsa.PrivateKey) *LocalSigner {
+	return &LocalSigner{privKey}
+}
+
+func (signer *LocalSigner) PublicKey() *rsa.PublicKey {
+	return &signer.privKey.PublicKey
+}
+
+func (signer *LocalSigner) CommitmentKey() (key [COMM_KEY_LENGTH]byte) {
+	copy(key[:], signer.privKey.N.Bytes())
+
+	return key
+}
+
+func (signer *LocalSigner) Sign(msg string) ([COMM_PROOF_LENGTH]byte, error) {
+	return SignMessageWithRSAKey(signer.privKey, msg)
+}
+
+type VRFSigner struct {
+	privKey ed25519.PrivateKey
+}
+
+func NewVRFSigner(privKey ed25519.PrivateKey) *VRFSigner {
+	return &VRFSigner{privKey}
+}
+
+func (signer *VRFSigner) CommitmentKey() [COMM_KEY_LENGTH]byte {
+	return VRFCommitmentKey(signer.privKey.Public().(ed25519.PublicKey))
+}
+
+func (signer *VRFSigner) Sign(msg string) (fixedProof [COMM_PROOF_LENGTH]byte, err error) {
+	proof := VRFProve(signer.privKey, []byte(msg))
+	copy(fixedProof[:], proof[:])
+
+	return fixedProof, nil
+}
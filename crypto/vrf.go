@@ -0,0 +1,295 @@
+package crypto
+
+import (
+	"crypto/sha512"
+	"golang.org/x/crypto/ed25519"
+	"math/big"
+)
+
+//Verifiable random function ECVRF-EDWARDS25519-SHA512-TAI (RFC 9381) used for the commitment proofs of validators.
+//The output of a block height (see VRFProofToHash) is unique for a key, the proof is not: a validator can compute other
+//valid proofs of the same output. The proof of stake therefore hashes the output. The proofs (80 bytes) and keys (32
+//bytes) are much smaller than the 2048 bit RSA signatures and moduli. VRF keys are ed25519 keys, they are stored in the
+//usual ed25519 key files.
+//
+//The fields holding commitment keys and proofs have the length of the RSA keys, VRF keys and proofs are stored at
+//their beginning, followed by zeros. An RSA modulus or signature never ends with that many zero bytes.
+//
+//The curve arithmetic uses math/big and is not constant time, the proofs should be computed on a machine not shared
+//with untrusted code.
+
+const (
+	VRF_KEY_LENGTH   = 32
+	VRF_PROOF_LENGTH = 80
+
+	vrfSuite = 0x03
+)
+
+var (
+	//Field prime 2^255-19, group order and curve constant.
+	vrfP = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 255), big.NewInt(19))
+	vrfQ, _ = new(big.Int).SetString("7237005577332262213973186563042994240857116359379907606001950938285454250989", 10)
+	vrfD = new(big.Int).Mod(new(big.Int).Mul(big.NewInt(-121665), new(big.Int).ModInverse(big.NewInt(121666), vrfP)), vrfP)
+	//Square root of -1.
+	vrfI = new(big.Int).Exp(big.NewInt(2), new(big.Int).Div(new(big.Int).Sub(vrfP, big.NewInt(1)), big.NewInt(4)), vrfP)
+
+	vrfBase = mustDecodeVRFPoint([]byte{
+		0x58, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
+		0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66, 0x66,
+	})
+)
+
+//Point in extended coordinates (x = X/Z, y = Y/Z, x*y = T/Z).
+type vrfPoint struct {
+	x, y, z, t *big.Int
+}
+
+func newVRFPoint(x, y *big.Int) *vrfPoint {
+	return &vrfPoint{x, y, big.NewInt(1), vrfMod(new(big.Int).Mul(x, y))}
+}
+
+func vrfMod(value *big.Int) *big.Int {
+	return value.Mod(value, vrfP)
+}
+
+//Complete addition for a = -1, also used for doubling.
+func (p1 *vrfPoint) add(p2 *vrfPoint) *vrfPoint {
+	a := vrfMod(new(big.Int).Mul(new(big.Int).Sub(p1.y, p1.x), new(big.Int).Sub(p2.y, p2.x)))
+	b := vrfMod(new(big.Int).Mul(new(big.Int).Add(p1.y, p1.x), new(big.Int).Add(p2.y, p2.x)))
+	c := vrfMod(new(big.Int).Mul(new(big.Int).Mul(p1.t, p2.t), new(big.Int).Lsh(vrfD, 1)))
+	d := vrfMod(new(big.Int).Lsh(new(big.Int).Mul(p1.z, p2.z), 1))
+	e := new(big.Int).Sub(b, a)
+	f := new(big.Int).Sub(d, c)
+	g := new(big.Int).Add(d, c)
+	h := new(big.Int).Add(b, a)
+
+	return &vrfPoint{
+		vrfMod(new(big.Int).Mul(e, f)),
+		vrfMod(new(big.Int).Mul(g, h)),
+		vrfMod(new(big.Int).Mul(f, g)),
+		vrfMod(new(big.Int).Mul(e, h)),
+	}
+}
+
+func (p *vrfPoint) neg() *vrfPoint {
+	return &vrfPoint{vrfMod(new(big.Int).Neg(p.x)), p.y, p.z, vrfMod(new(big.Int).Neg(p.t))}
+}
+
+func (p *vrfPoint) isNeutral() bool {
+	return vrfMod(new(big.Int).Set(p.x)).Sign() == 0 && vrfMod(new(big.Int).Sub(p.y, p.z)).Sign() == 0
+}
+
+func (p *vrfPoint) mul(scalar *big.Int) *vrfPoint {
+	result := newVRFPoint(big.NewInt(0), big.NewInt(1))
+	for i := scalar.BitLen() - 1; i >= 0; i-- {
+		result = result.add(result)
+		if scalar.Bit(i) == 1 {
+			result = result.add(p)
+		}
+	}
+
+	return result
+}
+
+//Encoding of RFC 8032: y in little endian, the highest bit is the lowest bit of x.
+func (p *vrfPoint) encode() []byte {
+	zInv := new(big.Int).ModInverse(p.z, vrfP)
+	x := vrfMod(new(big.Int).Mul(p.x, zInv))
+	y := vrfMod(new(big.Int).Mul(p.y, zInv))
+
+	encoded := littleEndian(y, 32)
+	encoded[31] |= byte(x.Bit(0) << 7)
+
+	return encoded
+}
+
+//Returns nil if encoded is not a point on the curve.
+func decodeVRFPoint(encoded []byte) *vrfPoint {
+	if len(encoded) != 32 {
+		return nil
+	}
+
+	yBytes := append([]byte{}, encoded...)
+	sign := yBytes[31] >> 7
+	yBytes[31] &= 0x7f
+	y := fromLittleEndian(yBytes)
+	if y.Cmp(vrfP) >= 0 {
+		return nil
+	}
+
+	//x^2 = (y^2 - 1) / (d*y^2 + 1)
+	y2 := vrfMod(new(big.Int).Mul(y, y))
+	u := vrfMod(new(big.Int).Sub(y2, big.NewInt(1)))
+	v := vrfMod(new(big.Int).Add(new(big.Int).Mul(vrfD, y2), big.NewInt(1)))
+	x2 := vrfMod(new(big.Int).Mul(u, new(big.Int).ModInverse(v, vrfP)))
+
+	x := new(big.Int).Exp(x2, new(big.Int).Div(new(big.Int).Add(vrfP, big.NewInt(3)), big.NewInt(8)), vrfP)
+	if vrfMod(new(big.Int).Mul(x, x)).Cmp(x2) != 0 {
+		x = vrfMod(x.Mul(x, vrfI))
+	}
+	if vrfMod(new(big.Int).Mul(x, x)).Cmp(x2) != 0 {
+		return nil
+	}
+	if x.Sign() == 0 && sign == 1 {
+		return nil
+	}
+	if x.Bit(0) != uint(sign) {
+		x.Sub(vrfP, x)
+	}
+
+	return newVRFPoint(x, y)
+}
+
+func mustDecodeVRFPoint(encoded []byte) *vrfPoint {
+	point := decodeVRFPoint(encoded)
+	if point == nil {
+		panic("Invalid curve point.")
+	}
+
+	return point
+}
+
+func littleEndian(value *big.Int, length int) []byte {
+	bigEndian := value.FillBytes(make([]byte, length))
+	for i, j := 0, length-1; i < j; i, j = i+1, j-1 {
+		bigEndian[i], bigEndian[j] = bigEndian[j], bigEndian[i]
+	}
+
+	return bigEndian
+}
+
+func fromLittleEndian(encoded []byte) *big.Int {
+	bigEndian := make([]byte, len(encoded))
+	for i := range encoded {
+		bigEndian[len(encoded)-1-i] = encoded[i]
+	}
+
+	return new(big.Int).SetBytes(bigEndian)
+}
+
+//Hashes the public key and the message to a point (try and increment).
+func vrfHashToCurve(pubKey []byte, msg []byte) *vrfPoint {
+	for ctr := 0; ctr < 256; ctr++ {
+		hash := sha512.New()
+		hash.Write([]byte{vrfSuite, 0x01})
+		hash.Write(pubKey)
+		hash.Write(msg)
+		hash.Write([]byte{byte(ctr), 0x00})
+		if point := decodeVRFPoint(hash.Sum(nil)[:32]); point != nil {
+			//Multiplied by the cofactor.
+			return point.mul(big.NewInt(8))
+		}
+	}
+
+	return nil
+}
+
+func vrfChallenge(points ...*vrfPoint) *big.Int {
+	hash := sha512.New()
+	hash.Write([]byte{vrfSuite, 0x02})
+	for _, point := range points {
+		hash.Write(point.encode())
+	}
+	hash.Write([]byte{0x00})
+
+	return fromLittleEndian(hash.Sum(nil)[:16])
+}
+
+//Returns the proof for msg, the proof is unique for the key and the message.
+func VRFProve(privKey ed25519.PrivateKey, msg []byte) (proof [VRF_PROOF_LENGTH]byte) {
+	hashedKey := sha512.Sum512(privKey.Seed())
+	hashedKey[0] &= 248
+	hashedKey[31] &= 127
+	hashedKey[31] |= 64
+	x := fromLittleEndian(hashedKey[:32])
+
+	pubKey := privKey.Public().(ed25519.PublicKey)
+	h := vrfHashToCurve(pubKey, msg)
+	gamma := h.mul(x)
+
+	nonceHash := sha512.New()
+	nonceHash.Write(hashedKey[32:])
+	nonceHash.Write(h.encode())
+	k := new(big.Int).Mod(fromLittleEndian(nonceHash.Sum(nil)), vrfQ)
+
+	c := vrfChallenge(decodeVRFPoint(pubKey), h, gamma, vrfBase.mul(k), h.mul(k))
+	s := new(big.Int).Mod(new(big.Int).Add(k, new(big.Int).Mul(c, x)), vrfQ)
+
+	copy(proof[:32], gamma.encode())
+	copy(proof[32:48], littleEndian(c, 16))
+	copy(proof[48:], littleEndian(s, 32))
+
+	return proof
+}
+
+//Returns false for keys which are no curve point or of small order. The proofs of a small order key do not depend on a
+//secret, they are rejected as required by RFC 9381.
+func ValidVRFKey(pubKey ed25519.PublicKey) bool {
+	y := decodeVRFPoint(pubKey)
+
+	return y != nil && !y.mul(big.NewInt(8)).isNeutral()
+}
+
+func VRFVerify(pubKey ed25519.PublicKey, msg []byte, proof [VRF_PROOF_LENGTH]byte) bool {
+	if !ValidVRFKey(pubKey) {
+		return false
+	}
+
+	y := decodeVRFPoint(pubKey)
+	gamma := decodeVRFPoint(proof[:32])
+	if gamma == nil {
+		return false
+	}
+
+	c := fromLittleEndian(proof[32:48])
+	s := fromLittleEndian(proof[48:])
+	if s.Cmp(vrfQ) >= 0 {
+		return false
+	}
+
+	h := vrfHashToCurve(pubKey, msg)
+	if h == nil {
+		return false
+	}
+
+	//U = s*B - c*Y, V = s*H - c*Gamma
+	u := vrfBase.mul(s).add(y.mul(c).neg())
+	v := h.mul(s).add(gamma.mul(c).neg())
+
+	return vrfChallenge(y, h, gamma, u, v).Cmp(c) == 0
+}
+
+//Returns the VRF output of a valid proof.
+func VRFProofToHash(proof [VRF_PROOF_LENGTH]byte) [64]byte {
+	gamma := decodeVRFPoint(proof[:32])
+	if gamma == nil {
+		return [64]byte{}
+	}
+
+	return sha512.Sum512(append(append([]byte{vrfSuite, 0x03}, gamma.mul(big.NewInt(8)).encode()...), 0x00))
+}
+
+//Returns the VRF key in a commitment key field.
+func VRFCommitmentKey(pubKey ed25519.PublicKey) (key [COMM_KEY_LENGTH]byte) {
+	copy(key[:], pubKey)
+
+	return key
+}
+
+func IsVRFCommitmentKey(key [COMM_KEY_LENGTH]byte) bool {
+	return key != [COMM_KEY_LENGTH]byte{} && isZero(key[VRF_KEY_LENGTH:])
+}
+
+func IsVRFCommitmentProof(proof [COMM_PROOF_LENGTH]byte) bool {
+	return proof != [COMM_PROOF_LENGTH]byte{} && isZero(proof[VRF_PROOF_LENGTH:])
+}
+
+func isZero(value []byte) bool {
+	for _, b := range value {
+		if b != 0 {
+			return false
+		}
+	}
+
+	return true
+}
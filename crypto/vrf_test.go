@@ -0,0 +1,84 @@
+package crypto
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+//Example 16 of RFC 9381 (ECVRF-EDWARDS25519-SHA512-TAI).
+func TestVRFTestVector(t *testing.T) {
+	seed, _ := hex.DecodeString("9d61b19deffd5a60ba844af492ec2cc44449c5697b326919703bac031cae7f60")
+	privKey := ed25519.NewKeyFromSeed(seed)
+
+	proof := VRFProve(privKey, []byte{})
+	if hex.EncodeToString(proof[:]) != "8657106690b5526245a92b003bb079ccd1a92130477671f6fc01ad16f26f723f26f8a57ccaed74ee1b190bed1f479d9727d2d0f9b005a6e456a35d4fb0daab1268a1b0db10836d9826a528ca76567805" {
+		t.Errorf("Unexpected proof: %x\n", proof)
+	}
+
+	output := VRFProofToHash(proof)
+	if hex.EncodeToString(output[:]) != "90cf1df3b703cce59e2a35b925d411164068269d7b2d29f3301c03dd757876ff66b71dda49d2de59d03450451af026798e8f81cd2e333de5cdf4f3e140fdd8ae" {
+		t.Errorf("Unexpected output: %x\n", output)
+	}
+
+	if !VRFVerify(privKey.Public().(ed25519.PublicKey), []byte{}, proof) {
+		t.Error("Valid proof was rejected.")
+	}
+}
+
+func TestVRFVerify(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	otherPubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+
+	proof := VRFProve(privKey, []byte("42"))
+	if !VRFVerify(pubKey, []byte("42"), proof) {
+		t.Fatal("Valid proof was rejected.")
+	}
+
+	if VRFVerify(pubKey, []byte("43"), proof) || VRFVerify(otherPubKey, []byte("42"), proof) {
+		t.Error("Proof was accepted for another message or key.")
+	}
+
+	proof[50] ^= 0x01
+	if VRFVerify(pubKey, []byte("42"), proof) {
+		t.Error("Tampered proof was accepted.")
+	}
+}
+
+func TestValidVRFKey(t *testing.T) {
+	pubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	if !ValidVRFKey(pubKey) {
+		t.Error("Valid key was rejected.")
+	}
+
+	//The neutral element and a point of order 4.
+	neutral := make([]byte, 32)
+	neutral[0] = 0x01
+	order4 := make([]byte, 32)
+	if ValidVRFKey(neutral) || ValidVRFKey(order4) {
+		t.Error("Small order key was accepted.")
+	}
+	if VRFVerify(neutral, []byte("42"), [VRF_PROOF_LENGTH]byte{}) {
+		t.Error("Proof of a small order key was accepted.")
+	}
+}
+
+func TestVRFSigner(t *testing.T) {
+	_, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	signer := NewVRFSigner(privKey)
+
+	key := signer.CommitmentKey()
+	proof, _ := signer.Sign("7")
+	if !IsVRFCommitmentKey(key) || !IsVRFCommitmentProof(proof) {
+		t.Fatal("VRF key or proof not recognized.")
+	}
+
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, COMM_KEY_BITS)
+	rsaSig, _ := SignMessageWithRSAKey(rsaKey, "7")
+	if IsVRFCommitmentKey(NewLocalSigner(rsaKey).CommitmentKey()) || IsVRFCommitmentProof(rsaSig) {
+		t.Error("RSA key or signature taken for a VRF key or proof.")
+	}
+}
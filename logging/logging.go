@@ -0,0 +1,314 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//Leveled logging with one logger per module (miner, p2p, storage, ...). Entries can carry structured fields (e.g.,
+//height, txhash, peer) which are appended as key=value pairs. The level of every module can be changed at runtime.
+//Entries are written to stdout and to the log file, which is rotated once it exceeds its maximum size.
+//
+//An entry looks like:
+//2019-05-02T14:03:11.123456Z INFO  [miner] block.go:412: Block validated. hash=5f1e... height=42
+
+type Level uint8
+
+const (
+	DEBUG Level = iota
+	INFO
+	WARN
+	ERROR
+)
+
+const (
+	LOG_FILE_DEFAULT        = "LoggerMiner.log"
+	LOG_MAX_SIZE_DEFAULT    = 100 << 20
+	LOG_MAX_BACKUPS_DEFAULT = 5
+	LOG_LEVEL_DEFAULT       = "info"
+)
+
+var levelNames = map[Level]string{
+	DEBUG: "DEBUG",
+	INFO:  "INFO",
+	WARN:  "WARN",
+	ERROR: "ERROR",
+}
+
+func (level Level) String() string {
+	if name, exists := levelNames[level]; exists {
+		return name
+	}
+
+	return fmt.Sprintf("LEVEL(%v)", uint8(level))
+}
+
+//Accepts the level names case-insensitively.
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if strings.EqualFold(name, levelName) {
+			return level, nil
+		}
+	}
+
+	return 0, errors.New(fmt.Sprintf("Unknown log level: %v", name))
+}
+
+type Fields map[string]interface{}
+
+type Logger struct {
+	module string
+	fields Fields
+}
+
+var (
+	output       io.Writer = os.Stdout
+	logFile      *rotatingFile
+	defaultLevel = INFO
+	levels       = make(map[string]Level)
+	loggers      = make(map[string]*Logger)
+	loggingMutex = &sync.Mutex{}
+)
+
+//Writes all entries to stdout and to file (if not empty), which is rotated once it exceeds maxSize bytes, keeping
+//maxBackups rotated files. Messages logged with the standard library's log package end up in the same output.
+func Init(file string, maxSize int64, maxBackups int) error {
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	if logFile != nil {
+		logFile.Close()
+		logFile = nil
+	}
+	output = os.Stdout
+
+	if len(file) > 0 {
+		rotating, err := openRotatingFile(file, maxSize, maxBackups)
+		if err != nil {
+			return err
+		}
+		logFile = rotating
+		output = io.MultiWriter(os.Stdout, rotating)
+	}
+	log.SetOutput(output)
+
+	return nil
+}
+
+//Loggers are shared, calling GetLogger twice with the same module returns the same logger.
+func GetLogger(module string) *Logger {
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	if logger, exists := loggers[module]; exists {
+		return logger
+	}
+	logger := &Logger{module: module}
+	loggers[module] = logger
+
+	return logger
+}
+
+//Sets the level of a single module, or the default level of all modules without their own level if module is empty.
+func SetLevel(module string, level Level) {
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	if len(module) == 0 {
+		defaultLevel = level
+	} else {
+		levels[module] = level
+	}
+}
+
+//Parses a comma separated list of levels, e.g., "info,p2p=debug,storage=warn". An entry without a module sets the
+//default level. Nothing is changed if the list contains an invalid entry.
+func SetLevels(spec string) error {
+	newDefault := GetLevel("")
+	newLevels := make(map[string]Level)
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if len(entry) == 0 {
+			continue
+		}
+
+		module, levelName := "", entry
+		if i := strings.Index(entry, "="); i >= 0 {
+			module, levelName = strings.TrimSpace(entry[:i]), strings.TrimSpace(entry[i+1:])
+			if len(module) == 0 {
+				return errors.New(fmt.Sprintf("Missing module in log level %v.", entry))
+			}
+		}
+
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return err
+		}
+
+		if len(module) == 0 {
+			newDefault = level
+		} else {
+			newLevels[module] = level
+		}
+	}
+
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	defaultLevel = newDefault
+	for module, level := range newLevels {
+		levels[module] = level
+	}
+
+	return nil
+}
+
+//Returns the level of module, or the default level if module is empty or has no level of its own.
+func GetLevel(module string) Level {
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	return levelOf(module)
+}
+
+//Returns the levels in the format accepted by SetLevels.
+func Levels() string {
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	var modules []string
+	for module := range levels {
+		modules = append(modules, module)
+	}
+	sort.Strings(modules)
+
+	spec := strings.ToLower(defaultLevel.String())
+	for _, module := range modules {
+		spec += fmt.Sprintf(",%v=%v", module, strings.ToLower(levels[module].String()))
+	}
+
+	return spec
+}
+
+func levelOf(module string) Level {
+	if level, exists := levels[module]; exists {
+		return level
+	}
+
+	return defaultLevel
+}
+
+//Returns a logger of the same module which adds fields to every entry, in addition to the fields already set.
+func (logger *Logger) WithFields(fields Fields) *Logger {
+	merged := make(Fields)
+	for key, value := range logger.fields {
+		merged[key] = value
+	}
+	for key, value := range fields {
+		merged[key] = value
+	}
+
+	return &Logger{module: logger.module, fields: merged}
+}
+
+func (logger *Logger) WithField(key string, value interface{}) *Logger {
+	return logger.WithFields(Fields{key: value})
+}
+
+func (logger *Logger) Enabled(level Level) bool {
+	return GetLevel(logger.module) <= level
+}
+
+func (logger *Logger) Debugf(format string, v ...interface{}) {
+	logger.log(false, DEBUG, fmt.Sprintf(format, v...))
+}
+
+func (logger *Logger) Infof(format string, v ...interface{}) {
+	logger.log(false, INFO, fmt.Sprintf(format, v...))
+}
+
+func (logger *Logger) Warnf(format string, v ...interface{}) {
+	logger.log(false, WARN, fmt.Sprintf(format, v...))
+}
+
+func (logger *Logger) Errorf(format string, v ...interface{}) {
+	logger.log(false, ERROR, fmt.Sprintf(format, v...))
+}
+
+//Printf, Println and Print log at level INFO, so the logger can be used wherever a log.Logger was used before.
+func (logger *Logger) Printf(format string, v ...interface{}) {
+	logger.log(false, INFO, fmt.Sprintf(format, v...))
+}
+
+func (logger *Logger) Println(v ...interface{}) {
+	logger.log(false, INFO, fmt.Sprintln(v...))
+}
+
+func (logger *Logger) Print(v ...interface{}) {
+	logger.log(false, INFO, fmt.Sprint(v...))
+}
+
+//Fatal and Fatalf log at level ERROR regardless of the module's level and exit the program.
+func (logger *Logger) Fatal(v ...interface{}) {
+	logger.log(true, ERROR, fmt.Sprint(v...))
+	os.Exit(1)
+}
+
+func (logger *Logger) Fatalf(format string, v ...interface{}) {
+	logger.log(true, ERROR, fmt.Sprintf(format, v...))
+	os.Exit(1)
+}
+
+//Entries below the module's level are dropped unless force is set.
+func (logger *Logger) log(force bool, level Level, msg string) {
+	if !force && !logger.Enabled(level) {
+		return
+	}
+
+	//Skip log and the exported logging function.
+	caller := "???:0"
+	if _, file, line, ok := runtime.Caller(2); ok {
+		caller = fmt.Sprintf("%v:%v", filepath.Base(file), line)
+	}
+
+	entry := fmt.Sprintf("%v %-5v [%v] %v: %v", time.Now().UTC().Format("2006-01-02T15:04:05.000000Z"), level, logger.module, caller, strings.TrimRight(msg, "\n"))
+	entry += formatFields(logger.fields) + "\n"
+
+	loggingMutex.Lock()
+	defer loggingMutex.Unlock()
+
+	output.Write([]byte(entry))
+}
+
+//Fields are sorted by key, so entries with the same fields always look the same.
+func formatFields(fields Fields) (formatted string) {
+	var keys []string
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := fields[key]
+		switch value.(type) {
+		case [32]byte:
+			hash := value.([32]byte)
+			value = fmt.Sprintf("%x", hash[0:8])
+		case []byte:
+			value = fmt.Sprintf("%x", value)
+		}
+		formatted += fmt.Sprintf(" %v=%v", key, value)
+	}
+
+	return formatted
+}
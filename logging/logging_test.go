@@ -0,0 +1,108 @@
+package logging
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func captureOutput(f func()) string {
+	buffer := new(bytes.Buffer)
+	loggingMutex.Lock()
+	previous := output
+	output = buffer
+	loggingMutex.Unlock()
+
+	f()
+
+	loggingMutex.Lock()
+	output = previous
+	loggingMutex.Unlock()
+
+	return buffer.String()
+}
+
+func TestLevels(t *testing.T) {
+	defer SetLevels("info")
+	logger := GetLogger("leveltest")
+
+	if err := SetLevels("warn,leveltest=debug,other=error"); err != nil {
+		t.Fatalf("Valid levels rejected: %v\n", err)
+	}
+	if GetLevel("leveltest") != DEBUG || GetLevel("other") != ERROR || GetLevel("unknown") != WARN {
+		t.Errorf("Levels not set: %v\n", Levels())
+	}
+	if Levels() != "warn,leveltest=debug,other=error" {
+		t.Errorf("Unexpected levels: %v\n", Levels())
+	}
+
+	//Nothing is changed if an entry is invalid.
+	if err := SetLevels("error,leveltest=verbose"); err == nil || GetLevel("") != WARN {
+		t.Error("Invalid levels accepted.")
+	}
+
+	out := captureOutput(func() {
+		logger.Debugf("debug entry")
+		GetLogger("other").Warnf("suppressed entry")
+	})
+	if !strings.Contains(out, "DEBUG [leveltest]") || !strings.Contains(out, "debug entry") || strings.Contains(out, "suppressed") {
+		t.Errorf("Unexpected output: %v\n", out)
+	}
+
+	SetLevel("leveltest", ERROR)
+	if out = captureOutput(func() { logger.Printf("info entry") }); len(out) > 0 {
+		t.Errorf("Entry below the module's level logged: %v\n", out)
+	}
+}
+
+func TestFields(t *testing.T) {
+	logger := GetLogger("fieldtest").WithFields(Fields{"height": 42, "txhash": [32]byte{0xab, 0xcd}})
+
+	out := captureOutput(func() {
+		logger.WithField("peer", "127.0.0.1:8000").Infof("Message with fields.\n")
+	})
+
+	if !strings.HasSuffix(out, "Message with fields. height=42 peer=127.0.0.1:8000 txhash=abcd000000000000\n") {
+		t.Errorf("Fields not formatted correctly: %v\n", out)
+	}
+
+	if !strings.Contains(out, "logging_test.go:") {
+		t.Errorf("Caller not logged: %v\n", out)
+	}
+
+	//The fields of the parent logger are not modified.
+	if len(GetLogger("fieldtest").fields) != 0 || len(logger.fields) != 2 {
+		t.Error("Fields of the parent logger modified.")
+	}
+}
+
+func TestRotation(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "bazo-logging")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.log")
+
+	rotating, err := openRotatingFile(path, 10, 2)
+	if err != nil {
+		t.Fatalf("Could not open log file: %v\n", err)
+	}
+	defer rotating.Close()
+
+	for _, entry := range []string{"aaaaaaaa\n", "bbbbbbbb\n", "cccccccc\n", "dddddddd\n"} {
+		if _, err := rotating.Write([]byte(entry)); err != nil {
+			t.Fatalf("Write failed: %v\n", err)
+		}
+	}
+
+	for file, expected := range map[string]string{path: "dddddddd\n", path + ".1": "cccccccc\n", path + ".2": "bbbbbbbb\n"} {
+		if content, _ := ioutil.ReadFile(file); string(content) != expected {
+			t.Errorf("Unexpected content of %v: %q instead of %q\n", file, content, expected)
+		}
+	}
+
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("More backups kept than configured.")
+	}
+}
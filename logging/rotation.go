@@ -0,0 +1,96 @@
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+//Once the log file exceeds maxSize, it is renamed to <file>.1, existing backups are shifted (<file>.1 becomes
+//<file>.2 etc.) and the oldest backup beyond maxBackups is removed. Not safe for concurrent use, writes are guarded by
+//loggingMutex.
+
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	rotating := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := rotating.open(); err != nil {
+		return nil, err
+	}
+
+	return rotating, nil
+}
+
+func (rotating *rotatingFile) open() error {
+	file, err := os.OpenFile(rotating.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0666)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Could not open log file %v: %v", rotating.path, err))
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return errors.New(fmt.Sprintf("Could not open log file %v: %v", rotating.path, err))
+	}
+	rotating.file = file
+	rotating.size = info.Size()
+
+	return nil
+}
+
+func (rotating *rotatingFile) Write(p []byte) (n int, err error) {
+	if rotating.file == nil {
+		return 0, errors.New("Log file is closed.")
+	}
+
+	//A single entry larger than maxSize is written to an empty file rather than rotating forever.
+	if rotating.maxSize > 0 && rotating.size > 0 && rotating.size+int64(len(p)) > rotating.maxSize {
+		if err := rotating.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = rotating.file.Write(p)
+	rotating.size += int64(n)
+
+	return n, err
+}
+
+func (rotating *rotatingFile) rotate() error {
+	rotating.file.Close()
+	rotating.file = nil
+
+	if rotating.maxBackups > 0 {
+		os.Remove(backupName(rotating.path, rotating.maxBackups))
+		for i := rotating.maxBackups - 1; i > 0; i-- {
+			os.Rename(backupName(rotating.path, i), backupName(rotating.path, i+1))
+		}
+		if err := os.Rename(rotating.path, backupName(rotating.path, 1)); err != nil {
+			return errors.New(fmt.Sprintf("Could not rotate log file %v: %v", rotating.path, err))
+		}
+	} else if err := os.Remove(rotating.path); err != nil {
+		return errors.New(fmt.Sprintf("Could not rotate log file %v: %v", rotating.path, err))
+	}
+
+	return rotating.open()
+}
+
+func (rotating *rotatingFile) Close() error {
+	if rotating.file == nil {
+		return nil
+	}
+	err := rotating.file.Close()
+	rotating.file = nil
+
+	return err
+}
+
+func backupName(path string, i int) string {
+	return fmt.Sprintf("%v.%v", path, i)
+}
@@ -2,13 +2,14 @@ package main
 
 import (
 	"github.com/bazo-blockchain/bazo-miner/cli"
-	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/bazo-blockchain/bazo-miner/logging"
 	cli2 "github.com/urfave/cli"
 	"os"
 )
 
 func main() {
-	logger := storage.InitLogger()
+	logging.Init(logging.LOG_FILE_DEFAULT, logging.LOG_MAX_SIZE_DEFAULT, logging.LOG_MAX_BACKUPS_DEFAULT)
+	logger := logging.GetLogger("cli")
 
 	app := cli2.NewApp()
 
@@ -19,7 +20,22 @@ func main() {
 	app.Commands = []cli2.Command {
 		cli.GetStartCommand(logger),
 		cli.GetGenerateWalletCommand(),
+		cli.GetRecoverWalletCommand(),
+		cli.GetSendFundsCommand(),
 		cli.GetGenerateCommitmentCommand(),
+		cli.GetSignerCommand(),
+		cli.GetEncryptKeyCommand(),
+		cli.GetBackupCommand(),
+		cli.GetSnapshotCommand(),
+		cli.GetStatusCommand(),
+		cli.GetMigrateCommand(),
+		cli.GetDbCommand(),
+		cli.GetDecryptIotCommand(),
+		cli.GetRegisterDeviceCommand(),
+		cli.GetAccountTxsCommand(),
+		cli.GetStakeCommand(),
+		cli.GetChainCommand(),
+		cli.GetBuildGenesisCommand(),
 	}
 
 	err := app.Run(os.Args)
@@ -0,0 +1,75 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//The account history index lists the txs affecting an account (see storage.ReadAccountTxs), so wallets and explorers
+//do not have to scan every closed block. It is built in postValidate, also when replaying the chain, and the entries
+//of a block are removed when the block is rolled back. Aggregated fundsTxs are indexed like standalone fundsTxs, the
+//aggTx itself is not indexed.
+
+const (
+	ACCOUNT_TXS_PAGE_SIZE     = 50
+	ACCOUNT_TXS_MAX_PAGE_SIZE = 1000
+)
+
+//Returns the hashes of the accounts affected by the tx, as used as keys of the state.
+func affectedAccounts(tx protocol.Transaction) (accounts [][32]byte) {
+	switch tx := tx.(type) {
+	case *protocol.FundsTx:
+		accounts = [][32]byte{tx.From, tx.To}
+	case *protocol.AccTx:
+		accounts = [][32]byte{protocol.SerializeHashContent(tx.PubKey), tx.Issuer}
+	case *protocol.StakeTx:
+		accounts = [][32]byte{tx.Account}
+	case *protocol.IotTx:
+		accounts = [][32]byte{tx.From, tx.To}
+	case *protocol.ContractTx:
+		accounts = [][32]byte{tx.From}
+	}
+
+	//Txs to the sender itself are listed once.
+	if len(accounts) == 2 && accounts[0] == accounts[1] {
+		accounts = accounts[:1]
+	}
+
+	return accounts
+}
+
+func blockTxs(data blockData) (txs []protocol.Transaction) {
+	for _, tx := range data.accTxSlice {
+		txs = append(txs, tx)
+	}
+	for _, tx := range data.fundsTxSlice {
+		txs = append(txs, tx)
+	}
+	for _, tx := range data.stakeTxSlice {
+		txs = append(txs, tx)
+	}
+	for _, tx := range data.iotTxSlice {
+		txs = append(txs, tx)
+	}
+	for _, tx := range data.contractTxSlice {
+		txs = append(txs, tx)
+	}
+
+	return txs
+}
+
+func writeAccountHistory(batch *storage.Batch, data blockData) {
+	for _, tx := range blockTxs(data) {
+		for _, account := range affectedAccounts(tx) {
+			batch.WriteAccountTx(account, data.block.Height, tx.Hash())
+		}
+	}
+}
+
+func deleteAccountHistory(batch *storage.Batch, data blockData) {
+	for _, tx := range blockTxs(data) {
+		for _, account := range affectedAccounts(tx) {
+			batch.DeleteAccountTx(account, data.block.Height, tx.Hash())
+		}
+	}
+}
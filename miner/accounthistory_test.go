@@ -0,0 +1,96 @@
+package miner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestAffectedAccounts(t *testing.T) {
+	from, to := [32]byte{0xa1}, [32]byte{0xa2}
+
+	tests := []struct {
+		tx       protocol.Transaction
+		accounts [][32]byte
+	}{
+		{&protocol.FundsTx{From: from, To: to}, [][32]byte{from, to}},
+		{&protocol.FundsTx{From: from, To: from}, [][32]byte{from}},
+		{&protocol.AccTx{PubKey: to, Issuer: from}, [][32]byte{protocol.SerializeHashContent(to), from}},
+		{&protocol.StakeTx{Account: from}, [][32]byte{from}},
+		{&protocol.IotTx{From: from, To: to}, [][32]byte{from, to}},
+		{&protocol.ContractTx{From: from}, [][32]byte{from}},
+		{&protocol.ConfigTx{}, nil},
+	}
+
+	for _, test := range tests {
+		if accounts := affectedAccounts(test.tx); !reflect.DeepEqual(accounts, test.accounts) {
+			t.Errorf("Affected accounts of %T: %x instead of %x\n", test.tx, accounts, test.accounts)
+		}
+	}
+}
+
+func TestAccountHistory(t *testing.T) {
+	sender, receiver := [32]byte{0xa3}, [32]byte{0xa4}
+	var blocks []blockData
+	for height := uint32(1); height <= 3; height++ {
+		data := blockData{block: &protocol.Block{Height: height}}
+		for txCnt := uint32(0); txCnt < height; txCnt++ {
+			data.fundsTxSlice = append(data.fundsTxSlice, &protocol.FundsTx{From: sender, To: receiver, TxCnt: txCnt, Amount: uint64(height)})
+		}
+		blocks = append(blocks, data)
+
+		batch := storage.NewBatch()
+		writeAccountHistory(batch, data)
+		batch.Commit()
+	}
+
+	if txs := storage.ReadAccountTxs(receiver, ^uint32(0), 100); len(txs) != 6 || txs[0].Height != 3 || txs[5].Height != 1 {
+		t.Errorf("Unexpected history of the receiver: %v\n", txs)
+	}
+
+	params, _ := json.Marshal(rpcGetAccountTxsParams{Address: hex.EncodeToString(sender[:]), Limit: 2})
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getAccountTxs", Params: params, Id: 1})
+	if response.Error != nil {
+		t.Fatalf("Querying account txs failed: %v\n", response.Error.Message)
+	}
+
+	//The page ends after the three txs of the last block.
+	page := response.Result.(rpcAccountTxs)
+	txHash := blocks[2].fundsTxSlice[0].Hash()
+	if len(page.Txs) != 3 || page.Next == nil || *page.Next != 2 || page.Txs[0].Height != 3 {
+		t.Errorf("Unexpected first page: %v\n", page)
+	}
+	if !containsAccountTx(page, hex.EncodeToString(txHash[:])) {
+		t.Errorf("Tx %x missing on the first page: %v\n", txHash[0:8], page)
+	}
+
+	//Rolled back blocks are removed from the history.
+	for _, data := range blocks {
+		batch := storage.NewBatch()
+		deleteAccountHistory(batch, data)
+		batch.Commit()
+	}
+
+	if txs := storage.ReadAccountTxs(sender, ^uint32(0), 100); len(txs) != 0 {
+		t.Errorf("History not removed: %v\n", txs)
+	}
+
+	params, _ = json.Marshal(rpcGetAccountTxsParams{Address: hex.EncodeToString(sender[:]), Limit: ACCOUNT_TXS_MAX_PAGE_SIZE + 1})
+	if response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getAccountTxs", Params: params, Id: 2}); response.Error == nil || response.Error.Code != RPC_INVALID_PARAMS {
+		t.Errorf("Invalid limit accepted: %v\n", response.Error)
+	}
+}
+
+func containsAccountTx(page rpcAccountTxs, txHash string) bool {
+	for _, tx := range page.Txs {
+		if tx.Hash == txHash {
+			return true
+		}
+	}
+
+	return false
+}
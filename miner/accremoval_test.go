@@ -0,0 +1,72 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestAccountRemoval(t *testing.T) {
+	rootPubKey, rootPrivKey, _ := ed25519.GenerateKey(nil)
+	var rootAddress [32]byte
+	copy(rootAddress[:], rootPubKey)
+	rootAcc := &protocol.Account{Address: rootAddress, Balance: 500}
+	rootHash := rootAcc.Hash()
+	storage.State.Set(rootHash, rootAcc)
+	storage.RootKeys[rootHash] = rootAcc
+	defer storage.State.Delete(rootHash)
+	defer delete(storage.RootKeys, rootHash)
+
+	address := [32]byte{'r', 'e', 'm', 'o', 'v', 'e'}
+	acc := &protocol.Account{Address: address, Balance: 300, TxCnt: 7}
+	accHash := acc.Hash()
+	storage.State.Set(accHash, acc)
+	defer storage.State.Delete(accHash)
+
+	tx, _, _ := protocol.ConstrAccTx(2, 1, 0, address, rootPrivKey, nil, nil)
+	if !verifyAccTx(tx) {
+		t.Fatal("Account removal signed by the issuer not verified.")
+	}
+
+	if err := accStateChange([]*protocol.AccTx{tx}); err != nil {
+		t.Fatalf("Account removal rejected: %v\n", err)
+	}
+
+	if storage.State.Get(accHash) != nil || rootAcc.Balance != 800 {
+		t.Errorf("Account not removed or balance not swept to the issuer: %v\n", rootAcc)
+	}
+
+	//The removed account is written with the block and restored on rollback.
+	batch := storage.NewBatch()
+	removed := removedAccounts[tx.Hash()]
+	batch.WriteRemovedAccount(tx.Hash(), &removed.acc, removed.isRoot)
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	accStateChangeRollback([]*protocol.AccTx{tx})
+	if restored := storage.State.Get(accHash); restored == nil || restored.Balance != 300 || restored.TxCnt != 7 || rootAcc.Balance != 500 {
+		t.Errorf("Account removal not rolled back: %v\n", restored)
+	}
+
+	//The balance is swept to the issuer, so it must be the root account that signed the tx.
+	forged := *tx
+	forged.Issuer = [32]byte{'f', 'o', 'r', 'g', 'e', 'd'}
+	forgedHash := forged.Hash()
+	copy(forged.Sig[:], ed25519.Sign(rootPrivKey, forgedHash[:]))
+	if verifyAccTx(&forged) {
+		t.Error("Account removal sweeping to another account verified.")
+	}
+
+	storage.State.Get(accHash).IsStaking = true
+	if err := accStateChange([]*protocol.AccTx{tx}); err == nil || storage.State.Get(accHash) == nil {
+		t.Error("Staking account removed.")
+	}
+
+	self, _, _ := protocol.ConstrAccTx(2, 1, 0, rootAddress, rootPrivKey, nil, nil)
+	if err := accStateChange([]*protocol.AccTx{self}); err == nil || !storage.IsRootKey(rootHash) {
+		t.Error("Root account removed itself.")
+	}
+}
@@ -0,0 +1,149 @@
+package miner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestDependentFundsTxs(t *testing.T) {
+	a, b := [32]byte{'a'}, [32]byte{'b'}
+	parent, child, unrelated := newMempoolTestTx(a, 3, 1), newMempoolTestTx(a, 4, 10), newMempoolTestTx(a, 6, 1)
+	single := newMempoolTestTx(b, 4, 1)
+
+	dependent := dependentFundsTxs([]*protocol.FundsTx{child, single, unrelated, parent})
+	if !dependent[parent.Hash()] || !dependent[child.Hash()] {
+		t.Error("Txs with sequential txCnts of the same sender should be dependent.")
+	}
+	if dependent[unrelated.Hash()] || dependent[single.Hash()] {
+		t.Error("Txs without a neighbouring txCnt of the same sender should not be dependent.")
+	}
+}
+
+func TestAggregationKeepsDependentTxs(t *testing.T) {
+	defer SetAggregation(uint32(aggregationMinTxs), uint32(noAggregationLength))
+	SetAggregation(2, 0)
+
+	a, b, receiver := [32]byte{'a', 'g', 'g'}, [32]byte{'b', 'a', 'g', 'g'}, [32]byte{'r', 'a', 'g', 'g'}
+	parent, child := &protocol.FundsTx{Amount: 1, Fee: 1, TxCnt: 0, From: a, To: receiver}, &protocol.FundsTx{Amount: 2, Fee: 10, TxCnt: 1, From: a, To: receiver}
+	other := &protocol.FundsTx{Amount: 3, Fee: 1, TxCnt: 0, From: b, To: receiver}
+
+	prevSenders, prevReceivers := storage.DifferentSenders, storage.DifferentReceivers
+	defer func() { storage.DifferentSenders, storage.DifferentReceivers = prevSenders, prevReceivers }()
+	storage.DifferentSenders = map[[32]byte]uint32{a: 2, b: 1}
+	storage.DifferentReceivers = map[[32]byte]uint32{receiver: 3}
+	for _, tx := range []*protocol.FundsTx{child, other, parent} {
+		storage.WriteFundsTxBeforeAggregation(tx)
+	}
+
+	block := new(protocol.Block)
+	sortFundsTxBeforeAggregation(storage.ReadFundsTxBeforeAggregation())
+	splitSortedAggregatableTransactions(block)
+
+	//Aggregated by receiver, the parent and the child would be split from each other.
+	if len(block.AggTxData) != 1 || len(block.FundsTxData) != 1 || block.FundsTxData[0] != other.Hash() {
+		t.Fatalf("Dependent txs should be aggregated by sender: %v aggTxs, %v fundsTxs\n", len(block.AggTxData), len(block.FundsTxData))
+	}
+
+	aggTx := storage.ReadOpenTx(block.AggTxData[0]).(*protocol.AggTx)
+	defer storage.DeleteOpenTx(aggTx)
+	if aggTx.Fee != parent.Fee+child.Fee {
+		t.Errorf("AggTx fee should be the sum of the aggregated fees: %v\n", aggTx.Fee)
+	}
+	if len(aggTx.AggregatedTxSlice) != 2 || aggTx.AggregatedTxSlice[0] != parent.Hash() || aggTx.AggregatedTxSlice[1] != child.Hash() {
+		t.Errorf("Dependent txs should be aggregated in txCnt order: %x\n", aggTx.AggregatedTxSlice)
+	}
+}
+
+func TestVerifyAggTx(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(nil)
+	sender := &protocol.Account{Address: [32]byte{}}
+	copy(sender.Address[:], pubKey)
+	from := sender.Hash()
+	receiver := &protocol.Account{Address: [32]byte{'v', 'a', 'g', 'g'}}
+	to := receiver.Hash()
+	storage.State.Set(from, sender)
+	storage.State.Set(to, receiver)
+	defer storage.State.Delete(from)
+	defer storage.State.Delete(to)
+
+	tx1, _ := protocol.ConstrFundsTx(0, 10, 1, 0, from, to, privKey, nil)
+	tx2, _ := protocol.ConstrFundsTx(0, 20, 2, 1, from, to, privKey, nil)
+	for _, tx := range []*protocol.FundsTx{tx1, tx2} {
+		storage.WriteOpenTx(tx)
+		defer storage.DeleteOpenTx(tx)
+	}
+
+	aggTx, err := aggregate([]*protocol.FundsTx{tx1, tx2})
+	if err != nil || !verifyAggTx(aggTx) {
+		t.Fatalf("AggTx derived from its txs was rejected: %v\n", err)
+	}
+
+	//Anyone could otherwise inject an aggTx crediting arbitrary amounts.
+	forged := *aggTx
+	forged.Amount = 1000
+	if verifyAggTx(&forged) || aggTxCheck([]*protocol.AggTx{&forged}) == nil {
+		t.Error("AggTx with forged amount was accepted.")
+	}
+
+	forged = *aggTx
+	forged.To = [][32]byte{from}
+	if verifyAggTx(&forged) {
+		t.Error("AggTx with forged receiver was accepted.")
+	}
+
+	unknown, _ := protocol.ConstrAggTx(30, 3, [][32]byte{from}, [][32]byte{to}, [][32]byte{tx1.Hash(), {'x'}})
+	if verifyAggTx(unknown) {
+		t.Error("AggTx of unknown txs was accepted.")
+	}
+
+	duplicate, _ := protocol.ConstrAggTx(20, 2, [][32]byte{from}, [][32]byte{to}, [][32]byte{tx1.Hash(), tx1.Hash()})
+	if verifyAggTx(duplicate) {
+		t.Error("AggTx aggregating a tx twice was accepted.")
+	}
+
+	//The aggregated txs must be signed by their senders.
+	tx3 := *tx2
+	tx3.Amount = 30
+	storage.WriteOpenTx(&tx3)
+	defer storage.DeleteOpenTx(&tx3)
+	unsigned, _ := aggregate([]*protocol.FundsTx{tx1, &tx3})
+	if verifyAggTx(unsigned) {
+		t.Error("AggTx of a tx with invalid signature was accepted.")
+	}
+}
+
+//Blocks up to the Agg_tx_auth_height are not checked for unauthenticated aggTxs, by default no block is.
+func TestAggTxAuthActivation(t *testing.T) {
+	cleanAndPrepare()
+	defer func() { parameterSlice[0].Agg_tx_auth_height = AGG_TX_AUTH_HEIGHT }()
+
+	from, to := [32]byte{'a', 'a', 'u'}, [32]byte{'a', 'a', 'v'}
+	forged, _ := protocol.ConstrAggTx(1000, 1, [][32]byte{from}, [][32]byte{to}, [][32]byte{{'x'}})
+	storage.WriteOpenTx(forged)
+	defer storage.DeleteOpenTx(forged)
+
+	b := newBlock(genesisBlock.Hash, genesisBlock.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b.AggTxData = [][32]byte{forged.Hash()}
+	b.NrAggTx = 1
+	b.Timestamp = time.Now().Unix()
+
+	countBefore := GetRejectedBlockCount(REJECTED_AGG_TX)
+	for _, height := range []uint64{AGG_TX_AUTH_HEIGHT, 1} {
+		parameterSlice[0].Agg_tx_auth_height = height
+		preValidate(context.Background(), b, false)
+		if GetRejectedBlockCount(REJECTED_AGG_TX) != countBefore {
+			t.Errorf("AggTxs of a block up to the Agg_tx_auth_height %v checked.\n", height)
+		}
+	}
+
+	parameterSlice[0].Agg_tx_auth_height = 0
+	if _, _, _, _, _, _, _, err := preValidate(context.Background(), b, false); err == nil || GetRejectedBlockCount(REJECTED_AGG_TX) != countBefore+1 {
+		t.Errorf("Block with a forged aggTx above the Agg_tx_auth_height not rejected: %v\n", err)
+	}
+}
@@ -0,0 +1,105 @@
+package miner
+
+import (
+	"encoding/binary"
+	"errors"
+	"expvar"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"golang.org/x/crypto/sha3"
+)
+
+//Validators are eligible as soon as their proof of stake is below the target. When several validators become eligible
+//in the same second, all of them propose a block and the network forks until one of the branches wins, causing
+//rollbacks. Before broadcasting, a proposer therefore waits for a delay proportional to the quality of its sortition
+//(the lower the proof of stake, the higher the priority) plus a random jitter. Lower-priority proposers receive the
+//block of the higher-priority one while still waiting and abandon their own block.
+
+const (
+	PROPOSAL_BACKOFF_DEFAULT = 2 * time.Second
+	PROPOSAL_JITTER_DEFAULT  = 500 * time.Millisecond
+	BACKOFF_POLL_INTERVAL    = 50 * time.Millisecond
+)
+
+var (
+	proposalBackoff = PROPOSAL_BACKOFF_DEFAULT
+	proposalJitter  = PROPOSAL_JITTER_DEFAULT
+
+	//Published under /debug/vars of the RPC server, so the effect of the backoff on the fork rate can be measured.
+	proposals      = expvar.NewMap("proposals")
+	blockRollbacks = expvar.NewInt("block_rollbacks")
+)
+
+//Sets the maximum delay for the lowest-priority proposer and the maximum random jitter added to every delay. Both 0
+//disables the backoff.
+func SetProposalBackoff(backoff time.Duration, jitter time.Duration) {
+	proposalBackoff = backoff
+	proposalJitter = jitter
+}
+
+//Returns how far the proof of stake is below the target, from 0 (best) to 1 (just eligible). Computed like the proof
+//of stake in validateProofOfStake: the first 8 bytes of the hash divided by the balance, which must be below
+//2^(64-diff) to be eligible.
+func sortitionQuality(diff uint8,
+	prevProofs [][crypto.COMM_KEY_LENGTH]byte,
+	height uint32,
+	balance uint64,
+	commitmentProof [crypto.COMM_KEY_LENGTH]byte,
+	timestamp int64) float64 {
+
+	if balance == 0 || diff >= 64 {
+		return 0
+	}
+
+	hashArgs := make([]byte, 0, len(prevProofs)*crypto.COMM_KEY_LENGTH+crypto.COMM_KEY_LENGTH+4+8)
+	for _, prevProof := range prevProofs {
+		hashArgs = append(hashArgs, prevProof[:]...)
+	}
+	output := commitmentOutput(commitmentProof)
+	hashArgs = append(hashArgs, output[:]...)
+
+	var heightBuf [4]byte
+	var timestampBuf [8]byte
+	binary.BigEndian.PutUint32(heightBuf[:], height)
+	binary.BigEndian.PutUint64(timestampBuf[:], uint64(timestamp))
+	hashArgs = append(append(hashArgs, heightBuf[:]...), timestampBuf[:]...)
+
+	pos := sha3.Sum256(hashArgs)
+	value := binary.BigEndian.Uint64(pos[:]) / balance
+
+	return math.Min(float64(value)/math.Pow(2, float64(64-diff)), 1)
+}
+
+func proposalDelay(quality float64) time.Duration {
+	delay := time.Duration(quality * float64(proposalBackoff))
+	if proposalJitter > 0 {
+		delay += time.Duration(rand.Int63n(int64(proposalJitter)))
+	}
+
+	return delay
+}
+
+//Waits for the proposal delay and returns an error if another block has been validated in the meantime.
+func backoff(prevHash [32]byte, quality float64) error {
+	deadline := time.Now().Add(proposalDelay(quality))
+	for {
+		if lastBlock != nil && prevHash != lastBlock.Hash {
+			proposals.Add("abandoned", 1)
+			return errors.New("Abort proposal, a block of a higher-priority proposer has been validated during the backoff.")
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			proposals.Add("proposed", 1)
+			return nil
+		}
+
+		if remaining > BACKOFF_POLL_INTERVAL {
+			remaining = BACKOFF_POLL_INTERVAL
+		}
+		time.Sleep(remaining)
+	}
+}
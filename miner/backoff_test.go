@@ -0,0 +1,53 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestSortitionQuality(t *testing.T) {
+	prevProofs := [][crypto.COMM_KEY_LENGTH]byte{{0x01}}
+	var commitmentProof [crypto.COMM_KEY_LENGTH]byte
+
+	//Eligible proposers have a quality below 1, the others are clamped to 1.
+	for timestamp := int64(0); timestamp < 200; timestamp++ {
+		commitmentProof[0] = byte(timestamp)
+		quality := sortitionQuality(4, prevProofs, 10, 3, commitmentProof, timestamp)
+		if valid := validateProofOfStake(4, prevProofs, 10, 3, commitmentProof, timestamp); valid != (quality < 1) {
+			t.Errorf("Quality %v does not match the proof of stake (valid: %v) at timestamp %v.\n", quality, valid, timestamp)
+		}
+
+		if quality < 0 || quality > 1 {
+			t.Errorf("Quality %v out of range.\n", quality)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	defer SetProposalBackoff(PROPOSAL_BACKOFF_DEFAULT, PROPOSAL_JITTER_DEFAULT)
+	SetProposalBackoff(200*time.Millisecond, 0)
+
+	previous := lastBlock
+	defer func() { lastBlock = previous }()
+	lastBlock = &protocol.Block{Hash: [32]byte{0x01}}
+
+	start := time.Now()
+	if err := backoff(lastBlock.Hash, 0.5); err != nil {
+		t.Fatalf("Backoff aborted without a new block: %v\n", err)
+	}
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Backoff of quality 0.5 took %v instead of 100ms.\n", elapsed)
+	}
+
+	//A block validated during the backoff aborts the proposal.
+	go func() {
+		time.Sleep(BACKOFF_POLL_INTERVAL)
+		lastBlock = &protocol.Block{Hash: [32]byte{0x02}}
+	}()
+	if err := backoff([32]byte{0x01}, 1); err == nil {
+		t.Error("Proposal not abandoned after a new block was validated.")
+	}
+}
@@ -0,0 +1,35 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//The balance history answers what the balance of an account was after the block at a given height (see
+//storage.ReadBalanceAt). For every block, the balances changed by it are written together with the block, the state
+//transition of the block knows which accounts changed. When a block is rolled back, the same accounts change again
+//and their entries at the block's height are removed. The initial balances (genesis or snapshot) are written for all
+//accounts.
+
+//Height of the first block the balance history covers. Nodes starting from a snapshot have no history before it.
+var balanceHistoryStart uint32
+
+func writeBalanceHistory(batch *storage.Batch, height uint32) {
+	for account, balance := range storage.ChangedBalances() {
+		batch.WriteBalance(account, height, balance)
+	}
+}
+
+func deleteBalanceHistory(batch *storage.Batch, height uint32) {
+	for account := range storage.ChangedBalances() {
+		batch.DeleteBalance(account, height)
+	}
+}
+
+func writeInitialBalances(height uint32) error {
+	batch := storage.NewBatch()
+	for account, acc := range storage.State.Snapshot() {
+		batch.WriteBalance(account, height, acc.Balance)
+	}
+
+	return batch.Commit()
+}
@@ -0,0 +1,96 @@
+package miner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func getBalanceAt(t *testing.T, address [32]byte, height uint32) (uint64, *rpcError) {
+	params, _ := json.Marshal(rpcGetBalanceAtParams{Address: hex.EncodeToString(address[:]), Height: height})
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getBalanceAt", Params: params, Id: 1})
+	if response.Error != nil {
+		return 0, response.Error
+	}
+
+	return response.Result.(rpcBalance).Balance, nil
+}
+
+func TestBalanceHistory(t *testing.T) {
+	prevLastBlock := lastBlock
+	defer func() { lastBlock = prevLastBlock }()
+	lastBlock = &protocol.Block{Height: 20}
+
+	sender := &protocol.Account{Address: [32]byte{0xb1}, Balance: 1000}
+	receiver := [32]byte{0xb2}
+	storage.State.Set(sender.Address, sender)
+	defer storage.State.Delete(sender.Address)
+	defer storage.State.Delete(receiver)
+
+	//Block 10 creates the receiver and pays it 300.
+	storage.BeginStateTransition()
+	acc, _ := storage.GetAccount(sender.Address)
+	acc.Balance -= 300
+	storage.GetAccount(receiver)
+	storage.State.Set(receiver, &protocol.Account{Address: receiver, Balance: 300})
+
+	batch := storage.NewBatch()
+	writeBalanceHistory(batch, 10)
+	batch.Commit()
+	storage.CommitStateTransition()
+	defer func() {
+		batch := storage.NewBatch()
+		batch.DeleteBalance(sender.Address, 10)
+		batch.DeleteBalance(receiver, 10)
+		batch.Commit()
+	}()
+
+	tests := []struct {
+		address [32]byte
+		height  uint32
+		balance uint64
+	}{
+		{receiver, 9, 0},
+		{receiver, 10, 300},
+		{receiver, 20, 300},
+		{sender.Address, 10, 700},
+	}
+
+	for _, test := range tests {
+		if balance, err := getBalanceAt(t, test.address, test.height); err != nil || balance != test.balance {
+			t.Errorf("Balance of %x at height %v: %v instead of %v (%v)\n", test.address[0:8], test.height, balance, test.balance, err)
+		}
+	}
+
+	if _, err := getBalanceAt(t, receiver, 21); err == nil || err.Code != RPC_INVALID_PARAMS {
+		t.Errorf("Balance above the tip returned: %v\n", err)
+	}
+
+	balanceHistoryStart = 15
+	if _, err := getBalanceAt(t, receiver, 14); err == nil || err.Code != RPC_INVALID_PARAMS {
+		t.Errorf("Balance before the snapshot returned: %v\n", err)
+	}
+	balanceHistoryStart = 0
+
+	//Rolling block 10 back changes the same accounts, their entries are removed.
+	storage.BeginStateTransition()
+	acc, _ = storage.GetAccount(sender.Address)
+	acc.Balance += 300
+	storage.GetAccount(receiver)
+	storage.State.Delete(receiver)
+
+	batch = storage.NewBatch()
+	deleteBalanceHistory(batch, 10)
+	batch.Commit()
+	storage.CommitStateTransition()
+
+	if _, exists := storage.ReadBalanceAt(receiver, 20); exists {
+		t.Error("Balance history not rolled back.\n")
+	}
+	if _, exists := storage.ReadBalanceAt(sender.Address, 20); exists {
+		t.Error("Balance history not rolled back.\n")
+	}
+}
@@ -1,6 +1,8 @@
 package miner
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
 	"encoding/hex"
 	"errors"
@@ -9,6 +11,7 @@ import (
 	"strconv"
 	"time"
 
+	"github.com/bazo-blockchain/bazo-miner/crash"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
 	"github.com/bazo-blockchain/bazo-miner/p2p"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
@@ -25,6 +28,7 @@ type blockData struct {
 	stakeTxSlice  		  []*protocol.StakeTx
 	aggTxSlice	  []*protocol.AggTx
 	iotTxSlice				[]*protocol.IotTx
+	contractTxSlice		  []*protocol.ContractTx
 	block        		  *protocol.Block
 }
 
@@ -45,16 +49,12 @@ func newBlock(prevHash [32]byte, prevHashWithoutTx [32]byte, commitmentProof [cr
 func finalizeBlock(block *protocol.Block) error {
 	//Check if we have a slashing proof that we can add to the block.
 	//The slashingDict is updated when a new block is received and when a slashing proof is provided.
-	if len(slashingDict) != 0 {
-		//Get the first slashing proof.
-		for hash, slashingProof := range slashingDict {
-			block.SlashedAddress = hash
-			block.ConflictingBlockHash1 = slashingProof.ConflictingBlockHash1
-			block.ConflictingBlockHash2 = slashingProof.ConflictingBlockHash2
-			block.ConflictingBlockHashWithoutTx1 = slashingProof.ConflictingBlockHashWithoutTx1
-			block.ConflictingBlockHashWithoutTx2 = slashingProof.ConflictingBlockHashWithoutTx2
-			break
-		}
+	if slashingProof := nextSlashingProof(); slashingProof != nil {
+		block.SlashedAddress = slashingProof.SlashedAddress
+		block.ConflictingBlockHash1 = slashingProof.ConflictingBlockHash1
+		block.ConflictingBlockHash2 = slashingProof.ConflictingBlockHash2
+		block.ConflictingBlockHashWithoutTx1 = slashingProof.ConflictingBlockHashWithoutTx1
+		block.ConflictingBlockHashWithoutTx2 = slashingProof.ConflictingBlockHashWithoutTx2
 	}
 
 	//Merkle tree includes the hashes of all txs in this block
@@ -68,22 +68,34 @@ func finalizeBlock(block *protocol.Block) error {
 	validatorAccHash := validatorAcc.Hash()
 	copy(block.Beneficiary[:], validatorAccHash[:])
 
-	// Cryptographic Sortition for PoS in Bazo
-	// The commitment proof stores a signed message of the Height that this block was created at.
-	commitmentProof, err := crypto.SignMessageWithRSAKey(commPrivKey, fmt.Sprint(block.Height))
+	//The proof of stake uses the balance of the epoch's validator set, see validatorset.go.
+	validator, err := getValidator(validatorAccHash, block.Height)
 	if err != nil {
 		return err
 	}
 
-	//Block hash with MerkleTree and therefore, including all transactions
-	partialHash := block.HashBlock()
+	//The beneficiary collects the fees and the block reward, the state root can only be computed once it is set.
+	if block.StateRoot, err = computeStateRoot(block); err != nil {
+		return err
+	}
 
-	//Block hash without MerkleTree and therefore, without any transactions
-	partialHashWithoutMerkleRoot := block.HashBlockWithoutMerkleRoot()
+	// Cryptographic Sortition for PoS in Bazo
+	// The commitment proof stores a signed message of the Height that this block was created at.
+	commitmentProof, err := commSigner.Sign(fmt.Sprint(block.Height))
+	if err != nil {
+		return err
+	}
 
 	prevProofs := GetLatestProofs(activeParameters.num_included_prev_proofs, block)
 
-	nonce, err := proofOfStake(getDifficulty(), block.PrevHash, prevProofs, block.Height, validatorAcc.Balance, commitmentProof)
+	nonce, err := proofOfStake(getDifficulty(), block.PrevHash, prevProofs, block.Height, validator.Balance, commitmentProof, medianTimePast(block))
+	if err == nil {
+		//Give higher-priority proposers eligible at the same time the chance to propose first.
+		quality := sortitionQuality(getDifficulty(), prevProofs, block.Height, validator.Balance, commitmentProof, nonce)
+		if err = backoff(block.PrevHash, quality); err != nil {
+			nonce = -2
+		}
+	}
 	if err != nil {
 		//Delete created AggTx From OpenTx.
 		if nonce == -2 {
@@ -98,10 +110,11 @@ func finalizeBlock(block *protocol.Block) error {
 	binary.BigEndian.PutUint64(nonceBuf[:], uint64(nonce))
 	block.Nonce = nonceBuf
 	block.Timestamp = nonce
+	copy(block.CommitmentProof[0:crypto.COMM_KEY_LENGTH], commitmentProof[:])
 
-	//Put pieces together to get the final hash.
-	block.Hash = sha3.Sum256(append(nonceBuf[:], partialHash[:]...))
-	block.HashWithoutTx = sha3.Sum256(append(nonceBuf[:], partialHashWithoutMerkleRoot[:]...))
+	//Block hash with MerkleTree and therefore, including all transactions, and block hash without MerkleTree and
+	//therefore, without any transactions (see blockhash.go).
+	block.Hash, block.HashWithoutTx = blockHashes(block)
 
 	//This doesn't need to be hashed, because we already have the merkle tree taking care of consistency.
 	block.NrAccTx = uint16(len(block.AccTxData))
@@ -110,9 +123,13 @@ func finalizeBlock(block *protocol.Block) error {
 	block.NrStakeTx = uint16(len(block.StakeTxData))
 	block.NrAggTx = uint16(len(block.AggTxData))
 	block.NrIoTTx = uint16(len(block.IoTTxData))
+	block.NrContractTx = uint16(len(block.ContractTxData))
 
 
-	copy(block.CommitmentProof[0:crypto.COMM_KEY_LENGTH], commitmentProof[:])
+	//Never produce two different blocks at the same height, we would get slashed.
+	if err := acquireSignLock(block); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -121,11 +138,11 @@ func finalizeBlock(block *protocol.Block) error {
 //We do not operate global state because the work might get interrupted by receiving a block that needs validation
 //which is done on the global state.
 func addTx(b *protocol.Block, tx protocol.Transaction) error {
-	//ActiveParameters is a datastructure that stores the current system parameters, gets only changed when
-	//configTxs are broadcast in the network.
-	if tx.TxFee() < activeParameters.Fee_minimum {
-		logger.Printf("Transaction fee too low: %v (minimum is: %v)\n", tx.TxFee(), activeParameters.Fee_minimum)
-		err := fmt.Sprintf("Transaction fee too low: %v (minimum is: %v)\n", tx.TxFee(), activeParameters.Fee_minimum)
+	//The minimum fee is based on activeParameters.Fee_minimum (only changed by configTxs broadcast in the network)
+	//and rises with the demand for block space, see feemarket.go.
+	if feeMinimum := effectiveFeeMinimum(); tx.TxFee() < feeMinimum {
+		logger.Printf("Transaction fee too low: %v (minimum is: %v)\n", tx.TxFee(), feeMinimum)
+		err := fmt.Sprintf("Transaction fee too low: %v (minimum is: %v)\n", tx.TxFee(), feeMinimum)
 		return errors.New(err)
 	}
 
@@ -173,6 +190,12 @@ func addTx(b *protocol.Block, tx protocol.Transaction) error {
 			//logger.Printf("Adding iotTx (%x) failed (%v): %v\n",tx.Hash(), err, tx.(*protocol.IotTx))
 			return err
 		}
+	case *protocol.ContractTx:
+		err := addContractTx(b, tx.(*protocol.ContractTx))
+		if err != nil {
+			logger.Printf("Adding contractTx (%x) failed (%v): %v\n",tx.Hash(), err, tx.(*protocol.ContractTx))
+			return err
+		}
 	default:
 		return errors.New("Transaction type not recognized.")
 	}
@@ -185,9 +208,39 @@ func addAccTx(b *protocol.Block, tx *protocol.AccTx) error {
 	//According to the accTx specification, we only accept new accounts except if the removal bit is
 	//set in the header (2nd bit).
 	if tx.Header&0x02 != 0x02 {
-		if _, exists := storage.State[accHash]; exists {
+		if storage.State.Get(accHash) != nil {
 			return errors.New("Account already exists.")
 		}
+		if tx.Device != nil {
+			if err := checkDeviceOwner(tx.Device); err != nil {
+				return err
+			}
+		}
+
+		//The initial balance is debited from the issuing root account, the endowments of the block must be covered.
+		if tx.Amount > 0 {
+			if _, exists := b.StateCopy[tx.Issuer]; !exists {
+				if acc := storage.State.Get(tx.Issuer); acc != nil {
+					newAcc := *acc
+					newAcc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
+					b.StateCopy[tx.Issuer] = &newAcc
+				} else {
+					return errors.New(fmt.Sprintf("Issuer account not present in the state: %x\n", tx.Issuer))
+				}
+			}
+			if tx.Amount > b.StateCopy[tx.Issuer].Balance {
+				return errors.New("Not enough funds for the initial balance!")
+			}
+			b.StateCopy[tx.Issuer].Balance -= tx.Amount
+		}
+	} else {
+		acc := storage.State.Get(accHash)
+		if acc == nil {
+			return errors.New("Account to remove does not exist.")
+		}
+		if acc.IsStaking {
+			return errors.New("Account to remove is staking.")
+		}
 	}
 
 	//Add the tx hash to the block header and write it to open storage (non-validated transactions).
@@ -198,11 +251,12 @@ func addAccTx(b *protocol.Block, tx *protocol.AccTx) error {
 
 func addIoTTx(b *protocol.Block, tx *protocol.IotTx) error {
 	if _, exists := b.StateCopy[tx.From]; !exists {
-		if acc := storage.State[tx.From]; acc != nil {
+		if acc := storage.State.Get(tx.From); acc != nil {
 			hash := protocol.SerializeHashContent(acc.Address)
 			if hash == tx.From {
 				newAcc := protocol.Account{}
 				newAcc = *acc
+				newAcc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
 				b.StateCopy[tx.From] = &newAcc
 			}
 		} else {
@@ -212,11 +266,12 @@ func addIoTTx(b *protocol.Block, tx *protocol.IotTx) error {
 
 	//Vice versa for receiver account.
 	if _, exists := b.StateCopy[tx.To]; !exists {
-		if acc := storage.State[tx.To]; acc != nil {
+		if acc := storage.State.Get(tx.To); acc != nil {
 			hash := protocol.SerializeHashContent(acc.Address)
 			if hash == tx.To {
 				newAcc := protocol.Account{}
 				newAcc = *acc
+				newAcc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
 				b.StateCopy[tx.To] = &newAcc
 			}
 		} else {
@@ -224,6 +279,17 @@ func addIoTTx(b *protocol.Block, tx *protocol.IotTx) error {
 		}
 	}
 
+	//Retransmitted readings must not be recorded twice.
+	if err := checkIoTDuplicate(tx); err != nil {
+		return err
+	}
+
+	//Transaction count need to match the state, preventing replay attacks.
+	if b.StateCopy[tx.From].TxCnt != tx.TxCnt {
+		err := fmt.Sprintf("Sender txCnt IoT does not match: %v (tx.txCnt) vs. %v (state txCnt)", tx.TxCnt, b.StateCopy[tx.From].TxCnt)
+		return errors.New(err)
+	}
+
 	if !storage.IsRootKey(tx.From) {
 		if (tx.Fee) > b.StateCopy[tx.From].Balance {
 			acc:= b.StateCopy[tx.From]
@@ -231,11 +297,10 @@ func addIoTTx(b *protocol.Block, tx *protocol.IotTx) error {
 			//return errors.New("Not enough funds to complete the IoT transaction!")
 		}
 	}
-	if b.StateCopy[tx.From].TxCnt != tx.TxCnt {
-		//TODO @ilecipi revert check TxCnt
-		//err := fmt.Sprintf("Sender txCnt IoT does not match: %v (tx.txCnt) vs. %v (state txCnt)", tx.TxCnt, b.StateCopy[tx.From].TxCnt)
-		//return errors.New(err)
+	if err := takeIotToken(b.StateCopy[tx.From], tx, b.Height, activeParameters); err != nil {
+		return err
 	}
+
 	accSender := b.StateCopy[tx.From]
 	accSender.TxCnt += 1
 	//TODO @ilecipi fix Fee
@@ -248,14 +313,20 @@ func addIoTTx(b *protocol.Block, tx *protocol.IotTx) error {
 
 
 func addFundsTx(b *protocol.Block, tx *protocol.FundsTx) error {
+	//The block's timestamp is set once it is finalized, which is not before now.
+	if !tx.IsUnlocked(b.Height, time.Now().Unix()) {
+		return errors.New(fmt.Sprintf("Tx is locked until %v.", tx.LockUntil))
+	}
+
 	//Checking if the sender account is already in the local state copy. If not and account exist, create local copy.
 	//If account does not exist in state, abort.
 	if _, exists := b.StateCopy[tx.From]; !exists {
-		if acc := storage.State[tx.From]; acc != nil {
+		if acc := storage.State.Get(tx.From); acc != nil {
 			hash := protocol.SerializeHashContent(acc.Address)
 			if hash == tx.From {
 				newAcc := protocol.Account{}
 				newAcc = *acc
+				newAcc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
 				b.StateCopy[tx.From] = &newAcc
 			}
 		} else {
@@ -265,11 +336,12 @@ func addFundsTx(b *protocol.Block, tx *protocol.FundsTx) error {
 
 	//Vice versa for receiver account.
 	if _, exists := b.StateCopy[tx.To]; !exists {
-		if acc := storage.State[tx.To]; acc != nil {
+		if acc := storage.State.Get(tx.To); acc != nil {
 			hash := protocol.SerializeHashContent(acc.Address)
 			if hash == tx.To {
 				newAcc := protocol.Account{}
 				newAcc = *acc
+				newAcc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
 				b.StateCopy[tx.To] = &newAcc
 			}
 		} else {
@@ -277,20 +349,35 @@ func addFundsTx(b *protocol.Block, tx *protocol.FundsTx) error {
 		}
 	}
 
+	//Transaction count need to match the state, preventing replay attacks. Checked before the balance, so a tx
+	//failing here with a higher txCnt is known to be out of order (see prepareBlock).
+	if b.StateCopy[tx.From].TxCnt != tx.TxCnt {
+		err := fmt.Sprintf("Sender txCnt does not match: %v (tx.txCnt) vs. %v (state txCnt)", tx.TxCnt, b.StateCopy[tx.From].TxCnt)
+		return errors.New(err)
+	}
+
+	//Delegation txs do not transfer the amount, see delegation.go.
+	if kind := delegationKind(tx, b.Height); kind != 0 {
+		if err := checkDelegation(tx, kind, b.StateCopy[tx.From], b.StateCopy[tx.To], b.Height); err != nil {
+			return err
+		}
+
+		applyDelegation(tx, kind, b.StateCopy[tx.From], b.StateCopy[tx.To])
+		b.FundsTxData = append(b.FundsTxData, tx.Hash())
+		return nil
+	}
+
 	//Root accounts are exempt from balance requirements. All other accounts need to have (at least)
-	//fee + amount to spend as balance available.
+	//fee + amount + gas to spend as balance available.
 	if !storage.IsRootKey(tx.From) {
-		if (tx.Amount + tx.Fee) > b.StateCopy[tx.From].Balance {
+		if (tx.Amount + tx.Fee + tx.GasCost()) > b.StateCopy[tx.From].Balance {
 			return errors.New("Not enough funds to complete the transaction!")
 		}
-	}
 
-	//Transaction count need to match the state, preventing replay attacks.
-	if b.StateCopy[tx.From].TxCnt != tx.TxCnt {
-		//TODO @ilecipi revert check TxCnt
-		//err := fmt.Sprintf("Sender txCnt does not match: %v (tx.txCnt) vs. %v (state txCnt)", tx.TxCnt, b.StateCopy[tx.From].TxCnt)
-
-		//return errors.New(err)
+		//The stake of a staking or unbonding sender cannot be spent.
+		if locked := lockedStake(b.StateCopy[tx.From], b.Height); (tx.Amount + tx.Fee + tx.GasCost() + locked) > b.StateCopy[tx.From].Balance {
+			return errors.New(fmt.Sprintf("Not enough funds to complete the transaction, %v coins of the balance are locked as stake!", locked))
+		}
 	}
 
 	//Prevent balance overflow in receiver account.
@@ -300,27 +387,34 @@ func addFundsTx(b *protocol.Block, tx *protocol.FundsTx) error {
 	}
 
 	//Check if transaction has data and the receiver account has a smart contract
+	var context *protocol.Context
 	if tx.Data != nil && b.StateCopy[tx.To].Contract != nil {
-		context := protocol.NewContext(*b.StateCopy[tx.To], *tx)
+		context = protocol.NewContext(*b.StateCopy[tx.To], *tx, stateCopyLoader(b))
 		virtualMachine := vm.NewVM(context)
 
-		// Check if vm execution run without error
-		if !virtualMachine.Exec(false) {
-			return errors.New(virtualMachine.GetErrorMsg())
+		//A failed execution (e.g., running out of gas) does not reject the tx, it is included and the sender pays the
+		//fee and the gas as if the contract succeeded. Only the changes to the contract variables are discarded.
+		if err := execContract(&virtualMachine, tx.Hash()); err != nil {
+			logger.Printf("Contract execution of tx %x failed after %v gas: %v\n", tx.Hash(), virtualMachine.GasUsed(), err)
+			context = nil
 		}
-
-		//Update changes vm has made to the contract variables
-		context.PersistChanges()
 	}
 
 	//Update state copy.
 	accSender := b.StateCopy[tx.From]
 	accSender.TxCnt += 1
 	accSender.Balance -= tx.Amount
+	accSender.Balance -= tx.GasCost()
 
 	accReceiver := b.StateCopy[tx.To]
 	accReceiver.Balance += tx.Amount
 
+	//Update changes vm has made to the contract variables and the balances of called contracts, the amount has to be
+	//credited first.
+	if context != nil {
+		context.PersistChanges()
+	}
+
 	//Add the tx hash to the block header and write it to open storage (non-validated transactions).
 	//b.FundsTxData = append(b.FundsTxData, tx.Hash())
 
@@ -333,6 +427,44 @@ func addFundsTx(b *protocol.Block, tx *protocol.FundsTx) error {
 	return nil
 }
 
+func addContractTx(b *protocol.Block, tx *protocol.ContractTx) error {
+	if _, exists := b.StateCopy[tx.From]; !exists {
+		if acc := storage.State.Get(tx.From); acc != nil {
+			hash := protocol.SerializeHashContent(acc.Address)
+			if hash == tx.From {
+				newAcc := protocol.Account{}
+				newAcc = *acc
+				newAcc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
+				b.StateCopy[tx.From] = &newAcc
+			}
+		} else {
+			return errors.New(fmt.Sprintf("Sender account not present in the state: %x\n", tx.From))
+		}
+	}
+
+	//Contract txs share the txCnt with the sender's fundsTxs, so the contract address is never derived twice.
+	if b.StateCopy[tx.From].TxCnt != tx.TxCnt {
+		err := fmt.Sprintf("Sender txCnt does not match: %v (tx.txCnt) vs. %v (state txCnt)", tx.TxCnt, b.StateCopy[tx.From].TxCnt)
+		return errors.New(err)
+	}
+
+	if storage.State.Get(protocol.SerializeHashContent(protocol.ContractAddress(tx.From, tx.TxCnt))) != nil {
+		return errors.New("Contract account already exists.")
+	}
+
+	if tx.Fee+tx.DeploymentGas() > b.StateCopy[tx.From].Balance {
+		return errors.New("Not enough funds to deploy the contract!")
+	}
+
+	//Update state copy.
+	accSender := b.StateCopy[tx.From]
+	accSender.TxCnt += 1
+	accSender.Balance -= tx.Fee + tx.DeploymentGas()
+
+	b.ContractTxData = append(b.ContractTxData, tx.Hash())
+	return nil
+}
+
 func addFundsTxFinal(b *protocol.Block, tx *protocol.FundsTx) error {
 	b.FundsTxData = append(b.FundsTxData, tx.Hash())
 	return nil
@@ -348,15 +480,29 @@ func addIoTTxFinal(b *protocol.Block, tx *protocol.IotTx) error {
 	return nil
 }
 
+//Txs of a sender with sequential txCnts depend on each other: a child can only be included after its parent and may pay
+//a higher fee for a parent stuck with a low one (child pays for parent). Dependent txs are therefore only aggregated by
+//sender, in txCnt order, so they always end up in the same aggTx. They are not counted for the receivers.
 func splitSortedAggregatableTransactions(b *protocol.Block){
 
 	txToAggregate := make([]*protocol.FundsTx, 0)
 	moreTransactionsToAggregate := true
 
+	dependent := dependentFundsTxs(storage.ReadFundsTxBeforeAggregation())
+	for _, tx := range storage.ReadFundsTxBeforeAggregation() {
+		if dependent[tx.Hash()] && storage.DifferentReceivers[tx.To] > 0 {
+			storage.DifferentReceivers[tx.To] = storage.DifferentReceivers[tx.To] - 1
+		}
+	}
+
 	for moreTransactionsToAggregate {
 		//Get Sender and Receiver which are most common
 		maxSender, addressSender := getMaxKeyAndValueFormMap(storage.DifferentSenders)
 		maxReceiver, addressReceiver := getMaxKeyAndValueFormMap(storage.DifferentReceivers)
+		if maxSender == 0 && maxReceiver == 0 {
+			//Counters exhausted, the remaining txs are aggregated by their sender.
+			addressSender = storage.ReadFundsTxBeforeAggregation()[0].From
+		}
 
 		//Then the sender or receiver which is most common is selected and all transactions are added to the txToAggregate
 		// slice. The number of transactions sent/Received will lower with every tx added. Then the splitted transactions
@@ -366,23 +512,35 @@ func splitSortedAggregatableTransactions(b *protocol.Block){
 				if tx.From == addressSender {
 					txToAggregate = append(txToAggregate, tx)
 					storage.DifferentSenders[tx.From] = storage.DifferentSenders[tx.From] - 1
-					storage.DifferentReceivers[tx.To] = storage.DifferentReceivers[tx.To] - 1
+					if !dependent[tx.Hash()] {
+						storage.DifferentReceivers[tx.To] = storage.DifferentReceivers[tx.To] - 1
+					}
 				}
 			}
-			AggregateFundsTransactions(txToAggregate, b, 0)
+			//The counters may include txs which did not fit into the block, they must not be selected again.
+			if len(txToAggregate) == 0 {
+				delete(storage.DifferentSenders, addressSender)
+			} else {
+				sortFundsTxBeforeAggregation(txToAggregate)
+				AggregateFundsTransactions(txToAggregate, b, 0)
+			}
 			for _, tx := range txToAggregate {
 				storage.DeleteFundsTxBeforeAggregation(tx.Hash())
 			}
 			txToAggregate = txToAggregate[:0]
 		} else {
 			for _, tx := range storage.ReadFundsTxBeforeAggregation() {
-				if tx.To == addressReceiver {
+				if tx.To == addressReceiver && !dependent[tx.Hash()] {
 					txToAggregate = append(txToAggregate, tx)
 					storage.DifferentReceivers[tx.To] = storage.DifferentReceivers[tx.To] - 1
 					storage.DifferentSenders[tx.From] = storage.DifferentSenders[tx.From] - 1
 				}
 			}
-			AggregateFundsTransactions(txToAggregate, b, 1)
+			if len(txToAggregate) == 0 {
+				delete(storage.DifferentReceivers, addressReceiver)
+			} else {
+				AggregateFundsTransactions(txToAggregate, b, 1)
+			}
 			for _, tx := range txToAggregate {
 				storage.DeleteFundsTxBeforeAggregation(tx.Hash())
 			}
@@ -400,11 +558,32 @@ func splitSortedAggregatableTransactions(b *protocol.Block){
 
 }
 
+//Returns the hashes of the txs whose sender has a tx with the previous or the next txCnt among the txs as well.
+func dependentFundsTxs(txs []*protocol.FundsTx) map[[32]byte]bool {
+	txCnts := make(map[[32]byte]map[uint32]bool)
+	for _, tx := range txs {
+		if txCnts[tx.From] == nil {
+			txCnts[tx.From] = make(map[uint32]bool)
+		}
+		txCnts[tx.From][tx.TxCnt] = true
+	}
+
+	dependent := make(map[[32]byte]bool)
+	for _, tx := range txs {
+		if txCnts[tx.From][tx.TxCnt+1] || (tx.TxCnt > 0 && txCnts[tx.From][tx.TxCnt-1]) {
+			dependent[tx.Hash()] = true
+		}
+	}
+
+	return dependent
+}
+
+//Ties are broken by the lower key, so the aggregation does not depend on the map's iteration order.
 func getMaxKeyAndValueFormMap(m map[[32]byte]uint32) (uint32, [32]byte) {
 	var max uint32 = 0
 	biggestK := [32]byte{}
 	for k := range m {
-		if m[k] > max {
+		if m[k] > max || (m[k] == max && max > 0 && bytes.Compare(k[:], biggestK[:]) < 0) {
 			max = m[k]
 			biggestK = k
 		}
@@ -414,50 +593,22 @@ func getMaxKeyAndValueFormMap(m map[[32]byte]uint32) (uint32, [32]byte) {
 }
 
 func AggregateFundsTransactions(SortedAndSelectedFundsTx []*protocol.FundsTx, block *protocol.Block, selection int ) error {
-	if len(SortedAndSelectedFundsTx) > 1 {
-
-		var transactionHashes [][32]byte
-		var transactionReceivers [][32]byte
-		var transactionSenders [][32]byte
-		var nrOfSender = map[[32]byte]uint32{}
-		var nrOfReceivers = map[[32]byte]uint32{}
-		var amount uint64
+	if len(SortedAndSelectedFundsTx) >= aggregationMinTxs {
 
-		//Sum up Amount, copy sender and receiver to correct slices and to map to check if aggregation by sender or receiver.
-		for _, tx := range SortedAndSelectedFundsTx {
-			amount += tx.Amount
-			transactionSenders = append(transactionSenders, tx.From)
-			nrOfSender[tx.From] = nrOfSender[tx.From]
-			transactionReceivers = append(transactionReceivers, tx.To)
-			nrOfReceivers[tx.To] = nrOfReceivers[tx.To]
-			transactionHashes = append(transactionHashes, tx.Hash())
-			tx.Aggregated = true
+		aggTx, err := aggregate(SortedAndSelectedFundsTx)
+		if err != nil {
+			logger.Printf("%v\n", err)
+			return err
 		}
 
-		// Remove Sender or Receiver if duplicated
-		if len(nrOfSender) < len(nrOfReceivers) {
+		if len(aggTx.From) < len(aggTx.To) {
 			logger.Printf("AGGREGATE: Sender %x ready for aggregation:", SortedAndSelectedFundsTx[0].From[0:8])
-			transactionSenders = transactionSenders[:1]
-		} else if len(nrOfSender) > len(nrOfReceivers){
+		} else if len(aggTx.From) > len(aggTx.To) {
 			logger.Printf("AGGREGATE: Receiver %x ready for aggregation:", SortedAndSelectedFundsTx[0].To[0:8])
-			transactionReceivers = transactionReceivers[:1]
 		}
 		for _, tx := range SortedAndSelectedFundsTx {
 			logger.Printf("  From: %x To: %x, TxCnt: %d  --  %x", tx.From[0:4], tx.To[0:4], tx.TxCnt, tx.Hash())
-		}
-
-		//Create Transactions
-		aggTx, err := protocol.ConstrAggTx(
-			amount,
-			FEE_MINIMUM,
-			transactionSenders,
-			transactionReceivers,
-			transactionHashes,
-		)
-
-		if err != nil {
-			logger.Printf("%v\n", err)
-			return err
+			tx.Aggregated = true
 		}
 
 		logger.Printf("AGGTX:  -------%v", aggTx)
@@ -466,14 +617,11 @@ func AggregateFundsTransactions(SortedAndSelectedFundsTx []*protocol.FundsTx, bl
 		addAggTxFinal(block, aggTx)
 		storage.WriteOpenTx(aggTx)
 
-		SortedAndSelectedFundsTx = nil
-		amount = 0
-		transactionReceivers = nil
-		transactionHashes = nil
-
-
 	} else if len(SortedAndSelectedFundsTx) > 0{
-		addFundsTxFinal(block, SortedAndSelectedFundsTx[0])
+		//Too few txs to aggregate, they are added as they are.
+		for _, tx := range SortedAndSelectedFundsTx {
+			addFundsTxFinal(block, tx)
+		}
 	} else {
 		err := errors.New("NullPointer")
 		return err
@@ -482,6 +630,48 @@ func AggregateFundsTransactions(SortedAndSelectedFundsTx []*protocol.FundsTx, bl
 	return nil
 }
 
+//Constructs the aggTx of the fundsTxs. AggTxs are not signed, they are authenticated by the signed fundsTxs they
+//aggregate: an aggTx is only valid if it equals the one constructed here from its aggregated txs (see verifyAggTx).
+func aggregate(fundsTxs []*protocol.FundsTx) (*protocol.AggTx, error) {
+	var transactionHashes [][32]byte
+	var transactionReceivers [][32]byte
+	var transactionSenders [][32]byte
+	var nrOfSender = map[[32]byte]uint32{}
+	var nrOfReceivers = map[[32]byte]uint32{}
+	var amount uint64
+	var fee uint64
+
+	//Sum up Amount and Fee, copy sender and receiver to correct slices and to map to check if aggregation by sender or receiver.
+	for _, tx := range fundsTxs {
+		if amount+tx.Amount < amount || fee+tx.Fee < fee {
+			return nil, errors.New("Aggregated amount or fee overflows.")
+		}
+		amount += tx.Amount
+		fee += tx.Fee
+		transactionSenders = append(transactionSenders, tx.From)
+		nrOfSender[tx.From] = nrOfSender[tx.From]
+		transactionReceivers = append(transactionReceivers, tx.To)
+		nrOfReceivers[tx.To] = nrOfReceivers[tx.To]
+		transactionHashes = append(transactionHashes, tx.Hash())
+	}
+
+	// Remove Sender or Receiver if duplicated
+	if len(nrOfSender) < len(nrOfReceivers) {
+		transactionSenders = transactionSenders[:1]
+	} else if len(nrOfSender) > len(nrOfReceivers){
+		transactionReceivers = transactionReceivers[:1]
+	}
+
+	//Create Transactions, the fee of the aggTx reflects the fees paid by the aggregated txs.
+	return protocol.ConstrAggTx(
+		amount,
+		fee,
+		transactionSenders,
+		transactionReceivers,
+		transactionHashes,
+	)
+}
+
 //TODO @ilecipi aggreagate IoT transactions?
 
 
@@ -544,6 +734,12 @@ func sortFundsTxBeforeAggregation(Slice []*protocol.FundsTx) {
 
 func addConfigTx(b *protocol.Block, tx *protocol.ConfigTx) error {
 	//No further checks needed, static checks were already done with verify().
+	if tx.IsRootKeyChange() {
+		if err := checkRootKeyChange(tx); err != nil {
+			return err
+		}
+	}
+
 	b.ConfigTxData = append(b.ConfigTxData, tx.Hash())
 	logger.Printf("Added tx (%x) to the ConfigTxData slice: %v", tx.Hash(), *tx)
 	return nil
@@ -553,11 +749,12 @@ func addStakeTx(b *protocol.Block, tx *protocol.StakeTx) error {
 	//Checking if the sender account is already in the local state copy. If not and account exist, create local copy
 	//If account does not exist in state, abort.
 	if _, exists := b.StateCopy[tx.Account]; !exists {
-		if acc := storage.State[tx.Account]; acc != nil {
+		if acc := storage.State.Get(tx.Account); acc != nil {
 			hash := protocol.SerializeHashContent(acc.Address)
 			if hash == tx.Account {
 				newAcc := protocol.Account{}
 				newAcc = *acc
+				newAcc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
 				b.StateCopy[tx.Account] = &newAcc
 			}
 		} else {
@@ -578,17 +775,26 @@ func addStakeTx(b *protocol.Block, tx *protocol.StakeTx) error {
 		return errors.New("Account has bool already set to the desired value.")
 	}
 
+	if tx.IsStaking {
+		if err := checkCommitmentKey(tx.CommitmentKey, b.Height); err != nil {
+			return err
+		}
+	}
+
 	//Update state copy.
 	accSender := b.StateCopy[tx.Account]
 	accSender.IsStaking = tx.IsStaking
 	accSender.CommitmentKey = tx.CommitmentKey
+	if !tx.IsStaking {
+		accSender.UnbondingHeight = unbondingHeight(b.Height)
+	}
 
 	//No further checks needed, static checks were already done with verify().
 	b.StakeTxData = append(b.StakeTxData, tx.Hash())
 	logger.Printf("Added tx (%x) to the StakeTxData slice: %v", tx.Hash(), *tx)
 	return nil
 }
-func fetchIotTxData(block *protocol.Block, iotTxSlice []*protocol.IotTx, initialSetup bool, errChan chan error) {
+func fetchIotTxData(ctx context.Context, block *protocol.Block, iotTxSlice []*protocol.IotTx, initialSetup bool, errChan chan error) {
 	for cnt, txHash := range block.IoTTxData {
 		var tx protocol.Transaction
 		var IoTTx *protocol.IotTx
@@ -612,34 +818,81 @@ func fetchIotTxData(block *protocol.Block, iotTxSlice []*protocol.IotTx, initial
 		if tx != nil {
 			IoTTx = tx.(*protocol.IotTx)
 		} else {
-			err := p2p.TxReq(txHash, p2p.IOTTX_REQ)
+			err := fetch(ctx, "IoTTx", TXFETCH_TIMEOUT*time.Second, func() error {
+				return p2p.TxReq(txHash, p2p.IOTTX_REQ)
+			}, func(timeout <-chan time.Time) bool {
+				select {
+				case IoTTx = <-p2p.IoTTxChan:
+					//This check is important. A malicious miner might have sent us a tx whose hash is a different one
+					//from what we requested.
+					return IoTTx.Hash() == txHash
+				case <-timeout:
+				case <-ctx.Done():
+				}
+				return false
+			})
 			if err != nil {
-				errChan <- errors.New(fmt.Sprintf("AccTx could not be read: %v", err))
+				errChan <- err
 				return
 			}
+		}
+
+		iotTxSlice[cnt] = IoTTx
+	}
+
+	errChan <- nil
+}
+
+func fetchContractTxData(ctx context.Context, block *protocol.Block, contractTxSlice []*protocol.ContractTx, initialSetup bool, errChan chan error) {
+	for cnt, txHash := range block.ContractTxData {
+		var tx protocol.Transaction
+		var contractTx *protocol.ContractTx
 
-			//Blocking Wait
-			select {
-			case IoTTx = <-p2p.IoTTxChan:
-				//Limit the waiting time for TXFETCH_TIMEOUT seconds.
-			case <-time.After(TXFETCH_TIMEOUT * time.Second):
-				errChan <- errors.New("IoTTx fetch timed out.")
+		closedTx := storage.ReadClosedTx(txHash)
+		if closedTx != nil {
+			if initialSetup {
+				contractTx = closedTx.(*protocol.ContractTx)
+				contractTxSlice[cnt] = contractTx
+				continue
+			} else {
+				//Reject blocks that have txs which have already been validated.
+				errChan <- errors.New("Block validation had contractTx that was already in a previous block.")
+				return
 			}
-			//This check is important. A malicious miner might have sent us a tx whose hash is a different one
-			//from what we requested.
-			if IoTTx.Hash() != txHash {
-				errChan <- errors.New("Received IoTHash did not correspond to our request.")
+		}
+
+		//Tx is either in open storage or needs to be fetched from the network.
+		tx = storage.ReadOpenTx(txHash)
+		if tx != nil {
+			contractTx = tx.(*protocol.ContractTx)
+		} else {
+			err := fetch(ctx, "ContractTx", TXFETCH_TIMEOUT*time.Second, func() error {
+				return p2p.TxReq(txHash, p2p.CONTRACTTX_REQ)
+			}, func(timeout <-chan time.Time) bool {
+				select {
+				case contractTx = <-p2p.ContractTxChan:
+					//This check is important. A malicious miner might have sent us a tx whose hash is a different one
+					//from what we requested.
+					return contractTx.Hash() == txHash
+				case <-timeout:
+				case <-ctx.Done():
+				}
+				return false
+			})
+			if err != nil {
+				errChan <- err
+				return
 			}
 		}
 
-		iotTxSlice[cnt] = IoTTx
+		contractTxSlice[cnt] = contractTx
 	}
 
 	errChan <- nil
 }
 
 //We use slices (not maps) because order is now important.
-func fetchAccTxData(block *protocol.Block, accTxSlice []*protocol.AccTx, initialSetup bool, errChan chan error) {
+func fetchAccTxData(ctx context.Context, block *protocol.Block, accTxSlice []*protocol.AccTx, initialSetup bool, errChan chan error) {
 	for cnt, txHash := range block.AccTxData {
 		var tx protocol.Transaction
 		var accTx *protocol.AccTx
@@ -663,24 +916,23 @@ func fetchAccTxData(block *protocol.Block, accTxSlice []*protocol.AccTx, initial
 		if tx != nil {
 			accTx = tx.(*protocol.AccTx)
 		} else {
-			err := p2p.TxReq(txHash, p2p.ACCTX_REQ)
+			err := fetch(ctx, "AccTx", TXFETCH_TIMEOUT*time.Second, func() error {
+				return p2p.TxReq(txHash, p2p.ACCTX_REQ)
+			}, func(timeout <-chan time.Time) bool {
+				select {
+				case accTx = <-p2p.AccTxChan:
+					//This check is important. A malicious miner might have sent us a tx whose hash is a different one
+					//from what we requested.
+					return accTx.Hash() == txHash
+				case <-timeout:
+				case <-ctx.Done():
+				}
+				return false
+			})
 			if err != nil {
-				errChan <- errors.New(fmt.Sprintf("AccTx could not be read: %v", err))
+				errChan <- err
 				return
 			}
-
-			//Blocking Wait
-			select {
-			case accTx = <-p2p.AccTxChan:
-				//Limit the waiting time for TXFETCH_TIMEOUT seconds.
-			case <-time.After(TXFETCH_TIMEOUT * time.Second):
-				errChan <- errors.New("AccTx fetch timed out.")
-			}
-			//This check is important. A malicious miner might have sent us a tx whose hash is a different one
-			//from what we requested.
-			if accTx.Hash() != txHash {
-				errChan <- errors.New("Received AcctxHash did not correspond to our request.")
-			}
 		}
 
 		accTxSlice[cnt] = accTx
@@ -689,7 +941,8 @@ func fetchAccTxData(block *protocol.Block, accTxSlice []*protocol.AccTx, initial
 	errChan <- nil
 }
 
-func fetchFundsTxData(block *protocol.Block, fundsTxSlice []*protocol.FundsTx, initialSetup bool, errChan chan error) {
+func fetchFundsTxData(ctx context.Context, block *protocol.Block, fundsTxSlice []*protocol.FundsTx, initialSetup bool, errChan chan error) {
+	var missing [][32]byte
 	for cnt, txHash := range block.FundsTxData {
 		var tx protocol.Transaction
 		var fundsTx *protocol.FundsTx
@@ -716,33 +969,30 @@ func fetchFundsTxData(block *protocol.Block, fundsTxSlice []*protocol.FundsTx, i
 		} else if  txINVALID != nil && verify(txINVALID) {
 			fundsTx = txINVALID.(*protocol.FundsTx)
 		} else {
-			err := p2p.TxReq(txHash, p2p.FUNDSTX_REQ)
-			if err != nil {
-				errChan <- errors.New(fmt.Sprintf("FundsTx could not be read: %v", err))
-				return
-			}
-			select {
-			case fundsTx = <-p2p.FundsTxChan:
-				storage.WriteOpenTx(fundsTx)
-				if initialSetup {
-					storage.WriteBootstrapTxReceived(fundsTx)
-				}
-			case <-time.After(TXFETCH_TIMEOUT * time.Second):
-				errChan <- errors.New("FundsTx fetch timed out")
-				return
-			}
-			if fundsTx.Hash() != txHash {
-				errChan <- errors.New("Received FundstxHash did not correspond to our request.")
-			}
+			missing = append(missing, txHash)
+			continue
 		}
 
 		fundsTxSlice[cnt] = fundsTx
 	}
 
+	if len(missing) > 0 {
+		fetched, err := fetchFundsTxBatch(ctx, missing, initialSetup)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		for cnt, txHash := range block.FundsTxData {
+			if fundsTxSlice[cnt] == nil {
+				fundsTxSlice[cnt] = fetched[txHash]
+			}
+		}
+	}
+
 	errChan <- nil
 }
 
-func fetchConfigTxData(block *protocol.Block, configTxSlice []*protocol.ConfigTx, initialSetup bool, errChan chan error) {
+func fetchConfigTxData(ctx context.Context, block *protocol.Block, configTxSlice []*protocol.ConfigTx, initialSetup bool, errChan chan error) {
 	for cnt, txHash := range block.ConfigTxData {
 		var tx protocol.Transaction
 		var configTx *protocol.ConfigTx
@@ -764,21 +1014,23 @@ func fetchConfigTxData(block *protocol.Block, configTxSlice []*protocol.ConfigTx
 		if tx != nil {
 			configTx = tx.(*protocol.ConfigTx)
 		} else {
-			err := p2p.TxReq(txHash, p2p.CONFIGTX_REQ)
+			err := fetch(ctx, "ConfigTx", TXFETCH_TIMEOUT*time.Second, func() error {
+				return p2p.TxReq(txHash, p2p.CONFIGTX_REQ)
+			}, func(timeout <-chan time.Time) bool {
+				select {
+				case configTx = <-p2p.ConfigTxChan:
+					//This check is important. A malicious miner might have sent us a tx whose hash is a different one
+					//from what we requested.
+					return configTx.Hash() == txHash
+				case <-timeout:
+				case <-ctx.Done():
+				}
+				return false
+			})
 			if err != nil {
-				errChan <- errors.New(fmt.Sprintf("ConfigTx could not be read: %v", err))
-				return
-			}
-
-			select {
-			case configTx = <-p2p.ConfigTxChan:
-			case <-time.After(TXFETCH_TIMEOUT * time.Second):
-				errChan <- errors.New("ConfigTx fetch timed out.")
+				errChan <- err
 				return
 			}
-			if configTx.Hash() != txHash {
-				errChan <- errors.New("Received ConfigtxHash did not correspond to our request.")
-			}
 		}
 
 		configTxSlice[cnt] = configTx
@@ -787,7 +1039,7 @@ func fetchConfigTxData(block *protocol.Block, configTxSlice []*protocol.ConfigTx
 	errChan <- nil
 }
 
-func fetchStakeTxData(block *protocol.Block, stakeTxSlice []*protocol.StakeTx, initialSetup bool, errChan chan error) {
+func fetchStakeTxData(ctx context.Context, block *protocol.Block, stakeTxSlice []*protocol.StakeTx, initialSetup bool, errChan chan error) {
 	for cnt, txHash := range block.StakeTxData {
 		var tx protocol.Transaction
 		var stakeTx *protocol.StakeTx
@@ -808,21 +1060,23 @@ func fetchStakeTxData(block *protocol.Block, stakeTxSlice []*protocol.StakeTx, i
 		if tx != nil {
 			stakeTx = tx.(*protocol.StakeTx)
 		} else {
-			err := p2p.TxReq(txHash, p2p.STAKETX_REQ)
+			err := fetch(ctx, "StakeTx", TXFETCH_TIMEOUT*time.Second, func() error {
+				return p2p.TxReq(txHash, p2p.STAKETX_REQ)
+			}, func(timeout <-chan time.Time) bool {
+				select {
+				case stakeTx = <-p2p.StakeTxChan:
+					//This check is important. A malicious miner might have sent us a tx whose hash is a different one
+					//from what we requested.
+					return stakeTx.Hash() == txHash
+				case <-timeout:
+				case <-ctx.Done():
+				}
+				return false
+			})
 			if err != nil {
-				errChan <- errors.New(fmt.Sprintf("StakeTx could not be read: %v", err))
-				return
-			}
-
-			select {
-			case stakeTx = <-p2p.StakeTxChan:
-			case <-time.After(TXFETCH_TIMEOUT * time.Second):
-				errChan <- errors.New("StakeTx fetch timed out.")
+				errChan <- err
 				return
 			}
-			if stakeTx.Hash() != txHash {
-				errChan <- errors.New("Received StaketxHash did not correspond to our request.")
-			}
 		}
 
 		stakeTxSlice[cnt] = stakeTx
@@ -831,7 +1085,7 @@ func fetchStakeTxData(block *protocol.Block, stakeTxSlice []*protocol.StakeTx, i
 	errChan <- nil
 }
 
-func fetchAggTxData(block *protocol.Block, aggTxSlice []*protocol.AggTx, aggregatedFundsTxSlice []*protocol.FundsTx, initialSetup bool, errChan chan error) {
+func fetchAggTxData(ctx context.Context, block *protocol.Block, aggTxSlice []*protocol.AggTx, aggregatedFundsTxSlice []*protocol.FundsTx, initialSetup bool, errChan chan error) {
 	errAggFundsTxFetchChan := make(chan error, 1)
 	var errAggFundsTxFetch error
 
@@ -849,7 +1103,7 @@ func fetchAggTxData(block *protocol.Block, aggTxSlice []*protocol.AggTx, aggrega
 				}
 				aggregatedFundsTxSlice = make([]*protocol.FundsTx, len(aggregatedFundsTxSliceHashes))
 
-				go fetchAggregatedFundsTxData(aggregatedFundsTxSliceHashes, aggregatedFundsTxSlice, initialSetup,errAggFundsTxFetchChan)
+				go fetchAggregatedFundsTxData(ctx, aggregatedFundsTxSliceHashes, aggregatedFundsTxSlice, initialSetup,errAggFundsTxFetchChan)
 
 				errAggFundsTxFetch = <-errAggFundsTxFetchChan
 
@@ -876,46 +1130,40 @@ func fetchAggTxData(block *protocol.Block, aggTxSlice []*protocol.AggTx, aggrega
 		//} else if  txINVALID != nil && verify(txINVALID) {
 		//	aggTx = txINVALID.(*protocol.AggTx)
 		} else {
-			cnt := 0
-			here:
-			cnt +=1
-			err := p2p.TxReq(txHash, p2p.AGGTX_REQ)
+			err := fetch(ctx, "AggTx", TXFETCH_TIMEOUT*time.Second, func() error {
+				return p2p.TxReq(txHash, p2p.AGGTX_REQ)
+			}, func(timeout <-chan time.Time) bool {
+				select {
+				case aggTx = <-p2p.AggTxChan:
+					return aggTx.Hash() == txHash
+				case <-timeout:
+				case <-ctx.Done():
+				}
+				return false
+			})
 			if err != nil {
-				errChan <- errors.New(fmt.Sprintf("AggTx could not be read: %v", err))
+				logger.Printf("Fetching (%x) failed... from Block: %v", txHash, block)
+				errChan <- err
 				return
 			}
 
-			select {
-			case aggTx = <-p2p.AggTxChan:
-				storage.WriteOpenTx(aggTx)
-				if initialSetup {
-					storage.WriteBootstrapTxReceived(aggTx)
-				}
-				for _, trx := range aggTx.AggregatedTxSlice {
-					aggregatedFundsTxSliceHashes = append(aggregatedFundsTxSliceHashes, trx)
-				}
-				aggregatedFundsTxSlice = make([]*protocol.FundsTx, len(aggregatedFundsTxSliceHashes))
-
-				go fetchAggregatedFundsTxData(aggregatedFundsTxSliceHashes, aggregatedFundsTxSlice, initialSetup, errAggFundsTxFetchChan)
+			storage.WriteOpenTx(aggTx)
+			if initialSetup {
+				storage.WriteBootstrapTxReceived(aggTx)
+			}
+			for _, trx := range aggTx.AggregatedTxSlice {
+				aggregatedFundsTxSliceHashes = append(aggregatedFundsTxSliceHashes, trx)
+			}
+			aggregatedFundsTxSlice = make([]*protocol.FundsTx, len(aggregatedFundsTxSliceHashes))
 
-				errAggFundsTxFetch = <-errAggFundsTxFetchChan
+			go fetchAggregatedFundsTxData(ctx, aggregatedFundsTxSliceHashes, aggregatedFundsTxSlice, initialSetup, errAggFundsTxFetchChan)
 
-				if errAggFundsTxFetch != nil {
-					errChan <- errAggFundsTxFetch
-				}
+			errAggFundsTxFetch = <-errAggFundsTxFetchChan
 
-			case <-time.After(TXFETCH_TIMEOUT * time.Second):
-				logger.Printf("Fetching (%x) timed out... from Block: %v", txHash, block)
-				errChan <- errors.New("AggTx fetch timed out")
+			if errAggFundsTxFetch != nil {
+				errChan <- errAggFundsTxFetch
 				return
 			}
-
-			//three tries to fetch correct AggTx
-			if aggTx.Hash() != txHash && cnt < 2 {
-				goto here
-				errChan <- errors.New("Received AggTxHash did not correspond to our request.")
-			}
-
 		}
 
 		aggTxSlice[cnt] = aggTx
@@ -925,7 +1173,8 @@ func fetchAggTxData(block *protocol.Block, aggTxSlice []*protocol.AggTx, aggrega
 }
 
 
-func fetchAggregatedFundsTxData(aggregatedFundsTxHashesSlice [][32]byte, aggregatedFundsTxSlice []*protocol.FundsTx, initialSetup bool, errAggFundsTxFetchChan chan error) {
+func fetchAggregatedFundsTxData(ctx context.Context, aggregatedFundsTxHashesSlice [][32]byte, aggregatedFundsTxSlice []*protocol.FundsTx, initialSetup bool, errAggFundsTxFetchChan chan error) {
+	var missing [][32]byte
 	for cnt, txHash := range aggregatedFundsTxHashesSlice {
 		var tx protocol.Transaction
 		var fundsTx *protocol.FundsTx
@@ -937,7 +1186,7 @@ func fetchAggregatedFundsTxData(aggregatedFundsTxHashesSlice [][32]byte, aggrega
 				aggregatedFundsTxSlice[cnt] = fundsTx
 				continue
 			} else {
-				logger.Printf("Block validation had fundsTx (%x, %v) that was already in a previous block (%x).", closedTx.Hash(), closedTx.Hash())
+				logger.Printf("Block validation had fundsTx (%x) that was already in a previous block.", closedTx.Hash())
 				errAggFundsTxFetchChan <- errors.New("Block validation had fundsTx that was already in a previous block.")
 				return
 			}
@@ -953,46 +1202,145 @@ func fetchAggregatedFundsTxData(aggregatedFundsTxHashesSlice [][32]byte, aggrega
 		} else if  txINVALID != nil && verify(txINVALID) {
 			fundsTx = txINVALID.(*protocol.FundsTx)
 		} else {
-			err := p2p.TxReq(txHash, p2p.FUNDSTX_REQ)
-			if err != nil {
-				errAggFundsTxFetchChan <- errors.New(fmt.Sprintf("FundsTx could not be read: %v", err))
-				return
+			missing = append(missing, txHash)
+			continue
+		}
+
+		aggregatedFundsTxSlice[cnt] = fundsTx
+		fundsTx = nil
+	}
+
+	if len(missing) > 0 {
+		fetched, err := fetchFundsTxBatch(ctx, missing, initialSetup)
+		if err != nil {
+			errAggFundsTxFetchChan <- err
+			return
+		}
+		for cnt, txHash := range aggregatedFundsTxHashesSlice {
+			if aggregatedFundsTxSlice[cnt] == nil {
+				aggregatedFundsTxSlice[cnt] = fetched[txHash]
 			}
-			select {
-			case fundsTx = <-p2p.FundsTxChan:
-				storage.WriteOpenTx(fundsTx)
-				if initialSetup {
-					storage.WriteBootstrapTxReceived(fundsTx)
+		}
+	}
+
+	errAggFundsTxFetchChan <- nil
+}
+
+//Fetches the FundsTxs not available locally with as few requests as possible, see p2p.BatchTxReq.
+func fetchFundsTxBatch(ctx context.Context, txHashes [][32]byte, initialSetup bool) (map[[32]byte]*protocol.FundsTx, error) {
+	fetched := make(map[[32]byte]*protocol.FundsTx)
+
+	//A tx requested twice would only be delivered once.
+	var unique [][32]byte
+	requested := make(map[[32]byte]bool)
+	for _, txHash := range txHashes {
+		if !requested[txHash] {
+			requested[txHash] = true
+			unique = append(unique, txHash)
+		}
+	}
+
+	for start := 0; start < len(unique); start += p2p.MAX_BATCH_TX_REQ {
+		end := start + p2p.MAX_BATCH_TX_REQ
+		if end > len(unique) {
+			end = len(unique)
+		}
+		batch := unique[start:end]
+
+		var pending [][32]byte
+		var txChan chan protocol.Transaction
+		err := fetch(ctx, "FundsTx", TXFETCH_TIMEOUT*time.Second, func() (err error) {
+			//Retries only request the txs still missing.
+			pending = nil
+			for _, txHash := range batch {
+				if fetched[txHash] == nil {
+					pending = append(pending, txHash)
 				}
-			case <-time.After(TXFETCH_TIMEOUT * time.Second):
-				errAggFundsTxFetchChan <- errors.New("FundsTx fetch timed out")
-				return
 			}
-
-			if fundsTx.Hash() != txHash {
-				errAggFundsTxFetchChan <- errors.New("Received AggregatedFundsTxHash did not correspond to our request.")
+			txChan, err = p2p.BatchTxReq(pending, p2p.FUNDSTX_REQ)
+			return err
+		}, func(timeout <-chan time.Time) bool {
+			defer p2p.CancelBatchTxReq(pending, txChan)
+			for received := 0; received < len(pending); received++ {
+				select {
+				case tx := <-txChan:
+					fundsTx, ok := tx.(*protocol.FundsTx)
+					if !ok {
+						return false
+					}
+					storage.WriteOpenTx(fundsTx)
+					if initialSetup {
+						storage.WriteBootstrapTxReceived(fundsTx)
+					}
+					fetched[fundsTx.Hash()] = fundsTx
+				case <-timeout:
+					return false
+				case <-ctx.Done():
+					return false
+				}
 			}
+			return true
+		})
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		aggregatedFundsTxSlice[cnt] = fundsTx
-		fundsTx = nil
+	return fetched, nil
+}
+
+//Contracts called by other contracts are copied to the block's state copy once they are loaded.
+func stateCopyLoader(b *protocol.Block) func(hash [32]byte) *protocol.Account {
+	return func(hash [32]byte) *protocol.Account {
+		if acc, exists := b.StateCopy[hash]; exists {
+			return acc
+		}
+
+		acc := storage.State.Get(hash)
+		if acc == nil {
+			return nil
+		}
+		//The contract variables are changed in place by the VM (see Context.PersistChanges), they must not be shared with
+		//the state.
+		newAcc := *acc
+		newAcc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
+		b.StateCopy[hash] = &newAcc
+
+		return &newAcc
 	}
+}
 
-	errAggFundsTxFetchChan <- nil
+//Contracts are arbitrary code, a panic in the VM only rejects the tx calling the contract.
+func execContract(virtualMachine *vm.VM, txHash [32]byte) (err error) {
+	defer crash.RecoverError("vm", txHash[:], "contract execution", &err)
+
+	if !virtualMachine.Exec(false) {
+		return errors.New(virtualMachine.GetErrorMsg())
+	}
+
+	return nil
 }
 
 //This function is split into block syntax/PoS check and actual state change
 //because there is the case that we might need to go fetch several blocks
 // and have to check the blocks first before changing the state in the correct order.
-func validate(b *protocol.Block, initialSetup bool) error {
+func validate(b *protocol.Block, initialSetup bool) (err error) {
+	//A panic rejects the block instead of stopping the node, the crash report helps to find the offending block.
+	defer crash.RecoverError("validation", b.Hash[:], fmt.Sprintf("block at height %v", b.Height), &err)
 
 	//This mutex is necessary that own-mined blocks and received blocks from the network are not
 	//validated concurrently.
 	blockValidation.Lock()
 	defer blockValidation.Unlock()
+	defer func() { recordValidationError(b, err) }()
+
+	//The state changes of the block being validated (or rolled back) are reverted if the validation fails or panics
+	//halfway. Blocks already completed stay applied.
+	defer storage.RevertStateTransition()
 
-	//Prepare datastructure to fill tx payloads.
-	blockDataMap := make(map[[32]byte]blockData)
+	//A competing block received in the meantime cancels the tx fetches of the blocks, see pipeline.go.
+	ctx := startValidation(b)
+	defer finishValidation()
 
 	//Get the right branch, and a list of blocks to rollback (if necessary).
 	blocksToRollback, blocksToValidate, err := getBlockSequences(b)
@@ -1017,126 +1365,142 @@ func validate(b *protocol.Block, initialSetup bool) error {
 		uptodate = true
 	}
 
+	//Checked before anything is rolled back, the blocks are ordered by height.
+	if trustedHeight, err = trustedCheckpointHeight(blocksToValidate); err != nil {
+		return err
+	}
+	defer func() { trustedHeight = 0 }()
+
 	//No rollback needed, just a new block to validate.
 	if len(blocksToRollback) == 0 {
 		for _, block := range blocksToValidate {
-			//Fetching payload data from the txs (if necessary, ask other miners).
-			accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, err := preValidate(block, initialSetup)
-
-			//Check if the validator that added the block has previously voted on different competing chains (find slashing proof).
-			//The proof will be stored in the global slashing dictionary.
-			if block.Height > 0 {
-				seekSlashingProof(block)
-			}
-
-			if err != nil {
+			if err := validateBlock(ctx, block, initialSetup); err != nil {
 				return err
 			}
-
-			blockDataMap[block.Hash] = blockData{accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, block}
-			if err := validateState(blockDataMap[block.Hash]); err != nil {
-				return err
-			}
-
-			postValidate(blockDataMap[block.Hash], initialSetup)
+			storage.CommitStateTransition()
 		}
 	} else {
 		logger.Printf("ROLLBACK")
+		//The weight of the rolled back blocks is deleted, see keepHeavierChain.
+		_, activeWeight := storage.ReadBlockWeight(blocksToRollback[0].Hash)
 		for _, block := range blocksToRollback {
 			if err := rollback(block); err != nil {
 				return err
 			}
 			//logger.Printf("Rolled back block: %vState:\n%v", block, getState())
 			logger.Printf("Rolled back block: %v", block.Hash)
+			blockRollbacks.Add(1)
 			//logger.Printf("Total Transactions in this block: %v", -1*int(uint16(block.NrFundsTx) + uint16(block.NrAccTx) + uint16(block.NrConfigTx) + uint16(block.NrStakeTx)))
 		}
 		for _, block := range blocksToValidate {
-			//Fetching payload data from the txs (if necessary, ask other miners).
-			accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, err := preValidate(block, initialSetup)
-
-			//Check if the validator that added the block has previously voted on different competing chains (find slashing proof).
-			//The proof will be stored in the global slashing dictionary.
-			if block.Height > 0 {
-				seekSlashingProof(block)
-			}
-
-			if err != nil {
-				return err
-			}
-
-			blockDataMap[block.Hash] = blockData{accTxs, fundsTxs, configTxs, stakeTxs, aggTxs,iotTxs, block}
-			if err := validateState(blockDataMap[block.Hash]); err != nil {
+			if err := validateBlock(ctx, block, initialSetup); err != nil {
 				return err
 			}
-
-			postValidate(blockDataMap[block.Hash], initialSetup)
+			storage.CommitStateTransition()
 			//logger.Printf("Validated block (after rollback): %x", block.Hash[0:8])
 			logger.Printf("Validated block (after rollback): %v", block)
 		}
+		seekRollbackSlashingProofs(blocksToRollback, blocksToValidate)
+		if err := keepHeavierChain(ctx, blocksToRollback, blocksToValidate, activeWeight, initialSetup); err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-//Doesn't involve any state changes.
-func preValidate(block *protocol.Block, initialSetup bool) (accTxSlice []*protocol.AccTx, fundsTxSlice []*protocol.FundsTx, configTxSlice []*protocol.ConfigTx, stakeTxSlice []*protocol.StakeTx, aggTxSlice []*protocol.AggTx, iotTxSlice []*protocol.IotTx, err error) {
+//Doesn't involve any state changes. The tx fetches are aborted once ctx is cancelled.
+func preValidate(ctx context.Context, block *protocol.Block, initialSetup bool) (accTxSlice []*protocol.AccTx, fundsTxSlice []*protocol.FundsTx, configTxSlice []*protocol.ConfigTx, stakeTxSlice []*protocol.StakeTx, aggTxSlice []*protocol.AggTx, iotTxSlice []*protocol.IotTx, contractTxSlice []*protocol.ContractTx, err error) {
 	//This dynamic check is only done if we're up-to-date with syncing, otherwise timestamp is not checked.
 	//Other miners (which are up-to-date) made sure that this is correct.
 	if !initialSetup && uptodate {
 		if err := timestampCheck(block.Timestamp); err != nil {
-			return nil, nil, nil, nil, nil, nil, err
+			countRejectedBlock(REJECTED_TIMESTAMP)
+			return nil, nil, nil, nil, nil, nil, nil, err
 		}
 	}
 
+	//Blocks are checked against the parameters that were active when they were produced, which differ from the
+	//active ones when replaying the chain.
+	params := parametersAt(block.Height)
+
 	//Check block size.
-	if block.GetSize() > activeParameters.Block_size {
-		return nil, nil, nil, nil, nil, nil, errors.New("Block size too large.")
+	if block.GetSize() > params.Block_size {
+		countRejectedBlock(REJECTED_BLOCK_SIZE)
+		return nil, nil, nil, nil, nil, nil, nil, errors.New("Block size too large.")
 	}
 
 	//Duplicates are not allowed, use tx hash hashmap to easily check for duplicates.
 	duplicates := make(map[[32]byte]bool)
 	for _, txHash := range block.AccTxData {
 		if _, exists := duplicates[txHash]; exists {
-			return nil, nil, nil, nil,  nil,nil, errors.New("Duplicate Account Transaction Hash detected.")
+			countRejectedBlock(REJECTED_DUPLICATE_TX)
+			return nil, nil, nil, nil,  nil,nil, nil, errors.New("Duplicate Account Transaction Hash detected.")
 		}
 		duplicates[txHash] = true
 	}
 	for _, txHash := range block.FundsTxData {
 		if _, exists := duplicates[txHash]; exists {
-			return nil, nil, nil, nil, nil, nil, errors.New("Duplicate Funds Transaction Hash detected.")
+			countRejectedBlock(REJECTED_DUPLICATE_TX)
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("Duplicate Funds Transaction Hash detected.")
 		}
 		duplicates[txHash] = true
 	}
 	for _, txHash := range block.ConfigTxData {
 		if _, exists := duplicates[txHash]; exists {
-			return nil, nil, nil, nil, nil, nil, errors.New("Duplicate Config Transaction Hash detected.")
+			countRejectedBlock(REJECTED_DUPLICATE_TX)
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("Duplicate Config Transaction Hash detected.")
 		}
 		duplicates[txHash] = true
 	}
 	for _, txHash := range block.StakeTxData {
 		if _, exists := duplicates[txHash]; exists {
-			return nil, nil, nil, nil, nil, nil, errors.New("Duplicate Stake Transaction Hash detected.")
+			countRejectedBlock(REJECTED_DUPLICATE_TX)
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("Duplicate Stake Transaction Hash detected.")
 		}
 		duplicates[txHash] = true
 	}
 
 	for _, txHash := range block.AggTxData {
 		if _, exists := duplicates[txHash]; exists {
-			return nil, nil, nil, nil, nil, nil, errors.New("Duplicate Aggregation Transaction Hash detected.")
+			countRejectedBlock(REJECTED_DUPLICATE_TX)
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("Duplicate Aggregation Transaction Hash detected.")
 		}
 		duplicates[txHash] = true
 	}
 
 	for _, txHash := range block.IoTTxData {
 		if _, exists := duplicates[txHash]; exists {
-			return nil, nil, nil, nil, nil, nil, errors.New("Duplicate IoT Transaction Hash detected.")
+			countRejectedBlock(REJECTED_DUPLICATE_TX)
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("Duplicate IoT Transaction Hash detected.")
+		}
+		duplicates[txHash] = true
+	}
+
+	for _, txHash := range block.ContractTxData {
+		if _, exists := duplicates[txHash]; exists {
+			countRejectedBlock(REJECTED_DUPLICATE_TX)
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("Duplicate Contract Transaction Hash detected.")
 		}
 		duplicates[txHash] = true
 	}
 
+	//Txs of previous blocks can not be replayed, also if the blocks have been pruned and the txs deleted since.
+	if !initialSetup {
+		for txHash := range duplicates {
+			if storage.IsClosedTx(txHash) {
+				countRejectedBlock(REJECTED_DUPLICATE_TX)
+				return nil, nil, nil, nil, nil, nil, nil, errors.New(fmt.Sprintf("Tx %x is already in a previous block.", txHash[0:8]))
+			}
+		}
+	}
 
-	//We fetch tx data for each type in parallel -> performance boost.
-	nrOfChannels := 6
+
+	//We fetch tx data for each type in parallel -> performance boost. The remaining fetches are cancelled as soon as
+	//one of them fails.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	nrOfChannels := 7
 	errChan := make(chan error, nrOfChannels)
 
 	//We need to allocate slice space for the underlying array when we pass them as reference.
@@ -1146,22 +1510,34 @@ func preValidate(block *protocol.Block, initialSetup bool) (accTxSlice []*protoc
 	stakeTxSlice = make([]*protocol.StakeTx, block.NrStakeTx)
 	aggTxSlice = make([]*protocol.AggTx, block.NrAggTx)
 	iotTxSlice = make([]*protocol.IotTx, block.NrIoTTx)
+	contractTxSlice = make([]*protocol.ContractTx, block.NrContractTx)
 
 	var aggregatedFundsTxSlice []*protocol.FundsTx
 
-	go fetchAccTxData(block, accTxSlice, initialSetup, errChan)
-	go fetchFundsTxData(block, fundsTxSlice, initialSetup, errChan)
-	go fetchConfigTxData(block, configTxSlice, initialSetup, errChan)
-	go fetchStakeTxData(block, stakeTxSlice, initialSetup, errChan)
-	go fetchAggTxData(block, aggTxSlice, aggregatedFundsTxSlice, initialSetup, errChan)
-	go fetchIotTxData(block, iotTxSlice, initialSetup, errChan)
+	go fetchAccTxData(ctx, block, accTxSlice, initialSetup, errChan)
+	go fetchFundsTxData(ctx, block, fundsTxSlice, initialSetup, errChan)
+	go fetchConfigTxData(ctx, block, configTxSlice, initialSetup, errChan)
+	go fetchStakeTxData(ctx, block, stakeTxSlice, initialSetup, errChan)
+	go fetchAggTxData(ctx, block, aggTxSlice, aggregatedFundsTxSlice, initialSetup, errChan)
+	go fetchIotTxData(ctx, block, iotTxSlice, initialSetup, errChan)
+	go fetchContractTxData(ctx, block, contractTxSlice, initialSetup, errChan)
 
 
 	//Wait for all goroutines to finish.
 	for cnt := 0; cnt < nrOfChannels; cnt++ {
 		err = <-errChan
 		if err != nil {
-			return nil, nil, nil, nil, nil, nil, err
+			countRejectedBlock(REJECTED_TX_FETCH)
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
+	}
+
+	//Like the timestamp, the order is not checked for the blocks loaded at startup, they were accepted before. Blocks up
+	//to the Tx_order_height, e.g., of chains started before the order was introduced, are not checked either.
+	if !initialSetup && uint64(block.Height) > params.Tx_order_height {
+		if err := txOrderCheck(accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, iotTxSlice, contractTxSlice); err != nil {
+			countRejectedBlock(REJECTED_TX_ORDER)
+			return nil, nil, nil, nil, nil, nil, nil, err
 		}
 	}
 
@@ -1169,209 +1545,402 @@ func preValidate(block *protocol.Block, initialSetup bool) (accTxSlice []*protoc
 		fundsTxSlice = append(fundsTxSlice, aggregatedFundsTxSlice...)
 	}
 
-	//Check state contains beneficiary.
-	acc, err := storage.GetAccount(block.Beneficiary)
-	if err != nil {
-		return nil, nil, nil, nil, nil, nil, err
+	//Like the order, aggTxs were verified when the blocks loaded at startup were accepted. Blocks up to the
+	//Agg_tx_auth_height, e.g., of chains started before aggTxs were authenticated, are not checked either.
+	if !initialSetup && uint64(block.Height) > params.Agg_tx_auth_height {
+		if err := aggTxCheck(aggTxSlice); err != nil {
+			countRejectedBlock(REJECTED_AGG_TX)
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
 	}
 
-	//Check if node is part of the validator set.
-	if !acc.IsStaking {
-		return nil, nil, nil, nil, nil, nil, errors.New("Validator is not part of the validator set.")
+	//Locked txs must not be included before their unlock point.
+	if err := lockCheck(block, fundsTxSlice, aggTxSlice); err != nil {
+		countRejectedBlock(REJECTED_LOCKED_TX)
+		return nil, nil, nil, nil, nil, nil, nil, err
 	}
 
-	//First, initialize an RSA Public Key instance with the modulus of the proposer of the block (acc)
-	//Second, check if the commitment proof of the proposed block can be verified with the public key
-	//Invalid if the commitment proof can not be verified with the public key of the proposer
-	//TODO: @ilecipi
-	commitmentPubKey, err := crypto.CreateRSAPubKeyFromBytes(acc.CommitmentKey)
+	//Check state contains beneficiary.
+	acc, err := storage.GetAccount(block.Beneficiary)
 	if err != nil {
-		return nil, nil, nil, nil,nil, nil, errors.New("Invalid commitment key in account.")
+		countRejectedBlock(REJECTED_BENEFICIARY)
+		return nil, nil, nil, nil, nil, nil, nil, err
 	}
 
-	err = crypto.VerifyMessageWithRSAKey(commitmentPubKey, fmt.Sprint(block.Height), block.CommitmentProof)
+	//Check if node is part of the validator set of the block's epoch, see validatorset.go.
+	validator, err := getValidator(block.Beneficiary, block.Height)
 	if err != nil {
-		return nil, nil, nil, nil, nil,nil, errors.New("The submitted commitment proof can not be verified.")
+		countRejectedBlock(REJECTED_NOT_VALIDATOR)
+		return nil, nil, nil, nil, nil, nil, nil, err
 	}
-	//Invalid if PoS calculation is not correct.
-	prevProofs := GetLatestProofs(activeParameters.num_included_prev_proofs, block)
 
-	//PoS validation
-	if !validateProofOfStake(getDifficulty(), prevProofs, block.Height, acc.Balance, block.CommitmentProof, block.Timestamp) {
-		return nil, nil, nil, nil, nil,nil, errors.New("The nonce is incorrect.")
+	//Blocks covered by a trusted checkpoint skip the expensive commitment proof and proof of stake verification.
+	if block.Height <= trustedHeight {
+		if err := verifyBlockHashes(block); err != nil {
+			countRejectedBlock(REJECTED_CHECKPOINT)
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
+	} else {
+		//Invalid if the commitment proof can not be verified with the commitment key (RSA or VRF, see commitment.go)
+		//of the proposer
+		if err := checkCommitmentKey(validator.CommitmentKey, block.Height); err != nil {
+			countRejectedBlock(REJECTED_COMMITMENT_KEY)
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
+
+		if err := verifyCommitmentProof(validator.CommitmentKey, block.Height, block.CommitmentProof); err != nil {
+			countRejectedBlock(REJECTED_COMMITMENT_PROOF)
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
+		//Invalid if PoS calculation is not correct.
+		prevProofs := GetLatestProofs(params.num_included_prev_proofs, block)
+
+		//PoS validation
+		if !validateProofOfStake(getDifficulty(), prevProofs, block.Height, validator.Balance, block.CommitmentProof, block.Timestamp) {
+			countRejectedBlock(REJECTED_PROOF_OF_STAKE)
+			return nil, nil, nil, nil, nil,nil, nil, errors.New("The nonce is incorrect.")
+		}
 	}
 
 	//Invalid if PoS is too far in the future.
 	now := time.Now()
-	if block.Timestamp > now.Unix()+int64(activeParameters.Accepted_time_diff) {
-		return nil, nil, nil, nil, nil, nil,errors.New("The timestamp is too far in the future. " + string(block.Timestamp) + " vs " + string(now.Unix()))
+	if block.Timestamp > now.Unix()+int64(params.Accepted_time_diff) {
+		countRejectedBlock(REJECTED_TIMESTAMP)
+		return nil, nil, nil, nil, nil, nil, nil, errors.New("The timestamp is too far in the future. " + fmt.Sprint(block.Timestamp) + " vs " + fmt.Sprint(now.Unix()))
+	}
+
+	//Nor may it be in the past of the chain, see mediantime.go. Not checked for the blocks loaded at startup, they were
+	//accepted before.
+	if !initialSetup {
+		if err := medianTimeCheck(block); err != nil {
+			countRejectedBlock(REJECTED_TIMESTAMP)
+			return nil, nil, nil, nil, nil, nil, nil, err
+		}
 	}
 
 	//Check for minimum waiting time.
-	if block.Height-acc.StakingBlockHeight < uint32(activeParameters.Waiting_minimum) {
-		return nil, nil, nil, nil, nil,nil, errors.New("The miner must wait a minimum amount of blocks before start validating. Block Height:" + fmt.Sprint(block.Height) + " - Height when started validating " + string(acc.StakingBlockHeight) + " MinWaitingTime: " + string(activeParameters.Waiting_minimum))
+	if block.Height-acc.StakingBlockHeight < uint32(params.Waiting_minimum) {
+		countRejectedBlock(REJECTED_WAITING_MINIMUM)
+		return nil, nil, nil, nil, nil,nil, nil, errors.New("The miner must wait a minimum amount of blocks before start validating. Block Height:" + fmt.Sprint(block.Height) + " - Height when started validating " + fmt.Sprint(acc.StakingBlockHeight) + " MinWaitingTime: " + fmt.Sprint(params.Waiting_minimum))
 	}
 
 	//Check if block contains a proof for two conflicting block hashes, else no proof provided.
 	if block.SlashedAddress != [32]byte{} {
-		if _, err = slashingCheck(block.SlashedAddress, block.ConflictingBlockHash1, block.ConflictingBlockHash2, block.ConflictingBlockHashWithoutTx1, block.ConflictingBlockHashWithoutTx2); err != nil {
-			return nil, nil, nil, nil, nil,nil, err
+		if _, err = slashingCheck(block.SlashedAddress, block.ConflictingBlockHash1, block.ConflictingBlockHash2, block.ConflictingBlockHashWithoutTx1, block.ConflictingBlockHashWithoutTx2, params.Slashing_window_size); err != nil {
+			countRejectedBlock(REJECTED_SLASHING_PROOF)
+			return nil, nil, nil, nil, nil,nil, nil, err
 		}
 	}
 
 	//Merkle Tree validation
 	if block.Aggregated == false && protocol.BuildMerkleTree(block).MerkleRoot() != block.MerkleRoot {
-		return nil, nil, nil, nil, nil,nil, errors.New("Merkle Root is incorrect.")
+		countRejectedBlock(REJECTED_MERKLE_ROOT)
+		return nil, nil, nil, nil, nil,nil, nil, errors.New("Merkle Root is incorrect.")
+	}
+
+	return accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, aggTxSlice, iotTxSlice, contractTxSlice, err
+}
+
+//Dynamic state check. The changes are recorded in a state transition, on error the state is reverted to the state
+//before the block. Otherwise the transition stays open, the caller commits it once the block is written to disk (or
+//reverts it if that fails).
+func validateState(data blockData, initialSetup bool) error {
+	if err := applyState(data); err != nil {
+		return err
+	}
+
+	if err := stateRootCheck(data.block, initialSetup); err != nil {
+		storage.RevertStateTransition()
+		return err
 	}
 
-	return accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, aggTxSlice, iotTxSlice, err
+	return nil
 }
 
-//Dynamic state check.
-func validateState(data blockData) error {
+//Applies the block to the state like validateState, without checking the state root of the block. Used by the
+//proposer to compute the state root.
+func applyState(data blockData) (err error) {
+	storage.BeginStateTransition()
+	defer func() {
+		if err != nil {
+			storage.RevertStateTransition()
+		}
+	}()
+
+	calledContractStates = make(map[[32]byte]map[[32]byte]protocol.Account)
+	contractLogs = nil
+
+	params := parametersAt(data.block.Height)
+
 	//The sequence of validation matters. If we start with accs, then fund/stake transactions can be done in the same block
 	//even though the accounts did not exist before the block validation.
 	if err := accStateChange(data.accTxSlice); err != nil {
 		return err
 	}
 
-	if err := fundsStateChange(data.fundsTxSlice); err != nil {
-		accStateChangeRollback(data.accTxSlice)
+	//After the accTxs, so accounts created in the block can become root accounts.
+	if err := rootKeyStateChange(data.configTxSlice); err != nil {
+		return err
+	}
+
+	if err := txCntCheck(data.fundsTxSlice, data.aggTxSlice, data.iotTxSlice, data.contractTxSlice); err != nil {
+		return err
+	}
+
+	if err := checkDeviceRateLimits(data.iotTxSlice); err != nil {
 		return err
 	}
 
-	if err := aggTxStateChange(data.aggTxSlice); err != nil {
-		fundsStateChangeRollback(data.fundsTxSlice)
-		accStateChangeRollback(data.accTxSlice)
+	if err := fundsStateChange(data.fundsTxSlice, data.block.Height); err != nil {
+		return err
+	}
+
+	if err := aggTxStateChange(data.aggTxSlice, data.block.Height); err != nil {
 		return err
 	}
 
 	if err := stakeStateChange(data.stakeTxSlice, data.block.Height); err != nil {
-		fundsStateChangeRollback(data.fundsTxSlice)
-		accStateChangeRollback(data.accTxSlice)
-		aggregatedSenderStateRollback(data.aggTxSlice)
 		return err
 	}
+
 	if err := iotStateChange(data.iotTxSlice); err != nil {
 		return err
 	}
 
+	if err := iotRateLimitStateChange(data.iotTxSlice, data.block.Height, params); err != nil {
+		return err
+	}
+
+	if err := contractStateChange(data.contractTxSlice, data.block.Beneficiary); err != nil {
+		return err
+	}
+
 	if err := collectTxFees(data.accTxSlice, data.fundsTxSlice, data.configTxSlice, data.stakeTxSlice, data.aggTxSlice, data.iotTxSlice, data.block.Beneficiary); err != nil {
-		stakeStateChangeRollback(data.stakeTxSlice)
-		fundsStateChangeRollback(data.fundsTxSlice)
-		aggregatedSenderStateRollback(data.aggTxSlice)
-		accStateChangeRollback(data.accTxSlice)
 		return err
 	}
 
-	if err := collectBlockReward(activeParameters.Block_reward, data.block.Beneficiary); err != nil {
-		collectTxFeesRollback(data.accTxSlice, data.fundsTxSlice, data.configTxSlice, data.stakeTxSlice, data.block.Beneficiary)
-		stakeStateChangeRollback(data.stakeTxSlice)
-		fundsStateChangeRollback(data.fundsTxSlice)
-		aggregatedSenderStateRollback(data.aggTxSlice)
-		accStateChangeRollback(data.accTxSlice)
+	if err := collectBlockReward(params.Block_reward, data.block.Beneficiary); err != nil {
 		return err
 	}
 
-	if err := collectSlashReward(activeParameters.Slash_reward, data.block); err != nil {
-		collectBlockRewardRollback(activeParameters.Block_reward, data.block.Beneficiary)
-		collectTxFeesRollback(data.accTxSlice, data.fundsTxSlice, data.configTxSlice, data.stakeTxSlice, data.block.Beneficiary)
-		stakeStateChangeRollback(data.stakeTxSlice)
-		fundsStateChangeRollback(data.fundsTxSlice)
-		aggregatedSenderStateRollback(data.aggTxSlice)
-		accStateChangeRollback(data.accTxSlice)
+	if err := collectDelegatorRewards(data, params.Block_reward); err != nil {
+		return err
+	}
+
+	if err := collectSlashReward(params.Slash_reward, data.block); err != nil {
 		return err
 	}
 
 	if err := updateStakingHeight(data.block); err != nil {
-		collectSlashRewardRollback(activeParameters.Slash_reward, data.block)
-		collectBlockRewardRollback(activeParameters.Block_reward, data.block.Beneficiary)
-		collectTxFeesRollback(data.accTxSlice, data.fundsTxSlice, data.configTxSlice, data.stakeTxSlice, data.block.Beneficiary)
-		stakeStateChangeRollback(data.stakeTxSlice)
-		fundsStateChangeRollback(data.fundsTxSlice)
-		aggregatedSenderStateRollback(data.aggTxSlice)
-		accStateChangeRollback(data.accTxSlice)
 		return err
 	}
 
 	return nil
 }
 
-func postValidate(data blockData, initialSetup bool) {
+func postValidate(data blockData, initialSetup bool) error {
+	//All writes of the block are committed at once, so a crash never leaves the block partially on disk.
+	batch := storage.NewBatch()
+
+	//The indexes are also built when replaying the chain.
+	batch.WriteBlockHeight(data.block.Height, data.block.Hash)
+	//The difficulty is only retargeted by collectStatistics below, it is the one the block has been validated at.
+	batch.WriteBlockWeight(data.block.Hash, getDifficulty(), accumulatedWeight(data.block, getDifficulty()))
+	writeAccountHistory(batch, data)
+	writeBalanceHistory(batch, data.block.Height)
+	batch.WriteBeneficiaryBlock(data.block.Beneficiary, data.block.Height, data.block.Hash)
+	if data.block.SlashedAddress != [32]byte{} {
+		batch.WriteSlashingBlock(data.block.SlashedAddress, data.block.Height, data.block.Hash)
+		//The proof is not pending anymore.
+		batch.DeleteSlashingProof(data.block.SlashedAddress)
+	}
+	if stake, exists := slashedStakes[data.block.Hash]; exists {
+		batch.WriteSlashedStake(data.block.Hash, stake)
+	}
+	if finalized := finalizedBlock(data.block); finalized != nil {
+		batch.WriteCheckpoint(finalized.Height, finalized.Hash)
+	}
+	for _, tx := range data.accTxSlice {
+		if removed, exists := removedAccounts[tx.Hash()]; exists {
+			batch.WriteRemovedAccount(tx.Hash(), &removed.acc, removed.isRoot)
+		}
+	}
+	for _, tx := range data.iotTxSlice {
+		if bucket, exists := iotBuckets[tx.Hash()]; exists {
+			batch.WriteIotBucket(tx.Hash(), bucket.tokens, bucket.refillHeight)
+		}
+	}
+	for txHash, accounts := range calledContractStates {
+		batch.WriteContractStates(txHash, accounts)
+	}
+	batch.WriteLogs(collectLogs(data.block))
+
+	if !initialSetup {
+		//Write all open transactions to closed/validated storage.
+		for _, tx := range data.accTxSlice {
+			batch.WriteClosedTx(tx)
+		}
+
+		for _, tx := range data.fundsTxSlice {
+			batch.WriteClosedTx(tx)
+		}
+
+		for _, tx := range data.configTxSlice {
+			batch.WriteClosedTx(tx)
+		}
+
+		for _, tx := range data.stakeTxSlice {
+			batch.WriteClosedTx(tx)
+		}
+
+		for _, tx := range data.aggTxSlice {
+			//Write the FundsTx per aggTx to the closed storage.
+			for _, aggregatedTxHash := range tx.AggregatedTxSlice {
+				batch.WriteClosedTx(storage.ReadOpenTx(aggregatedTxHash))
+			}
+			batch.WriteClosedTx(tx)
+		}
+
+		for _, tx := range data.iotTxSlice {
+			batch.WriteClosedTx(tx)
+			batch.WriteIotTxBlock(tx.Hash(), data.block.Hash)
+		}
+
+		for _, tx := range data.contractTxSlice {
+			batch.WriteClosedTx(tx)
+		}
+
+		//It might be that block is not in the openblock storage, but this doesn't matter.
+		batch.DeleteOpenBlock(data.block.Hash)
+		batch.WriteClosedBlock(data.block)
+
+		// Write last block to db and delete last block's ancestor.
+		batch.SetLastClosedBlock(data.block)
+	}
+
 	//The new system parameters get active if the block was successfully validated
 	//This is done after state validation (in contrast to accTx/fundsTx).
 	//Conversely, if blocks are rolled back, the system parameters are changed first.
-	configStateChange(data.configTxSlice, data.block.Hash)
+	configStateChange(data.configTxSlice, data.block.Hash, data.block.Height)
+	//The validator set of the next epoch includes the config changes of the block. It is written with the block, so
+	//there is no block ending an epoch without the set of the next one.
+	writeValidatorSet(batch, data.block.Height, writeLivenessEvidence(batch, data.block))
+
+	if err := batch.Commit(); err != nil {
+		configStateChangeRollback(data.configTxSlice, data.block.Hash, data.block.Height)
+		return errors.New(fmt.Sprintf("Block (%x) could not be written: %v", data.block.Hash[0:8], err))
+	}
+
+	delete(slashingDict, data.block.SlashedAddress)
+
 	//Collects meta information about the block (and handled difficulty adaption).
 	collectStatistics(data.block)
+	recordBlockUtilization(data.block)
 
 	if !initialSetup {
-		//Write all open transactions to closed/validated storage.
+		//Delete the closed transactions from the mempool.
 		for _, tx := range data.accTxSlice {
-			storage.WriteClosedTx(tx)
 			storage.DeleteOpenTx(tx)
 		}
 
 		for _, tx := range data.fundsTxSlice {
-			storage.WriteClosedTx(tx)
 			storage.DeleteOpenTx(tx)
 			storage.DeleteINVALIDOpenTx(tx)
 		}
 
 		for _, tx := range data.configTxSlice {
-			storage.WriteClosedTx(tx)
 			storage.DeleteOpenTx(tx)
 		}
 
 		for _, tx := range data.stakeTxSlice {
-			storage.WriteClosedTx(tx)
 			storage.DeleteOpenTx(tx)
 		}
 
 		for _, tx := range data.aggTxSlice {
-
-			//delete FundsTx per aggTx in open storage and write them to the closed storage.
 			for _, aggregatedTxHash := range tx.AggregatedTxSlice {
-				trx := storage.ReadOpenTx(aggregatedTxHash)
-				storage.WriteClosedTx(trx)
-				storage.DeleteOpenTx(trx)
+				storage.DeleteOpenTxWithHash(aggregatedTxHash)
 			}
-			//Delete AggTx and write it to closed Tx.
 			logger.Printf("write closed and delete open Tx: %x", tx.Hash())
-			storage.WriteClosedTx(tx)
 			storage.DeleteOpenTx(tx)
 		}
 		for _, tx := range data.iotTxSlice {
-			storage.WriteClosedTx(tx)
+			storage.DeleteOpenTx(tx)
+			recordIoTReading(tx)
+		}
+
+		for _, tx := range data.contractTxSlice {
 			storage.DeleteOpenTx(tx)
 		}
 
+		releaseParkedTxs(data)
+		adoptOrphanTxs()
+		pruneIotData(data.block)
+
 		if len(data.fundsTxSlice) > 0 {
 			broadcastVerifiedTxs(data.fundsTxSlice)
 		}
 
+		emptyBlocks(data.block.Height)
+		aggregateEpochs(data.block.Height)
 
-		//It might be that block is not in the openblock storage, but this doesn't matter.
-		storage.DeleteOpenBlock(data.block.Hash)
-		storage.WriteClosedBlock(data.block)
+		storeSnapshot(data.block)
+		publishBlock(data.block)
+	}
 
-		//Do not empty last three blocks and only if it not aggregated already. TODO Probably rewrite this later.
-		for _, block := range storage.ReadAllClosedBlocks(){
+	return nil
+}
 
-			//Empty all blocks despite the last 3 and genesis block.
-			if !block.Aggregated && block.Height > 0 {
-				if (int(block.Height)) < (int(data.block.Height) - NO_AGGREGATION_LENGTH) {
-					storage.UpdateBlocksToBlocksWithoutTx(block)
-				}
-			}
+//Blocks below this height on the active chain have been emptied already.
+var emptiedHeight = uint32(1)
+
+//Moves the blocks without txs below the last noAggregationLength blocks to the blocks without txs. The blocks are
+//looked up by their height, starting where the last call stopped, instead of reading all closed blocks for every
+//validated block. Genesis is never emptied.
+func emptyBlocks(tip uint32) {
+	if int(tip) <= noAggregationLength {
+		return
+	}
+
+	for limit := tip - uint32(noAggregationLength); emptiedHeight < limit; emptiedHeight++ {
+		blockHash, exists := storage.ReadBlockHashByHeight(emptiedHeight)
+		if !exists {
+			continue
 		}
 
-		// Write last block to db and delete last block's ancestor.
-		storage.DeleteAllLastClosedBlock()
-		storage.WriteLastClosedBlock(data.block)
+		if block := storage.ReadClosedBlock(blockHash); block != nil && !block.Aggregated {
+			storage.UpdateBlocksToBlocksWithoutTx(block)
+		}
 	}
 }
 
+//Checks that every aggTx of the block is authenticated by the fundsTxs it aggregates, see verifyAggTx.
+func aggTxCheck(aggTxSlice []*protocol.AggTx) error {
+	for _, aggTx := range aggTxSlice {
+		if err := verifyAggregation(aggTx); err != nil {
+			aggTxHash := aggTx.Hash()
+			return errors.New(fmt.Sprintf("Invalid aggTx %x: %v", aggTxHash[0:8], err))
+		}
+	}
+
+	return nil
+}
+
+//Returns an error if the block includes a fundsTx (standalone or aggregated) before the tx's unlock point.
+func lockCheck(block *protocol.Block, fundsTxSlice []*protocol.FundsTx, aggTxSlice []*protocol.AggTx) error {
+	for _, aggTx := range aggTxSlice {
+		fundsTxSlice = append(fundsTxSlice, aggregatedFundsTxs(aggTx)...)
+	}
+
+	for _, tx := range fundsTxSlice {
+		if !tx.IsUnlocked(block.Height, block.Timestamp) {
+			txHash := tx.Hash()
+			return errors.New(fmt.Sprintf("Tx %x is locked until %v.", txHash[0:8], tx.LockUntil))
+		}
+	}
+
+	return nil
+}
+
 //Only blocks with timestamp not diverging from system time (past or future) more than one hour are accepted.
 func timestampCheck(timestamp int64) error {
 	systemTime := p2p.ReadSystemTime()
@@ -1389,15 +1958,15 @@ func timestampCheck(timestamp int64) error {
 	return nil
 }
 
-func slashingCheck(slashedAddress, conflictingBlockHash1, conflictingBlockHash2, conflictingBlockHashWithoutTx1, conflictingBlockHashWithoutTx2 [32]byte) (bool, error) {
+func slashingCheck(slashedAddress, conflictingBlockHash1, conflictingBlockHash2, conflictingBlockHashWithoutTx1, conflictingBlockHashWithoutTx2 [32]byte, slashingWindowSize uint64) (height uint32, err error) {
 	prefix := "Invalid slashing proof: "
 
 	if conflictingBlockHash1 == [32]byte{} || conflictingBlockHash2 == [32]byte{} {
-		return false, errors.New(fmt.Sprintf(prefix + "Invalid conflicting block hashes provided."))
+		return 0, errors.New(prefix + "Invalid conflicting block hashes provided.")
 	}
 
 	if conflictingBlockHash1 == conflictingBlockHash2 {
-		return false, errors.New(fmt.Sprintf(prefix + "Conflicting block hashes are the same."))
+		return 0, errors.New(prefix + "Conflicting block hashes are the same.")
 	}
 
 	//Fetch the blocks for the provided block hashes.
@@ -1412,31 +1981,35 @@ func slashingCheck(slashedAddress, conflictingBlockHash1, conflictingBlockHash2,
 		conflictingBlock2 = storage.ReadClosedBlockWithoutTx(conflictingBlockHashWithoutTx2)
 	}
 
-	if IsInSameChain(conflictingBlock1, conflictingBlock2) {
-		return false, errors.New(fmt.Sprintf(prefix + "Conflicting block hashes are on the same chain."))
-	}
-
 	//TODO Optimize code (duplicated)
 	//If this block is unknown we need to check if its in the openblock storage or we must request it.
 	if conflictingBlock1 == nil {
 		conflictingBlock1 = storage.ReadOpenBlock(conflictingBlockHash1)
 		if conflictingBlock1 == nil {
 			//Fetch the block we apparently missed from the network.
-			p2p.BlockReq(conflictingBlockHash1, conflictingBlockHashWithoutTx1)
-
-			//Blocking wait
-			select {
-			case encodedBlock := <-p2p.BlockReqChan:
-				conflictingBlock1 = conflictingBlock1.Decode(encodedBlock)
-				//Limit waiting time to BLOCKFETCH_TIMEOUT seconds before aborting.
-			case <-time.After(BLOCKFETCH_TIMEOUT * time.Second):
-				return false, errors.New(fmt.Sprintf(prefix + "Could not find a block with the provided conflicting hash (1)."))
+			err := fetch(context.Background(), "Block", BLOCKFETCH_TIMEOUT*time.Second, func() error {
+				return p2p.BlockReq(conflictingBlockHash1, conflictingBlockHashWithoutTx1)
+			}, func(timeout <-chan time.Time) bool {
+				select {
+				case encodedBlock := <-p2p.BlockReqChan:
+					conflictingBlock1, _ = conflictingBlock1.Decode(encodedBlock)
+					return conflictingBlock1 != nil && (conflictingBlock1.Hash == conflictingBlockHash1 || conflictingBlock1.HashWithoutTx == conflictingBlockHashWithoutTx1)
+				case <-timeout:
+				}
+				return false
+			})
+			if err != nil {
+				return 0, errors.New(fmt.Sprintf(prefix+"Could not find a block with the provided conflicting hash (1): %v", err))
 			}
 		}
 
+		if conflictingBlock1 == nil {
+			return 0, errors.New(prefix + "Could not decode the block with the provided conflicting hash (1).")
+		}
+
 		ancestor, _ := getNewChain(conflictingBlock1)
 		if ancestor == nil {
-			return false, errors.New(fmt.Sprintf(prefix + "Could not find a ancestor for the provided conflicting hash (1)."))
+			return 0, errors.New(prefix + "Could not find a ancestor for the provided conflicting hash (1).")
 		}
 	}
 
@@ -1446,32 +2019,49 @@ func slashingCheck(slashedAddress, conflictingBlockHash1, conflictingBlockHash2,
 		conflictingBlock2 = storage.ReadOpenBlock(conflictingBlockHash2)
 		if conflictingBlock2 == nil {
 			//Fetch the block we apparently missed from the network.
-			p2p.BlockReq(conflictingBlockHash2, conflictingBlockHashWithoutTx2)
-
-			//Blocking wait
-			select {
-			case encodedBlock := <-p2p.BlockReqChan:
-				conflictingBlock2 = conflictingBlock2.Decode(encodedBlock)
-				//Limit waiting time to BLOCKFETCH_TIMEOUT seconds before aborting.
-			case <-time.After(BLOCKFETCH_TIMEOUT * time.Second):
-				return false, errors.New(fmt.Sprintf(prefix + "Could not find a block with the provided conflicting hash (2)."))
+			err := fetch(context.Background(), "Block", BLOCKFETCH_TIMEOUT*time.Second, func() error {
+				return p2p.BlockReq(conflictingBlockHash2, conflictingBlockHashWithoutTx2)
+			}, func(timeout <-chan time.Time) bool {
+				select {
+				case encodedBlock := <-p2p.BlockReqChan:
+					conflictingBlock2, _ = conflictingBlock2.Decode(encodedBlock)
+					return conflictingBlock2 != nil && (conflictingBlock2.Hash == conflictingBlockHash2 || conflictingBlock2.HashWithoutTx == conflictingBlockHashWithoutTx2)
+				case <-timeout:
+				}
+				return false
+			})
+			if err != nil {
+				return 0, errors.New(fmt.Sprintf(prefix+"Could not find a block with the provided conflicting hash (2): %v", err))
 			}
 		}
 
+		if conflictingBlock2 == nil {
+			return 0, errors.New(prefix + "Could not decode the block with the provided conflicting hash (2).")
+		}
+
 		ancestor, _ := getNewChain(conflictingBlock2)
 		if ancestor == nil {
-			return false, errors.New(fmt.Sprintf(prefix + "Could not find a ancestor for the provided conflicting hash (2)."))
+			return 0, errors.New(prefix + "Could not find a ancestor for the provided conflicting hash (2).")
 		}
 	}
 
 	// We found the height of the blocks and the height of the blocks can be checked.
 	// If the height is not within the active slashing window size, we must throw an error. If not, the proof is valid.
-	if !(conflictingBlock1.Height < uint32(activeParameters.Slashing_window_size)+conflictingBlock2.Height) {
-		return false, errors.New(fmt.Sprintf(prefix + "Could not find a ancestor for the provided conflicting hash (2)."))
+	if !(conflictingBlock1.Height < uint32(slashingWindowSize)+conflictingBlock2.Height) {
+		return 0, errors.New(prefix + "Could not find a ancestor for the provided conflicting hash (2).")
 	}
 
-	//Delete the proof from local slashing dictionary. If proof has not existed yet, nothing will be deleted.
-	delete(slashingDict, slashedAddress)
+	if IsInSameChain(conflictingBlock1, conflictingBlock2) {
+		return 0, errors.New(prefix + "Conflicting block hashes are on the same chain.")
+	}
+
+	if conflictingBlock1.Beneficiary != slashedAddress || conflictingBlock2.Beneficiary != slashedAddress {
+		return 0, errors.New(prefix + "Conflicting blocks were not produced by the slashed address.")
+	}
+
+	if conflictingBlock1.Height < conflictingBlock2.Height {
+		return conflictingBlock1.Height, nil
+	}
 
-	return true, nil
+	return conflictingBlock2.Height, nil
 }
@@ -18,7 +18,7 @@ import (
 func TestBlock(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	hashFundsSlice, hashAccSlice, hashConfigSlice, hashStakeSlice := createBlockWithTxs(b)
 	err := finalizeBlock(b)
 	if err != nil {
@@ -30,7 +30,7 @@ func TestBlock(t *testing.T) {
 
 	var decodedBlock *protocol.Block
 
-	decodedBlock = decodedBlock.Decode(encodedBlock)
+	decodedBlock, _ = decodedBlock.Decode(encodedBlock)
 
 	err = validate(decodedBlock, false)
 
@@ -61,7 +61,7 @@ func TestBlock(t *testing.T) {
 func TestBlockTxDuplicates(t *testing.T) {
 
 	cleanAndPrepare()
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	createBlockWithTxs(b)
 
 	if err := finalizeBlock(b); err != nil {
@@ -100,28 +100,28 @@ func TestBlockTxDuplicates(t *testing.T) {
 func TestMultipleBlocks(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	createBlockWithTxs(b)
 	finalizeBlock(b)
 	if err := validate(b, false); err != nil {
 		t.Errorf("Block validation for (%v) failed: %v\n", b, err)
 	}
 
-	b2 := newBlock(b.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b2 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	createBlockWithTxs(b2)
 	finalizeBlock(b2)
 	if err := validate(b2, false); err != nil {
 		t.Errorf("Block validation failed: %v\n", err)
 	}
 
-	b3 := newBlock(b2.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 3)
+	b3 := newBlock(b2.Hash, b2.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 3)
 	createBlockWithTxs(b3)
 	finalizeBlock(b3)
 	if err := validate(b3, false); err != nil {
 		t.Errorf("Block validation failed: %v\n", err)
 	}
 
-	b4 := newBlock(b3.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 4)
+	b4 := newBlock(b3.Hash, b3.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 4)
 	createBlockWithTxs(b4)
 	finalizeBlock(b4)
 	if err := validate(b4, false); err != nil {
@@ -167,7 +167,7 @@ func createBlockWithTxs(b *protocol.Block) ([][32]byte, [][32]byte, [][32]byte,
 	for cnt := int(accA.TxCnt); cnt < loopMax; cnt++ {
 		accAHash := protocol.SerializeHashContent(accA.Address)
 		accBHash := protocol.SerializeHashContent(accB.Address)
-		tx, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%100+1, randVar.Uint64()%100+1, uint32(cnt), accAHash, accBHash, PrivKeyAccA, PrivKeyMultiSig, nil)
+		tx, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%100+1, randVar.Uint64()%100+1, uint32(cnt), accAHash, accBHash, PrivKeyAccA, nil)
 		if err := addTx(b, tx); err == nil {
 			//Might  be that we generated a block that was already generated before
 			if storage.ReadOpenTx(tx.Hash()) != nil || storage.ReadClosedTx(tx.Hash()) != nil {
@@ -180,10 +180,16 @@ func createBlockWithTxs(b *protocol.Block) ([][32]byte, [][32]byte, [][32]byte,
 		}
 	}
 
-	nullAddress := [64]byte{}
+	//Validation requires the txs in canonical order (see txorder.go), they are sorted before being added.
+	var accTxs []protocol.Transaction
+	nullAddress := [32]byte{}
 	loopMax = int(randVar.Uint32()%testSize) + 1
 	for cnt := 0; cnt < loopMax; cnt++ {
-		tx, _, _ := protocol.ConstrAccTx(0, randVar.Uint64()%100+1, nullAddress, PrivKeyRoot, nil, nil)
+		tx, _, _ := protocol.ConstrAccTx(0, randVar.Uint64()%100+1, 0, nullAddress, PrivKeyRoot, nil, nil)
+		accTxs = append(accTxs, tx)
+	}
+	sortOpenTxs(accTxs)
+	for _, tx := range accTxs {
 		if err := addTx(b, tx); err == nil {
 			if storage.ReadOpenTx(tx.Hash()) != nil || storage.ReadClosedTx(tx.Hash()) != nil {
 				continue
@@ -196,6 +202,7 @@ func createBlockWithTxs(b *protocol.Block) ([][32]byte, [][32]byte, [][32]byte,
 	}
 
 	//NrConfigTx is saved in a uint8, so testsize shouldn't be larger than 255
+	var configTxs []protocol.Transaction
 	loopMax = int(randVar.Uint32()%testSize) + 1
 	for cnt := 0; cnt < loopMax; cnt++ {
 		tx, err := protocol.ConstrConfigTx(uint8(randVar.Uint32()%256), uint8(randVar.Uint32()%10+1), randVar.Uint64()%2342873423, randVar.Uint64()%1000+1, uint8(cnt), PrivKeyRoot)
@@ -210,6 +217,10 @@ func createBlockWithTxs(b *protocol.Block) ([][32]byte, [][32]byte, [][32]byte,
 		if tx.Id == 3 || tx.Id == 1 || tx.Id == 6 {
 			continue
 		}
+		configTxs = append(configTxs, tx)
+	}
+	sortOpenTxs(configTxs)
+	for _, tx := range configTxs {
 		if err := addTx(b, tx); err == nil {
 
 			hashConfigSlice = append(hashConfigSlice, tx.Hash())
@@ -222,6 +233,48 @@ func createBlockWithTxs(b *protocol.Block) ([][32]byte, [][32]byte, [][32]byte,
 	return hashFundsSlice, hashAccSlice, hashConfigSlice, hashStakeSlice
 }
 
+//Blocks without txs are emptied once they are noAggregationLength blocks below the tip, blocks with txs are kept.
+func TestEmptyBlocks(t *testing.T) {
+	defer func(height uint32) { emptiedHeight = height }(emptiedHeight)
+	emptiedHeight = 700000
+
+	var blocks []*protocol.Block
+	for height := uint32(700000); height < 700010; height++ {
+		block := &protocol.Block{Hash: [32]byte{0xe7, byte(height)}, HashWithoutTx: [32]byte{0xe8, byte(height)}, Height: height}
+		if height == 700002 {
+			block.NrFundsTx = 1
+		}
+		storage.WriteClosedBlock(block)
+		storage.WriteBlockHeight(height, block.Hash)
+		blocks = append(blocks, block)
+	}
+	defer func() {
+		for _, block := range blocks {
+			storage.DeleteClosedBlock(block.Hash)
+			storage.DeleteBlockHeight(block.Height)
+		}
+	}()
+
+	emptyBlocks(700009)
+	if emptiedHeight != 700009-uint32(noAggregationLength) {
+		t.Errorf("Blocks emptied up to height %v, expected %v.", emptiedHeight, 700009-noAggregationLength)
+	}
+	for _, block := range blocks {
+		indexed := storage.ReadBlockByHeight(block.Height)
+		emptied := block.Height < emptiedHeight && block.NrFundsTx == 0
+		if indexed == nil || indexed.Aggregated != emptied || (storage.ReadClosedBlock(block.Hash) == nil) != emptied {
+			t.Errorf("Block at height %v emptied: %v, expected %v.", block.Height, indexed != nil && indexed.Aggregated, emptied)
+		}
+	}
+
+	//A rollback below the emptied height walks the heights again.
+	emptiedHeight = 700001
+	emptyBlocks(700009)
+	if emptiedHeight != 700009-uint32(noAggregationLength) {
+		t.Errorf("Blocks emptied up to height %v after the rollback, expected %v.", emptiedHeight, 700009-noAggregationLength)
+	}
+}
+
 //TODO
 //func TestReadLastClosedBlock(t *testing.T) {
 //	cleanAndPrepare()
@@ -3,57 +3,62 @@ package miner
 import (
 	"crypto/rsa"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/logging"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 	"github.com/bazo-blockchain/bazo-miner/storage"
 	"golang.org/x/crypto/ed25519"
-	"log"
-	"os"
 	"sync"
 	"time"
 )
 
 var (
-	logger                       *log.Logger
+	logger                       = logging.GetLogger("miner")
 	blockValidation              = &sync.Mutex{}
 	parameterSlice               []Parameters
 	activeParameters             *Parameters
 	uptodate                     bool
-	slashingDict                 = make(map[[32]byte]SlashingProof)
+	slashingDict                 = make(map[[32]byte]*protocol.SlashingProof)
 	validatorAccAddress          [32]byte
 	multisigPubKey               ed25519.PublicKey
-	commPrivKey, rootCommPrivKey *rsa.PrivateKey
+	commSigner                   crypto.CommitmentSigner
+	rootCommPrivKey              *rsa.PrivateKey
 	blockchainSize               = 0
-	FileConnectionsLog         *os.File
-	FileConnections   	       *os.File
-
-
 )
 
 //Miner entry point
-func Init(validatorWallet, multisigWallet ed25519.PublicKey , rootWallet ed25519.PrivateKey, validatorCommitment, rootCommitment *rsa.PrivateKey) {
+func Init(validatorWallet, multisigWallet ed25519.PublicKey , rootWallet ed25519.PrivateKey, validatorCommitment crypto.CommitmentSigner, rootCommitment *rsa.PrivateKey) {
 	var err error
 
 
 	validatorAccAddress = crypto.GetAddressFromPubKeyED(validatorWallet)
 	multisigPubKey = multisigWallet
-	commPrivKey = validatorCommitment
+	commSigner = validatorCommitment
 	rootCommPrivKey = rootCommitment
 
-	//Set up logger.
-	logger = storage.InitLogger()
 	logger.Printf("\n\n\n-------------------- START MINER ---------------------")
 
 	parameterSlice = append(parameterSlice, NewDefaultParameters())
 	activeParameters = &parameterSlice[0]
+	if devMode {
+		applyDevModeParameters(activeParameters)
+	}
 
-	//Initialize root key.
-	initRootKey(ed25519.PublicKey(rootWallet[32:]))
+	//Initialize the genesis state, or the root key if there is no genesis file.
+	withGenesis, err := initGenesis()
 	if err != nil {
-		logger.Printf("Could not create a root account.\n")
+		logger.Printf("Could not set up the genesis state: %v\n", err)
+		return
+	}
+	if !withGenesis {
+		initRootKey(ed25519.PublicKey(rootWallet[32:]))
+	}
+
+	if devMode {
+		logger.Printf("Dev mode: the root account %x produces all blocks.\n", validatorAccAddress[0:8])
 	}
 
 	currentTargetTime = new(timerange)
-	target = append(target, 15)
+	target = append(target, initialDifficulty())
 
 	initialBlock, err := initState()
 	if err != nil {
@@ -61,17 +66,28 @@ func Init(validatorWallet, multisigWallet ed25519.PublicKey , rootWallet ed25519
 		return
 	}
 
+	restoreOpenTxs()
+	loadSlashingProofs()
+
 	logger.Printf("ActiveConfigParams: \n%v\n------------------------------------------------------------------------\n\nBAZO is Running\n\n", activeParameters)
 
 	//this is used to generate the state with aggregated transactions.
+	batch := storage.NewBatch()
 	for _, tx := range storage.ReadAllBootstrapReceivedTransactions() {
 		storage.DeleteOpenTx(tx)
-		storage.WriteClosedTx(tx)
+		batch.WriteClosedTx(tx)
+	}
+	if err := batch.Commit(); err != nil {
+		logger.Printf("Bootstrap txs could not be written: %v\n", err)
 	}
 	storage.DeleteBootstrapReceivedMempool()
 
 	//Start to listen to network inputs (txs and blocks).
 	go incomingData()
+	go incomingSlashingProofs()
+	go forwardTxEvents()
+	go rebroadcastService()
+	go gcService()
 	mining(initialBlock)
 }
 var StartTime = time.Now()
@@ -80,11 +96,19 @@ func mining(initialBlock *protocol.Block) {
 	currentBlock := newBlock(initialBlock.Hash, initialBlock.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, initialBlock.Height+1)
 
 	for {
+		waitForReadiness(currentBlock.Height - 1)
+
 		err := finalizeBlock(currentBlock)
+		blockLogger := logger.WithFields(logging.Fields{"hash": currentBlock.Hash, "height": currentBlock.Height})
 		if err != nil {
-			logger.Printf("%v\n", err)
+			logger.Errorf("%v", err)
 		} else {
-			logger.Printf("Block mined (%x)\n", currentBlock.Hash[0:8])
+			blockLogger.Infof("Block mined.")
+		}
+
+		//The mined block is dropped, the process exits once the shutdown is complete.
+		if isShuttingDown() {
+			select {}
 		}
 
 		if err == nil {
@@ -92,13 +116,17 @@ func mining(initialBlock *protocol.Block) {
 			if err == nil {
 				//Only broadcast the block if it is valid.
 				broadcastBlock(currentBlock)
-				logger.Printf("Validated block (mined): %vState:\n%v", currentBlock, getState())
-
+				blockLogger.Infof("Validated block (mined).")
+				if blockLogger.Enabled(logging.DEBUG) {
+					blockLogger.Debugf("%vState:\n%v", currentBlock, getState())
+				}
 			} else {
-				logger.Printf("Mined block (%x) could not be validated: %v\n", currentBlock.Hash[0:8], err)
+				blockLogger.Errorf("Mined block could not be validated: %v", err)
 			}
 		}
 
+		waitForBlockTrigger()
+
 		storage.ReadMempool()
 
 		//This is the same mutex that is claimed at the beginning of a block validation. The reason we do this is
@@ -124,7 +152,7 @@ func initRootKey(rootKey ed25519.PublicKey) error {
 	copy(commPubKey[:], rootCommPrivKey.N.Bytes())
 
 	rootAcc := protocol.NewAccount(address, [32]byte{}, activeParameters.Staking_minimum, true, commPubKey, nil, nil)
-	storage.State[addressHash] = &rootAcc
+	storage.State.Set(addressHash, &rootAcc)
 	storage.RootKeys[addressHash] = &rootAcc
 
 	return nil
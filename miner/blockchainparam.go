@@ -14,6 +14,7 @@ var (
 	localBlockCount   = int64(-1)
 	target            []uint8    //Stores the history of target values
 	currentTargetTime *timerange //Corresponds to the active timerange
+	blockTimeEMA      int64      //Average block time in milliseconds if Diff_ema_window is set, 0 until the first block
 )
 
 //An instance of this datastructure is created whenever system parameters change.
@@ -22,6 +23,7 @@ var (
 //code to execute [e.g., when they're running an older version of the code]).
 type Parameters struct {
 	BlockHash               	[BLOCKHASH_SIZE]byte
+	Height                  	uint32 //Height of the block containing the config txs plus the activation delay, the parameters apply to the blocks above.
 	Fee_minimum             	uint64 //Paid minimum fee for sending a tx.
 	Block_size              	uint64 //Block size in bytes.
 	Diff_interval           	uint64
@@ -32,12 +34,29 @@ type Parameters struct {
 	Accepted_time_diff      	uint64 //Number of seconds that a block can be received in the future.
 	Slashing_window_size    	uint64 //Number of blocks that a validator cannot vote on two competing chains.
 	Slash_reward            	uint64 //Reward for providing the correct slashing proof.
+	Block_trigger_txs       	uint64 //Number of pending txs for which a validator produces a block, see blocktrigger.go.
+	Block_trigger_time      	uint64 //Seconds since the last block after which a block is produced with fewer pending txs.
+	Iot_rate_capacity       	uint64 //Number of low fee IoT txs a sender can send in a burst, see iotratelimit.go.
+	Iot_rate_refill         	uint64 //Number of blocks after which a sender can send another low fee IoT tx.
+	Unbonding_period        	uint64 //Number of blocks the stake stays locked after unstaking, see unbonding.go.
+	Rsa_commitment_deadline 	uint64 //Height after which RSA commitment keys and proofs are rejected, 0 for none, see commitment.go.
+	Slash_fraction          	uint64 //Percent of the balance burned when a validator is slashed, at least the staking minimum.
+	Config_quorum           	uint64 //Number of root keys which have to sign a config tx.
+	Config_activation_delay 	uint64 //Number of blocks after the block containing a config tx until the change applies.
+	Diff_ema_window         	uint64 //Number of blocks the average block time is taken over, 0 to retarget every Diff_interval blocks.
+	State_root_height       	uint64 //Height above which blocks have to commit to the state root, see stateroot.go.
+	Tx_order_height         	uint64 //Height above which the txs of a block have to be in canonical order, see txorder.go.
+	Agg_tx_auth_height      	uint64 //Height above which aggTxs have to be authenticated by the fundsTxs they aggregate, see aggTxCheck.
+	Median_time_height      	uint64 //Height above which block timestamps have to be above the median time past, see mediantime.go.
+	Block_hash_height       	uint64 //Height above which the block hashes cover the timestamp and the commitment proof, see blockhash.go.
+	Delegation_height       	uint64 //Height above which funds txs can delegate stake to validators, see delegation.go.
 	num_included_prev_proofs	int
 }
 
 func NewDefaultParameters() Parameters {
 	newParameters := Parameters{
 		[BLOCKHASH_SIZE]byte{},
+		0,
 		FEE_MINIMUM,
 		BLOCK_SIZE,
 		DIFF_INTERVAL,
@@ -48,12 +67,42 @@ func NewDefaultParameters() Parameters {
 		ACCEPTED_TIME_DIFF,
 		SLASHING_WINDOW_SIZE,
 		SLASH_REWARD,
+		BLOCK_TRIGGER_TXS,
+		BLOCK_TRIGGER_TIME,
+		IOT_RATE_CAPACITY,
+		IOT_RATE_REFILL,
+		UNBONDING_PERIOD,
+		RSA_COMMITMENT_DEADLINE,
+		SLASH_FRACTION,
+		CONFIG_QUORUM,
+		CONFIG_ACTIVATION_DELAY,
+		DIFF_EMA_WINDOW,
+		STATE_ROOT_HEIGHT,
+		TX_ORDER_HEIGHT,
+		AGG_TX_AUTH_HEIGHT,
+		MEDIAN_TIME_HEIGHT,
+		BLOCK_HASH_HEIGHT,
+		DELEGATION_HEIGHT,
 		NUM_INCL_PREV_PROOFS,
 	}
 
 	return newParameters
 }
 
+//The parameter slice is the history of all parameter changes of the current chain, in height order. Blocks are
+//validated against the parameters that were active when they were produced, not against the active ones, so blocks
+//produced under older fee minimums, block sizes or slashing windows still verify when the chain is replayed or
+//re-validated (e.g., during a full resync).
+func parametersAt(height uint32) *Parameters {
+	for i := len(parameterSlice) - 1; i > 0; i-- {
+		if parameterSlice[i].Height < height {
+			return &parameterSlice[i]
+		}
+	}
+
+	return &parameterSlice[0]
+}
+
 //Captures first and last timestamp of the intended blocks of the range.
 type timerange struct {
 	first int64
@@ -68,6 +117,13 @@ func collectStatistics(b *protocol.Block) {
 	duration := end.Sub(StartTime)
 	logger.Printf("BlockDuration %v;NumberIoTTransactions %v;BlockSize %v", duration.Seconds(),b.NrIoTTx, b.GetSize())
 	globalBlockCount++
+
+	if activeParameters.Diff_ema_window > 0 {
+		retargetEMA(b)
+		lastBlock = b
+		return
+	}
+
 	localBlockCount++
 
 	if localBlockCount >= int64(activeParameters.Diff_interval) {
@@ -96,8 +152,10 @@ func collectStatistics(b *protocol.Block) {
 func collectStatisticsRollback(b *protocol.Block) {
 	globalBlockCount--
 
-	//Never rollback the genesis blocks.
-	if localBlockCount == 0 && globalBlockCount != 0 {
+	if len(emaStates) > 0 && emaStates[len(emaStates)-1].height == b.Height {
+		retargetEMARollback()
+	} else if localBlockCount == 0 && globalBlockCount != 0 {
+		//Never rollback the genesis blocks.
 		localBlockCount = int64(activeParameters.Diff_interval) - 1
 		//Target rollback
 		target = target[:len(target)-1]
@@ -151,6 +209,82 @@ func calculateNewDifficulty(t *timerange) uint8 {
 	return target_change_rounded + target[len(target)-1]
 }
 
+//Retargeting every Diff_interval blocks reacts late and in big steps when validators join or leave. If Diff_ema_window
+//is set, the difficulty is adjusted after every block based on an exponential moving average of the block times over
+//about Diff_ema_window blocks instead. The difficulty is the number of leading zero bits of the proof of stake, so every
+//step doubles or halves the expected block time: the difficulty is increased once the average is below 2/3 of the
+//block interval and decreased once it is above 3/2 of it, the average is then doubled or halved accordingly. Integers
+//are used throughout, all nodes have to arrive at the same difficulty.
+const (
+	//A single block time is capped at this multiple of the block interval, a long outage would otherwise make the
+	//difficulty drop over many blocks.
+	EMA_MAX_BLOCK_TIME_FACTOR = 6
+)
+
+//The average before each block processed with the EMA, needed to roll back. Blocks deeper than maxRollbackDepth are
+//never rolled back, older states are dropped.
+type emaState struct {
+	height     uint32
+	ema        int64
+	retargeted bool
+}
+
+var emaStates []emaState
+
+func retargetEMA(b *protocol.Block) {
+	state := emaState{height: b.Height, ema: blockTimeEMA}
+	defer func() {
+		emaStates = append(emaStates, state)
+		if maxRollbackDepth > 0 && len(emaStates) > 2*int(maxRollbackDepth) {
+			emaStates = append([]emaState(nil), emaStates[len(emaStates)-int(maxRollbackDepth):]...)
+		}
+	}()
+
+	//See collectStatistics, the timestamp of the genesis block is 0.
+	if lastBlock == nil || lastBlock.Timestamp == 0 {
+		return
+	}
+
+	interval := int64(activeParameters.Block_interval) * 1000
+	if blockTimeEMA == 0 {
+		blockTimeEMA = interval
+	}
+
+	blockTime := (b.Timestamp - lastBlock.Timestamp) * 1000
+	if blockTime < 0 {
+		blockTime = 0
+	} else if blockTime > EMA_MAX_BLOCK_TIME_FACTOR*interval {
+		blockTime = EMA_MAX_BLOCK_TIME_FACTOR * interval
+	}
+
+	blockTimeEMA += (blockTime - blockTimeEMA) / int64(activeParameters.Diff_ema_window)
+
+	difficulty := getDifficulty()
+	if 3*blockTimeEMA < 2*interval && difficulty < math.MaxUint8 {
+		target = append(target, difficulty+1)
+		blockTimeEMA *= 2
+		state.retargeted = true
+	} else if 2*blockTimeEMA > 3*interval && difficulty > 0 {
+		target = append(target, difficulty-1)
+		blockTimeEMA /= 2
+		state.retargeted = true
+	}
+
+	if state.retargeted {
+		logger.Printf("Target changed, new target: %v (average block time %vms)", getDifficulty(), blockTimeEMA)
+	}
+}
+
+func retargetEMARollback() {
+	state := emaStates[len(emaStates)-1]
+	emaStates = emaStates[:len(emaStates)-1]
+
+	if state.retargeted {
+		target = target[:len(target)-1]
+	}
+	blockTimeEMA = state.ema
+}
+
 func getDifficulty() uint8 {
 	return target[len(target)-1]
 }
@@ -169,6 +303,22 @@ func (param Parameters) String() string {
 			"Acceptanced time difference: %v\n"+
 			"Slashing window size: %v\n"+
 			"Slash reward: %v\n"+
+			"Block trigger txs: %v\n"+
+			"Block trigger time: %v\n"+
+			"IoT rate capacity: %v\n"+
+			"IoT rate refill: %v\n"+
+			"Unbonding period: %v\n"+
+			"RSA commitment deadline: %v\n"+
+			"Slash fraction: %v\n"+
+			"Config quorum: %v\n"+
+			"Config activation delay: %v\n"+
+			"Difficulty EMA window: %v\n"+
+			"State root height: %v\n"+
+			"Tx order height: %v\n"+
+			"AggTx authentication height: %v\n"+
+			"Median time height: %v\n"+
+			"Block hash height: %v\n"+
+			"Delegation height: %v\n"+
 			"Num of previous proofs included in PoS: %v\n",
 		param.BlockHash[0:8],
 		param.Block_size,
@@ -181,6 +331,22 @@ func (param Parameters) String() string {
 		param.Accepted_time_diff,
 		param.Slashing_window_size,
 		param.Slash_reward,
+		param.Block_trigger_txs,
+		param.Block_trigger_time,
+		param.Iot_rate_capacity,
+		param.Iot_rate_refill,
+		param.Unbonding_period,
+		param.Rsa_commitment_deadline,
+		param.Slash_fraction,
+		param.Config_quorum,
+		param.Config_activation_delay,
+		param.Diff_ema_window,
+		param.State_root_height,
+		param.Tx_order_height,
+		param.Agg_tx_auth_height,
+		param.Median_time_height,
+		param.Block_hash_height,
+		param.Delegation_height,
 		param.num_included_prev_proofs,
 	)
 }
@@ -19,7 +19,7 @@ func TestTargetHistory(t *testing.T) {
 	var tmpBlock *protocol.Block
 	tmpBlock = new(protocol.Block)
 	for cnt := 0; cnt < 10; cnt++ {
-		tmpBlock = newBlock(tmpBlock.Hash, [crypto.COMM_KEY_LENGTH]byte{}, tmpBlock.Height+1)
+		tmpBlock = newBlock(tmpBlock.Hash, tmpBlock.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, tmpBlock.Height+1)
 		finalizeBlock(tmpBlock)
 		validate(tmpBlock, false)
 		blocks = append(blocks, tmpBlock)
@@ -53,7 +53,7 @@ func TestTargetHistory(t *testing.T) {
 	targetSize = len(target)
 	targetTimesSize = len(targetTimes)
 
-	tmpBlock = newBlock(blocks[len(blocks)-1].Hash, [crypto.COMM_KEY_LENGTH]byte{}, blocks[len(blocks)-1].Height+1)
+	tmpBlock = newBlock(blocks[len(blocks)-1].Hash, blocks[len(blocks)-1].HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, blocks[len(blocks)-1].Height+1)
 	finalizeBlock(tmpBlock)
 	validate(tmpBlock, false)
 
@@ -72,7 +72,7 @@ func TestTimestamps(t *testing.T) {
 
 	prevHash := [32]byte{}
 	for cnt := 0; cnt < 0; cnt++ {
-		b := newBlock(prevHash, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+		b := newBlock(prevHash, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 
 		if cnt == 8 {
 			tx, err := protocol.ConstrConfigTx(0, protocol.DIFF_INTERVAL_ID, 20, 2, 0, PrivKeyRoot)
@@ -147,3 +147,116 @@ func TestCalculateNewDifficulty(t *testing.T) {
 		t.Errorf("Difficulty should: %v, difficulty is: %v\n", 11, calculateNewDifficulty(&time))
 	}
 }
+
+//Blocks are validated against the parameters active at their height, not the latest ones.
+func TestParametersAt(t *testing.T) {
+	defer func(previous []Parameters) {
+		parameterSlice = previous
+		activeParameters = &parameterSlice[len(parameterSlice)-1]
+	}(parameterSlice)
+
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	activeParameters = &parameterSlice[0]
+
+	configStateChange([]*protocol.ConfigTx{{Id: protocol.BLOCK_SIZE_ID, Payload: 5000}}, [32]byte{'1'}, 10)
+	configStateChange([]*protocol.ConfigTx{{Id: protocol.SLASHING_WINDOW_SIZE_ID, Payload: 50}}, [32]byte{'2'}, 20)
+
+	for _, test := range []struct {
+		height             uint32
+		blockSize          uint64
+		slashingWindowSize uint64
+	}{
+		{0, BLOCK_SIZE, SLASHING_WINDOW_SIZE},
+		{10, BLOCK_SIZE, SLASHING_WINDOW_SIZE},
+		{11, 5000, SLASHING_WINDOW_SIZE},
+		{20, 5000, SLASHING_WINDOW_SIZE},
+		{21, 5000, 50},
+	} {
+		params := parametersAt(test.height)
+		if params.Block_size != test.blockSize || params.Slashing_window_size != test.slashingWindowSize {
+			t.Errorf("Wrong parameters at height %v: %v\n", test.height, *params)
+		}
+	}
+
+	//The latest parameters apply to the next block.
+	if parametersAt(21) != activeParameters {
+		t.Error("Active parameters do not apply to the next block.")
+	}
+}
+
+//With an EMA window, the difficulty follows the block times block by block and is restored by rollbacks.
+func TestEMADifficulty(t *testing.T) {
+	defer func(params *Parameters, previousTarget []uint8, ema int64, states []emaState, previousBlock *protocol.Block, count int64) {
+		activeParameters, target, blockTimeEMA, emaStates, lastBlock, globalBlockCount = params, previousTarget, ema, states, previousBlock, count
+	}(activeParameters, target, blockTimeEMA, emaStates, lastBlock, globalBlockCount)
+
+	params := NewDefaultParameters()
+	params.Block_interval = 10
+	params.Diff_ema_window = 4
+	activeParameters = &params
+	target = []uint8{10}
+	blockTimeEMA = 0
+	emaStates = nil
+	lastBlock = &protocol.Block{Height: 0, Timestamp: 1000}
+
+	//Blocks every second are far too fast, the difficulty goes up step by step.
+	var blocks []*protocol.Block
+	for i := uint32(1); i <= 8; i++ {
+		block := &protocol.Block{Height: i, Timestamp: 1000 + int64(i)}
+		collectStatistics(block)
+		blocks = append(blocks, block)
+	}
+	//The average drops below 2/3 of the interval at blocks 2, 5 and 8.
+	if getDifficulty() != 13 {
+		t.Errorf("Difficulty %v after fast blocks, expected 13.\n", getDifficulty())
+	}
+
+	//A rollback restores the difficulty and the average.
+	difficulty, ema := getDifficulty(), blockTimeEMA
+	block := &protocol.Block{Height: 9, Timestamp: 1009}
+	collectStatistics(block)
+	collectStatisticsRollback(block)
+	if getDifficulty() != difficulty || blockTimeEMA != ema {
+		t.Errorf("Rollback left difficulty %v and average %v, expected %v and %v.\n", getDifficulty(), blockTimeEMA, difficulty, ema)
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		collectStatisticsRollback(blocks[i])
+	}
+	if getDifficulty() != 10 || blockTimeEMA != 0 || len(emaStates) != 0 {
+		t.Errorf("Full rollback left difficulty %v and average %v.\n", getDifficulty(), blockTimeEMA)
+	}
+
+	//Only the states of blocks which can still be rolled back are kept.
+	defer SetFinality(MAX_ROLLBACK_DEPTH_DEFAULT, CHECKPOINT_INTERVAL_DEFAULT)
+	SetFinality(4, CHECKPOINT_INTERVAL_DEFAULT)
+	lastBlock = &protocol.Block{Height: 0, Timestamp: 1000}
+	for i := uint32(1); i <= 20; i++ {
+		collectStatistics(&protocol.Block{Height: i, Timestamp: 1000 + 10*int64(i)})
+		if len(emaStates) > 8 {
+			t.Fatalf("%v average states kept after %v blocks with a rollback depth of 4.\n", len(emaStates), i)
+		}
+	}
+	if len(emaStates) < 4 || emaStates[len(emaStates)-1].height != 20 {
+		t.Errorf("%v average states kept, the last at height %v instead of 20.\n", len(emaStates), emaStates[len(emaStates)-1].height)
+	}
+	target = []uint8{10}
+	blockTimeEMA = 0
+	emaStates = nil
+
+	//An outage only counts as EMA_MAX_BLOCK_TIME_FACTOR block intervals.
+	lastBlock = &protocol.Block{Height: 0, Timestamp: 1000}
+	collectStatistics(&protocol.Block{Height: 1, Timestamp: 100000})
+	if getDifficulty() != 9 || blockTimeEMA != (10000+(60000-10000)/4)/2 {
+		t.Errorf("Difficulty %v and average %v after an outage, expected 9 and %v.\n", getDifficulty(), blockTimeEMA, (10000+(60000-10000)/4)/2)
+	}
+
+	//The difficulty never underflows.
+	target = []uint8{0}
+	blockTimeEMA = 0
+	lastBlock = &protocol.Block{Height: 0, Timestamp: 1000}
+	collectStatistics(&protocol.Block{Height: 1, Timestamp: 100000})
+	if getDifficulty() != 0 {
+		t.Errorf("Difficulty %v, expected 0.\n", getDifficulty())
+	}
+}
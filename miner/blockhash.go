@@ -0,0 +1,29 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"golang.org/x/crypto/sha3"
+)
+
+//The hashes of a block are prefixed with its nonce. Up to the Block_hash_height, the rest of the hashes was computed
+//before the proof of stake was found, i.e., with the timestamp and the commitment proof not set yet. Such hashes do
+//not bind the timestamp and the commitment proof, which is why the blocks above the Block_hash_height cover them.
+//Chains started before keep the old hashes until a config tx sets the height, new chains set it in the genesis.
+
+//Returns the hash and the hash without txs of the block. The nonce, the timestamp and the commitment proof need to be
+//set.
+func blockHashes(block *protocol.Block) (hash [32]byte, hashWithoutTx [32]byte) {
+	hashed := block
+	if uint64(block.Height) <= parametersAt(block.Height).Block_hash_height {
+		withoutProof := *block
+		withoutProof.Timestamp = 0
+		withoutProof.CommitmentProof = [crypto.COMM_KEY_LENGTH]byte{}
+		hashed = &withoutProof
+	}
+
+	partialHash := hashed.HashBlock()
+	partialHashWithoutMerkleRoot := hashed.HashBlockWithoutMerkleRoot()
+
+	return sha3.Sum256(append(block.Nonce[:], partialHash[:]...)), sha3.Sum256(append(block.Nonce[:], partialHashWithoutMerkleRoot[:]...))
+}
@@ -4,29 +4,37 @@ import (
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 	"github.com/bazo-blockchain/bazo-miner/storage"
 	"sort"
+	"time"
 )
 
 //The code here is needed if a new block is built. All open (not yet validated) transactions are first fetched
 //from the mempool and then sorted. The sorting is important because if transactions are fetched from the mempool
-//they're received in random order (because it's implemented as a map). However, if a user wants to issue more fundsTxs
-//they need to be sorted according to increasing txCnt, this greatly increases throughput.
+//they're received in random order (because it's implemented as a map). Txs without txCnt come first, the others follow
+//in canonical order (see txorder.go), i.e., sorted according to increasing txCnt per sender, which greatly increases
+//throughput, and by fee otherwise. The txs of each type end up in the block in canonical order.
 
-type openTxs []protocol.Transaction
+type openTxs struct {
+	txs  []protocol.Transaction
+	keys []txOrderKey
+}
 
 func prepareBlock(block *protocol.Block) {
 	//Fetch all txs from mempool (opentxs).
 	opentxs := storage.ReadAllOpenTxs()
 
-	//This copy is strange, but seems to be necessary to leverage the sort interface.
-	//Shouldn't be too bad because no deep copy.
-	var tmpCopy openTxs
-	tmpCopy = opentxs
-	sort.Sort(tmpCopy)
-
 	//Counter for all transactions which will not be aggregated. (Stake-, config-, acctx)
 	nonAggregatableTxCounter := 0
 	blockSize := block.GetSize()+block.GetBloomFilterSize()
 
+	//The block lists the tx hashes, if they do not all fit the ones paying the highest fee per byte are selected.
+	capacity := 0
+	if headerSize := block.GetSize() + 10; headerSize <= activeParameters.Block_size {
+		capacity = int(activeParameters.Block_size-headerSize)/protocol.HASH_LEN + 1
+	}
+	opentxs = selectTxs(opentxs, capacity)
+
+	sortOpenTxs(opentxs)
+
 	//map where all senders from FundsTx and AggTx are added to. --> this ensures that tx with same sender are only counted once.
 	storage.DifferentSenders = map[[32]byte]uint32{}
 	storage.DifferentReceivers = map[[32]byte]uint32{}
@@ -50,11 +58,47 @@ func prepareBlock(block *protocol.Block) {
 			(int(nonAggregatableTxCounter)*int(len(tx.Hash()))) > int(activeParameters.Block_size){
 			break
 		}
-		err := addTx(block, tx)
+		//Policies may reject txs which were fine at admission (e.g., after a rules update), they are dropped.
+		annotations, err := applyTxPolicies(tx, POLICY_STAGE_BLOCK)
+		if err != nil {
+			storage.DeleteOpenTx(tx)
+			continue
+		}
+		mempool.Annotate(tx.Hash(), annotations)
+
+		//Locked txs stay in the mempool until they can be included.
+		if isLocked(block, tx) {
+			continue
+		}
+
+		//Txs of devices which reached their rate limit wait for the next block.
+		if isRateLimited(block, tx) {
+			continue
+		}
+
+		//Low fee IoT txs of senders without tokens wait until their bucket is refilled.
+		if isIotThrottled(block, tx) {
+			continue
+		}
+
+		err = addTx(block, tx)
 		if err != nil {
+			storage.DeleteOpenTx(tx)
+
+			//Txs with a gap in the txCnt are parked until the missing txs arrive.
+			if isOutOfOrder(block, tx) {
+				mempool.Park(tx)
+				continue
+			}
+
+			//Txs referencing accounts which do not exist yet wait for them as orphans.
+			if len(missingAccounts(tx)) > 0 {
+				mempool.Orphan(tx)
+				continue
+			}
+
 			//If the tx is invalid, we remove it completely, prevents starvation in the mempool.
 			storage.WriteINVALIDOpenTx(tx)
-			storage.DeleteOpenTx(tx)
 		}
 	}
 
@@ -74,46 +118,107 @@ func prepareBlock(block *protocol.Block) {
 
 }
 
+//Returns true if the tx's txCnt is ahead of the sender's txCnt in the block's state copy.
+func isOutOfOrder(block *protocol.Block, tx protocol.Transaction) bool {
+	var acc *protocol.Account
+	var txCnt uint32
+	switch tx.(type) {
+	case *protocol.FundsTx:
+		acc, txCnt = block.StateCopy[tx.(*protocol.FundsTx).From], tx.(*protocol.FundsTx).TxCnt
+	case *protocol.IotTx:
+		acc, txCnt = block.StateCopy[tx.(*protocol.IotTx).From], tx.(*protocol.IotTx).TxCnt
+	case *protocol.ContractTx:
+		acc, txCnt = block.StateCopy[tx.(*protocol.ContractTx).From], tx.(*protocol.ContractTx).TxCnt
+	default:
+		return false
+	}
+
+	return acc != nil && txCnt > acc.TxCnt
+}
+
+//Returns the accounts referenced by the tx which are not in the state yet.
+func missingAccounts(tx protocol.Transaction) (missing [][32]byte) {
+	var referenced [][32]byte
+	switch tx.(type) {
+	case *protocol.FundsTx:
+		referenced = [][32]byte{tx.(*protocol.FundsTx).From, tx.(*protocol.FundsTx).To}
+	case *protocol.IotTx:
+		referenced = [][32]byte{tx.(*protocol.IotTx).From, tx.(*protocol.IotTx).To}
+	case *protocol.ContractTx:
+		referenced = [][32]byte{tx.(*protocol.ContractTx).From}
+	}
+
+	for _, address := range referenced {
+		if storage.State.Get(address) == nil {
+			missing = append(missing, address)
+		}
+	}
+
+	return missing
+}
+
+//The block's timestamp is set once it is finalized, which is not before now.
+func isLocked(block *protocol.Block, tx protocol.Transaction) bool {
+	fundsTx, ok := tx.(*protocol.FundsTx)
+	return ok && !fundsTx.IsUnlocked(block.Height, time.Now().Unix())
+}
+
+//Orphans whose accounts have been created by the block are admitted to the mempool.
+func adoptOrphanTxs() {
+	if adopted := mempool.AdoptOrphans(); len(adopted) > 0 {
+		logger.Printf("Adopted %v orphan txs.\n", len(adopted))
+	}
+}
+
+//The block's txs advanced the senders' txCnt, parked txs which are now next in line move to the mempool.
+func releaseParkedTxs(data blockData) {
+	senders := make(map[[32]byte]bool)
+	for _, tx := range data.fundsTxSlice {
+		senders[tx.From] = true
+	}
+	for _, aggTx := range data.aggTxSlice {
+		for _, tx := range aggregatedFundsTxs(aggTx) {
+			senders[tx.From] = true
+		}
+	}
+	for _, tx := range data.iotTxSlice {
+		senders[tx.From] = true
+	}
+	for _, tx := range data.contractTxSlice {
+		senders[tx.From] = true
+	}
+
+	for sender := range senders {
+		mempool.Release(sender)
+	}
+}
+
+//The keys are computed once, computing the tx hashes for every comparison would be expensive.
+func sortOpenTxs(txs []protocol.Transaction) {
+	sorted := openTxs{txs, make([]txOrderKey, len(txs))}
+	for i, tx := range txs {
+		sorted.keys[i] = newTxOrderKey(tx)
+	}
+
+	sort.Sort(sorted)
+}
+
 //Implement the sort interface
 func (f openTxs) Len() int {
-	return len(f)
+	return len(f.txs)
 }
 
 func (f openTxs) Swap(i, j int) {
-	f[i], f[j] = f[j], f[i]
+	f.txs[i], f.txs[j] = f.txs[j], f.txs[i]
+	f.keys[i], f.keys[j] = f.keys[j], f.keys[i]
 }
 
 func (f openTxs) Less(i, j int) bool {
-	//Comparison only makes sense if both tx are fundsTxs.
-	//Why can we only do that with switch, and not e.g., if tx.(type) == ..?
-	switch f[i].(type) {
-	case *protocol.AccTx:
-		//We only want to sort a subset of all transactions, namely all fundsTxs.
-		//However, to successfully do that we have to place all other txs at the beginning.
-		//The order between accTxs and configTxs doesn't matter.
-		return true
-	case *protocol.ConfigTx:
-		return true
-	case *protocol.StakeTx:
-		return true
-	case *protocol.AggTx:
-		return true
-	case *protocol.IotTx:
-		return true
+	//Txs without txCnt are placed at the beginning, e.g., accTxs have to be added before the fundsTxs sending funds to
+	//the new accounts.
+	if f.keys[i].ordered != f.keys[j].ordered {
+		return !f.keys[i].ordered
 	}
 
-	switch f[j].(type) {
-	case *protocol.AccTx:
-		return false
-	case *protocol.ConfigTx:
-		return false
-	case *protocol.StakeTx:
-		return false
-	case *protocol.AggTx:
-		return false
-	case *protocol.IotTx:
-		return false
-	}
-
-	return f[i].(*protocol.FundsTx).TxCnt < f[j].(*protocol.FundsTx).TxCnt
-}
\ No newline at end of file
+	return f.keys[i].less(f.keys[j])
+}
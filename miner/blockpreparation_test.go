@@ -19,8 +19,8 @@ func TestPrepareAndSortTxs(t *testing.T) {
 	for cnt := 0; cnt < testsize; cnt++ {
 		accAHash := protocol.SerializeHashContent(accA.Address)
 		accBHash := protocol.SerializeHashContent(accB.Address)
-		tx, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%100+1, randVar.Uint64()%100+1, uint32(cnt), accAHash, accBHash, PrivKeyAccA, PrivKeyMultiSig, nil)
-		tx2, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%100+1, randVar.Uint64()%100+1, uint32(cnt), accBHash, accAHash, PrivKeyAccB, PrivKeyMultiSig, nil)
+		tx, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%100+1, randVar.Uint64()%100+1, uint32(cnt), accAHash, accBHash, PrivKeyAccA, nil)
+		tx2, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%100+1, randVar.Uint64()%100+1, uint32(cnt), accBHash, accAHash, PrivKeyAccB, nil)
 
 		if verifyFundsTx(tx) {
 			storage.WriteOpenTx(tx)
@@ -32,9 +32,9 @@ func TestPrepareAndSortTxs(t *testing.T) {
 	}
 
 	//Add other tx types as well to make the test more challenging
-	nullAddress := [64]byte{}
+	nullAddress := [32]byte{}
 	for cnt := 0; cnt < testsize; cnt++ {
-		tx, _, _ := protocol.ConstrAccTx(0x01, randVar.Uint64()%100+1, nullAddress, PrivKeyRoot, nil, nil)
+		tx, _, _ := protocol.ConstrAccTx(0x01, randVar.Uint64()%100+1, 0, nullAddress, PrivKeyRoot, nil, nil)
 		if verifyAccTx(tx) {
 			storage.WriteOpenTx(tx)
 		}
@@ -52,7 +52,7 @@ func TestPrepareAndSortTxs(t *testing.T) {
 		}
 	}
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	prepareBlock(b)
 	finalizeBlock(b)
 
@@ -2,6 +2,7 @@ package miner
 
 import (
 	"errors"
+	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 	"github.com/bazo-blockchain/bazo-miner/storage"
 )
@@ -9,33 +10,42 @@ import (
 //Already validated block but not part of the current longest chain.
 //No need for an additional state mutex, because this function is called while the blockValidation mutex is actively held.
 func rollback(b *protocol.Block) error {
-	accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, aggTxSlice, iotTxSlice, err := preValidateRollback(b)
+	accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, aggTxSlice, iotTxSlice, contractTxSlice, err := preValidateRollback(b)
 	if err != nil {
 		return err
 	}
 
-	data := blockData{accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, aggTxSlice, iotTxSlice, b}
+	data := blockData{accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, aggTxSlice, iotTxSlice, contractTxSlice, b}
 
 	//Going back to pre-block system parameters before the state is rolled back.
-	configStateChangeRollback(data.configTxSlice, b.Hash)
+	configStateChangeRollback(data.configTxSlice, b.Hash, b.Height)
+
+	//Like the validation, the rollback of the state is applied atomically together with the writes to disk.
+	storage.BeginStateTransition()
 
 	//TODO Does not throw error but crashes
 	validateStateRollback(data)
 
-	postValidateRollback(data)
+	if err := postValidateRollback(data); err != nil {
+		storage.RevertStateTransition()
+		return err
+	}
+	storage.CommitStateTransition()
+
+	publishRollback(b)
 
 	return nil
 }
 
 func preValidateRollback(b *protocol.Block) (accTxSlice []*protocol.AccTx, fundsTxSlice []*protocol.FundsTx,
-	configTxSlice []*protocol.ConfigTx, stakeTxSlice []*protocol.StakeTx, aggTxSlice []*protocol.AggTx,iotTxSlice []*protocol.IotTx, err error) {
+	configTxSlice []*protocol.ConfigTx, stakeTxSlice []*protocol.StakeTx, aggTxSlice []*protocol.AggTx,iotTxSlice []*protocol.IotTx, contractTxSlice []*protocol.ContractTx, err error) {
 	//Fetch all transactions from closed storage.
 	for _, hash := range b.AccTxData {
 		var accTx *protocol.AccTx
 		tx := storage.ReadClosedTx(hash)
 		if tx == nil {
 			//This should never happen, because all validated transactions are in closed storage.
-			return nil, nil, nil, nil, nil, nil, errors.New("CRITICAL: Validated accTx was not in the confirmed tx storage")
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("CRITICAL: Validated accTx was not in the confirmed tx storage")
 		} else {
 			accTx = tx.(*protocol.AccTx)
 		}
@@ -46,7 +56,7 @@ func preValidateRollback(b *protocol.Block) (accTxSlice []*protocol.AccTx, funds
 		var fundsTx *protocol.FundsTx
 		tx := storage.ReadClosedTx(hash)
 		if tx == nil {
-			return nil, nil, nil, nil,nil, nil,errors.New("CRITICAL: Validated fundsTx was not in the confirmed tx storage")
+			return nil, nil, nil, nil,nil, nil, nil, errors.New("CRITICAL: Validated fundsTx was not in the confirmed tx storage")
 		} else {
 			fundsTx = tx.(*protocol.FundsTx)
 		}
@@ -57,7 +67,7 @@ func preValidateRollback(b *protocol.Block) (accTxSlice []*protocol.AccTx, funds
 		var configTx *protocol.ConfigTx
 		tx := storage.ReadClosedTx(hash)
 		if tx == nil {
-			return nil, nil, nil, nil, nil,nil, errors.New("CRITICAL: Validated configTx was not in the confirmed tx storage")
+			return nil, nil, nil, nil, nil,nil, nil, errors.New("CRITICAL: Validated configTx was not in the confirmed tx storage")
 		} else {
 			configTx = tx.(*protocol.ConfigTx)
 		}
@@ -68,7 +78,7 @@ func preValidateRollback(b *protocol.Block) (accTxSlice []*protocol.AccTx, funds
 		var stakeTx *protocol.StakeTx
 		tx := storage.ReadClosedTx(hash)
 		if tx == nil {
-			return nil, nil, nil, nil, nil,nil, errors.New("CRITICAL: Validated stakeTx was not in the confirmed tx storage")
+			return nil, nil, nil, nil, nil,nil, nil, errors.New("CRITICAL: Validated stakeTx was not in the confirmed tx storage")
 		} else {
 			stakeTx = tx.(*protocol.StakeTx)
 		}
@@ -79,7 +89,7 @@ func preValidateRollback(b *protocol.Block) (accTxSlice []*protocol.AccTx, funds
 		var IoTTx *protocol.IotTx
 		tx := storage.ReadClosedTx(hash)
 		if tx == nil {
-			return nil, nil, nil, nil, nil, nil,errors.New("CRITICAL: Aggregated Transaction was not in the confirmed tx storage")
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("CRITICAL: Aggregated Transaction was not in the confirmed tx storage")
 		} else {
 			IoTTx = tx.(*protocol.IotTx)
 		}
@@ -90,72 +100,165 @@ func preValidateRollback(b *protocol.Block) (accTxSlice []*protocol.AccTx, funds
 		var aggTx *protocol.AggTx
 		tx := storage.ReadClosedTx(hash)
 		if tx == nil {
-			return nil, nil, nil, nil, nil, nil,errors.New("CRITICAL: Aggregated Transaction was not in the confirmed tx storage")
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("CRITICAL: Aggregated Transaction was not in the confirmed tx storage")
 		} else {
 			aggTx = tx.(*protocol.AggTx)
 		}
 		aggTxSlice = append(aggTxSlice, aggTx)
 	}
 
-	return accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, aggTxSlice, iotTxSlice,nil
+	for _, hash := range b.ContractTxData {
+		var contractTx *protocol.ContractTx
+		tx := storage.ReadClosedTx(hash)
+		if tx == nil {
+			return nil, nil, nil, nil, nil, nil, nil, errors.New("CRITICAL: Validated contractTx was not in the confirmed tx storage")
+		} else {
+			contractTx = tx.(*protocol.ContractTx)
+		}
+		contractTxSlice = append(contractTxSlice, contractTx)
+	}
+
+	return accTxSlice, fundsTxSlice, configTxSlice, stakeTxSlice, aggTxSlice, iotTxSlice, contractTxSlice, nil
 }
 
 func validateStateRollback(data blockData) {
-	collectSlashRewardRollback(activeParameters.Slash_reward, data.block)
-	collectBlockRewardRollback(activeParameters.Block_reward, data.block.Beneficiary)
+	params := parametersAt(data.block.Height)
+	collectSlashRewardRollback(params.Slash_reward, data.block)
+	collectDelegatorRewardsRollback(data, params.Block_reward)
+	collectBlockRewardRollback(params.Block_reward, data.block.Beneficiary)
 	collectTxFeesRollback(data.accTxSlice, data.fundsTxSlice, data.configTxSlice, data.stakeTxSlice, data.block.Beneficiary)
+	contractStateChangeRollback(data.contractTxSlice, data.block.Beneficiary)
+	iotRateLimitStateChangeRollback(data.iotTxSlice)
+	iotStateChangeRollback(data.iotTxSlice)
 	stakeStateChangeRollback(data.stakeTxSlice)
-	fundsStateChangeRollback(data.fundsTxSlice)
-	aggregatedSenderStateRollback(data.aggTxSlice)
+	aggregatedSenderStateRollback(data.aggTxSlice, data.block.Height)
+	fundsStateChangeRollback(data.fundsTxSlice, data.block.Height)
+	rootKeyStateChangeRollback(data.configTxSlice)
 	accStateChangeRollback(data.accTxSlice)
 }
 
-func postValidateRollback(data blockData) {
+func postValidateRollback(data blockData) error {
+	//All writes of the block are committed at once, so a crash never leaves the block partially rolled back on disk.
+	batch := storage.NewBatch()
+
+	for _, tx := range data.accTxSlice {
+		batch.DeleteClosedTx(tx)
+		if tx.Header == 2 {
+			batch.DeleteRemovedAccount(tx.Hash())
+		}
+	}
+
+	for _, tx := range data.fundsTxSlice {
+		batch.DeleteClosedTx(tx)
+		batch.DeleteContractStates(tx.Hash())
+	}
+
+	for _, tx := range data.configTxSlice {
+		batch.DeleteClosedTx(tx)
+	}
+
+	for _, tx := range data.stakeTxSlice {
+		batch.DeleteClosedTx(tx)
+	}
+
+	for _, tx := range data.iotTxSlice {
+		batch.DeleteClosedTx(tx)
+		batch.DeleteIotTxBlock(tx.Hash())
+		batch.DeleteIotBucket(tx.Hash())
+	}
+
+	for _, tx := range data.contractTxSlice {
+		batch.DeleteClosedTx(tx)
+	}
+
+	var aggregatedTxs []protocol.Transaction
+	for _, tx := range data.aggTxSlice {
+		for _, aggregatedTxHash := range tx.AggregatedTxSlice {
+			trx := storage.ReadClosedTx(aggregatedTxHash)
+			aggregatedTxs = append(aggregatedTxs, trx)
+			batch.DeleteClosedTx(trx)
+			batch.DeleteContractStates(aggregatedTxHash)
+		}
+
+		//Delete AggTx. No need to write in OpenTx, because it will be created newly.
+		batch.DeleteClosedTx(tx)
+	}
+
+	batch.DeleteBlockHeight(data.block.Height)
+	batch.DeleteBlockWeight(data.block.Hash)
+	if data.block.Height%VALIDATOR_EPOCH_LENGTH == 0 {
+		batch.DeleteValidatorSet(data.block.Height)
+		batch.DeleteLivenessEvidence(data.block.Height)
+	}
+	deleteAccountHistory(batch, data)
+	deleteBalanceHistory(batch, data.block.Height)
+	batch.DeleteBeneficiaryBlock(data.block.Beneficiary, data.block.Height)
+	if data.block.SlashedAddress != [32]byte{} {
+		batch.DeleteSlashingBlock(data.block.SlashedAddress, data.block.Height)
+		batch.DeleteSlashedStake(data.block.Hash)
+		//The proof is pending again, the other chain might not include it yet. The height of the conflicting blocks is
+		//not known without reading them, the block's height is an upper bound.
+		addSlashingProof(&protocol.SlashingProof{SlashedAddress: data.block.SlashedAddress, ConflictingBlockHash1: data.block.ConflictingBlockHash1, ConflictingBlockHash2: data.block.ConflictingBlockHash2, ConflictingBlockHashWithoutTx1: data.block.ConflictingBlockHashWithoutTx1, ConflictingBlockHashWithoutTx2: data.block.ConflictingBlockHashWithoutTx2, Height: data.block.Height})
+	}
+	for _, contract := range calledContracts(data.fundsTxSlice) {
+		batch.DeleteLogs(data.block.Height, contract)
+	}
+
+	//For transactions we switch from closed to open. However, we do not write back blocks
+	//to open storage, because in case of rollback the chain they belonged to is likely to starve.
+	batch.DeleteClosedBlock(data.block.Hash)
+
+	//Save the previous block as the last closed block.
+	batch.SetLastClosedBlock(storage.ReadClosedBlock(data.block.PrevHash))
+
+	if err := batch.Commit(); err != nil {
+		return errors.New(fmt.Sprintf("Rollback of block (%x) could not be written: %v", data.block.Hash[0:8], err))
+	}
+
+	//The block replacing it at this height has to be emptied as well.
+	if data.block.Height < emptiedHeight {
+		emptiedHeight = data.block.Height
+	}
+
 	//Put all validated txs into invalidated state.
 	for _, tx := range data.accTxSlice {
 		storage.WriteOpenTx(tx)
-		storage.DeleteClosedTx(tx)
 	}
 
 	for _, tx := range data.fundsTxSlice {
 		storage.WriteOpenTx(tx)
-		storage.DeleteClosedTx(tx)
 	}
 
 	for _, tx := range data.configTxSlice {
 		storage.WriteOpenTx(tx)
-		storage.DeleteClosedTx(tx)
 	}
 
 	for _, tx := range data.stakeTxSlice {
 		storage.WriteOpenTx(tx)
-		storage.DeleteClosedTx(tx)
 	}
 
-	for _, tx := range data.aggTxSlice {
+	for _, tx := range data.iotTxSlice {
+		storage.WriteOpenTx(tx)
+	}
 
-		//Reopen FundsTx per aggTx
-		for _, aggregatedTxHash := range tx.AggregatedTxSlice {
-			trx := storage.ReadClosedTx(aggregatedTxHash)
-			storage.WriteOpenTx(trx)
-			storage.DeleteClosedTx(trx)
-		}
+	for _, tx := range data.contractTxSlice {
+		storage.WriteOpenTx(tx)
+	}
 
-		//Delete AggTx. No need to write in OpenTx, because it will be created newly.
+	//Reopen FundsTx per aggTx
+	for _, trx := range aggregatedTxs {
+		storage.WriteOpenTx(trx)
+	}
+	for _, tx := range data.aggTxSlice {
 		logger.Printf("Rolled Back AggTx: %x, %v", tx.Hash(), tx.Hash())
-		storage.DeleteClosedTx(tx)
 	}
 
 	//CalculateBlockchainSize(-int(data.block.GetSize()))
 
 	collectStatisticsRollback(data.block)
+	recordBlockUtilizationRollback()
 
-	//For transactions we switch from closed to open. However, we do not write back blocks
-	//to open storage, because in case of rollback the chain they belonged to is likely to starve.
-	storage.DeleteClosedBlock(data.block.Hash)
 	storage.WriteToReceivedStash(data.block) //Write it to received stash, it will be deleted after X new blocks.
 
-	//Save the previous block as the last closed block.
-	storage.DeleteAllLastClosedBlock()
-	storage.WriteLastClosedBlock(storage.ReadClosedBlock(data.block.PrevHash))
+	return nil
 }
@@ -13,14 +13,14 @@ import (
 func TestValidateBlockRollback(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 
 	//Make state snapshot
-	accsBefore := make(map[[64]byte]protocol.Account)
-	accsBefore2 := make(map[[64]byte]protocol.Account)
-	accsAfter := make(map[[64]byte]protocol.Account)
+	accsBefore := make(map[[32]byte]protocol.Account)
+	accsBefore2 := make(map[[32]byte]protocol.Account)
+	accsAfter := make(map[[32]byte]protocol.Account)
 
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		accsBefore[acc.Address] = *acc
 	}
 
@@ -33,7 +33,7 @@ func TestValidateBlockRollback(t *testing.T) {
 		t.Errorf("Could not validate block: %v\n", err)
 	}
 
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		accsAfter[acc.Address] = *acc
 	}
 
@@ -46,7 +46,7 @@ func TestValidateBlockRollback(t *testing.T) {
 		t.Errorf("%v\n", err)
 	}
 
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		accsBefore2[acc.Address] = *acc
 	}
 	accsBefore2 = resetStakingBlockHeight(accsBefore2)
@@ -62,59 +62,59 @@ func TestMultipleBlocksRollback(t *testing.T) {
 	cleanAndPrepare()
 
 	//State snapshot
-	stateb := make(map[[64]byte]protocol.Account)
-	stateb2 := make(map[[64]byte]protocol.Account)
-	stateb3 := make(map[[64]byte]protocol.Account)
-	tmpState := make(map[[64]byte]protocol.Account)
+	stateb := make(map[[32]byte]protocol.Account)
+	stateb2 := make(map[[32]byte]protocol.Account)
+	stateb3 := make(map[[32]byte]protocol.Account)
+	tmpState := make(map[[32]byte]protocol.Account)
 
 	//system parameters
 	var paramb []Parameters
 	var paramb2 []Parameters
 	var paramb3 []Parameters
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	createBlockWithTxs(b)
 	finalizeBlock(b)
 	if err := validate(b, false); err != nil {
 		t.Errorf("Block validation for (%v) failed: %v\n", b, err)
 	}
 
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		stateb[acc.Address] = *acc
 	}
 
 	paramb = make([]Parameters, len(parameterSlice))
 	copy(paramb, parameterSlice)
 
-	b2 := newBlock(b.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b2 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	createBlockWithTxs(b2)
 	finalizeBlock(b2)
 	if err := validate(b2, false); err != nil {
 		t.Errorf("Block failed: %v\n", b2)
 	}
 
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		stateb2[acc.Address] = *acc
 	}
 
 	paramb2 = make([]Parameters, len(parameterSlice))
 	copy(paramb2, parameterSlice)
 
-	b3 := newBlock(b2.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 3)
+	b3 := newBlock(b2.Hash, b2.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 3)
 	createBlockWithTxs(b3)
 	finalizeBlock(b3)
 	if err := validate(b3, false); err != nil {
 		t.Errorf("Block failed: %v\n", b3)
 	}
 
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		stateb3[acc.Address] = *acc
 	}
 
 	paramb3 = make([]Parameters, len(parameterSlice))
 	copy(paramb3, parameterSlice)
 
-	b4 := newBlock(b3.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 4)
+	b4 := newBlock(b3.Hash, b3.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 4)
 	createBlockWithTxs(b4)
 	finalizeBlock(b4)
 	if err := validate(b4, false); err != nil {
@@ -125,7 +125,7 @@ func TestMultipleBlocksRollback(t *testing.T) {
 	if err := rollback(b4); err != nil {
 		t.Errorf("%v\n", err)
 	}
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		tmpState[acc.Address] = *acc
 	}
 	tmpState = resetStakingBlockHeight(tmpState)
@@ -143,7 +143,7 @@ func TestMultipleBlocksRollback(t *testing.T) {
 		t.Errorf("%v\n", err)
 		return
 	}
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		tmpState[acc.Address] = *acc
 	}
 	tmpState = resetStakingBlockHeight(tmpState)
@@ -158,7 +158,7 @@ func TestMultipleBlocksRollback(t *testing.T) {
 	if err := rollback(b2); err != nil {
 		t.Errorf("%v\n", err)
 	}
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		tmpState[acc.Address] = *acc
 	}
 	tmpState = resetStakingBlockHeight(tmpState)
@@ -173,7 +173,7 @@ func TestMultipleBlocksRollback(t *testing.T) {
 	if err := rollback(b); err != nil {
 		t.Errorf("%v\n", err)
 	}
-	for _, acc := range storage.State {
+	for _, acc := range storage.State.Snapshot() {
 		tmpState[acc.Address] = *acc
 	}
 
@@ -185,8 +185,8 @@ func TestMultipleBlocksRollback(t *testing.T) {
 // resetStakingBlockHeight sets the StackingBlockHeight of all accounts to 0.
 // This is needed so that the other fields can get tested.
 // TODO Remove this function if rollback of StakingBlockHeight gets implemented.
-func resetStakingBlockHeight(accounts map[[64]byte]protocol.Account) map[[64]byte]protocol.Account {
-	accountsNoStakingBlockHeight := make(map[[64]byte]protocol.Account)
+func resetStakingBlockHeight(accounts map[[32]byte]protocol.Account) map[[32]byte]protocol.Account {
+	accountsNoStakingBlockHeight := make(map[[32]byte]protocol.Account)
 
 	for hash, acc := range accounts {
 		acc.StakingBlockHeight = 0
@@ -0,0 +1,69 @@
+package miner
+
+import (
+	"time"
+)
+
+//Validators produce a block as soon as they are eligible, even if there are no txs to include. With the block trigger
+//parameters (set by config txs), a validator waits until enough txs are pending (Block_trigger_txs) or until the last
+//block is old enough (Block_trigger_time), whichever comes first. This avoids a chain full of empty blocks on a quiet
+//network while the time trigger still bounds the latency of a single tx. A tx count of 0 does not wait, which is the
+//default.
+//Independent of the parameters, a validator can be configured to suppress empty blocks: it does not propose a block
+//while its mempool is empty, except for a heartbeat block when the last block is older than the heartbeat interval.
+//The heartbeat keeps the chain alive, e.g., for the difficulty adjustment and for nodes checking the tip's age.
+
+const (
+	BLOCK_TRIGGER_CHECK_INTERVAL  = 500 * time.Millisecond
+	EMPTY_BLOCK_HEARTBEAT_DEFAULT = 5 * time.Minute
+)
+
+var (
+	suppressEmptyBlocks bool
+	emptyBlockHeartbeat = EMPTY_BLOCK_HEARTBEAT_DEFAULT
+)
+
+func SetEmptyBlockSuppression(enabled bool, heartbeat time.Duration) {
+	suppressEmptyBlocks = enabled
+	emptyBlockHeartbeat = heartbeat
+}
+
+func blockTriggered(pendingTxs int, sinceLastBlock int64, triggerTxs uint64, triggerTime uint64) bool {
+	if triggerTxs == 0 || uint64(pendingTxs) >= triggerTxs {
+		return true
+	}
+
+	return sinceLastBlock >= int64(triggerTime)
+}
+
+func emptyBlockSuppressed(pendingTxs int, sinceLastBlock int64) bool {
+	return suppressEmptyBlocks && pendingTxs == 0 && sinceLastBlock < int64(emptyBlockHeartbeat/time.Second)
+}
+
+//Blocks until the next block should be produced according to the active parameters and the empty block suppression.
+func waitForBlockTrigger() {
+	var waiting bool
+	for !isShuttingDown() {
+		blockValidation.Lock()
+		params := *activeParameters
+		sinceLastBlock := time.Now().Unix() - lastBlock.Timestamp
+		blockValidation.Unlock()
+
+		pendingTxs := mempool.Len()
+		triggered := blockTriggered(pendingTxs, sinceLastBlock, params.Block_trigger_txs, params.Block_trigger_time)
+		if triggered && !emptyBlockSuppressed(pendingTxs, sinceLastBlock) {
+			return
+		}
+
+		if !waiting {
+			waiting = true
+			if triggered {
+				logger.Printf("Mempool empty, waiting for txs or the heartbeat (%v).\n", emptyBlockHeartbeat)
+			} else {
+				logger.Printf("Waiting for %v pending txs (%v now) or %v seconds since the last block.\n", params.Block_trigger_txs, pendingTxs, params.Block_trigger_time)
+			}
+		}
+
+		time.Sleep(BLOCK_TRIGGER_CHECK_INTERVAL)
+	}
+}
@@ -0,0 +1,70 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestBlockTriggered(t *testing.T) {
+	tests := []struct {
+		pendingTxs     int
+		sinceLastBlock int64
+		triggerTxs     uint64
+		triggerTime    uint64
+		triggered      bool
+	}{
+		{0, 0, 0, 0, true},
+		{0, 0, 0, 60, true},
+		{9, 10, 10, 60, false},
+		{10, 10, 10, 60, true},
+		{0, 60, 10, 60, true},
+		{0, 59, 10, 60, false},
+		{0, 0, 10, 0, true},
+	}
+
+	for _, test := range tests {
+		if triggered := blockTriggered(test.pendingTxs, test.sinceLastBlock, test.triggerTxs, test.triggerTime); triggered != test.triggered {
+			t.Errorf("Block trigger for %+v should be %v, got %v.\n", test, test.triggered, triggered)
+		}
+	}
+}
+
+func TestBlockTriggerParameters(t *testing.T) {
+	params := NewDefaultParameters()
+	configTxs := []*protocol.ConfigTx{
+		{Id: protocol.BLOCK_TRIGGER_TXS_ID, Payload: 50},
+		{Id: protocol.BLOCK_TRIGGER_TIME_ID, Payload: 120},
+	}
+
+	if !CheckAndChangeParameters(&params, &configTxs) || params.Block_trigger_txs != 50 || params.Block_trigger_time != 120 {
+		t.Errorf("Block trigger parameters not changed: %v\n", params)
+	}
+
+	configTxs = []*protocol.ConfigTx{{Id: protocol.BLOCK_TRIGGER_TIME_ID, Payload: protocol.MAX_BLOCK_TRIGGER_TIME + 1}}
+	if CheckAndChangeParameters(&params, &configTxs) || params.Block_trigger_time != 120 {
+		t.Errorf("Block trigger time out of bounds should be ignored: %v\n", params)
+	}
+}
+
+func TestEmptyBlockSuppression(t *testing.T) {
+	defer SetEmptyBlockSuppression(false, EMPTY_BLOCK_HEARTBEAT_DEFAULT)
+
+	if emptyBlockSuppressed(0, 0) {
+		t.Error("Empty blocks should not be suppressed by default.")
+	}
+
+	SetEmptyBlockSuppression(true, time.Minute)
+	if !emptyBlockSuppressed(0, 59) {
+		t.Error("Empty block should be suppressed before the heartbeat.")
+	}
+
+	if emptyBlockSuppressed(0, 60) {
+		t.Error("Heartbeat block should not be suppressed.")
+	}
+
+	if emptyBlockSuppressed(1, 0) {
+		t.Error("Block with pending txs should not be suppressed.")
+	}
+}
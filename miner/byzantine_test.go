@@ -0,0 +1,101 @@
+package miner
+
+import (
+	"context"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//Deliberately invalid blocks a byzantine validator might send us. Every fixture starts from a properly finalized
+//block and breaks exactly one property, so the rejection can be attributed to a single check in preValidate.
+type byzantineBlock struct {
+	name   string
+	reason string
+	create func(t *testing.T) *protocol.Block
+}
+
+var byzantineBlocks = []byzantineBlock{
+	{"bad merkle root", REJECTED_MERKLE_ROOT, byzantineBadMerkleRoot},
+	{"forged commitment proof", REJECTED_COMMITMENT_PROOF, byzantineForgedCommitmentProof},
+	{"duplicate tx hashes", REJECTED_DUPLICATE_TX, byzantineDuplicateTxHashes},
+	{"unknown beneficiary", REJECTED_BENEFICIARY, byzantineUnknownBeneficiary},
+	{"beneficiary not staking", REJECTED_NOT_VALIDATOR, byzantineNonStakingBeneficiary},
+}
+
+func finalizedTestBlock(t *testing.T) *protocol.Block {
+	b := newBlock(genesisBlock.Hash, genesisBlock.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	if err := finalizeBlock(b); err != nil {
+		t.Fatalf("Block finalization failed (%v)\n", err)
+	}
+
+	return b
+}
+
+func byzantineBadMerkleRoot(t *testing.T) *protocol.Block {
+	b := finalizedTestBlock(t)
+	b.MerkleRoot = protocol.SerializeHashContent("forged merkle root")
+
+	return b
+}
+
+func byzantineForgedCommitmentProof(t *testing.T) *protocol.Block {
+	b := finalizedTestBlock(t)
+	copy(b.CommitmentProof[:], protocol.RandomBytesWithLength(crypto.COMM_PROOF_LENGTH))
+
+	return b
+}
+
+func byzantineDuplicateTxHashes(t *testing.T) *protocol.Block {
+	b := finalizedTestBlock(t)
+	txHash := protocol.SerializeHashContent("duplicate")
+	b.FundsTxData = [][32]byte{txHash, txHash}
+	b.NrFundsTx = 2
+
+	return b
+}
+
+func byzantineUnknownBeneficiary(t *testing.T) *protocol.Block {
+	b := finalizedTestBlock(t)
+	b.Beneficiary = protocol.SerializeHashContent("unknown beneficiary")
+
+	return b
+}
+
+func byzantineNonStakingBeneficiary(t *testing.T) *protocol.Block {
+	b := finalizedTestBlock(t)
+	b.Beneficiary = protocol.SerializeHashContent(accA.Address)
+
+	return b
+}
+
+func TestPreValidateByzantineBlocks(t *testing.T) {
+	for _, fixture := range byzantineBlocks {
+		cleanAndPrepare()
+
+		block := fixture.create(t)
+		countBefore := GetRejectedBlockCount(fixture.reason)
+
+		if _, _, _, _, _, _, _, err := preValidate(context.Background(), block, false); err == nil {
+			t.Errorf("%v: block has been accepted by preValidate\n", fixture.name)
+		}
+
+		if countAfter := GetRejectedBlockCount(fixture.reason); countAfter != countBefore+1 {
+			t.Errorf("%v: rejection has not been counted as %v (%v vs. %v)\n", fixture.name, fixture.reason, countBefore, countAfter)
+		}
+	}
+}
+
+func TestRejectedBlockCount(t *testing.T) {
+	countBefore := GetRejectedBlockCount(REJECTED_BLOCK_SIZE)
+	countRejectedBlock(REJECTED_BLOCK_SIZE)
+
+	if GetRejectedBlockCount(REJECTED_BLOCK_SIZE) != countBefore+1 {
+		t.Errorf("Rejected block counter has not been increased\n")
+	}
+
+	if GetRejectedBlockCount("unknown") != 0 {
+		t.Errorf("Unknown rejection reason should not have been counted\n")
+	}
+}
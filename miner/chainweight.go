@@ -0,0 +1,86 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"math/big"
+)
+
+//Competing chains are compared by their accumulated difficulty, not by their length. A block validated at difficulty d
+//(the number of leading zero bits of its proof of stake) weighs 2^d, the weight of a block is stored together with the
+//sum of the weights of the chain up to it. The weights of the blocks of a competing chain are summed block by block:
+//blocks which have a weight already are weighted with their own difficulty, the difficulty of the blocks which have
+//not been validated yet is estimated with the one of the block below, starting with the difficulty of the first block
+//above the ancestor on the active chain (the difficulty the competing chain starts with). Retargets within the
+//competing chain are only known once its blocks are validated, the fork choice is therefore checked again with the
+//weights of the validated blocks before the switch is kept (see keepHeavierChain).
+//Blocks validated before the weights were stored have none, the weights of their descendants start from 0. The
+//chains are compared by length if the ancestor or the blocks above it have no weight.
+
+//Returns 2^difficulty.
+func blockWork(difficulty uint8) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(difficulty))
+}
+
+//Returns the accumulated difficulty of the chain up to block, validated at difficulty.
+func accumulatedWeight(block *protocol.Block, difficulty uint8) *big.Int {
+	weight := blockWork(difficulty)
+	if _, parentWeight := storage.ReadBlockWeight(block.PrevHash); parentWeight != nil {
+		weight.Add(weight, parentWeight)
+	}
+
+	return weight
+}
+
+//Returns true if newChain (the blocks above ancestor, in height order) outweighs the active chain, whose blocks above
+//ancestor are blocksToRollback (from the last block down).
+func isHeavierChain(ancestor *protocol.Block, blocksToRollback []*protocol.Block, newChain []*protocol.Block) bool {
+	if len(blocksToRollback) == 0 {
+		return len(newChain) > 0
+	}
+
+	_, ancestorWeight := storage.ReadBlockWeight(ancestor.Hash)
+	difficulty, firstWeight := storage.ReadBlockWeight(blocksToRollback[len(blocksToRollback)-1].Hash)
+	_, activeWeight := storage.ReadBlockWeight(blocksToRollback[0].Hash)
+	if ancestorWeight == nil || firstWeight == nil || activeWeight == nil {
+		return len(newChain) > len(blocksToRollback)
+	}
+
+	newWeight := new(big.Int).Set(ancestorWeight)
+	for _, block := range newChain {
+		if blockDifficulty, weight := storage.ReadBlockWeight(block.Hash); weight != nil {
+			difficulty = blockDifficulty
+		}
+		newWeight.Add(newWeight, blockWork(difficulty))
+	}
+
+	return newWeight.Cmp(activeWeight) > 0
+}
+
+//Called once the blocks of a competing chain have been validated in place of the rolled back ones. Returns an error if
+//the new chain does not outweigh the previous one with the difficulties its blocks have been validated at, the
+//previous chain is restored then. activeWeight is the weight of the previous last block, read before the rollback.
+func keepHeavierChain(ctx context.Context, rolledBack []*protocol.Block, validated []*protocol.Block, activeWeight *big.Int, initialSetup bool) error {
+	_, newWeight := storage.ReadBlockWeight(validated[len(validated)-1].Hash)
+	if activeWeight == nil || newWeight == nil || newWeight.Cmp(activeWeight) > 0 {
+		return nil
+	}
+
+	logger.Printf("Chain is not heavier after validation (%v vs %v), switching back.\n", newWeight, activeWeight)
+	for i := len(validated) - 1; i >= 0; i-- {
+		if err := rollback(validated[i]); err != nil {
+			return err
+		}
+	}
+	for i := len(rolledBack) - 1; i >= 0; i-- {
+		if err := validateBlock(ctx, rolledBack[i], initialSetup); err != nil {
+			return err
+		}
+		storage.CommitStateTransition()
+	}
+
+	return errors.New(fmt.Sprintf("Block belongs to a lighter or equally heavy chain after validation (%v vs %v).", newWeight, activeWeight))
+}
@@ -0,0 +1,119 @@
+package miner
+
+import (
+	"context"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"math/big"
+	"testing"
+)
+
+//Writes the blocks as closed blocks with weights, each validated at the given difficulty.
+func writeWeightedChain(t *testing.T, ancestor *protocol.Block, difficulties []uint8, tag byte) (chain []*protocol.Block) {
+	prev := ancestor
+	for i, difficulty := range difficulties {
+		block := &protocol.Block{Hash: [32]byte{0xc4, tag, byte(i)}, PrevHash: prev.Hash, Height: prev.Height + 1}
+		storage.WriteClosedBlock(block)
+		batch := storage.NewBatch()
+		batch.WriteBlockWeight(block.Hash, difficulty, accumulatedWeight(block, difficulty))
+		if err := batch.Commit(); err != nil {
+			t.Fatal(err)
+		}
+		chain = append(chain, block)
+		prev = block
+	}
+
+	return chain
+}
+
+func deleteWeightedChain(chain []*protocol.Block) {
+	for _, block := range chain {
+		storage.DeleteClosedBlock(block.Hash)
+		storage.DeleteBlockWeight(block.Hash)
+	}
+}
+
+func TestAccumulatedWeight(t *testing.T) {
+	ancestor := &protocol.Block{Hash: [32]byte{0xc5, 0x01}, Height: 900000}
+	//The ancestor has no weight, the weights start from 0.
+	chain := writeWeightedChain(t, ancestor, []uint8{10, 9}, 0x01)
+	defer deleteWeightedChain(chain)
+
+	if difficulty, weight := storage.ReadBlockWeight(chain[1].Hash); difficulty != 9 || weight == nil || weight.Cmp(big.NewInt(1024+512)) != 0 {
+		t.Errorf("Block weight %v at difficulty %v, expected 1536 at 9.", weight, difficulty)
+	}
+
+	if _, weight := storage.ReadBlockWeight(ancestor.Hash); weight != nil {
+		t.Errorf("Weight %v of a block without weight.", weight)
+	}
+}
+
+//Equally long chains are compared by their accumulated difficulty.
+func TestEqualHeightForks(t *testing.T) {
+	defer func(block *protocol.Block) { lastBlock = block }(lastBlock)
+
+	ancestor := &protocol.Block{Hash: [32]byte{0xc5, 0x02}, Height: 900000}
+	storage.WriteClosedBlock(ancestor)
+	defer storage.DeleteClosedBlock(ancestor.Hash)
+	ancestorWeight := storage.NewBatch()
+	ancestorWeight.WriteBlockWeight(ancestor.Hash, 10, big.NewInt(1<<20))
+	ancestorWeight.Commit()
+	defer storage.DeleteBlockWeight(ancestor.Hash)
+
+	//The difficulty dropped after the first block of the active chain.
+	active := writeWeightedChain(t, ancestor, []uint8{10, 9}, 0x02)
+	defer deleteWeightedChain(active)
+	lastBlock = active[1]
+
+	//A competing chain of the same length starts at difficulty 10 as well and outweighs the active one.
+	fork1 := &protocol.Block{Hash: [32]byte{0xc4, 0x03, 0x00}, PrevHash: ancestor.Hash, Height: ancestor.Height + 1}
+	fork2 := &protocol.Block{Hash: [32]byte{0xc4, 0x03, 0x01}, PrevHash: fork1.Hash, Height: ancestor.Height + 2}
+	storage.WriteToReceivedStash(fork1)
+
+	blocksToRollback, blocksToValidate, err := getBlockSequences(fork2)
+	if err != nil {
+		t.Fatalf("Heavier fork of the same length rejected: %v", err)
+	}
+	if len(blocksToRollback) != 2 || len(blocksToValidate) != 2 || blocksToValidate[0] != fork1 || blocksToValidate[1] != fork2 {
+		t.Errorf("Rolling back %v blocks and validating %v blocks, expected 2 and 2.", len(blocksToRollback), len(blocksToValidate))
+	}
+
+	//Without the retarget the chains weigh the same, the active one is kept.
+	storage.DeleteBlockWeight(active[1].Hash)
+	batch := storage.NewBatch()
+	batch.WriteBlockWeight(active[1].Hash, 10, accumulatedWeight(active[1], 10))
+	batch.Commit()
+	if _, _, err := getBlockSequences(fork2); err == nil {
+		t.Error("Fork as heavy as the active chain accepted.")
+	}
+
+	//A shorter fork does not outweigh the active chain at the same difficulty.
+	if isHeavierChain(ancestor, []*protocol.Block{active[1], active[0]}, []*protocol.Block{fork1}) {
+		t.Error("Shorter fork accepted.")
+	}
+
+	//Blocks validated before are weighted with their own difficulty, the blocks above them with the same.
+	forkWeight := storage.NewBatch()
+	forkWeight.WriteBlockWeight(fork1.Hash, 12, accumulatedWeight(fork1, 12))
+	forkWeight.Commit()
+	defer storage.DeleteBlockWeight(fork1.Hash)
+	if !isHeavierChain(ancestor, []*protocol.Block{active[1], active[0]}, []*protocol.Block{fork1}) {
+		t.Error("Shorter fork at a higher difficulty rejected.")
+	}
+
+	//The weights of the validated blocks decide, the heavier chain is kept.
+	_, activeWeight := storage.ReadBlockWeight(active[1].Hash)
+	if err := keepHeavierChain(context.Background(), []*protocol.Block{active[1], active[0]}, []*protocol.Block{fork1}, activeWeight, false); err != nil {
+		t.Errorf("Heavier chain not kept: %v", err)
+	}
+	storage.DeleteBlockWeight(fork1.Hash)
+
+	//Without weights, the chains are compared by length.
+	storage.DeleteBlockWeight(ancestor.Hash)
+	if isHeavierChain(ancestor, []*protocol.Block{active[1], active[0]}, []*protocol.Block{fork1, fork2}) {
+		t.Error("Fork of the same length accepted without weights.")
+	}
+	if !isHeavierChain(ancestor, []*protocol.Block{active[1], active[0]}, []*protocol.Block{fork1, fork2, {}}) {
+		t.Error("Longer fork rejected without weights.")
+	}
+}
@@ -0,0 +1,288 @@
+package miner
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//Constrained IoT devices often can not keep the TCP connections of the p2p protocol or afford HTTP. The CoAP endpoint
+//(RFC 7252) accepts pre-signed IotTxs in the compact encoding (see protocol/iotcompact.go) over UDP:
+//
+//	POST coap://<node>/iot with the compact tx as payload
+//
+//The response is 2.01 Created with the hash of the tx as payload or an error code with a diagnostic message.
+//Only the subset of CoAP needed for this is implemented: confirmable requests are answered with a piggybacked
+//response, non-confirmable requests with a non-confirmable response. Block-wise transfers are not supported, a tx
+//must fit into a single datagram.
+
+const (
+	COAP_VERSION = 1
+
+	COAP_CON = 0
+	COAP_NON = 1
+	COAP_ACK = 2
+	COAP_RST = 3
+
+	//Codes are encoded as class (3 bits) and detail (5 bits), e.g., 2.01 is 0x41.
+	COAP_EMPTY              = 0x00
+	COAP_POST               = 0x02
+	COAP_CREATED            = 0x41
+	COAP_BAD_REQUEST        = 0x80
+	COAP_BAD_OPTION         = 0x82
+	COAP_FORBIDDEN          = 0x83
+	COAP_NOT_FOUND          = 0x84
+	COAP_METHOD_NOT_ALLOWED = 0x85
+
+	COAP_OPTION_URI_HOST = 3
+	COAP_OPTION_URI_PORT = 7
+	COAP_OPTION_URI_PATH = 11
+	COAP_PAYLOAD_MARKER  = 0xff
+	COAP_MAX_TOKEN_SIZE  = 8
+
+	COAP_IOT_PATH         = "iot"
+	COAP_MAX_MESSAGE_SIZE = 65507 //Maximum UDP payload
+	//A confirmable request is retransmitted with the same message ID until it is acknowledged, the response is
+	//repeated instead of submitting the tx again (EXCHANGE_LIFETIME in RFC 7252).
+	COAP_EXCHANGE_LIFETIME = 247 * time.Second
+)
+
+type coapMessage struct {
+	msgType   uint8
+	code      uint8
+	messageID uint16
+	token     []byte
+	uriPath   []string
+	payload   []byte
+
+	//Set if the request contains a critical option which is not supported.
+	badOption bool
+}
+
+type coapExchange struct {
+	addr      string
+	messageID uint16
+}
+
+type coapResponse struct {
+	encoded []byte
+	expires time.Time
+}
+
+var (
+	coapResponses      = make(map[coapExchange]coapResponse)
+	coapNextMessageID  uint16
+	coapResponsesMutex sync.Mutex
+)
+
+func StartCoapServer(ipport string) error {
+	conn, err := net.ListenPacket("udp", ipport)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return serveCoap(conn)
+}
+
+func serveCoap(conn net.PacketConn) error {
+	buffer := make([]byte, COAP_MAX_MESSAGE_SIZE)
+	for {
+		n, addr, err := conn.ReadFrom(buffer)
+		if err != nil {
+			return err
+		}
+
+		if response := handleCoapDatagram(addr.String(), buffer[:n]); response != nil {
+			conn.WriteTo(response, addr)
+		}
+	}
+}
+
+//Returns the encoded response to the datagram or nil if it is not answered.
+func handleCoapDatagram(addr string, datagram []byte) []byte {
+	req, err := parseCoapMessage(datagram)
+	if req == nil {
+		//Not even the header could be read.
+		return nil
+	}
+
+	//Responses and resets are not expected, only requests are handled.
+	if req.msgType == COAP_ACK || req.msgType == COAP_RST {
+		return nil
+	}
+
+	//Malformed confirmable messages and pings (empty confirmable messages) are answered with a reset.
+	if err != nil || req.code == COAP_EMPTY {
+		if req.msgType == COAP_CON {
+			return (&coapMessage{msgType: COAP_RST, messageID: req.messageID}).encode()
+		}
+		return nil
+	}
+
+	exchange := coapExchange{addr, req.messageID}
+	coapResponsesMutex.Lock()
+	if cached, exists := coapResponses[exchange]; exists && req.msgType == COAP_CON {
+		coapResponsesMutex.Unlock()
+		return cached.encoded
+	}
+	coapResponsesMutex.Unlock()
+
+	code, payload := handleCoapRequest(req)
+	response := &coapMessage{msgType: COAP_ACK, code: code, messageID: req.messageID, token: req.token, payload: payload}
+	if req.msgType == COAP_NON {
+		response.msgType = COAP_NON
+	}
+
+	coapResponsesMutex.Lock()
+	defer coapResponsesMutex.Unlock()
+
+	if response.msgType == COAP_NON {
+		coapNextMessageID++
+		response.messageID = coapNextMessageID
+	}
+	encoded := response.encode()
+
+	if req.msgType == COAP_CON {
+		now := time.Now()
+		for cachedExchange, cached := range coapResponses {
+			if now.After(cached.expires) {
+				delete(coapResponses, cachedExchange)
+			}
+		}
+		coapResponses[exchange] = coapResponse{encoded, now.Add(COAP_EXCHANGE_LIFETIME)}
+	}
+
+	return encoded
+}
+
+//Returns the response code and payload to the request.
+func handleCoapRequest(req *coapMessage) (code uint8, payload []byte) {
+	if req.badOption {
+		return COAP_BAD_OPTION, []byte("Unsupported critical option.")
+	}
+
+	if strings.Join(req.uriPath, "/") != COAP_IOT_PATH {
+		return COAP_NOT_FOUND, []byte(fmt.Sprintf("Unknown resource, submit IoT txs to /%v.", COAP_IOT_PATH))
+	}
+
+	if req.code != COAP_POST {
+		return COAP_METHOD_NOT_ALLOWED, []byte("Only POST requests are supported.")
+	}
+
+	tx, err := protocol.DecodeCompactIotTx(req.payload)
+	if err != nil {
+		return COAP_BAD_REQUEST, []byte(err.Error())
+	}
+
+	if err := submitTx(tx, tx.Encode(), p2p.IOTTX_BRDCST); err != nil {
+		return COAP_FORBIDDEN, []byte(err.Error())
+	}
+
+	txHash := tx.Hash()
+	return COAP_CREATED, txHash[:]
+}
+
+//Returns nil if the header can not be read, the message read so far and an error if the rest of the message is invalid.
+func parseCoapMessage(datagram []byte) (*coapMessage, error) {
+	if len(datagram) < 4 || datagram[0]>>6 != COAP_VERSION {
+		return nil, errors.New("Invalid CoAP header.")
+	}
+
+	msg := &coapMessage{
+		msgType:   (datagram[0] >> 4) & 0x03,
+		code:      datagram[1],
+		messageID: binary.BigEndian.Uint16(datagram[2:4]),
+	}
+
+	tokenLength := int(datagram[0] & 0x0f)
+	if tokenLength > COAP_MAX_TOKEN_SIZE || len(datagram) < 4+tokenLength {
+		return msg, errors.New("Invalid CoAP token.")
+	}
+	msg.token = datagram[4 : 4+tokenLength]
+
+	if msg.code == COAP_EMPTY && len(datagram) > 4 {
+		return msg, errors.New("Empty CoAP message with content.")
+	}
+
+	rest := datagram[4+tokenLength:]
+	var option uint16
+	for len(rest) > 0 {
+		if rest[0] == COAP_PAYLOAD_MARKER {
+			if len(rest) == 1 {
+				return msg, errors.New("CoAP payload marker without payload.")
+			}
+			msg.payload = rest[1:]
+			break
+		}
+
+		delta, length := uint16(rest[0]>>4), uint16(rest[0]&0x0f)
+		rest = rest[1:]
+
+		var err error
+		if delta, rest, err = coapOptionValue(delta, rest); err != nil {
+			return msg, err
+		}
+		if length, rest, err = coapOptionValue(length, rest); err != nil {
+			return msg, err
+		}
+		if uint32(option)+uint32(delta) > 0xffff || len(rest) < int(length) {
+			return msg, errors.New("Invalid CoAP option.")
+		}
+		option += delta
+		value := rest[:length]
+		rest = rest[length:]
+
+		switch {
+		case option == COAP_OPTION_URI_PATH:
+			msg.uriPath = append(msg.uriPath, string(value))
+		case option == COAP_OPTION_URI_HOST || option == COAP_OPTION_URI_PORT:
+			//The node serves a single endpoint.
+		case option%2 == 1:
+			//Odd options are critical, they must not be ignored.
+			msg.badOption = true
+		}
+	}
+
+	return msg, nil
+}
+
+//Reads the extended option delta or length, 13 and 14 are followed by one or two more bytes.
+func coapOptionValue(value uint16, rest []byte) (uint16, []byte, error) {
+	switch value {
+	case 13:
+		if len(rest) < 1 {
+			return 0, nil, errors.New("Invalid CoAP option.")
+		}
+		return uint16(rest[0]) + 13, rest[1:], nil
+	case 14:
+		if len(rest) < 2 || binary.BigEndian.Uint16(rest) > 0xffff-269 {
+			return 0, nil, errors.New("Invalid CoAP option.")
+		}
+		return binary.BigEndian.Uint16(rest) + 269, rest[2:], nil
+	case 15:
+		return 0, nil, errors.New("Invalid CoAP option.")
+	}
+
+	return value, rest, nil
+}
+
+//Encodes the message without options, which is all that responses need.
+func (msg *coapMessage) encode() []byte {
+	encoded := []byte{COAP_VERSION<<6 | msg.msgType<<4 | uint8(len(msg.token)), msg.code, 0, 0}
+	binary.BigEndian.PutUint16(encoded[2:4], msg.messageID)
+	encoded = append(encoded, msg.token...)
+	if len(msg.payload) > 0 {
+		encoded = append(encoded, COAP_PAYLOAD_MARKER)
+		encoded = append(encoded, msg.payload...)
+	}
+
+	return encoded
+}
@@ -0,0 +1,161 @@
+package miner
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//Encodes a request with the given path, option numbers below 13 are written without extended deltas.
+func newCoapRequest(msgType uint8, code uint8, messageID uint16, path string, payload []byte) []byte {
+	msg := &coapMessage{msgType: msgType, code: code, messageID: messageID, token: []byte{0xab, 0xcd}}
+	encoded := msg.encode()
+
+	if len(path) > 0 {
+		encoded = append(encoded, COAP_OPTION_URI_PATH<<4|uint8(len(path)))
+		encoded = append(encoded, path...)
+	}
+	if len(payload) > 0 {
+		encoded = append(encoded, COAP_PAYLOAD_MARKER)
+		encoded = append(encoded, payload...)
+	}
+
+	return encoded
+}
+
+func TestParseCoapMessage(t *testing.T) {
+	//Uri-Host (3) with a 13 byte value, two Uri-Path (11) segments, Content-Format (12) and an option (2000) with
+	//an extended delta of two bytes.
+	datagram := []byte{0x51, COAP_POST, 0x12, 0x34, 0x07}
+	datagram = append(datagram, 0x3d, 0x00)
+	datagram = append(datagram, "bazo.local:56"...)
+	datagram = append(datagram, 0x82, 'i', 'o', 0x03, 'i', 'o', 't', 0x10)
+	datagram = append(datagram, 0xe0, 0x06, 0xb7)
+	datagram = append(datagram, COAP_PAYLOAD_MARKER, 'h', 'i')
+
+	msg, err := parseCoapMessage(datagram)
+	if err != nil {
+		t.Fatalf("Valid message rejected: %v\n", err)
+	}
+
+	if msg.msgType != COAP_NON || msg.code != COAP_POST || msg.messageID != 0x1234 || !bytes.Equal(msg.token, []byte{0x07}) {
+		t.Errorf("Header not parsed: %v\n", msg)
+	}
+	if len(msg.uriPath) != 2 || msg.uriPath[0] != "io" || msg.uriPath[1] != "iot" {
+		t.Errorf("Uri-Path not parsed: %v\n", msg.uriPath)
+	}
+	if msg.badOption || !bytes.Equal(msg.payload, []byte("hi")) {
+		t.Errorf("Elective options should be ignored: %v\n", msg)
+	}
+
+	//Option 2001 is critical.
+	datagram[len(datagram)-4] = 0xb8
+	if msg, err := parseCoapMessage(datagram); err != nil || !msg.badOption {
+		t.Errorf("Unsupported critical option not detected: %v\n", err)
+	}
+
+	invalid := [][]byte{
+		{0x40, COAP_POST, 0, 1, 0xff},
+		{0x40, COAP_POST, 0, 1, 0xb5, 'i'},
+		{0x49, COAP_POST, 0, 1},
+		{0x40, COAP_EMPTY, 0, 1, 0xff, 0},
+		{0x40, COAP_POST, 0, 1, 0xf0},
+	}
+	for _, datagram := range invalid {
+		if msg, err := parseCoapMessage(datagram); msg == nil || err == nil {
+			t.Errorf("Invalid message %x accepted\n", datagram)
+		}
+	}
+
+	if msg, _ := parseCoapMessage([]byte{0x80, COAP_POST, 0, 1}); msg != nil {
+		t.Error("Message of an unknown version parsed\n")
+	}
+}
+
+func TestHandleCoapDatagram(t *testing.T) {
+	tests := []struct {
+		request []byte
+		code    uint8
+	}{
+		{newCoapRequest(COAP_CON, COAP_POST, 1, "other", nil), COAP_NOT_FOUND},
+		{newCoapRequest(COAP_CON, 0x01, 2, COAP_IOT_PATH, nil), COAP_METHOD_NOT_ALLOWED},
+		{newCoapRequest(COAP_CON, COAP_POST, 3, COAP_IOT_PATH, []byte{1, 2, 3}), COAP_BAD_REQUEST},
+		//The accounts of the tx do not exist.
+		{newCoapRequest(COAP_CON, COAP_POST, 4, COAP_IOT_PATH, (&protocol.IotTx{From: [32]byte{0xc0}, To: [32]byte{0xa9}}).EncodeCompact()), COAP_FORBIDDEN},
+	}
+
+	for _, test := range tests {
+		response, err := parseCoapMessage(handleCoapDatagram("127.0.0.1:5683", test.request))
+		if err != nil {
+			t.Fatalf("Invalid response: %v\n", err)
+		}
+
+		request, _ := parseCoapMessage(test.request)
+		if response.msgType != COAP_ACK || response.messageID != request.messageID || !bytes.Equal(response.token, request.token) {
+			t.Errorf("Response does not acknowledge the request: %v\n", response)
+		}
+		if response.code != test.code {
+			t.Errorf("Response code %x, want %x: %s\n", response.code, test.code, response.payload)
+		}
+	}
+
+	//A ping is answered with a reset, responses are ignored.
+	if response, _ := parseCoapMessage(handleCoapDatagram("127.0.0.1:5683", []byte{0x40, COAP_EMPTY, 0, 5})); response == nil || response.msgType != COAP_RST {
+		t.Errorf("Ping not answered with a reset: %v\n", response)
+	}
+	if response := handleCoapDatagram("127.0.0.1:5683", []byte{0x60, COAP_EMPTY, 0, 6}); response != nil {
+		t.Errorf("Acknowledgement answered: %x\n", response)
+	}
+
+	//Non-confirmable requests get non-confirmable responses with their own message ID.
+	nonConfirmable := newCoapRequest(COAP_NON, COAP_POST, 7, "other", nil)
+	if response, _ := parseCoapMessage(handleCoapDatagram("127.0.0.1:5683", nonConfirmable)); response == nil || response.msgType != COAP_NON || response.code != COAP_NOT_FOUND {
+		t.Errorf("Non-confirmable request not answered: %v\n", response)
+	}
+}
+
+func TestCoapRetransmission(t *testing.T) {
+	request := newCoapRequest(COAP_CON, COAP_POST, 8, COAP_IOT_PATH, []byte{1})
+	response := handleCoapDatagram("127.0.0.1:5684", request)
+
+	//A retransmission is answered with the same response even if the request changed, e.g., by a forged datagram.
+	retransmission := newCoapRequest(COAP_CON, COAP_POST, 8, "other", nil)
+	if !bytes.Equal(handleCoapDatagram("127.0.0.1:5684", retransmission), response) {
+		t.Error("Retransmitted request not answered with the same response\n")
+	}
+
+	//The message ID is only unique per endpoint.
+	if bytes.Equal(handleCoapDatagram("127.0.0.1:5685", retransmission), response) {
+		t.Error("Request of another endpoint answered with a cached response\n")
+	}
+}
+
+func TestServeCoap(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go serveCoap(conn)
+	defer conn.Close()
+
+	client, err := net.Dial("udp", conn.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+
+	client.Write(newCoapRequest(COAP_CON, COAP_POST, 9, "other", nil))
+	client.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buffer := make([]byte, COAP_MAX_MESSAGE_SIZE)
+	n, err := client.Read(buffer)
+	if err != nil {
+		t.Fatalf("No response received: %v\n", err)
+	}
+
+	if response, err := parseCoapMessage(buffer[:n]); err != nil || response.code != COAP_NOT_FOUND {
+		t.Errorf("Unexpected response: %v, %v\n", response, err)
+	}
+}
@@ -0,0 +1,71 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"golang.org/x/crypto/ed25519"
+)
+
+//Validators commit with an RSA key or, since the migration to VRF proofs, with an ed25519 VRF key (see crypto/vrf.go).
+//Both are accepted until the Rsa_commitment_deadline set by a config tx: RSA keys can no longer be staked with in the
+//blocks above it, and blocks above it need a VRF proof. Validators switch over by unstaking and staking again with a
+//VRF key. VRF keys and proofs are told apart from RSA ones by the zeros they are padded with.
+
+//Returns an error if the key can not commit in the block at height: RSA keys above the Rsa_commitment_deadline and
+//invalid VRF keys (see crypto.ValidVRFKey).
+func checkCommitmentKey(key [crypto.COMM_KEY_LENGTH]byte, height uint32) error {
+	if crypto.IsVRFCommitmentKey(key) {
+		if !crypto.ValidVRFKey(ed25519.PublicKey(key[:crypto.VRF_KEY_LENGTH])) {
+			return errors.New("Invalid VRF commitment key.")
+		}
+
+		return nil
+	}
+
+	deadline := parametersAt(height).Rsa_commitment_deadline
+	if deadline > 0 && uint64(height) > deadline {
+		return errors.New(fmt.Sprintf("RSA commitment keys are not accepted above height %v.", deadline))
+	}
+
+	return nil
+}
+
+//The part of a commitment proof entering the proof of stake. For VRF proofs this is the VRF output, the proof itself
+//is not unique (see crypto/vrf.go). RSA signatures are unique, they are used as they are.
+func commitmentOutput(proof [crypto.COMM_PROOF_LENGTH]byte) (output [crypto.COMM_PROOF_LENGTH]byte) {
+	if !crypto.IsVRFCommitmentProof(proof) {
+		return proof
+	}
+
+	var vrfProof [crypto.VRF_PROOF_LENGTH]byte
+	copy(vrfProof[:], proof[:])
+	vrfOutput := crypto.VRFProofToHash(vrfProof)
+	copy(output[:], vrfOutput[:])
+
+	return output
+}
+
+//Verifies the commitment proof of the block at height against the commitment key of its proposer.
+func verifyCommitmentProof(key [crypto.COMM_KEY_LENGTH]byte, height uint32, proof [crypto.COMM_PROOF_LENGTH]byte) error {
+	if crypto.IsVRFCommitmentKey(key) {
+		var vrfProof [crypto.VRF_PROOF_LENGTH]byte
+		copy(vrfProof[:], proof[:])
+		if !crypto.IsVRFCommitmentProof(proof) || !crypto.VRFVerify(ed25519.PublicKey(key[:crypto.VRF_KEY_LENGTH]), []byte(fmt.Sprint(height)), vrfProof) {
+			return errors.New("The submitted commitment proof can not be verified.")
+		}
+
+		return nil
+	}
+
+	commitmentPubKey, err := crypto.CreateRSAPubKeyFromBytes(key)
+	if err != nil {
+		return errors.New("Invalid commitment key in account.")
+	}
+
+	if err = crypto.VerifyMessageWithRSAKey(commitmentPubKey, fmt.Sprint(height), proof); err != nil {
+		return errors.New("The submitted commitment proof can not be verified.")
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package miner
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestVerifyCommitmentProof(t *testing.T) {
+	_, vrfPrivKey, _ := ed25519.GenerateKey(rand.Reader)
+	vrfSigner := crypto.NewVRFSigner(vrfPrivKey)
+	rsaPrivKey, _ := crypto.CreateRSAPrivKeyFromBase64(CommPubA, CommPrivA, []string{CommPrim1A, CommPrim2A})
+	rsaSigner := crypto.NewLocalSigner(rsaPrivKey)
+
+	for _, signer := range []crypto.CommitmentSigner{vrfSigner, rsaSigner} {
+		proof, _ := signer.Sign("10")
+		if err := verifyCommitmentProof(signer.CommitmentKey(), 10, proof); err != nil {
+			t.Errorf("Valid commitment proof rejected: %v\n", err)
+		}
+		if err := verifyCommitmentProof(signer.CommitmentKey(), 11, proof); err == nil {
+			t.Error("Commitment proof of another height accepted.\n")
+		}
+	}
+
+	//An RSA proof does not verify against a VRF key and vice versa.
+	rsaProof, _ := rsaSigner.Sign("10")
+	vrfProof, _ := vrfSigner.Sign("10")
+	if verifyCommitmentProof(vrfSigner.CommitmentKey(), 10, rsaProof) == nil || verifyCommitmentProof(rsaSigner.CommitmentKey(), 10, vrfProof) == nil {
+		t.Error("Commitment proof of the other kind accepted.\n")
+	}
+}
+
+func TestCommitmentDeadline(t *testing.T) {
+	prevParameterSlice := parameterSlice
+	defer func() { parameterSlice = prevParameterSlice }()
+
+	_, vrfPrivKey, _ := ed25519.GenerateKey(rand.Reader)
+	vrfKey := crypto.NewVRFSigner(vrfPrivKey).CommitmentKey()
+	rsaPrivKey, _ := crypto.CreateRSAPrivKeyFromBase64(CommPubA, CommPrivA, []string{CommPrim1A, CommPrim2A})
+	rsaKey := crypto.NewLocalSigner(rsaPrivKey).CommitmentKey()
+
+	//Without a deadline both kinds are accepted.
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	if checkCommitmentKey(rsaKey, 1000) != nil || checkCommitmentKey(vrfKey, 1000) != nil {
+		t.Error("Commitment key rejected without a deadline.\n")
+	}
+
+	parameterSlice[0].Rsa_commitment_deadline = 100
+	if err := checkCommitmentKey(rsaKey, 100); err != nil {
+		t.Errorf("RSA commitment key rejected at the deadline: %v\n", err)
+	}
+	if checkCommitmentKey(rsaKey, 101) == nil {
+		t.Error("RSA commitment key accepted above the deadline.\n")
+	}
+	if err := checkCommitmentKey(vrfKey, 101); err != nil {
+		t.Errorf("VRF commitment key rejected above the deadline: %v\n", err)
+	}
+
+	//The neutral element, a key of small order.
+	var smallOrderKey [crypto.COMM_KEY_LENGTH]byte
+	smallOrderKey[0] = 0x01
+	if checkCommitmentKey(smallOrderKey, 10) == nil {
+		t.Error("Small order VRF commitment key accepted.\n")
+	}
+}
+
+func TestCommitmentOutput(t *testing.T) {
+	_, vrfPrivKey, _ := ed25519.GenerateKey(rand.Reader)
+	vrfProof, _ := crypto.NewVRFSigner(vrfPrivKey).Sign("10")
+	rsaPrivKey, _ := crypto.CreateRSAPrivKeyFromBase64(CommPubA, CommPrivA, []string{CommPrim1A, CommPrim2A})
+	rsaProof, _ := crypto.NewLocalSigner(rsaPrivKey).Sign("10")
+
+	var proof [crypto.VRF_PROOF_LENGTH]byte
+	copy(proof[:], vrfProof[:])
+	hash := crypto.VRFProofToHash(proof)
+	output := commitmentOutput(vrfProof)
+	if !bytes.Equal(output[:64], hash[:]) || !bytes.Equal(output[64:], make([]byte, crypto.COMM_PROOF_LENGTH-64)) {
+		t.Error("VRF proof does not enter the proof of stake with its output.\n")
+	}
+
+	if commitmentOutput(rsaProof) != rsaProof {
+		t.Error("RSA proof changed.\n")
+	}
+}
@@ -24,6 +24,35 @@ const (
 	ACCEPTED_TIME_DIFF   	= 60      //Sec
 	SLASHING_WINDOW_SIZE 	= 100     //Blocks
 	SLASH_REWARD         	= 2       //Coins
+	BLOCK_TRIGGER_TXS    	= 0       //Txs, blocks are produced without waiting for txs
+	BLOCK_TRIGGER_TIME   	= 0       //Sec
+	IOT_RATE_CAPACITY    	= 0       //Txs, IoT txs are not rate limited
+	IOT_RATE_REFILL      	= 1       //Blocks
+	UNBONDING_PERIOD     	= 100     //Blocks
+	RSA_COMMITMENT_DEADLINE	= 0       //Height, RSA commitment proofs are accepted until a deadline is set
+	SLASH_FRACTION       	= 0       //Percent, slashed validators lose the staking minimum
+	CONFIG_QUORUM        	= 1       //Root keys, a single root key can change the parameters
+	CONFIG_ACTIVATION_DELAY	= 0       //Blocks, parameter changes apply to the next block
+	DIFF_EMA_WINDOW      	= 0       //Blocks, the difficulty is retargeted every DIFF_INTERVAL blocks
+	STATE_ROOT_HEIGHT    	= 4294967295 //Height, blocks without state root are accepted unless the genesis sets a height
+	TX_ORDER_HEIGHT      	= 4294967295 //Height, the tx order is not checked unless the genesis sets a height
+	AGG_TX_AUTH_HEIGHT   	= 4294967295 //Height, aggTxs are not authenticated unless the genesis sets a height
+	MEDIAN_TIME_HEIGHT   	= 4294967295 //Height, the median time past is not checked unless the genesis sets a height
+	BLOCK_HASH_HEIGHT    	= 4294967295 //Height, block hashes do not cover the timestamp unless the genesis sets a height
+	DELEGATION_HEIGHT    	= 4294967295 //Height, stake can not be delegated unless the genesis sets a height
 	NUM_INCL_PREV_PROOFS 	= 5       //Number of previous proofs included in the PoS condition
 	NO_AGGREGATION_LENGTH	= 3		  //Number of blocks after the newest block which are not aggregated.
+	AGGREGATION_MIN_TXS		= 2		  //Number of fundsTxs of the same sender or receiver needed for an aggregation.
 )
+
+//Aggregation only affects which txs a miner puts into its blocks and what it keeps in its database, so it can be
+//configured per node.
+var (
+	aggregationMinTxs   = AGGREGATION_MIN_TXS
+	noAggregationLength = NO_AGGREGATION_LENGTH
+)
+
+func SetAggregation(minTxs uint32, keptBlocks uint32) {
+	aggregationMinTxs = int(minTxs)
+	noAggregationLength = int(keptBlocks)
+}
@@ -0,0 +1,106 @@
+package miner
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestConfigTxQuorum(t *testing.T) {
+	prevParameterSlice, prevRootKeys := parameterSlice, storage.RootKeys
+	defer func() {
+		parameterSlice, storage.RootKeys = prevParameterSlice, prevRootKeys
+		activeParameters = &parameterSlice[len(parameterSlice)-1]
+	}()
+
+	storage.RootKeys = make(map[[32]byte]*protocol.Account)
+	var rootKeys []ed25519.PrivateKey
+	for i := 0; i < 3; i++ {
+		pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+		acc := &protocol.Account{}
+		copy(acc.Address[:], pubKey)
+		storage.RootKeys[acc.Hash()] = acc
+		rootKeys = append(rootKeys, privKey)
+	}
+	_, otherKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	activeParameters = &parameterSlice[0]
+	tx, _ := protocol.ConstrConfigTx(0, protocol.FEE_MINIMUM_ID, 5, 1, 0, rootKeys[0])
+	if !verifyConfigTx(tx) {
+		t.Error("ConfigTx signed by a root key rejected without a quorum.\n")
+	}
+	if notRoot, _ := protocol.ConstrConfigTx(0, protocol.FEE_MINIMUM_ID, 5, 1, 0, otherKey); verifyConfigTx(notRoot) {
+		t.Error("ConfigTx not signed by a root key accepted.\n")
+	}
+
+	activeParameters.Config_quorum = 2
+	if verifyConfigTx(tx) {
+		t.Error("ConfigTx signed by a single root key accepted with a quorum of 2.\n")
+	}
+
+	//Cosignatures of the signer itself and of other keys do not count.
+	tx.Cosign(rootKeys[0])
+	tx.Cosign(otherKey)
+	if verifyConfigTx(tx) {
+		t.Error("ConfigTx accepted without a second root key.\n")
+	}
+
+	tx.Cosign(rootKeys[1])
+	if !verifyConfigTx(tx) {
+		t.Error("ConfigTx signed by 2 root keys rejected with a quorum of 2.\n")
+	}
+
+	activeParameters.Config_quorum = 3
+	if verifyConfigTx(tx) {
+		t.Error("ConfigTx signed by 2 root keys accepted with a quorum of 3.\n")
+	}
+}
+
+func TestConfigActivationDelay(t *testing.T) {
+	prevParameterSlice := parameterSlice
+	defer func() {
+		parameterSlice = prevParameterSlice
+		activeParameters = &parameterSlice[len(parameterSlice)-1]
+	}()
+
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	parameterSlice[0].Config_activation_delay = 10
+	activeParameters = &parameterSlice[0]
+
+	_, rootKey, _ := ed25519.GenerateKey(rand.Reader)
+	tx, _ := protocol.ConstrConfigTx(0, protocol.FEE_MINIMUM_ID, 5, 1, 0, rootKey)
+	configStateChange([]*protocol.ConfigTx{tx}, [32]byte{'a'}, 100)
+	if len(parameterSlice) != 2 || parameterSlice[1].Height != 110 {
+		t.Fatalf("Config change not recorded with the activation delay: %v\n", parameterSlice)
+	}
+	if activeParameters.Fee_minimum != FEE_MINIMUM {
+		t.Error("Config change active before the activation height.\n")
+	}
+
+	//A later change builds on the pending one.
+	tx2, _ := protocol.ConstrConfigTx(0, protocol.BLOCK_REWARD_ID, 7, 1, 0, rootKey)
+	configStateChange([]*protocol.ConfigTx{tx2}, [32]byte{'b'}, 101)
+	if len(parameterSlice) != 3 || parameterSlice[2].Height != 111 || parameterSlice[2].Fee_minimum != 5 {
+		t.Errorf("Second config change not based on the pending one: %v\n", parameterSlice[2])
+	}
+
+	configStateChange(nil, [32]byte{'c'}, 110)
+	if activeParameters.Fee_minimum != 5 || activeParameters.Block_reward != BLOCK_REWARD {
+		t.Errorf("Config change not active at the activation height: %v\n", *activeParameters)
+	}
+
+	configStateChangeRollback(nil, [32]byte{'c'}, 110)
+	if activeParameters.Fee_minimum != FEE_MINIMUM {
+		t.Error("Config change still active after the rollback below the activation height.\n")
+	}
+
+	configStateChangeRollback([]*protocol.ConfigTx{tx2}, [32]byte{'b'}, 101)
+	configStateChangeRollback([]*protocol.ConfigTx{tx}, [32]byte{'a'}, 100)
+	if len(parameterSlice) != 1 || activeParameters != &parameterSlice[0] {
+		t.Errorf("Config changes not rolled back: %v\n", parameterSlice)
+	}
+}
@@ -16,7 +16,7 @@ import (
 func TestMultipleBlocksWithContractTx(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	contract := []byte{
 		35,         // CALLDATA
 		0, 1, 0, 5, // PUSH 5
@@ -29,7 +29,7 @@ func TestMultipleBlocksWithContractTx(t *testing.T) {
 		t.Errorf("Block validation for (%v) failed: %v\n", b, err)
 	}
 
-	b2 := newBlock(b.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b2 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	transactionData := []byte{
 		1, 0, 15,
 	}
@@ -45,7 +45,7 @@ func TestMultipleBlocksWithContractTx(t *testing.T) {
 func TestMultipleBlocksWithStateChangeContractTx(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	contract := []byte{
 		35,    // CALLDATA
 		29, 0, // SLOAD
@@ -59,7 +59,7 @@ func TestMultipleBlocksWithStateChangeContractTx(t *testing.T) {
 		t.Errorf("Block validation for (%v) failed: %v\n", b, err)
 	}
 
-	b2 := newBlock(b.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b2 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	transactionData := []byte{
 		1, 0, 15,
 	}
@@ -77,12 +77,55 @@ func TestMultipleBlocksWithStateChangeContractTx(t *testing.T) {
 	}
 }
 
+// The contract call of a rolled back block is reverted, the contract variables and the balance of the contract are the
+// ones before the call.
+func TestRollbackStateChangeContractTx(t *testing.T) {
+	cleanAndPrepare()
+
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	contract := []byte{
+		35,    // CALLDATA
+		29, 0, // SLOAD
+		4,     // ADD
+		27, 0, // SSTORE
+		50, // HALT
+	}
+	createBlockWithSingleContractDeployTx(b, contract, []protocol.ByteArray{[]byte{0, 2}})
+	finalizeBlock(b)
+	if err := validate(b, false); err != nil {
+		t.Fatalf("Block validation for (%v) failed: %v\n", b, err)
+	}
+
+	b2 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	hash := createBlockWithSingleContractCallTx(b2, []byte{1, 0, 15})
+	finalizeBlock(b2)
+	balanceBefore := storage.State.Get(hash).Balance
+	if err := validate(b2, false); err != nil {
+		t.Fatalf("Block validation failed: %v\n", err)
+	}
+	if variables := storage.State.Get(hash).ContractVariables; !reflect.DeepEqual(variables, []protocol.ByteArray{[]byte{0, 17}}) {
+		t.Fatalf("State change not persisted: %v\n", variables)
+	}
+
+	if err := rollback(b2); err != nil {
+		t.Fatalf("Block rollback failed: %v\n", err)
+	}
+
+	acc := storage.State.Get(hash)
+	if !reflect.DeepEqual(acc.ContractVariables, []protocol.ByteArray{[]byte{0, 2}}) || acc.Balance != balanceBefore {
+		t.Errorf("Contract call not reverted: %v\n", acc)
+	}
+	if storage.ReadContractStates(b2.FundsTxData[0]) != nil {
+		t.Error("Contract state of the rolled back block not deleted.")
+	}
+}
+
 // This test is similar to the TestMultipleBlocksWithStateChangeContractTx. The difference is, that after the first state change
 // transaction, a second one is called, which changes the state again.
 func TestMultipleBlocksWithDoubleStateChangeContractTx(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	contract := []byte{
 		35,    // CALLDATA
 		29, 0, // SLOAD
@@ -96,7 +139,7 @@ func TestMultipleBlocksWithDoubleStateChangeContractTx(t *testing.T) {
 		t.Errorf("Block validation for (%v) failed: %v\n", b, err)
 	}
 
-	b2 := newBlock(b.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b2 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	transactionData := []byte{
 		1, 0, 15,
 	}
@@ -106,7 +149,7 @@ func TestMultipleBlocksWithDoubleStateChangeContractTx(t *testing.T) {
 		t.Errorf("Block validation failed: %v\n", err)
 	}
 
-	b3 := newBlock(b2.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 3)
+	b3 := newBlock(b2.Hash, b2.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 3)
 	transactionData = []byte{
 		1, 0, 15,
 	}
@@ -127,7 +170,7 @@ func TestMultipleBlocksWithDoubleStateChangeContractTx(t *testing.T) {
 func TestMultipleBlocksWithContextContractTx(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	contract := []byte{
 		35, 0, 0, 1, 10, 22, 0, 10, 1, 50, 28, 0, 31, 33, 10, 22, 0, 21, 2, 24, 28, 0, 29, 0, 0, 4, 27, 0, 0, 24,
 	}
@@ -137,7 +180,7 @@ func TestMultipleBlocksWithContextContractTx(t *testing.T) {
 		t.Errorf("Block validation for (%v) failed: %v\n", b, err)
 	}
 
-	b1 := newBlock(b.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b1 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	transactionData := []byte{
 		0, 100, // Amount
 		0, 1,
@@ -153,7 +196,7 @@ func TestMultipleBlocksWithContextContractTx(t *testing.T) {
 func TestMultipleBlocksWithTokenizationContractTx(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	contract := []byte{
 		35, 1, 0, 0, 1, 10, 22, 0, 11, 3, 50, 28, 1, 28, 0, 29, 1, 33, 10, 22, 0, 24, 2, 24, 28, 1, 28, 0, 1, 29, 2, 37, 22, 0, 46, 2, 28, 1, 28, 0, 29, 2, 38, 27, 2, 50, 28, 1, 29, 2, 39, 28, 0, 4, 28, 1, 29, 2, 40, 27, 2, 50,
 	}
@@ -176,7 +219,7 @@ func TestMultipleBlocksWithTokenizationContractTx(t *testing.T) {
 		t.Errorf("Block validation for (%v) failed: %v\n", b, err)
 	}
 
-	b1 := newBlock(b.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b1 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	transactionData := []byte{
 		1, 0, 100, // Amount
 		1, receiver[0], receiver[1], // receiver address
@@ -191,12 +234,12 @@ func TestMultipleBlocksWithTokenizationContractTx(t *testing.T) {
 	acc, _ := storage.GetAccount(hash)
 	m, err := vm.MapFromByteArray(acc.ContractVariables[2])
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Error(err)
 	}
 
 	tmp, err := m.GetVal(receiver)
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Error(err)
 	}
 
 	actual := uint64(tmp[1])
@@ -209,7 +252,7 @@ func TestMultipleBlocksWithTokenizationContractTx(t *testing.T) {
 func TestMultipleBlocksWithTokenizationContractTxWhichAddsKey(t *testing.T) {
 	cleanAndPrepare()
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	contract := []byte{
 		35, 1, 0, 0, 1, 10, 22, 0, 11, 3, 50, 28, 1, 28, 0, 29, 1, 33, 10, 22, 0, 24, 2, 24, 28, 1, 28, 0, 1, 29, 2, 37, 22, 0, 46, 2, 28, 1, 28, 0, 29, 2, 38, 27, 2, 50, 28, 1, 29, 2, 39, 28, 0, 4, 28, 1, 29, 2, 40, 27, 2, 50,
 	}
@@ -232,7 +275,7 @@ func TestMultipleBlocksWithTokenizationContractTxWhichAddsKey(t *testing.T) {
 		t.Errorf("Block validation for (%v) failed: %v\n", b, err)
 	}
 
-	b1 := newBlock(b.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b1 := newBlock(b.Hash, b.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	transactionData := []byte{
 		1, 0, 100, // Amount
 		1, receiver[0], receiver[1], // receiver address
@@ -247,12 +290,12 @@ func TestMultipleBlocksWithTokenizationContractTxWhichAddsKey(t *testing.T) {
 	acc, _ := storage.GetAccount(hash)
 	m, err := vm.MapFromByteArray(acc.ContractVariables[2])
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Error(err)
 	}
 
 	tmp, err := m.GetVal(receiver)
 	if err != nil {
-		t.Errorf(err.Error())
+		t.Error(err)
 	}
 
 	actual := uint64(tmp[1])
@@ -263,7 +306,7 @@ func TestMultipleBlocksWithTokenizationContractTxWhichAddsKey(t *testing.T) {
 }
 
 func createBlockWithSingleContractDeployTx(b *protocol.Block, contract []byte, contractVariables []protocol.ByteArray) [32]byte {
-	tx, _, _ := protocol.ConstrAccTx(0, 1000000, [64]byte{}, PrivKeyRoot, contract, contractVariables)
+	tx, _, _ := protocol.ConstrAccTx(0, 1000000, 0, [32]byte{}, PrivKeyRoot, contract, contractVariables)
 	if err := addTx(b, tx); err == nil {
 		storage.WriteOpenTx(tx)
 		return tx.Issuer
@@ -274,13 +317,13 @@ func createBlockWithSingleContractDeployTx(b *protocol.Block, contract []byte, c
 }
 
 func createBlockWithSingleContractCallTx(b *protocol.Block, transactionData []byte) [32]byte {
-	for hash := range storage.State {
+	for hash := range storage.State.Snapshot() {
 		acc, _ := storage.GetAccount(hash)
 		if acc.Contract != nil {
 			accAHash := protocol.SerializeHashContent(accA.Address)
 			accBHash := acc.Hash()
 
-			tx, _ := protocol.ConstrFundsTx(0x01, rand.Uint64()%100+1, 100000, uint32(accA.TxCnt), accAHash, accBHash, PrivKeyAccA, PrivKeyMultiSig, transactionData)
+			tx, _ := protocol.ConstrContractCallTx(0x01, rand.Uint64()%100+1, 100000, 100000, 1, uint32(accA.TxCnt), accAHash, accBHash, protocol.NewKeySigner(PrivKeyAccA), transactionData)
 			if err := addTx(b, tx); err == nil {
 				storage.WriteOpenTx(tx)
 			} else {
@@ -296,7 +339,7 @@ func createBlockWithSingleContractCallTxDefined(b *protocol.Block, transactionDa
 	accA, _ := storage.GetAccount(from)
 	accB, _ := storage.GetAccount(to)
 
-	tx, _ := protocol.ConstrFundsTx(0x01, rand.Uint64()%100+1, rand.Uint64()%100+1, uint32(accA.TxCnt), accA.Hash(), accB.Hash(), PrivKeyAccA, PrivKeyMultiSig, transactionData)
+	tx, _ := protocol.ConstrContractCallTx(0x01, rand.Uint64()%100+1, rand.Uint64()%100+1, 100000, 1, uint32(accA.TxCnt), accA.Hash(), accB.Hash(), protocol.NewKeySigner(PrivKeyAccA), transactionData)
 	if err := addTx(b, tx); err == nil {
 		storage.WriteOpenTx(tx)
 	} else {
@@ -306,7 +349,7 @@ func createBlockWithSingleContractCallTxDefined(b *protocol.Block, transactionDa
 
 func getAccountsWithContracts() []protocol.Account {
 	var accounts []protocol.Account
-	for hash := range storage.State {
+	for hash := range storage.State.Snapshot() {
 		acc, _ := storage.GetAccount(hash)
 		if acc.Contract != nil {
 			accounts = append(accounts, *acc)
@@ -0,0 +1,68 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestContractDeployment(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(nil)
+	var address [32]byte
+	copy(address[:], pubKey)
+	deployer := &protocol.Account{Address: address, Balance: 5000}
+	from := deployer.Hash()
+	storage.State.Set(from, deployer)
+	defer storage.State.Delete(from)
+
+	minerAcc := &protocol.Account{Address: [32]byte{'d', 'e', 'p', 'l', 'o', 'y'}}
+	minerHash := minerAcc.Hash()
+	storage.State.Set(minerHash, minerAcc)
+	defer storage.State.Delete(minerHash)
+
+	contract := []byte{0x01, 0x02, 0x03}
+	variables := []protocol.ByteArray{{0x04}}
+	tx, _ := protocol.ConstrContractTx(0, 1, 2000, 0, from, contract, variables, privKey)
+	gas := tx.DeploymentGas()
+	if !verifyContractTx(tx) {
+		t.Fatal("Valid contract tx was rejected.")
+	}
+
+	lowGasLimit, _ := protocol.ConstrContractTx(0, 1, gas-1, 0, from, contract, variables, privKey)
+	if verifyContractTx(lowGasLimit) {
+		t.Error("Contract tx exceeding its gas limit was verified.")
+	}
+
+	block := &protocol.Block{StateCopy: make(map[[32]byte]*protocol.Account)}
+	if err := addContractTx(block, tx); err != nil || len(block.ContractTxData) != 1 || block.StateCopy[from].Balance != 5000-1-gas {
+		t.Errorf("Contract tx not added to the block: %v\n", err)
+	}
+
+	if err := contractStateChange([]*protocol.ContractTx{tx}, minerHash); err != nil {
+		t.Fatalf("Contract deployment rejected: %v\n", err)
+	}
+
+	contractHash := protocol.SerializeHashContent(protocol.ContractAddress(from, 0))
+	contractAcc := storage.State.Get(contractHash)
+	defer storage.State.Delete(contractHash)
+	if contractAcc == nil || string(contractAcc.Contract) != string(contract) || len(contractAcc.ContractVariables) != 1 || contractAcc.Issuer != from {
+		t.Fatalf("Contract account not created: %v\n", contractAcc)
+	}
+
+	//The fee goes to the miner, the deployment gas is burned.
+	if deployer.Balance != 5000-1-gas || deployer.TxCnt != 1 || minerAcc.Balance != 1 {
+		t.Errorf("Deployment not charged: %v, %v\n", deployer, minerAcc)
+	}
+
+	contractStateChangeRollback([]*protocol.ContractTx{tx}, minerHash)
+	if storage.State.Get(contractHash) != nil || deployer.Balance != 5000 || deployer.TxCnt != 0 || minerAcc.Balance != 0 {
+		t.Error("Contract deployment not rolled back.")
+	}
+
+	deployer.Balance = gas
+	if err := contractStateChange([]*protocol.ContractTx{tx}, minerHash); err == nil {
+		t.Error("Contract deployed without enough funds for the fee and the gas.")
+	}
+}
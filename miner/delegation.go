@@ -0,0 +1,246 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Accounts delegate stake to a validator with a funds tx sent to the validator with the FUNDSTX_DELEGATE header bit:
+//the amount is moved from the sender's balance to its delegation, which is kept by the validator's account. A tx with
+//the FUNDSTX_UNDELEGATE bit moves (part of) it back. Delegated stake counts for the validator set (see
+//computeValidatorSet). The block rewards and fees a validator collects are shared with its delegators pro rata: the
+//validator's own stake counts as the staking minimum, every delegator gets its part rounded down and the validator
+//keeps the rest. Up to the Delegation_height, the header bits are ignored and the txs are plain transfers, as by the
+//earlier versions.
+
+//Returns the delegation header bits of a tx in the block at height, 0 for a plain transfer.
+func delegationKind(tx *protocol.FundsTx, height uint32) byte {
+	if uint64(height) <= parametersAt(height).Delegation_height {
+		return 0
+	}
+
+	return tx.Header & (protocol.FUNDSTX_DELEGATE | protocol.FUNDSTX_UNDELEGATE)
+}
+
+//Checks whether the sender can delegate the amount to the receiver or take it back, the fee is paid from the balance.
+func checkDelegation(tx *protocol.FundsTx, kind byte, sender, receiver *protocol.Account, height uint32) error {
+	if kind == protocol.FUNDSTX_DELEGATE|protocol.FUNDSTX_UNDELEGATE {
+		return errors.New("Tx can not delegate and undelegate at once.")
+	}
+	if storage.IsRootKey(tx.From) {
+		return errors.New("Root accounts can not delegate stake.")
+	}
+	if tx.From == tx.To || tx.Amount == 0 || tx.Data != nil || tx.HasGas() {
+		return errors.New("Delegation txs need an amount and another receiver, without data and gas.")
+	}
+
+	if kind == protocol.FUNDSTX_DELEGATE {
+		if !receiver.IsStaking {
+			return errors.New(fmt.Sprintf("Stake can only be delegated to validators, %x is not staking.", tx.To[0:8]))
+		}
+		if locked := lockedStake(sender, height); tx.Amount+tx.Fee+locked > sender.Balance {
+			return errors.New(fmt.Sprintf("Sender does not have enough funds to delegate: Balance = %v, Amount = %v, Fee = %v, Locked = %v.", sender.Balance, tx.Amount, tx.Fee, locked))
+		}
+		if receiver.DelegatedStake()+tx.Amount > MAX_MONEY {
+			return errors.New("Delegation would lead to an overflow of the validator's delegated stake.")
+		}
+
+		return nil
+	}
+
+	delegation := receiver.GetDelegation(tx.From)
+	if delegation == nil || delegation.Amount < tx.Amount {
+		return errors.New(fmt.Sprintf("Sender did not delegate %v to %x.", tx.Amount, tx.To[0:8]))
+	}
+	if tx.Fee+lockedStake(sender, height) > sender.Balance {
+		return errors.New(fmt.Sprintf("Sender does not have enough funds for the fee: Balance = %v, Fee = %v.", sender.Balance, tx.Fee))
+	}
+	if sender.Balance+tx.Amount > MAX_MONEY {
+		return errors.New("Undelegation would lead to balance overflow at the sender account.")
+	}
+
+	return nil
+}
+
+//Applies a delegation tx checked with checkDelegation, the fee is collected with the others (see collectTxFees).
+func applyDelegation(tx *protocol.FundsTx, kind byte, sender, receiver *protocol.Account) {
+	sender.TxCnt += 1
+	if kind == protocol.FUNDSTX_DELEGATE {
+		sender.Balance -= tx.Amount
+		receiver.Delegations = changeDelegation(receiver.Delegations, tx.From, func(delegation *protocol.Delegation) {
+			delegation.Amount += tx.Amount
+		})
+	} else {
+		sender.Balance += tx.Amount
+		receiver.Delegations = changeDelegation(receiver.Delegations, tx.From, func(delegation *protocol.Delegation) {
+			delegation.Amount -= tx.Amount
+		})
+	}
+}
+
+func applyDelegationRollback(tx *protocol.FundsTx, kind byte, sender, receiver *protocol.Account) {
+	sender.TxCnt -= 1
+	if kind == protocol.FUNDSTX_DELEGATE {
+		sender.Balance += tx.Amount
+		receiver.Delegations = changeDelegation(receiver.Delegations, tx.From, func(delegation *protocol.Delegation) {
+			delegation.Amount -= tx.Amount
+		})
+	} else {
+		sender.Balance -= tx.Amount
+		receiver.Delegations = changeDelegation(receiver.Delegations, tx.From, func(delegation *protocol.Delegation) {
+			delegation.Amount += tx.Amount
+		})
+	}
+}
+
+//Returns a copy of the delegations with the delegator's one changed, the slice may be shared with a copy of the
+//account (e.g., the state copy of a block or the journal of the state). The delegations are sorted by delegator, so a
+//rollback restores the same encoding. Delegations without amount and rewards are dropped.
+func changeDelegation(delegations []protocol.Delegation, delegator [32]byte, change func(*protocol.Delegation)) []protocol.Delegation {
+	changed := make([]protocol.Delegation, 0, len(delegations)+1)
+	found := false
+	for _, delegation := range delegations {
+		if delegation.Delegator == delegator {
+			change(&delegation)
+			found = true
+		}
+		if delegation.Amount > 0 || delegation.Rewards > 0 {
+			changed = append(changed, delegation)
+		}
+	}
+
+	if !found {
+		delegation := protocol.Delegation{Delegator: delegator}
+		change(&delegation)
+		if delegation.Amount > 0 || delegation.Rewards > 0 {
+			changed = append(changed, delegation)
+			sort.Slice(changed, func(i, j int) bool {
+				return bytes.Compare(changed[i].Delegator[:], changed[j].Delegator[:]) < 0
+			})
+		}
+	}
+
+	if len(changed) == 0 {
+		return nil
+	}
+
+	return changed
+}
+
+//Returns the part of the amount each delegator of the validator gets in the block at height, in the order of the
+//delegations. Delegators whose account does not exist anymore get nothing.
+func delegatorShares(validator *protocol.Account, amount uint64, height uint32) []uint64 {
+	shares := make([]uint64, len(validator.Delegations))
+
+	total := new(big.Int).SetUint64(parametersAt(height).Staking_minimum)
+	total.Add(total, new(big.Int).SetUint64(validator.DelegatedStake()))
+	if total.Sign() == 0 {
+		return shares
+	}
+
+	for i, delegation := range validator.Delegations {
+		if acc, _ := storage.GetAccount(delegation.Delegator); acc == nil {
+			continue
+		}
+
+		share := new(big.Int).Mul(new(big.Int).SetUint64(amount), new(big.Int).SetUint64(delegation.Amount))
+		shares[i] = share.Div(share, total).Uint64()
+	}
+
+	return shares
+}
+
+//Pays the delegators of the beneficiary their part of the block reward and the fees collected in the block.
+func collectDelegatorRewards(data blockData, reward uint64) error {
+	validator, err := storage.GetAccount(data.block.Beneficiary)
+	if err != nil {
+		return err
+	}
+	if len(validator.Delegations) == 0 {
+		return nil
+	}
+
+	shares := delegatorShares(validator, reward+collectedFees(data), data.block.Height)
+	for i, share := range shares {
+		if share == 0 {
+			continue
+		}
+
+		delegator, _ := storage.GetAccount(validator.Delegations[i].Delegator)
+		if delegator.Balance+share > MAX_MONEY {
+			return errors.New("Delegation reward would lead to balance overflow at the delegator account.")
+		}
+	}
+
+	delegations := append([]protocol.Delegation(nil), validator.Delegations...)
+	for i, share := range shares {
+		if share == 0 {
+			continue
+		}
+
+		delegator, _ := storage.GetAccount(delegations[i].Delegator)
+		delegator.Balance += share
+		validator.Balance -= share
+		delegations[i].Rewards += share
+	}
+	validator.Delegations = delegations
+
+	return nil
+}
+
+//The delegations are the same as when the rewards were paid, only the rewards paid so far differ.
+func collectDelegatorRewardsRollback(data blockData, reward uint64) {
+	validator, _ := storage.GetAccount(data.block.Beneficiary)
+	if validator == nil || len(validator.Delegations) == 0 {
+		return
+	}
+
+	shares := delegatorShares(validator, reward+collectedFees(data), data.block.Height)
+	delegations := append([]protocol.Delegation(nil), validator.Delegations...)
+	for i, share := range shares {
+		if share == 0 {
+			continue
+		}
+
+		delegator, _ := storage.GetAccount(delegations[i].Delegator)
+		delegator.Balance -= share
+		validator.Balance += share
+		delegations[i].Rewards -= share
+	}
+	validator.Delegations = changeDelegation(delegations, [32]byte{}, func(*protocol.Delegation) {})
+}
+
+//Returns the fees (and the gas of contract calls) the beneficiary collects in the block.
+func collectedFees(data blockData) (fees uint64) {
+	fundsTxs := append([]*protocol.FundsTx(nil), data.fundsTxSlice...)
+	for _, tx := range data.aggTxSlice {
+		fundsTxs = append(fundsTxs, aggregatedFundsTxs(tx)...)
+	}
+
+	for _, tx := range data.accTxSlice {
+		fees += tx.Fee
+	}
+	for _, tx := range fundsTxs {
+		fees += tx.Fee + tx.GasCost()
+	}
+	for _, tx := range data.configTxSlice {
+		fees += tx.Fee
+	}
+	for _, tx := range data.stakeTxSlice {
+		fees += tx.Fee
+	}
+	for _, tx := range data.iotTxSlice {
+		fees += tx.Fee
+	}
+	for _, tx := range data.contractTxSlice {
+		fees += tx.Fee
+	}
+
+	return fees
+}
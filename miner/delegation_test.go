@@ -0,0 +1,129 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+)
+
+func newDelegationTestAccount(balance uint64, isStaking bool) ([32]byte, *protocol.Account, ed25519.PrivateKey) {
+	pubKey, privKey, _ := ed25519.GenerateKey(nil)
+	var address [32]byte
+	copy(address[:], pubKey)
+	acc := &protocol.Account{Address: address, Balance: balance, IsStaking: isStaking}
+	hash := acc.Hash()
+	storage.State.Set(hash, acc)
+
+	return hash, acc, privKey
+}
+
+func TestDelegation(t *testing.T) {
+	defer func(height uint64) { parameterSlice[0].Delegation_height = height }(parameterSlice[0].Delegation_height)
+	parameterSlice[0].Delegation_height = 0
+
+	from, delegator, privKey := newDelegationTestAccount(1000, false)
+	to, validator, _ := newDelegationTestAccount(2000, true)
+	toOther, other, _ := newDelegationTestAccount(2000, false)
+	defer storage.State.Delete(from)
+	defer storage.State.Delete(to)
+	defer storage.State.Delete(toOther)
+
+	delegate, _ := protocol.ConstrFundsTx(protocol.FUNDSTX_DELEGATE, 300, 1, 0, from, to, privKey, nil)
+	undelegate, _ := protocol.ConstrFundsTx(protocol.FUNDSTX_UNDELEGATE, 300, 1, 1, from, to, privKey, nil)
+
+	//The proposer changes its copy of the state only.
+	block := &protocol.Block{Height: 1, StateCopy: make(map[[32]byte]*protocol.Account)}
+	if err := addFundsTx(block, delegate); err != nil {
+		t.Fatalf("Delegation not added to the block: %v\n", err)
+	}
+	if block.StateCopy[from].Balance != 700 || block.StateCopy[to].Balance != 2000 || block.StateCopy[to].GetDelegation(from).Amount != 300 {
+		t.Errorf("Delegation not applied to the state copy: %v, %v\n", block.StateCopy[from], block.StateCopy[to])
+	}
+	if validator.Delegations != nil {
+		t.Errorf("Delegation of the state copy changed the state: %v\n", validator)
+	}
+
+	if err := fundsStateChange([]*protocol.FundsTx{delegate}, 1); err != nil {
+		t.Fatalf("Delegation rejected: %v\n", err)
+	}
+	if delegator.Balance != 700 || delegator.TxCnt != 1 || validator.Balance != 2000 || validator.DelegatedStake() != 300 {
+		t.Errorf("Delegation not applied: %v, %v\n", delegator, validator)
+	}
+
+	tooMuch, _ := protocol.ConstrFundsTx(protocol.FUNDSTX_UNDELEGATE, 301, 1, 1, from, to, privKey, nil)
+	if err := fundsStateChange([]*protocol.FundsTx{tooMuch}, 1); err == nil {
+		t.Error("Undelegation of more than the delegated amount accepted.")
+	}
+
+	if err := fundsStateChange([]*protocol.FundsTx{undelegate}, 1); err != nil {
+		t.Fatalf("Undelegation rejected: %v\n", err)
+	}
+	if delegator.Balance != 1000 || delegator.TxCnt != 2 || validator.Delegations != nil {
+		t.Errorf("Undelegation not applied: %v, %v\n", delegator, validator)
+	}
+
+	fundsStateChangeRollback([]*protocol.FundsTx{delegate, undelegate}, 1)
+	if delegator.Balance != 1000 || delegator.TxCnt != 0 || validator.Balance != 2000 || validator.Delegations != nil {
+		t.Errorf("Delegation not rolled back: %v, %v\n", delegator, validator)
+	}
+
+	//Only validators can be delegated to.
+	notStaking, _ := protocol.ConstrFundsTx(protocol.FUNDSTX_DELEGATE, 300, 1, 0, from, toOther, privKey, nil)
+	if err := fundsStateChange([]*protocol.FundsTx{notStaking}, 1); err == nil || other.Delegations != nil {
+		t.Error("Delegation to an account which is not staking accepted.")
+	}
+
+	//Up to the Delegation_height, the tx is a plain transfer.
+	if err := fundsStateChange([]*protocol.FundsTx{delegate}, 0); err != nil {
+		t.Fatalf("Transfer rejected: %v\n", err)
+	}
+	if delegator.Balance != 700 || validator.Balance != 2300 || validator.Delegations != nil {
+		t.Errorf("Tx below the Delegation_height not applied as a transfer: %v, %v\n", delegator, validator)
+	}
+	fundsStateChangeRollback([]*protocol.FundsTx{delegate}, 0)
+}
+
+func TestDelegatorRewards(t *testing.T) {
+	hash1, delegator1, _ := newDelegationTestAccount(0, false)
+	hash2, delegator2, _ := newDelegationTestAccount(0, false)
+	to, validator, _ := newDelegationTestAccount(5000, true)
+	defer storage.State.Delete(hash1)
+	defer storage.State.Delete(hash2)
+	defer storage.State.Delete(to)
+
+	validator.Delegations = changeDelegation(validator.Delegations, hash1, func(delegation *protocol.Delegation) { delegation.Amount = 300 })
+	validator.Delegations = changeDelegation(validator.Delegations, hash2, func(delegation *protocol.Delegation) { delegation.Amount = 700 })
+	delegations := validator.Delegations
+
+	if set := computeValidatorSet(0, nil); set.Get(to) == nil || set.Get(to).Balance != 6000 {
+		t.Errorf("Delegated stake not counted for the validator set: %v\n", set.Get(to))
+	}
+
+	//The validator's own stake counts as the staking minimum (1000), the rest of the shares is rounded down.
+	data := blockData{block: &protocol.Block{Height: 1, Beneficiary: to}}
+	if err := collectDelegatorRewards(data, 1001); err != nil {
+		t.Fatalf("Delegator rewards not paid: %v\n", err)
+	}
+	if delegator1.Balance != 150 || delegator2.Balance != 350 || validator.Balance != 5000-500 {
+		t.Errorf("Delegator rewards not shared pro rata: %v, %v, %v\n", delegator1, delegator2, validator)
+	}
+	if validator.GetDelegation(hash1).Rewards != 150 || validator.GetDelegation(hash2).Rewards != 350 || delegations[0].Rewards != 0 {
+		t.Errorf("Delegator rewards not recorded: %v\n", validator.Delegations)
+	}
+
+	collectDelegatorRewardsRollback(data, 1001)
+	if delegator1.Balance != 0 || delegator2.Balance != 0 || validator.Balance != 5000 || validator.GetDelegation(hash1).Rewards != 0 {
+		t.Errorf("Delegator rewards not rolled back: %v, %v, %v\n", delegator1, delegator2, validator)
+	}
+
+	//The validator keeps the share of a delegator whose account was removed.
+	storage.State.Delete(hash2)
+	if err := collectDelegatorRewards(data, 1001); err != nil {
+		t.Fatalf("Delegator rewards not paid: %v\n", err)
+	}
+	if delegator1.Balance != 150 || validator.Balance != 5000-150 || validator.GetDelegation(hash2).Rewards != 0 {
+		t.Errorf("Share of a removed delegator not kept by the validator: %v, %v\n", delegator1, validator)
+	}
+}
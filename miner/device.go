@@ -0,0 +1,70 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Devices are onboarded with an accTx carrying the device metadata (see protocol/device.go). The rate limit of a device
+//is part of consensus: a block must not contain more IotTxs of a device than its limit. When preparing a block, the
+//txs exceeding the limit stay in the mempool for the next blocks.
+
+//Devices are plain accounts, they can not be root or multi-signature accounts.
+func verifyDevice(tx *protocol.AccTx) bool {
+	if tx.Header != 0 || tx.Threshold > 0 || len(tx.Cosigners) > 0 {
+		logger.Printf("Device %x can not be a root or multi-signature account.\n", tx.PubKey[0:8])
+		return false
+	}
+
+	if err := tx.Device.Check(); err != nil {
+		logger.Printf("Invalid device %x: %v\n", tx.PubKey[0:8], err)
+		return false
+	}
+
+	return true
+}
+
+func checkDeviceOwner(device *protocol.DeviceInfo) error {
+	if storage.State.Get(device.Owner) == nil {
+		return errors.New(fmt.Sprintf("Owner %x of the device is not present in the state.", device.Owner[0:8]))
+	}
+
+	return nil
+}
+
+//Returns true if the sender of the IotTx is a device which already sent as many txs in the block as it is allowed to.
+//Txs of a sender advance its txCnt in the block's state copy, the difference to the state is the number of txs sent.
+func isRateLimited(block *protocol.Block, tx protocol.Transaction) bool {
+	iotTx, ok := tx.(*protocol.IotTx)
+	if !ok {
+		return false
+	}
+
+	acc := storage.State.Get(iotTx.From)
+	if acc == nil || !acc.IsDevice() {
+		return false
+	}
+
+	var sent uint32
+	if accCopy := block.StateCopy[iotTx.From]; accCopy != nil {
+		sent = accCopy.TxCnt - acc.TxCnt
+	}
+
+	return acc.Device.RateLimited(sent)
+}
+
+//Called after the accTxs of the block have been applied, so devices created by the block are limited as well.
+func checkDeviceRateLimits(iotTxSlice []*protocol.IotTx) error {
+	sent := make(map[[32]byte]uint32)
+	for _, tx := range iotTxSlice {
+		acc := storage.State.Get(tx.From)
+		if acc != nil && acc.IsDevice() && acc.Device.RateLimited(sent[tx.From]) {
+			return errors.New(fmt.Sprintf("Device %x exceeds its rate limit of %v txs per block.", tx.From[0:8], acc.Device.RateLimit))
+		}
+		sent[tx.From]++
+	}
+
+	return nil
+}
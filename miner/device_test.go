@@ -0,0 +1,83 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestVerifyDevice(t *testing.T) {
+	accTx := func() *protocol.AccTx {
+		return &protocol.AccTx{PubKey: [32]byte{0x01}, Device: &protocol.DeviceInfo{Type: "thermometer", Owner: [32]byte{0x02}, RateLimit: 5}}
+	}
+
+	if !verifyDevice(accTx()) {
+		t.Error("Valid device was rejected.")
+	}
+
+	tx := accTx()
+	tx.Header = 1
+	if verifyDevice(tx) {
+		t.Error("Devices can not be root accounts.")
+	}
+
+	tx = accTx()
+	tx.Threshold, tx.Cosigners = 1, [][32]byte{{0x03}}
+	if verifyDevice(tx) {
+		t.Error("Devices can not be multi-signature accounts.")
+	}
+
+	tx = accTx()
+	tx.Device.Type = ""
+	if verifyDevice(tx) {
+		t.Error("Device without a type should be rejected.")
+	}
+
+	tx = accTx()
+	tx.Device.Owner = [32]byte{}
+	if verifyDevice(tx) {
+		t.Error("Device without an owner should be rejected.")
+	}
+}
+
+func TestDeviceRateLimits(t *testing.T) {
+	deviceHash := [32]byte{0xd1}
+	otherHash := [32]byte{0xd2}
+	defer storage.State.Delete(deviceHash)
+	defer storage.State.Delete(otherHash)
+
+	storage.State.Set(deviceHash, &protocol.Account{TxCnt: 3, Device: &protocol.DeviceInfo{Type: "thermometer", Owner: [32]byte{0x01}, RateLimit: 2}})
+	storage.State.Set(otherHash, &protocol.Account{})
+
+	iotTx := func(from [32]byte) *protocol.IotTx {
+		return &protocol.IotTx{From: from}
+	}
+
+	if err := checkDeviceRateLimits([]*protocol.IotTx{iotTx(deviceHash), iotTx(otherHash), iotTx(deviceHash)}); err != nil {
+		t.Errorf("Txs within the rate limit were rejected: %v", err)
+	}
+
+	if err := checkDeviceRateLimits([]*protocol.IotTx{iotTx(deviceHash), iotTx(deviceHash), iotTx(deviceHash)}); err == nil {
+		t.Error("Txs exceeding the rate limit should be rejected.")
+	}
+
+	if err := checkDeviceRateLimits([]*protocol.IotTx{iotTx(otherHash), iotTx(otherHash), iotTx(otherHash)}); err != nil {
+		t.Errorf("Accounts which are not devices should not be limited: %v", err)
+	}
+
+	block := &protocol.Block{StateCopy: make(map[[32]byte]*protocol.Account)}
+	if isRateLimited(block, iotTx(deviceHash)) {
+		t.Error("Device without txs in the block should not be limited.")
+	}
+
+	block.StateCopy[deviceHash] = &protocol.Account{TxCnt: 5}
+	if !isRateLimited(block, iotTx(deviceHash)) {
+		t.Error("Device with the maximum number of txs in the block should be limited.")
+	}
+
+	storage.State.Get(deviceHash).Device.RateLimit = 0
+	if isRateLimited(block, iotTx(deviceHash)) {
+		t.Error("Device without a rate limit should not be limited.")
+	}
+}
@@ -0,0 +1,31 @@
+package miner
+
+import "github.com/bazo-blockchain/bazo-miner/protocol"
+
+//In dev mode, a single node runs a private network for contract and wallet developers. The difficulty is minimal and
+//the parameters of the chain never adjust it, so blocks are found at the first attempt. The start command takes care of the rest: the node is its
+//own bootstrap node, the generated root key is the staking validator, no peers are required and a block is produced as
+//soon as a tx arrives. Other nodes would reject the blocks of a dev node, which must only be used locally.
+
+const DEV_MODE_DIFFICULTY = 0
+
+var devMode bool
+
+func SetDevMode(enabled bool) {
+	devMode = enabled
+}
+
+//Returns the difficulty of the first blocks.
+func initialDifficulty() uint8 {
+	if devMode {
+		return DEV_MODE_DIFFICULTY
+	}
+
+	return 15
+}
+
+//The difficulty is retargeted after MAX_DIFF_INTERVAL blocks, i.e., never.
+func applyDevModeParameters(params *Parameters) {
+	params.Diff_interval = protocol.MAX_DIFF_INTERVAL
+	params.Diff_ema_window = 0
+}
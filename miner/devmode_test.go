@@ -0,0 +1,34 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestDevModeDifficulty(t *testing.T) {
+	defer func(params *Parameters, previousTarget []uint8, timerange *timerange, previousBlock *protocol.Block, local int64, global int64) {
+		activeParameters, target, currentTargetTime, lastBlock, localBlockCount, globalBlockCount = params, previousTarget, timerange, previousBlock, local, global
+		SetDevMode(false)
+	}(activeParameters, target, currentTargetTime, lastBlock, localBlockCount, globalBlockCount)
+
+	SetDevMode(true)
+	target = []uint8{initialDifficulty()}
+	if getDifficulty() != DEV_MODE_DIFFICULTY {
+		t.Errorf("Dev mode starts with difficulty %v.\n", getDifficulty())
+	}
+
+	params := NewDefaultParameters()
+	applyDevModeParameters(&params)
+	activeParameters = &params
+	currentTargetTime = &timerange{first: 1000}
+	localBlockCount = 0
+
+	//Blocks found every second do not raise the difficulty.
+	for i := uint32(1); i <= 3*DIFF_INTERVAL; i++ {
+		collectStatistics(&protocol.Block{Height: i, Timestamp: 1000 + int64(i)})
+	}
+	if getDifficulty() != DEV_MODE_DIFFICULTY {
+		t.Errorf("Difficulty adjusted in dev mode: %v\n", getDifficulty())
+	}
+}
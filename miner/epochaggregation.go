@@ -0,0 +1,204 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"math"
+)
+
+//Recursive aggregation of the aggTxs of old blocks (see protocol/epochaggregate.go). Once the blocks of an epoch can
+//not be rolled back anymore, their aggTxs are aggregated into a first level aggregate, and every epochLength aggregates
+//of a level into one of the next level. The aggregates are kept. With pruning enabled, the aggTxs of an epoch, the
+//fundsTxs they aggregate and the hashes of the first level aggregate are deleted once a snapshot above the epoch is
+//stored, the node never replays these blocks again. What remains of the txs are the aggregates, whose number only
+//depends on the number of blocks. Proofs of pruned txs (getAggregationProof) need to be requested before the txs are
+//pruned, they stay verifiable against the kept aggregates.
+
+const (
+	EPOCH_LENGTH_DEFAULT = 100 //Blocks per first level aggregate, aggregates per higher level aggregate
+	MAX_EPOCH_LEVEL      = 16
+)
+
+var (
+	epochLength     = uint32(EPOCH_LENGTH_DEFAULT)
+	pruneAggregated bool
+)
+
+//A length of 0 disables the aggregation.
+func SetEpochAggregation(length uint32, prune bool) {
+	epochLength = length
+	pruneAggregated = prune
+}
+
+//Returns the highest height up to which blocks can not be rolled back anymore and are not kept with their txs.
+func aggregatableHeight(tip uint32) (uint32, bool) {
+	depth := uint32(noAggregationLength)
+	if maxRollbackDepth > depth {
+		depth = maxRollbackDepth
+	}
+
+	if tip <= depth {
+		return 0, false
+	}
+
+	return tip - depth - 1, true
+}
+
+//Aggregates the epochs which became aggregatable with the block at the tip height, then the aggregates of all levels.
+func aggregateEpochs(tip uint32) {
+	if epochLength < 2 {
+		return
+	}
+
+	maxHeight, ok := aggregatableHeight(tip)
+	if !ok {
+		return
+	}
+
+	first := uint32(0)
+	last := storage.ReadLastEpochAggregate(1)
+	if last != nil {
+		first = last.LastHeight + 1
+	}
+
+	for ; first+epochLength-1 <= maxHeight; first += epochLength {
+		aggTxs, complete := epochAggTxs(first, first+epochLength-1)
+		if !complete {
+			//A node bootstrapped from a snapshot does not know the blocks before, it starts with the next epoch.
+			if last == nil {
+				continue
+			}
+			logger.Printf("Could not aggregate epoch %v-%v: blocks or aggTxs missing.\n", first, first+epochLength-1)
+			return
+		}
+
+		aggregate, leaves, err := protocol.NewEpochAggregate(first, first+epochLength-1, aggTxs)
+		if err == nil {
+			err = storage.WriteEpochAggregate(aggregate, leaves)
+		}
+		if err != nil {
+			logger.Printf("Could not aggregate epoch %v-%v: %v\n", first, first+epochLength-1, err)
+			return
+		}
+		last = aggregate
+	}
+
+	for level := uint8(1); level < MAX_EPOCH_LEVEL; level++ {
+		from := uint32(0)
+		if parent := storage.ReadLastEpochAggregate(level + 1); parent != nil {
+			from = parent.LastHeight + 1
+		}
+
+		for {
+			children := storage.ReadEpochAggregates(level, from, int(epochLength))
+			if len(children) < int(epochLength) {
+				break
+			}
+
+			aggregate, leaves, err := protocol.AggregateEpochAggregates(children)
+			if err == nil {
+				err = storage.WriteEpochAggregate(aggregate, leaves)
+			}
+			if err != nil {
+				logger.Printf("Could not aggregate level %v aggregates from height %v: %v\n", level, from, err)
+				return
+			}
+			from = aggregate.LastHeight + 1
+		}
+
+		if storage.ReadLastEpochAggregate(level+1) == nil {
+			return
+		}
+	}
+}
+
+//Returns the aggTxs of the blocks from first to last height, complete is false if any of them is not known.
+func epochAggTxs(first uint32, last uint32) (aggTxs []*protocol.AggTx, complete bool) {
+	for height := first; height <= last; height++ {
+		blockHash, exists := storage.ReadBlockHashByHeight(height)
+		if !exists {
+			//The genesis block has no txs and is not indexed.
+			if height == 0 {
+				continue
+			}
+			return nil, false
+		}
+
+		block := readBlock(blockHash)
+		if block == nil {
+			return nil, false
+		}
+
+		for _, txHash := range block.AggTxData {
+			aggTx, ok := storage.ReadClosedTx(txHash).(*protocol.AggTx)
+			if !ok {
+				return nil, false
+			}
+			aggTxs = append(aggTxs, aggTx)
+		}
+	}
+
+	return aggTxs, true
+}
+
+//Deletes the aggregated txs of the first level aggregates below the snapshot height.
+func pruneAggregatedTxs(snapshotHeight uint32) {
+	if !pruneAggregated {
+		return
+	}
+
+	for _, aggregate := range storage.ReadEpochAggregates(1, 0, math.MaxInt32) {
+		if aggregate.LastHeight >= snapshotHeight {
+			break
+		}
+
+		//Aggregates of which the hashes have been deleted are pruned already.
+		leaves := storage.ReadEpochLeaves(1, aggregate.FirstHeight)
+		if leaves == nil {
+			continue
+		}
+
+		aggTxs, _ := epochAggTxs(aggregate.FirstHeight, aggregate.LastHeight)
+		batch := storage.NewBatch()
+		for _, aggTx := range aggTxs {
+			for _, txHash := range aggTx.AggregatedTxSlice {
+				if tx := storage.ReadClosedTx(txHash); tx != nil {
+					batch.PruneClosedTx(tx)
+				}
+			}
+			batch.PruneClosedTx(aggTx)
+		}
+		batch.DeleteEpochLeaves(1, aggregate.FirstHeight)
+		if err := batch.Commit(); err != nil {
+			logger.Printf("Could not prune the txs of epoch %v-%v: %v\n", aggregate.FirstHeight, aggregate.LastHeight, err)
+			return
+		}
+	}
+}
+
+//Returns the proof that the tx is aggregated, up to the highest level aggregate. Nil if the tx is not aggregated or
+//it has been pruned.
+func aggregationProof(txHash [32]byte) *protocol.AggregationProof {
+	aggregate, leaves := storage.FindEpochLeaf(txHash)
+	if aggregate == nil {
+		return nil
+	}
+
+	proof := &protocol.AggregationProof{TxHash: txHash}
+	leaf := txHash
+	for aggregate != nil {
+		merkleProof, err := aggregate.Proof(leaves, leaf)
+		if err != nil {
+			logger.Printf("Could not create the proof of %x: %v\n", txHash[0:8], err)
+			return nil
+		}
+		proof.Steps = append(proof.Steps, protocol.AggregationStep{Aggregate: *aggregate, Proof: *merkleProof})
+
+		leaf = aggregate.Hash()
+		if aggregate = storage.ReadEpochAggregate(aggregate.Level+1, aggregate.FirstHeight); aggregate != nil {
+			leaves = storage.ReadEpochLeaves(aggregate.Level, aggregate.FirstHeight)
+		}
+	}
+
+	return proof
+}
@@ -0,0 +1,78 @@
+package miner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestEpochAggregation(t *testing.T) {
+	defer SetEpochAggregation(epochLength, pruneAggregated)
+	defer SetAggregation(uint32(aggregationMinTxs), uint32(noAggregationLength))
+	defer SetFinality(maxRollbackDepth, checkpointInterval)
+	SetEpochAggregation(2, false)
+	SetAggregation(uint32(aggregationMinTxs), 0)
+	SetFinality(0, 0)
+
+	//Blocks 0 to 3 with an aggTx of two fundsTxs each.
+	var fundsTxs []*protocol.FundsTx
+	for height := uint32(0); height < 4; height++ {
+		tx1 := &protocol.FundsTx{Amount: 1, Fee: 1, TxCnt: 2 * height, From: [32]byte{'e', 'p'}, To: [32]byte{'e', 'q'}}
+		tx2 := &protocol.FundsTx{Amount: 2, Fee: 1, TxCnt: 2*height + 1, From: [32]byte{'e', 'p'}, To: [32]byte{'e', 'r'}}
+		aggTx, _ := aggregate([]*protocol.FundsTx{tx1, tx2})
+		block := &protocol.Block{Hash: [32]byte{'e', 'p', byte(height)}, Height: height, AggTxData: [][32]byte{aggTx.Hash()}}
+
+		for _, tx := range []protocol.Transaction{tx1, tx2, aggTx} {
+			storage.WriteClosedTx(tx)
+			defer storage.DeleteClosedTx(tx)
+		}
+		storage.WriteClosedBlock(block)
+		storage.WriteBlockHeight(height, block.Hash)
+		defer storage.DeleteClosedBlock(block.Hash)
+		defer storage.DeleteBlockHeight(height)
+		fundsTxs = append(fundsTxs, tx1, tx2)
+	}
+	defer storage.DeleteEpochAggregate(1, 0)
+	defer storage.DeleteEpochAggregate(1, 2)
+	defer storage.DeleteEpochAggregate(2, 0)
+
+	//Block 3 is the newest block that can not be rolled back anymore.
+	aggregateEpochs(3)
+	if aggregate := storage.ReadLastEpochAggregate(1); aggregate == nil || aggregate.LastHeight != 1 {
+		t.Fatalf("Only the first epoch should have been aggregated: %v\n", aggregate)
+	}
+
+	aggregateEpochs(4)
+	top := storage.ReadLastEpochAggregate(2)
+	if top == nil || top.FirstHeight != 0 || top.LastHeight != 3 || top.NrTxs != 8 || top.Amount != 12 {
+		t.Fatalf("Epochs should have been aggregated into a second level aggregate: %v\n", top)
+	}
+
+	txHash := fundsTxs[5].Hash()
+	proof := aggregationProof(txHash)
+	if proof == nil || len(proof.Steps) != 2 || proof.Verify() == nil || proof.Verify().Hash() != top.Hash() {
+		t.Fatalf("Proof of an aggregated tx does not lead to the top aggregate: %v\n", proof)
+	}
+
+	params, _ := json.Marshal([]string{hex.EncodeToString(txHash[:])})
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getAggregationProof", Params: params, Id: 1})
+	if response.Error != nil || len(response.Result.([]rpcAggregationStep)) != 2 {
+		t.Errorf("Querying aggregation proof failed: %v\n", response.Error)
+	}
+
+	//The txs below the snapshot are pruned, the aggregates are kept.
+	SetEpochAggregation(2, true)
+	pruneAggregatedTxs(2)
+	if storage.ReadClosedTx(fundsTxs[0].Hash()) != nil || storage.ReadEpochLeaves(1, 0) != nil {
+		t.Error("Txs of the epoch below the snapshot should have been pruned.")
+	}
+	if storage.ReadClosedTx(fundsTxs[5].Hash()) == nil || aggregationProof(txHash) == nil {
+		t.Error("Txs of the epoch above the snapshot should have been kept.")
+	}
+	if storage.ReadEpochAggregate(1, 0) == nil || storage.ReadEpochAggregate(2, 0) == nil {
+		t.Error("Aggregates should have been kept.")
+	}
+}
@@ -0,0 +1,35 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Logs are not part of the state, they are collected from the contract calls executed while validating the block (see
+//callContract). Failed executions emit no logs.
+func collectLogs(block *protocol.Block) (logs []*protocol.Log) {
+	for _, log := range contractLogs {
+		log.Height = block.Height
+		log.Index = uint32(len(logs))
+		logs = append(logs, log)
+	}
+
+	return logs
+}
+
+func readStateAccount(hash [32]byte) *protocol.Account {
+	return storage.State.Get(hash)
+}
+
+//The contracts possibly having emitted logs in the block, see collectLogs.
+func calledContracts(fundsTxSlice []*protocol.FundsTx) (contracts [][32]byte) {
+	called := make(map[[32]byte]bool)
+	for _, tx := range fundsTxSlice {
+		if tx.Data != nil && !called[tx.To] {
+			called[tx.To] = true
+			contracts = append(contracts, tx.To)
+		}
+	}
+
+	return contracts
+}
@@ -0,0 +1,39 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/bazo-blockchain/bazo-miner/vm"
+)
+
+func TestCollectLogs(t *testing.T) {
+	contractAcc := &protocol.Account{Address: [32]byte{'l', 'o', 'g'}, Contract: []byte{vm.PUSH, 0, 1, vm.PUSH, 0, 9, vm.LOG, 1, vm.HALT}}
+	contract := contractAcc.Hash()
+	storage.State.Set(contract, contractAcc)
+	defer storage.State.Delete(contract)
+
+	call := &protocol.FundsTx{Amount: 1, To: contract, Data: []byte{0x01}, GasLimit: 1000, GasPrice: 1}
+	outOfGas := &protocol.FundsTx{Amount: 2, To: contract, Data: []byte{0x01}, GasLimit: 2, GasPrice: 1}
+	transfer := &protocol.FundsTx{Amount: 3, To: contract}
+
+	contractLogs = nil
+	if callContract(outOfGas) != nil {
+		t.Error("Out of gas execution succeeded.")
+	}
+	callContract(call).PersistChanges()
+	callContract(call).PersistChanges()
+
+	logs := collectLogs(&protocol.Block{Height: 7})
+	if len(logs) != 2 || logs[0].TxHash != call.Hash() || logs[0].Contract != contract || logs[0].Height != 7 || logs[1].Index != 1 {
+		t.Fatalf("Invalid logs: %v\n", logs)
+	}
+	if !logs[0].HasTopic([]byte{1}) || string(logs[0].Data) != "\x09" {
+		t.Errorf("Invalid log content: %v\n", logs[0])
+	}
+
+	if contracts := calledContracts([]*protocol.FundsTx{outOfGas, transfer, call}); len(contracts) != 1 || contracts[0] != contract {
+		t.Errorf("Invalid called contracts: %v\n", contracts)
+	}
+}
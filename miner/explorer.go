@@ -0,0 +1,185 @@
+package miner
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Read-only REST API for block explorers, served next to the JSON-RPC interface. The responses use the same JSON
+//representation as the RPC methods, so explorers can be built in any language:
+//
+//	GET /blocks?to=<height>&limit=<n>                  blocks from height to (default: the tip) down
+//	GET /blocks/<hash>                                 a closed block
+//	GET /blocks/height/<height>                        the block at the height on the node's chain
+//	GET /accounts/<address>                            an account by its address or the hash of its address
+//	GET /accounts/<address>/txs?to=<height>&limit=<n>  the txs affecting the account, newest first
+//	GET /txs/<hash>                                    a closed or open tx
+//
+//The responses of /blocks and /accounts/<address>/txs contain the height of the next page in next, it is omitted on the
+//last page.
+
+const (
+	EXPLORER_PAGE_SIZE     = 20
+	EXPLORER_MAX_PAGE_SIZE = 100
+)
+
+type explorerBlocks struct {
+	Blocks []rpcBlock `json:"blocks"`
+	Next   *uint32    `json:"next,omitempty"`
+}
+
+type explorerError struct {
+	Error string `json:"error"`
+}
+
+func handleExplorer(mux *http.ServeMux) {
+	mux.HandleFunc("/blocks", explorerHandler(explorerGetBlocks))
+	mux.HandleFunc("/blocks/", explorerHandler(explorerGetBlock))
+	mux.HandleFunc("/accounts/", explorerHandler(explorerGetAccount))
+	mux.HandleFunc("/txs/", explorerHandler(explorerGetTx))
+}
+
+//Handlers return the response and the HTTP status, errors are returned as explorerError.
+func explorerHandler(handler func(r *http.Request) (interface{}, int)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var response interface{}
+		status := http.StatusMethodNotAllowed
+		if r.Method == http.MethodGet {
+			response, status = handler(r)
+		} else {
+			response = explorerError{"Only GET requests are supported."}
+		}
+
+		//Explorers are usually web applications served from another origin.
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+func explorerGetBlocks(r *http.Request) (interface{}, int) {
+	blockValidation.Lock()
+	tip := lastBlock
+	blockValidation.Unlock()
+
+	if tip == nil {
+		return explorerError{"Node is not synchronized yet."}, http.StatusServiceUnavailable
+	}
+
+	to, limit := uint64(tip.Height), uint64(EXPLORER_PAGE_SIZE)
+	var err error
+	if param := r.URL.Query().Get("to"); len(param) > 0 {
+		if to, err = strconv.ParseUint(param, 10, 32); err != nil {
+			return explorerError{fmt.Sprintf("Invalid height: %v", param)}, http.StatusBadRequest
+		}
+	}
+	if param := r.URL.Query().Get("limit"); len(param) > 0 {
+		if limit, err = strconv.ParseUint(param, 10, 32); err != nil || limit == 0 || limit > EXPLORER_MAX_PAGE_SIZE {
+			return explorerError{fmt.Sprintf("Limit must be between 1 and %v.", EXPLORER_MAX_PAGE_SIZE)}, http.StatusBadRequest
+		}
+	}
+
+	response := explorerBlocks{Blocks: []rpcBlock{}}
+	page := storage.ReadBlockHeights(uint32(to), int(limit))
+	for _, indexed := range page {
+		if block := readBlock(indexed.BlockHash); block != nil {
+			response.Blocks = append(response.Blocks, newRPCBlock(block))
+		}
+	}
+
+	//The genesis block ends the chain.
+	if uint64(len(page)) == limit && page[len(page)-1].Height > 0 {
+		next := page[len(page)-1].Height - 1
+		response.Next = &next
+	}
+
+	return response, http.StatusOK
+}
+
+func explorerGetBlock(r *http.Request) (interface{}, int) {
+	path := strings.TrimPrefix(r.URL.Path, "/blocks/")
+
+	if strings.HasPrefix(path, "height/") {
+		param := strings.TrimPrefix(path, "height/")
+		height, err := strconv.ParseUint(param, 10, 32)
+		if err != nil {
+			return explorerError{fmt.Sprintf("Invalid height: %v", param)}, http.StatusBadRequest
+		}
+
+		block := storage.ReadBlockByHeight(uint32(height))
+		if block == nil {
+			return explorerError{fmt.Sprintf("No block at height %v.", height)}, http.StatusNotFound
+		}
+		return newRPCBlock(block), http.StatusOK
+	}
+
+	blockHash, err := decodeHash(path)
+	if err != nil {
+		return explorerError{err.Error()}, http.StatusBadRequest
+	}
+
+	block := readBlock(blockHash)
+	if block == nil {
+		return explorerError{fmt.Sprintf("Block (%x) not found.", blockHash[0:8])}, http.StatusNotFound
+	}
+
+	return newRPCBlock(block), http.StatusOK
+}
+
+func explorerGetAccount(r *http.Request) (interface{}, int) {
+	param := strings.TrimPrefix(r.URL.Path, "/accounts/")
+	history := strings.HasSuffix(param, "/txs")
+	param = strings.TrimSuffix(param, "/txs")
+
+	hash, err := decodeHash(param)
+	if err != nil {
+		return explorerError{fmt.Sprintf("Invalid address: %v", param)}, http.StatusBadRequest
+	}
+
+	//Accounts are stored by the hash of their address, explorers usually show the address itself.
+	acc, err := storage.GetAccount(hash)
+	if err != nil {
+		hash = protocol.SerializeHashContent(hash)
+		if acc, err = storage.GetAccount(hash); err != nil {
+			return explorerError{fmt.Sprintf("Account %v not found.", param)}, http.StatusNotFound
+		}
+	}
+
+	if !history {
+		return newRPCAccount(hash, acc), http.StatusOK
+	}
+
+	to, limit := uint64(^uint32(0)), uint64(ACCOUNT_TXS_PAGE_SIZE)
+	if param := r.URL.Query().Get("to"); len(param) > 0 {
+		if to, err = strconv.ParseUint(param, 10, 32); err != nil {
+			return explorerError{fmt.Sprintf("Invalid height: %v", param)}, http.StatusBadRequest
+		}
+	}
+	if param := r.URL.Query().Get("limit"); len(param) > 0 {
+		if limit, err = strconv.ParseUint(param, 10, 32); err != nil || limit == 0 || limit > ACCOUNT_TXS_MAX_PAGE_SIZE {
+			return explorerError{fmt.Sprintf("Limit must be between 1 and %v.", ACCOUNT_TXS_MAX_PAGE_SIZE)}, http.StatusBadRequest
+		}
+	}
+
+	return newRPCAccountTxs(hash, uint32(to), int(limit)), http.StatusOK
+}
+
+func explorerGetTx(r *http.Request) (interface{}, int) {
+	txHash, err := decodeHash(strings.TrimPrefix(r.URL.Path, "/txs/"))
+	if err != nil {
+		return explorerError{err.Error()}, http.StatusBadRequest
+	}
+
+	if tx := readRPCTx(txHash); tx != nil {
+		return tx, http.StatusOK
+	}
+
+	return explorerError{fmt.Sprintf("Transaction (%x) not found.", txHash[0:8])}, http.StatusNotFound
+}
@@ -0,0 +1,133 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func explorerGet(t *testing.T, path string, status int, response interface{}) {
+	mux := http.NewServeMux()
+	handleExplorer(mux)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	if recorder.Code != status {
+		t.Fatalf("GET %v: status %v instead of %v: %v\n", path, recorder.Code, status, recorder.Body.String())
+	}
+
+	if response != nil {
+		if err := json.NewDecoder(recorder.Body).Decode(response); err != nil {
+			t.Fatalf("GET %v: invalid response: %v\n", path, err)
+		}
+	}
+}
+
+func TestExplorerBlocks(t *testing.T) {
+	prevLastBlock := lastBlock
+	defer func() { lastBlock = prevLastBlock }()
+
+	var blocks []*protocol.Block
+	for height := uint32(0); height < 5; height++ {
+		block := &protocol.Block{Hash: [32]byte{0xe0, byte(height)}, Height: height}
+		blocks = append(blocks, block)
+		storage.WriteClosedBlock(block)
+		storage.WriteBlockHeight(height, block.Hash)
+		defer storage.DeleteClosedBlock(block.Hash)
+		defer storage.DeleteBlockHeight(height)
+	}
+	lastBlock = blocks[4]
+
+	var page explorerBlocks
+	explorerGet(t, "/blocks?limit=3", http.StatusOK, &page)
+	if len(page.Blocks) != 3 || page.Blocks[0].Height != 4 || page.Blocks[2].Height != 2 || page.Next == nil || *page.Next != 1 {
+		t.Errorf("Unexpected first page: %v\n", page)
+	}
+
+	page = explorerBlocks{}
+	explorerGet(t, "/blocks?to=1&limit=3", http.StatusOK, &page)
+	if len(page.Blocks) != 2 || page.Blocks[1].Height != 0 || page.Next != nil {
+		t.Errorf("Unexpected last page: %v\n", page)
+	}
+
+	explorerGet(t, "/blocks?limit=0", http.StatusBadRequest, nil)
+	explorerGet(t, "/blocks?to=x", http.StatusBadRequest, nil)
+
+	var block rpcBlock
+	explorerGet(t, "/blocks/"+hex.EncodeToString(blocks[2].Hash[:]), http.StatusOK, &block)
+	if block.Height != 2 {
+		t.Errorf("Unexpected block: %v\n", block)
+	}
+
+	block = rpcBlock{}
+	explorerGet(t, "/blocks/height/3", http.StatusOK, &block)
+	if block.Hash != hex.EncodeToString(blocks[3].Hash[:]) {
+		t.Errorf("Unexpected block at height 3: %v\n", block)
+	}
+
+	explorerGet(t, "/blocks/height/5", http.StatusNotFound, nil)
+	//The genesis block of the tests has the null hash.
+	explorerGet(t, "/blocks/"+hex.EncodeToString(bytes.Repeat([]byte{0xe0}, 32)), http.StatusNotFound, nil)
+	explorerGet(t, "/blocks/xyz", http.StatusBadRequest, nil)
+}
+
+func TestExplorerAccount(t *testing.T) {
+	address := [32]byte{0xe1}
+	acc := protocol.NewAccount(address, [32]byte{}, 1000, false, [256]byte{}, nil, nil)
+	accHash := protocol.SerializeHashContent(address)
+	storage.State.Set(accHash, &acc)
+	defer storage.State.Delete(accHash)
+
+	//Accounts are found by their address and the hash of their address.
+	for _, hash := range [][32]byte{address, accHash} {
+		var result rpcAccount
+		explorerGet(t, "/accounts/"+hex.EncodeToString(hash[:]), http.StatusOK, &result)
+		if result.Balance != 1000 || result.Address != hex.EncodeToString(address[:]) {
+			t.Errorf("Unexpected account: %v\n", result)
+		}
+	}
+
+	storage.WriteAccountTx(accHash, 9, [32]byte{0xe1})
+	defer storage.DeleteAccountTx(accHash, 9, [32]byte{0xe1})
+
+	var history rpcAccountTxs
+	explorerGet(t, "/accounts/"+hex.EncodeToString(address[:])+"/txs?limit=5", http.StatusOK, &history)
+	if len(history.Txs) != 1 || history.Txs[0].Height != 9 || history.Next != nil {
+		t.Errorf("Unexpected account history: %v\n", history)
+	}
+
+	explorerGet(t, "/accounts/"+hex.EncodeToString(address[:])+"/txs?limit=0", http.StatusBadRequest, nil)
+	explorerGet(t, "/accounts/"+hex.EncodeToString(make([]byte, 32)), http.StatusNotFound, nil)
+}
+
+func TestExplorerTx(t *testing.T) {
+	tx := &protocol.IotTx{From: [32]byte{0xe2}, Data: []byte("21.5")}
+	storage.WriteClosedTx(tx)
+	defer storage.DeleteClosedTx(tx)
+
+	txHash := tx.Hash()
+	var result rpcTxStatus
+	explorerGet(t, "/txs/"+hex.EncodeToString(txHash[:]), http.StatusOK, &result)
+	if result.Status != "closed" || result.Type != "iot" || result.Hash != hex.EncodeToString(txHash[:]) {
+		t.Errorf("Unexpected tx: %v\n", result)
+	}
+
+	explorerGet(t, "/txs/"+hex.EncodeToString(make([]byte, 32)), http.StatusNotFound, nil)
+}
+
+func TestExplorerReadOnly(t *testing.T) {
+	mux := http.NewServeMux()
+	handleExplorer(mux)
+
+	recorder := httptest.NewRecorder()
+	mux.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/blocks", nil))
+	if recorder.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST request accepted: %v\n", recorder.Code)
+	}
+}
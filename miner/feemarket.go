@@ -0,0 +1,119 @@
+package miner
+
+import (
+	"math"
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//Fee_minimum is the floor set by the network. When demand for block space is high, the minimum fee a transaction
+//has to pay to be included by this miner rises above that floor. Demand (pressure) is the higher of the mempool
+//fullness and the average utilization of the last FEE_MARKET_WINDOW blocks. Below FEE_MARKET_THRESHOLD the floor
+//applies, above it the minimum fee grows linearly up to FEE_MARKET_MAX_MULTIPLIER times the floor at full pressure.
+//This is a local inclusion policy, blocks of other miners are not validated against it.
+
+const (
+	FEE_MARKET_WINDOW         = 10  //Blocks
+	FEE_MARKET_THRESHOLD      = 0.5 //Pressure
+	FEE_MARKET_MAX_MULTIPLIER = 8
+)
+
+type FeeEstimate struct {
+	Minimum          uint64  `json:"minimum"`
+	Suggested        uint64  `json:"suggested"`
+	MempoolFullness  float64 `json:"mempoolFullness"`
+	BlockUtilization float64 `json:"blockUtilization"`
+}
+
+var (
+	//Utilization (size / block size limit) of the last FEE_MARKET_WINDOW blocks, the most recent one last.
+	blockUtilization      []float64
+	blockUtilizationMutex sync.Mutex
+)
+
+func recordBlockUtilization(block *protocol.Block) {
+	blockUtilizationMutex.Lock()
+	defer blockUtilizationMutex.Unlock()
+
+	utilization := 0.0
+	if blockSize := parametersAt(block.Height).Block_size; blockSize > 0 {
+		utilization = math.Min(float64(block.GetSize())/float64(blockSize), 1)
+	}
+
+	blockUtilization = append(blockUtilization, utilization)
+	if len(blockUtilization) > FEE_MARKET_WINDOW {
+		blockUtilization = blockUtilization[len(blockUtilization)-FEE_MARKET_WINDOW:]
+	}
+}
+
+//Drops the utilization of the rolled back block. Older blocks that fell out of the window are not restored, the
+//estimate recovers as soon as new blocks are validated.
+func recordBlockUtilizationRollback() {
+	blockUtilizationMutex.Lock()
+	defer blockUtilizationMutex.Unlock()
+
+	if len(blockUtilization) > 0 {
+		blockUtilization = blockUtilization[:len(blockUtilization)-1]
+	}
+}
+
+func averageBlockUtilization() float64 {
+	blockUtilizationMutex.Lock()
+	defer blockUtilizationMutex.Unlock()
+
+	if len(blockUtilization) == 0 {
+		return 0
+	}
+
+	sum := 0.0
+	for _, utilization := range blockUtilization {
+		sum += utilization
+	}
+
+	return sum / float64(len(blockUtilization))
+}
+
+//Scales the fee floor by the pressure, see the comment at the top of the file.
+func scaledFee(floor uint64, pressure float64) uint64 {
+	if pressure <= FEE_MARKET_THRESHOLD {
+		return floor
+	}
+
+	multiplier := 1 + (math.Min(pressure, 1)-FEE_MARKET_THRESHOLD)/(1-FEE_MARKET_THRESHOLD)*(FEE_MARKET_MAX_MULTIPLIER-1)
+	scaled := math.Ceil(float64(floor) * multiplier)
+	if scaled >= math.MaxUint64 {
+		return math.MaxUint64
+	}
+
+	return uint64(scaled)
+}
+
+//Returns the current fee estimate for a transaction of txSize bytes. The suggested fee is the minimum fee, raised such
+//that the transaction outbids the cheapest transaction in a busy mempool (which would be evicted first).
+func estimateFee(txSize uint64) FeeEstimate {
+	var stats MempoolStats
+	if mempool != nil {
+		stats = mempool.Stats()
+	}
+
+	estimate := FeeEstimate{BlockUtilization: averageBlockUtilization()}
+	if stats.MaxSize > 0 {
+		estimate.MempoolFullness = math.Min(float64(stats.Size)/float64(stats.MaxSize), 1)
+	}
+
+	estimate.Minimum = scaledFee(activeParameters.Fee_minimum, math.Max(estimate.MempoolFullness, estimate.BlockUtilization))
+	estimate.Suggested = estimate.Minimum
+	if estimate.MempoolFullness > FEE_MARKET_THRESHOLD {
+		if outbid := uint64(math.Floor(stats.MinFeePerByte*float64(txSize))) + 1; outbid > estimate.Suggested {
+			estimate.Suggested = outbid
+		}
+	}
+
+	return estimate
+}
+
+//The minimum fee a transaction has to pay to be included in a block produced by this miner.
+func effectiveFeeMinimum() uint64 {
+	return estimateFee(0).Minimum
+}
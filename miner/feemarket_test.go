@@ -0,0 +1,55 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestScaledFee(t *testing.T) {
+	if fee := scaledFee(10, FEE_MARKET_THRESHOLD); fee != 10 {
+		t.Errorf("Fee should not be scaled below the threshold: %v\n", fee)
+	}
+
+	if fee := scaledFee(10, 1); fee != 10*FEE_MARKET_MAX_MULTIPLIER {
+		t.Errorf("Fee should be scaled by the maximum multiplier at full pressure: %v\n", fee)
+	}
+
+	if low, high := scaledFee(10, 0.6), scaledFee(10, 0.8); low <= 10 || high <= low {
+		t.Errorf("Fee should grow with the pressure: %v vs. %v\n", low, high)
+	}
+}
+
+func TestEstimateFee(t *testing.T) {
+	previousMempool, previousUtilization := mempool, blockUtilization
+	defer func() { mempool, blockUtilization = previousMempool, previousUtilization }()
+
+	txSize := uint64(len(newMempoolTestTx([32]byte{}, 0, 1).Encode()))
+	mempool = NewMempool(4 * txSize)
+	blockUtilization = nil
+
+	if estimate := estimateFee(txSize); estimate.Minimum != activeParameters.Fee_minimum || estimate.Suggested != estimate.Minimum {
+		t.Errorf("Idle network should require the fee floor only: %+v\n", estimate)
+	}
+
+	//Full blocks raise the minimum fee.
+	for i := 0; i < FEE_MARKET_WINDOW; i++ {
+		recordBlockUtilization(&protocol.Block{Height: uint32(i), NrFundsTx: 1000})
+	}
+	if estimate := estimateFee(txSize); estimate.BlockUtilization != 1 || estimate.Minimum <= activeParameters.Fee_minimum {
+		t.Errorf("Full blocks should raise the minimum fee: %+v\n", estimate)
+	}
+
+	//A busy mempool requires outbidding its cheapest tx.
+	blockUtilization = nil
+	for i, fee := range []uint64{50, 60, 70} {
+		mempool.Add(newMempoolTestTx([32]byte{byte(i)}, 0, fee))
+	}
+	estimate := estimateFee(txSize)
+	if estimate.MempoolFullness != 0.75 || estimate.Suggested <= 50 {
+		t.Errorf("Suggested fee should outbid the cheapest tx in the mempool: %+v\n", estimate)
+	}
+	if estimate.Minimum != scaledFee(activeParameters.Fee_minimum, 0.75) {
+		t.Errorf("Minimum fee should be scaled by the mempool fullness: %+v\n", estimate)
+	}
+}
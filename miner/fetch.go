@@ -0,0 +1,60 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+//Txs and blocks missing locally are requested from the network. A request may go unanswered (the peers do not have the
+//data yet, the response got lost) or be answered with something else than what was requested, e.g., by a malicious
+//miner. fetch retries a request up to fetchRetries times and waits before every retry, starting with fetchBackoff and
+//doubling it each time. Every attempt requests the data from other random miners (see p2p/fetchpeers.go) and takes
+//the first response, so a retry falls back to other peers when the previous ones did not answer or answered with
+//invalid data.
+
+const (
+	FETCH_RETRIES_DEFAULT = 2
+	FETCH_BACKOFF_DEFAULT = 500 * time.Millisecond
+)
+
+var (
+	fetchRetries = FETCH_RETRIES_DEFAULT
+	fetchBackoff = FETCH_BACKOFF_DEFAULT
+)
+
+//Sets how many times a failed fetch is retried and the delay before the first retry, 0 retries disables them.
+func SetFetchRetries(retries int, backoff time.Duration) {
+	fetchRetries = retries
+	fetchBackoff = backoff
+}
+
+//Sends request and calls receive to wait for the response, receive returns true once the requested data has been
+//received and false if the timeout expired or the response was invalid. what describes the data in the errors.
+func fetch(ctx context.Context, what string, timeout time.Duration, request func() error, receive func(timeout <-chan time.Time) bool) error {
+	backoff := fetchBackoff
+	for attempt := 1; ; attempt++ {
+		if err := request(); err != nil {
+			return errors.New(fmt.Sprintf("%v could not be read: %v", what, err))
+		}
+
+		if receive(time.After(timeout)) {
+			return nil
+		}
+		if ctx.Err() != nil {
+			return errValidationCancelled
+		}
+		if attempt > fetchRetries {
+			return errors.New(fmt.Sprintf("%v fetch failed after %v attempts.", what, attempt))
+		}
+
+		logger.Printf("%v fetch failed (attempt %v), retrying in %v.", what, attempt, backoff)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return errValidationCancelled
+		}
+		backoff *= 2
+	}
+}
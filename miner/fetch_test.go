@@ -0,0 +1,65 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFetchRetries(t *testing.T) {
+	defer SetFetchRetries(FETCH_RETRIES_DEFAULT, FETCH_BACKOFF_DEFAULT)
+	SetFetchRetries(2, time.Millisecond)
+
+	//The first response is invalid, the retry succeeds.
+	requests := 0
+	err := fetch(context.Background(), "Tx", time.Second, func() error {
+		requests++
+		return nil
+	}, func(timeout <-chan time.Time) bool {
+		return requests == 2
+	})
+	if err != nil || requests != 2 {
+		t.Errorf("Fetch returned %v after %v requests, expected success after 2.", err, requests)
+	}
+
+	requests = 0
+	err = fetch(context.Background(), "Tx", time.Millisecond, func() error {
+		requests++
+		return nil
+	}, func(timeout <-chan time.Time) bool {
+		<-timeout
+		return false
+	})
+	if err == nil || requests != 3 {
+		t.Errorf("Fetch returned %v after %v requests, expected an error after 3.", err, requests)
+	}
+
+	//Failing to send the request is not retried.
+	requests = 0
+	err = fetch(context.Background(), "Tx", time.Second, func() error {
+		requests++
+		return errors.New("No peers.")
+	}, func(timeout <-chan time.Time) bool {
+		return true
+	})
+	if err == nil || requests != 1 {
+		t.Errorf("Fetch returned %v after %v requests, expected an error after 1.", err, requests)
+	}
+}
+
+func TestFetchCancelled(t *testing.T) {
+	defer SetFetchRetries(FETCH_RETRIES_DEFAULT, FETCH_BACKOFF_DEFAULT)
+	SetFetchRetries(2, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	err := fetch(ctx, "Tx", time.Millisecond, func() error {
+		return nil
+	}, func(timeout <-chan time.Time) bool {
+		cancel()
+		return false
+	})
+	if err != errValidationCancelled {
+		t.Errorf("Cancelled fetch returned %v, expected %v.", err, errValidationCancelled)
+	}
+}
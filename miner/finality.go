@@ -0,0 +1,87 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Blocks that would require rolling back more than maxRollbackDepth blocks are rejected. This protects the node from
+//long-range attacks (an attacker building a long alternative chain from an old block) and bounds the cost of
+//rollback(). Every checkpointInterval blocks, the block maxRollbackDepth below the new block becomes a finality
+//checkpoint, which is stored in the database. Blocks conflicting with the latest checkpoint are rejected even if the
+//depth limit would allow the rollback, e.g., after a restart with a higher limit.
+
+const (
+	MAX_ROLLBACK_DEPTH_DEFAULT  = 100 //Blocks
+	CHECKPOINT_INTERVAL_DEFAULT = 100 //Blocks
+)
+
+var (
+	maxRollbackDepth   = uint32(MAX_ROLLBACK_DEPTH_DEFAULT)
+	checkpointInterval = uint32(CHECKPOINT_INTERVAL_DEFAULT)
+)
+
+//A depth of 0 disables the limit and the checkpoints, an interval of 0 only disables the checkpoints.
+func SetFinality(maxDepth uint32, interval uint32) {
+	maxRollbackDepth = maxDepth
+	checkpointInterval = interval
+}
+
+//Checks whether the chain can be switched to a branch splitting off at the ancestor.
+func checkFinality(ancestor *protocol.Block) error {
+	if maxRollbackDepth == 0 || lastBlock == nil {
+		return nil
+	}
+
+	if lastBlock.Height > ancestor.Height+maxRollbackDepth {
+		countRejectedBlock(REJECTED_ROLLBACK_DEPTH)
+		return errors.New(fmt.Sprintf("Rollback of %v blocks exceeds the maximum rollback depth of %v blocks.", lastBlock.Height-ancestor.Height, maxRollbackDepth))
+	}
+
+	if height, hash, exists := storage.ReadLastCheckpoint(); exists && ancestor.Height < height {
+		countRejectedBlock(REJECTED_CHECKPOINT)
+		return errors.New(fmt.Sprintf("Block conflicts with the finality checkpoint at height %v (%x).", height, hash[0:8]))
+	}
+
+	return nil
+}
+
+//Checked for every block of a new branch whose predecessor is not a validated block, before the predecessor is
+//looked up or fetched. The branch is abandoned as soon as its ancestor can not be within the rollback depth or above
+//the latest checkpoint anymore, which bounds the walk back (and the blocks fetched for it) as well.
+func checkBranchFinality(block *protocol.Block) error {
+	if maxRollbackDepth == 0 || lastBlock == nil {
+		return nil
+	}
+
+	//The ancestor is below the block.
+	if block.Height == 0 || lastBlock.Height >= block.Height+maxRollbackDepth {
+		countRejectedBlock(REJECTED_ROLLBACK_DEPTH)
+		return errors.New(fmt.Sprintf("Branch at height %v exceeds the maximum rollback depth of %v blocks.", block.Height, maxRollbackDepth))
+	}
+
+	if height, hash, exists := storage.ReadLastCheckpoint(); exists && block.Height <= height {
+		countRejectedBlock(REJECTED_CHECKPOINT)
+		return errors.New(fmt.Sprintf("Branch at height %v conflicts with the finality checkpoint at height %v (%x).", block.Height, height, hash[0:8]))
+	}
+
+	return nil
+}
+
+//Returns the block that becomes a checkpoint with the given block, nil if the block does not create a checkpoint.
+func finalizedBlock(block *protocol.Block) *protocol.Block {
+	if maxRollbackDepth == 0 || checkpointInterval == 0 || block.Height <= maxRollbackDepth ||
+		(block.Height-maxRollbackDepth)%checkpointInterval != 0 {
+		return nil
+	}
+
+	finalized := block
+	for finalized != nil && finalized.Height > block.Height-maxRollbackDepth {
+		finalized = readHeader(finalized.PrevHash, finalized.PrevHashWithoutTx)
+	}
+
+	return finalized
+}
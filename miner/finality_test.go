@@ -0,0 +1,73 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestFinality(t *testing.T) {
+	defer SetFinality(MAX_ROLLBACK_DEPTH_DEFAULT, CHECKPOINT_INTERVAL_DEFAULT)
+	SetFinality(5, 2)
+
+	previous := lastBlock
+	defer func() { lastBlock = previous }()
+
+	var chain []*protocol.Block
+	for height := uint32(0); height < 12; height++ {
+		block := &protocol.Block{Hash: [32]byte{0xf1, byte(height)}, Height: height}
+		if height > 0 {
+			block.PrevHash = chain[height-1].Hash
+		}
+		chain = append(chain, block)
+		storage.WriteClosedBlock(block)
+		defer storage.DeleteClosedBlock(block.Hash)
+	}
+	defer storage.DeleteAllCheckpoints()
+
+	//Every second block finalizes the block 5 blocks below it.
+	if finalized := finalizedBlock(chain[7]); finalized == nil || finalized.Hash != chain[2].Hash {
+		t.Errorf("Block 7 does not finalize block 2: %v\n", finalized)
+	}
+	if finalizedBlock(chain[8]) != nil || finalizedBlock(chain[5]) != nil {
+		t.Error("Checkpoint created outside the interval.")
+	}
+
+	lastBlock = chain[11]
+	if err := checkFinality(chain[5]); err == nil {
+		t.Error("Rollback of 6 blocks accepted.")
+	}
+	if err := checkFinality(chain[6]); err != nil {
+		t.Errorf("Rollback of 5 blocks rejected: %v\n", err)
+	}
+
+	//The ancestor of a branch block is below it.
+	if err := checkBranchFinality(&protocol.Block{Height: 7}); err != nil {
+		t.Errorf("Branch at height 7 rejected: %v\n", err)
+	}
+	if err := checkBranchFinality(&protocol.Block{Height: 6}); err == nil {
+		t.Error("Branch at height 6 accepted.")
+	}
+	//The walk back is abandoned before the missing predecessor is requested.
+	if ancestor, _ := getNewChain(&protocol.Block{Hash: [32]byte{0xf2}, PrevHash: [32]byte{0xf3}, Height: 6}); ancestor != nil {
+		t.Errorf("Ancestor of a branch beyond the rollback depth found: %x\n", ancestor.Hash)
+	}
+
+	batch := storage.NewBatch()
+	batch.WriteCheckpoint(chain[7].Height, chain[7].Hash)
+	batch.Commit()
+
+	if err := checkFinality(chain[6]); err == nil {
+		t.Error("Rollback beyond the checkpoint accepted.")
+	}
+	if err := checkFinality(chain[7]); err != nil {
+		t.Errorf("Rollback up to the checkpoint rejected: %v\n", err)
+	}
+	if err := checkBranchFinality(&protocol.Block{Height: 7}); err == nil {
+		t.Error("Branch below the checkpoint accepted.")
+	}
+	if err := checkBranchFinality(&protocol.Block{Height: 8}); err != nil {
+		t.Errorf("Branch above the checkpoint rejected: %v\n", err)
+	}
+}
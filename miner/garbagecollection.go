@@ -0,0 +1,59 @@
+package miner
+
+import (
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Removes the copies of blocks which can not be rolled back anymore and are not part of the chain (see
+//storage/compact.go) in the background. The space is reused by bolt, compacting the database with the node stopped
+//returns it to the file system.
+
+const GC_INTERVAL_DEFAULT = 30 * time.Minute
+
+var gcInterval = GC_INTERVAL_DEFAULT
+
+//0 disables the garbage collection.
+func SetGarbageCollection(interval time.Duration) {
+	gcInterval = interval
+}
+
+func gcService() {
+	if gcInterval <= 0 {
+		return
+	}
+
+	for range time.Tick(gcInterval) {
+		if isShuttingDown() {
+			return
+		}
+
+		collectGarbage()
+	}
+}
+
+func collectGarbage() {
+	//No block must be validated or rolled back while the horizon is determined and the garbage removed.
+	blockValidation.Lock()
+	defer blockValidation.Unlock()
+
+	//Without a maximum rollback depth, no block is final.
+	if lastBlock == nil || maxRollbackDepth == 0 {
+		return
+	}
+
+	horizon, ok := aggregatableHeight(lastBlock.Height)
+	if !ok {
+		return
+	}
+
+	removed, err := storage.CollectGarbage(horizon)
+	if err != nil {
+		logger.Printf("Garbage collection failed: %v\n", err)
+		return
+	}
+	if removed > 0 {
+		logger.Printf("Garbage collection removed %v blocks below height %v.\n", removed, horizon)
+	}
+}
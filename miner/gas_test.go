@@ -0,0 +1,68 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/bazo-blockchain/bazo-miner/vm"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestContractCallGas(t *testing.T) {
+	pubKey, privKey, _ := ed25519.GenerateKey(nil)
+	var address [32]byte
+	copy(address[:], pubKey)
+	caller := &protocol.Account{Address: address, Balance: 1000}
+	from := caller.Hash()
+	storage.State.Set(from, caller)
+	defer storage.State.Delete(from)
+
+	contractAcc := &protocol.Account{Address: [32]byte{'g', 'a', 's'}, Contract: []byte{vm.PUSH, 1, 0, 8, vm.PUSH, 1, 0, 8, vm.ADD, vm.HALT}}
+	to := contractAcc.Hash()
+	storage.State.Set(to, contractAcc)
+	defer storage.State.Delete(to)
+
+	minerAcc := &protocol.Account{Address: [32]byte{'g', 'a', 's', 'm'}}
+	minerHash := minerAcc.Hash()
+	storage.State.Set(minerHash, minerAcc)
+	defer storage.State.Delete(minerHash)
+
+	//Not enough gas to execute the contract, the tx is included anyway.
+	tx, _ := protocol.ConstrContractCallTx(0, 10, 1, 5, 3, 0, from, to, protocol.NewKeySigner(privKey), []byte{0x01})
+	if !verifyFundsTx(tx) {
+		t.Fatal("Valid contract call was rejected.")
+	}
+
+	block := &protocol.Block{StateCopy: make(map[[32]byte]*protocol.Account)}
+	if err := addFundsTx(block, tx); err != nil || len(block.FundsTxData) != 1 || block.StateCopy[from].Balance != 1000-10-15 {
+		t.Fatalf("Contract call running out of gas not added to the block: %v\n", err)
+	}
+
+	if err := fundsStateChange([]*protocol.FundsTx{tx}, 0); err != nil {
+		t.Fatalf("Contract call rejected: %v\n", err)
+	}
+	if err := collectTxFees(nil, []*protocol.FundsTx{tx}, nil, nil, nil, nil, minerHash); err != nil {
+		t.Fatalf("Fees of contract call not collected: %v\n", err)
+	}
+
+	if caller.Balance != 1000-10-1-15 || contractAcc.Balance != 10 || minerAcc.Balance != 1+15 {
+		t.Errorf("Gas not charged: %v, %v\n", caller, minerAcc)
+	}
+
+	collectTxFeesRollback(nil, []*protocol.FundsTx{tx}, nil, nil, minerHash)
+	fundsStateChangeRollback([]*protocol.FundsTx{tx}, 0)
+	if caller.Balance != 1000 || caller.TxCnt != 0 || contractAcc.Balance != 0 || minerAcc.Balance != 0 {
+		t.Errorf("Gas not rolled back: %v, %v\n", caller, minerAcc)
+	}
+
+	free, _ := protocol.ConstrContractCallTx(0, 10, 1, 5, 0, 0, from, to, protocol.NewKeySigner(privKey), []byte{0x01})
+	if verifyFundsTx(free) {
+		t.Error("Contract call with free gas was verified.")
+	}
+
+	caller.Balance = 10 + 1 + 14
+	if err := fundsStateChange([]*protocol.FundsTx{tx}, 0); err == nil {
+		t.Error("Contract call without enough funds for the gas was accepted.")
+	}
+}
@@ -0,0 +1,243 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"io/ioutil"
+)
+
+//A genesis file specifies the initial state of a chain in JSON: the accounts with their balances, root status and
+//commitment keys, the system parameters (keyed by their names in the RPC interface, see rpcParameters) and the
+//commitment proof of the genesis block. All nodes of a network need the same genesis file, build-genesis creates one.
+//It is stored in the database at the first start, later starts use the stored one and reject a different one.
+//Without a genesis file, the root key passed at start is the only account of the initial state.
+
+type Genesis struct {
+	Accounts   []GenesisAccount  `json:"accounts"`
+	Parameters map[string]uint64 `json:"parameters,omitempty"`
+	//Hex encoded, included in the proof of stake of the first blocks. Signed with the root commitment key passed at
+	//start if empty.
+	CommitmentProof string `json:"commitmentProof,omitempty"`
+}
+
+type GenesisAccount struct {
+	Address       string `json:"address"` //Hex encoded public key
+	Balance       uint64 `json:"balance"`
+	Root          bool   `json:"root,omitempty"`
+	Staking       bool   `json:"staking,omitempty"`
+	CommitmentKey string `json:"commitmentKey,omitempty"` //Hex encoded RSA modulus or VRF key, needed for staking
+}
+
+var genesisParameterIds = map[string]uint8{
+	"feeMinimum":            protocol.FEE_MINIMUM_ID,
+	"blockSize":             protocol.BLOCK_SIZE_ID,
+	"diffInterval":          protocol.DIFF_INTERVAL_ID,
+	"blockInterval":         protocol.BLOCK_INTERVAL_ID,
+	"blockReward":           protocol.BLOCK_REWARD_ID,
+	"stakingMinimum":        protocol.STAKING_MINIMUM_ID,
+	"waitingMinimum":        protocol.WAITING_MINIMUM_ID,
+	"acceptedTimeDiff":      protocol.ACCEPTANCE_TIME_DIFF_ID,
+	"slashingWindowSize":    protocol.SLASHING_WINDOW_SIZE_ID,
+	"slashReward":           protocol.SLASHING_REWARD_ID,
+	"blockTriggerTxs":       protocol.BLOCK_TRIGGER_TXS_ID,
+	"blockTriggerTime":      protocol.BLOCK_TRIGGER_TIME_ID,
+	"iotRateCapacity":       protocol.IOT_RATE_CAPACITY_ID,
+	"iotRateRefill":         protocol.IOT_RATE_REFILL_ID,
+	"unbondingPeriod":       protocol.UNBONDING_PERIOD_ID,
+	"rsaCommitmentDeadline": protocol.RSA_COMMITMENT_DEADLINE_ID,
+	"slashFraction":         protocol.SLASH_FRACTION_ID,
+	"configQuorum":          protocol.CONFIG_QUORUM_ID,
+	"configActivationDelay": protocol.CONFIG_ACTIVATION_DELAY_ID,
+	"diffEmaWindow":         protocol.DIFF_EMA_WINDOW_ID,
+	"stateRootHeight":       protocol.STATE_ROOT_HEIGHT_ID,
+	"txOrderHeight":         protocol.TX_ORDER_HEIGHT_ID,
+	"aggTxAuthHeight":       protocol.AGG_TX_AUTH_HEIGHT_ID,
+	"medianTimeHeight":      protocol.MEDIAN_TIME_HEIGHT_ID,
+	"blockHashHeight":       protocol.BLOCK_HASH_HEIGHT_ID,
+	"delegationHeight":      protocol.DELEGATION_HEIGHT_ID,
+}
+
+//The genesis file passed at start, nil if none.
+var genesis *Genesis
+
+func SetGenesis(spec *Genesis) {
+	genesis = spec
+}
+
+func LoadGenesisFile(file string) (*Genesis, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	spec, err := ParseGenesis(data)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Invalid genesis file %v: %v", file, err))
+	}
+
+	return spec, nil
+}
+
+//Parses and checks a genesis specification.
+func ParseGenesis(data []byte) (*Genesis, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	spec := new(Genesis)
+	if err := decoder.Decode(spec); err != nil {
+		return nil, err
+	}
+
+	params := NewDefaultParameters()
+	if err := spec.applyParameters(&params); err != nil {
+		return nil, err
+	}
+
+	if _, err := spec.commitmentProof(); err != nil {
+		return nil, err
+	}
+
+	var nrRoots uint64
+	addresses := make(map[[32]byte]bool)
+	for _, account := range spec.Accounts {
+		acc, err := account.account()
+		if err != nil {
+			return nil, err
+		}
+
+		if addresses[acc.Address] {
+			return nil, errors.New(fmt.Sprintf("Account %x is specified twice.", acc.Address[0:8]))
+		}
+		addresses[acc.Address] = true
+
+		if acc.IsStaking && acc.Balance < params.Staking_minimum {
+			return nil, errors.New(fmt.Sprintf("Staking account %x has less than the staking minimum (%v).", acc.Address[0:8], params.Staking_minimum))
+		}
+
+		if account.Root {
+			nrRoots++
+		}
+	}
+
+	if nrRoots < params.Config_quorum {
+		return nil, errors.New(fmt.Sprintf("%v root accounts are specified, the config quorum requires %v.", nrRoots, params.Config_quorum))
+	}
+
+	return spec, nil
+}
+
+func (spec *Genesis) Encode() []byte {
+	encoded, _ := json.Marshal(spec)
+	return encoded
+}
+
+//Sets the parameters of the genesis file, which need to be within the bounds of the config txs.
+func (spec *Genesis) applyParameters(params *Parameters) error {
+	for name, value := range spec.Parameters {
+		id, exists := genesisParameterIds[name]
+		if !exists {
+			return errors.New(fmt.Sprintf("Unknown parameter %v.", name))
+		}
+
+		if !parameterBoundsChecking(id, value) {
+			return errors.New(fmt.Sprintf("Parameter %v is out of bounds: %v.", name, value))
+		}
+
+		configTxs := []*protocol.ConfigTx{{Id: id, Payload: value}}
+		CheckAndChangeParameters(params, &configTxs)
+	}
+
+	return nil
+}
+
+//Returns the commitment proof of the genesis block, nil if it is not specified.
+func (spec *Genesis) commitmentProof() (proof []byte, err error) {
+	if len(spec.CommitmentProof) == 0 {
+		return nil, nil
+	}
+
+	if proof, err = hex.DecodeString(spec.CommitmentProof); err != nil || len(proof) > crypto.COMM_PROOF_LENGTH {
+		return nil, errors.New("Invalid commitment proof.")
+	}
+
+	return proof, nil
+}
+
+func (account GenesisAccount) account() (*protocol.Account, error) {
+	var address [32]byte
+	if decoded, err := hex.DecodeString(account.Address); err != nil || len(decoded) != len(address) {
+		return nil, errors.New(fmt.Sprintf("Invalid account address %v.", account.Address))
+	} else {
+		copy(address[:], decoded)
+	}
+
+	if account.Balance > MAX_MONEY {
+		return nil, errors.New(fmt.Sprintf("Balance of account %x exceeds the maximum.", address[0:8]))
+	}
+
+	var commitmentKey [crypto.COMM_KEY_LENGTH]byte
+	decoded, err := hex.DecodeString(account.CommitmentKey)
+	if err != nil || len(decoded) > len(commitmentKey) {
+		return nil, errors.New(fmt.Sprintf("Invalid commitment key of account %x.", address[0:8]))
+	}
+	copy(commitmentKey[:], decoded)
+
+	if account.Staking && len(decoded) == 0 {
+		return nil, errors.New(fmt.Sprintf("Staking account %x has no commitment key.", address[0:8]))
+	}
+
+	acc := protocol.NewAccount(address, [32]byte{}, account.Balance, account.Staking, commitmentKey, nil, nil)
+	return &acc, nil
+}
+
+//Sets up the initial state and parameters from the genesis file, which is stored at the first start and loaded from
+//the database later. Returns false if the database was created without a genesis file.
+func initGenesis() (bool, error) {
+	stored := storage.ReadGenesis()
+	if stored == nil {
+		if genesis == nil {
+			return false, nil
+		}
+
+		//A database created with the root key passed at start can not be switched to a genesis file.
+		if storage.ReadLastClosedBlock() != nil {
+			return false, errors.New("The database was created without a genesis file.")
+		}
+
+		if err := storage.WriteGenesis(genesis.Encode()); err != nil {
+			return false, err
+		}
+	} else if genesis == nil {
+		var err error
+		if genesis, err = ParseGenesis(stored); err != nil {
+			return false, errors.New(fmt.Sprintf("Invalid genesis file in the database: %v", err))
+		}
+	} else if !bytes.Equal(genesis.Encode(), stored) {
+		return false, errors.New("The database was created with a different genesis file.")
+	}
+
+	if err := genesis.applyParameters(&parameterSlice[0]); err != nil {
+		return false, err
+	}
+
+	for _, account := range genesis.Accounts {
+		acc, err := account.account()
+		if err != nil {
+			return false, err
+		}
+
+		accHash := acc.Hash()
+		storage.State.Set(accHash, acc)
+		if account.Root {
+			storage.RootKeys[accHash] = acc
+		}
+	}
+
+	return true, nil
+}
@@ -0,0 +1,91 @@
+package miner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestParseGenesis(t *testing.T) {
+	rootPubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	root := hex.EncodeToString(rootPubKey)
+
+	spec, err := ParseGenesis([]byte(`{"accounts": [{"address": "` + root + `", "balance": 5000, "root": true, "staking": true, "commitmentKey": "0102"}], "parameters": {"feeMinimum": 5, "configQuorum": 1}}`))
+	if err != nil {
+		t.Fatalf("Valid genesis rejected: %v\n", err)
+	}
+
+	params := NewDefaultParameters()
+	spec.applyParameters(&params)
+	if params.Fee_minimum != 5 {
+		t.Errorf("Genesis parameter not applied: %v\n", params.Fee_minimum)
+	}
+
+	invalid := map[string]string{
+		"unknown field":      `{"accounts": [], "chainId": 1}`,
+		"unknown parameter":  `{"accounts": [{"address": "` + root + `", "balance": 1, "root": true}], "parameters": {"foo": 1}}`,
+		"out of bounds":      `{"accounts": [{"address": "` + root + `", "balance": 1, "root": true}], "parameters": {"blockSize": 1}}`,
+		"invalid address":    `{"accounts": [{"address": "0102", "balance": 1, "root": true}]}`,
+		"duplicate account":  `{"accounts": [{"address": "` + root + `", "balance": 1, "root": true}, {"address": "` + root + `", "balance": 1}]}`,
+		"no commitment key":  `{"accounts": [{"address": "` + root + `", "balance": 5000, "root": true, "staking": true}]}`,
+		"below staking min":  `{"accounts": [{"address": "` + root + `", "balance": 1, "root": true, "staking": true, "commitmentKey": "0102"}]}`,
+		"quorum not reached": `{"accounts": [{"address": "` + root + `", "balance": 1, "root": true}], "parameters": {"configQuorum": 2}}`,
+		"invalid proof":      `{"accounts": [{"address": "` + root + `", "balance": 1, "root": true}], "commitmentProof": "xyz"}`,
+	}
+	for reason, data := range invalid {
+		if _, err := ParseGenesis([]byte(data)); err == nil {
+			t.Errorf("Genesis accepted with %v.\n", reason)
+		}
+	}
+}
+
+func TestInitGenesis(t *testing.T) {
+	prevGenesis, prevParameterSlice, prevRootKeys := genesis, parameterSlice, storage.RootKeys
+	defer func() {
+		genesis, parameterSlice, storage.RootKeys = prevGenesis, prevParameterSlice, prevRootKeys
+	}()
+	defer storage.DeleteGenesis()
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	storage.RootKeys = make(map[[32]byte]*protocol.Account)
+
+	rootPubKey, _, _ := ed25519.GenerateKey(rand.Reader)
+	spec, _ := ParseGenesis([]byte(`{"accounts": [{"address": "` + hex.EncodeToString(rootPubKey) + `", "balance": 1, "root": true}]}`))
+
+	block := &protocol.Block{Hash: [32]byte{'g', 'e', 'n'}}
+	storage.WriteLastClosedBlock(block)
+	defer storage.DeleteLastClosedBlock(block.Hash)
+
+	genesis = nil
+	if withGenesis, err := initGenesis(); withGenesis || err != nil {
+		t.Errorf("Database without genesis not accepted: %v\n", err)
+	}
+
+	genesis = spec
+	if _, err := initGenesis(); err == nil {
+		t.Error("Genesis accepted for a database created without one.\n")
+	}
+
+	//The stored genesis is used if none is passed.
+	storage.WriteGenesis(spec.Encode())
+	genesis = nil
+	if withGenesis, err := initGenesis(); !withGenesis || err != nil {
+		t.Errorf("Stored genesis not loaded: %v\n", err)
+	}
+	var address [32]byte
+	copy(address[:], rootPubKey)
+	accHash := protocol.SerializeHashContent(address)
+	if storage.State.Get(accHash) == nil || storage.RootKeys[accHash] == nil {
+		t.Error("Genesis root account not set up.\n")
+	}
+	storage.State.Delete(accHash)
+
+	other, _ := ParseGenesis([]byte(`{"accounts": [{"address": "` + hex.EncodeToString(rootPubKey) + `", "balance": 2, "root": true}]}`))
+	genesis = other
+	if _, err := initGenesis(); err == nil {
+		t.Error("Genesis accepted for a database created with another one.\n")
+	}
+}
@@ -0,0 +1,459 @@
+//go:build grpc
+// +build grpc
+
+package miner
+
+import (
+	"context"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/protocol/pb"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"net"
+)
+
+//gRPC interface, offering the same tx submission and chain queries as the JSON-RPC interface with the messages
+//defined in protocol/pb/bazo.proto.
+
+type grpcServer struct {
+	pb.UnimplementedBazoServer
+}
+
+//Starts the gRPC server listening at ipport. The call blocks as long as the server is running.
+func StartGRPCServer(ipport string) error {
+	listener, err := net.Listen("tcp", ipport)
+	if err != nil {
+		return err
+	}
+
+	server := grpc.NewServer()
+	pb.RegisterBazoServer(server, &grpcServer{})
+
+	return server.Serve(listener)
+}
+
+func (*grpcServer) SubmitTx(ctx context.Context, req *pb.SubmitTxRequest) (*pb.SubmitTxResponse, error) {
+	var tx protocol.Transaction
+	var brdcstType uint8
+	switch {
+	case req.GetFunds() != nil:
+		tx, brdcstType = fundsTxFromPB(req.GetFunds()), p2p.FUNDSTX_BRDCST
+	case req.GetAcc() != nil:
+		tx, brdcstType = accTxFromPB(req.GetAcc()), p2p.ACCTX_BRDCST
+	case req.GetConfig() != nil:
+		tx, brdcstType = configTxFromPB(req.GetConfig()), p2p.CONFIGTX_BRDCST
+	case req.GetStake() != nil:
+		tx, brdcstType = stakeTxFromPB(req.GetStake()), p2p.STAKETX_BRDCST
+	case req.GetIot() != nil:
+		tx, brdcstType = iotTxFromPB(req.GetIot()), p2p.IOTTX_BRDCST
+	case req.GetContract() != nil:
+		tx, brdcstType = contractTxFromPB(req.GetContract()), p2p.CONTRACTTX_BRDCST
+	default:
+		return nil, status.Error(codes.InvalidArgument, "Request contains no transaction.")
+	}
+
+	if err := submitTx(tx, tx.Encode(), brdcstType); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	txHash := tx.Hash()
+	return &pb.SubmitTxResponse{TxHash: txHash[:]}, nil
+}
+
+func (*grpcServer) GetTip(ctx context.Context, req *pb.GetTipRequest) (*pb.Block, error) {
+	blockValidation.Lock()
+	tip := lastBlock
+	blockValidation.Unlock()
+
+	if tip == nil {
+		return nil, status.Error(codes.Unavailable, "Node is not synchronized yet.")
+	}
+
+	return blockToPB(tip), nil
+}
+
+func (*grpcServer) GetBlock(ctx context.Context, req *pb.HashRequest) (*pb.Block, error) {
+	hash, err := hashFromPB(req.GetHash())
+	if err != nil {
+		return nil, err
+	}
+
+	block := storage.ReadClosedBlock(hash)
+	if block == nil {
+		return nil, status.Error(codes.NotFound, fmt.Sprintf("Block (%x) not found.", hash[0:8]))
+	}
+
+	return blockToPB(block), nil
+}
+
+func (*grpcServer) GetTx(ctx context.Context, req *pb.HashRequest) (*pb.Transaction, error) {
+	hash, err := hashFromPB(req.GetHash())
+	if err != nil {
+		return nil, err
+	}
+
+	tx := storage.ReadClosedTx(hash)
+	if tx == nil {
+		tx = storage.ReadOpenTx(hash)
+	}
+
+	switch tx := tx.(type) {
+	case *protocol.FundsTx:
+		return &pb.Transaction{Tx: &pb.Transaction_Funds{Funds: fundsTxToPB(tx)}}, nil
+	case *protocol.AccTx:
+		return &pb.Transaction{Tx: &pb.Transaction_Acc{Acc: accTxToPB(tx)}}, nil
+	case *protocol.ConfigTx:
+		return &pb.Transaction{Tx: &pb.Transaction_Config{Config: configTxToPB(tx)}}, nil
+	case *protocol.StakeTx:
+		return &pb.Transaction{Tx: &pb.Transaction_Stake{Stake: stakeTxToPB(tx)}}, nil
+	case *protocol.AggTx:
+		return &pb.Transaction{Tx: &pb.Transaction_Agg{Agg: aggTxToPB(tx)}}, nil
+	case *protocol.IotTx:
+		return &pb.Transaction{Tx: &pb.Transaction_Iot{Iot: iotTxToPB(tx)}}, nil
+	case *protocol.ContractTx:
+		return &pb.Transaction{Tx: &pb.Transaction_Contract{Contract: contractTxToPB(tx)}}, nil
+	}
+
+	return nil, status.Error(codes.NotFound, fmt.Sprintf("Transaction (%x) not found.", hash[0:8]))
+}
+
+func (*grpcServer) GetAccount(ctx context.Context, req *pb.HashRequest) (*pb.Account, error) {
+	address, err := hashFromPB(req.GetHash())
+	if err != nil {
+		return nil, err
+	}
+
+	acc, err := storage.GetAccount(address)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+
+	return &pb.Account{
+		Address:            acc.Address[:],
+		Issuer:             acc.Issuer[:],
+		Balance:            acc.Balance,
+		TxCnt:              acc.TxCnt,
+		IsStaking:          acc.IsStaking,
+		StakingBlockHeight: acc.StakingBlockHeight,
+		IsRoot:             storage.IsRootKey(address),
+		CommitmentKey:      acc.CommitmentKey[:],
+		Contract:           acc.Contract,
+		ContractVariables:  variablesToPB(acc.ContractVariables),
+		Threshold:          uint32(acc.Threshold),
+		Cosigners:          hashesToPB(acc.Cosigners),
+		Device:             deviceToPB(acc.Device),
+		IotTokens:          acc.IotTokens,
+		IotRefillHeight:    acc.IotRefillHeight,
+		UnbondingHeight:    acc.UnbondingHeight,
+	}, nil
+}
+
+func hashFromPB(encoded []byte) (hash [32]byte, err error) {
+	if len(encoded) != 32 {
+		return hash, status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid hash: %x", encoded))
+	}
+	copy(hash[:], encoded)
+
+	return hash, nil
+}
+
+func hashesToPB(hashes [][32]byte) (encoded [][]byte) {
+	for _, hash := range hashes {
+		encoded = append(encoded, append([]byte{}, hash[:]...))
+	}
+
+	return encoded
+}
+
+func hashesFromPB(encoded [][]byte) (hashes [][32]byte) {
+	for _, hash := range encoded {
+		var decoded [32]byte
+		copy(decoded[:], hash)
+		hashes = append(hashes, decoded)
+	}
+
+	return hashes
+}
+
+func variablesToPB(variables []protocol.ByteArray) (encoded [][]byte) {
+	for _, variable := range variables {
+		encoded = append(encoded, []byte(variable))
+	}
+
+	return encoded
+}
+
+func variablesFromPB(encoded [][]byte) (variables []protocol.ByteArray) {
+	for _, variable := range encoded {
+		variables = append(variables, protocol.ByteArray(variable))
+	}
+
+	return variables
+}
+
+func deviceToPB(device *protocol.DeviceInfo) *pb.DeviceInfo {
+	if device == nil {
+		return nil
+	}
+
+	return &pb.DeviceInfo{
+		Type:      device.Type,
+		Owner:     device.Owner[:],
+		RateLimit: device.RateLimit,
+	}
+}
+
+func deviceFromPB(msg *pb.DeviceInfo) *protocol.DeviceInfo {
+	if msg == nil {
+		return nil
+	}
+
+	device := &protocol.DeviceInfo{
+		Type:      msg.Type,
+		RateLimit: msg.RateLimit,
+	}
+	copy(device.Owner[:], msg.Owner)
+
+	return device
+}
+
+func blockToPB(block *protocol.Block) *pb.Block {
+	return &pb.Block{
+		Header:                         uint32(block.Header),
+		Hash:                           block.Hash[:],
+		PrevHash:                       block.PrevHash[:],
+		HashWithoutTx:                  block.HashWithoutTx[:],
+		PrevHashWithoutTx:              block.PrevHashWithoutTx[:],
+		Height:                         block.Height,
+		Beneficiary:                    block.Beneficiary[:],
+		Aggregated:                     block.Aggregated,
+		Nonce:                          block.Nonce[:],
+		Timestamp:                      block.Timestamp,
+		MerkleRoot:                     block.MerkleRoot[:],
+		CommitmentProof:                block.CommitmentProof[:],
+		SlashedAddress:                 block.SlashedAddress[:],
+		ConflictingBlockHash1:          block.ConflictingBlockHash1[:],
+		ConflictingBlockHash2:          block.ConflictingBlockHash2[:],
+		ConflictingBlockHashWithoutTx1: block.ConflictingBlockHashWithoutTx1[:],
+		ConflictingBlockHashWithoutTx2: block.ConflictingBlockHashWithoutTx2[:],
+		AccTxData:                      hashesToPB(block.AccTxData),
+		FundsTxData:                    hashesToPB(block.FundsTxData),
+		ConfigTxData:                   hashesToPB(block.ConfigTxData),
+		StakeTxData:                    hashesToPB(block.StakeTxData),
+		AggTxData:                      hashesToPB(block.AggTxData),
+		IotTxData:                      hashesToPB(block.IoTTxData),
+		StateRoot:                      block.StateRoot[:],
+		ContractTxData:                 hashesToPB(block.ContractTxData),
+	}
+}
+
+func fundsTxToPB(tx *protocol.FundsTx) *pb.FundsTx {
+	msg := &pb.FundsTx{
+		Header:     uint32(tx.Header),
+		Amount:     tx.Amount,
+		Fee:        tx.Fee,
+		TxCnt:      tx.TxCnt,
+		From:       tx.From[:],
+		To:         tx.To[:],
+		Sig:        tx.Sig[:],
+		Aggregated: tx.Aggregated,
+		Data:       tx.Data,
+		LockUntil:  tx.LockUntil,
+		GasLimit:   tx.GasLimit,
+		GasPrice:   tx.GasPrice,
+	}
+	for _, cosig := range tx.Cosigs {
+		msg.Cosigs = append(msg.Cosigs, &pb.Cosignature{Index: uint32(cosig.Index), Sig: append([]byte{}, cosig.Sig[:]...)})
+	}
+
+	return msg
+}
+
+func fundsTxFromPB(msg *pb.FundsTx) *protocol.FundsTx {
+	tx := &protocol.FundsTx{
+		Header:     byte(msg.Header),
+		Amount:     msg.Amount,
+		Fee:        msg.Fee,
+		TxCnt:      msg.TxCnt,
+		Aggregated: msg.Aggregated,
+		Data:       msg.Data,
+		LockUntil:  msg.LockUntil,
+		GasLimit:   msg.GasLimit,
+		GasPrice:   msg.GasPrice,
+	}
+	copy(tx.From[:], msg.From)
+	copy(tx.To[:], msg.To)
+	copy(tx.Sig[:], msg.Sig)
+	for _, cosigMsg := range msg.Cosigs {
+		cosig := protocol.Cosignature{Index: uint8(cosigMsg.Index)}
+		copy(cosig.Sig[:], cosigMsg.Sig)
+		tx.Cosigs = append(tx.Cosigs, cosig)
+	}
+
+	return tx
+}
+
+func accTxToPB(tx *protocol.AccTx) *pb.AccTx {
+	return &pb.AccTx{
+		Header:            uint32(tx.Header),
+		Issuer:            tx.Issuer[:],
+		Fee:               tx.Fee,
+		PubKey:            tx.PubKey[:],
+		Sig:               tx.Sig[:],
+		Amount:            tx.Amount,
+		Contract:          tx.Contract,
+		ContractVariables: variablesToPB(tx.ContractVariables),
+		Threshold:         uint32(tx.Threshold),
+		Cosigners:         hashesToPB(tx.Cosigners),
+		Device:            deviceToPB(tx.Device),
+	}
+}
+
+func accTxFromPB(msg *pb.AccTx) *protocol.AccTx {
+	tx := &protocol.AccTx{
+		Header:            byte(msg.Header),
+		Fee:               msg.Fee,
+		Amount:            msg.Amount,
+		Contract:          msg.Contract,
+		ContractVariables: variablesFromPB(msg.ContractVariables),
+		Threshold:         uint8(msg.Threshold),
+		Cosigners:         hashesFromPB(msg.Cosigners),
+		Device:            deviceFromPB(msg.Device),
+	}
+	copy(tx.Issuer[:], msg.Issuer)
+	copy(tx.PubKey[:], msg.PubKey)
+	copy(tx.Sig[:], msg.Sig)
+
+	return tx
+}
+
+func configTxToPB(tx *protocol.ConfigTx) *pb.ConfigTx {
+	msg := &pb.ConfigTx{
+		Header:  uint32(tx.Header),
+		Id:      uint32(tx.Id),
+		Payload: tx.Payload,
+		Fee:     tx.Fee,
+		TxCnt:   uint32(tx.TxCnt),
+		Sig:     tx.Sig[:],
+		RootKey: tx.RootKey[:],
+	}
+	for _, cosig := range tx.Cosigs {
+		msg.Cosigs = append(msg.Cosigs, &pb.ConfigCosignature{Root: append([]byte{}, cosig.Root[:]...), Sig: append([]byte{}, cosig.Sig[:]...)})
+	}
+
+	return msg
+}
+
+func configTxFromPB(msg *pb.ConfigTx) *protocol.ConfigTx {
+	tx := &protocol.ConfigTx{
+		Header:  byte(msg.Header),
+		Id:      uint8(msg.Id),
+		Payload: msg.Payload,
+		Fee:     msg.Fee,
+		TxCnt:   uint8(msg.TxCnt),
+	}
+	copy(tx.Sig[:], msg.Sig)
+	copy(tx.RootKey[:], msg.RootKey)
+	for _, cosigMsg := range msg.Cosigs {
+		var cosig protocol.ConfigCosignature
+		copy(cosig.Root[:], cosigMsg.Root)
+		copy(cosig.Sig[:], cosigMsg.Sig)
+		tx.Cosigs = append(tx.Cosigs, cosig)
+	}
+
+	return tx
+}
+
+func stakeTxToPB(tx *protocol.StakeTx) *pb.StakeTx {
+	return &pb.StakeTx{
+		Header:        uint32(tx.Header),
+		Fee:           tx.Fee,
+		IsStaking:     tx.IsStaking,
+		Account:       tx.Account[:],
+		Sig:           tx.Sig[:],
+		CommitmentKey: tx.CommitmentKey[:],
+	}
+}
+
+func stakeTxFromPB(msg *pb.StakeTx) *protocol.StakeTx {
+	tx := &protocol.StakeTx{
+		Header:    byte(msg.Header),
+		Fee:       msg.Fee,
+		IsStaking: msg.IsStaking,
+	}
+	copy(tx.Account[:], msg.Account)
+	copy(tx.Sig[:], msg.Sig)
+	copy(tx.CommitmentKey[:], msg.CommitmentKey)
+
+	return tx
+}
+
+func aggTxToPB(tx *protocol.AggTx) *pb.AggTx {
+	return &pb.AggTx{
+		Amount:        tx.Amount,
+		Fee:           tx.Fee,
+		From:          hashesToPB(tx.From),
+		To:            hashesToPB(tx.To),
+		AggregatedTxs: hashesToPB(tx.AggregatedTxSlice),
+	}
+}
+
+func iotTxToPB(tx *protocol.IotTx) *pb.IotTx {
+	return &pb.IotTx{
+		Header:   uint32(tx.Header),
+		TxCnt:    tx.TxCnt,
+		From:     tx.From[:],
+		To:       tx.To[:],
+		Sig:      tx.Sig[:],
+		Data:     tx.Data,
+		Fee:      tx.Fee,
+		Sequence: tx.Sequence,
+	}
+}
+
+func iotTxFromPB(msg *pb.IotTx) *protocol.IotTx {
+	tx := &protocol.IotTx{
+		Header:   byte(msg.Header),
+		TxCnt:    msg.TxCnt,
+		Data:     msg.Data,
+		Fee:      msg.Fee,
+		Sequence: msg.Sequence,
+	}
+	copy(tx.From[:], msg.From)
+	copy(tx.To[:], msg.To)
+	copy(tx.Sig[:], msg.Sig)
+
+	return tx
+}
+
+func contractTxToPB(tx *protocol.ContractTx) *pb.ContractTx {
+	return &pb.ContractTx{
+		Header:            uint32(tx.Header),
+		From:              tx.From[:],
+		TxCnt:             tx.TxCnt,
+		Fee:               tx.Fee,
+		GasLimit:          tx.GasLimit,
+		Contract:          tx.Contract,
+		ContractVariables: variablesToPB(tx.ContractVariables),
+		Sig:               tx.Sig[:],
+	}
+}
+
+func contractTxFromPB(msg *pb.ContractTx) *protocol.ContractTx {
+	tx := &protocol.ContractTx{
+		Header:            byte(msg.Header),
+		TxCnt:             msg.TxCnt,
+		Fee:               msg.Fee,
+		GasLimit:          msg.GasLimit,
+		Contract:          msg.Contract,
+		ContractVariables: variablesFromPB(msg.ContractVariables),
+	}
+	copy(tx.From[:], msg.From)
+	copy(tx.Sig[:], msg.Sig)
+
+	return tx
+}
@@ -0,0 +1,13 @@
+//go:build !grpc
+// +build !grpc
+
+package miner
+
+import (
+	"errors"
+)
+
+//Without the grpc build tag, the generated protobuf code and the gRPC dependency are not compiled in.
+func StartGRPCServer(ipport string) error {
+	return errors.New("Node built without gRPC support, run go generate ./protocol/pb and build with -tags grpc.")
+}
@@ -0,0 +1,39 @@
+//go:build grpc
+// +build grpc
+
+package miner
+
+import (
+	"bytes"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"testing"
+)
+
+//The conversion from and to the protobuf messages must not lose any field, otherwise the signature of submitted txs
+//does not match anymore.
+func TestGRPCTxConversion(t *testing.T) {
+	fundsTx := &protocol.FundsTx{Header: 1, Amount: 10, Fee: 2, TxCnt: 3, From: [32]byte{1}, To: [32]byte{2}, Sig: [64]byte{3},
+		Data: []byte{4}, Cosigs: []protocol.Cosignature{{Index: 1, Sig: [64]byte{5}}}, LockUntil: 6, GasLimit: 7, GasPrice: 8}
+	if !bytes.Equal(fundsTxFromPB(fundsTxToPB(fundsTx)).Encode(), fundsTx.Encode()) {
+		t.Errorf("FundsTx changed by the conversion\n")
+	}
+
+	accTx := &protocol.AccTx{Header: 1, Issuer: [32]byte{1}, Fee: 2, PubKey: [32]byte{3}, Sig: [64]byte{4}, Amount: 5,
+		Contract: []byte{6}, ContractVariables: []protocol.ByteArray{{7}}, Threshold: 2, Cosigners: [][32]byte{{8}, {9}},
+		Device: &protocol.DeviceInfo{Type: "sensor", Owner: [32]byte{10}, RateLimit: 11}}
+	if !bytes.Equal(accTxFromPB(accTxToPB(accTx)).Encode(), accTx.Encode()) {
+		t.Errorf("AccTx changed by the conversion\n")
+	}
+
+	configTx := &protocol.ConfigTx{Header: 1, Id: 2, Payload: 3, Fee: 4, TxCnt: 5, Sig: [64]byte{6},
+		Cosigs: []protocol.ConfigCosignature{{Root: [32]byte{7}, Sig: [64]byte{8}}}, RootKey: [32]byte{9}}
+	if !bytes.Equal(configTxFromPB(configTxToPB(configTx)).Encode(), configTx.Encode()) {
+		t.Errorf("ConfigTx changed by the conversion\n")
+	}
+
+	contractTx := &protocol.ContractTx{Header: 1, From: [32]byte{2}, TxCnt: 3, Fee: 4, GasLimit: 5, Contract: []byte{6},
+		ContractVariables: []protocol.ByteArray{{7}}, Sig: [64]byte{8}}
+	if !bytes.Equal(contractTxFromPB(contractTxToPB(contractTx)).Encode(), contractTx.Encode()) {
+		t.Errorf("ContractTx changed by the conversion\n")
+	}
+}
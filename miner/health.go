@@ -0,0 +1,74 @@
+package miner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//The /health endpoint of the RPC server reports the sync status of the node for monitoring and orchestration, e.g.,
+//as a Kubernetes readiness probe. It responds with 200 if the node is up to date (it validated the last blocks as they
+//arrived, see validate) and ready to produce blocks (see readiness.go), with 503 otherwise. The last block which could
+//not be validated is reported as well but does not make the node unhealthy, any peer can send an invalid block.
+
+type rpcHealth struct {
+	rpcSyncStatus
+	UpToDate bool `json:"uptodate"`
+	//The last validation error, the block and when it occurred, omitted if every block has been valid so far.
+	LastValidationError     string `json:"lastValidationError,omitempty"`
+	LastInvalidBlock        string `json:"lastInvalidBlock,omitempty"`
+	LastValidationErrorTime string `json:"lastValidationErrorTime,omitempty"`
+}
+
+//Guarded by blockValidation.
+var lastValidationError struct {
+	err       error
+	blockHash [32]byte
+	time      time.Time
+}
+
+//Called by validate while it holds blockValidation.
+func recordValidationError(block *protocol.Block, err error) {
+	if err == nil {
+		return
+	}
+
+	lastValidationError.err = err
+	lastValidationError.blockHash = block.Hash
+	lastValidationError.time = time.Now()
+}
+
+func handleHealth(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET requests are supported.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	blockValidation.Lock()
+	tip := lastBlock
+	health := rpcHealth{UpToDate: uptodate}
+	if lastValidationError.err != nil {
+		health.LastValidationError = lastValidationError.err.Error()
+		health.LastInvalidBlock = hex.EncodeToString(lastValidationError.blockHash[:])
+		health.LastValidationErrorTime = lastValidationError.time.UTC().Format(time.RFC3339)
+	}
+	blockValidation.Unlock()
+
+	if tip != nil {
+		health.rpcSyncStatus = newSyncStatus(tip)
+	} else {
+		health.NotReady = "Node is not synchronized yet."
+	}
+
+	code := http.StatusOK
+	if tip == nil || !health.UpToDate || !health.Ready {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(health)
+}
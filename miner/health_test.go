@@ -0,0 +1,58 @@
+package miner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func getHealth(t *testing.T) (health rpcHealth, code int) {
+	recorder := httptest.NewRecorder()
+	handleHealth(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+	if err := json.NewDecoder(recorder.Body).Decode(&health); err != nil {
+		t.Fatalf("Invalid health response: %v", err)
+	}
+
+	return health, recorder.Code
+}
+
+func TestHealth(t *testing.T) {
+	defer func(block *protocol.Block, upToDate bool) { lastBlock, uptodate = block, upToDate }(lastBlock, uptodate)
+	defer func(peers uint32, distance uint32) { SetProductionSafeguard(peers, distance) }(minPeers, maxTipDistance)
+	defer func() { lastValidationError.err = nil }()
+	SetProductionSafeguard(0, 0)
+
+	lastBlock = nil
+	if health, code := getHealth(t); code != http.StatusServiceUnavailable || health.NotReady == "" {
+		t.Errorf("Node without a block reported as healthy (%v): %v", code, health)
+	}
+
+	lastBlock = &protocol.Block{Hash: [32]byte{0x4e, 0x01}, Height: 12}
+	uptodate = false
+	if health, code := getHealth(t); code != http.StatusServiceUnavailable || health.Height != 12 || health.UpToDate {
+		t.Errorf("Syncing node reported as healthy (%v): %v", code, health)
+	}
+
+	//An invalid block is reported, but the node stays healthy.
+	uptodate = true
+	invalid := &protocol.Block{Hash: [32]byte{0x4e, 0x02}, Height: 13}
+	recordValidationError(invalid, errors.New("Invalid block."))
+	health, code := getHealth(t)
+	if code != http.StatusOK || !health.UpToDate || !health.Ready || health.Hash != hex.EncodeToString(lastBlock.Hash[:]) {
+		t.Errorf("Synced node reported as unhealthy (%v): %v", code, health)
+	}
+	if health.LastValidationError != "Invalid block." || health.LastInvalidBlock != hex.EncodeToString(invalid.Hash[:]) || health.LastValidationErrorTime == "" {
+		t.Errorf("Last validation error %v of block %v, expected the invalid block.", health.LastValidationError, health.LastInvalidBlock)
+	}
+
+	//Not enough peers to produce blocks.
+	SetProductionSafeguard(1000, 0)
+	if health, code := getHealth(t); code != http.StatusServiceUnavailable || health.Ready || health.NotReady == "" {
+		t.Errorf("Node without peers reported as healthy (%v): %v", code, health)
+	}
+}
@@ -0,0 +1,94 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"sync"
+	"time"
+)
+
+//Flaky gateways retransmit readings, often re-signed with a new txCnt. The device-supplied sequence of an IoT tx
+//identifies the reading, a second tx with the same (device, sequence) pair within the window is rejected at mempool
+//admission and when preparing a block. Resending the identical tx (same hash) is not a duplicate.
+//Like tx policies, this is local to the node and not applied when validating blocks of other miners, as the window
+//depends on the local clock.
+
+const IOT_DEDUP_DEFAULT_WINDOW = 10 * time.Minute
+
+type iotReading struct {
+	device   [32]byte
+	sequence uint64
+}
+
+type iotReadingEntry struct {
+	hash [32]byte
+	seen time.Time
+}
+
+var (
+	iotDedupWindow   = IOT_DEDUP_DEFAULT_WINDOW
+	iotReadings      = make(map[iotReading]iotReadingEntry)
+	iotReadingsMutex = &sync.Mutex{}
+	iotLastPurge     time.Time
+)
+
+//A window of 0 disables the deduplication.
+func SetIoTDedupWindow(window time.Duration) {
+	iotReadingsMutex.Lock()
+	defer iotReadingsMutex.Unlock()
+
+	iotDedupWindow = window
+	if window == 0 {
+		iotReadings = make(map[iotReading]iotReadingEntry)
+	}
+}
+
+func checkIoTDuplicate(tx *protocol.IotTx) error {
+	iotReadingsMutex.Lock()
+	defer iotReadingsMutex.Unlock()
+
+	if tx.Sequence == 0 || iotDedupWindow == 0 {
+		return nil
+	}
+
+	now := time.Now()
+	purgeIoTReadings(now)
+
+	entry, exists := iotReadings[iotReading{tx.From, tx.Sequence}]
+	if exists && entry.hash != tx.Hash() && now.Sub(entry.seen) <= iotDedupWindow {
+		return errors.New(fmt.Sprintf("Duplicate IoT reading: sequence %v of device %x already seen in tx %x.", tx.Sequence, tx.From[0:8], entry.hash[0:8]))
+	}
+
+	return nil
+}
+
+//Records the reading of the tx. The first tx seen for a (device, sequence) pair is kept until the window expires.
+func recordIoTReading(tx *protocol.IotTx) {
+	iotReadingsMutex.Lock()
+	defer iotReadingsMutex.Unlock()
+
+	if tx.Sequence == 0 || iotDedupWindow == 0 {
+		return
+	}
+
+	now := time.Now()
+	reading := iotReading{tx.From, tx.Sequence}
+	if entry, exists := iotReadings[reading]; !exists || now.Sub(entry.seen) > iotDedupWindow {
+		iotReadings[reading] = iotReadingEntry{tx.Hash(), now}
+	}
+}
+
+//Iterating the whole map on every check would be wasteful, expired readings are dropped at most once per second.
+func purgeIoTReadings(now time.Time) {
+	if now.Sub(iotLastPurge) < time.Second {
+		return
+	}
+	iotLastPurge = now
+
+	for reading, entry := range iotReadings {
+		if now.Sub(entry.seen) > iotDedupWindow {
+			delete(iotReadings, reading)
+		}
+	}
+}
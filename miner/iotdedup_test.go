@@ -0,0 +1,122 @@
+package miner
+
+import (
+	"crypto/rand"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+	"testing"
+	"time"
+)
+
+func TestIoTDuplicate(t *testing.T) {
+	SetIoTDedupWindow(time.Minute)
+	defer SetIoTDedupWindow(IOT_DEDUP_DEFAULT_WINDOW)
+
+	device := [32]byte{0x42}
+	reading := &protocol.IotTx{TxCnt: 0, From: device, Data: []byte("21.5"), Sequence: 7}
+	retransmission := &protocol.IotTx{TxCnt: 1, From: device, Data: []byte("21.5"), Sequence: 7}
+
+	if err := checkIoTDuplicate(reading); err != nil {
+		t.Errorf("First reading rejected: %v\n", err)
+	}
+	recordIoTReading(reading)
+
+	if err := checkIoTDuplicate(reading); err != nil {
+		t.Errorf("Resending the same tx rejected: %v\n", err)
+	}
+
+	if err := checkIoTDuplicate(retransmission); err == nil {
+		t.Error("Retransmitted reading not rejected.\n")
+	}
+
+	if err := checkIoTDuplicate(&protocol.IotTx{TxCnt: 1, From: device, Sequence: 8}); err != nil {
+		t.Errorf("Next reading rejected: %v\n", err)
+	}
+
+	if err := checkIoTDuplicate(&protocol.IotTx{TxCnt: 1, From: [32]byte{0x43}, Sequence: 7}); err != nil {
+		t.Errorf("Reading of other device rejected: %v\n", err)
+	}
+
+	//Readings without a sequence are never deduplicated.
+	recordIoTReading(&protocol.IotTx{From: device})
+	if err := checkIoTDuplicate(&protocol.IotTx{TxCnt: 1, From: device}); err != nil {
+		t.Errorf("Reading without sequence rejected: %v\n", err)
+	}
+
+	SetIoTDedupWindow(0)
+	if err := checkIoTDuplicate(retransmission); err != nil {
+		t.Errorf("Retransmitted reading rejected with deduplication disabled: %v\n", err)
+	}
+}
+
+func TestIoTForgedReadingNotRecorded(t *testing.T) {
+	SetIoTDedupWindow(time.Minute)
+	defer SetIoTDedupWindow(IOT_DEDUP_DEFAULT_WINDOW)
+
+	devicePubKey, devicePrivKey, _ := ed25519.GenerateKey(rand.Reader)
+	_, forgerPrivKey, _ := ed25519.GenerateKey(rand.Reader)
+	device := &protocol.Account{}
+	copy(device.Address[:], devicePubKey)
+	receiver := &protocol.Account{Address: [32]byte{'i', 'o', 't', 'r', 'c', 'v'}}
+	storage.State.Set(protocol.SerializeHashContent(device.Address), device)
+	storage.State.Set(protocol.SerializeHashContent(receiver.Address), receiver)
+	defer storage.State.Delete(protocol.SerializeHashContent(device.Address))
+	defer storage.State.Delete(protocol.SerializeHashContent(receiver.Address))
+
+	//The signature is over the tx with the IoT hashes of the addresses, see verifyIotTx.
+	newReading := func(key ed25519.PrivateKey, data string) *protocol.IotTx {
+		tx, _ := protocol.ConstrIotTx(0, 1, 0, protocol.SerializeHashContentIoT(device.Address), protocol.SerializeHashContentIoT(receiver.Address), key, []byte(data), 9)
+		tx.From, tx.To = protocol.SerializeHashContent(device.Address), protocol.SerializeHashContent(receiver.Address)
+		return tx
+	}
+
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	if err := pool.AddOrdered(newReading(forgerPrivKey, "forged")); err != nil {
+		t.Fatalf("Adding forged reading failed: %v\n", err)
+	}
+
+	reading := newReading(devicePrivKey, "21.5")
+	if err := checkIoTDuplicate(reading); err != nil {
+		t.Fatalf("Reading of the device blocked by a forged tx: %v\n", err)
+	}
+
+	if err := NewMempool(MEMPOOL_DEFAULT_SIZE).AddOrdered(reading); err != nil {
+		t.Fatalf("Adding reading failed: %v\n", err)
+	}
+	if err := checkIoTDuplicate(newReading(devicePrivKey, "22.0")); err == nil {
+		t.Error("Retransmitted reading not rejected.\n")
+	}
+}
+
+func TestIoTDuplicateWindowExpired(t *testing.T) {
+	SetIoTDedupWindow(time.Minute)
+	defer SetIoTDedupWindow(IOT_DEDUP_DEFAULT_WINDOW)
+
+	device := [32]byte{0x44}
+	reading := &protocol.IotTx{TxCnt: 0, From: device, Sequence: 1}
+	recordIoTReading(reading)
+
+	iotReadingsMutex.Lock()
+	iotReadings[iotReading{device, 1}] = iotReadingEntry{reading.Hash(), time.Now().Add(-2 * time.Minute)}
+	iotReadingsMutex.Unlock()
+
+	if err := checkIoTDuplicate(&protocol.IotTx{TxCnt: 1, From: device, Sequence: 1}); err != nil {
+		t.Errorf("Reading outside of the window rejected: %v\n", err)
+	}
+}
+
+func TestIoTSequenceHash(t *testing.T) {
+	tx := &protocol.IotTx{From: [32]byte{0x01}, Data: []byte("data")}
+	hash := tx.Hash()
+
+	tx.Sequence = 1
+	if tx.Hash() == hash {
+		t.Error("Sequence is not part of the tx hash.\n")
+	}
+
+	decoded, _ := tx.Decode(tx.Encode())
+	if decoded.Sequence != tx.Sequence || decoded.Hash() != tx.Hash() {
+		t.Errorf("Sequence not encoded: %v vs. %v\n", decoded.Sequence, tx.Sequence)
+	}
+}
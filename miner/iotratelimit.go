@@ -0,0 +1,128 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//IoT txs are accepted without sufficient funds and without a fee, so without a limit a single device could flood the
+//chain. Every sender has a token bucket in its account: it holds up to Iot_rate_capacity tokens and gains a token
+//every Iot_rate_refill blocks. An IotTx paying less than the minimum fee takes a token, a tx finding the bucket empty
+//is not added to a block and a block containing it is invalid. Txs paying the minimum fee and txs of root accounts
+//are not limited, a capacity of 0 disables the limit. Accounts which never sent a limited tx start with a full bucket.
+//Before a tx takes a token, the bucket is saved under the hash of the tx to restore it if the block is rolled back.
+
+type iotBucket struct {
+	tokens       uint32
+	refillHeight uint32
+}
+
+//Buckets of the senders before the txs of the block being validated took their tokens, written to disk together
+//with the block (see postValidate).
+var iotBuckets = make(map[[32]byte]iotBucket)
+
+func isIotRateLimited(tx *protocol.IotTx, params *Parameters) bool {
+	return params.Iot_rate_capacity > 0 && tx.Fee < params.Fee_minimum && !storage.IsRootKey(tx.From)
+}
+
+//Returns the tokens in the account's bucket at the given height and the height up to which the bucket was refilled.
+func refillIotBucket(acc *protocol.Account, height uint32, params *Parameters) (tokens uint32, refillHeight uint32) {
+	capacity := uint32(params.Iot_rate_capacity)
+	if acc.IotRefillHeight == 0 {
+		return capacity, height
+	}
+
+	tokens, refillHeight = acc.IotTokens, acc.IotRefillHeight
+	if height > refillHeight {
+		refills := uint64(height-refillHeight) / params.Iot_rate_refill
+		if uint64(tokens)+refills >= uint64(capacity) {
+			return capacity, height
+		}
+		tokens += uint32(refills)
+		refillHeight += uint32(refills * params.Iot_rate_refill)
+	}
+
+	//The capacity may have been lowered since the last refill.
+	if tokens > capacity {
+		tokens = capacity
+	}
+
+	return tokens, refillHeight
+}
+
+//Takes a token from the bucket of the sender's account if the tx is rate limited.
+func takeIotToken(acc *protocol.Account, tx *protocol.IotTx, height uint32, params *Parameters) error {
+	if !isIotRateLimited(tx, params) {
+		return nil
+	}
+
+	tokens, refillHeight := refillIotBucket(acc, height, params)
+	if tokens == 0 {
+		return errors.New(fmt.Sprintf("Sender %x exceeds the IoT rate limit, the next low fee tx is possible at height %v.", tx.From[0:8], uint64(refillHeight)+params.Iot_rate_refill))
+	}
+
+	acc.IotTokens = tokens - 1
+	acc.IotRefillHeight = refillHeight
+
+	return nil
+}
+
+//Returns true if the tx is rate limited and the sender has no tokens left in the block. Such txs stay in the mempool
+//until the bucket is refilled.
+func isIotThrottled(block *protocol.Block, tx protocol.Transaction) bool {
+	iotTx, ok := tx.(*protocol.IotTx)
+	if !ok || !isIotRateLimited(iotTx, activeParameters) {
+		return false
+	}
+
+	acc := block.StateCopy[iotTx.From]
+	if acc == nil {
+		if acc = storage.State.Get(iotTx.From); acc == nil {
+			return false
+		}
+	}
+
+	tokens, _ := refillIotBucket(acc, block.Height, activeParameters)
+	return tokens == 0
+}
+
+func iotRateLimitStateChange(txSlice []*protocol.IotTx, height uint32, params *Parameters) error {
+	iotBuckets = make(map[[32]byte]iotBucket)
+
+	for _, tx := range txSlice {
+		if !isIotRateLimited(tx, params) {
+			continue
+		}
+
+		acc, err := storage.GetAccount(tx.From)
+		if err != nil {
+			return err
+		}
+
+		iotBuckets[tx.Hash()] = iotBucket{acc.IotTokens, acc.IotRefillHeight}
+		if err := takeIotToken(acc, tx, height, params); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func iotRateLimitStateChangeRollback(txSlice []*protocol.IotTx) {
+	//Rollback in reverse order than original state change
+	for cnt := len(txSlice) - 1; cnt >= 0; cnt-- {
+		tx := txSlice[cnt]
+
+		tokens, refillHeight, exists := storage.ReadIotBucket(tx.Hash())
+		if !exists {
+			continue
+		}
+
+		if acc, err := storage.GetAccount(tx.From); err == nil {
+			acc.IotTokens = tokens
+			acc.IotRefillHeight = refillHeight
+		}
+	}
+}
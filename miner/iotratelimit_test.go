@@ -0,0 +1,94 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestIotBucketRefill(t *testing.T) {
+	params := NewDefaultParameters()
+	params.Iot_rate_capacity = 3
+	params.Iot_rate_refill = 10
+
+	acc := &protocol.Account{}
+	if tokens, refillHeight := refillIotBucket(acc, 5, &params); tokens != 3 || refillHeight != 5 {
+		t.Errorf("Unused bucket should be full: %v tokens refilled at %v\n", tokens, refillHeight)
+	}
+
+	acc.IotTokens, acc.IotRefillHeight = 0, 100
+	if tokens, refillHeight := refillIotBucket(acc, 109, &params); tokens != 0 || refillHeight != 100 {
+		t.Errorf("Bucket refilled too early: %v tokens refilled at %v\n", tokens, refillHeight)
+	}
+
+	//The remainder of the interval is kept for the next token.
+	if tokens, refillHeight := refillIotBucket(acc, 125, &params); tokens != 2 || refillHeight != 120 {
+		t.Errorf("Bucket not refilled: %v tokens refilled at %v\n", tokens, refillHeight)
+	}
+
+	if tokens, _ := refillIotBucket(acc, 1000, &params); tokens != 3 {
+		t.Errorf("Bucket refilled beyond its capacity: %v tokens\n", tokens)
+	}
+
+	acc.IotTokens = 5
+	if tokens, _ := refillIotBucket(acc, 100, &params); tokens != 3 {
+		t.Errorf("Bucket exceeds a lowered capacity: %v tokens\n", tokens)
+	}
+}
+
+func TestIotRateLimit(t *testing.T) {
+	params := NewDefaultParameters()
+	params.Iot_rate_capacity = 2
+	params.Iot_rate_refill = 5
+	params.Fee_minimum = 1
+
+	sender := &protocol.Account{Address: [32]byte{'i', 'o', 't', 'r', 'a', 't', 'e'}}
+	senderHash := sender.Hash()
+	storage.State.Set(senderHash, sender)
+	defer storage.State.Delete(senderHash)
+
+	var txs []*protocol.IotTx
+	for i := uint32(0); i < 3; i++ {
+		txs = append(txs, &protocol.IotTx{From: senderHash, TxCnt: i, Data: []byte{byte(i)}})
+	}
+
+	if err := iotRateLimitStateChange(txs[:2], 10, &params); err != nil {
+		t.Fatalf("Txs within the capacity were rejected: %v\n", err)
+	}
+	if sender.IotTokens != 0 || sender.IotRefillHeight != 10 {
+		t.Errorf("Tokens not taken: %v\n", sender)
+	}
+
+	if err := iotRateLimitStateChange(txs[2:], 14, &params); err == nil {
+		t.Error("Tx without a token should be rejected.")
+	}
+
+	//Txs paying the minimum fee are not limited.
+	paying := &protocol.IotTx{From: senderHash, Fee: 1}
+	if err := iotRateLimitStateChange([]*protocol.IotTx{paying}, 14, &params); err != nil {
+		t.Errorf("Tx paying the minimum fee was rate limited: %v\n", err)
+	}
+
+	if err := iotRateLimitStateChange(txs[2:], 15, &params); err != nil {
+		t.Fatalf("Tx after the refill was rejected: %v\n", err)
+	}
+
+	//The bucket before the tx is written with the block and restored on rollback.
+	batch := storage.NewBatch()
+	bucket := iotBuckets[txs[2].Hash()]
+	batch.WriteIotBucket(txs[2].Hash(), bucket.tokens, bucket.refillHeight)
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	iotRateLimitStateChangeRollback(txs)
+	if sender.IotTokens != 0 || sender.IotRefillHeight != 10 {
+		t.Errorf("Bucket not rolled back: %v\n", sender)
+	}
+
+	params.Iot_rate_capacity = 0
+	if err := iotRateLimitStateChange(txs, 10, &params); err != nil {
+		t.Errorf("Txs were rate limited with a capacity of 0: %v\n", err)
+	}
+}
@@ -0,0 +1,116 @@
+package miner
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Gateway nodes store the data of every IoT reading, which lets their database grow without bounds. With a retention
+//depth set, the data of the IotTxs more than iotRetentionDepth blocks below the last block is pruned: the tx is kept
+//without its data, together with the hash of the data (see storage.Batch.PruneIotTx). IoT acks can still be created
+//and data restored from an archive can be verified against the hash. Before the data is pruned, it can be exported to
+//an archive. Pruned txs can not be served to peers anymore, nodes syncing the chain need a peer keeping the data.
+//The retention depth must exceed the maximum rollback depth, the txs of rolled back blocks have to be complete.
+
+const IOT_PRUNE_INTERVAL = 100 //Blocks
+
+//Off-chain storage of pruned IoT data, e.g., a directory or an object store.
+type IotArchive interface {
+	//The tx is not pruned if archiving its data fails.
+	Archive(txHash [32]byte, data []byte) error
+}
+
+var (
+	iotRetentionDepth uint32
+	iotArchive        IotArchive
+
+	//The data of the blocks up to this height has been pruned. After a restart, the first pruning walks back to the
+	//genesis block, already pruned txs are skipped.
+	iotPrunedHeight uint32
+)
+
+//A depth of 0 keeps the data forever, the archive is optional.
+func SetIotRetention(depth uint32, archive IotArchive) {
+	iotRetentionDepth = depth
+	iotArchive = archive
+}
+
+//Writes the data of every tx to a file named after the hash of the tx.
+type fileIotArchive struct {
+	dir string
+}
+
+func NewFileIotArchive(dir string) (IotArchive, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &fileIotArchive{dir}, nil
+}
+
+func (archive *fileIotArchive) Archive(txHash [32]byte, data []byte) error {
+	return ioutil.WriteFile(filepath.Join(archive.dir, fmt.Sprintf("%x", txHash)), data, 0600)
+}
+
+//Prunes the data below the retention depth every IOT_PRUNE_INTERVAL blocks. Called after the block has been written,
+//with blockValidation held.
+func pruneIotData(block *protocol.Block) {
+	if iotRetentionDepth == 0 || block.Height <= iotRetentionDepth || block.Height%IOT_PRUNE_INTERVAL != 0 {
+		return
+	}
+
+	pruneHeight := block.Height - iotRetentionDepth
+	prunable := block
+	for prunable != nil && prunable.Height > pruneHeight {
+		prunable = readHeader(prunable.PrevHash, prunable.PrevHashWithoutTx)
+	}
+
+	var pruned int
+	for ; prunable != nil && prunable.Height > iotPrunedHeight; prunable = readHeader(prunable.PrevHash, prunable.PrevHashWithoutTx) {
+		prunedTxs, err := pruneIotTxs(prunable)
+		pruned += prunedTxs
+		if err != nil {
+			logger.Printf("IoT data of block (%x) could not be pruned: %v\n", prunable.Hash[0:8], err)
+			return
+		}
+	}
+
+	iotPrunedHeight = pruneHeight
+	if pruned > 0 {
+		logger.Printf("Pruned the data of %v IoT txs up to height %v.\n", pruned, pruneHeight)
+	}
+}
+
+func pruneIotTxs(block *protocol.Block) (pruned int, err error) {
+	batch := storage.NewBatch()
+	for _, txHash := range block.IoTTxData {
+		if _, alreadyPruned := storage.ReadPrunedIotDataHash(txHash); alreadyPruned {
+			continue
+		}
+
+		iotTx, ok := storage.ReadClosedTx(txHash).(*protocol.IotTx)
+		if !ok {
+			continue
+		}
+
+		if iotArchive != nil {
+			if err := iotArchive.Archive(txHash, iotTx.Data); err != nil {
+				return 0, err
+			}
+		}
+
+		batch.PruneIotTx(txHash, iotTx)
+		pruned++
+	}
+
+	if err := batch.Commit(); err != nil {
+		return 0, err
+	}
+
+	return pruned, nil
+}
@@ -0,0 +1,72 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+type memoryIotArchive map[[32]byte][]byte
+
+func (archive memoryIotArchive) Archive(txHash [32]byte, data []byte) error {
+	archive[txHash] = data
+	return nil
+}
+
+func TestPruneIotData(t *testing.T) {
+	archive := make(memoryIotArchive)
+	SetIotRetention(50, archive)
+	defer SetIotRetention(0, nil)
+	defer func() { iotPrunedHeight = 0 }()
+
+	oldTx := &protocol.IotTx{From: [32]byte{'o', 'l', 'd'}, Data: []byte("old reading")}
+	recentTx := &protocol.IotTx{From: [32]byte{'r', 'e', 'c', 'e', 'n', 't'}, Data: []byte("recent reading")}
+
+	var blocks []*protocol.Block
+	var prevHash [32]byte
+	for height := uint32(0); height <= 2*IOT_PRUNE_INTERVAL; height++ {
+		block := &protocol.Block{Hash: [32]byte{'i', 'o', 't', byte(height), byte(height >> 8)}, PrevHash: prevHash, Height: height}
+		switch height {
+		case IOT_PRUNE_INTERVAL:
+			block.IoTTxData = [][32]byte{oldTx.Hash()}
+		case 2*IOT_PRUNE_INTERVAL - 10:
+			block.IoTTxData = [][32]byte{recentTx.Hash()}
+		}
+		storage.WriteClosedBlock(block)
+		blocks = append(blocks, block)
+		prevHash = block.Hash
+	}
+	storage.WriteClosedTx(oldTx)
+	storage.WriteClosedTx(recentTx)
+	defer func() {
+		for _, block := range blocks {
+			storage.DeleteClosedBlock(block.Hash)
+		}
+		storage.DeleteClosedTx(oldTx)
+		storage.DeleteClosedTx(recentTx)
+	}()
+
+	//Only every IOT_PRUNE_INTERVAL blocks.
+	pruneIotData(blocks[len(blocks)-2])
+	if _, pruned := storage.ReadPrunedIotDataHash(oldTx.Hash()); pruned {
+		t.Error("IoT data should only be pruned every IOT_PRUNE_INTERVAL blocks.")
+	}
+
+	pruneIotData(blocks[len(blocks)-1])
+	if _, pruned := storage.ReadPrunedIotDataHash(oldTx.Hash()); !pruned || storage.ReadClosedTx(oldTx.Hash()).(*protocol.IotTx).Data != nil {
+		t.Error("Data of the tx below the retention depth should have been pruned.")
+	}
+
+	if string(archive[oldTx.Hash()]) != "old reading" {
+		t.Errorf("Pruned data should have been archived: %v\n", archive)
+	}
+
+	if _, pruned := storage.ReadPrunedIotDataHash(recentTx.Hash()); pruned || len(archive) != 1 {
+		t.Error("Data of the tx within the retention depth should be kept.")
+	}
+
+	if iotPrunedHeight != 2*IOT_PRUNE_INTERVAL-50 {
+		t.Errorf("Pruned height should be %v, is %v.\n", 2*IOT_PRUNE_INTERVAL-50, iotPrunedHeight)
+	}
+}
@@ -0,0 +1,437 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"time"
+)
+
+//Light clients, e.g., IoT gateways with little storage, only download and validate block headers. A header is accepted
+//if it extends the chain of hashes without tx (HashWithoutTx), its hashes match its content, its commitment proof is
+//signed by the beneficiary and its proof of stake is valid. Transactions are fetched on demand and checked against the
+//Merkle root of their block (see FetchTx).
+//A light client does not keep the state: the accounts of the validators (commitment key and balance) are requested
+//from the peers with a proof against the state root of the previous header, which the client has validated itself.
+//Peers only keep the state of the most recent blocks (see storage.STATE_TRIES_KEPT), validators which are not known
+//yet can therefore only be verified close to the tip of the chain. The difficulty is derived from the header
+//timestamps with the default parameters, config txs are not taken into account. The balance of the first verified
+//account is used for the proof of stake, which is a lower bound for the difficulty as long as validators do not spend
+//their stake.
+
+//Validator accounts requested from the peers, guarded by blockValidation.
+var lightValidators = make(map[[32]byte]*protocol.Account)
+
+//Light client entry point, the call blocks and follows the headers of the chain.
+func InitLightClient() {
+	logger.Printf("\n\n\n-------------------- START LIGHT CLIENT ---------------------")
+
+	parameterSlice = append(parameterSlice, NewDefaultParameters())
+	activeParameters = &parameterSlice[0]
+	//Only the parameters of the genesis file are needed to validate the headers.
+	if _, err := initGenesis(); err != nil {
+		logger.Printf("Could not set up the genesis state: %v\n", err)
+		return
+	}
+	currentTargetTime = new(timerange)
+	target = append(target, initialDifficulty())
+
+	blockValidation.Lock()
+	loadHeaders()
+	if err := syncHeaders(nil); err != nil {
+		logger.Printf("Could not sync headers: %v\n", err)
+	}
+	blockValidation.Unlock()
+
+	for {
+		processHeader(<-p2p.BlockIn)
+	}
+}
+
+//Replays the stored headers to restore the difficulty.
+func loadHeaders() {
+	var headers []*protocol.Block
+	for header := storage.ReadLastClosedBlock(); header != nil; header = readHeader(header.PrevHash, header.PrevHashWithoutTx) {
+		headers = append(headers, header)
+		if header.Height == 0 {
+			break
+		}
+	}
+
+	for _, header := range InvertBlockArray(headers) {
+		collectStatistics(header)
+	}
+
+	if lastBlock != nil {
+		logger.Printf("Loaded %v headers, last header: %v\n", len(headers), lastBlock.Height)
+	}
+}
+
+//Blocks are broadcast in full, only their header is kept.
+func processHeader(encodedBlock []byte) {
+	var block, header *protocol.Block
+	var err error
+	if block, err = block.Decode(encodedBlock); err != nil {
+		logger.Printf("Received block could not be decoded: %v\n", err)
+		return
+	}
+	if header, err = header.Decode(block.EncodeHeader()); err != nil {
+		logger.Printf("Header of block (%x) could not be decoded: %v\n", block.Hash[0:8], err)
+		return
+	}
+
+	blockValidation.Lock()
+	defer blockValidation.Unlock()
+
+	if readHeader(header.Hash, header.HashWithoutTx) != nil {
+		p2p.BlockRelayValidated(header.Hash, header.Height, true)
+		return
+	}
+
+	if lastBlock != nil && header.PrevHashWithoutTx == lastBlock.HashWithoutTx {
+		err = appendHeaders([]*protocol.Block{header})
+	} else {
+		err = syncHeaders(header)
+	}
+
+	p2p.BlockRelayValidated(header.Hash, header.Height, err == nil)
+	if err != nil {
+		logger.Printf("Received header (%x) could not be validated: %v\n", header.Hash[0:8], err)
+	}
+}
+
+//Fetches the headers from tip (the latest header of a peer if nil) back to the current chain and switches to them if
+//they form a longer chain. The caller needs to hold blockValidation.
+func syncHeaders(tip *protocol.Block) (err error) {
+	if tip == nil {
+		if tip, err = fetchHeader(nil, [32]byte{}); err != nil {
+			return err
+		}
+	}
+
+	if lastBlock != nil && tip.Height <= lastBlock.Height {
+		return nil
+	}
+
+	var headers []*protocol.Block
+	ancestor := tip
+	for !isOnChain(ancestor) {
+		headers = append(headers, ancestor)
+		if ancestor.Height == 0 {
+			ancestor = nil
+			break
+		}
+
+		prevHeader, err := fetchHeader(&ancestor.PrevHash, ancestor.PrevHashWithoutTx)
+		if err != nil {
+			return err
+		}
+		if prevHeader.Height+1 != ancestor.Height {
+			return errors.New(fmt.Sprintf("Received header at height %v instead of %v.", prevHeader.Height, ancestor.Height-1))
+		}
+		ancestor = prevHeader
+	}
+
+	var abandoned []*protocol.Block
+	for lastBlock != nil && (ancestor == nil || lastBlock.HashWithoutTx != ancestor.HashWithoutTx) {
+		abandoned = append(abandoned, lastBlock)
+		rollbackHeader()
+	}
+
+	if err = appendHeaders(InvertBlockArray(headers)); err != nil {
+		//Back to the chain we were on, its headers have already been validated.
+		for lastBlock != nil && (ancestor == nil || lastBlock.HashWithoutTx != ancestor.HashWithoutTx) {
+			rollbackHeader()
+		}
+		for _, header := range InvertBlockArray(abandoned) {
+			storeHeader(header)
+		}
+		return err
+	}
+
+	logger.Printf("Synced headers up to height %v (%x).\n", lastBlock.Height, lastBlock.Hash[0:8])
+
+	return nil
+}
+
+//Validates and stores the headers, the first one needs to extend the current chain.
+func appendHeaders(headers []*protocol.Block) error {
+	for _, header := range headers {
+		if lastBlock == nil {
+			if header.Height != 0 || header.Hash != [32]byte{} || header.HashWithoutTx != [32]byte{} {
+				return errors.New(fmt.Sprintf("Header (%x) is not the genesis block.", header.Hash[0:8]))
+			}
+		} else if err := validateHeader(header, lastBlock); err != nil {
+			return err
+		}
+
+		storeHeader(header)
+	}
+
+	return nil
+}
+
+func storeHeader(header *protocol.Block) {
+	if header.Aggregated {
+		storage.WriteClosedBlockWithoutTx(header)
+	} else {
+		storage.WriteClosedBlock(header)
+	}
+	storage.DeleteAllLastClosedBlock()
+	storage.WriteLastClosedBlock(header)
+
+	collectStatistics(header)
+}
+
+//Headers stay in storage, they might become part of the chain again.
+func rollbackHeader() {
+	prevHeader := readHeader(lastBlock.PrevHash, lastBlock.PrevHashWithoutTx)
+	collectStatisticsRollback(lastBlock)
+	lastBlock = prevHeader
+
+	storage.DeleteAllLastClosedBlock()
+	if lastBlock != nil {
+		storage.WriteLastClosedBlock(lastBlock)
+	}
+}
+
+func validateHeader(header *protocol.Block, prevHeader *protocol.Block) error {
+	if header.Height != prevHeader.Height+1 || header.PrevHashWithoutTx != prevHeader.HashWithoutTx {
+		return errors.New(fmt.Sprintf("Header (%x) does not extend header (%x).", header.Hash[0:8], prevHeader.Hash[0:8]))
+	}
+
+	if err := verifyBlockHashes(header); err != nil {
+		return err
+	}
+
+	if header.Timestamp > time.Now().Unix()+int64(activeParameters.Accepted_time_diff) {
+		return errors.New("The timestamp is too far in the future.")
+	}
+
+	if err := medianTimeCheck(header); err != nil {
+		return err
+	}
+
+	acc, err := fetchValidator(header.Beneficiary, prevHeader, false)
+	if err != nil {
+		return err
+	}
+
+	if err = verifyCommitmentProof(acc.CommitmentKey, header.Height, header.CommitmentProof); err != nil {
+		return err
+	}
+
+	prevProofs := GetLatestProofs(activeParameters.num_included_prev_proofs, header)
+	if !validateLightProofOfStake(header, prevProofs, acc) {
+		//The balance of the validator might have grown since it was requested.
+		if acc, err = fetchValidator(header.Beneficiary, prevHeader, true); err != nil {
+			return err
+		}
+		if !validateLightProofOfStake(header, prevProofs, acc) {
+			return errors.New("The nonce is incorrect.")
+		}
+	}
+
+	return nil
+}
+
+func validateLightProofOfStake(header *protocol.Block, prevProofs [][crypto.COMM_KEY_LENGTH]byte, acc *protocol.Account) bool {
+	return acc.Balance > 0 && validateProofOfStake(getDifficulty(), prevProofs, header.Height, acc.Balance, header.CommitmentProof, header.Timestamp)
+}
+
+//Returns the account of the validator, requested from a peer if it is not known yet or refresh is set. The account is
+//verified against the state root of prevHeader, the header the validated header extends.
+func fetchValidator(hash [32]byte, prevHeader *protocol.Block, refresh bool) (*protocol.Account, error) {
+	if acc := lightValidators[hash]; acc != nil && !refresh {
+		return acc, nil
+	}
+
+	acc, err := fetchAccountProof(hash, prevHeader)
+	if err != nil {
+		return nil, err
+	}
+	if acc == nil {
+		return nil, errors.New(fmt.Sprintf("Validator (%x) not found.", hash[0:8]))
+	}
+	if !acc.IsStaking {
+		return nil, errors.New("Validator is not part of the validator set.")
+	}
+	lightValidators[hash] = acc
+
+	return acc, nil
+}
+
+//Requests the account after the block of the header with its proof and checks it against the state root of the
+//header. Returns nil if the account did not exist.
+func fetchAccountProof(address [32]byte, header *protocol.Block) (*protocol.Account, error) {
+	if err := p2p.AccountProofReq(address, header.Height); err != nil {
+		return nil, err
+	}
+
+	var proof *protocol.AccountProof
+	select {
+	case encodedProof := <-p2p.AccountProofReqChan:
+		proof, _ = proof.Decode(encodedProof)
+	case <-time.After(TXFETCH_TIMEOUT * time.Second):
+		return nil, errors.New(fmt.Sprintf("Account proof request for account (%x) timed out.", address[0:8]))
+	}
+
+	return protocol.VerifyAccountProof(header, address, proof)
+}
+
+//Requests the header of a block or, if hash is nil, the latest header of a peer.
+func fetchHeader(hash *[32]byte, hashWithoutTx [32]byte) (*protocol.Block, error) {
+	var err error
+	if hash == nil {
+		err = p2p.LastBlockHeaderReq()
+	} else {
+		err = p2p.BlockHeaderReq(*hash, hashWithoutTx)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	select {
+	case encodedHeader := <-p2p.BlockHeaderReqChan:
+		var header *protocol.Block
+		if header, _ = header.Decode(encodedHeader); header == nil {
+			return nil, errors.New("Received header could not be decoded.")
+		}
+		if hash != nil && header.Hash != *hash && header.HashWithoutTx != hashWithoutTx {
+			return nil, errors.New(fmt.Sprintf("Received header (%x) does not correspond to our request.", header.Hash[0:8]))
+		}
+		return header, nil
+	case <-time.After(BLOCKFETCH_TIMEOUT * time.Second):
+		return nil, errors.New("Header request timed out.")
+	}
+}
+
+func readHeader(hash [32]byte, hashWithoutTx [32]byte) *protocol.Block {
+	if header := storage.ReadClosedBlock(hash); header != nil {
+		return header
+	}
+
+	return storage.ReadClosedBlockWithoutTx(hashWithoutTx)
+}
+
+//Checks whether the header is part of the current chain.
+func isOnChain(header *protocol.Block) bool {
+	if lastBlock == nil || header.Height > lastBlock.Height || readHeader(header.Hash, header.HashWithoutTx) == nil {
+		return false
+	}
+
+	chainHeader := lastBlock
+	for chainHeader != nil && chainHeader.Height > header.Height {
+		chainHeader = readHeader(chainHeader.PrevHash, chainHeader.PrevHashWithoutTx)
+	}
+
+	return chainHeader != nil && chainHeader.HashWithoutTx == header.HashWithoutTx
+}
+
+//Fetches the account with the hash as it was after the block at the height of the current chain and checks it against
+//the state root of the block's header. Returns nil if the account did not exist.
+func FetchAccount(address [32]byte, height uint32) (*protocol.Account, error) {
+	blockValidation.Lock()
+	header := lastBlock
+	for header != nil && header.Height > height {
+		header = readHeader(header.PrevHash, header.PrevHashWithoutTx)
+	}
+	blockValidation.Unlock()
+
+	if header == nil || header.Height != height {
+		return nil, errors.New(fmt.Sprintf("No header at height %v.", height))
+	}
+
+	return fetchAccountProof(address, header)
+}
+
+//Fetches a tx from the network and checks that it is included in the Merkle root of the block's header. txType is
+//the request type of the tx (e.g., p2p.IOTTX_REQ).
+func FetchTx(blockHash [32]byte, txHash [32]byte, txType uint8) (protocol.Transaction, error) {
+	header := storage.ReadClosedBlock(blockHash)
+	if header == nil {
+		return nil, errors.New(fmt.Sprintf("Header (%x) not found.", blockHash[0:8]))
+	}
+
+	if err := p2p.MerkleProofReq(blockHash, txHash); err != nil {
+		return nil, err
+	}
+
+	var proof *protocol.MerkleProof
+	select {
+	case encodedProof := <-p2p.MerkleProofReqChan:
+		proof, _ = proof.Decode(encodedProof)
+	case <-time.After(TXFETCH_TIMEOUT * time.Second):
+		return nil, errors.New(fmt.Sprintf("Merkle proof request for tx (%x) timed out.", txHash[0:8]))
+	}
+
+	if proof == nil || !proof.Verify(txHash, header.MerkleRoot) {
+		return nil, errors.New(fmt.Sprintf("Tx (%x) is not included in block (%x).", txHash[0:8], blockHash[0:8]))
+	}
+
+	if err := p2p.TxReq(txHash, txType); err != nil {
+		return nil, err
+	}
+
+	var tx protocol.Transaction
+	timeout := time.After(TXFETCH_TIMEOUT * time.Second)
+	switch txType {
+	case p2p.FUNDSTX_REQ:
+		select {
+		case fundsTx := <-p2p.FundsTxChan:
+			tx = fundsTx
+		case <-timeout:
+		}
+	case p2p.ACCTX_REQ:
+		select {
+		case accTx := <-p2p.AccTxChan:
+			tx = accTx
+		case <-timeout:
+		}
+	case p2p.CONFIGTX_REQ:
+		select {
+		case configTx := <-p2p.ConfigTxChan:
+			tx = configTx
+		case <-timeout:
+		}
+	case p2p.STAKETX_REQ:
+		select {
+		case stakeTx := <-p2p.StakeTxChan:
+			tx = stakeTx
+		case <-timeout:
+		}
+	case p2p.AGGTX_REQ:
+		select {
+		case aggTx := <-p2p.AggTxChan:
+			tx = aggTx
+		case <-timeout:
+		}
+	case p2p.IOTTX_REQ:
+		select {
+		case iotTx := <-p2p.IoTTxChan:
+			tx = iotTx
+		case <-timeout:
+		}
+	case p2p.CONTRACTTX_REQ:
+		select {
+		case contractTx := <-p2p.ContractTxChan:
+			tx = contractTx
+		case <-timeout:
+		}
+	default:
+		return nil, errors.New(fmt.Sprintf("Unknown tx request type %v.", txType))
+	}
+
+	if tx == nil {
+		return nil, errors.New(fmt.Sprintf("Tx (%x) request timed out.", txHash[0:8]))
+	}
+
+	if tx.Hash() != txHash {
+		return nil, errors.New("Received txHash did not correspond to our request.")
+	}
+
+	return tx, nil
+}
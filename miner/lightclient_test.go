@@ -0,0 +1,123 @@
+package miner
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"testing"
+	"time"
+)
+
+func newTestHeader(t *testing.T, prevHeader *protocol.Block, acc *protocol.Account, commitmentHeight uint32) *protocol.Block {
+	commPrivKey, err := crypto.CreateRSAPrivKeyFromBase64(CommPubA, CommPrivA, []string{CommPrim1A, CommPrim2A})
+	if err != nil {
+		t.Fatalf("Could not create commitment key: %v\n", err)
+	}
+
+	header := &protocol.Block{
+		PrevHash:          prevHeader.Hash,
+		PrevHashWithoutTx: prevHeader.HashWithoutTx,
+		Height:            prevHeader.Height + 1,
+		Beneficiary:       acc.Hash(),
+		Timestamp:         time.Now().Unix() + int64(prevHeader.Height), //Above the median time past of the previous headers.
+		MerkleRoot:        [32]byte{0x01},
+	}
+
+	commitmentProof, err := crypto.SignMessageWithRSAKey(commPrivKey, fmt.Sprint(commitmentHeight))
+	if err != nil {
+		t.Fatalf("Could not create commitment proof: %v\n", err)
+	}
+	copy(header.CommitmentProof[:], commitmentProof[:])
+
+	binary.BigEndian.PutUint64(header.Nonce[:], uint64(header.Timestamp))
+	header.Hash, header.HashWithoutTx = blockHashes(header)
+
+	return header
+}
+
+func TestLightClientHeaders(t *testing.T) {
+	prevTarget, prevLastBlock, prevValidators := target, lastBlock, lightValidators
+	prevGlobalBlockCount, prevLocalBlockCount, prevTargetTime := globalBlockCount, localBlockCount, currentTargetTime
+	defer func() {
+		target, lastBlock, lightValidators = prevTarget, prevLastBlock, prevValidators
+		globalBlockCount, localBlockCount, currentTargetTime = prevGlobalBlockCount, prevLocalBlockCount, prevTargetTime
+		storage.DeleteAllLastClosedBlock()
+	}()
+
+	//Difficulty 0, the proof of stake is valid for every balance.
+	target = []uint8{0}
+	lastBlock = nil
+	globalBlockCount, localBlockCount, currentTargetTime = -1, -1, new(timerange)
+
+	commPrivKey, _ := crypto.CreateRSAPrivKeyFromBase64(CommPubA, CommPrivA, []string{CommPrim1A, CommPrim2A})
+	acc := &protocol.Account{Address: [32]byte{0x0a}, Balance: 1000, IsStaking: true}
+	copy(acc.CommitmentKey[:], commPrivKey.PublicKey.N.Bytes())
+	lightValidators = map[[32]byte]*protocol.Account{acc.Hash(): acc}
+
+	genesis := &protocol.Block{}
+	header1 := newTestHeader(t, genesis, acc, 1)
+	defer storage.DeleteClosedBlock(genesis.Hash)
+	defer storage.DeleteClosedBlock(header1.Hash)
+
+	if err := appendHeaders([]*protocol.Block{header1}); err == nil {
+		t.Error("Header accepted as genesis block.\n")
+	}
+
+	if err := appendHeaders([]*protocol.Block{genesis, header1}); err != nil {
+		t.Fatalf("Valid headers not accepted: %v\n", err)
+	}
+
+	if lastBlock.Hash != header1.Hash || !isOnChain(genesis) || !isOnChain(header1) {
+		t.Errorf("Headers not appended to the chain.\n")
+	}
+
+	if last := storage.ReadLastClosedBlock(); last == nil || last.Hash != header1.Hash {
+		t.Error("Last header not stored.\n")
+	}
+
+	header2 := newTestHeader(t, header1, acc, 2)
+	header2.Timestamp++
+	if err := validateHeader(header2, header1); err == nil {
+		t.Error("Header with a modified timestamp accepted.\n")
+	}
+
+	header2 = newTestHeader(t, header1, acc, 2)
+	header2.HashWithoutTx = [32]byte{0x02}
+	if err := validateHeader(header2, header1); err == nil {
+		t.Error("Header with an invalid hash without tx accepted.\n")
+	}
+
+	if err := validateHeader(newTestHeader(t, header1, acc, 5), header1); err == nil {
+		t.Error("Header with an invalid commitment proof accepted.\n")
+	}
+
+	if err := validateHeader(newTestHeader(t, genesis, acc, 1), header1); err == nil {
+		t.Error("Header not extending the chain accepted.\n")
+	}
+
+	header2 = newTestHeader(t, header1, acc, 2)
+	if err := validateHeader(header2, header1); err != nil {
+		t.Errorf("Valid header not accepted: %v\n", err)
+	}
+
+	rollbackHeader()
+	if lastBlock.Hash != genesis.Hash || isOnChain(header1) {
+		t.Errorf("Header not rolled back.\n")
+	}
+}
+
+func TestLightClientInvalidInput(t *testing.T) {
+	prevValidators := lightValidators
+	defer func() { lightValidators = prevValidators }()
+
+	//Must not panic.
+	processHeader([]byte{0x01, 0x02})
+
+	//Unknown validators are only accepted with a proof against the state root, none is available without peers.
+	lightValidators = make(map[[32]byte]*protocol.Account)
+	if _, err := fetchValidator([32]byte{0x0a}, &protocol.Block{Height: 1, StateRoot: [32]byte{0x01}}, false); err == nil {
+		t.Error("Unverified validator accepted.\n")
+	}
+}
@@ -0,0 +1,88 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"math/big"
+)
+
+//Validators which stop producing blocks slow the chain down, the blocks they would have produced are produced later by
+//the others. After the last block of an epoch, every node derives the liveness evidence of the epoch from its blocks
+//(see protocol/livenessevidence.go): the validators of the epoch's set which did not produce a single block although
+//their share of the stake let them expect at least LIVENESS_MIN_EXPECTED_BLOCKS. Validators missing their blocks in
+//LIVENESS_MISSED_EPOCHS consecutive epochs are left out of the next validator set. They are back in the set after
+//that, as they can not miss blocks in an epoch they are not part of. The evidence is persisted, rolling back the last
+//block of an epoch deletes it together with the validator set.
+
+const (
+	LIVENESS_MIN_EXPECTED_BLOCKS = 5 //Blocks
+	LIVENESS_MISSED_EPOCHS       = 2
+)
+
+//Returns the liveness evidence of the epoch ending with block.
+func computeLivenessEvidence(block *protocol.Block) *protocol.LivenessEvidence {
+	var missed [][32]byte
+
+	set := storage.ReadValidatorSet(validatorSetHeight(block.Height))
+	if set == nil {
+		return protocol.NewLivenessEvidence(block.Height, missed)
+	}
+
+	produced := make(map[[32]byte]bool)
+	for epochBlock := block; epochBlock != nil && epochBlock.Height > set.Height; epochBlock = readHeader(epochBlock.PrevHash, epochBlock.PrevHashWithoutTx) {
+		produced[epochBlock.Beneficiary] = true
+	}
+
+	total := new(big.Int)
+	for _, validator := range set.Validators {
+		total.Add(total, new(big.Int).SetUint64(validator.Balance))
+	}
+
+	//Expected blocks = epoch length * balance / total stake
+	minimum := new(big.Int).Mul(big.NewInt(LIVENESS_MIN_EXPECTED_BLOCKS), total)
+	for _, validator := range set.Validators {
+		expected := new(big.Int).Mul(big.NewInt(VALIDATOR_EPOCH_LENGTH), new(big.Int).SetUint64(validator.Balance))
+		if !produced[validator.Address] && expected.Cmp(minimum) >= 0 {
+			missed = append(missed, validator.Address)
+		}
+	}
+
+	return protocol.NewLivenessEvidence(block.Height, missed)
+}
+
+//Called once block has been validated, adds the liveness evidence to the block's batch if it was the last block of an
+//epoch. Returns the evidence, nil for the other blocks.
+func writeLivenessEvidence(batch *storage.Batch, block *protocol.Block) *protocol.LivenessEvidence {
+	if block.Height == 0 || block.Height%VALIDATOR_EPOCH_LENGTH != 0 {
+		return nil
+	}
+
+	evidence := computeLivenessEvidence(block)
+	batch.WriteLivenessEvidence(evidence)
+
+	if len(evidence.Missed) > 0 {
+		logger.Printf("%v validators missed their blocks in the epoch ending at height %v.\n", len(evidence.Missed), block.Height)
+	}
+
+	return evidence
+}
+
+//Returns whether the validator missed its blocks in the last LIVENESS_MISSED_EPOCHS epochs up to the one ending at
+//height. The evidence of the epoch ending at height is read from storage unless it is passed.
+func isLivenessPenalized(address [32]byte, height uint32, pending *protocol.LivenessEvidence) bool {
+	for i := uint32(0); i < LIVENESS_MISSED_EPOCHS; i++ {
+		if height < i*VALIDATOR_EPOCH_LENGTH {
+			return false
+		}
+
+		evidence := pending
+		if i > 0 || evidence == nil || evidence.Height != height {
+			evidence = storage.ReadLivenessEvidence(height - i*VALIDATOR_EPOCH_LENGTH)
+		}
+		if evidence == nil || !evidence.Contains(address) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,80 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestLivenessEvidence(t *testing.T) {
+	const setHeight = 10 * VALIDATOR_EPOCH_LENGTH
+
+	active := [32]byte{'a', 'c', 't', 'i', 'v', 'e'}
+	idle := [32]byte{'i', 'd', 'l', 'e'}
+	small := [32]byte{'s', 'm', 'a', 'l', 'l'}
+	storage.WriteValidatorSet(protocol.NewValidatorSet(setHeight, []*protocol.Validator{
+		{Address: active, Balance: 5000},
+		{Address: idle, Balance: 5000},
+		//Expects less than LIVENESS_MIN_EXPECTED_BLOCKS in an epoch.
+		{Address: small, Balance: 100},
+	}))
+	defer storage.DeleteValidatorSet(setHeight)
+
+	//All blocks of the epoch are produced by the same validator.
+	var block *protocol.Block
+	for height := uint32(setHeight + 1); height <= setHeight+VALIDATOR_EPOCH_LENGTH; height++ {
+		next := &protocol.Block{Height: height, Beneficiary: active, Hash: [32]byte{'l', byte(height), byte(height >> 8)}}
+		if block != nil {
+			next.PrevHash = block.Hash
+		}
+		storage.WriteClosedBlock(next)
+		defer storage.DeleteClosedBlock(next.Hash)
+		block = next
+	}
+
+	evidence := computeLivenessEvidence(block)
+	if evidence.Height != block.Height || len(evidence.Missed) != 1 || !evidence.Contains(idle) {
+		t.Errorf("Expected only the idle validator to miss its blocks, got %v\n", evidence.Missed)
+	}
+
+	storage.WriteLivenessEvidence(evidence)
+	defer storage.DeleteLivenessEvidence(evidence.Height)
+	if isLivenessPenalized(idle, evidence.Height, nil) {
+		t.Error("Validator penalized after missing its blocks in a single epoch.")
+	}
+
+	storage.WriteLivenessEvidence(protocol.NewLivenessEvidence(evidence.Height+VALIDATOR_EPOCH_LENGTH, [][32]byte{idle}))
+	defer storage.DeleteLivenessEvidence(evidence.Height + VALIDATOR_EPOCH_LENGTH)
+	if !isLivenessPenalized(idle, evidence.Height+VALIDATOR_EPOCH_LENGTH, nil) {
+		t.Error("Validator not penalized after missing its blocks in consecutive epochs.")
+	}
+	if isLivenessPenalized(active, evidence.Height+VALIDATOR_EPOCH_LENGTH, nil) {
+		t.Error("Validator producing blocks penalized.")
+	}
+
+	//The penalized validator is left out of the next validator set.
+	acc := &protocol.Account{Address: [32]byte{'l', 'i', 'v', 'e'}, Balance: activeParameters.Staking_minimum, IsStaking: true}
+	storage.State.Set(acc.Hash(), acc)
+	defer storage.State.Delete(acc.Hash())
+	storage.WriteLivenessEvidence(protocol.NewLivenessEvidence(evidence.Height+2*VALIDATOR_EPOCH_LENGTH, [][32]byte{acc.Hash()}))
+	defer storage.DeleteLivenessEvidence(evidence.Height + 2*VALIDATOR_EPOCH_LENGTH)
+	if computeValidatorSet(evidence.Height+2*VALIDATOR_EPOCH_LENGTH, nil).Get(acc.Hash()) == nil {
+		t.Error("Validator left out of the set after missing its blocks in a single epoch.")
+	}
+
+	storage.WriteLivenessEvidence(protocol.NewLivenessEvidence(evidence.Height+3*VALIDATOR_EPOCH_LENGTH, [][32]byte{acc.Hash()}))
+	defer storage.DeleteLivenessEvidence(evidence.Height + 3*VALIDATOR_EPOCH_LENGTH)
+	if computeValidatorSet(evidence.Height+3*VALIDATOR_EPOCH_LENGTH, nil).Get(acc.Hash()) != nil {
+		t.Error("Penalized validator is part of the set.")
+	}
+
+	//The evidence of the last epoch is not stored yet while its last block is written.
+	pending := protocol.NewLivenessEvidence(evidence.Height+4*VALIDATOR_EPOCH_LENGTH, [][32]byte{acc.Hash()})
+	if computeValidatorSet(pending.Height, nil).Get(acc.Hash()) == nil {
+		t.Error("Validator left out of the set without the evidence of the last epoch.")
+	}
+	if computeValidatorSet(pending.Height, pending).Get(acc.Hash()) != nil {
+		t.Error("Pending liveness evidence is ignored.")
+	}
+}
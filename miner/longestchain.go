@@ -20,6 +20,11 @@ func getBlockSequences(newBlock *protocol.Block) (blocksToRollback, blocksToVali
 		return nil, nil, errors.New("Common ancestor not found.")
 	}
 
+	//Checked before the blocks to roll back are collected, so the walk back is bounded as well.
+	if err := checkFinality(ancestor); err != nil {
+		return nil, nil, err
+	}
+
 	//Count how many blocks there are on the currently active chain.
 	tmpBlock := lastBlock
 
@@ -32,10 +37,10 @@ func getBlockSequences(newBlock *protocol.Block) (blocksToRollback, blocksToVali
 		tmpBlock = storage.ReadClosedBlock(tmpBlock.PrevHash)
 	}
 
-	//Compare current length with new chain length.
-	if len(blocksToRollback) >= len(newChain) {
-		//Current chain length is longer or equal (our consensus protocol states that in this case we reject the block).
-		return nil, nil, errors.New(fmt.Sprintf("Block belongs to shorter or equally long chain --> NO ROLLBACK (blocks to rollback %d vs block of new chain %d)", len(blocksToRollback), len(newChain)))
+	//Compare the accumulated difficulty of the chains, see chainweight.go.
+	if !isHeavierChain(ancestor, blocksToRollback, newChain) {
+		//Current chain is heavier or equally heavy (our consensus protocol states that in this case we reject the block).
+		return nil, nil, errors.New(fmt.Sprintf("Block belongs to a lighter or equally heavy chain --> NO ROLLBACK (blocks to rollback %d vs block of new chain %d)", len(blocksToRollback), len(newChain)))
 	} else {
 		//New chain is longer, rollback and validate new chain.
 		return blocksToRollback, newChain, nil
@@ -67,9 +72,15 @@ func getNewChain(newBlock *protocol.Block) (ancestor *protocol.Block, newChain [
 			return potentialAncestor, newChain
 		}
 
+		//The predecessor is not validated, the ancestor is further down.
+		if err := checkBranchFinality(newBlock); err != nil {
+			logger.Printf("Abandoning branch of block (%x): %v\n", newBlock.Hash[0:8], err)
+			return nil, nil
+		}
+
 		//It might be the case that we already started a sync and the block is in the openblock storage.
-		newBlock = storage.ReadOpenBlock(newBlock.PrevHash)
-		if newBlock != nil {
+		if openBlock := storage.ReadOpenBlock(newBlock.PrevHash); openBlock != nil {
+			newBlock = openBlock
 			continue
 		}
 
@@ -84,6 +95,12 @@ func getNewChain(newBlock *protocol.Block) (ancestor *protocol.Block, newChain [
 			}
 		}
 
+		//Branches longer than the stash are in the orphan pool, see orphanpool.go.
+		if orphan := orphans.get(newBlock.PrevHash); orphan != nil {
+			newBlock = orphan
+			continue
+		}
+
 		//Fetch the block we apparently missed from the network.
 		//p2p.BlockReq(newBlock.PrevHash, newBlock.PrevHashWithoutTx)
 		p2p.BlockReq(newBlock.PrevHash, newBlock.PrevHashWithoutTx)
@@ -91,13 +108,13 @@ func getNewChain(newBlock *protocol.Block) (ancestor *protocol.Block, newChain [
 		//Blocking wait
 		select {
 		case encodedBlock := <-p2p.BlockReqChan:
-			newBlock = newBlock.Decode(encodedBlock)
+			if newBlock, _ = newBlock.Decode(encodedBlock); newBlock == nil {
+				return nil, nil
+			}
 			storage.WriteToReceivedStash(newBlock)
 		//Limit waiting time to BLOCKFETCH_TIMEOUT seconds before aborting.
 		case <-time.After(BLOCKFETCH_TIMEOUT * time.Second):
 			return nil, nil
 		}
 	}
-
-	return nil, nil
 }
@@ -1,15 +1,13 @@
 package miner
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/rsa"
+	"encoding/hex"
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
-	"io/ioutil"
-	"log"
-	"math/big"
+	"github.com/bazo-blockchain/bazo-miner/logging"
+	"golang.org/x/crypto/ed25519"
 	"os"
 	"testing"
 
@@ -25,16 +23,13 @@ const (
 )
 
 const (
-	PubA1 = "c2be9abbeaec39a066c2a09cee23bb9ab2a0b88f2880b1e785b4d317adf0dc7c"
-	PubA2 = "8ce020fde838d9c443f6c93345dafe7fd74f091c4d2f30b37e2453679a257ed5"
+	//Seeds of the ed25519 keys
 	PrivA = "ba127fa8f802b008b9cdb58f4e44809d48f1b000cff750dda9cd6b312395c1c5"
 	CommPubA = "vsl0yfAd3dqJfDEawAl7Xp2/hOvXGN/u0UXBpRSxWAT+FSKlt5Ha8Ibd59tGkM4D8i/MABx0MMNEVL8Ghe1QkXIITJRnFtoqsidTlcHSL4WL7sc+8LwJjIjMdqM5BYIZJap/j2O2qREcEICEN8i+6LF844iMqFysDOuL8F5MAH22twrh0SMVXAM+IAEqa0Z9TymvX8Op3dt/t5IhrA4ivsS/+QMWzr3xJE9XfQxMrDUNoBwXIszOr656m8/wYa9dOEZn8qlglEySAjievkECJZq9Q3DRat5SUoXjG8M6UJp/AeRUUANsXhrPn6Cg7j4ke5Yw0bk6Lz9foYaZ9rugkw=="
 	CommPrivA = "n5Xdlei+4sshA3wDlyyXQF6NS78GTi1KE0zZHJ/BdBHBAqbXnURosZbuWTmmvgtFa7ilWFZ0rjE3n/elmjMWmIKdBImB7bCR1DFnDjZw/QUlNpb9Q9rV1fK7rGT9lmjrZgFG8AcFTEgehIMrlYnafsOv5pdaqJ3T4H7KsEYAJsuNZhHAFmReqNdeiUbdAntPLQjttbs43DqaVQ0D3YnHrKxeu7Ekwcs4ap18tkFt7Lp0mkJ3fjpsvJFPDP2CotrZadLilv7dmOrXe26XDLUQ2aBguExV4Wx85J29puOJwpoM60KiFgiBMtRQFRukzRuValiVkXEBLZKlbh6wYy0vwQ=="
 	CommPrim1A = "9UbkVH5chUZCZaehntnZWAfTJ9OYvsKfu19Cb39RrBZ9FDMjDoBlKZslyvRzTez33An84JAgwOBtEbaSTAkVqvPmDin3oZhTYbwwDc9SIBVsYhI6VmbjcPkMAFIeoKbS4KzweXneeKBB9FbozcgvnYrv3lTqofVVWONY/EL9q7M="
 	CommPrim2A = "xyC4Jl6ojvL+uF2/iK9kRj3yQh8bV2ngl/fongysmUvxCZrwxaEaOZcBHreTiP6SFPOrWCyk6e9zHjtDPP/LhxrHsaiFapv6AjQejML/gCyFj4GRWMzayFBJlW6prsjZfhNG6FpQbFrEj8FtYdM0vRLyDyzeknrC66PJtwEcR6E="
 
-	PubB1 = "5d7eefd58e3d2f309471928ab4bbd104e52973372c159fa652b8ca6b57ff68b8"
-	PubB2 = "ab301a6a77b201c416ddc13a2d33fdf200a5302f6f687e0ea09085debaf8a1d9"
 	PrivB = "7a0a9babcc97ea7991ed67ed7f800f70c5e04e99718960ad8efab2ca052f00c7"
 	CommPubB = "n7vb+4YNgTDwjJ1St3/UQP+bXrN/mMmsPgTjKthIMpoMYN7mRhpk6/MGa6Gv0p1Zbw39g6fVsluHSXvyYO6VmsahTQ0gI9MEmxgKt4c6ZQct6M+kWP7E3omXT68NsXXXaZBjBuewfHrJReTz/znbS66HgY8BML55YDRKQBsmDz+cb/H6FWT7/mmPBRXufz7sf6OqvwiMRGXNlRbktbEn3gpumXpndlGhmGL0ZVZj2VklqWSHtgsfBut+rov7uuIN28StPZYZvllnCCvP1DHeImExWHOltWTnZAE0pRUbaX3q3NVAqU4ngL1sbkMSghF8bmz8G26qawM7YNiiDrAmcQ=="
 	CommPrivB = "P0og9Hz99tVcSmq/boOQpxxgBFrc0L3/qCcplz1RBfOxueQ3m0kz+aU2QwkycCH2YKFLdJHYgy3u4bfhpnSCBGx1VuE/fdJLfeQ9wtAq3ALHNvqm5Lg1avNbZ7A1nb3SVzplckP00q2X+ECqSNM0x7zkZfoyf4zI7MxrKxFWuC1c1BT7zj7EUT1idG+n/yz3WCx4Xr+4XM3CIt1dTrddhCboLdLlNYCOIh4t5JSTfYysp8YR4FSc96vRVCe+QCVtMOfo7RCR8bcZDoIQjat+u5umnyAsyXLetBerh/MABqHq8wOgC6a8vCqRnyAwhLOT+VQbTbFMQzLO9Lw9T8v9EQ=="
@@ -42,8 +37,6 @@ const (
 	CommPrim2B = "xVQiK60JgjOqSdQ6EKEjDxdxfWp1NDGpHbBLElzbqJyAHfd5KCPdwASLIR8V0WHIa12df877xGGL1W+SlXXXOsJaER+FfnlzxzaO5D8a3GqaYMJBYWyUBnf1f0/lgVvnJzh0hKHlKSlvJX2mbObD9mPeuYhEXNO1v7Vo0846sL0="
 
 	//Root account for testing
-	PubRoot1 = "6323cc034597195ae69bcfb628ecdffa5989c7503154c566bab4a87f3e9910ac"
-	PubRoot2 = "f6115b77a15852764c609c6a5c1739e698ebc6e49bf14617c561b9110039cec7"
 	PrivRoot = "277ed539f56122c25a6fc115d07d632b47e71416c9aebf1beb54ee704f11842c"
 	CommPubRoot = "1e2QBjDop/b9Gk4U1YUtxzTpDrMvFTNb4dFIm2mIxhimeiJtHKnc0xDR1LPqkHN9Ke+tCbg6T3csbONoj8NT+ePIYF97DuUUL9d0ok8QZaSoAOGVIQHLbdCE08zwq8qiwzFWsfJSyKVJe1Bwbjsp9OWaxHenA3f2SWALiK1ZHAA13YV+nxm5Jh2O4uSmmz3PLv7Iz7Lfpo1uhpa0qfWap8Eqsp1XSWj60yms+hfy3X/r57FrbHUjJqeVQUPOqPmRRl3r3j1P+l/b+WQNA0WYu1ArjI8T3BEohqLZW3tZcx4NssyVyiS59SU16Yu3qroAdkLnFP4YPBSgQhXRjVzt8w=="
 	CommPrivRoot = "jKphuoBsaw1wDdzrvB6PJF65JE5UFjeoIgswF+jD46YPyV1bq65RooN7xcXr5cHaujl76Vk3FkuBbbP2bBl+3WCWwC/oRboBlRex/IvKd1tWkQXDvmlkrzeeL3qhggSDE6AcpnN1VbPBZpFU7FaA1yQmqSsYKaK20jaSPvPlFRAllP1adSd+m3ZrJY5rPWzPkPDmeyLRhbTPMp2ke3gAVXn2JdX6hYwYBeZJv2ZnDM/ZQfmWezHJpjsaichnbB8mUiHOOqBnGXaHKKomgmveZ+UjLD7QN9x12NfRyhFM7Aih8iAgbK06CNzBMPvj4J3MGrJrZ1sjqpOw7ljLiGccGQ=="
@@ -52,81 +45,51 @@ const (
 
 
 	//Multisig account for testing
-	MultiSigPub1 = "d5a0c62eeaf699eeba121f92e08becd38577f57b83eba981dc057e92fde1ad22"
-	MultiSigPub2 = "a480e4ee6ff8b4edbf9470631ec27d3b1eb27f210d5a994a7cbcffa3bfce958e"
 	MultiSigPriv = "b8d1fa3cc7476eafca970ea222676647da1817d1d9dc602e9446290454ffe1a4"
 )
 
 //Globally accessible values for all other tests, (root)account-related
 var (
 	accA, accB, validatorAcc, multiSigAcc, rootAcc         	*protocol.Account
-	PrivKeyAccA, PrivKeyAccB, PrivKeyMultiSig, PrivKeyRoot 	*ecdsa.PrivateKey
+	PrivKeyAccA, PrivKeyAccB, PrivKeyMultiSig, PrivKeyRoot 	ed25519.PrivateKey
 	CommPrivKeyAccA, CommPrivKeyAccB, CommPrivKeyRoot	   	*rsa.PrivateKey
 	genesisBlock *protocol.Block
 )
 
+func keyFromSeed(seed string) ed25519.PrivateKey {
+	decoded, _ := hex.DecodeString(seed)
+	return ed25519.NewKeyFromSeed(decoded)
+}
+
 //Create some accounts that are used by the tests
 func addTestingAccounts() {
 	accA, accB, validatorAcc, multiSigAcc = new(protocol.Account), new(protocol.Account), new(protocol.Account), new(protocol.Account)
 
-	pubAccA1, _ := new(big.Int).SetString(PubA1, 16)
-	pubAccA2, _ := new(big.Int).SetString(PubA2, 16)
-	privAccA, _ := new(big.Int).SetString(PrivA, 16)
-	pubKeyAccA := ecdsa.PublicKey{
-		elliptic.P256(),
-		pubAccA1,
-		pubAccA2,
-	}
-	PrivKeyAccA = &ecdsa.PrivateKey{
-		pubKeyAccA,
-		privAccA,
-	}
-
+	PrivKeyAccA = keyFromSeed(PrivA)
 	CommPrivKeyAccA, _ = crypto.CreateRSAPrivKeyFromBase64(CommPubA, CommPrivA, []string{CommPrim1A, CommPrim2A})
 
-	copy(accA.Address[0:32], PrivKeyAccA.PublicKey.X.Bytes())
-	copy(accA.Address[32:64], PrivKeyAccA.PublicKey.Y.Bytes())
+	copy(accA.Address[:], PrivKeyAccA[32:])
 	copy(accA.CommitmentKey[:], CommPrivKeyAccA.PublicKey.N.Bytes())
 	hashAccA := protocol.SerializeHashContent(accA.Address)
 
-	pubAccB1, _ := new(big.Int).SetString(PubB1, 16)
-	pubAccB2, _ := new(big.Int).SetString(PubB2, 16)
-	privAccB, _ := new(big.Int).SetString(PrivB, 16)
-	pubKeyAccB := ecdsa.PublicKey{
-		elliptic.P256(),
-		pubAccB1,
-		pubAccB2,
-	}
-	PrivKeyAccB = &ecdsa.PrivateKey{
-		pubKeyAccB,
-		privAccB,
-	}
-
+	PrivKeyAccB = keyFromSeed(PrivB)
 	CommPrivKeyAccB, _ = crypto.CreateRSAPrivKeyFromBase64(CommPubB, CommPrivB, []string{CommPrim1B, CommPrim2B})
 
-	copy(accB.Address[0:32], PrivKeyAccB.PublicKey.X.Bytes())
-	copy(accB.Address[32:64], PrivKeyAccB.PublicKey.Y.Bytes())
+	copy(accB.Address[:], PrivKeyAccB[32:])
 	copy(accB.CommitmentKey[:], CommPrivKeyAccB.PublicKey.N.Bytes())
 	hashAccB := protocol.SerializeHashContent(accB.Address)
 
-	privMultiSig, _ := new(big.Int).SetString(MultiSigPriv, 16)
-	pubKeyMultiSig, _ := crypto.GetPubKeyFromString(MultiSigPub1, MultiSigPub2)
-	PrivKeyMultiSig = &ecdsa.PrivateKey{
-		*pubKeyMultiSig,
-		privMultiSig,
-	}
+	PrivKeyMultiSig = keyFromSeed(MultiSigPriv)
 
-	copy(multiSigAcc.Address[0:32], PrivKeyMultiSig.PublicKey.X.Bytes())
-	copy(multiSigAcc.Address[32:64], PrivKeyMultiSig.PublicKey.Y.Bytes())
+	copy(multiSigAcc.Address[:], PrivKeyMultiSig[32:])
 	hashMultiSig := protocol.SerializeHashContent(multiSigAcc.Address)
 
 	//Set the global variable in blockchain.go
-	multisigPubKey = pubKeyMultiSig
+	multisigPubKey = PrivKeyMultiSig.Public().(ed25519.PublicKey)
 
-	privKeyValidator, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privKeyValidator, _ := ed25519.GenerateKey(rand.Reader)
 
-	copy(validatorAcc.Address[:32], privKeyValidator.X.Bytes())
-	copy(validatorAcc.Address[32:64], privKeyValidator.Y.Bytes())
+	copy(validatorAcc.Address[:], privKeyValidator[32:])
 	hashValidator := protocol.SerializeHashContent(validatorAcc.Address)
 
 	//Create and store an initial commitment key for the validator account.
@@ -138,40 +101,26 @@ func addTestingAccounts() {
 
 	//Set the global variable in blockchain.go
 	validatorAccAddress = validatorAcc.Address
-	commPrivKey = commPrivKeyValidator
+	commSigner = crypto.NewLocalSigner(commPrivKeyValidator)
 
-	storage.State[hashAccA] = accA
-	storage.State[hashAccB] = accB
-	storage.State[hashMultiSig] = multiSigAcc
-	storage.State[hashValidator] = validatorAcc
+	storage.State.Set(hashAccA, accA)
+	storage.State.Set(hashAccB, accB)
+	storage.State.Set(hashMultiSig, multiSigAcc)
+	storage.State.Set(hashValidator, validatorAcc)
 }
 
 //Create some root accounts that are used by the tests
 func addRootAccounts() {
 	rootAcc = new(protocol.Account)
 
-	pubRoot1, _ := new(big.Int).SetString(PubRoot1, 16)
-	pubRoot2, _ := new(big.Int).SetString(PubRoot2, 16)
-	privRoot, _ := new(big.Int).SetString(PrivRoot, 16)
-	pubKeyRoot := ecdsa.PublicKey{
-		elliptic.P256(),
-		pubRoot1,
-		pubRoot2,
-	}
-	PrivKeyRoot = &ecdsa.PrivateKey{
-		pubKeyRoot,
-		privRoot,
-	}
+	PrivKeyRoot = keyFromSeed(PrivRoot)
 
-	copy(rootAcc.Address[:32], PrivKeyRoot.X.Bytes())
-	copy(rootAcc.Address[32:64], PrivKeyRoot.Y.Bytes())
+	copy(rootAcc.Address[:], PrivKeyRoot[32:])
 	hashRoot := protocol.SerializeHashContent(rootAcc.Address)
 
 	//Create root file
-	file, _ := os.Create(TestKeyFileName)
-	_, _ = file.WriteString(PubRoot1 + "\n")
-	_, _ = file.WriteString(PubRoot2 + "\n")
-	_, _ = file.WriteString(PrivRoot + "\n")
+	os.Remove(TestKeyFileName)
+	crypto.WriteEDKeyFile(TestKeyFileName, PrivKeyRoot)
 
 	CommPrivKeyRoot, _ = crypto.CreateRSAPrivKeyFromBase64(CommPubRoot, CommPrivRoot, []string{CommPrimRoot1, CommPrimRoot2})
 	copy(rootAcc.CommitmentKey[:], CommPrivKeyRoot.PublicKey.N.Bytes()[:])
@@ -179,7 +128,7 @@ func addRootAccounts() {
 	rootAcc.Balance = activeParameters.Staking_minimum
 	rootAcc.IsStaking = true
 
-	storage.State[hashRoot] = rootAcc
+	storage.State.Set(hashRoot, rootAcc)
 	storage.RootKeys[hashRoot] = rootAcc
 }
 
@@ -191,7 +140,7 @@ func cleanAndPrepare() {
 	tmpState := make(map[[32]byte]*protocol.Account)
 	tmpRootKeys := make(map[[32]byte]*protocol.Account)
 
-	storage.State = tmpState
+	storage.State = storage.NewAccountStore(tmpState)
 	storage.RootKeys = tmpRootKeys
 
 	lastBlock = nil
@@ -207,12 +156,12 @@ func cleanAndPrepare() {
 	var tmpSlice []Parameters
 	tmpSlice = append(tmpSlice, NewDefaultParameters())
 
-	slashingDict = make(map[[32]byte]SlashingProof)
+	slashingDict = make(map[[32]byte]*protocol.SlashingProof)
 
 	parameterSlice = tmpSlice
 	activeParameters = &tmpSlice[0]
 
-	slashingDict = make(map[[32]byte]SlashingProof)
+	slashingDict = make(map[[32]byte]*protocol.SlashingProof)
 
 	//Override some params to ensure tests work correctly.
 	activeParameters.num_included_prev_proofs = 0
@@ -221,9 +170,10 @@ func cleanAndPrepare() {
 
 	addTestingAccounts()
 	addRootAccounts()
+	storeValidatorSet(0)
 
 	genesisCommitmentProof, _ := crypto.SignMessageWithRSAKey(CommPrivKeyRoot, "0")
-	genesisBlock = newBlock([32]byte{}, genesisCommitmentProof, 0)
+	genesisBlock = newBlock([32]byte{}, [32]byte{}, genesisCommitmentProof, 0)
 
 	collectStatistics(genesisBlock)
 	if err := storage.WriteClosedBlock(genesisBlock); err != nil {
@@ -243,14 +193,16 @@ func cleanAndPrepare() {
 
 func TestMain(m *testing.M) {
 	storage.Init(TestDBFileName, TestIpPort)
+	InitMempool(MEMPOOL_DEFAULT_SIZE)
 	p2p.Init(TestIpPort)
 
 	cleanAndPrepare()
 	addTestingAccounts()
 	addRootAccounts()
 	//We don't want logging msgs when testing, we have designated messages
-	logger = log.New(nil, "", 0)
-	logger.SetOutput(ioutil.Discard)
+	logging.SetLevel("", logging.ERROR)
+	//Blocks are mined one after another, no need to wait for other proposers.
+	SetProposalBackoff(0, 0)
 	retCode := m.Run()
 
 	//Teardown
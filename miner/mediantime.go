@@ -0,0 +1,55 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"sort"
+)
+
+//The timestamp of a block is also the nonce of its proof of stake. The only bound the time check puts on it is the
+//local clock plus Accepted_time_diff, so a validator could try timestamps in the past, long before the previous block,
+//until it finds one below the target. A block's timestamp therefore has to be above the median of the timestamps of the
+//previous MEDIAN_TIME_BLOCKS blocks. The median follows the chain rather than the local clock, a few validators with
+//wrong clocks can not move it.
+
+const MEDIAN_TIME_BLOCKS = 11
+
+//Returns the median timestamp of the MEDIAN_TIME_BLOCKS blocks below block, 0 for the blocks right after genesis (whose
+//timestamp is 0).
+func medianTimePast(block *protocol.Block) int64 {
+	var timestamps []int64
+	for block.Height > 0 && len(timestamps) < MEDIAN_TIME_BLOCKS {
+		prevBlock := storage.ReadClosedBlock(block.PrevHash)
+		if prevBlock == nil {
+			prevBlock = storage.ReadClosedBlockWithoutTx(block.PrevHashWithoutTx)
+		}
+		if prevBlock == nil {
+			break
+		}
+
+		timestamps = append(timestamps, prevBlock.Timestamp)
+		block = prevBlock
+	}
+
+	if len(timestamps) == 0 {
+		return 0
+	}
+
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	return timestamps[len(timestamps)/2]
+}
+
+//Blocks up to the Median_time_height, e.g., of chains started before the check was introduced, are not checked.
+func medianTimeCheck(block *protocol.Block) error {
+	if uint64(block.Height) <= parametersAt(block.Height).Median_time_height {
+		return nil
+	}
+
+	if median := medianTimePast(block); block.Timestamp <= median {
+		return errors.New(fmt.Sprintf("Block timestamp %v is not above the median time past %v.", block.Timestamp, median))
+	}
+
+	return nil
+}
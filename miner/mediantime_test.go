@@ -0,0 +1,64 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"testing"
+)
+
+func TestMedianTimePast(t *testing.T) {
+	//A chain of 15 blocks above genesis, with the timestamps out of order.
+	timestamps := []int64{0, 100, 120, 110, 130, 90, 150, 140, 160, 170, 125, 180, 190, 135, 200, 210}
+	var blocks []*protocol.Block
+	var prevHash [32]byte
+	for height, timestamp := range timestamps {
+		block := &protocol.Block{Hash: [32]byte{0x3e, byte(height)}, PrevHash: prevHash, Height: uint32(height), Timestamp: timestamp}
+		storage.WriteClosedBlock(block)
+		defer storage.DeleteClosedBlock(block.Hash)
+		blocks = append(blocks, block)
+		prevHash = block.Hash
+	}
+
+	for _, test := range []struct {
+		height uint32
+		median int64
+	}{
+		{0, 0},
+		{1, 0},
+		{2, 100},
+		{3, 100},
+		//Sorted: 0 90 100 110 120 130 140 150 160 170 180, the genesis timestamp is still included.
+		{12, 130},
+		//Sorted: 90 125 130 135 140 150 160 170 180 190 200
+		{15, 150},
+	} {
+		if median := medianTimePast(blocks[test.height]); median != test.median {
+			t.Errorf("Median time past at height %v is %v, expected %v.\n", test.height, median, test.median)
+		}
+	}
+
+	next := &protocol.Block{PrevHash: blocks[15].Hash, Height: 16, Timestamp: 160}
+	if err := medianTimeCheck(next); err != nil {
+		t.Errorf("Median time past checked without a Median_time_height: %v\n", err)
+	}
+
+	params := parametersAt(next.Height)
+	defer func(height uint64) { params.Median_time_height = height }(params.Median_time_height)
+	params.Median_time_height = 0
+
+	//A timestamp before the previous block is fine as long as it is above the median (160).
+	next.Timestamp = 161
+	if err := medianTimeCheck(next); err != nil {
+		t.Errorf("Block above the median time past rejected: %v\n", err)
+	}
+
+	next.Timestamp = 160
+	if err := medianTimeCheck(next); err == nil {
+		t.Error("Block at the median time past accepted.\n")
+	}
+
+	params.Median_time_height = 16
+	if err := medianTimeCheck(next); err != nil {
+		t.Errorf("Block up to the Median_time_height checked: %v\n", err)
+	}
+}
@@ -0,0 +1,694 @@
+package miner
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"sync"
+	"time"
+)
+
+//The mempool holds all open (not yet validated) transactions. Its size is limited, if it is full the transactions
+//paying the lowest fee per byte are evicted first. Without a limit, flooding the network with cheap transactions
+//would exhaust the memory of every miner.
+//FundsTxs, IotTxs and ContractTxs of a sender need to be included in txCnt order. Transactions arriving out of order
+//(txCnt higher than the next expected one) are parked and released as soon as the gap is closed. Transactions with a
+//txCnt lower than the sender's state are rejected, they have already been included (replay). Parked transactions count
+//towards the size limit as well.
+//Transactions referencing accounts which do not exist yet (e.g., a fundsTx to an account whose accTx is still open)
+//can not be included and are kept as orphans. Once a block creates the missing accounts, they are admitted like newly
+//received transactions.
+//Orphans can not be verified before their sender exists, their number is limited and they expire.
+//A pending fundsTx can be replaced by a fundsTx of the same sender with the same txCnt paying a higher fee
+//(replace-by-fee), e.g., to speed up a tx stuck with a fee that is too low. Otherwise, the first tx received for a txCnt
+//wins. Every replacement is relayed to all miners, so a replacement has to raise the fee by RBF_MIN_FEE_BUMP percent
+//(at least by the minimum fee) and the tx with a txCnt can only be replaced MAX_TX_REPLACEMENTS times.
+
+const (
+	//Default size limit of the mempool in bytes (encoded transactions).
+	MEMPOOL_DEFAULT_SIZE = 32 * 1024 * 1024
+
+	//Maximum distance between a parked transaction's txCnt and the sender's state txCnt.
+	MAX_TXCNT_GAP = 100
+
+	MAX_ORPHANS    = 1000
+	ORPHAN_TIMEOUT = 30 * time.Minute
+
+	RBF_MIN_FEE_BUMP    = 10
+	MAX_TX_REPLACEMENTS = 10
+)
+
+type mempoolEntry struct {
+	tx         protocol.Transaction
+	hash       [32]byte
+	size       uint64
+	feePerByte float64
+	parked     bool
+	orphaned   bool
+	//Number of txs this one replaced with the same txCnt, see replace.
+	replacements int
+	received   time.Time
+	//Last time the transaction has been broadcast, see rebroadcast.go.
+	broadcast time.Time
+	//Labels added by tx policies, see txpolicy.go.
+	annotations []string
+	//Position in the eviction heap, -1 if the entry can't be evicted.
+	index int
+}
+
+//Min-heap of the evictable entries ordered by fee per byte.
+type evictionHeap []*mempoolEntry
+
+type Mempool struct {
+	maxSize  uint64
+	size     uint64
+	txs      map[[32]byte]*mempoolEntry
+	senders  map[[32]byte]map[uint32]*mempoolEntry
+	parked   map[[32]byte]map[uint32]*mempoolEntry
+	orphans  map[[32]byte]*mempoolEntry
+	eviction evictionHeap
+	evicted  uint64
+	rejected uint64
+	replaced uint64
+	mutex    sync.Mutex
+}
+
+type MempoolStats struct {
+	Txs           int     `json:"txs"`
+	Parked        int     `json:"parked"`
+	Orphans       int     `json:"orphans"`
+	Size          uint64  `json:"size"`
+	MaxSize       uint64  `json:"maxSize"`
+	Evicted       uint64  `json:"evicted"`
+	Rejected      uint64  `json:"rejected"`
+	Replaced      uint64  `json:"replaced"`
+	MinFeePerByte float64 `json:"minFeePerByte"`
+}
+
+var mempool *Mempool
+
+//Creates the miner's mempool and registers it with the storage package, which gives the p2p package access to it.
+func InitMempool(maxSize uint64) {
+	mempool = NewMempool(maxSize)
+	storage.SetMempool(mempool)
+	publishMempoolStats()
+}
+
+func NewMempool(maxSize uint64) *Mempool {
+	return &Mempool{
+		maxSize: maxSize,
+		txs:     make(map[[32]byte]*mempoolEntry),
+		senders: make(map[[32]byte]map[uint32]*mempoolEntry),
+		parked:  make(map[[32]byte]map[uint32]*mempoolEntry),
+		orphans: make(map[[32]byte]*mempoolEntry),
+	}
+}
+
+//Returns the sender and txCnt of transactions which need to be ordered.
+func orderedTxCnt(transaction protocol.Transaction) (sender [32]byte, txCnt uint32, ordered bool) {
+	switch tx := transaction.(type) {
+	case *protocol.FundsTx:
+		return tx.From, tx.TxCnt, true
+	case *protocol.IotTx:
+		return tx.From, tx.TxCnt, true
+	case *protocol.ContractTx:
+		return tx.From, tx.TxCnt, true
+	}
+
+	return sender, 0, false
+}
+
+func newMempoolEntry(tx protocol.Transaction) *mempoolEntry {
+	now := time.Now()
+	entry := &mempoolEntry{tx: tx, hash: tx.Hash(), size: uint64(len(tx.Encode())), received: now, broadcast: now, index: -1}
+	if entry.size > 0 {
+		entry.feePerByte = float64(tx.TxFee()) / float64(entry.size)
+	}
+
+	return entry
+}
+
+//Adds the transaction without checking its txCnt, e.g., when transactions of a rolled back block are reopened.
+func (m *Mempool) Add(tx protocol.Transaction) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.txs[tx.Hash()]; exists {
+		return nil
+	}
+
+	return m.add(newMempoolEntry(tx))
+}
+
+//Admission of transactions received from the network or submitted by clients. Tx policies are applied and the txCnt
+//of fundsTxs, iotTxs and contractTxs is checked.
+func (m *Mempool) AddOrdered(tx protocol.Transaction) error {
+	annotations, err := applyTxPolicies(tx, POLICY_STAGE_ADMISSION)
+
+	//Only readings signed by the device are recorded, a forged tx must not block the device's reading. verifyIotTx
+	//changes the addresses of the tx while verifying, a copy is verified.
+	iotTx, isIotTx := tx.(*protocol.IotTx)
+	var signedReading bool
+	if isIotTx && err == nil && iotTx.Sequence != 0 {
+		iotTxCopy := *iotTx
+		signedReading = verify(&iotTxCopy)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if _, exists := m.txs[tx.Hash()]; exists {
+		return nil
+	}
+
+	if err != nil {
+		m.rejected++
+		return err
+	}
+
+	if isIotTx {
+		if err := checkIoTDuplicate(iotTx); err != nil {
+			m.rejected++
+			return err
+		}
+	}
+
+	entry := newMempoolEntry(tx)
+	entry.annotations = annotations
+
+	//Txs of unknown senders become orphans if they are still invalid when a block is built, see prepareBlock.
+	if sender, _, ordered := orderedTxCnt(tx); !ordered || storage.State.Get(sender) == nil {
+		err = m.add(entry)
+	} else {
+		err = m.addOrdered(entry)
+	}
+
+	if err == nil && signedReading {
+		recordIoTReading(iotTx)
+	}
+
+	return err
+}
+
+//Parks the transaction until the transactions with lower txCnt of its sender are included or arrive.
+func (m *Mempool) Park(tx protocol.Transaction) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.park(newMempoolEntry(tx))
+}
+
+//Keeps the transaction until the accounts it references exist.
+func (m *Mempool) Orphan(tx protocol.Transaction) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.orphan(newMempoolEntry(tx))
+}
+
+//Admits the orphans whose accounts exist by now, expired orphans are dropped. Returns the transactions moved to the
+//mempool, adopted orphans with a txCnt gap are parked instead.
+func (m *Mempool) AdoptOrphans() (adopted []protocol.Transaction) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for _, entry := range m.orphans {
+		if now.Sub(entry.received) > ORPHAN_TIMEOUT {
+			m.remove(entry)
+			continue
+		}
+
+		if len(missingAccounts(entry.tx)) > 0 {
+			continue
+		}
+
+		m.remove(entry)
+		entry.orphaned = false
+		if err := m.addOrdered(entry); err == nil && m.txs[entry.hash] == entry {
+			adopted = append(adopted, entry.tx)
+		}
+	}
+
+	return adopted
+}
+
+//Returns the open transactions which have not been broadcast within the interval and marks them as broadcast.
+//Parked transactions and orphans are not rebroadcast, peers would not accept them either.
+func (m *Mempool) Rebroadcastable(interval time.Duration) (txs []protocol.Transaction) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	now := time.Now()
+	for _, entry := range m.txs {
+		if _, isAggTx := entry.tx.(*protocol.AggTx); isAggTx || now.Sub(entry.broadcast) < interval {
+			continue
+		}
+
+		entry.broadcast = now
+		txs = append(txs, entry.tx)
+	}
+
+	return txs
+}
+
+//Moves the sender's parked transactions which follow the current state (and the transactions in the mempool) to the
+//mempool, in txCnt order. Parked transactions whose txCnt has been used in the meantime are dropped.
+func (m *Mempool) Release(sender [32]byte) (released []protocol.Transaction) {
+	acc := storage.State.Get(sender)
+	if acc == nil {
+		return nil
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return m.release(sender, acc.TxCnt)
+}
+
+func (m *Mempool) Get(hash [32]byte) protocol.Transaction {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if entry := m.txs[hash]; entry != nil {
+		return entry.tx
+	}
+
+	return nil
+}
+
+func (m *Mempool) Remove(hash [32]byte) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if entry := m.txs[hash]; entry != nil {
+		m.remove(entry)
+	}
+}
+
+//Adds labels to an open transaction, e.g., by tx policies at block building.
+func (m *Mempool) Annotate(hash [32]byte, annotations []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if entry := m.txs[hash]; entry != nil {
+		entry.annotations = append(entry.annotations, annotations...)
+	}
+}
+
+func (m *Mempool) Annotations(hash [32]byte) []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if entry := m.txs[hash]; entry != nil {
+		return entry.annotations
+	}
+
+	return nil
+}
+
+func (m *Mempool) All() (txs []protocol.Transaction) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, entry := range m.txs {
+		txs = append(txs, entry.tx)
+	}
+
+	return txs
+}
+
+func (m *Mempool) AllParked() (txs []protocol.Transaction) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, entries := range m.parked {
+		for _, entry := range entries {
+			txs = append(txs, entry.tx)
+		}
+	}
+
+	return txs
+}
+
+//Orphans count as parked, they are not in the mempool yet either.
+func (m *Mempool) IsParked(tx protocol.Transaction) bool {
+	sender, txCnt, ordered := orderedTxCnt(tx)
+	if !ordered {
+		return false
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.orphans[tx.Hash()] != nil {
+		return true
+	}
+
+	entry := m.parked[sender][txCnt]
+
+	return entry != nil && entry.hash == tx.Hash()
+}
+
+func (m *Mempool) Len() int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	return len(m.txs)
+}
+
+func (m *Mempool) Clear() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.size = 0
+	m.txs = make(map[[32]byte]*mempoolEntry)
+	m.senders = make(map[[32]byte]map[uint32]*mempoolEntry)
+	m.parked = make(map[[32]byte]map[uint32]*mempoolEntry)
+	m.orphans = make(map[[32]byte]*mempoolEntry)
+	m.eviction = nil
+}
+
+func (m *Mempool) Stats() MempoolStats {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	stats := MempoolStats{
+		Txs:      len(m.txs),
+		Orphans:  len(m.orphans),
+		Size:     m.size,
+		MaxSize:  m.maxSize,
+		Evicted:  m.evicted,
+		Rejected: m.rejected,
+		Replaced: m.replaced,
+	}
+
+	for _, entries := range m.parked {
+		stats.Parked += len(entries)
+	}
+
+	if len(m.eviction) > 0 {
+		stats.MinFeePerByte = m.eviction[0].feePerByte
+	}
+
+	return stats
+}
+
+//All functions below expect the mutex to be held by the caller.
+
+func (m *Mempool) add(entry *mempoolEntry) error {
+	if err := m.makeRoom(entry); err != nil {
+		return err
+	}
+
+	m.txs[entry.hash] = entry
+	m.size += entry.size
+
+	if sender, txCnt, ordered := orderedTxCnt(entry.tx); ordered {
+		if m.senders[sender] == nil {
+			m.senders[sender] = make(map[uint32]*mempoolEntry)
+		}
+		m.senders[sender][txCnt] = entry
+	}
+
+	m.pushEvictable(entry)
+
+	return nil
+}
+
+//Checks the txCnt of the entry against the sender's state, entries following a gap are parked.
+func (m *Mempool) addOrdered(entry *mempoolEntry) error {
+	sender, txCnt, _ := orderedTxCnt(entry.tx)
+	acc := storage.State.Get(sender)
+
+	if txCnt < acc.TxCnt {
+		m.rejected++
+		return errors.New(fmt.Sprintf("TxCnt too low: %v (tx.txCnt) vs. %v (state txCnt).", txCnt, acc.TxCnt))
+	}
+
+	if txCnt-acc.TxCnt > MAX_TXCNT_GAP {
+		m.rejected++
+		return errors.New(fmt.Sprintf("TxCnt too high: %v (tx.txCnt) vs. %v (state txCnt).", txCnt, acc.TxCnt))
+	}
+
+	if pending := m.senders[sender][txCnt]; pending != nil {
+		return m.replace(pending, entry)
+	}
+
+	if txCnt > m.nextTxCnt(sender, acc.TxCnt) {
+		return m.park(entry)
+	}
+
+	if err := m.add(entry); err != nil {
+		return err
+	}
+	m.release(sender, acc.TxCnt)
+
+	return nil
+}
+
+func (m *Mempool) park(entry *mempoolEntry) error {
+	sender, txCnt, ordered := orderedTxCnt(entry.tx)
+	if !ordered {
+		return errors.New("Only fundsTxs, iotTxs and contractTxs can be parked.")
+	}
+
+	if parkedEntry := m.parked[sender][txCnt]; parkedEntry != nil {
+		if parkedEntry.hash != entry.hash {
+			return m.replace(parkedEntry, entry)
+		}
+		return nil
+	}
+
+	if err := m.makeRoom(entry); err != nil {
+		return err
+	}
+
+	if m.parked[sender] == nil {
+		m.parked[sender] = make(map[uint32]*mempoolEntry)
+	}
+	entry.parked = true
+	m.parked[sender][txCnt] = entry
+	m.size += entry.size
+	m.pushEvictable(entry)
+
+	return nil
+}
+
+func (m *Mempool) orphan(entry *mempoolEntry) error {
+	if m.orphans[entry.hash] != nil {
+		return nil
+	}
+
+	//Orphans are not verified, the oldest one makes room for a new one to keep the pool from being filled forever.
+	if len(m.orphans) >= MAX_ORPHANS {
+		var oldest *mempoolEntry
+		for _, orphan := range m.orphans {
+			if oldest == nil || orphan.received.Before(oldest.received) {
+				oldest = orphan
+			}
+		}
+		m.remove(oldest)
+		m.evicted++
+	}
+
+	if err := m.makeRoom(entry); err != nil {
+		return err
+	}
+
+	entry.orphaned = true
+	m.orphans[entry.hash] = entry
+	m.size += entry.size
+	m.pushEvictable(entry)
+
+	return nil
+}
+
+func (m *Mempool) remove(entry *mempoolEntry) {
+	sender, txCnt, ordered := orderedTxCnt(entry.tx)
+
+	if entry.orphaned {
+		delete(m.orphans, entry.hash)
+	} else if entry.parked {
+		delete(m.parked[sender], txCnt)
+		if len(m.parked[sender]) == 0 {
+			delete(m.parked, sender)
+		}
+	} else {
+		delete(m.txs, entry.hash)
+		if ordered && m.senders[sender][txCnt] == entry {
+			delete(m.senders[sender], txCnt)
+			if len(m.senders[sender]) == 0 {
+				delete(m.senders, sender)
+			}
+		}
+	}
+
+	if entry.index >= 0 {
+		heap.Remove(&m.eviction, entry.index)
+	}
+	m.size -= entry.size
+}
+
+func (m *Mempool) release(sender [32]byte, stateTxCnt uint32) (released []protocol.Transaction) {
+	for txCnt, entry := range m.parked[sender] {
+		if txCnt < stateTxCnt {
+			m.remove(entry)
+		}
+	}
+
+	next := m.nextTxCnt(sender, stateTxCnt)
+	for entry := m.parked[sender][next]; entry != nil; entry = m.parked[sender][next] {
+		delete(m.parked[sender], next)
+		entry.parked = false
+		m.txs[entry.hash] = entry
+		if m.senders[sender] == nil {
+			m.senders[sender] = make(map[uint32]*mempoolEntry)
+		}
+		m.senders[sender][next] = entry
+		released = append(released, entry.tx)
+		next++
+	}
+
+	if len(m.parked[sender]) == 0 {
+		delete(m.parked, sender)
+	}
+
+	return released
+}
+
+//Replaces the pending or parked entry with the same sender and txCnt by the entry if it pays enough more.
+func (m *Mempool) replace(pending *mempoolEntry, entry *mempoolEntry) error {
+	if err := checkReplacement(pending, entry); err != nil {
+		m.rejected++
+		return err
+	}
+
+	parked := pending.parked
+	m.remove(pending)
+
+	entry.replacements = pending.replacements + 1
+	add := m.add
+	if parked {
+		add = m.park
+	}
+
+	//The pending entry is restored if the replacement does not fit, e.g., because it is larger.
+	if err := add(entry); err != nil {
+		add(pending)
+		return err
+	}
+	m.replaced++
+
+	return nil
+}
+
+func checkReplacement(pending *mempoolEntry, entry *mempoolEntry) error {
+	_, txCnt, _ := orderedTxCnt(entry.tx)
+	_, pendingIsFundsTx := pending.tx.(*protocol.FundsTx)
+	if _, isFundsTx := entry.tx.(*protocol.FundsTx); !isFundsTx || !pendingIsFundsTx {
+		return errors.New(fmt.Sprintf("Another transaction with txCnt %v is already pending, only fundsTxs can be replaced.", txCnt))
+	}
+
+	if pending.replacements >= MAX_TX_REPLACEMENTS {
+		return errors.New(fmt.Sprintf("Transaction with txCnt %v has already been replaced %v times.", txCnt, pending.replacements))
+	}
+
+	bump := pending.tx.TxFee() / 100 * RBF_MIN_FEE_BUMP
+	if activeParameters != nil && bump < activeParameters.Fee_minimum {
+		bump = activeParameters.Fee_minimum
+	}
+	if bump == 0 {
+		bump = 1
+	}
+
+	if minFee := pending.tx.TxFee() + bump; entry.tx.TxFee() < minFee || entry.feePerByte < pending.feePerByte {
+		return errors.New(fmt.Sprintf("Replacement fee too low: %v, at least %v (and the same fee per byte) required.", entry.tx.TxFee(), minFee))
+	}
+
+	return nil
+}
+
+//Returns the txCnt for the sender's next tx, following its txs in the state and in the mempool.
+func (m *Mempool) NextTxCnt(sender [32]byte) uint32 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	acc := storage.State.Get(sender)
+	if acc == nil {
+		return 0
+	}
+
+	return m.nextTxCnt(sender, acc.TxCnt)
+}
+
+//Returns the txCnt following the consecutive sequence of the sender's transactions in the mempool.
+func (m *Mempool) nextTxCnt(sender [32]byte, stateTxCnt uint32) uint32 {
+	next := stateTxCnt
+	for m.senders[sender][next] != nil {
+		next++
+	}
+
+	return next
+}
+
+//AggTxs are created by the miner itself while building a block and must never be evicted.
+func (m *Mempool) pushEvictable(entry *mempoolEntry) {
+	if _, isAggTx := entry.tx.(*protocol.AggTx); !isAggTx {
+		heap.Push(&m.eviction, entry)
+	}
+}
+
+//Evicts the transactions with the lowest fee per byte until the entry fits. Fails if the entry itself doesn't pay
+//more than the transactions it would replace.
+func (m *Mempool) makeRoom(entry *mempoolEntry) error {
+	if entry.size > m.maxSize {
+		m.rejected++
+		return errors.New(fmt.Sprintf("Transaction size (%v) exceeds the mempool size (%v).", entry.size, m.maxSize))
+	}
+
+	for m.size+entry.size > m.maxSize {
+		if len(m.eviction) == 0 || m.eviction[0].feePerByte >= entry.feePerByte {
+			m.rejected++
+			return errors.New(fmt.Sprintf("Mempool full, fee per byte too low: %v.", entry.feePerByte))
+		}
+
+		m.remove(m.eviction[0])
+		m.evicted++
+	}
+
+	return nil
+}
+
+//Implement the heap interface
+func (h evictionHeap) Len() int {
+	return len(h)
+}
+
+func (h evictionHeap) Less(i, j int) bool {
+	return h[i].feePerByte < h[j].feePerByte
+}
+
+func (h evictionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *evictionHeap) Push(x interface{}) {
+	entry := x.(*mempoolEntry)
+	entry.index = len(*h)
+	*h = append(*h, entry)
+}
+
+func (h *evictionHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	old[len(old)-1] = nil
+	entry.index = -1
+	*h = old[:len(old)-1]
+
+	return entry
+}
@@ -0,0 +1,312 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func newMempoolTestTx(sender [32]byte, txCnt uint32, fee uint64) *protocol.FundsTx {
+	return &protocol.FundsTx{Amount: 1, Fee: fee, TxCnt: txCnt, From: sender}
+}
+
+func TestMempoolEviction(t *testing.T) {
+	txSize := uint64(len(newMempoolTestTx([32]byte{}, 0, 1).Encode()))
+	pool := NewMempool(3 * txSize)
+
+	cheap := newMempoolTestTx([32]byte{'a'}, 0, 1)
+	medium := newMempoolTestTx([32]byte{'b'}, 0, 2)
+	expensive := newMempoolTestTx([32]byte{'c'}, 0, 3)
+	for _, tx := range []*protocol.FundsTx{cheap, medium, expensive} {
+		if err := pool.Add(tx); err != nil {
+			t.Fatalf("Adding tx failed: %v\n", err)
+		}
+	}
+
+	//Not paying more than the cheapest tx, rejected.
+	if err := pool.Add(newMempoolTestTx([32]byte{'d'}, 0, 1)); err == nil {
+		t.Error("Tx paying the lowest fee should be rejected if the mempool is full.")
+	}
+
+	//Paying more, the cheapest tx is evicted.
+	richest := newMempoolTestTx([32]byte{'e'}, 0, 4)
+	if err := pool.Add(richest); err != nil {
+		t.Errorf("Tx paying a higher fee should evict the cheapest: %v\n", err)
+	}
+
+	if pool.Get(cheap.Hash()) != nil || pool.Get(richest.Hash()) == nil {
+		t.Error("Cheapest tx should have been evicted.")
+	}
+
+	stats := pool.Stats()
+	if stats.Txs != 3 || stats.Evicted != 1 || stats.Rejected != 1 || stats.Size != 3*txSize {
+		t.Errorf("Unexpected mempool stats: %+v\n", stats)
+	}
+
+	pool.Remove(medium.Hash())
+	if pool.Len() != 2 || pool.Stats().Size != 2*txSize {
+		t.Errorf("Removing tx did not free its space: %+v\n", pool.Stats())
+	}
+
+	if stats := pool.Stats(); stats.MinFeePerByte != float64(3)/float64(txSize) {
+		t.Errorf("Lowest fee per byte should be the one of the remaining cheapest tx: %v\n", stats.MinFeePerByte)
+	}
+}
+
+func TestMempoolAggTxNotEvicted(t *testing.T) {
+	aggTx, _ := protocol.ConstrAggTx(1, 0, [][32]byte{{'a'}}, [][32]byte{{'b'}}, [][32]byte{{'c'}})
+	pool := NewMempool(uint64(len(aggTx.Encode())))
+
+	if err := pool.Add(aggTx); err != nil {
+		t.Fatalf("Adding aggTx failed: %v\n", err)
+	}
+
+	if err := pool.Add(newMempoolTestTx([32]byte{'a'}, 0, 1000)); err == nil {
+		t.Error("AggTx must not be evicted.")
+	}
+}
+
+func TestMempoolOrdering(t *testing.T) {
+	sender := [32]byte{'o', 'r', 'd', 'e', 'r', 'e', 'd'}
+	storage.State.Set(sender, &protocol.Account{Address: sender, TxCnt: 5})
+	defer storage.State.Delete(sender)
+
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	txs := make([]*protocol.FundsTx, 9)
+	for i := range txs {
+		txs[i] = newMempoolTestTx(sender, uint32(i), 1)
+	}
+
+	//Already used txCnt (replay).
+	if err := pool.AddOrdered(txs[4]); err == nil {
+		t.Error("Tx with lower txCnt than the state should be rejected.")
+	}
+
+	//Arriving in reverse order, txs are parked until the gap is closed.
+	for _, cnt := range []int{8, 7, 6} {
+		if err := pool.AddOrdered(txs[cnt]); err != nil {
+			t.Errorf("Tx with txCnt %v should be parked: %v\n", cnt, err)
+		}
+		if pool.Get(txs[cnt].Hash()) != nil || !pool.IsParked(txs[cnt]) {
+			t.Errorf("Tx with txCnt %v should be parked, not in the mempool.\n", cnt)
+		}
+	}
+
+	if err := pool.AddOrdered(txs[5]); err != nil {
+		t.Errorf("Next tx should be accepted: %v\n", err)
+	}
+
+	for _, cnt := range []int{5, 6, 7, 8} {
+		if pool.Get(txs[cnt].Hash()) == nil || pool.IsParked(txs[cnt]) {
+			t.Errorf("Tx with txCnt %v should have been released to the mempool.\n", cnt)
+		}
+	}
+
+	if len(pool.AllParked()) != 0 {
+		t.Errorf("No tx should be parked anymore: %v\n", len(pool.AllParked()))
+	}
+
+	//Txs too far ahead are rejected.
+	if err := pool.AddOrdered(newMempoolTestTx(sender, 5+MAX_TXCNT_GAP+1, 1)); err == nil {
+		t.Error("Tx exceeding the maximum txCnt gap should be rejected.")
+	}
+}
+
+func TestMempoolReplaceByFee(t *testing.T) {
+	sender := [32]byte{'r', 'b', 'f'}
+	storage.State.Set(sender, &protocol.Account{Address: sender, TxCnt: 0})
+	defer storage.State.Delete(sender)
+
+	prevParameters := activeParameters
+	defer func() { activeParameters = prevParameters }()
+	activeParameters = &Parameters{Fee_minimum: 1}
+
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	pending := newMempoolTestTx(sender, 0, 100)
+	parked := newMempoolTestTx(sender, 2, 100)
+	for _, tx := range []*protocol.FundsTx{pending, parked} {
+		if err := pool.AddOrdered(tx); err != nil {
+			t.Fatalf("Adding tx failed: %v\n", err)
+		}
+	}
+
+	//The fee has to be raised by RBF_MIN_FEE_BUMP percent.
+	if err := pool.AddOrdered(newMempoolTestTx(sender, 0, 105)); err == nil {
+		t.Error("Replacement with a too small fee bump should be rejected.")
+	}
+
+	replacement := newMempoolTestTx(sender, 0, 110)
+	if err := pool.AddOrdered(replacement); err != nil {
+		t.Fatalf("Replacement should be accepted: %v\n", err)
+	}
+	if pool.Get(pending.Hash()) != nil || pool.Get(replacement.Hash()) == nil || pool.Len() != 1 {
+		t.Errorf("Only the replacement should be in the mempool: %v txs\n", pool.Len())
+	}
+
+	parkedReplacement := newMempoolTestTx(sender, 2, 200)
+	if err := pool.AddOrdered(parkedReplacement); err != nil {
+		t.Fatalf("Replacement of a parked tx should be accepted: %v\n", err)
+	}
+	if parkedTxs := pool.AllParked(); len(parkedTxs) != 1 || parkedTxs[0].Hash() != parkedReplacement.Hash() {
+		t.Errorf("Only the replacement should be parked: %v\n", parkedTxs)
+	}
+
+	if stats := pool.Stats(); stats.Replaced != 2 {
+		t.Errorf("Replacements not counted: %v\n", stats.Replaced)
+	}
+
+	//Only fundsTxs can be replaced.
+	iotTx := &protocol.IotTx{From: sender, TxCnt: 1, Fee: 100}
+	if err := pool.AddOrdered(iotTx); err != nil {
+		t.Fatalf("Adding iotTx failed: %v\n", err)
+	}
+	if err := pool.AddOrdered(newMempoolTestTx(sender, 1, 1000)); err == nil {
+		t.Error("Replacement of an iotTx should be rejected.")
+	}
+}
+
+func TestMempoolReplacementLimit(t *testing.T) {
+	sender := [32]byte{'r', 'b', 'f', 'l'}
+	storage.State.Set(sender, &protocol.Account{Address: sender, TxCnt: 0})
+	defer storage.State.Delete(sender)
+
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	fee := uint64(1)
+	if err := pool.AddOrdered(newMempoolTestTx(sender, 0, fee)); err != nil {
+		t.Fatalf("Adding tx failed: %v\n", err)
+	}
+
+	for i := 0; i < MAX_TX_REPLACEMENTS; i++ {
+		fee *= 2
+		if err := pool.AddOrdered(newMempoolTestTx(sender, 0, fee)); err != nil {
+			t.Fatalf("Replacement %v should be accepted: %v\n", i+1, err)
+		}
+	}
+
+	if err := pool.AddOrdered(newMempoolTestTx(sender, 0, fee*2)); err == nil {
+		t.Error("Replacement exceeding MAX_TX_REPLACEMENTS should be rejected.")
+	}
+	if pool.Len() != 1 {
+		t.Errorf("Only one tx per txCnt should be in the mempool: %v\n", pool.Len())
+	}
+}
+
+func TestMempoolRelease(t *testing.T) {
+	sender := [32]byte{'r', 'e', 'l', 'e', 'a', 's', 'e'}
+	storage.State.Set(sender, &protocol.Account{Address: sender, TxCnt: 0})
+	defer storage.State.Delete(sender)
+
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	tx1, tx2, tx4 := newMempoolTestTx(sender, 1, 1), newMempoolTestTx(sender, 2, 1), newMempoolTestTx(sender, 4, 1)
+	//Same txCnt and fee, not a replacement.
+	conflicting := &protocol.FundsTx{Amount: 2, Fee: 1, TxCnt: 2, From: sender}
+
+	pool.Park(tx1)
+	pool.Park(tx2)
+	pool.Park(tx4)
+
+	if err := pool.Park(conflicting); err == nil {
+		t.Error("Conflicting tx with the same txCnt should not be parked.")
+	}
+
+	//State advanced (e.g., tx with txCnt 0 and 1 were included in a block received from another miner).
+	storage.State.Get(sender).TxCnt = 2
+	released := pool.Release(sender)
+
+	if len(released) != 1 || released[0] != tx2 {
+		t.Errorf("Only tx with txCnt 2 should have been released: %v\n", released)
+	}
+
+	if pool.IsParked(tx1) {
+		t.Error("Outdated parked tx should have been dropped.")
+	}
+
+	if !pool.IsParked(tx4) {
+		t.Error("Tx with txCnt 4 should still be parked (txCnt 3 missing).")
+	}
+
+	if stats := pool.Stats(); stats.Txs != 1 || stats.Parked != 1 || stats.Size != 2*uint64(len(tx4.Encode())) {
+		t.Errorf("Unexpected mempool stats: %+v\n", stats)
+	}
+}
+
+func TestMempoolOrphans(t *testing.T) {
+	sender, receiver := [32]byte{'o', 'r', 'p', 'h', 'a', 'n'}, [32]byte{'r', 'e', 'c', 'e', 'i', 'v', 'e', 'r'}
+	defer storage.State.Delete(sender)
+	defer storage.State.Delete(receiver)
+
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	tx0, tx2 := newMempoolTestTx(sender, 0, 1), newMempoolTestTx(sender, 2, 1)
+	tx0.To, tx2.To = receiver, receiver
+	expired := newMempoolTestTx(sender, 1, 1)
+	expired.To = receiver
+
+	for _, tx := range []*protocol.FundsTx{tx0, tx2, expired} {
+		if err := pool.Orphan(tx); err != nil {
+			t.Fatalf("Orphaning tx failed: %v\n", err)
+		}
+	}
+	pool.orphans[expired.Hash()].received = time.Now().Add(-ORPHAN_TIMEOUT - time.Second)
+
+	if !pool.IsParked(tx0) || pool.Get(tx0.Hash()) != nil {
+		t.Error("Orphan should not be in the mempool yet.")
+	}
+
+	//Only the sender exists, the receiver is still missing.
+	storage.State.Set(sender, &protocol.Account{Address: sender, TxCnt: 0})
+	if adopted := pool.AdoptOrphans(); len(adopted) != 0 {
+		t.Errorf("Orphans with missing receiver should not be adopted: %v\n", adopted)
+	}
+
+	if stats := pool.Stats(); stats.Orphans != 2 || stats.Size != 2*uint64(len(tx0.Encode())) {
+		t.Errorf("Expired orphan should have been dropped: %+v\n", stats)
+	}
+
+	storage.State.Set(receiver, &protocol.Account{Address: receiver})
+	adopted := pool.AdoptOrphans()
+	if len(adopted) != 1 || adopted[0] != tx0 {
+		t.Errorf("Only the orphan with txCnt 0 should have been adopted: %v\n", adopted)
+	}
+
+	if pool.Get(tx0.Hash()) == nil || !pool.IsParked(tx2) {
+		t.Error("Orphan with txCnt 2 should have been parked (txCnt 1 missing).")
+	}
+
+	if stats := pool.Stats(); stats.Txs != 1 || stats.Parked != 1 || stats.Orphans != 0 {
+		t.Errorf("Unexpected mempool stats: %+v\n", stats)
+	}
+}
+
+func TestMempoolOrphanLimit(t *testing.T) {
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	oldest := newMempoolTestTx([32]byte{'o', 'l', 'd'}, 0, 1)
+	pool.Orphan(oldest)
+	pool.orphans[oldest.Hash()].received = time.Now().Add(-time.Minute)
+
+	for i := 1; i <= MAX_ORPHANS; i++ {
+		pool.Orphan(newMempoolTestTx([32]byte{'o', 'r', 'p', 'h', 'a', 'n'}, uint32(i), 1))
+	}
+
+	if stats := pool.Stats(); stats.Orphans != MAX_ORPHANS || stats.Evicted != 1 || pool.IsParked(oldest) {
+		t.Errorf("Oldest orphan should have been evicted: %+v\n", stats)
+	}
+}
+
+func TestMempoolRebroadcastable(t *testing.T) {
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	tx, recent := newMempoolTestTx([32]byte{'a'}, 0, 1), newMempoolTestTx([32]byte{'b'}, 0, 1)
+	pool.Add(tx)
+	pool.Add(recent)
+	pool.Park(newMempoolTestTx([32]byte{'c'}, 1, 1))
+	pool.txs[tx.Hash()].broadcast = time.Now().Add(-time.Hour)
+
+	if txs := pool.Rebroadcastable(time.Minute); len(txs) != 1 || txs[0] != tx {
+		t.Errorf("Only the tx not broadcast within the interval should be rebroadcast: %v\n", txs)
+	}
+
+	if txs := pool.Rebroadcastable(time.Minute); len(txs) != 0 {
+		t.Errorf("Rebroadcast tx should not be rebroadcast again within the interval: %v\n", txs)
+	}
+}
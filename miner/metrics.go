@@ -0,0 +1,54 @@
+package miner
+
+import (
+	"expvar"
+)
+
+//Reasons a block can be rejected with during prevalidation. They are used as keys of the rejected blocks metric.
+const (
+	REJECTED_TIMESTAMP        = "timestamp"
+	REJECTED_BLOCK_SIZE       = "block_size"
+	REJECTED_DUPLICATE_TX     = "duplicate_tx"
+	REJECTED_TX_FETCH         = "tx_fetch"
+	REJECTED_BENEFICIARY      = "beneficiary"
+	REJECTED_NOT_VALIDATOR    = "not_validator"
+	REJECTED_COMMITMENT_KEY   = "commitment_key"
+	REJECTED_COMMITMENT_PROOF = "commitment_proof"
+	REJECTED_PROOF_OF_STAKE   = "proof_of_stake"
+	REJECTED_WAITING_MINIMUM  = "waiting_minimum"
+	REJECTED_SLASHING_PROOF   = "slashing_proof"
+	REJECTED_MERKLE_ROOT      = "merkle_root"
+	REJECTED_ROLLBACK_DEPTH   = "rollback_depth"
+	REJECTED_CHECKPOINT       = "checkpoint"
+	REJECTED_LOCKED_TX        = "locked_tx"
+	REJECTED_TX_ORDER         = "tx_order"
+	REJECTED_AGG_TX           = "agg_tx"
+	REJECTED_STATE_ROOT       = "state_root"
+)
+
+//Counts the rejected blocks by reason. A sudden increase of a single reason is a good indicator for a peer
+//trying to feed us forged blocks. The counters are published with expvar, the RPC server serves them as JSON under
+///debug/vars.
+var rejectedBlocks = expvar.NewMap("rejected_blocks")
+
+func countRejectedBlock(reason string) {
+	rejectedBlocks.Add(reason, 1)
+}
+
+//Publishes the mempool's Stats() under /debug/vars as well. Publishing twice panics, hence the check.
+func publishMempoolStats() {
+	if expvar.Get("mempool") == nil {
+		expvar.Publish("mempool", expvar.Func(func() interface{} {
+			return mempool.Stats()
+		}))
+	}
+}
+
+//Returns how many blocks have been rejected for the given reason since the miner was started.
+func GetRejectedBlockCount(reason string) int64 {
+	if counter, ok := rejectedBlocks.Get(reason).(*expvar.Int); ok {
+		return counter.Value()
+	}
+
+	return 0
+}
@@ -0,0 +1,13 @@
+//go:build !mqtt
+// +build !mqtt
+
+package miner
+
+import (
+	"errors"
+)
+
+//Without the mqtt build tag, the MQTT client dependency is not compiled in.
+func StartMqttBridge(broker string, clientID string, bridge *MqttBridge) error {
+	return errors.New("Node built without MQTT support, build with -tags mqtt.")
+}
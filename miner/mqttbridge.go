@@ -0,0 +1,269 @@
+package miner
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"golang.org/x/crypto/ed25519"
+)
+
+//The MQTT bridge lets existing sensor fleets publish to Bazo: it subscribes to MQTT topics, wraps every message into
+//an IotTx signed with the key of the publishing device and adds it to the mempool like a tx submitted over JSON-RPC.
+//The routes file maps topics to devices and receivers, one route per line:
+//
+//	# topic               device  receiver (hex address)  options
+//	factory/line1/temp    temp1   6f1c...                  fee=1 compress
+//	sensors/+/reading     +       6f1c...                  encrypt
+//
+//Topics may contain the MQTT wildcards + and #. A device of + takes the device from the topic level matched by the
+//first + wildcard. The options are fee=<coins>, compress (see protocol/iotcompression.go) and encrypt (encrypts the
+//message to the receiver, see protocol/iotencryption.go).
+//The keys of the devices are read from the key store, a directory with a wallet file named <device>.txt per device.
+//The transport (see mqtt.go) is only compiled in with the mqtt build tag.
+
+const MQTT_KEY_FILE_EXTENSION = ".txt"
+
+type MqttBridge struct {
+	routes []mqttRoute
+	keys   *deviceKeyStore
+
+	//Messages are handled one at a time, so txs of the same device get consecutive txCnts.
+	mutex sync.Mutex
+}
+
+type mqttRoute struct {
+	topic    string
+	device   string
+	receiver [32]byte
+	fee      uint64
+	compress bool
+	encrypt  bool
+}
+
+type deviceKeyStore struct {
+	dir   string
+	keys  map[string]ed25519.PrivateKey
+	mutex sync.Mutex
+}
+
+func NewMqttBridge(routesFile string, keyStoreDir string) (*MqttBridge, error) {
+	filehandle, err := os.Open(routesFile)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Could not open MQTT routes: %v", err))
+	}
+	defer filehandle.Close()
+
+	routes, err := parseMqttRoutes(filehandle)
+	if err != nil {
+		return nil, err
+	}
+
+	if info, err := os.Stat(keyStoreDir); err != nil || !info.IsDir() {
+		return nil, errors.New(fmt.Sprintf("MQTT key store %v is not a directory.", keyStoreDir))
+	}
+
+	return &MqttBridge{routes: routes, keys: newDeviceKeyStore(keyStoreDir)}, nil
+}
+
+func parseMqttRoutes(reader io.Reader) (routes []mqttRoute, err error) {
+	scanner := bufio.NewScanner(reader)
+
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		route, err := parseMqttRoute(strings.Fields(line))
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid MQTT route in line %v: %v", lineNr, err))
+		}
+		routes = append(routes, route)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(routes) == 0 {
+		return nil, errors.New("No MQTT routes defined.")
+	}
+
+	return routes, nil
+}
+
+func parseMqttRoute(tokens []string) (route mqttRoute, err error) {
+	if len(tokens) < 3 {
+		return route, errors.New("expected topic, device and receiver")
+	}
+
+	route.topic, route.device = tokens[0], tokens[1]
+	if route.device == "+" && !strings.Contains(route.topic, "+") {
+		return route, errors.New("device + requires a + wildcard in the topic")
+	}
+
+	receiver, err := hex.DecodeString(tokens[2])
+	if err != nil || len(receiver) != 32 {
+		return route, errors.New("receiver must be a hex encoded address (public key)")
+	}
+	copy(route.receiver[:], receiver)
+
+	for _, option := range tokens[3:] {
+		switch {
+		case option == "compress":
+			route.compress = true
+		case option == "encrypt":
+			route.encrypt = true
+		case strings.HasPrefix(option, "fee="):
+			if route.fee, err = strconv.ParseUint(strings.TrimPrefix(option, "fee="), 10, 64); err != nil {
+				return route, errors.New(fmt.Sprintf("invalid fee: %v", option))
+			}
+		default:
+			return route, errors.New(fmt.Sprintf("unknown option %v", option))
+		}
+	}
+
+	return route, nil
+}
+
+//Returns the topic filters to subscribe to.
+func (bridge *MqttBridge) Topics() (topics []string) {
+	for _, route := range bridge.routes {
+		topics = append(topics, route.topic)
+	}
+
+	return topics
+}
+
+//Wraps the message into an IotTx of the device of the first route matching the topic and adds it to the mempool.
+func (bridge *MqttBridge) HandleMessage(topic string, payload []byte) (*protocol.IotTx, error) {
+	bridge.mutex.Lock()
+	defer bridge.mutex.Unlock()
+
+	for _, route := range bridge.routes {
+		device, matches := route.match(topic)
+		if !matches {
+			continue
+		}
+
+		privKey, err := bridge.keys.key(device)
+		if err != nil {
+			return nil, err
+		}
+
+		tx, err := route.newIotTx(privKey, payload)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := submitTx(tx, tx.Encode(), p2p.IOTTX_BRDCST); err != nil {
+			return nil, err
+		}
+
+		return tx, nil
+	}
+
+	return nil, errors.New(fmt.Sprintf("No MQTT route for topic %v.", topic))
+}
+
+//Returns the device publishing on the topic if the topic matches the route's filter.
+func (route *mqttRoute) match(topic string) (device string, matches bool) {
+	filter := strings.Split(route.topic, "/")
+	levels := strings.Split(topic, "/")
+
+	device = route.device
+	deviceFromTopic := route.device == "+"
+	for i, level := range filter {
+		//# matches the remaining levels, including none.
+		if level == "#" {
+			return device, true
+		}
+		if i >= len(levels) {
+			return "", false
+		}
+		if level == "+" {
+			if deviceFromTopic {
+				device = levels[i]
+				deviceFromTopic = false
+			}
+			continue
+		}
+		if level != levels[i] {
+			return "", false
+		}
+	}
+
+	if len(filter) != len(levels) {
+		return "", false
+	}
+
+	return device, true
+}
+
+func (route *mqttRoute) newIotTx(privKey ed25519.PrivateKey, payload []byte) (tx *protocol.IotTx, err error) {
+	var header byte
+	data := payload
+	switch {
+	case route.encrypt:
+		header = protocol.IOTTX_ENCRYPTED
+		if route.compress {
+			header |= protocol.IOTTX_COMPRESSED
+		}
+		data, err = protocol.EncryptIotData(route.receiver, payload, route.compress)
+	case route.compress:
+		header = protocol.IOTTX_COMPRESSED
+		data, err = protocol.CompressIotData(payload)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	//Accounts are referenced by the hash of their address (the public key) in txs and the state.
+	from := protocol.SerializeHashContent(crypto.GetAddressFromPubKeyED(ed25519.PublicKey(privKey[32:])))
+	to := protocol.SerializeHashContent(route.receiver)
+
+	return protocol.ConstrIotTx(header, route.fee, mempool.NextTxCnt(from), from, to, privKey, data, 0)
+}
+
+func newDeviceKeyStore(dir string) *deviceKeyStore {
+	return &deviceKeyStore{dir: dir, keys: make(map[string]ed25519.PrivateKey)}
+}
+
+//Loads the key of the device on first use. Unlike wallets, missing key files are not created.
+func (store *deviceKeyStore) key(device string) (ed25519.PrivateKey, error) {
+	store.mutex.Lock()
+	defer store.mutex.Unlock()
+
+	if privKey, exists := store.keys[device]; exists {
+		return privKey, nil
+	}
+
+	//The device may come from the topic, it must not point outside the key store.
+	if len(device) == 0 || device != filepath.Base(device) || device == "." || device == ".." {
+		return nil, errors.New(fmt.Sprintf("Invalid device name: %v", device))
+	}
+
+	filename := filepath.Join(store.dir, device+MQTT_KEY_FILE_EXTENSION)
+	if _, err := os.Stat(filename); err != nil {
+		return nil, errors.New(fmt.Sprintf("No key for device %v: %v", device, err))
+	}
+
+	privKey, err := crypto.ExtractEDPrivKeyFromFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	store.keys[device] = privKey
+
+	return privKey, nil
+}
@@ -0,0 +1,146 @@
+package miner
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"golang.org/x/crypto/ed25519"
+)
+
+const mqttTestReceiver = "6f1c2b3a4d5e6f708192a3b4c5d6e7f8091a2b3c4d5e6f708192a3b4c5d6e7f8"
+
+func TestParseMqttRoutes(t *testing.T) {
+	routes, err := parseMqttRoutes(strings.NewReader(
+		"# topic device receiver options\n" +
+			"\n" +
+			"factory/line1/temp temp1 " + mqttTestReceiver + " fee=2 compress\n" +
+			"sensors/+/reading + " + mqttTestReceiver + " encrypt\n"))
+	if err != nil {
+		t.Fatalf("Valid routes rejected: %v\n", err)
+	}
+
+	if len(routes) != 2 {
+		t.Fatalf("Expected 2 routes, got %v\n", len(routes))
+	}
+	if routes[0].device != "temp1" || routes[0].fee != 2 || !routes[0].compress || routes[0].encrypt {
+		t.Errorf("Route options not parsed: %v\n", routes[0])
+	}
+	if routes[1].device != "+" || routes[1].fee != 0 || routes[1].compress || !routes[1].encrypt {
+		t.Errorf("Route options not parsed: %v\n", routes[1])
+	}
+
+	invalid := []string{
+		"",
+		"factory/temp temp1\n",
+		"factory/temp temp1 6f1c\n",
+		"factory/temp + " + mqttTestReceiver + "\n",
+		"factory/temp temp1 " + mqttTestReceiver + " fee=x\n",
+		"factory/temp temp1 " + mqttTestReceiver + " unknown\n",
+	}
+	for _, routesFile := range invalid {
+		if _, err := parseMqttRoutes(strings.NewReader(routesFile)); err == nil {
+			t.Errorf("Invalid routes accepted: %q\n", routesFile)
+		}
+	}
+}
+
+func TestMqttRouteMatch(t *testing.T) {
+	tests := []struct {
+		filter  string
+		device  string
+		topic   string
+		matches bool
+		matched string
+	}{
+		{"factory/line1/temp", "temp1", "factory/line1/temp", true, "temp1"},
+		{"factory/line1/temp", "temp1", "factory/line2/temp", false, ""},
+		{"factory/line1/temp", "temp1", "factory/line1", false, ""},
+		{"sensors/+/reading", "+", "sensors/dev7/reading", true, "dev7"},
+		{"sensors/+/+/reading", "+", "sensors/dev7/a/reading", true, "dev7"},
+		{"sensors/+/reading", "+", "sensors/dev7/reading/raw", false, ""},
+		{"sensors/#", "hub", "sensors", true, "hub"},
+		{"sensors/#", "hub", "sensors/a/b", true, "hub"},
+		{"+/#", "+", "dev8/a/b", true, "dev8"},
+	}
+
+	for _, test := range tests {
+		route := mqttRoute{topic: test.filter, device: test.device}
+		device, matches := route.match(test.topic)
+		if matches != test.matches || device != test.matched {
+			t.Errorf("Filter %v matching %v: got (%v, %v), want (%v, %v)\n", test.filter, test.topic, device, matches, test.matched, test.matches)
+		}
+	}
+}
+
+func TestDeviceKeyStore(t *testing.T) {
+	dir, err := ioutil.TempDir("", "mqttkeys")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	_, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	if err := crypto.WriteEDKeyFile(filepath.Join(dir, "temp1"+MQTT_KEY_FILE_EXTENSION), privKey); err != nil {
+		t.Fatal(err)
+	}
+
+	store := newDeviceKeyStore(dir)
+	if key, err := store.key("temp1"); err != nil || !bytes.Equal(key, privKey) {
+		t.Errorf("Device key not loaded: %v\n", err)
+	}
+
+	for _, device := range []string{"", ".", "..", "../temp1", "a/temp1"} {
+		if _, err := store.key(device); err == nil {
+			t.Errorf("Invalid device name %q accepted\n", device)
+		}
+	}
+
+	//Unlike wallets, missing device keys must not be created.
+	if _, err := store.key("temp2"); err == nil {
+		t.Error("Key of an unknown device returned\n")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "temp2"+MQTT_KEY_FILE_EXTENSION)); !os.IsNotExist(err) {
+		t.Error("Key file of an unknown device created\n")
+	}
+}
+
+func TestMqttRouteNewIotTx(t *testing.T) {
+	receiverPubKey, receiverPrivKey, _ := ed25519.GenerateKey(rand.Reader)
+	devicePubKey, devicePrivKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	route := mqttRoute{fee: 3, compress: true, encrypt: true}
+	copy(route.receiver[:], receiverPubKey)
+
+	payload := bytes.Repeat([]byte("21.5C "), 50)
+	tx, err := route.newIotTx(devicePrivKey, payload)
+	if err != nil {
+		t.Fatalf("IotTx not created: %v\n", err)
+	}
+
+	if tx.Fee != 3 || tx.Header != protocol.IOTTX_ENCRYPTED|protocol.IOTTX_COMPRESSED {
+		t.Errorf("Route options not applied: %v\n", tx)
+	}
+
+	var deviceAddress [32]byte
+	copy(deviceAddress[:], devicePubKey)
+	if tx.From != protocol.SerializeHashContent(deviceAddress) || tx.To != protocol.SerializeHashContent(route.receiver) {
+		t.Errorf("Unexpected sender or receiver: %v\n", tx)
+	}
+
+	txHash := tx.Hash()
+	if !ed25519.Verify(devicePubKey, txHash[:], tx.Sig[:]) {
+		t.Error("IotTx not signed with the device key\n")
+	}
+
+	decrypted, err := tx.DecryptPayload(receiverPrivKey)
+	if err != nil || !bytes.Equal(decrypted, payload) {
+		t.Errorf("Message not recoverable by the receiver: %v\n", err)
+	}
+}
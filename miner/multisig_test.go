@@ -0,0 +1,107 @@
+package miner
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestVerifyCosigners(t *testing.T) {
+	cosigners := [][32]byte{{0x01}, {0x02}, {0x03}}
+	accTx := func(threshold uint8, cosigners [][32]byte) *protocol.AccTx {
+		return &protocol.AccTx{PubKey: protocol.MultiSigAddress(threshold, cosigners), Threshold: threshold, Cosigners: cosigners}
+	}
+
+	if !verifyCosigners(accTx(2, cosigners)) {
+		t.Error("Valid cosigner set was rejected.")
+	}
+
+	if verifyCosigners(accTx(0, cosigners)) || verifyCosigners(accTx(4, cosigners)) {
+		t.Error("Threshold must be between 1 and the number of cosigners.")
+	}
+
+	if verifyCosigners(accTx(2, [][32]byte{{0x01}, {0x01}})) {
+		t.Error("Duplicate cosigners should be rejected.")
+	}
+
+	tx := accTx(2, cosigners)
+	tx.PubKey = [32]byte{0x04}
+	if verifyCosigners(tx) {
+		t.Error("Address not derived from the cosigners should be rejected.")
+	}
+
+	tx = accTx(2, cosigners)
+	tx.Header = 1
+	if verifyCosigners(tx) {
+		t.Error("Multi-signature root accounts should be rejected.")
+	}
+}
+
+func TestVerifyMultiSigFundsTx(t *testing.T) {
+	var signers []protocol.FundsTxSigner
+	var cosigners [][32]byte
+	for i := 0; i < 3; i++ {
+		_, privKey, _ := ed25519.GenerateKey(rand.Reader)
+		signer := protocol.NewKeySigner(privKey)
+		var cosigner [32]byte
+		copy(cosigner[:], signer.PublicKey())
+		signers = append(signers, signer)
+		cosigners = append(cosigners, cosigner)
+	}
+
+	accTx := &protocol.AccTx{PubKey: protocol.MultiSigAddress(2, cosigners), Threshold: 2, Cosigners: cosigners}
+	if err := accStateChange([]*protocol.AccTx{accTx}); err != nil {
+		t.Fatal(err)
+	}
+	from := protocol.SerializeHashContent(accTx.PubKey)
+	defer storage.State.Delete(from)
+
+	if acc := storage.State.Get(from); acc == nil || acc.Threshold != 2 || len(acc.Cosigners) != 3 {
+		t.Fatalf("Cosigner set not stored in the state: %v\n", acc)
+	}
+
+	to := [32]byte{'m', 'u', 'l', 't', 'i'}
+	storage.State.Set(to, &protocol.Account{Address: to})
+	defer storage.State.Delete(to)
+
+	newTx := func(indexes ...uint8) *protocol.FundsTx {
+		tx := &protocol.FundsTx{Amount: 1, Fee: 1, From: from, To: protocol.SerializeHashContent(to)}
+		storage.State.Set(tx.To, storage.State.Get(to))
+		for _, index := range indexes {
+			if err := tx.Cosign(index, signers[index]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return tx
+	}
+	defer storage.State.Delete(protocol.SerializeHashContent(to))
+
+	if !verifyFundsTx(newTx(0, 2)) {
+		t.Error("Tx signed by the threshold of cosigners was rejected.")
+	}
+
+	if verifyFundsTx(newTx(1)) {
+		t.Error("Tx signed by less than the threshold of cosigners should be rejected.")
+	}
+
+	tx := newTx(0, 1)
+	tx.Cosigs[1].Index = 0
+	if verifyFundsTx(tx) {
+		t.Error("Tx signed twice by the same cosigner should be rejected.")
+	}
+
+	tx = newTx(0, 1)
+	tx.Cosigs[1].Index = 2
+	if verifyFundsTx(tx) {
+		t.Error("Tx with an invalid cosignature should be rejected.")
+	}
+
+	//The cosignatures survive the round trip over the network.
+	var decoded *protocol.FundsTx
+	if decoded, _ = decoded.Decode(newTx(1, 2).Encode()); decoded == nil || !verifyFundsTx(decoded) {
+		t.Error("Decoded tx was rejected.")
+	}
+}
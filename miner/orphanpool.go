@@ -0,0 +1,227 @@
+package miner
+
+import (
+	"context"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"sync"
+	"time"
+)
+
+//A received block whose parent is unknown is not validated right away, which would walk back the chain and wait for
+//every missing ancestor while holding the validation lock. It is kept in the orphan pool instead and its parent is
+//requested from the network in the background. The parent is processed like any received block, so it becomes an orphan
+//as well if its own parent is missing. Once the parent of an orphan is known, the highest orphan building on it is
+//validated, together with the orphans below it (getNewChain finds them in the pool). Validating the whole branch at
+//once compares its accumulated difficulty with the one of the active chain (see chainweight.go), a competing branch is
+//not rejected block by block.
+//The pool is limited in size, the oldest orphan is evicted when it is full, and orphans expire.
+
+const (
+	MAX_ORPHAN_BLOCKS = 500
+	ORPHAN_EXPIRY     = 10 * time.Minute
+)
+
+type orphanBlock struct {
+	block    *protocol.Block
+	received time.Time
+}
+
+type orphanPool struct {
+	blocks map[[32]byte]*orphanBlock
+	//Hashes of the orphans by the hash of their parent.
+	children map[[32]byte][][32]byte
+	//Parents requested from the network and not received yet.
+	requested map[[32]byte]bool
+	mutex     sync.Mutex
+}
+
+var orphans = newOrphanPool()
+
+func newOrphanPool() *orphanPool {
+	return &orphanPool{
+		blocks:    make(map[[32]byte]*orphanBlock),
+		children:  make(map[[32]byte][][32]byte),
+		requested: make(map[[32]byte]bool),
+	}
+}
+
+//Adds the block and returns true if its parent has to be requested, i.e. it is neither in the pool nor requested yet.
+func (pool *orphanPool) add(block *protocol.Block, now time.Time) (requestParent bool) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	pool.purge(now)
+	if _, exists := pool.blocks[block.Hash]; exists {
+		return false
+	}
+
+	if len(pool.blocks) >= MAX_ORPHAN_BLOCKS {
+		var oldest *orphanBlock
+		for _, orphan := range pool.blocks {
+			if oldest == nil || orphan.received.Before(oldest.received) {
+				oldest = orphan
+			}
+		}
+		pool.remove(oldest.block.Hash)
+	}
+
+	pool.blocks[block.Hash] = &orphanBlock{block, now}
+	pool.children[block.PrevHash] = append(pool.children[block.PrevHash], block.Hash)
+
+	if _, exists := pool.blocks[block.PrevHash]; exists || pool.requested[block.PrevHash] {
+		return false
+	}
+	pool.requested[block.PrevHash] = true
+
+	return true
+}
+
+func (pool *orphanPool) get(hash [32]byte) *protocol.Block {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	if orphan, exists := pool.blocks[hash]; exists {
+		return orphan.block
+	}
+
+	return nil
+}
+
+//Called once the request for the parent has been answered or has failed, a later orphan can request it again.
+func (pool *orphanPool) fetched(parentHash [32]byte) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	delete(pool.requested, parentHash)
+}
+
+//Returns the highest orphan building on the block with the given hash, nil if there is none.
+func (pool *orphanPool) tip(hash [32]byte) *protocol.Block {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	var tip *protocol.Block
+	for _, childHash := range pool.children[hash] {
+		child := pool.blocks[childHash].block
+		if descendant := pool.tipOf(child); tip == nil || descendant.Height > tip.Height {
+			tip = descendant
+		}
+	}
+
+	return tip
+}
+
+func (pool *orphanPool) tipOf(block *protocol.Block) *protocol.Block {
+	tip := block
+	for _, childHash := range pool.children[block.Hash] {
+		if descendant := pool.tipOf(pool.blocks[childHash].block); descendant.Height > tip.Height {
+			tip = descendant
+		}
+	}
+
+	return tip
+}
+
+//Returns the orphans whose parent is not in the pool and has been validated in the meantime.
+func (pool *orphanPool) connectable() (roots []*protocol.Block) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	for _, orphan := range pool.blocks {
+		if _, exists := pool.blocks[orphan.block.PrevHash]; !exists && isParentKnown(orphan.block) {
+			roots = append(roots, orphan.block)
+		}
+	}
+
+	return roots
+}
+
+//Removes the orphan with the given hash and the orphans below it. Orphans building on them stay in the pool.
+func (pool *orphanPool) removeBranch(hash [32]byte) {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	for orphan, exists := pool.blocks[hash]; exists; orphan, exists = pool.blocks[hash] {
+		pool.remove(hash)
+		hash = orphan.block.PrevHash
+	}
+}
+
+func (pool *orphanPool) purge(now time.Time) {
+	for hash, orphan := range pool.blocks {
+		if now.Sub(orphan.received) > ORPHAN_EXPIRY {
+			pool.remove(hash)
+		}
+	}
+}
+
+func (pool *orphanPool) remove(hash [32]byte) {
+	orphan, exists := pool.blocks[hash]
+	if !exists {
+		return
+	}
+	delete(pool.blocks, hash)
+
+	siblings := pool.children[orphan.block.PrevHash]
+	for i, sibling := range siblings {
+		if sibling == hash {
+			siblings = append(siblings[:i], siblings[i+1:]...)
+			break
+		}
+	}
+	if len(siblings) == 0 {
+		delete(pool.children, orphan.block.PrevHash)
+	} else {
+		pool.children[orphan.block.PrevHash] = siblings
+	}
+}
+
+//Blocks are only validated if their parent is a validated block or a block of the sync in progress.
+func isParentKnown(block *protocol.Block) bool {
+	return storage.ReadClosedBlock(block.PrevHash) != nil ||
+		storage.ReadClosedBlockWithoutTx(block.PrevHashWithoutTx) != nil ||
+		storage.ReadOpenBlock(block.PrevHash) != nil
+}
+
+//Requests the parent of an orphan and processes it like a received block.
+func fetchOrphanParent(hash [32]byte, hashWithoutTx [32]byte) {
+	defer orphans.fetched(hash)
+
+	var encoded []byte
+	err := fetch(context.Background(), "Orphan parent", BLOCKFETCH_TIMEOUT*time.Second, func() error {
+		return p2p.BlockReq(hash, hashWithoutTx)
+	}, func(timeout <-chan time.Time) bool {
+		select {
+		case encoded = <-p2p.BlockReqChan:
+			var parent *protocol.Block
+			parent, _ = parent.Decode(encoded)
+			return parent != nil && (parent.Hash == hash || parent.HashWithoutTx == hashWithoutTx)
+		case <-timeout:
+		}
+		return false
+	})
+	if err != nil {
+		logger.Warnf("Parent %x of an orphan block could not be fetched: %v", hash[0:8], err)
+		return
+	}
+
+	processBlock(encoded)
+}
+
+//Validates the orphans whose parents have been validated, until there are none left.
+func connectOrphans() {
+	for roots := orphans.connectable(); len(roots) > 0; roots = orphans.connectable() {
+		for _, root := range roots {
+			tip := orphans.tip(root.PrevHash)
+			if tip == nil {
+				continue
+			}
+
+			//The branch is dropped either way, it has been validated or contains an invalid block.
+			validateReceivedBlock(tip)
+			orphans.removeBranch(tip.Hash)
+		}
+	}
+}
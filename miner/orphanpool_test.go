@@ -0,0 +1,88 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"testing"
+	"time"
+)
+
+func TestOrphanPool(t *testing.T) {
+	pool := newOrphanPool()
+	now := time.Now()
+
+	parent := &protocol.Block{Hash: [32]byte{0x0f, 0x01}, Height: 10}
+	a := &protocol.Block{Hash: [32]byte{0x0f, 0x02}, PrevHash: parent.Hash, Height: 11}
+	b := &protocol.Block{Hash: [32]byte{0x0f, 0x03}, PrevHash: a.Hash, Height: 12}
+	c := &protocol.Block{Hash: [32]byte{0x0f, 0x04}, PrevHash: b.Hash, Height: 13}
+	fork := &protocol.Block{Hash: [32]byte{0x0f, 0x05}, PrevHash: a.Hash, Height: 12}
+
+	if !pool.add(a, now) {
+		t.Error("Parent of the first orphan not requested.")
+	}
+	if pool.add(a, now) {
+		t.Error("Parent requested for an orphan already in the pool.")
+	}
+	for _, block := range []*protocol.Block{b, c, fork} {
+		if pool.add(block, now) {
+			t.Errorf("Parent of orphan %x in the pool requested.", block.Hash[0:2])
+		}
+	}
+
+	//The parent of a is requested already.
+	sibling := &protocol.Block{Hash: [32]byte{0x0f, 0x06}, PrevHash: parent.Hash, Height: 11}
+	if pool.add(sibling, now) {
+		t.Error("Parent requested twice.")
+	}
+	pool.fetched(parent.Hash)
+	pool.remove(sibling.Hash)
+
+	if tip := pool.tip(parent.Hash); tip == nil || tip.Hash != c.Hash {
+		t.Errorf("Tip %v, expected %x.", tip, c.Hash[0:2])
+	}
+	if tip := pool.tip(c.Hash); tip != nil {
+		t.Errorf("Tip %x above the highest orphan.", tip.Hash[0:2])
+	}
+
+	//The orphans only become connectable once their parent is known.
+	if roots := pool.connectable(); len(roots) != 0 {
+		t.Errorf("%v orphans connectable without their parent.", len(roots))
+	}
+	storage.WriteClosedBlock(parent)
+	defer storage.DeleteClosedBlock(parent.Hash)
+	if roots := pool.connectable(); len(roots) != 1 || roots[0].Hash != a.Hash {
+		t.Errorf("Connectable orphans %v, expected %x.", roots, a.Hash[0:2])
+	}
+	if pool.get(b.Hash) != b {
+		t.Error("Orphan not found.")
+	}
+
+	//Removing the validated branch keeps the fork, which builds on a validated block now.
+	pool.removeBranch(c.Hash)
+	if len(pool.blocks) != 1 || pool.get(fork.Hash) == nil || len(pool.children) != 1 {
+		t.Errorf("Pool holds %v orphans after the branch was removed, expected the fork only.", len(pool.blocks))
+	}
+}
+
+func TestOrphanPoolLimits(t *testing.T) {
+	pool := newOrphanPool()
+	now := time.Now()
+
+	for i := 0; i < MAX_ORPHAN_BLOCKS; i++ {
+		block := &protocol.Block{Hash: [32]byte{0x0e, byte(i >> 8), byte(i)}, PrevHash: [32]byte{0x0d, byte(i >> 8), byte(i)}}
+		pool.add(block, now.Add(time.Duration(i)*time.Millisecond))
+	}
+
+	//The oldest orphan is evicted when the pool is full.
+	newest := &protocol.Block{Hash: [32]byte{0x0e, 0xff, 0xff}}
+	pool.add(newest, now.Add(time.Second))
+	if len(pool.blocks) != MAX_ORPHAN_BLOCKS || pool.get([32]byte{0x0e}) != nil || pool.get(newest.Hash) == nil {
+		t.Error("Oldest orphan not evicted.")
+	}
+
+	//Expired orphans are purged.
+	pool.add(&protocol.Block{Hash: [32]byte{0x0c}}, now.Add(ORPHAN_EXPIRY+time.Second/2))
+	if len(pool.blocks) != 2 {
+		t.Errorf("%v orphans left after the others expired, expected 2.", len(pool.blocks))
+	}
+}
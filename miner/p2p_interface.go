@@ -1,10 +1,12 @@
 package miner
 
 import (
+	"github.com/bazo-blockchain/bazo-miner/logging"
 	"github.com/bazo-blockchain/bazo-miner/p2p"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 	"github.com/bazo-blockchain/bazo-miner/storage"
 	"sync"
+	"time"
 )
 
 //The code in this source file communicates with the p2p package via channels
@@ -13,40 +15,100 @@ var (
 	processBlockMutex = &sync.Mutex{}
 )
 
-//Constantly listen to incoming data from the network
+//Constantly listen to incoming data from the network. The blocks are processed in order by a separate goroutine, so a
+//competing block can cancel the validation in progress (see pipeline.go).
 func incomingData() {
+	queue := make(chan []byte, BLOCK_QUEUE_SIZE)
+	go func() {
+		for payload := range queue {
+			processBlock(payload)
+		}
+	}()
+
 	for {
-		block := <-p2p.BlockIn
-		processBlock(block)
+		payload := <-p2p.BlockIn
+		var block *protocol.Block
+		if block, _ = block.Decode(payload); block != nil {
+			cancelCompetingValidation(block)
+		}
+		queue <- payload
 	}
 }
 
 //ReceivedBlockStash is a stash with all Blocks received such that we can prevent forking
 func processBlock(payload []byte) {
+	if isShuttingDown() {
+		return
+	}
 
 	processBlockMutex.Lock()
 	defer processBlockMutex.Unlock()
 	//TODO: Maybe a mutex around this function. such that blocks are not sent twice...
 	var block *protocol.Block
-	block = block.Decode(payload)
+	block, err := block.Decode(payload)
+	if err != nil {
+		logger.Warnf("Received block could not be decoded: %v", err)
+		return
+	}
+
+	//The p2p package tracks the relaying peer until the validation result is reported, whichever way we leave.
+	valid := false
+	defer func() { p2p.BlockRelayValidated(block.Hash, block.Height, valid) }()
+
+	blockLogger := logger.WithFields(logging.Fields{"hash": block.Hash, "height": block.Height})
 
 	//Block already confirmed and validated
 	if storage.ReadClosedBlock(block.Hash) != nil {
-		logger.Printf("Received block (%x) has already been validated.\n", block.Hash[0:8])
+		blockLogger.Debugf("Received block has already been validated.")
+		valid = true
 		return
 	}
 
 	//Append received Block to stash
 	storage.WriteToReceivedStash(block)
 
-	//Start validation process
+	//Blocks building on an unknown parent wait for it in the orphan pool, see orphanpool.go.
+	if !isParentKnown(block) {
+		if err := verifyBlockHashes(block); err != nil {
+			blockLogger.Warnf("Received orphan block is invalid: %v", err)
+			return
+		}
+
+		blockLogger.Debugf("Received orphan block, parent %x unknown.", block.PrevHash[0:8])
+		if orphans.add(block, time.Now()) {
+			go fetchOrphanParent(block.PrevHash, block.PrevHashWithoutTx)
+		}
+		return
+	}
+
+	//The orphans waiting for this block are validated with it.
+	tip := orphans.tip(block.Hash)
+	if tip == nil {
+		tip = block
+	}
+	valid = validateReceivedBlock(tip)
+	orphans.removeBranch(tip.Hash)
+
+	connectOrphans()
+}
+
+//Returns whether the block (and hence the whole branch up to it) passed validation.
+func validateReceivedBlock(block *protocol.Block) bool {
+	blockLogger := logger.WithFields(logging.Fields{"hash": block.Hash, "height": block.Height})
+
 	err := validate(block, false)
+	p2p.BlockRelayValidated(block.Hash, block.Height, err == nil)
 	if err == nil {
-		logger.Printf("Validated block (received): %vState:\n%v", block, getState())
+		blockLogger.Infof("Validated block (received).")
+		if blockLogger.Enabled(logging.DEBUG) {
+			blockLogger.Debugf("%vState:\n%v", block, getState())
+		}
 		broadcastBlock(block)
 	} else {
-		logger.Printf("Received block (%x) could not be validated: %v\n", block.Hash[0:8], err)
+		blockLogger.Warnf("Received block could not be validated: %v", err)
 	}
+
+	return err == nil
 }
 
 //p2p.BlockOut is a channel whose data get consumed by the p2p package
@@ -56,7 +118,7 @@ func broadcastBlock(block *protocol.Block) {
 	//Make a deep copy of the block (since it is a pointer and will be saved to db later).
 	//Otherwise the block's bloom filter is initialized on the original block.
 	var blockCopy = *block
-	blockCopy.InitBloomFilter(append(storage.GetTxPubKeys(&blockCopy)))
+	blockCopy.InitBloomFilter(storage.GetTxPubKeys(&blockCopy))
 	p2p.BlockHeaderOut <- blockCopy.EncodeHeader()
 }
 
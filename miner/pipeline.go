@@ -0,0 +1,102 @@
+package miner
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//A block is validated in three stages: preValidate checks the block and fetches its txs (from the network if
+//necessary), validateState applies the txs to the state and postValidate writes the block to disk. The stages run with
+//a context. Fetching the txs of a block may wait for TXFETCH_TIMEOUT per tx type, if a competing block extends the
+//chain beyond the block being validated in the meantime, the block would be rolled back anyway. The context is
+//cancelled then, the fetches are aborted and the competing block is validated right away.
+
+//Number of received blocks waiting for the block being validated.
+const BLOCK_QUEUE_SIZE = 100
+
+var errValidationCancelled = errors.New("Block validation cancelled by a competing block.")
+
+type validationRun struct {
+	block  *protocol.Block
+	cancel context.CancelFunc
+}
+
+var (
+	//The block passed to validate, nil if no block is being validated.
+	currentValidation      *validationRun
+	currentValidationMutex = &sync.Mutex{}
+)
+
+//Runs the stages for a single block. The state transition is left open, the caller commits it.
+func validateBlock(ctx context.Context, block *protocol.Block, initialSetup bool) error {
+	//Fetching payload data from the txs (if necessary, ask other miners).
+	accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, contractTxs, err := preValidate(ctx, block, initialSetup)
+
+	//Check if the validator that added the block has previously voted on different competing chains (find slashing proof).
+	//The proof will be stored in the global slashing dictionary.
+	if block.Height > 0 {
+		seekSlashingProof(block)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	//Once the state is changed, the block is completed.
+	if ctx.Err() != nil {
+		return errValidationCancelled
+	}
+
+	data := blockData{accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, contractTxs, block}
+	if err := validateState(data, initialSetup); err != nil {
+		return err
+	}
+
+	return postValidate(data, initialSetup)
+}
+
+//Called by validate with blockValidation held.
+func startValidation(block *protocol.Block) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	currentValidationMutex.Lock()
+	currentValidation = &validationRun{block, cancel}
+	currentValidationMutex.Unlock()
+
+	return ctx
+}
+
+func finishValidation() {
+	currentValidationMutex.Lock()
+	defer currentValidationMutex.Unlock()
+
+	if currentValidation != nil {
+		currentValidation.cancel()
+		currentValidation = nil
+	}
+}
+
+//Cancels the validation in progress if the received block is higher and does not build on the block being validated.
+//Returns whether the validation was cancelled.
+func cancelCompetingValidation(block *protocol.Block) bool {
+	currentValidationMutex.Lock()
+	defer currentValidationMutex.Unlock()
+
+	if currentValidation == nil {
+		return false
+	}
+
+	validated := currentValidation.block
+	if block.Height <= validated.Height || block.PrevHash == validated.Hash {
+		return false
+	}
+
+	logger.Printf("Block validation (%x) cancelled by competing block (%x) at height %v.", validated.Hash[0:8], block.Hash[0:8], block.Height)
+	currentValidation.cancel()
+	currentValidation = nil
+
+	return true
+}
@@ -0,0 +1,49 @@
+package miner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestPreValidateCancelled(t *testing.T) {
+	prevUptodate := uptodate
+	defer func() { uptodate = prevUptodate }()
+	uptodate = false
+
+	//The tx is neither closed nor open, it would be fetched from the network.
+	b := &protocol.Block{Height: 1, NrConfigTx: 1, ConfigTxData: [][32]byte{{0x0c, 0xa1}}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	start := time.Now()
+	if _, _, _, _, _, _, _, err := preValidate(ctx, b, false); err != errValidationCancelled {
+		t.Errorf("Cancelled validation returned %v, expected %v.", err, errValidationCancelled)
+	}
+	if time.Since(start) >= TXFETCH_TIMEOUT*time.Second {
+		t.Error("Cancelled tx fetch waited for the timeout.")
+	}
+}
+
+func TestCancelCompetingValidation(t *testing.T) {
+	validated := &protocol.Block{Hash: [32]byte{0x01}, Height: 10}
+	ctx := startValidation(validated)
+	defer finishValidation()
+
+	if cancelCompetingValidation(&protocol.Block{Hash: [32]byte{0x02}, Height: 10}) {
+		t.Error("Block at the same height cancelled the validation.")
+	}
+	if cancelCompetingValidation(&protocol.Block{Hash: [32]byte{0x03}, PrevHash: validated.Hash, Height: 11}) {
+		t.Error("Block building on the validated block cancelled the validation.")
+	}
+	if ctx.Err() != nil {
+		t.Fatal("Validation cancelled without a competing block.")
+	}
+
+	if !cancelCompetingValidation(&protocol.Block{Hash: [32]byte{0x04}, PrevHash: [32]byte{0x05}, Height: 11}) || ctx.Err() == nil {
+		t.Error("Competing block did not cancel the validation.")
+	}
+}
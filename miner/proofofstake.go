@@ -39,7 +39,8 @@ func validateProofOfStake(diff uint8,
 		index += crypto.COMM_KEY_LENGTH
 	}
 
-	copy(hashArgs[index:index + crypto.COMM_KEY_LENGTH], commitmentProof[:]) // COMM_KEY_LENGTH bytes
+	output := commitmentOutput(commitmentProof)
+	copy(hashArgs[index:index + crypto.COMM_KEY_LENGTH], output[:]) // COMM_KEY_LENGTH bytes
 	index += crypto.COMM_KEY_LENGTH
 	copy(hashArgs[index:index + 4], heightBuf[:]) 		// 4 bytes
 	index += 4
@@ -71,13 +72,15 @@ func validateProofOfStake(diff uint8,
 }
 
 //diff and partialHash is needed to calculate a valid PoS, prevHash is needed to check whether we should stop
-//PoS calculation because another block has been validated meanwhile
+//PoS calculation because another block has been validated meanwhile. Only timestamps above minTimestamp are tried, see
+//mediantime.go.
 func proofOfStake(diff uint8,
 	prevHash [32]byte,
 	prevProofs [][crypto.COMM_KEY_LENGTH]byte,
 	height uint32,
 	balance uint64,
-	commitmentProof [crypto.COMM_KEY_LENGTH]byte) (int64, error) {
+	commitmentProof [crypto.COMM_KEY_LENGTH]byte,
+	minTimestamp int64) (int64, error) {
 
 	var (
 		pos    [32]byte
@@ -106,7 +109,8 @@ func proofOfStake(diff uint8,
 		index += crypto.COMM_KEY_LENGTH
 	}
 
-	copy(hashArgs[index:index + crypto.COMM_KEY_LENGTH], commitmentProof[:]) // COMM_KEY_LENGTH bytes
+	output := commitmentOutput(commitmentProof)
+	copy(hashArgs[index:index + crypto.COMM_KEY_LENGTH], output[:]) // COMM_KEY_LENGTH bytes
 	index += crypto.COMM_KEY_LENGTH
 	copy(hashArgs[index:index + 4], heightBuf[:]) 		// 4 bytes
 	index += 4
@@ -126,6 +130,9 @@ func proofOfStake(diff uint8,
 
 		//add the number of seconds that have passed since the Unix epoch (00:00:00 UTC, 1 January 1970)
 		timestamp = time.Now().Unix()
+		if timestamp <= minTimestamp {
+			continue
+		}
 		binary.BigEndian.PutUint64(timestampBuf[:], uint64(timestamp))
 		copy(hashArgs[timestampBufIndexStart:timestampBufIndexEnd], timestampBuf[:]) //8 bytes
 
@@ -165,6 +172,7 @@ func proofOfStake(diff uint8,
 	return timestamp, nil
 }
 
+//Returns the commitment outputs (see commitmentOutput) of the n blocks before block.
 func GetLatestProofs(n int, block *protocol.Block) (prevProofs [][crypto.COMM_KEY_LENGTH]byte) {
 
 	for block.Height > 0 && n > 0 {
@@ -173,7 +181,7 @@ func GetLatestProofs(n int, block *protocol.Block) (prevProofs [][crypto.COMM_KE
 		if closedBlock == nil {
 			closedBlock = storage.ReadClosedBlockWithoutTx(block.PrevHashWithoutTx)
 		}
-		prevProofs = append(prevProofs, closedBlock.CommitmentProof)
+		prevProofs = append(prevProofs, commitmentOutput(closedBlock.CommitmentProof))
 		n -= 1
 		block = closedBlock
 	}
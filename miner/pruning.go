@@ -0,0 +1,117 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Long-running nodes keep every closed tx and every block with the hashes of its txs, their database grows without
+//bounds. With a retention set, the txs of the blocks more than pruneRetention blocks below a snapshot are deleted and
+//the blocks, emptied or not, are stored as headers (see protocol.Block.EncodeHeader): the hashes linking the chain,
+//the Merkle root and the proof of stake are kept, the state is not affected. Pruning runs whenever a snapshot is
+//stored, the node never replays the blocks below it again. Pruned blocks and txs can not be served to peers anymore,
+//nodes syncing the chain need a peer keeping them or a snapshot (fast sync).
+//The retention must exceed the maximum rollback depth, the txs of rolled back blocks have to be complete. The blocks of
+//an epoch are only pruned once their aggTxs are aggregated (see epochaggregation.go).
+
+var (
+	pruneRetention uint32
+
+	//The blocks up to this height have been pruned. After a restart, the first pruning walks back to the genesis block,
+	//already pruned blocks are skipped.
+	prunedHeight uint32
+)
+
+//A retention of 0 keeps all blocks and txs.
+func SetPruning(retention uint32) {
+	pruneRetention = retention
+}
+
+//Prunes the blocks below the retention. Called after the snapshot of the block has been stored.
+func pruneBlocks(block *protocol.Block) {
+	if pruneRetention == 0 || block.Height <= pruneRetention {
+		return
+	}
+
+	pruneHeight := block.Height - pruneRetention
+	if epochLength >= 2 {
+		lastAggregate := storage.ReadLastEpochAggregate(1)
+		if lastAggregate == nil {
+			return
+		}
+		if lastAggregate.LastHeight < pruneHeight {
+			pruneHeight = lastAggregate.LastHeight
+		}
+	}
+
+	prunable := block
+	for prunable != nil && prunable.Height > pruneHeight {
+		prunable = readHeader(prunable.PrevHash, prunable.PrevHashWithoutTx)
+	}
+
+	var pruned int
+	for ; prunable != nil && prunable.Height > prunedHeight; prunable = readHeader(prunable.PrevHash, prunable.PrevHashWithoutTx) {
+		if isPruned(prunable) {
+			continue
+		}
+
+		if err := pruneBlock(prunable); err != nil {
+			logger.Printf("Block (%x) could not be pruned: %v\n", prunable.Hash[0:8], err)
+			return
+		}
+		pruned++
+	}
+
+	if pruneHeight > prunedHeight {
+		prunedHeight = pruneHeight
+	}
+	if pruned > 0 {
+		logger.Printf("Pruned %v blocks up to height %v.\n", pruned, pruneHeight)
+	}
+}
+
+//Blocks without tx hashes are either pruned or empty, there is nothing to prune.
+func isPruned(block *protocol.Block) bool {
+	return len(block.AccTxData) == 0 && len(block.FundsTxData) == 0 && len(block.ConfigTxData) == 0 &&
+		len(block.StakeTxData) == 0 && len(block.AggTxData) == 0 && len(block.IoTTxData) == 0 &&
+		len(block.ContractTxData) == 0
+}
+
+//Deletes the txs of the block and replaces the block with its header, in one transaction.
+func pruneBlock(block *protocol.Block) error {
+	batch := storage.NewBatch()
+
+	var txHashes [][32]byte
+	for _, txData := range [][][32]byte{block.AccTxData, block.FundsTxData, block.ConfigTxData, block.StakeTxData, block.ContractTxData} {
+		txHashes = append(txHashes, txData...)
+	}
+	for _, txHash := range block.AggTxData {
+		if aggTx, ok := storage.ReadClosedTx(txHash).(*protocol.AggTx); ok {
+			txHashes = append(txHashes, aggTx.AggregatedTxSlice...)
+		}
+		txHashes = append(txHashes, txHash)
+	}
+
+	//The hashes of the txs are kept, the txs can not be replayed in a later block.
+	for _, txHash := range txHashes {
+		if tx := storage.ReadClosedTx(txHash); tx != nil {
+			batch.PruneClosedTx(tx)
+		}
+	}
+	for _, txHash := range block.IoTTxData {
+		if _, dataPruned := storage.ReadPrunedIotDataHash(txHash); dataPruned {
+			batch.DeletePrunedIotTx(txHash)
+		} else if tx := storage.ReadClosedTx(txHash); tx != nil {
+			batch.PruneClosedTx(tx)
+		}
+		batch.DeleteIotTxBlock(txHash)
+	}
+
+	if storage.ReadClosedBlock(block.Hash) != nil {
+		batch.PruneClosedBlock(block)
+	} else {
+		batch.PruneClosedBlockWithoutTx(block)
+	}
+
+	return batch.Commit()
+}
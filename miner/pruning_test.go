@@ -0,0 +1,82 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestPruneBlocks(t *testing.T) {
+	defer SetEpochAggregation(epochLength, pruneAggregated)
+	defer SetPruning(0)
+	defer func() { prunedHeight = 0 }()
+	SetEpochAggregation(0, false)
+	SetPruning(10)
+
+	oldTx := &protocol.FundsTx{Amount: 1, Fee: 1, From: [32]byte{'o', 'l', 'd'}, To: [32]byte{'t', 'o'}}
+	aggregatedTx1 := &protocol.FundsTx{Amount: 2, Fee: 1, From: [32]byte{'a', 'g', 'g'}, To: [32]byte{'t', 'o'}}
+	aggregatedTx2 := &protocol.FundsTx{Amount: 3, Fee: 1, TxCnt: 1, From: [32]byte{'a', 'g', 'g'}, To: [32]byte{'t', 'o'}}
+	aggTx, _ := aggregate([]*protocol.FundsTx{aggregatedTx1, aggregatedTx2})
+	recentTx := &protocol.FundsTx{Amount: 4, Fee: 1, From: [32]byte{'r', 'e', 'c', 'e', 'n', 't'}, To: [32]byte{'t', 'o'}}
+
+	var blocks []*protocol.Block
+	var prevHash [32]byte
+	for height := uint32(0); height <= 20; height++ {
+		block := &protocol.Block{Hash: [32]byte{'p', 'r', 'u', 'n', 'e', byte(height)}, PrevHash: prevHash, Height: height, MerkleRoot: [32]byte{byte(height)}}
+		switch height {
+		case 5:
+			block.FundsTxData = [][32]byte{oldTx.Hash()}
+			block.NrFundsTx = 1
+		case 6:
+			block.AggTxData = [][32]byte{aggTx.Hash()}
+			block.NrAggTx = 1
+		case 18:
+			block.FundsTxData = [][32]byte{recentTx.Hash()}
+			block.NrFundsTx = 1
+		}
+		storage.WriteClosedBlock(block)
+		blocks = append(blocks, block)
+		prevHash = block.Hash
+	}
+	for _, tx := range []protocol.Transaction{oldTx, aggregatedTx1, aggregatedTx2, aggTx, recentTx} {
+		storage.WriteClosedTx(tx)
+		defer storage.DeleteClosedTx(tx)
+	}
+	defer func() {
+		for _, block := range blocks {
+			storage.DeleteClosedBlock(block.Hash)
+		}
+	}()
+
+	//With epoch aggregation, blocks are only pruned once their epoch is aggregated.
+	SetEpochAggregation(2, false)
+	pruneBlocks(blocks[20])
+	if storage.ReadClosedTx(oldTx.Hash()) == nil || prunedHeight != 0 {
+		t.Fatal("Blocks of epochs which are not aggregated should not be pruned.")
+	}
+
+	SetEpochAggregation(0, false)
+	pruneBlocks(blocks[20])
+	for _, tx := range []protocol.Transaction{oldTx, aggregatedTx1, aggregatedTx2, aggTx} {
+		if storage.ReadClosedTx(tx.Hash()) != nil {
+			t.Errorf("Tx %x below the retention should have been deleted.\n", tx.Hash())
+		}
+		if !storage.IsClosedTx(tx.Hash()) {
+			t.Errorf("Hash of the pruned tx %x should have been kept.\n", tx.Hash())
+		}
+	}
+
+	header := storage.ReadClosedBlock(blocks[6].Hash)
+	if header == nil || len(header.AggTxData) != 0 || header.NrAggTx != 0 || header.PrevHash != blocks[5].Hash || header.MerkleRoot != blocks[6].MerkleRoot {
+		t.Errorf("Block below the retention should have been replaced by its header: %v\n", header)
+	}
+
+	if storage.ReadClosedTx(recentTx.Hash()) == nil || len(storage.ReadClosedBlock(blocks[18].Hash).FundsTxData) != 1 {
+		t.Error("Blocks within the retention should be kept.")
+	}
+
+	if prunedHeight != 10 {
+		t.Errorf("Pruned height should be 10, is %v.\n", prunedHeight)
+	}
+}
@@ -0,0 +1,60 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"time"
+)
+
+//A validator booting with broken connectivity would happily extend its own chain and end up on a solo fork. Before
+//proposing a block, the miner therefore waits until it is connected to enough miners and has caught up with the tip
+//reported by its peers (see p2p.NetworkTipHeight). If no peer relayed a block yet, e.g., because the whole network
+//restarted, only the peer count is checked, otherwise no validator would ever start producing again.
+
+const READINESS_CHECK_INTERVAL = 1
+
+var (
+	minPeers       uint32
+	maxTipDistance uint32
+)
+
+//A value of 0 disables the respective check.
+func SetProductionSafeguard(peers uint32, tipDistance uint32) {
+	minPeers = peers
+	maxTipDistance = tipDistance
+}
+
+func checkReadiness(peerCount int, height uint32, tipHeight uint32, tipKnown bool) error {
+	if peerCount < int(minPeers) {
+		return errors.New(fmt.Sprintf("Connected to %v miners, at least %v required.", peerCount, minPeers))
+	}
+
+	if maxTipDistance > 0 && tipKnown && tipHeight > height && tipHeight-height > maxTipDistance {
+		return errors.New(fmt.Sprintf("Block height %v is %v blocks behind the network tip (%v), at most %v allowed.", height, tipHeight-height, tipHeight, maxTipDistance))
+	}
+
+	return nil
+}
+
+//Blocks until the node is ready to propose a block at the given height.
+func waitForReadiness(height uint32) {
+	var lastReason string
+	for {
+		tipHeight, tipKnown := p2p.NetworkTipHeight()
+		err := checkReadiness(p2p.MinerPeerCount(), height, tipHeight, tipKnown)
+		if err == nil {
+			if lastReason != "" {
+				logger.Printf("Ready to produce blocks again.\n")
+			}
+			return
+		}
+
+		if err.Error() != lastReason {
+			lastReason = err.Error()
+			logger.Printf("Not producing blocks: %v\n", lastReason)
+		}
+
+		time.Sleep(READINESS_CHECK_INTERVAL * time.Second)
+	}
+}
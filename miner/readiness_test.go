@@ -0,0 +1,34 @@
+package miner
+
+import "testing"
+
+func TestCheckReadiness(t *testing.T) {
+	defer SetProductionSafeguard(0, 0)
+
+	SetProductionSafeguard(0, 0)
+	if err := checkReadiness(0, 0, 100, true); err != nil {
+		t.Errorf("Disabled safeguard prevented block production: %v\n", err)
+	}
+
+	SetProductionSafeguard(3, 5)
+	if err := checkReadiness(2, 10, 10, true); err == nil {
+		t.Error("Block production with too few peers allowed.\n")
+	}
+
+	if err := checkReadiness(3, 10, 16, true); err == nil {
+		t.Error("Block production too far behind the tip allowed.\n")
+	}
+
+	if err := checkReadiness(3, 10, 15, true); err != nil {
+		t.Errorf("Block production within the tip distance prevented: %v\n", err)
+	}
+
+	if err := checkReadiness(3, 20, 15, true); err != nil {
+		t.Errorf("Block production ahead of the beacon tip prevented: %v\n", err)
+	}
+
+	//Without beacon peers, e.g., after a restart of the whole network, the tip is unknown.
+	if err := checkReadiness(3, 0, 0, false); err != nil {
+		t.Errorf("Block production with unknown tip prevented: %v\n", err)
+	}
+}
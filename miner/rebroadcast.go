@@ -0,0 +1,78 @@
+package miner
+
+import (
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//Txs are only broadcast once when they arrive. Peers which were not connected at that time or which evicted the tx
+//never learn about it and a tx only known to a few miners may wait a long time for a block. Open txs which are still
+//valid are therefore broadcast again periodically. Peers already knowing a tx drop it without relaying it further.
+
+const (
+	TX_REBROADCAST_INTERVAL_DEFAULT = 10 * time.Minute
+	TX_REBROADCAST_POLL_INTERVAL    = 30 * time.Second
+)
+
+var txRebroadcastInterval = TX_REBROADCAST_INTERVAL_DEFAULT
+
+//0 disables the rebroadcast.
+func SetTxRebroadcastInterval(interval time.Duration) {
+	txRebroadcastInterval = interval
+}
+
+func rebroadcastService() {
+	for range time.Tick(TX_REBROADCAST_POLL_INTERVAL) {
+		if isShuttingDown() {
+			return
+		}
+
+		if txRebroadcastInterval > 0 {
+			rebroadcastOpenTxs()
+		}
+	}
+}
+
+func rebroadcastOpenTxs() {
+	var rebroadcast int
+
+	for _, tx := range mempool.Rebroadcastable(txRebroadcastInterval) {
+		//The state must not change while the tx is verified.
+		blockValidation.Lock()
+		verified := verify(tx)
+		blockValidation.Unlock()
+
+		brdcstType := txBrdcstType(tx)
+		if !verified || brdcstType == 0 {
+			continue
+		}
+
+		p2p.BroadcastTx(tx.Encode(), brdcstType)
+		rebroadcast++
+	}
+
+	if rebroadcast > 0 {
+		logger.Printf("Rebroadcast %v open txs.\n", rebroadcast)
+	}
+}
+
+func txBrdcstType(tx protocol.Transaction) uint8 {
+	switch tx.(type) {
+	case *protocol.FundsTx:
+		return p2p.FUNDSTX_BRDCST
+	case *protocol.AccTx:
+		return p2p.ACCTX_BRDCST
+	case *protocol.ConfigTx:
+		return p2p.CONFIGTX_BRDCST
+	case *protocol.StakeTx:
+		return p2p.STAKETX_BRDCST
+	case *protocol.IotTx:
+		return p2p.IOTTX_BRDCST
+	case *protocol.ContractTx:
+		return p2p.CONTRACTTX_BRDCST
+	}
+
+	return 0
+}
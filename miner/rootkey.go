@@ -0,0 +1,87 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Root keys are added and removed on-chain with config txs (see protocol/configtx.go), which need the signatures of
+//Config_quorum root keys like parameter changes. A compromised root key is rotated by adding a new root key and
+//removing the compromised one, without restarting the nodes with new genesis data. The account of a new root key has
+//to exist (it can be created by an accTx in the same block), removing a root key keeps its account. At least
+//Config_quorum root keys have to remain, otherwise the parameters and root keys could not be changed anymore.
+
+//Returns an error if the root key change can not be applied to the current state.
+func checkRootKeyChange(tx *protocol.ConfigTx) error {
+	if storage.State.Get(tx.RootKey) == nil {
+		return errors.New(fmt.Sprintf("Account %x of the root key change does not exist.", tx.RootKey[0:8]))
+	}
+
+	switch tx.Id {
+	case protocol.ROOT_KEY_ADD_ID:
+		if storage.IsRootKey(tx.RootKey) {
+			return errors.New(fmt.Sprintf("Account %x is already a root account.", tx.RootKey[0:8]))
+		}
+	case protocol.ROOT_KEY_REMOVE_ID:
+		if !storage.IsRootKey(tx.RootKey) {
+			return errors.New(fmt.Sprintf("Account %x is not a root account.", tx.RootKey[0:8]))
+		}
+
+		//Pending changes of the quorum count as well.
+		quorum := parameterSlice[len(parameterSlice)-1].Config_quorum
+		if uint64(len(storage.RootKeys)-1) < quorum {
+			return errors.New(fmt.Sprintf("Removing root account %x would leave less than %v root keys.", tx.RootKey[0:8], quorum))
+		}
+	}
+
+	return nil
+}
+
+func rootKeyStateChange(txSlice []*protocol.ConfigTx) error {
+	for _, tx := range txSlice {
+		if !tx.IsRootKeyChange() {
+			continue
+		}
+
+		if err := checkRootKeyChange(tx); err != nil {
+			return err
+		}
+
+		//Recorded in the state transition, so the change is reverted if the block is invalid.
+		acc, err := storage.GetAccount(tx.RootKey)
+		if err != nil {
+			return err
+		}
+
+		if tx.Id == protocol.ROOT_KEY_ADD_ID {
+			storage.RootKeys[tx.RootKey] = acc
+		} else {
+			delete(storage.RootKeys, tx.RootKey)
+		}
+	}
+
+	return nil
+}
+
+func rootKeyStateChangeRollback(txSlice []*protocol.ConfigTx) {
+	//Rollback in reverse order than original state change
+	for cnt := len(txSlice) - 1; cnt >= 0; cnt-- {
+		tx := txSlice[cnt]
+		if !tx.IsRootKeyChange() {
+			continue
+		}
+
+		acc, _ := storage.GetAccount(tx.RootKey)
+		if acc == nil {
+			logger.Fatal("CRITICAL: The account of a root key change does not exist.")
+		}
+
+		if tx.Id == protocol.ROOT_KEY_ADD_ID {
+			delete(storage.RootKeys, tx.RootKey)
+		} else {
+			storage.RootKeys[tx.RootKey] = acc
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package miner
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestRootKeyRotation(t *testing.T) {
+	prevParameterSlice, prevRootKeys := parameterSlice, storage.RootKeys
+	defer func() {
+		parameterSlice, storage.RootKeys = prevParameterSlice, prevRootKeys
+		activeParameters = &parameterSlice[len(parameterSlice)-1]
+	}()
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	activeParameters = &parameterSlice[0]
+
+	storage.RootKeys = make(map[[32]byte]*protocol.Account)
+	var accs []*protocol.Account
+	var privKeys []ed25519.PrivateKey
+	for i := 0; i < 3; i++ {
+		pubKey, privKey, _ := ed25519.GenerateKey(rand.Reader)
+		acc := &protocol.Account{}
+		copy(acc.Address[:], pubKey)
+		storage.State.Set(acc.Hash(), acc)
+		defer storage.State.Delete(acc.Hash())
+		accs = append(accs, acc)
+		privKeys = append(privKeys, privKey)
+	}
+	storage.RootKeys[accs[0].Hash()] = accs[0]
+
+	//The compromised root key 0 is replaced by the accounts 1 and 2.
+	add, _ := protocol.ConstrRootKeyTx(0, protocol.ROOT_KEY_ADD_ID, accs[1].Hash(), 1, 0, privKeys[0])
+	add2, _ := protocol.ConstrRootKeyTx(0, protocol.ROOT_KEY_ADD_ID, accs[2].Hash(), 1, 1, privKeys[0])
+	if !verifyConfigTx(add) {
+		t.Error("Root key change signed by a root key rejected.\n")
+	}
+	if err := rootKeyStateChange([]*protocol.ConfigTx{add, add2}); err != nil || !storage.IsRootKey(accs[1].Hash()) || !storage.IsRootKey(accs[2].Hash()) {
+		t.Fatalf("Root keys not added: %v\n", err)
+	}
+	if err := checkRootKeyChange(add); err == nil {
+		t.Error("Root account added twice.\n")
+	}
+
+	//A quorum above the number of root keys could never be reached.
+	quorum, _ := protocol.ConstrConfigTx(0, protocol.CONFIG_QUORUM_ID, 4, 1, 2, privKeys[1])
+	if verifyConfigTx(quorum) {
+		t.Error("Config quorum above the number of root keys accepted.\n")
+	}
+	parameterSlice[0].Config_quorum = 2
+
+	remove, _ := protocol.ConstrRootKeyTx(0, protocol.ROOT_KEY_REMOVE_ID, accs[0].Hash(), 1, 3, privKeys[1])
+	if verifyConfigTx(remove) {
+		t.Error("Root key change signed by a single root key accepted with a quorum of 2.\n")
+	}
+	remove.Cosign(privKeys[2])
+	if !verifyConfigTx(remove) {
+		t.Error("Root key change signed by 2 root keys rejected.\n")
+	}
+	if err := rootKeyStateChange([]*protocol.ConfigTx{remove}); err != nil || storage.IsRootKey(accs[0].Hash()) {
+		t.Fatalf("Root key not removed: %v\n", err)
+	}
+	if storage.State.Get(accs[0].Hash()) == nil {
+		t.Error("Account of the removed root key removed.\n")
+	}
+
+	//The removed root key can no longer sign config txs.
+	if tx, _ := protocol.ConstrConfigTx(0, protocol.FEE_MINIMUM_ID, 5, 1, 4, privKeys[0]); verifyConfigTx(tx) {
+		t.Error("Config tx signed by a removed root key accepted.\n")
+	}
+
+	//Less than Config_quorum root keys would remain.
+	remove2, _ := protocol.ConstrRootKeyTx(0, protocol.ROOT_KEY_REMOVE_ID, accs[1].Hash(), 1, 5, privKeys[1])
+	if err := checkRootKeyChange(remove2); err == nil {
+		t.Error("Root key removal below the config quorum accepted.\n")
+	}
+
+	rootKeyStateChangeRollback([]*protocol.ConfigTx{remove})
+	rootKeyStateChangeRollback([]*protocol.ConfigTx{add, add2})
+	if len(storage.RootKeys) != 1 || !storage.IsRootKey(accs[0].Hash()) {
+		t.Errorf("Root key changes not rolled back: %v root keys\n", len(storage.RootKeys))
+	}
+}
@@ -0,0 +1,1202 @@
+package miner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/logging"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"net/http"
+	"sort"
+)
+
+//JSON-RPC 2.0 interface over HTTP, so wallets and explorers can query a running node without speaking the p2p
+//protocol. All hashes and addresses are hex encoded, transactions are submitted in their (hex encoded) wire format.
+
+const (
+	RPC_VERSION = "2.0"
+
+	//Error codes as defined by the JSON-RPC 2.0 specification
+	RPC_PARSE_ERROR      = -32700
+	RPC_INVALID_REQUEST  = -32600
+	RPC_METHOD_NOT_FOUND = -32601
+	RPC_INVALID_PARAMS   = -32602
+	RPC_INTERNAL_ERROR   = -32603
+)
+
+type rpcRequest struct {
+	JsonRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	Id      interface{}     `json:"id"`
+}
+
+type rpcResponse struct {
+	JsonRPC string      `json:"jsonrpc"`
+	Result  interface{} `json:"result,omitempty"`
+	Error   *rpcError   `json:"error,omitempty"`
+	Id      interface{} `json:"id"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type rpcHandler func(params json.RawMessage) (interface{}, *rpcError)
+
+var rpcMethods = map[string]rpcHandler{
+	"getTip":                 rpcGetTip,
+	"getParameters":          rpcGetParameters,
+	"getParameterHistory":    rpcGetParameterHistory,
+	"getBlockByHash":         rpcGetBlockByHash,
+	"getBlockByHeight":       rpcGetBlockByHeight,
+	"getTx":                  rpcGetTx,
+	"getSyncStatus":          rpcGetSyncStatus,
+	"getAccount":             rpcGetAccount,
+	"getAccountProof":        rpcGetAccountProof,
+	"getOpenTxs":             rpcGetOpenTxs,
+	"getMempoolStats":        rpcGetMempoolStats,
+	"getSuggestedFee":        rpcGetSuggestedFee,
+	"getIotAck":              rpcGetIotAck,
+	"getAggregationProof":    rpcGetAggregationProof,
+	"getBlocksByBeneficiary": rpcGetBlocksByBeneficiary,
+	"getSlashingBlocks":      rpcGetSlashingBlocks,
+	"getLogs":                rpcGetLogs,
+	"getAccountTxs":          rpcGetAccountTxs,
+	"getBalanceAt":           rpcGetBalanceAt,
+	"getDelegations":         rpcGetDelegations,
+	"traceTx":                rpcTraceTx,
+	"submitTx":               rpcSubmitTx,
+	"fetchTx":                rpcFetchTx,
+	"getLogLevels":           rpcGetLogLevels,
+	"setLogLevels":           rpcSetLogLevels,
+}
+
+type rpcBlock struct {
+	Hash           string   `json:"hash"`
+	PrevHash       string   `json:"prevHash"`
+	Height         uint32   `json:"height"`
+	Timestamp      int64    `json:"timestamp"`
+	Beneficiary    string   `json:"beneficiary"`
+	MerkleRoot     string   `json:"merkleRoot"`
+	StateRoot      string   `json:"stateRoot"`
+	AccTxData      []string `json:"accTxData"`
+	FundsTxData    []string `json:"fundsTxData"`
+	ConfigTxData   []string `json:"configTxData"`
+	StakeTxData    []string `json:"stakeTxData"`
+	AggTxData      []string `json:"aggTxData"`
+	IoTTxData      []string `json:"iotTxData"`
+	ContractTxData []string `json:"contractTxData"`
+}
+
+type rpcAccount struct {
+	Address            string          `json:"address"`
+	Issuer             string          `json:"issuer"`
+	Balance            uint64          `json:"balance"`
+	TxCnt              uint32          `json:"txCnt"`
+	IsStaking          bool            `json:"isStaking"`
+	StakingBlockHeight uint32          `json:"stakingBlockHeight"`
+	UnbondingHeight    uint32          `json:"unbondingHeight,omitempty"`
+	IsRoot             bool            `json:"isRoot"`
+	Threshold          uint8           `json:"threshold,omitempty"`
+	Cosigners          []string        `json:"cosigners,omitempty"`
+	Device             *rpcDevice      `json:"device,omitempty"`
+	Delegations        []rpcDelegation `json:"delegations,omitempty"`
+}
+
+//The system parameters, see blockchainparam.go. They apply to the blocks above the height, the block hash is the one of
+//the block containing the config txs which changed them (zero for the defaults).
+type rpcParameters struct {
+	Height                uint32 `json:"height"`
+	BlockHash             string `json:"blockHash"`
+	FeeMinimum            uint64 `json:"feeMinimum"`
+	BlockSize             uint64 `json:"blockSize"`
+	DiffInterval          uint64 `json:"diffInterval"`
+	BlockInterval         uint64 `json:"blockInterval"`
+	BlockReward           uint64 `json:"blockReward"`
+	StakingMinimum        uint64 `json:"stakingMinimum"`
+	WaitingMinimum        uint64 `json:"waitingMinimum"`
+	AcceptedTimeDiff      uint64 `json:"acceptedTimeDiff"`
+	SlashingWindowSize    uint64 `json:"slashingWindowSize"`
+	SlashReward           uint64 `json:"slashReward"`
+	BlockTriggerTxs       uint64 `json:"blockTriggerTxs"`
+	BlockTriggerTime      uint64 `json:"blockTriggerTime"`
+	IotRateCapacity       uint64 `json:"iotRateCapacity"`
+	IotRateRefill         uint64 `json:"iotRateRefill"`
+	UnbondingPeriod       uint64 `json:"unbondingPeriod"`
+	RsaCommitmentDeadline uint64 `json:"rsaCommitmentDeadline"`
+	SlashFraction         uint64 `json:"slashFraction"`
+	ConfigQuorum          uint64 `json:"configQuorum"`
+	ConfigActivationDelay uint64 `json:"configActivationDelay"`
+	DiffEmaWindow         uint64 `json:"diffEmaWindow"`
+	StateRootHeight       uint64 `json:"stateRootHeight"`
+	TxOrderHeight         uint64 `json:"txOrderHeight"`
+	AggTxAuthHeight       uint64 `json:"aggTxAuthHeight"`
+	MedianTimeHeight      uint64 `json:"medianTimeHeight"`
+	BlockHashHeight       uint64 `json:"blockHashHeight"`
+	DelegationHeight      uint64 `json:"delegationHeight"`
+	//Changes which only apply to blocks above the next one, see Config_activation_delay.
+	Pending bool `json:"pending,omitempty"`
+}
+
+type rpcSyncStatus struct {
+	Height    uint32 `json:"height"`
+	Hash      string `json:"hash"`
+	Timestamp int64  `json:"timestamp"`
+	Peers     int    `json:"peers"`
+	//The highest height relayed by the beacon peers, omitted if there are none.
+	NetworkHeight *uint32 `json:"networkHeight,omitempty"`
+	OpenTxs       int     `json:"openTxs"`
+	//Whether the node would produce the next block, see readiness.go, otherwise the reason.
+	Ready    bool   `json:"ready"`
+	NotReady string `json:"notReady,omitempty"`
+}
+
+type rpcDevice struct {
+	Type      string `json:"type"`
+	Owner     string `json:"owner"`
+	RateLimit uint32 `json:"rateLimit"`
+}
+
+//A delegation kept by the validator's account (see delegation.go), with the delegator or the validator, depending on
+//the account it is listed for.
+type rpcDelegation struct {
+	Delegator string `json:"delegator,omitempty"`
+	Validator string `json:"validator,omitempty"`
+	Amount    uint64 `json:"amount"`
+	Rewards   uint64 `json:"rewards"`
+}
+
+type rpcTx struct {
+	Hash        string   `json:"hash"`
+	Type        string   `json:"type"`
+	Fee         uint64   `json:"fee"`
+	Tx          string   `json:"tx"`
+	Annotations []string `json:"annotations,omitempty"`
+}
+
+type rpcTxStatus struct {
+	rpcTx
+	//"closed" if the tx is in a block, "open" if it is in the mempool.
+	Status string `json:"status"`
+}
+
+//Ack holds the binary encoded bundle (see protocol.IotAck) for devices to store, the other fields are informational.
+type rpcIotAck struct {
+	TxHash        string   `json:"txHash"`
+	BlockHash     string   `json:"blockHash"`
+	Height        uint32   `json:"height"`
+	Confirmations uint32   `json:"confirmations"`
+	MerkleRoot    string   `json:"merkleRoot"`
+	Path          uint32   `json:"path"`
+	Proof         []string `json:"proof"`
+	Ack           string   `json:"ack"`
+}
+
+//One step per aggregate level, see protocol.AggregationProof. Aggregate holds the binary encoded aggregate whose hash
+//is the leaf of the next step.
+type rpcAggregationStep struct {
+	Level       uint8    `json:"level"`
+	FirstHeight uint32   `json:"firstHeight"`
+	LastHeight  uint32   `json:"lastHeight"`
+	Root        string   `json:"root"`
+	Path        uint32   `json:"path"`
+	Proof       []string `json:"proof"`
+	Aggregate   string   `json:"aggregate"`
+}
+
+//The proof is against the state root of the last block, see protocol.StateProof. Account and Proof hold the binary
+//encoded account (empty if there is none) and proof, which light clients verify against the header of the block.
+type rpcAccountProof struct {
+	BlockHash string      `json:"blockHash"`
+	Height    uint32      `json:"height"`
+	StateRoot string      `json:"stateRoot"`
+	Account   string      `json:"account"`
+	Proof     string      `json:"proof"`
+	Details   *rpcAccount `json:"details,omitempty"`
+}
+
+type rpcIndexedBlock struct {
+	Height uint32 `json:"height"`
+	Hash   string `json:"hash"`
+}
+
+type rpcLog struct {
+	Contract string   `json:"contract"`
+	TxHash   string   `json:"txHash"`
+	Height   uint32   `json:"height"`
+	Index    uint32   `json:"index"`
+	Topics   []string `json:"topics"`
+	Data     string   `json:"data"`
+}
+
+type rpcTrace struct {
+	TxHash  string         `json:"txHash"`
+	Success bool           `json:"success"`
+	Error   string         `json:"error,omitempty"`
+	GasUsed uint64         `json:"gasUsed"`
+	Steps   []rpcTraceStep `json:"steps"`
+}
+
+//The stack is hex encoded, the top of the stack last.
+type rpcTraceStep struct {
+	Depth        int              `json:"depth"`
+	Pc           int              `json:"pc"`
+	OpCode       string           `json:"opCode"`
+	Gas          uint64           `json:"gas"`
+	Stack        []string         `json:"stack"`
+	StorageWrite *rpcStorageWrite `json:"storageWrite,omitempty"`
+}
+
+type rpcStorageWrite struct {
+	Index int    `json:"index"`
+	Value string `json:"value"`
+}
+
+//All fields are optional, the height range defaults to the whole chain.
+type rpcGetLogsParams struct {
+	Address    string  `json:"address"`
+	Topic      string  `json:"topic"`
+	FromHeight uint32  `json:"fromHeight"`
+	ToHeight   *uint32 `json:"toHeight"`
+}
+
+type rpcGetAccountTxsParams struct {
+	Address  string  `json:"address"`
+	ToHeight *uint32 `json:"toHeight"`
+	Limit    int     `json:"limit"`
+}
+
+type rpcGetAccountProofParams struct {
+	Address string  `json:"address"`
+	Height  *uint32 `json:"height,omitempty"`
+}
+
+type rpcGetBalanceAtParams struct {
+	Address string `json:"address"`
+	Height  uint32 `json:"height"`
+}
+
+type rpcBalance struct {
+	Address string `json:"address"`
+	Height  uint32 `json:"height"`
+	Balance uint64 `json:"balance"`
+}
+
+type rpcAccountTxs struct {
+	Txs  []rpcAccountTx `json:"txs"`
+	Next *uint32        `json:"next,omitempty"`
+}
+
+type rpcAccountTx struct {
+	Height uint32 `json:"height"`
+	Hash   string `json:"hash"`
+	Type   string `json:"type"`
+}
+
+type rpcSubmitTxParams struct {
+	Type string `json:"type"`
+	Tx   string `json:"tx"`
+}
+
+type rpcFetchTxParams struct {
+	Type      string `json:"type"`
+	BlockHash string `json:"blockHash"`
+	TxHash    string `json:"txHash"`
+}
+
+//Request types used to fetch txs from the network, by the type names used in the RPC interface.
+var rpcTxReqTypes = map[string]uint8{
+	"funds":    p2p.FUNDSTX_REQ,
+	"acc":      p2p.ACCTX_REQ,
+	"config":   p2p.CONFIGTX_REQ,
+	"stake":    p2p.STAKETX_REQ,
+	"agg":      p2p.AGGTX_REQ,
+	"iot":      p2p.IOTTX_REQ,
+	"contract": p2p.CONTRACTTX_REQ,
+}
+
+//Starts the JSON-RPC server listening at ipport. The call blocks as long as the server is running.
+func StartRPCServer(ipport string) error {
+	return http.ListenAndServe(ipport, authorizeRPC(newRPCMux()))
+}
+
+func newRPCMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", handleRPC)
+	mux.HandleFunc("/ws", handleWebSocket)
+	mux.HandleFunc("/backup", handleBackup)
+	mux.HandleFunc("/snapshot", handleSnapshot)
+	mux.HandleFunc("/health", handleHealth)
+	//The metrics published with expvar (see metrics.go).
+	mux.Handle("/debug/vars", expvar.Handler())
+	handleExplorer(mux)
+
+	return mux
+}
+
+func handleRPC(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "Only POST requests are supported.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	var response rpcResponse
+	var req rpcRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		response = rpcResponse{JsonRPC: RPC_VERSION, Error: &rpcError{RPC_PARSE_ERROR, fmt.Sprintf("Parsing request failed: %v", err)}}
+	} else {
+		response = processRPCRequest(&req)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+//Streams a consistent snapshot of the database, used by "backup create" while the node is running. Like every RPC
+//endpoint, it is only served to loopback clients or clients sending the RPC token (see rpcauth.go).
+func handleBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET requests are supported.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if _, err := storage.WriteBackup(w); err != nil {
+		logger.Printf("Writing backup failed: %v\n", err)
+	}
+}
+
+//Creates a snapshot of the current state, used by "snapshot export" while the node is running.
+func handleSnapshot(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Only GET requests are supported.", http.StatusMethodNotAllowed)
+		return
+	}
+
+	blockValidation.Lock()
+	var encodedSnapshot []byte
+	if lastBlock != nil {
+		encodedSnapshot = createSnapshot(lastBlock).Encode()
+	}
+	blockValidation.Unlock()
+
+	if encodedSnapshot == nil {
+		http.Error(w, "Node is not synchronized yet.", http.StatusServiceUnavailable)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Write(encodedSnapshot)
+}
+
+//Split from handleRPC for cleaner testing.
+func processRPCRequest(req *rpcRequest) rpcResponse {
+	response := rpcResponse{JsonRPC: RPC_VERSION, Id: req.Id}
+
+	if req.JsonRPC != RPC_VERSION {
+		response.Error = &rpcError{RPC_INVALID_REQUEST, fmt.Sprintf("Unsupported JSON-RPC version: %v", req.JsonRPC)}
+		return response
+	}
+
+	handler, exists := rpcMethods[req.Method]
+	if !exists {
+		response.Error = &rpcError{RPC_METHOD_NOT_FOUND, fmt.Sprintf("Method not found: %v", req.Method)}
+		return response
+	}
+
+	response.Result, response.Error = handler(req.Params)
+
+	return response
+}
+
+//Params are expected as a positional array with a single hex encoded hash.
+func parseHashParam(params json.RawMessage) (hash [32]byte, err error) {
+	var args []string
+	if err = json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+		return hash, errors.New("Expected a single hash as parameter.")
+	}
+
+	return decodeHash(args[0])
+}
+
+func decodeHash(encoded string) (hash [32]byte, err error) {
+	decoded, err := hex.DecodeString(encoded)
+	if err != nil || len(decoded) != 32 {
+		return hash, errors.New(fmt.Sprintf("Invalid hash: %v", encoded))
+	}
+	copy(hash[:], decoded)
+
+	return hash, nil
+}
+
+func encodeHashes(hashes [][32]byte) (encoded []string) {
+	encoded = []string{}
+	for _, hash := range hashes {
+		encoded = append(encoded, hex.EncodeToString(hash[:]))
+	}
+
+	return encoded
+}
+
+//Returns the last block of the chain the node is currently on.
+func rpcGetTip(params json.RawMessage) (interface{}, *rpcError) {
+	blockValidation.Lock()
+	tip := lastBlock
+	blockValidation.Unlock()
+
+	if tip == nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, "Node is not synchronized yet."}
+	}
+
+	return newRPCBlock(tip), nil
+}
+
+func rpcGetSyncStatus(params json.RawMessage) (interface{}, *rpcError) {
+	blockValidation.Lock()
+	tip := lastBlock
+	blockValidation.Unlock()
+
+	if tip == nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, "Node is not synchronized yet."}
+	}
+
+	return newSyncStatus(tip), nil
+}
+
+func newSyncStatus(tip *protocol.Block) rpcSyncStatus {
+	status := rpcSyncStatus{
+		Height:    tip.Height,
+		Hash:      hex.EncodeToString(tip.Hash[:]),
+		Timestamp: tip.Timestamp,
+		Peers:     p2p.MinerPeerCount(),
+		OpenTxs:   mempool.Len(),
+		Ready:     true,
+	}
+
+	networkHeight, known := p2p.NetworkTipHeight()
+	if known {
+		status.NetworkHeight = &networkHeight
+	}
+
+	if err := checkReadiness(status.Peers, tip.Height, networkHeight, known); err != nil {
+		status.Ready = false
+		status.NotReady = err.Error()
+	}
+
+	return status
+}
+
+func newRPCParameters(params *Parameters) rpcParameters {
+	return rpcParameters{
+		Height:                params.Height,
+		BlockHash:             hex.EncodeToString(params.BlockHash[:]),
+		FeeMinimum:            params.Fee_minimum,
+		BlockSize:             params.Block_size,
+		DiffInterval:          params.Diff_interval,
+		BlockInterval:         params.Block_interval,
+		BlockReward:           params.Block_reward,
+		StakingMinimum:        params.Staking_minimum,
+		WaitingMinimum:        params.Waiting_minimum,
+		AcceptedTimeDiff:      params.Accepted_time_diff,
+		SlashingWindowSize:    params.Slashing_window_size,
+		SlashReward:           params.Slash_reward,
+		BlockTriggerTxs:       params.Block_trigger_txs,
+		BlockTriggerTime:      params.Block_trigger_time,
+		IotRateCapacity:       params.Iot_rate_capacity,
+		IotRateRefill:         params.Iot_rate_refill,
+		UnbondingPeriod:       params.Unbonding_period,
+		RsaCommitmentDeadline: params.Rsa_commitment_deadline,
+		SlashFraction:         params.Slash_fraction,
+		ConfigQuorum:          params.Config_quorum,
+		ConfigActivationDelay: params.Config_activation_delay,
+		DiffEmaWindow:         params.Diff_ema_window,
+		StateRootHeight:       params.State_root_height,
+		TxOrderHeight:         params.Tx_order_height,
+		AggTxAuthHeight:       params.Agg_tx_auth_height,
+		MedianTimeHeight:      params.Median_time_height,
+		BlockHashHeight:       params.Block_hash_height,
+		DelegationHeight:      params.Delegation_height,
+	}
+}
+
+//Returns the parameters that apply to the block above the tip.
+func rpcGetParameters(params json.RawMessage) (interface{}, *rpcError) {
+	blockValidation.Lock()
+	active := *activeParameters
+	blockValidation.Unlock()
+
+	return newRPCParameters(&active), nil
+}
+
+//Returns all parameter changes of the chain in height order, starting with the defaults (or the parameters of the
+//snapshot the node started from). Changes which are not active yet are marked as pending.
+func rpcGetParameterHistory(params json.RawMessage) (interface{}, *rpcError) {
+	blockValidation.Lock()
+	defer blockValidation.Unlock()
+
+	var tipHeight uint32
+	if lastBlock != nil {
+		tipHeight = lastBlock.Height
+	}
+
+	history := make([]rpcParameters, len(parameterSlice))
+	for i := range parameterSlice {
+		history[i] = newRPCParameters(&parameterSlice[i])
+		history[i].Pending = parameterSlice[i].Height > tipHeight
+	}
+
+	return history, nil
+}
+
+func rpcGetBlockByHash(params json.RawMessage) (interface{}, *rpcError) {
+	hash, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	block := readBlock(hash)
+	if block == nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, fmt.Sprintf("Block (%x) not found.", hash[0:8])}
+	}
+
+	return newRPCBlock(block), nil
+}
+
+//Returns the block at the height on the chain the node is currently on.
+func rpcGetBlockByHeight(params json.RawMessage) (interface{}, *rpcError) {
+	var args []uint32
+	if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+		return nil, &rpcError{RPC_INVALID_PARAMS, "Expected the block height as parameter."}
+	}
+
+	block := storage.ReadBlockByHeight(args[0])
+	if block == nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, fmt.Sprintf("No block at height %v.", args[0])}
+	}
+
+	return newRPCBlock(block), nil
+}
+
+//Blocks of which the txs have been aggregated are stored without txs.
+func readBlock(hash [32]byte) *protocol.Block {
+	if block := storage.ReadClosedBlock(hash); block != nil {
+		return block
+	}
+
+	return storage.ReadClosedBlockWithoutTx(hash)
+}
+
+func newRPCBlock(block *protocol.Block) rpcBlock {
+	return rpcBlock{
+		Hash:           hex.EncodeToString(block.Hash[:]),
+		PrevHash:       hex.EncodeToString(block.PrevHash[:]),
+		Height:         block.Height,
+		Timestamp:      block.Timestamp,
+		Beneficiary:    hex.EncodeToString(block.Beneficiary[:]),
+		MerkleRoot:     hex.EncodeToString(block.MerkleRoot[:]),
+		StateRoot:      hex.EncodeToString(block.StateRoot[:]),
+		AccTxData:      encodeHashes(block.AccTxData),
+		FundsTxData:    encodeHashes(block.FundsTxData),
+		ConfigTxData:   encodeHashes(block.ConfigTxData),
+		StakeTxData:    encodeHashes(block.StakeTxData),
+		AggTxData:      encodeHashes(block.AggTxData),
+		IoTTxData:      encodeHashes(block.IoTTxData),
+		ContractTxData: encodeHashes(block.ContractTxData),
+	}
+}
+
+func rpcGetAccount(params json.RawMessage) (interface{}, *rpcError) {
+	address, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	acc, err := storage.GetAccount(address)
+	if err != nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, err.Error()}
+	}
+
+	return newRPCAccount(address, acc), nil
+}
+
+func newRPCAccount(address [32]byte, acc *protocol.Account) rpcAccount {
+	var cosigners []string
+	for _, cosigner := range acc.Cosigners {
+		cosigners = append(cosigners, hex.EncodeToString(cosigner[:]))
+	}
+
+	var device *rpcDevice
+	if acc.IsDevice() {
+		device = &rpcDevice{acc.Device.Type, hex.EncodeToString(acc.Device.Owner[:]), acc.Device.RateLimit}
+	}
+
+	var delegations []rpcDelegation
+	for _, delegation := range acc.Delegations {
+		delegations = append(delegations, rpcDelegation{Delegator: hex.EncodeToString(delegation.Delegator[:]), Amount: delegation.Amount, Rewards: delegation.Rewards})
+	}
+
+	return rpcAccount{
+		Address:            hex.EncodeToString(acc.Address[:]),
+		Issuer:             hex.EncodeToString(acc.Issuer[:]),
+		Balance:            acc.Balance,
+		TxCnt:              acc.TxCnt,
+		IsStaking:          acc.IsStaking,
+		StakingBlockHeight: acc.StakingBlockHeight,
+		UnbondingHeight:    acc.UnbondingHeight,
+		IsRoot:             storage.IsRootKey(address),
+		Threshold:          acc.Threshold,
+		Cosigners:          cosigners,
+		Device:             device,
+		Delegations:        delegations,
+	}
+}
+
+//Returns the delegations of the delegator to all validators, sorted by validator.
+func rpcGetDelegations(params json.RawMessage) (interface{}, *rpcError) {
+	delegator, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	delegations := []rpcDelegation{}
+	for hash, acc := range storage.State.Snapshot() {
+		if delegation := acc.GetDelegation(delegator); delegation != nil {
+			delegations = append(delegations, rpcDelegation{Validator: hex.EncodeToString(hash[:]), Amount: delegation.Amount, Rewards: delegation.Rewards})
+		}
+	}
+
+	sort.Slice(delegations, func(i, j int) bool {
+		return delegations[i].Validator < delegations[j].Validator
+	})
+
+	return delegations, nil
+}
+
+func rpcGetAccountProof(params json.RawMessage) (interface{}, *rpcError) {
+	var args rpcGetAccountProofParams
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Expected address and optionally height as parameters: %v", err)}
+	}
+
+	address, err := decodeHash(args.Address)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	blockValidation.Lock()
+	defer blockValidation.Unlock()
+
+	if lastBlock == nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, "No block validated yet."}
+	}
+
+	//Without height, the proof is for the last block.
+	height := lastBlock.Height
+	if args.Height != nil {
+		height = *args.Height
+	}
+	if height > lastBlock.Height {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Height %v is above the tip (%v).", height, lastBlock.Height)}
+	}
+	if height == lastBlock.Height && lastBlock.StateRoot == [32]byte{} {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, fmt.Sprintf("Block (%x) has no state root.", lastBlock.Hash[0:8])}
+	}
+
+	proof, err := accountProof(address, height)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	stateRoot := storage.ReadStateTrie(proof.BlockHash).Root()
+	result := rpcAccountProof{
+		BlockHash: hex.EncodeToString(proof.BlockHash[:]),
+		Height:    proof.Height,
+		StateRoot: hex.EncodeToString(stateRoot[:]),
+		Account:   hex.EncodeToString(proof.Account),
+		Proof:     hex.EncodeToString(proof.Proof.Encode()),
+	}
+	if len(proof.Account) > 0 {
+		var acc *protocol.Account
+		if acc, _ = acc.Decode(proof.Account); acc != nil {
+			details := newRPCAccount(address, acc)
+			result.Details = &details
+		}
+	}
+
+	return result, nil
+}
+
+func rpcGetOpenTxs(params json.RawMessage) (interface{}, *rpcError) {
+	openTxs := []rpcTx{}
+	for _, tx := range storage.ReadAllOpenTxs() {
+		openTxs = append(openTxs, newRPCTx(tx))
+	}
+
+	return openTxs, nil
+}
+
+func rpcGetIotAck(params json.RawMessage) (interface{}, *rpcError) {
+	hash, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	ack, err := storage.ReadIotAck(hash)
+	if err != nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, err.Error()}
+	}
+
+	return rpcIotAck{
+		TxHash:        hex.EncodeToString(ack.TxHash[:]),
+		BlockHash:     hex.EncodeToString(ack.BlockHash[:]),
+		Height:        ack.Height,
+		Confirmations: ack.Confirmations,
+		MerkleRoot:    hex.EncodeToString(ack.MerkleRoot[:]),
+		Path:          ack.Proof.Path,
+		Proof:         encodeHashes(ack.Proof.Siblings),
+		Ack:           hex.EncodeToString(ack.Encode()),
+	}, nil
+}
+
+//Proofs are available from the time the tx's epoch is aggregated until its txs are pruned.
+func rpcGetAggregationProof(params json.RawMessage) (interface{}, *rpcError) {
+	hash, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	proof := aggregationProof(hash)
+	if proof == nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, fmt.Sprintf("No aggregation proof for tx (%x).", hash[0:8])}
+	}
+
+	steps := make([]rpcAggregationStep, len(proof.Steps))
+	for i, step := range proof.Steps {
+		steps[i] = rpcAggregationStep{
+			Level:       step.Aggregate.Level,
+			FirstHeight: step.Aggregate.FirstHeight,
+			LastHeight:  step.Aggregate.LastHeight,
+			Root:        hex.EncodeToString(step.Aggregate.Root[:]),
+			Path:        step.Proof.Path,
+			Proof:       encodeHashes(step.Proof.Siblings),
+			Aggregate:   hex.EncodeToString(step.Aggregate.Encode()),
+		}
+	}
+
+	return steps, nil
+}
+
+//Params: the hash of the beneficiary's account, as in the beneficiary field of the blocks.
+func rpcGetBlocksByBeneficiary(params json.RawMessage) (interface{}, *rpcError) {
+	beneficiary, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	return newRPCIndexedBlocks(storage.ReadBeneficiaryBlocks(beneficiary)), nil
+}
+
+//Params: the slashed address, as in the slashedAddress field of the blocks.
+func rpcGetSlashingBlocks(params json.RawMessage) (interface{}, *rpcError) {
+	slashedAddress, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	return newRPCIndexedBlocks(storage.ReadSlashingBlocks(slashedAddress)), nil
+}
+
+//Params: the contract's address (the hash of its account), a hex encoded topic and the height range to search.
+func rpcGetLogs(params json.RawMessage) (interface{}, *rpcError) {
+	var args rpcGetLogsParams
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Expected address, topic, fromHeight and toHeight as parameters: %v", err)}
+	}
+
+	var contract [32]byte
+	if args.Address != "" {
+		var err error
+		if contract, err = decodeHash(args.Address); err != nil {
+			return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+		}
+	}
+
+	var topic []byte
+	if args.Topic != "" {
+		var err error
+		if topic, err = hex.DecodeString(args.Topic); err != nil || len(topic) > protocol.MAX_LOG_TOPIC_SIZE {
+			return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Invalid topic: %v", args.Topic)}
+		}
+	}
+
+	toHeight := ^uint32(0)
+	if args.ToHeight != nil {
+		toHeight = *args.ToHeight
+	}
+	if args.FromHeight > toHeight {
+		return nil, &rpcError{RPC_INVALID_PARAMS, "fromHeight is above toHeight."}
+	}
+
+	logs := []rpcLog{}
+	for _, log := range storage.ReadLogs(contract, topic, args.FromHeight, toHeight) {
+		topics := []string{}
+		for _, logTopic := range log.Topics {
+			topics = append(topics, hex.EncodeToString(logTopic))
+		}
+		logs = append(logs, rpcLog{
+			Contract: hex.EncodeToString(log.Contract[:]),
+			TxHash:   hex.EncodeToString(log.TxHash[:]),
+			Height:   log.Height,
+			Index:    log.Index,
+			Topics:   topics,
+			Data:     hex.EncodeToString(log.Data),
+		})
+	}
+
+	return logs, nil
+}
+
+//Params: the hash of a fundsTx calling a contract. The call is replayed against the current state (see
+//traceContractCall), every executed instruction is returned.
+func rpcTraceTx(params json.RawMessage) (interface{}, *rpcError) {
+	txHash, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	blockValidation.Lock()
+	trace, err := traceContractCall(txHash)
+	blockValidation.Unlock()
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	steps := []rpcTraceStep{}
+	for _, step := range trace.Steps {
+		stack := []string{}
+		for _, element := range step.Stack {
+			stack = append(stack, hex.EncodeToString(element))
+		}
+
+		rpcStep := rpcTraceStep{Depth: step.Depth, Pc: step.Pc, OpCode: step.OpCode, Gas: step.Gas, Stack: stack}
+		if step.StorageWrite != nil {
+			rpcStep.StorageWrite = &rpcStorageWrite{step.StorageWrite.Index, hex.EncodeToString(step.StorageWrite.Value)}
+		}
+		steps = append(steps, rpcStep)
+	}
+
+	return rpcTrace{
+		TxHash:  hex.EncodeToString(txHash[:]),
+		Success: trace.Success,
+		Error:   trace.Error,
+		GasUsed: trace.GasUsed,
+		Steps:   steps,
+	}, nil
+}
+
+//Returns the log levels in the format of the --loglevel flag, e.g., "info,p2p=debug".
+func rpcGetLogLevels(params json.RawMessage) (interface{}, *rpcError) {
+	return logging.Levels(), nil
+}
+
+//Params: the levels to change in the format of the --loglevel flag, modules not listed keep their level.
+func rpcSetLogLevels(params json.RawMessage) (interface{}, *rpcError) {
+	var args []string
+	if err := json.Unmarshal(params, &args); err != nil || len(args) != 1 {
+		return nil, &rpcError{RPC_INVALID_PARAMS, "Expected the log levels as single parameter."}
+	}
+
+	if err := logging.SetLevels(args[0]); err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	return logging.Levels(), nil
+}
+
+//Params: the hash of the account, the height to start at (default: the newest txs) and the page size.
+func rpcGetAccountTxs(params json.RawMessage) (interface{}, *rpcError) {
+	var args rpcGetAccountTxsParams
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Expected address, toHeight and limit as parameters: %v", err)}
+	}
+
+	address, err := decodeHash(args.Address)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	toHeight := ^uint32(0)
+	if args.ToHeight != nil {
+		toHeight = *args.ToHeight
+	}
+
+	if args.Limit == 0 {
+		args.Limit = ACCOUNT_TXS_PAGE_SIZE
+	}
+	if args.Limit < 0 || args.Limit > ACCOUNT_TXS_MAX_PAGE_SIZE {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Limit must be between 1 and %v.", ACCOUNT_TXS_MAX_PAGE_SIZE)}
+	}
+
+	return newRPCAccountTxs(address, toHeight, args.Limit), nil
+}
+
+//Params: the hash of the account and the height, the balance after the block at the height is returned.
+func rpcGetBalanceAt(params json.RawMessage) (interface{}, *rpcError) {
+	var args rpcGetBalanceAtParams
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Expected address and height as parameters: %v", err)}
+	}
+
+	address, err := decodeHash(args.Address)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	blockValidation.Lock()
+	tip := lastBlock
+	blockValidation.Unlock()
+
+	if tip == nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, "Node is not synchronized yet."}
+	}
+	if args.Height > tip.Height {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Height %v is above the tip (%v).", args.Height, tip.Height)}
+	}
+	if args.Height < balanceHistoryStart {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Balances before height %v are not available, the node started from a snapshot.", balanceHistoryStart)}
+	}
+
+	//Accounts without an entry did not exist at the height.
+	balance, _ := storage.ReadBalanceAt(address, args.Height)
+
+	return rpcBalance{args.Address, args.Height, balance}, nil
+}
+
+func newRPCAccountTxs(address [32]byte, toHeight uint32, limit int) rpcAccountTxs {
+	page := rpcAccountTxs{Txs: []rpcAccountTx{}}
+	entries := storage.ReadAccountTxs(address, toHeight, limit)
+	for _, entry := range entries {
+		txType := "unknown"
+		if tx := storage.ReadClosedTx(entry.TxHash); tx != nil {
+			txType = rpcTxType(tx)
+		}
+		page.Txs = append(page.Txs, rpcAccountTx{entry.Height, hex.EncodeToString(entry.TxHash[:]), txType})
+	}
+
+	//Pages end at block boundaries, the next page starts below the last block of this one.
+	if len(entries) >= limit && entries[len(entries)-1].Height > 0 {
+		next := entries[len(entries)-1].Height - 1
+		page.Next = &next
+	}
+
+	return page
+}
+
+func newRPCIndexedBlocks(blocks []storage.IndexedBlock) []rpcIndexedBlock {
+	rpcBlocks := []rpcIndexedBlock{}
+	for _, block := range blocks {
+		rpcBlocks = append(rpcBlocks, rpcIndexedBlock{block.Height, hex.EncodeToString(block.BlockHash[:])})
+	}
+
+	return rpcBlocks
+}
+
+func rpcGetMempoolStats(params json.RawMessage) (interface{}, *rpcError) {
+	return mempool.Stats(), nil
+}
+
+//Params are optional, a positional array with the size of the transaction in bytes. Defaults to the size of a fundsTx.
+func rpcGetSuggestedFee(params json.RawMessage) (interface{}, *rpcError) {
+	txSize := uint64(protocol.FUNDSTX_SIZE)
+	if len(params) > 0 && string(params) != "null" {
+		var args []uint64
+		if err := json.Unmarshal(params, &args); err != nil || len(args) > 1 {
+			return nil, &rpcError{RPC_INVALID_PARAMS, "Expected the tx size as optional parameter."}
+		}
+		if len(args) == 1 {
+			txSize = args[0]
+		}
+	}
+
+	return estimateFee(txSize), nil
+}
+
+func rpcGetTx(params json.RawMessage) (interface{}, *rpcError) {
+	txHash, err := parseHashParam(params)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	tx := readRPCTx(txHash)
+	if tx == nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, fmt.Sprintf("Transaction (%x) not found.", txHash[0:8])}
+	}
+
+	return tx, nil
+}
+
+func readRPCTx(txHash [32]byte) *rpcTxStatus {
+	if tx := storage.ReadClosedTx(txHash); tx != nil {
+		return &rpcTxStatus{newRPCTx(tx), "closed"}
+	}
+
+	if tx := storage.ReadOpenTx(txHash); tx != nil {
+		return &rpcTxStatus{newRPCTx(tx), "open"}
+	}
+
+	return nil
+}
+
+func newRPCTx(tx protocol.Transaction) rpcTx {
+	txHash := tx.Hash()
+	return rpcTx{
+		Hash:        hex.EncodeToString(txHash[:]),
+		Type:        rpcTxType(tx),
+		Fee:         tx.TxFee(),
+		Tx:          hex.EncodeToString(tx.Encode()),
+		Annotations: mempool.Annotations(txHash),
+	}
+}
+
+func rpcTxType(tx protocol.Transaction) string {
+	switch tx.(type) {
+	case *protocol.FundsTx:
+		return "funds"
+	case *protocol.AccTx:
+		return "acc"
+	case *protocol.ConfigTx:
+		return "config"
+	case *protocol.StakeTx:
+		return "stake"
+	case *protocol.AggTx:
+		return "agg"
+	case *protocol.IotTx:
+		return "iot"
+	case *protocol.ContractTx:
+		return "contract"
+	}
+
+	return "unknown"
+}
+
+//Decodes the transaction according to its type and returns the broadcast type used to relay it to the network.
+func decodeRPCTx(txType string, payload []byte) (protocol.Transaction, uint8, error) {
+	var err error
+	switch txType {
+	case "funds":
+		var fTx *protocol.FundsTx
+		if fTx, err = fTx.Decode(payload); err == nil {
+			return fTx, p2p.FUNDSTX_BRDCST, nil
+		}
+	case "acc":
+		var aTx *protocol.AccTx
+		if aTx, err = aTx.Decode(payload); err == nil {
+			return aTx, p2p.ACCTX_BRDCST, nil
+		}
+	case "config":
+		var cTx *protocol.ConfigTx
+		if cTx, err = cTx.Decode(payload); err == nil {
+			return cTx, p2p.CONFIGTX_BRDCST, nil
+		}
+	case "stake":
+		var sTx *protocol.StakeTx
+		if sTx, err = sTx.Decode(payload); err == nil {
+			return sTx, p2p.STAKETX_BRDCST, nil
+		}
+	case "iot":
+		var iTx *protocol.IotTx
+		if iTx, err = iTx.Decode(payload); err == nil {
+			return iTx, p2p.IOTTX_BRDCST, nil
+		}
+	case "contract":
+		var cTx *protocol.ContractTx
+		if cTx, err = cTx.Decode(payload); err == nil {
+			return cTx, p2p.CONTRACTTX_BRDCST, nil
+		}
+	default:
+		err = errors.New(fmt.Sprintf("Unknown transaction type %v.", txType))
+	}
+
+	return nil, 0, err
+}
+
+//Submitted transactions are verified against the current state before they are added to the mempool and broadcast.
+func rpcSubmitTx(params json.RawMessage) (interface{}, *rpcError) {
+	var args rpcSubmitTxParams
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Expected type and tx as parameters: %v", err)}
+	}
+
+	payload, err := hex.DecodeString(args.Tx)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Invalid transaction encoding: %v", err)}
+	}
+
+	tx, brdcstType, err := decodeRPCTx(args.Type, payload)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Could not decode transaction of type %v: %v", args.Type, err)}
+	}
+
+	if err := submitTx(tx, payload, brdcstType); err != nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, err.Error()}
+	}
+
+	txHash := tx.Hash()
+	return hex.EncodeToString(txHash[:]), nil
+}
+
+//Adds a tx submitted by a client to the mempool and broadcasts its payload, shared by the JSON-RPC and gRPC interfaces.
+func submitTx(tx protocol.Transaction, payload []byte, brdcstType uint8) error {
+	txHash := tx.Hash()
+	if storage.ReadOpenTx(txHash) != nil || storage.IsClosedTx(txHash) || storage.IsParkedTx(tx) {
+		return errors.New(fmt.Sprintf("Transaction (%x) already known.", txHash[0:8]))
+	}
+
+	if !verify(tx) {
+		return errors.New(fmt.Sprintf("Transaction (%x) could not be verified.", txHash[0:8]))
+	}
+
+	if err := storage.WriteOpenTxOrdered(tx); err != nil {
+		return errors.New(fmt.Sprintf("Transaction (%x) rejected: %v", txHash[0:8], err))
+	}
+	p2p.BroadcastTx(payload, brdcstType)
+
+	return nil
+}
+
+//Fetches a tx from the network, used by light clients which do not store txs. The tx is only returned if it is
+//included in the Merkle root of the block.
+func rpcFetchTx(params json.RawMessage) (interface{}, *rpcError) {
+	var args rpcFetchTxParams
+	if err := json.Unmarshal(params, &args); err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Expected type, blockHash and txHash as parameters: %v", err)}
+	}
+
+	txType, exists := rpcTxReqTypes[args.Type]
+	if !exists {
+		return nil, &rpcError{RPC_INVALID_PARAMS, fmt.Sprintf("Unknown transaction type: %v", args.Type)}
+	}
+
+	blockHash, err := decodeHash(args.BlockHash)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	txHash, err := decodeHash(args.TxHash)
+	if err != nil {
+		return nil, &rpcError{RPC_INVALID_PARAMS, err.Error()}
+	}
+
+	tx, err := FetchTx(blockHash, txHash, txType)
+	if err != nil {
+		return nil, &rpcError{RPC_INTERNAL_ERROR, err.Error()}
+	}
+
+	return newRPCTx(tx), nil
+}
@@ -0,0 +1,197 @@
+package miner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/logging"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestProcessRPCRequest(t *testing.T) {
+	response := processRPCRequest(&rpcRequest{JsonRPC: "1.0", Method: "getOpenTxs", Id: 1})
+	if response.Error == nil || response.Error.Code != RPC_INVALID_REQUEST {
+		t.Errorf("Request with unsupported version was not rejected: %v\n", response.Error)
+	}
+
+	response = processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "unknown", Id: 2})
+	if response.Error == nil || response.Error.Code != RPC_METHOD_NOT_FOUND {
+		t.Errorf("Request with unknown method was not rejected: %v\n", response.Error)
+	}
+
+	response = processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getAccount", Params: json.RawMessage(`["xyz"]`), Id: 3})
+	if response.Error == nil || response.Error.Code != RPC_INVALID_PARAMS || response.Id != 3 {
+		t.Errorf("Request with invalid hash was not rejected: %v\n", response.Error)
+	}
+}
+
+func TestRPCGetAccount(t *testing.T) {
+	acc := protocol.NewAccount(protocol.SerializeHashContent("rpc"), [32]byte{}, 1000, false, [256]byte{}, nil, nil)
+	storage.State.Set(acc.Address, &acc)
+	defer storage.State.Delete(acc.Address)
+
+	params, _ := json.Marshal([]string{hex.EncodeToString(acc.Address[:])})
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getAccount", Params: params, Id: 1})
+	if response.Error != nil {
+		t.Fatalf("Querying account failed: %v\n", response.Error.Message)
+	}
+
+	result := response.Result.(rpcAccount)
+	if result.Balance != acc.Balance || result.Address != hex.EncodeToString(acc.Address[:]) {
+		t.Errorf("Queried account does not match: %v vs. %v\n", result, acc)
+	}
+}
+
+func TestRPCGetTip(t *testing.T) {
+	prevLastBlock := lastBlock
+	defer func() { lastBlock = prevLastBlock }()
+
+	lastBlock = &protocol.Block{Hash: [32]byte{0x01}, PrevHash: [32]byte{0x02}, Height: 7}
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getTip", Id: 1})
+	if response.Error != nil {
+		t.Fatalf("Querying tip failed: %v\n", response.Error.Message)
+	}
+
+	result := response.Result.(rpcBlock)
+	if result.Height != 7 || result.Hash != hex.EncodeToString(lastBlock.Hash[:]) {
+		t.Errorf("Queried tip does not match: %v\n", result)
+	}
+}
+
+func TestRPCGetBlockByHeight(t *testing.T) {
+	block := &protocol.Block{Hash: [32]byte{0x03}, Height: 9}
+	storage.WriteClosedBlock(block)
+	storage.WriteBlockHeight(block.Height, block.Hash)
+	defer storage.DeleteClosedBlock(block.Hash)
+	defer storage.DeleteBlockHeight(block.Height)
+
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getBlockByHeight", Params: json.RawMessage(`[9]`), Id: 1})
+	if response.Error != nil || response.Result.(rpcBlock).Hash != hex.EncodeToString(block.Hash[:]) {
+		t.Errorf("Querying block by height failed: %v\n", response.Error)
+	}
+
+	response = processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getBlockByHeight", Params: json.RawMessage(`[10]`), Id: 2})
+	if response.Error == nil || response.Error.Code != RPC_INTERNAL_ERROR {
+		t.Errorf("Missing block returned: %v\n", response.Result)
+	}
+
+	response = processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getBlockByHeight", Params: json.RawMessage(`["9"]`), Id: 3})
+	if response.Error == nil || response.Error.Code != RPC_INVALID_PARAMS {
+		t.Errorf("Invalid height accepted: %v\n", response.Result)
+	}
+}
+
+func TestRPCGetTx(t *testing.T) {
+	tx := &protocol.IotTx{From: [32]byte{0x04}, Data: []byte("7")}
+	storage.WriteClosedTx(tx)
+	defer storage.DeleteClosedTx(tx)
+
+	txHash := tx.Hash()
+	params, _ := json.Marshal([]string{hex.EncodeToString(txHash[:])})
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getTx", Params: params, Id: 1})
+	if response.Error != nil {
+		t.Fatalf("Querying tx failed: %v\n", response.Error.Message)
+	}
+
+	if result := response.Result.(*rpcTxStatus); result.Status != "closed" || result.Type != "iot" {
+		t.Errorf("Queried tx does not match: %v\n", result)
+	}
+}
+
+func TestRPCGetSyncStatus(t *testing.T) {
+	prevLastBlock := lastBlock
+	defer func() { lastBlock = prevLastBlock }()
+	defer SetProductionSafeguard(minPeers, maxTipDistance)
+
+	lastBlock = &protocol.Block{Hash: [32]byte{0x05}, Height: 11}
+	SetProductionSafeguard(0, 0)
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getSyncStatus", Id: 1})
+	if response.Error != nil {
+		t.Fatalf("Querying sync status failed: %v\n", response.Error.Message)
+	}
+
+	if result := response.Result.(rpcSyncStatus); result.Height != 11 || !result.Ready {
+		t.Errorf("Sync status does not match: %v\n", result)
+	}
+
+	//Without any miner peers, the node is not ready to produce blocks.
+	SetProductionSafeguard(1, 0)
+	response = processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getSyncStatus", Id: 2})
+	if result := response.Result.(rpcSyncStatus); result.Ready || len(result.NotReady) == 0 {
+		t.Errorf("Node without peers reported ready: %v\n", result)
+	}
+}
+
+func TestRPCGetParameters(t *testing.T) {
+	prevParameters := activeParameters
+	defer func() { activeParameters = prevParameters }()
+
+	activeParameters = &Parameters{Height: 3, Staking_minimum: 1000, Waiting_minimum: 5}
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getParameters", Id: 1})
+	if response.Error != nil {
+		t.Fatalf("Querying parameters failed: %v\n", response.Error.Message)
+	}
+
+	result := response.Result.(rpcParameters)
+	if result.Height != 3 || result.StakingMinimum != 1000 || result.WaitingMinimum != 5 {
+		t.Errorf("Queried parameters do not match: %v\n", result)
+	}
+}
+
+func TestRPCGetParameterHistory(t *testing.T) {
+	prevParameterSlice, prevLastBlock := parameterSlice, lastBlock
+	defer func() { parameterSlice, lastBlock = prevParameterSlice, prevLastBlock }()
+
+	parameterSlice = []Parameters{NewDefaultParameters(), NewDefaultParameters(), NewDefaultParameters()}
+	parameterSlice[1].Height, parameterSlice[1].BlockHash, parameterSlice[1].Fee_minimum = 5, [32]byte{0x05}, 3
+	parameterSlice[2].Height, parameterSlice[2].Fee_minimum = 12, 4
+	lastBlock = &protocol.Block{Height: 10}
+
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getParameterHistory", Id: 1})
+	if response.Error != nil {
+		t.Fatalf("Querying the parameter history failed: %v\n", response.Error.Message)
+	}
+
+	history := response.Result.([]rpcParameters)
+	if len(history) != 3 || history[1].Height != 5 || history[1].FeeMinimum != 3 || history[1].BlockHash[0:2] != "05" {
+		t.Fatalf("Queried parameter history does not match: %v\n", history)
+	}
+	if history[0].Pending || history[1].Pending || !history[2].Pending {
+		t.Errorf("Parameter changes not marked as pending correctly: %v\n", history)
+	}
+}
+
+func TestRPCSetLogLevels(t *testing.T) {
+	defer logging.SetLevels(logging.Levels())
+
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "setLogLevels", Params: json.RawMessage(`["rpctest=debug"]`), Id: 1})
+	if response.Error != nil || logging.GetLevel("rpctest") != logging.DEBUG {
+		t.Errorf("Setting log levels failed: %v\n", response.Error)
+	}
+
+	response = processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "setLogLevels", Params: json.RawMessage(`["rpctest=verbose"]`), Id: 2})
+	if response.Error == nil || response.Error.Code != RPC_INVALID_PARAMS || logging.GetLevel("rpctest") != logging.DEBUG {
+		t.Errorf("Invalid log levels accepted: %v\n", response.Error)
+	}
+}
+
+func TestRPCDebugVars(t *testing.T) {
+	countRejectedBlock(REJECTED_TX_ORDER)
+
+	recorder := httptest.NewRecorder()
+	newRPCMux().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/debug/vars", nil))
+
+	var vars map[string]json.RawMessage
+	if err := json.NewDecoder(recorder.Body).Decode(&vars); err != nil {
+		t.Fatalf("Invalid metrics response: %v\n", err)
+	}
+	for _, name := range []string{"rejected_blocks", "proposals", "block_rollbacks"} {
+		if _, exists := vars[name]; !exists {
+			t.Errorf("Metric %v not served under /debug/vars: %v\n", name, recorder.Code)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"crypto/subtle"
+	"net"
+	"net/http"
+)
+
+//The RPC server gives access to the whole database (/backup), to the node's logging and to tx submission. Without a
+//token it only serves clients connecting from the loopback interface. With a token, every client has to send it as
+//bearer token (Authorization: Bearer <token>), wherever it connects from. /health is always served, orchestrators
+//probe it without credentials.
+
+const RPC_MAX_BODY_SIZE = 1 << 20 //Bytes
+
+var rpcToken string
+
+//An empty token restricts the RPC server to loopback clients.
+func SetRPCToken(token string) {
+	rpcToken = token
+}
+
+func authorizeRPC(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/health" && !isAuthorized(r) {
+			http.Error(w, "Unauthorized.", http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, RPC_MAX_BODY_SIZE)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func isAuthorized(r *http.Request) bool {
+	if len(rpcToken) > 0 {
+		return subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+rpcToken)) == 1
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,73 @@
+package miner
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func rpcAuthStatus(remoteAddr string, path string, authorization string) int {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = remoteAddr
+	if len(authorization) > 0 {
+		req.Header.Set("Authorization", authorization)
+	}
+
+	recorder := httptest.NewRecorder()
+	authorizeRPC(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(recorder, req)
+
+	return recorder.Code
+}
+
+func TestAuthorizeRPC(t *testing.T) {
+	defer SetRPCToken(rpcToken)
+
+	//Without a token, only loopback clients are served.
+	SetRPCToken("")
+	for addr, expected := range map[string]int{"127.0.0.1:5000": http.StatusOK, "[::1]:5000": http.StatusOK, "192.0.2.1:5000": http.StatusUnauthorized} {
+		if code := rpcAuthStatus(addr, "/", ""); code != expected {
+			t.Errorf("Request from %v answered with %v instead of %v.\n", addr, code, expected)
+		}
+	}
+
+	SetRPCToken("secret")
+	if code := rpcAuthStatus("127.0.0.1:5000", "/", ""); code != http.StatusUnauthorized {
+		t.Errorf("Request without token answered with %v.\n", code)
+	}
+	if code := rpcAuthStatus("192.0.2.1:5000", "/", "Bearer wrong"); code != http.StatusUnauthorized {
+		t.Errorf("Request with a wrong token answered with %v.\n", code)
+	}
+	if code := rpcAuthStatus("192.0.2.1:5000", "/", "Bearer secret"); code != http.StatusOK {
+		t.Errorf("Request with the token answered with %v.\n", code)
+	}
+
+	//Health probes need no credentials.
+	if code := rpcAuthStatus("192.0.2.1:5000", "/health", ""); code != http.StatusOK {
+		t.Errorf("Health probe answered with %v.\n", code)
+	}
+}
+
+func TestRPCBodyLimit(t *testing.T) {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","method":"getTip","params":["`+strings.Repeat("a", RPC_MAX_BODY_SIZE)+`"],"id":1}`))
+	req.RemoteAddr = "127.0.0.1:5000"
+	recorder := httptest.NewRecorder()
+	authorizeRPC(newRPCMux()).ServeHTTP(recorder, req)
+
+	if !strings.Contains(recorder.Body.String(), "Parsing request failed") {
+		t.Errorf("Oversized request processed: %v\n", recorder.Body.String())
+	}
+}
+
+//The database is never streamed to unauthorized clients.
+func TestBackupAuthorization(t *testing.T) {
+	defer SetRPCToken(rpcToken)
+	SetRPCToken("")
+
+	req := httptest.NewRequest(http.MethodGet, "/backup", nil)
+	recorder := httptest.NewRecorder()
+	authorizeRPC(newRPCMux()).ServeHTTP(recorder, req)
+	if recorder.Code != http.StatusUnauthorized || recorder.Body.Len() > len("Unauthorized.\n") {
+		t.Errorf("Backup served to a remote client without token: %v\n", recorder.Code)
+	}
+}
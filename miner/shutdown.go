@@ -0,0 +1,86 @@
+package miner
+
+import (
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//The node is shut down in the following order, so the database is never closed while a block is written:
+//1. No new blocks are accepted (neither received nor mined).
+//2. The block currently being validated is finished (blockValidation is held until the process exits).
+//3. The open transactions and the last closed block are flushed to disk.
+//4. The p2p connections and the database are closed.
+//The open transactions are restored to the mempool on the next start.
+
+var (
+	shuttingDown  bool
+	shutdownMutex = &sync.Mutex{}
+)
+
+func isShuttingDown() bool {
+	shutdownMutex.Lock()
+	defer shutdownMutex.Unlock()
+
+	return shuttingDown
+}
+
+//Shuts the node down, the function returns once the state is on disk and the process can exit. Calling it again
+//has no effect.
+func Shutdown() {
+	shutdownMutex.Lock()
+	if shuttingDown {
+		shutdownMutex.Unlock()
+		return
+	}
+	shuttingDown = true
+	shutdownMutex.Unlock()
+
+	logger.Printf("Shutting down, waiting for the block validation in progress.\n")
+
+	//Never released, blocks arriving after this point are not validated anymore and no further block is signed.
+	blockValidation.Lock()
+	signLockMutex.Lock()
+
+	if mempool != nil {
+		openTxs := append(mempool.All(), mempool.AllParked()...)
+		if err := storage.WriteOpenTxs(openTxs); err != nil {
+			logger.Errorf("Could not persist the open transactions: %v", err)
+		} else {
+			logger.Printf("Persisted %v open transactions.\n", len(openTxs))
+		}
+	}
+
+	if lastBlock != nil {
+		storage.DeleteAllLastClosedBlock()
+		storage.WriteLastClosedBlock(lastBlock)
+	}
+
+	p2p.Shutdown()
+	storage.TearDown()
+
+	logger.Printf("Shutdown complete.\n")
+}
+
+//Adds the open transactions persisted on the last shutdown to the mempool. Transactions which have been included in a
+//block in the meantime are dropped.
+func restoreOpenTxs() {
+	var restored int
+	for _, tx := range storage.ReadAllPersistedOpenTxs() {
+		if storage.IsClosedTx(tx.Hash()) {
+			continue
+		}
+
+		if err := storage.WriteOpenTxOrdered(tx); err != nil {
+			logger.Debugf("Persisted tx (%x) not restored: %v", tx.Hash(), err)
+			continue
+		}
+		restored++
+	}
+	storage.DeleteAllPersistedOpenTxs()
+
+	if restored > 0 {
+		logger.Printf("Restored %v open transactions.\n", restored)
+	}
+}
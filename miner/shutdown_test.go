@@ -0,0 +1,35 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestRestoreOpenTxs(t *testing.T) {
+	defer mempool.Clear()
+	mempool.Clear()
+
+	open := &protocol.FundsTx{Amount: 10, Fee: 1, TxCnt: 0, From: [32]byte{0x51}, To: [32]byte{0x52}}
+	closed := &protocol.FundsTx{Amount: 20, Fee: 1, TxCnt: 1, From: [32]byte{0x51}, To: [32]byte{0x52}}
+	storage.WriteClosedTx(closed)
+	defer storage.DeleteClosedTx(closed)
+
+	if err := storage.WriteOpenTxs([]protocol.Transaction{open, closed}); err != nil {
+		t.Fatalf("Could not persist open txs: %v\n", err)
+	}
+
+	restoreOpenTxs()
+
+	if storage.ReadOpenTx(open.Hash()) == nil {
+		t.Error("Persisted open tx not restored.")
+	}
+	//Included in a block in the meantime.
+	if storage.ReadOpenTx(closed.Hash()) != nil {
+		t.Error("Closed tx restored to the mempool.")
+	}
+	if len(storage.ReadAllPersistedOpenTxs()) != 0 {
+		t.Error("Persisted open txs not deleted after restoring them.")
+	}
+}
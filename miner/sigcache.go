@@ -0,0 +1,93 @@
+package miner
+
+import (
+	"container/list"
+	"expvar"
+	"golang.org/x/crypto/ed25519"
+	"sync"
+)
+
+//A tx is verified when it is admitted to the mempool and again when the block including it is validated, by this node
+//and by every block it receives. The signatures found valid are kept in an LRU cache, so each one is checked once.
+//An entry is keyed by the tx hash and the public key (multi-signature and config txs carry several signatures over the
+//same hash) and holds the signature found valid. A tx whose content changed has another hash, and a different signature
+//for the same hash is verified again, so a cache hit is as good as a successful verification. Invalid signatures are
+//not cached, a peer could otherwise evict the valid ones by sending garbage.
+
+const SIG_CACHE_SIZE = 16384
+
+type sigCacheKey struct {
+	txHash [32]byte
+	pubKey [32]byte
+}
+
+type sigCacheEntry struct {
+	key sigCacheKey
+	sig [64]byte
+}
+
+type sigCache struct {
+	entries map[sigCacheKey]*list.Element
+	//Most recently used first.
+	order *list.List
+	size  int
+	mutex sync.Mutex
+}
+
+var (
+	verifiedSigs = newSigCache(SIG_CACHE_SIZE)
+	//Hits and misses are published under /debug/vars, see metrics.go.
+	sigCacheStats = expvar.NewMap("signature_cache")
+)
+
+func newSigCache(size int) *sigCache {
+	return &sigCache{entries: make(map[sigCacheKey]*list.Element), order: list.New(), size: size}
+}
+
+//Verifies an ed25519 signature over a tx hash, looking it up in the cache first.
+func verifySignature(pubKey [32]byte, txHash [32]byte, sig [64]byte) bool {
+	key := sigCacheKey{txHash, pubKey}
+	if verifiedSigs.contains(key, sig) {
+		sigCacheStats.Add("hits", 1)
+		return true
+	}
+
+	sigCacheStats.Add("misses", 1)
+	if !ed25519.Verify(pubKey[:], txHash[:], sig[:]) {
+		return false
+	}
+
+	verifiedSigs.add(key, sig)
+	return true
+}
+
+func (cache *sigCache) contains(key sigCacheKey, sig [64]byte) bool {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	element, exists := cache.entries[key]
+	if !exists || element.Value.(*sigCacheEntry).sig != sig {
+		return false
+	}
+	cache.order.MoveToFront(element)
+
+	return true
+}
+
+func (cache *sigCache) add(key sigCacheKey, sig [64]byte) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, exists := cache.entries[key]; exists {
+		element.Value = &sigCacheEntry{key, sig}
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[key] = cache.order.PushFront(&sigCacheEntry{key, sig})
+	if cache.order.Len() > cache.size {
+		oldest := cache.order.Back()
+		cache.order.Remove(oldest)
+		delete(cache.entries, oldest.Value.(*sigCacheEntry).key)
+	}
+}
@@ -0,0 +1,57 @@
+package miner
+
+import (
+	"crypto/rand"
+	"golang.org/x/crypto/ed25519"
+	"testing"
+)
+
+func TestVerifySignatureCache(t *testing.T) {
+	savedSigs := verifiedSigs
+	defer func() { verifiedSigs = savedSigs }()
+	verifiedSigs = newSigCache(2)
+
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+	var pubKey [32]byte
+	copy(pubKey[:], pub)
+
+	txHash := [32]byte{1}
+	var sig [64]byte
+	copy(sig[:], ed25519.Sign(priv, txHash[:]))
+
+	if !verifySignature(pubKey, txHash, sig) {
+		t.Fatal("Valid signature rejected.")
+	}
+	if !verifiedSigs.contains(sigCacheKey{txHash, pubKey}, sig) {
+		t.Error("Valid signature not cached.")
+	}
+	if !verifySignature(pubKey, txHash, sig) {
+		t.Error("Cached signature rejected.")
+	}
+
+	//A different signature for the same hash is verified, not taken from the cache.
+	forged := sig
+	forged[0] ^= 0xff
+	if verifySignature(pubKey, txHash, forged) {
+		t.Error("Forged signature accepted.")
+	}
+
+	//Changing the content changes the hash.
+	if verifySignature(pubKey, [32]byte{2}, sig) {
+		t.Error("Signature accepted for another tx.")
+	}
+	if verifiedSigs.order.Len() != 1 {
+		t.Errorf("Invalid signatures cached: %v entries instead of 1.", verifiedSigs.order.Len())
+	}
+
+	//The least recently used entry is evicted.
+	for i := byte(2); i <= 3; i++ {
+		otherHash := [32]byte{i}
+		var otherSig [64]byte
+		copy(otherSig[:], ed25519.Sign(priv, otherHash[:]))
+		verifySignature(pubKey, otherHash, otherSig)
+	}
+	if verifiedSigs.contains(sigCacheKey{txHash, pubKey}, sig) || verifiedSigs.order.Len() != 2 {
+		t.Error("Least recently used signature not evicted.")
+	}
+}
@@ -0,0 +1,114 @@
+package miner
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//The sign lock protects the validator from slashing itself. It records the highest height (and the corresponding
+//block hash) we have produced a block for. The file survives crashes and is not part of the database, so even after
+//restoring an old database from a backup, we never produce a competing block at a height we already produced one for.
+//The file consists of two lines, the height and the hex encoded block hash.
+
+var (
+	signLockFile   string
+	signLockHeight uint32
+	signLockHash   [32]byte
+	signLockMutex  = &sync.Mutex{}
+)
+
+//Loads the sign lock from the given file. The file is created once the first block is produced.
+func InitSignLock(filename string) error {
+	signLockMutex.Lock()
+	defer signLockMutex.Unlock()
+
+	signLockFile = filename
+	signLockHeight = 0
+	signLockHash = [32]byte{}
+
+	if _, err := os.Stat(filename); os.IsNotExist(err) {
+		return nil
+	}
+
+	filehandle, err := os.Open(filename)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Could not open sign lock: %v", err))
+	}
+	defer filehandle.Close()
+
+	reader := bufio.NewReader(filehandle)
+
+	heightLine, err := reader.ReadString('\n')
+	if err != nil {
+		return errors.New(fmt.Sprintf("Could not read height from sign lock: %v", err))
+	}
+	height, err := strconv.ParseUint(strings.TrimSpace(heightLine), 10, 32)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Invalid height in sign lock: %v", err))
+	}
+
+	hashLine, err := reader.ReadString('\n')
+	if err != nil {
+		return errors.New(fmt.Sprintf("Could not read hash from sign lock: %v", err))
+	}
+	hash, err := hex.DecodeString(strings.TrimSpace(hashLine))
+	if err != nil || len(hash) != 32 {
+		return errors.New(fmt.Sprintf("Invalid hash in sign lock: %v", strings.TrimSpace(hashLine)))
+	}
+
+	signLockHeight = uint32(height)
+	copy(signLockHash[:], hash)
+
+	return nil
+}
+
+//Checks whether we are allowed to produce the block and records it in the sign lock if so. Producing the same block
+//twice is fine, producing a different block at the same or a lower height is not.
+func acquireSignLock(block *protocol.Block) error {
+	signLockMutex.Lock()
+	defer signLockMutex.Unlock()
+
+	//Sign lock is disabled.
+	if signLockFile == "" {
+		return nil
+	}
+
+	if signLockHeight != 0 && (block.Height < signLockHeight || (block.Height == signLockHeight && block.Hash != signLockHash)) {
+		logger.Printf("AUDIT: Refused to produce block (%x) at height %v, already produced block (%x) at height %v.\n", block.Hash[0:8], block.Height, signLockHash[0:8], signLockHeight)
+		return errors.New(fmt.Sprintf("Sign lock prevents producing a block at height %v (highest produced block at height %v).", block.Height, signLockHeight))
+	}
+
+	if err := writeSignLock(block.Height, block.Hash); err != nil {
+		return err
+	}
+
+	signLockHeight = block.Height
+	signLockHash = block.Hash
+	logger.Printf("AUDIT: Produced block (%x) at height %v, sign lock updated.\n", block.Hash[0:8], block.Height)
+
+	return nil
+}
+
+//The lock is written to a temporary file first and renamed afterwards, a crash can't leave a corrupted lock behind.
+func writeSignLock(height uint32, hash [32]byte) error {
+	content := fmt.Sprintf("%v\n%x\n", height, hash)
+	tmpFile := signLockFile + ".tmp"
+
+	if err := ioutil.WriteFile(tmpFile, []byte(content), 0600); err != nil {
+		return errors.New(fmt.Sprintf("Could not write sign lock: %v", err))
+	}
+
+	if err := os.Rename(tmpFile, signLockFile); err != nil {
+		return errors.New(fmt.Sprintf("Could not write sign lock: %v", err))
+	}
+
+	return nil
+}
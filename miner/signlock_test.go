@@ -0,0 +1,54 @@
+package miner
+
+import (
+	"os"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestSignLock(t *testing.T) {
+	filename := "test_signlock.txt"
+	os.Remove(filename)
+	defer os.Remove(filename)
+
+	if err := InitSignLock(filename); err != nil {
+		t.Fatalf("Could not initialize sign lock: %v\n", err)
+	}
+	defer InitSignLock("")
+
+	block := new(protocol.Block)
+	block.Height = 10
+	block.Hash = protocol.SerializeHashContent("block")
+
+	if err := acquireSignLock(block); err != nil {
+		t.Fatalf("Sign lock refused the first block: %v\n", err)
+	}
+
+	//Producing the same block again is allowed.
+	if err := acquireSignLock(block); err != nil {
+		t.Errorf("Sign lock refused the same block twice: %v\n", err)
+	}
+
+	//Simulate a restart, the lock has to be restored from the file.
+	if err := InitSignLock(filename); err != nil {
+		t.Fatalf("Could not reload sign lock: %v\n", err)
+	}
+
+	competingBlock := new(protocol.Block)
+	competingBlock.Height = 10
+	competingBlock.Hash = protocol.SerializeHashContent("competing block")
+	if err := acquireSignLock(competingBlock); err == nil {
+		t.Error("Sign lock accepted a competing block at the same height.\n")
+	}
+
+	competingBlock.Height = 9
+	if err := acquireSignLock(competingBlock); err == nil {
+		t.Error("Sign lock accepted a block at a lower height.\n")
+	}
+
+	competingBlock.Height = 11
+	if err := acquireSignLock(competingBlock); err != nil {
+		t.Errorf("Sign lock refused a block at a higher height: %v\n", err)
+	}
+}
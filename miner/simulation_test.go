@@ -0,0 +1,191 @@
+package miner
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/simulation"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//The miner of this process runs as a node of a simulated network (see package simulation) and receives the blocks
+//through the same path as from a peer. The miner keeps its state in package variables, so the other nodes are
+//producers: they build their blocks on top of their own tip with the block production of this miner (signed with the
+//validator key of the tests). Producing a block does not change the state, only the miner validates it once the
+//block arrives. The state root of a block is computed on the state of the miner, so producers build on the height the
+//miner is at: the blocks are produced in rounds, each followed by the delivery of the blocks.
+
+const SIM_MINER = "miner"
+
+type simProducer struct {
+	t        *testing.T
+	address  string
+	network  *simulation.Network
+	tip      *protocol.Block
+	produced []*protocol.Block
+}
+
+func (producer *simProducer) produce() *protocol.Block {
+	prevLastBlock := lastBlock
+	lastBlock = producer.tip
+	defer func() { lastBlock = prevLastBlock }()
+
+	block := newBlock(producer.tip.Hash, producer.tip.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, producer.tip.Height+1)
+	if err := finalizeBlock(block); err != nil {
+		producer.t.Fatalf("%v could not finalize block at height %v: %v\n", producer.address, block.Height, err)
+	}
+
+	producer.tip = block
+	producer.produced = append(producer.produced, block)
+	producer.broadcast(block)
+
+	return block
+}
+
+//Sends the blocks produced so far from the tip down, one after another. This is the order in which a node catches up
+//on a branch it missed: the parents of an orphan block are fetched one by one, see orphanpool.go.
+func (producer *simProducer) replay(clock *simulation.Clock) {
+	for i := len(producer.produced) - 1; i >= 0; i-- {
+		producer.broadcast(producer.produced[i])
+		clock.Advance(time.Second)
+	}
+}
+
+func (producer *simProducer) broadcast(block *protocol.Block) {
+	if err := producer.network.Broadcast(producer.address, p2p.BuildPacket(p2p.BLOCK_BRDCST, block.Encode())); err != nil {
+		producer.t.Errorf("Block broadcast of %v failed: %v\n", producer.address, err)
+	}
+}
+
+//Producers following the blocks of each other, the one with the higher tip wins.
+func (producer *simProducer) handle(from string, header *p2p.Header, payload []byte) {
+	if header.TypeID != p2p.BLOCK_BRDCST {
+		return
+	}
+
+	block, err := new(protocol.Block).Decode(payload)
+	if err != nil {
+		producer.t.Errorf("Block could not be decoded: %v\n", err)
+		return
+	}
+	if block.Height > producer.tip.Height {
+		producer.tip = block
+	}
+}
+
+//Every producer given builds one block, which are delivered afterwards.
+func simRound(clock *simulation.Clock, producers ...*simProducer) {
+	for _, producer := range producers {
+		producer.produce()
+	}
+	clock.Advance(time.Second)
+}
+
+func newSimulation(t *testing.T, seed int64, producers int) (*simulation.Clock, *simulation.Network, []*simProducer) {
+	cleanAndPrepare()
+
+	clock := simulation.NewClock(time.Unix(0, 0))
+	network := simulation.NewNetwork(clock, seed, 10*time.Millisecond, 200*time.Millisecond)
+	network.Join(SIM_MINER, func(from string, header *p2p.Header, payload []byte) {
+		if header.TypeID == p2p.BLOCK_BRDCST {
+			processBlock(payload)
+		}
+	})
+
+	var nodes []*simProducer
+	for i := 0; i < producers; i++ {
+		producer := &simProducer{t: t, address: fmt.Sprintf("producer%v", i), network: network, tip: genesisBlock}
+		network.Join(producer.address, producer.handle)
+		nodes = append(nodes, producer)
+	}
+
+	return clock, network, nodes
+}
+
+//A producer partitioned off builds a longer chain. Once the partition heals and its blocks arrive, the miner rolls
+//back the blocks it validated in the meantime and switches to the longer chain.
+func TestSimulationForkRollback(t *testing.T) {
+	clock, network, producers := newSimulation(t, 1, 2)
+	rollbacks := blockRollbacks.Value()
+
+	network.Partition([]string{producers[1].address})
+	simRound(clock, producers[0], producers[1])
+	simRound(clock, producers[0], producers[1])
+	simRound(clock, producers[1])
+
+	if lastBlock.Hash != producers[0].tip.Hash || lastBlock.Height != 2 {
+		t.Fatalf("Miner is at %x (height %v) instead of the tip of the connected producer.\n", lastBlock.Hash[0:8], lastBlock.Height)
+	}
+
+	network.Heal()
+	producers[1].replay(clock)
+
+	if lastBlock.Hash != producers[1].tip.Hash || lastBlock.Height != 3 {
+		t.Errorf("Miner is at %x (height %v) instead of the longer chain.\n", lastBlock.Hash[0:8], lastBlock.Height)
+	}
+	if rolledBack := blockRollbacks.Value() - rollbacks; rolledBack != 2 {
+		t.Errorf("%v blocks rolled back instead of 2.\n", rolledBack)
+	}
+	for _, block := range producers[0].produced {
+		if closed := storage.ReadClosedBlock(block.Hash); closed != nil {
+			t.Errorf("Block %x of the abandoned chain is still closed.\n", block.Hash[0:8])
+		}
+	}
+
+	//The producers agree on the tip again.
+	if producers[0].tip.Hash != producers[1].tip.Hash {
+		t.Errorf("Producers did not converge: %x and %x.\n", producers[0].tip.Hash[0:8], producers[1].tip.Hash[0:8])
+	}
+}
+
+//Both producers sign with the same validator key, so two conflicting blocks at the same height are an equivocation.
+//The miner detects it while switching chains and the next block includes the slashing proof, which removes the
+//validator from the validator set.
+func TestSimulationSlashing(t *testing.T) {
+	clock, network, producers := newSimulation(t, 2, 2)
+
+	network.Partition([]string{producers[1].address})
+	simRound(clock, producers[0], producers[1])
+	simRound(clock, producers[1])
+
+	network.Heal()
+	producers[1].replay(clock)
+
+	if lastBlock.Hash != producers[1].tip.Hash {
+		t.Fatalf("Miner is at %x (height %v) instead of the longer chain.\n", lastBlock.Hash[0:8], lastBlock.Height)
+	}
+
+	validatorHash := protocol.SerializeHashContent(validatorAccAddress)
+	proof := slashingDict[validatorHash]
+	if proof == nil {
+		t.Fatalf("Equivocation of the validator was not detected.\n")
+	}
+	if proof.Height != 1 {
+		t.Errorf("Slashing proof at height %v instead of 1.\n", proof.Height)
+	}
+
+	simRound(clock, producers[0])
+	slashingBlock := producers[0].produced[len(producers[0].produced)-1]
+
+	if slashingBlock.SlashedAddress != validatorHash {
+		t.Fatalf("Slashing proof not included in the next block.\n")
+	}
+	if lastBlock.Hash != slashingBlock.Hash {
+		t.Fatalf("Block with the slashing proof was not validated, miner is at height %v.\n", lastBlock.Height)
+	}
+	if _, pending := slashingDict[validatorHash]; pending {
+		t.Errorf("Included slashing proof is still pending.\n")
+	}
+
+	validator, err := storage.GetAccount(validatorHash)
+	if err != nil {
+		t.Fatalf("Validator account not found: %v\n", err)
+	}
+	if validator.IsStaking {
+		t.Errorf("Slashed validator is still staking.\n")
+	}
+}
@@ -1,16 +1,84 @@
 package miner
 
 import (
+	"bytes"
 	"errors"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 	"github.com/bazo-blockchain/bazo-miner/storage"
 )
 
-type SlashingProof struct {
-	ConflictingBlockHash1 [32]byte
-	ConflictingBlockHash2 [32]byte
-	ConflictingBlockHashWithoutTx1 [32]byte
-	ConflictingBlockHashWithoutTx2 [32]byte
+//Slashing proofs found by the validation of a block or received from other miners are kept in the slashingDict (and
+//persisted) until a block includes them. New proofs are gossiped to the other miners, so the next validator includes
+//them even if it has not seen both conflicting blocks itself. The slashingDict is guarded by blockValidation.
+
+//Loads the proofs pending on the last shutdown.
+func loadSlashingProofs() {
+	for _, proof := range storage.ReadAllSlashingProofs() {
+		slashingDict[proof.SlashedAddress] = proof
+	}
+}
+
+//Adds a proof unless one is already pending for the address, the new proof is persisted and gossiped. Returns whether
+//the proof was added.
+func addSlashingProof(proof *protocol.SlashingProof) bool {
+	if _, exists := slashingDict[proof.SlashedAddress]; exists {
+		return false
+	}
+
+	slashingDict[proof.SlashedAddress] = proof
+	if err := storage.WriteSlashingProof(proof); err != nil {
+		logger.Printf("Slashing proof for (%x) could not be written: %v", proof.SlashedAddress[0:8], err)
+	}
+	go p2p.BroadcastSlashingProof(proof.Encode())
+
+	return true
+}
+
+//Returns the proof to include in the next block: the oldest one, the lowest address if several are equally old, so
+//the choice does not depend on the map's iteration order.
+func nextSlashingProof() *protocol.SlashingProof {
+	var next *protocol.SlashingProof
+	for _, proof := range slashingDict {
+		if next == nil || proof.Height < next.Height ||
+			(proof.Height == next.Height && bytes.Compare(proof.SlashedAddress[:], next.SlashedAddress[:]) < 0) {
+			next = proof
+		}
+	}
+
+	return next
+}
+
+//Verifies the slashing proofs gossiped by other miners and adds the valid ones.
+func incomingSlashingProofs() {
+	for payload := range p2p.SlashingProofIn {
+		var proof *protocol.SlashingProof
+		proof, err := proof.Decode(payload)
+		if err != nil {
+			logger.Warnf("Received slashing proof could not be decoded: %v", err)
+			continue
+		}
+
+		blockValidation.Lock()
+		_, known := slashingDict[proof.SlashedAddress]
+		window := activeParameters.Slashing_window_size
+		blockValidation.Unlock()
+		if known {
+			continue
+		}
+
+		//The conflicting blocks might have to be fetched, which is done without holding blockValidation.
+		height, err := slashingCheck(proof.SlashedAddress, proof.ConflictingBlockHash1, proof.ConflictingBlockHash2, proof.ConflictingBlockHashWithoutTx1, proof.ConflictingBlockHashWithoutTx2, window)
+		if err != nil {
+			logger.Printf("Received slashing proof for (%x) rejected: %v", proof.SlashedAddress[0:8], err)
+			continue
+		}
+		proof.Height = height
+
+		blockValidation.Lock()
+		addSlashingProof(proof)
+		blockValidation.Unlock()
+	}
 }
 
 //Find a proof where a validator votes on two different chains within the slashing window
@@ -31,14 +99,20 @@ func seekSlashingProof(block *protocol.Block) error {
 		if prevBlocks == nil {
 			return nil
 		}
+		slashingWindowSize := parametersAt(block.Height).Slashing_window_size
 		for _, prevBlock := range prevBlocks {
+			//Blocks of the same chain do not conflict, the other blocks may still do.
 			if IsInSameChain(prevBlock, block) {
-				return nil
+				continue
 			}
 			if prevBlock.Beneficiary == block.Beneficiary &&
-				(uint64(prevBlock.Height) < uint64(block.Height)+activeParameters.Slashing_window_size ||
-					uint64(block.Height) < uint64(prevBlock.Height)+activeParameters.Slashing_window_size) {
-				slashingDict[block.Beneficiary] = SlashingProof{ConflictingBlockHash1: block.Hash, ConflictingBlockHash2: prevBlock.Hash, ConflictingBlockHashWithoutTx1: block.HashWithoutTx, ConflictingBlockHashWithoutTx2: block.PrevHashWithoutTx}
+				(uint64(prevBlock.Height) < uint64(block.Height)+slashingWindowSize ||
+					uint64(block.Height) < uint64(prevBlock.Height)+slashingWindowSize) {
+				height := block.Height
+				if prevBlock.Height < height {
+					height = prevBlock.Height
+				}
+				addSlashingProof(&protocol.SlashingProof{SlashedAddress: block.Beneficiary, ConflictingBlockHash1: block.Hash, ConflictingBlockHash2: prevBlock.Hash, ConflictingBlockHashWithoutTx1: block.HashWithoutTx, ConflictingBlockHashWithoutTx2: prevBlock.HashWithoutTx, Height: height})
 			}
 		}
 	}
@@ -74,3 +148,29 @@ func IsInSameChain(b1, b2 *protocol.Block) bool {
 
 	return false
 }
+
+//Blocks of the abandoned chain are no longer closed once rolled back, so seekSlashingProof does not see them while the
+//new chain is validated. A validator that signed blocks on both chains within the slashing window is caught here, the
+//abandoned block is kept in the open block storage so the proof can still be verified.
+func seekRollbackSlashingProofs(rolledBack []*protocol.Block, validated []*protocol.Block) {
+	for _, block := range validated {
+		slashingWindowSize := parametersAt(block.Height).Slashing_window_size
+		for _, prevBlock := range rolledBack {
+			if prevBlock.Beneficiary != block.Beneficiary ||
+				uint64(prevBlock.Height) >= uint64(block.Height)+slashingWindowSize ||
+				uint64(block.Height) >= uint64(prevBlock.Height)+slashingWindowSize {
+				continue
+			}
+
+			height := block.Height
+			if prevBlock.Height < height {
+				height = prevBlock.Height
+			}
+			if addSlashingProof(&protocol.SlashingProof{SlashedAddress: block.Beneficiary, ConflictingBlockHash1: block.Hash, ConflictingBlockHash2: prevBlock.Hash, ConflictingBlockHashWithoutTx1: block.HashWithoutTx, ConflictingBlockHashWithoutTx2: prevBlock.HashWithoutTx, Height: height}) {
+				if err := storage.WriteOpenBlock(prevBlock); err != nil {
+					logger.Printf("Conflicting block (%x) could not be written: %v", prevBlock.Hash[0:8], err)
+				}
+			}
+		}
+	}
+}
@@ -14,7 +14,7 @@ func TestSlashingCondition(t *testing.T) {
 	myAcc, _ := storage.GetAccount(protocol.SerializeHashContent(validatorAccAddress))
 	initBalance := myAcc.Balance
 
-	forkBlock := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	forkBlock := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	if err := finalizeBlock(forkBlock); err != nil {
 		t.Errorf("Block finalization for b1 (%v) failed: %v\n", forkBlock, err)
 	}
@@ -23,7 +23,7 @@ func TestSlashingCondition(t *testing.T) {
 	}
 
 	// genesis <- forkBlock <- b
-	b := newBlock(forkBlock.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b := newBlock(forkBlock.Hash, forkBlock.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	if err := finalizeBlock(b); err != nil {
 		t.Errorf("Block finalization for b1 (%v) failed: %v\n", b, err)
 	}
@@ -35,7 +35,7 @@ func TestSlashingCondition(t *testing.T) {
 	lastBlock = forkBlock
 
 	// genesis <- forkBlock <- b2
-	b2 := newBlock(forkBlock.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	b2 := newBlock(forkBlock.Hash, forkBlock.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 2)
 	if err := finalizeBlock(b2); err != nil {
 		t.Errorf("Block finalization for b2 (%v) failed: %v\n", b2, err)
 	}
@@ -45,22 +45,22 @@ func TestSlashingCondition(t *testing.T) {
 		t.Errorf("Block validation for b2 (%v) failed: %v\n", b2, err)
 	}
 
-	slashingDict2 := make(map[[32]byte]SlashingProof)
-	slashingDict2[b.Beneficiary] = SlashingProof{b2.Hash, b.Hash}
+	slashingDict2 := make(map[[32]byte]*protocol.SlashingProof)
+	slashingDict2[b.Beneficiary] = &protocol.SlashingProof{SlashedAddress: b.Beneficiary, ConflictingBlockHash1: b2.Hash, ConflictingBlockHash2: b.Hash, ConflictingBlockHashWithoutTx1: b2.HashWithoutTx, ConflictingBlockHashWithoutTx2: b.HashWithoutTx, Height: 2}
 
 	if !reflect.DeepEqual(slashingDict, slashingDict2) {
 		t.Error("Slashing dictionary was not built correctly.", slashingDict, slashingDict2)
 	}
 
 	//third block contains the slashing proof
-	b3 := newBlock(b2.Hash, [crypto.COMM_KEY_LENGTH]byte{}, 3)
+	b3 := newBlock(b2.Hash, b2.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 3)
 	if err := finalizeBlock(b3); err != nil {
 		t.Errorf("Block finalization for b3 (%v) failed: %v\n", b3, err)
 	}
 
 	//Check whether the right proof was included in b3
-	slashingDict3 := make(map[[32]byte]SlashingProof)
-	slashingDict3[b3.Beneficiary] = SlashingProof{b3.ConflictingBlockHash1, b3.ConflictingBlockHash2}
+	slashingDict3 := make(map[[32]byte]*protocol.SlashingProof)
+	slashingDict3[b3.Beneficiary] = &protocol.SlashingProof{SlashedAddress: b3.Beneficiary, ConflictingBlockHash1: b3.ConflictingBlockHash1, ConflictingBlockHash2: b3.ConflictingBlockHash2, ConflictingBlockHashWithoutTx1: b3.ConflictingBlockHashWithoutTx1, ConflictingBlockHashWithoutTx2: b3.ConflictingBlockHashWithoutTx2, Height: 2}
 
 	if !reflect.DeepEqual(slashingDict, slashingDict3) {
 		t.Error("Slashing proof was not correctly included in b3.", slashingDict, slashingDict3)
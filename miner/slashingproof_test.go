@@ -0,0 +1,88 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestNextSlashingProof(t *testing.T) {
+	defer func() {
+		slashingDict = make(map[[32]byte]*protocol.SlashingProof)
+	}()
+
+	slashingDict = make(map[[32]byte]*protocol.SlashingProof)
+	if nextSlashingProof() != nil {
+		t.Fatal("Proof returned without pending proofs.")
+	}
+
+	slashingDict[[32]byte{0x01}] = &protocol.SlashingProof{SlashedAddress: [32]byte{0x01}, Height: 9}
+	slashingDict[[32]byte{0x03}] = &protocol.SlashingProof{SlashedAddress: [32]byte{0x03}, Height: 4}
+	slashingDict[[32]byte{0x02}] = &protocol.SlashingProof{SlashedAddress: [32]byte{0x02}, Height: 4}
+
+	//The oldest proof is included first, the lowest address if several are equally old.
+	if proof := nextSlashingProof(); proof.SlashedAddress != [32]byte{0x02} {
+		t.Errorf("Expected the proof of %x, got %x\n", [32]byte{0x02}, proof.SlashedAddress)
+	}
+}
+
+func TestSlashedStake(t *testing.T) {
+	prevParameterSlice := parameterSlice
+	defer func() { parameterSlice = prevParameterSlice }()
+
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	parameterSlice[0].Staking_minimum = 100
+
+	//Without a slash fraction, the staking minimum is burned.
+	if stake := slashedStake(10000, 1); stake != 100 {
+		t.Errorf("Expected the staking minimum to be burned, got %v\n", stake)
+	}
+
+	parameterSlice[0].Slash_fraction = 10
+	if stake := slashedStake(10050, 1); stake != 1005 {
+		t.Errorf("Expected 10%% of the balance to be burned, got %v\n", stake)
+	}
+	if stake := slashedStake(500, 1); stake != 100 {
+		t.Errorf("Expected at least the staking minimum to be burned, got %v\n", stake)
+	}
+	if stake := slashedStake(50, 1); stake != 50 {
+		t.Errorf("Expected at most the balance to be burned, got %v\n", stake)
+	}
+}
+
+func TestCollectSlashRewardRollback(t *testing.T) {
+	prevParameterSlice := parameterSlice
+	defer func() { parameterSlice = prevParameterSlice }()
+
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	parameterSlice[0].Staking_minimum = 100
+	parameterSlice[0].Slash_fraction = 50
+
+	minerAcc := &protocol.Account{Address: [32]byte{'m', 'i', 'n', 'e', 'r'}, Balance: 1000, IsStaking: true}
+	slashedAcc := &protocol.Account{Address: [32]byte{'s', 'l', 'a', 's', 'h', 'e', 'd'}, Balance: 1001, IsStaking: true}
+	for _, acc := range []*protocol.Account{minerAcc, slashedAcc} {
+		storage.State.Set(acc.Hash(), acc)
+		defer storage.State.Delete(acc.Hash())
+	}
+
+	block := &protocol.Block{Hash: [32]byte{'s', 'l', 'a', 's', 'h'}, Height: 1, Beneficiary: minerAcc.Hash(), SlashedAddress: slashedAcc.Hash()}
+	if err := collectSlashReward(2, block); err != nil {
+		t.Fatalf("Slashing failed: %v\n", err)
+	}
+	if minerAcc.Balance != 1002 || slashedAcc.Balance != 501 || slashedAcc.IsStaking {
+		t.Errorf("Unexpected balances after slashing: %v, %v\n", minerAcc.Balance, slashedAcc.Balance)
+	}
+
+	batch := storage.NewBatch()
+	batch.WriteSlashedStake(block.Hash, slashedStakes[block.Hash])
+	batch.Commit()
+	defer storage.DeleteSlashedStake(block.Hash)
+
+	//The balance is restored even if the fraction changed since.
+	parameterSlice[0].Slash_fraction = 0
+	collectSlashRewardRollback(2, block)
+	if minerAcc.Balance != 1000 || slashedAcc.Balance != 1001 || !slashedAcc.IsStaking {
+		t.Errorf("Unexpected balances after the rollback: %v, %v\n", minerAcc.Balance, slashedAcc.Balance)
+	}
+}
@@ -0,0 +1,375 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"time"
+)
+
+//State snapshots let new nodes skip replaying the chain. Every snapshotInterval blocks the miner stores a snapshot of
+//the state after the block, which is served to other nodes (SNAPSHOT_REQ) and can be exported with the CLI. A node
+//bootstrapping with an imported (or, with fast sync, requested) snapshot only fetches and validates the blocks on
+//top of the snapshot block.
+
+const (
+	SNAPSHOT_DEFAULT_INTERVAL = 1000
+	SNAPSHOT_FETCH_TIMEOUT    = 60
+	//Number of miners which have to serve a snapshot of the same block, unless the block is checkpointed.
+	SNAPSHOT_CONFIRMATIONS    = 2
+)
+
+var (
+	snapshotInterval uint32 = SNAPSHOT_DEFAULT_INTERVAL
+	fastSync         bool
+)
+
+//An interval of 0 disables periodic snapshots.
+func SetSnapshotInterval(interval uint32) {
+	snapshotInterval = interval
+}
+
+//With fast sync enabled, a node without a snapshot requests one from the network when bootstrapping.
+func SetFastSync(enabled bool) {
+	fastSync = enabled
+}
+
+//Creates a snapshot of the current state, which needs to be the state after block. The caller needs to hold the
+//blockValidation lock.
+func createSnapshot(block *protocol.Block) *protocol.Snapshot {
+	snapshot := &protocol.Snapshot{
+		Version:          protocol.SNAPSHOT_VERSION,
+		Height:           block.Height,
+		BlockHash:        block.Hash,
+		Blocks:           [][]byte{block.Encode()},
+		Parameters:       newSnapshotParameters(parametersAt(block.Height + 1)),
+		Target:           append([]uint8{}, target...),
+		TargetTimeFirst:  currentTargetTime.first,
+		GlobalBlockCount: globalBlockCount,
+		LocalBlockCount:  localBlockCount,
+		BlockTimeEMA:     blockTimeEMA,
+	}
+
+	//The proof of stake of the next blocks includes the commitment proofs of the previous blocks, the liveness evidence
+	//of the epoch is derived from all of its blocks.
+	epochStart := validatorSetHeight(block.Height + 1)
+	prevBlock := block
+	for i := 0; (i < activeParameters.num_included_prev_proofs || prevBlock.Height > epochStart+1) && prevBlock.Height > 0; i++ {
+		closedBlock := storage.ReadClosedBlock(prevBlock.PrevHash)
+		if closedBlock == nil {
+			closedBlock = storage.ReadClosedBlockWithoutTx(prevBlock.PrevHashWithoutTx)
+		}
+		if closedBlock == nil {
+			break
+		}
+		prevBlock = closedBlock
+		snapshot.Blocks = append(snapshot.Blocks, prevBlock.Encode())
+	}
+
+	for _, acc := range storage.State.Snapshot() {
+		snapshot.Accounts = append(snapshot.Accounts, acc.Encode())
+	}
+
+	for hash := range storage.RootKeys {
+		snapshot.RootKeys = append(snapshot.RootKeys, hash)
+	}
+
+	snapshot.ValidatorSet = storage.ReadValidatorSet(epochStart).Encode()
+	for i := uint32(0); i < LIVENESS_MISSED_EPOCHS-1 && epochStart >= i*VALIDATOR_EPOCH_LENGTH; i++ {
+		if evidence := storage.ReadLivenessEvidence(epochStart - i*VALIDATOR_EPOCH_LENGTH); evidence != nil {
+			snapshot.LivenessEvidence = append(snapshot.LivenessEvidence, evidence.Encode())
+		}
+	}
+
+	//Parameter changes which are not active yet, see configStateChange.
+	for _, params := range parameterSlice {
+		if params.Height > block.Height {
+			snapshot.PendingParameters = append(snapshot.PendingParameters, newSnapshotParameters(&params))
+		}
+	}
+
+	return snapshot
+}
+
+//Called after a block has been validated.
+func storeSnapshot(block *protocol.Block) {
+	if snapshotInterval == 0 || block.Height == 0 || block.Height%snapshotInterval != 0 {
+		return
+	}
+
+	start := time.Now()
+	if err := storage.WriteSnapshot(createSnapshot(block).Encode()); err != nil {
+		logger.Printf("Could not store snapshot at height %v: %v\n", block.Height, err)
+		return
+	}
+	logger.Printf("Stored snapshot at height %v (%v).\n", block.Height, time.Since(start))
+
+	//The blocks below the snapshot are not replayed anymore, their aggregated txs are not needed.
+	pruneAggregatedTxs(block.Height)
+	pruneBlocks(block)
+}
+
+//Returns the stored snapshot. If there is none and fast sync is enabled, the snapshot is requested from the network.
+//Confirmed is true if the snapshot block has already been stored by this node or if the block has been served by at
+//least SNAPSHOT_CONFIRMATIONS of the miners asked, see applySnapshot.
+func loadSnapshot() (snapshot *protocol.Snapshot, confirmed bool) {
+	if encodedSnapshot := storage.ReadSnapshot(); encodedSnapshot != nil {
+		if snapshot, _ = snapshot.Decode(encodedSnapshot); snapshot == nil {
+			logger.Printf("Stored snapshot is invalid, ignoring it.\n")
+			return nil, false
+		}
+		//Snapshots created by this node or applied before belong to a stored block, imported ones do not.
+		return snapshot, storage.ReadClosedBlock(snapshot.BlockHash) != nil
+	}
+
+	if !fastSync {
+		return nil, false
+	}
+
+	requested, err := p2p.SnapshotReq()
+	if err != nil {
+		logger.Printf("Could not request snapshot: %v\n", err)
+		return nil, false
+	}
+
+	//The snapshot whose block most miners agree on is used, the state itself is checked against the block's state root.
+	confirmations := make(map[[32]byte]int)
+	timeout := time.After(SNAPSHOT_FETCH_TIMEOUT * time.Second)
+	for received := 0; received < requested; received++ {
+		select {
+		case encodedSnapshot := <-p2p.SnapshotReqChan:
+			var candidate *protocol.Snapshot
+			if candidate, _ = candidate.Decode(encodedSnapshot); candidate == nil {
+				logger.Printf("Received snapshot is invalid, ignoring it.\n")
+				continue
+			}
+			confirmations[candidate.BlockHash]++
+			if snapshot == nil || confirmations[candidate.BlockHash] > confirmations[snapshot.BlockHash] {
+				snapshot = candidate
+			}
+		case <-timeout:
+			logger.Printf("Snapshot request timed out.\n")
+			received = requested
+		}
+	}
+
+	if snapshot == nil {
+		return nil, false
+	}
+
+	return snapshot, confirmations[snapshot.BlockHash] >= SNAPSHOT_CONFIRMATIONS
+}
+
+//A snapshot replaces the validation of the chain up to its block, a single peer could otherwise make the node start
+//from a forged state. The block has to match a trusted checkpoint or the finality checkpoint at its height, or it has
+//to be confirmed (see loadSnapshot).
+func checkSnapshotBlock(block *protocol.Block, confirmed bool) error {
+	if hash, exists := trustedCheckpoints[block.Height]; exists {
+		//Aggregated blocks are only identified by their hash without tx.
+		if block.Hash != hash && block.HashWithoutTx != hash {
+			countRejectedBlock(REJECTED_CHECKPOINT)
+			return errors.New(fmt.Sprintf("Snapshot block (%x) conflicts with the trusted checkpoint at height %v (%x).", block.Hash[0:8], block.Height, hash[0:8]))
+		}
+		return nil
+	}
+
+	if height, hash, exists := storage.ReadLastCheckpoint(); exists && height == block.Height {
+		if block.Hash != hash {
+			countRejectedBlock(REJECTED_CHECKPOINT)
+			return errors.New(fmt.Sprintf("Snapshot block (%x) conflicts with the finality checkpoint at height %v (%x).", block.Hash[0:8], height, hash[0:8]))
+		}
+		return nil
+	}
+
+	if !confirmed {
+		return errors.New(fmt.Sprintf("Snapshot block (%x) is neither checkpointed nor confirmed by %v miners.", block.Hash[0:8], SNAPSHOT_CONFIRMATIONS))
+	}
+
+	return nil
+}
+
+//Replaces the state with the snapshot and stores its blocks. Returns the snapshot block.
+func applySnapshot(snapshot *protocol.Snapshot, confirmed bool) (*protocol.Block, error) {
+	var blocks []*protocol.Block
+	for _, encodedBlock := range snapshot.Blocks {
+		var block *protocol.Block
+		if block, _ = block.Decode(encodedBlock); block == nil {
+			return nil, errors.New("Snapshot contains an invalid block.")
+		}
+		blocks = append(blocks, block)
+	}
+
+	if len(blocks) == 0 || blocks[0].Hash != snapshot.BlockHash || blocks[0].Height != snapshot.Height {
+		return nil, errors.New(fmt.Sprintf("Snapshot does not contain its block (%x).", snapshot.BlockHash[0:8]))
+	}
+
+	if err := checkSnapshotBlock(blocks[0], confirmed); err != nil {
+		return nil, err
+	}
+
+	if len(snapshot.Target) == 0 {
+		return nil, errors.New("Snapshot does not contain the difficulty.")
+	}
+
+	state := make(map[[32]byte]*protocol.Account)
+	for _, encodedAcc := range snapshot.Accounts {
+		var acc *protocol.Account
+		if acc, _ = acc.Decode(encodedAcc); acc == nil {
+			return nil, errors.New("Snapshot contains an invalid account.")
+		}
+		state[acc.Hash()] = acc
+	}
+
+	//A forged state is detected with the state root of the block, blocks without one can not be a snapshot block.
+	if stateRoot := blocks[0].StateRoot; stateRoot == [32]byte{} {
+		return nil, errors.New(fmt.Sprintf("Snapshot block (%x) has no state root.", snapshot.BlockHash[0:8]))
+	} else if protocol.NewStateTrie(state).Root() != stateRoot {
+		return nil, errors.New(fmt.Sprintf("Snapshot state does not match the state root of its block (%x).", snapshot.BlockHash[0:8]))
+	}
+
+	rootKeys := make(map[[32]byte]*protocol.Account)
+	for _, hash := range snapshot.RootKeys {
+		if state[hash] == nil {
+			return nil, errors.New(fmt.Sprintf("Root account %x not in the snapshot.", hash[0:8]))
+		}
+		rootKeys[hash] = state[hash]
+	}
+
+	for _, block := range blocks {
+		if block.Aggregated {
+			storage.WriteClosedBlockWithoutTx(block)
+		} else {
+			storage.WriteClosedBlock(block)
+		}
+	}
+	storage.DeleteAllLastClosedBlock()
+	storage.WriteLastClosedBlock(blocks[0])
+
+	storage.State = storage.NewAccountStore(state)
+	storage.RootKeys = rootKeys
+
+	parameterSlice = []Parameters{parametersFromSnapshot(snapshot.Parameters, snapshot.Height)}
+	for _, params := range snapshot.PendingParameters {
+		parameterSlice = append(parameterSlice, parametersFromSnapshot(params, params.Height))
+	}
+	activeParameters = &parameterSlice[0]
+
+	target = append([]uint8{}, snapshot.Target...)
+	targetTimes = nil
+	currentTargetTime = &timerange{first: snapshot.TargetTimeFirst}
+	globalBlockCount = snapshot.GlobalBlockCount
+	localBlockCount = snapshot.LocalBlockCount
+	blockTimeEMA = snapshot.BlockTimeEMA
+	emaStates = nil
+	lastBlock = blocks[0]
+
+	//Snapshots created before the validator sets were added have none, the set is then determined by the snapshot's
+	//state, which is only the right one if the snapshot block ends an epoch.
+	var set *protocol.ValidatorSet
+	if set, _ = set.Decode(snapshot.ValidatorSet); set == nil || set.Height != validatorSetHeight(snapshot.Height+1) {
+		logger.Printf("Snapshot contains no validator set, using the state at height %v.\n", snapshot.Height)
+		set = computeValidatorSet(snapshot.Height, nil)
+		set.Height = validatorSetHeight(snapshot.Height + 1)
+	}
+	if err := storage.WriteValidatorSet(set); err != nil {
+		return nil, err
+	}
+
+	for _, encodedEvidence := range snapshot.LivenessEvidence {
+		var evidence *protocol.LivenessEvidence
+		if evidence, _ = evidence.Decode(encodedEvidence); evidence == nil {
+			return nil, errors.New("Snapshot contains invalid liveness evidence.")
+		}
+		if err := storage.WriteLivenessEvidence(evidence); err != nil {
+			return nil, err
+		}
+	}
+
+	logger.Printf("Applied snapshot: %v", snapshot)
+
+	return blocks[0], nil
+}
+
+func newSnapshotParameters(params *Parameters) protocol.SnapshotParameters {
+	return protocol.SnapshotParameters{
+		BlockHash:             params.BlockHash,
+		FeeMinimum:            params.Fee_minimum,
+		BlockSize:             params.Block_size,
+		DiffInterval:          params.Diff_interval,
+		BlockInterval:         params.Block_interval,
+		BlockReward:           params.Block_reward,
+		StakingMinimum:        params.Staking_minimum,
+		WaitingMinimum:        params.Waiting_minimum,
+		AcceptedTimeDiff:      params.Accepted_time_diff,
+		SlashingWindowSize:    params.Slashing_window_size,
+		SlashReward:           params.Slash_reward,
+		NumIncludedPrevProofs: params.num_included_prev_proofs,
+		BlockTriggerTxs:       params.Block_trigger_txs,
+		BlockTriggerTime:      params.Block_trigger_time,
+		IotRateCapacity:       params.Iot_rate_capacity,
+		IotRateRefill:         params.Iot_rate_refill,
+		UnbondingPeriod:       params.Unbonding_period,
+		RsaCommitmentDeadline: params.Rsa_commitment_deadline,
+		SlashFraction:         params.Slash_fraction,
+		ConfigQuorum:          params.Config_quorum,
+		ConfigActivationDelay: params.Config_activation_delay,
+		DiffEmaWindow:         params.Diff_ema_window,
+		StateRootHeight:       params.State_root_height,
+		TxOrderHeight:         params.Tx_order_height,
+		AggTxAuthHeight:       params.Agg_tx_auth_height,
+		MedianTimeHeight:      params.Median_time_height,
+		BlockHashHeight:       params.Block_hash_height,
+		DelegationHeight:      params.Delegation_height,
+		Height:                params.Height,
+	}
+}
+
+//Returns the parameters of a snapshot, which apply to the blocks above height.
+func parametersFromSnapshot(params protocol.SnapshotParameters, height uint32) Parameters {
+	//Snapshots created before the IoT rate limit was added have no refill interval.
+	if params.IotRateRefill == 0 {
+		params.IotRateRefill = IOT_RATE_REFILL
+	}
+	//Nor an unbonding period.
+	if params.UnbondingPeriod == 0 {
+		params.UnbondingPeriod = UNBONDING_PERIOD
+	}
+	//Nor a config quorum.
+	if params.ConfigQuorum == 0 {
+		params.ConfigQuorum = CONFIG_QUORUM
+	}
+
+	return Parameters{
+		params.BlockHash,
+		height,
+		params.FeeMinimum,
+		params.BlockSize,
+		params.DiffInterval,
+		params.BlockInterval,
+		params.BlockReward,
+		params.StakingMinimum,
+		params.WaitingMinimum,
+		params.AcceptedTimeDiff,
+		params.SlashingWindowSize,
+		params.SlashReward,
+		params.BlockTriggerTxs,
+		params.BlockTriggerTime,
+		params.IotRateCapacity,
+		params.IotRateRefill,
+		params.UnbondingPeriod,
+		params.RsaCommitmentDeadline,
+		params.SlashFraction,
+		params.ConfigQuorum,
+		params.ConfigActivationDelay,
+		params.DiffEmaWindow,
+		params.StateRootHeight,
+		params.TxOrderHeight,
+		params.AggTxAuthHeight,
+		params.MedianTimeHeight,
+		params.BlockHashHeight,
+		params.DelegationHeight,
+		params.NumIncludedPrevProofs,
+	}
+}
@@ -0,0 +1,133 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotCreateApply(t *testing.T) {
+	prevState, prevRootKeys := storage.State, storage.RootKeys
+	prevParameters, prevTarget, prevLastBlock := parameterSlice, target, lastBlock
+	defer func() {
+		storage.State, storage.RootKeys = prevState, prevRootKeys
+		parameterSlice, target, lastBlock = prevParameters, prevTarget, prevLastBlock
+		activeParameters = &parameterSlice[0]
+		currentTargetTime = new(timerange)
+		storage.DeleteAllLastClosedBlock()
+	}()
+
+	//The previous hash is not the one of the genesis block of the tests (the null hash), the snapshot ends at prevBlock.
+	prevBlock := &protocol.Block{Hash: [32]byte{0x01}, PrevHash: [32]byte{0x7f}, PrevHashWithoutTx: [32]byte{0x7f}, Height: 9}
+	storage.WriteClosedBlock(prevBlock)
+	defer storage.DeleteClosedBlock(prevBlock.Hash)
+
+	rootAcc := &protocol.Account{Address: [32]byte{0x0a}, Balance: 1000}
+	acc := &protocol.Account{Address: [32]byte{0x0b}, Balance: 50, TxCnt: 2}
+	stateRoot := protocol.NewStateTrie(map[[32]byte]*protocol.Account{rootAcc.Hash(): rootAcc, acc.Hash(): acc}).Root()
+	block := &protocol.Block{Hash: [32]byte{0x02}, PrevHash: prevBlock.Hash, Height: 10, StateRoot: stateRoot}
+	storage.State = storage.NewAccountStore(map[[32]byte]*protocol.Account{rootAcc.Hash(): rootAcc, acc.Hash(): acc})
+	storage.RootKeys = map[[32]byte]*protocol.Account{rootAcc.Hash(): rootAcc}
+
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	activeParameters = &parameterSlice[0]
+	activeParameters.Fee_minimum = 7
+	target = []uint8{8, 10}
+	currentTargetTime = &timerange{first: 1234}
+
+	snapshot := createSnapshot(block)
+	if len(snapshot.Blocks) != 2 {
+		t.Errorf("Snapshot contains %v blocks instead of 2.\n", len(snapshot.Blocks))
+	}
+
+	var decoded *protocol.Snapshot
+	if decoded, _ = decoded.Decode(snapshot.Encode()); decoded == nil {
+		t.Fatal("Could not decode snapshot.\n")
+	}
+
+	storage.State = storage.NewAccountStore(nil)
+	storage.RootKeys = make(map[[32]byte]*protocol.Account)
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	activeParameters = &parameterSlice[0]
+	target = []uint8{1}
+
+	//Served by a single miner and not checkpointed.
+	if _, err := applySnapshot(decoded, false); err == nil {
+		t.Error("Unconfirmed snapshot applied.\n")
+	}
+
+	snapshotBlock, err := applySnapshot(decoded, true)
+	if err != nil {
+		t.Fatalf("Could not apply snapshot: %v\n", err)
+	}
+
+	if snapshotBlock.Hash != block.Hash || lastBlock.Hash != block.Hash {
+		t.Errorf("Snapshot block not restored: %x\n", snapshotBlock.Hash)
+	}
+
+	if storage.State.Len() != 2 || !reflect.DeepEqual(storage.State.Get(acc.Hash()), acc) {
+		t.Errorf("State not restored: %v\n", storage.State)
+	}
+
+	if len(storage.RootKeys) != 1 || storage.RootKeys[rootAcc.Hash()] != storage.State.Get(rootAcc.Hash()) {
+		t.Errorf("Root keys not restored: %v\n", storage.RootKeys)
+	}
+
+	if activeParameters.Fee_minimum != 7 || !reflect.DeepEqual(target, []uint8{8, 10}) || currentTargetTime.first != 1234 {
+		t.Errorf("Parameters not restored: %v, target %v\n", activeParameters, target)
+	}
+
+	if last := storage.ReadLastClosedBlock(); last == nil || last.Hash != block.Hash {
+		t.Error("Last closed block not stored.\n")
+	}
+}
+
+func TestSnapshotInvalid(t *testing.T) {
+	snapshot := &protocol.Snapshot{Version: protocol.SNAPSHOT_VERSION, Height: 5, BlockHash: [32]byte{0x05}, Target: []uint8{8}}
+	if _, err := applySnapshot(snapshot, true); err == nil {
+		t.Error("Snapshot without its block applied.\n")
+	}
+
+	acc := &protocol.Account{Address: [32]byte{0x0b}, Balance: 50}
+	snapshot.Accounts = [][]byte{acc.Encode()}
+	block := &protocol.Block{Hash: [32]byte{0x05}, Height: 5}
+	snapshot.Blocks = [][]byte{block.Encode()}
+	if _, err := applySnapshot(snapshot, true); err == nil {
+		t.Error("Snapshot of a block without state root applied.\n")
+	}
+
+	block.StateRoot = protocol.NewStateTrie(map[[32]byte]*protocol.Account{acc.Hash(): acc}).Root()
+	snapshot.Blocks = [][]byte{block.Encode()}
+	snapshot.Accounts = [][]byte{acc.Encode(), {0x01}}
+	if _, err := applySnapshot(snapshot, true); err == nil {
+		t.Error("Snapshot with an invalid account applied.\n")
+	}
+
+	snapshot.Accounts = [][]byte{acc.Encode()}
+	snapshot.RootKeys = [][32]byte{{0x01}}
+	if _, err := applySnapshot(snapshot, true); err == nil {
+		t.Error("Snapshot with missing root account applied.\n")
+	}
+}
+
+func TestSnapshotBlockCheckpoint(t *testing.T) {
+	prevCheckpoints := trustedCheckpoints
+	defer func() { trustedCheckpoints = prevCheckpoints }()
+
+	block := &protocol.Block{Hash: [32]byte{0x05}, HashWithoutTx: [32]byte{0x06}, Height: 5}
+	if err := checkSnapshotBlock(block, false); err == nil {
+		t.Error("Unconfirmed snapshot block accepted.\n")
+	}
+
+	trustedCheckpoints = map[uint32][32]byte{5: {0x05}}
+	if err := checkSnapshotBlock(block, false); err != nil {
+		t.Errorf("Snapshot block of a trusted checkpoint rejected: %v\n", err)
+	}
+
+	//The checkpoint also overrides the miners confirming the block.
+	trustedCheckpoints = map[uint32][32]byte{5: {0x07}}
+	if err := checkSnapshotBlock(block, true); err == nil {
+		t.Error("Snapshot block conflicting with a trusted checkpoint accepted.\n")
+	}
+}
@@ -1,12 +1,14 @@
 package miner
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
 	"github.com/bazo-blockchain/bazo-miner/p2p"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/bazo-blockchain/bazo-miner/vm"
 	"strconv"
 	"time"
 )
@@ -48,7 +50,7 @@ func CheckAndChangeParameters(parameters *Parameters, configTxSlice *[]*protocol
 				parameters.Staking_minimum = tx.Payload
 				change = true
 				//Go through all accounts and remove all validators from the validator sett that no longer fulfill the minimum staking amount
-				for _, account := range storage.State {
+				for _, account := range storage.State.Snapshot() {
 					if account.IsStaking && account.Balance < 0+tx.Payload {
 						account.IsStaking = false
 					}
@@ -74,6 +76,87 @@ func CheckAndChangeParameters(parameters *Parameters, configTxSlice *[]*protocol
 				parameters.Slash_reward = tx.Payload
 				change = true
 			}
+		case protocol.BLOCK_TRIGGER_TXS_ID:
+			if parameterBoundsChecking(protocol.BLOCK_TRIGGER_TXS_ID, tx.Payload) {
+				parameters.Block_trigger_txs = tx.Payload
+				change = true
+			}
+		case protocol.BLOCK_TRIGGER_TIME_ID:
+			if parameterBoundsChecking(protocol.BLOCK_TRIGGER_TIME_ID, tx.Payload) {
+				parameters.Block_trigger_time = tx.Payload
+				change = true
+			}
+		case protocol.IOT_RATE_CAPACITY_ID:
+			if parameterBoundsChecking(protocol.IOT_RATE_CAPACITY_ID, tx.Payload) {
+				parameters.Iot_rate_capacity = tx.Payload
+				change = true
+			}
+		case protocol.IOT_RATE_REFILL_ID:
+			if parameterBoundsChecking(protocol.IOT_RATE_REFILL_ID, tx.Payload) {
+				parameters.Iot_rate_refill = tx.Payload
+				change = true
+			}
+		case protocol.UNBONDING_PERIOD_ID:
+			if parameterBoundsChecking(protocol.UNBONDING_PERIOD_ID, tx.Payload) {
+				parameters.Unbonding_period = tx.Payload
+				change = true
+			}
+		case protocol.RSA_COMMITMENT_DEADLINE_ID:
+			if parameterBoundsChecking(protocol.RSA_COMMITMENT_DEADLINE_ID, tx.Payload) {
+				parameters.Rsa_commitment_deadline = tx.Payload
+				change = true
+			}
+		case protocol.SLASH_FRACTION_ID:
+			if parameterBoundsChecking(protocol.SLASH_FRACTION_ID, tx.Payload) {
+				parameters.Slash_fraction = tx.Payload
+				change = true
+			}
+		case protocol.CONFIG_QUORUM_ID:
+			if parameterBoundsChecking(protocol.CONFIG_QUORUM_ID, tx.Payload) {
+				parameters.Config_quorum = tx.Payload
+				change = true
+			}
+		case protocol.CONFIG_ACTIVATION_DELAY_ID:
+			if parameterBoundsChecking(protocol.CONFIG_ACTIVATION_DELAY_ID, tx.Payload) {
+				parameters.Config_activation_delay = tx.Payload
+				change = true
+			}
+		case protocol.DIFF_EMA_WINDOW_ID:
+			if parameterBoundsChecking(protocol.DIFF_EMA_WINDOW_ID, tx.Payload) {
+				parameters.Diff_ema_window = tx.Payload
+				logger.Printf("DIFF_EMA_WINDOW: %v", parameters.Diff_ema_window)
+				change = true
+			}
+		case protocol.STATE_ROOT_HEIGHT_ID:
+			if parameterBoundsChecking(protocol.STATE_ROOT_HEIGHT_ID, tx.Payload) {
+				parameters.State_root_height = tx.Payload
+				change = true
+			}
+		case protocol.TX_ORDER_HEIGHT_ID:
+			if parameterBoundsChecking(protocol.TX_ORDER_HEIGHT_ID, tx.Payload) {
+				parameters.Tx_order_height = tx.Payload
+				change = true
+			}
+		case protocol.AGG_TX_AUTH_HEIGHT_ID:
+			if parameterBoundsChecking(protocol.AGG_TX_AUTH_HEIGHT_ID, tx.Payload) {
+				parameters.Agg_tx_auth_height = tx.Payload
+				change = true
+			}
+		case protocol.MEDIAN_TIME_HEIGHT_ID:
+			if parameterBoundsChecking(protocol.MEDIAN_TIME_HEIGHT_ID, tx.Payload) {
+				parameters.Median_time_height = tx.Payload
+				change = true
+			}
+		case protocol.BLOCK_HASH_HEIGHT_ID:
+			if parameterBoundsChecking(protocol.BLOCK_HASH_HEIGHT_ID, tx.Payload) {
+				parameters.Block_hash_height = tx.Payload
+				change = true
+			}
+		case protocol.DELEGATION_HEIGHT_ID:
+			if parameterBoundsChecking(protocol.DELEGATION_HEIGHT_ID, tx.Payload) {
+				parameters.Delegation_height = tx.Payload
+				change = true
+			}
 		}
 	}
 
@@ -83,14 +166,14 @@ func CheckAndChangeParameters(parameters *Parameters, configTxSlice *[]*protocol
 //For logging purposes
 func getState() (state string) {
 	accountWithBalance :=0
-	state += fmt.Sprintf("Number Accounts: %v\n", len(storage.State))
-	for _, acc := range storage.State {
+	state += fmt.Sprintf("Number Accounts: %v\n", storage.State.Len())
+	for _, acc := range storage.State.Snapshot() {
 		state += fmt.Sprintf("Is root: %v, %v\n", storage.IsRootKey(acc.Hash()), acc)
 		if(acc.Balance>0){
 			accountWithBalance++;
 		}
 	}
-	//state += fmt.Sprintf("Number Accounts: %v\n", len(storage.State))
+	//state += fmt.Sprintf("Number Accounts: %v\n", storage.State.Len())
 
 	state += fmt.Sprintf(" -> With Balance: %v\n", accountWithBalance)
 
@@ -99,15 +182,20 @@ func getState() (state string) {
 
 func initState() (initialBlock *protocol.Block, err error) {
 	var allClosedBlocks []*protocol.Block
+	snapshot, confirmed := loadSnapshot()
 	if p2p.IsBootstrap() {
 		allClosedBlocks = storage.ReadAllClosedBlocks()
+		if snapshot != nil && !containsBlock(allClosedBlocks, snapshot.BlockHash) {
+			logger.Printf("Snapshot (%x) is not part of the chain, replaying the chain instead.\n", snapshot.BlockHash[0:8])
+			snapshot = nil
+		}
 	} else {
 		p2p.LastBlockReq()
 		var lastBlock *protocol.Block
 		//Blocking wait
 		select {
 		case encodedBlock := <-p2p.BlockReqChan:
-			lastBlock = lastBlock.Decode(encodedBlock)
+			lastBlock, _ = lastBlock.Decode(encodedBlock)
 			//Limit waiting time to BLOCKFETCH_TIMEOUT seconds before aborting.
 		case <-time.After(BLOCKFETCH_TIMEOUT * time.Second):
 			return nil, nil
@@ -122,11 +210,20 @@ func initState() (initialBlock *protocol.Block, err error) {
 		}
 
 		for {
+			//Blocks up to the snapshot block do not need to be fetched and validated.
+			if snapshot != nil && lastBlock.Height <= snapshot.Height {
+				if lastBlock.Hash == snapshot.BlockHash {
+					break
+				}
+				logger.Printf("Snapshot (%x) is not part of the chain, replaying the chain instead.\n", snapshot.BlockHash[0:8])
+				snapshot = nil
+			}
+
 			p2p.BlockReq(lastBlock.PrevHash, lastBlock.PrevHashWithoutTx)
 			//p2p.BlockReq(lastBlock.PrevHash, lastBlock.PrevHashWithoutTx)
 			select {
 			case encodedBlock := <-p2p.BlockReqChan:
-				lastBlock = lastBlock.Decode(encodedBlock)
+				lastBlock, _ = lastBlock.Decode(encodedBlock)
 				//Limit waiting time to BLOCKFETCH_TIMEOUT seconds before aborting.
 			case <-time.After(BLOCKFETCH_TIMEOUT * time.Second):
 				logger.Println("Timed out")
@@ -151,6 +248,28 @@ func initState() (initialBlock *protocol.Block, err error) {
 		}
 	}
 
+	if snapshot != nil {
+		if initialBlock, err = applySnapshot(snapshot, confirmed); err != nil {
+			return nil, errors.New(fmt.Sprintf("Could not apply snapshot: %v", err))
+		}
+
+		if err := writeInitialBalances(snapshot.Height); err != nil {
+			return nil, err
+		}
+		balanceHistoryStart = snapshot.Height
+
+		var blocksOnTop []*protocol.Block
+		for _, block := range allClosedBlocks {
+			if block.Height > snapshot.Height {
+				blocksOnTop = append(blocksOnTop, block)
+			} else {
+				//Blocks covered by the snapshot are not validated, which indexes the others.
+				storage.WriteBlockHeight(block.Height, block.Hash)
+			}
+		}
+		allClosedBlocks = blocksOnTop
+	}
+
 	if len(allClosedBlocks) > 0 {
 		//Set the last closed block as the initial block
 		initialBlock = allClosedBlocks[0]
@@ -159,12 +278,19 @@ func initState() (initialBlock *protocol.Block, err error) {
 				initialBlock = blockToValidate
 			}
 		}
-	} else {
+	} else if snapshot == nil {
 		initialBlock = newBlock([32]byte{},[32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 0)
 
-		commitmentProof, err := crypto.SignMessageWithRSAKey(rootCommPrivKey, fmt.Sprint(initialBlock.Height))
-		if err != nil {
-			return nil, err
+		var commitmentProof []byte
+		if genesis != nil {
+			commitmentProof, _ = genesis.commitmentProof()
+		}
+		if commitmentProof == nil {
+			proof, err := crypto.SignMessageWithRSAKey(rootCommPrivKey, fmt.Sprint(initialBlock.Height))
+			if err != nil {
+				return nil, err
+			}
+			commitmentProof = proof[:]
 		}
 		copy(initialBlock.CommitmentProof[:], commitmentProof[:])
 		//Append genesis block to the map and save in storage
@@ -178,6 +304,18 @@ func initState() (initialBlock *protocol.Block, err error) {
 		allClosedBlocks = InvertBlockArray(allClosedBlocks)
 	}
 
+	if trustedHeight, err = trustedCheckpointHeight(allClosedBlocks); err != nil {
+		return nil, err
+	}
+	defer func() { trustedHeight = 0 }()
+
+	//The validator set of the first epoch is determined by the genesis state.
+	if snapshot == nil {
+		if err := storeValidatorSet(0); err != nil {
+			return nil, errors.New(fmt.Sprintf("Validator set of the genesis state could not be written: %v", err))
+		}
+	}
+
 	//Validate all closed blocks and update state
 	for _, blockToValidate := range allClosedBlocks {
 		//Prepare datastructure to fill tx payloads
@@ -186,23 +324,32 @@ func initState() (initialBlock *protocol.Block, err error) {
 		//Do not validate the genesis block, since a lot of properties are set to nil
 		if blockToValidate.Hash != [32]byte{} {
 			//Fetching payload data from the txs (if necessary, ask other miners)
-			accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, err := preValidate(blockToValidate, true)
+			accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, contractTxs, err := preValidate(context.Background(), blockToValidate, true)
 			if err != nil {
 				return nil, errors.New(fmt.Sprintf("Block (%x) could not be prevalidated: %v\n", blockToValidate.Hash[0:8], err))
 			}
 
-			blockDataMap[blockToValidate.Hash] = blockData{accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs,blockToValidate}
+			blockDataMap[blockToValidate.Hash] = blockData{accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, contractTxs, blockToValidate}
 
-			err = validateState(blockDataMap[blockToValidate.Hash])
+			err = validateState(blockDataMap[blockToValidate.Hash], true)
 			if err != nil {
 				return nil, errors.New(fmt.Sprintf("Block (%x) could not be statevalidated: %v\n", blockToValidate.Hash[0:8], err))
 			}
 
-			postValidate(blockDataMap[blockToValidate.Hash], true)
+			if err := postValidate(blockDataMap[blockToValidate.Hash], true); err != nil {
+				storage.RevertStateTransition()
+				return nil, err
+			}
+			storage.CommitStateTransition()
 		} else {
-			blockDataMap[blockToValidate.Hash] = blockData{nil, nil, nil, nil, nil, nil, blockToValidate}
+			if err := writeInitialBalances(blockToValidate.Height); err != nil {
+				return nil, err
+			}
+			blockDataMap[blockToValidate.Hash] = blockData{nil, nil, nil, nil, nil, nil, nil, blockToValidate}
 
-			postValidate(blockDataMap[blockToValidate.Hash], true)
+			if err := postValidate(blockDataMap[blockToValidate.Hash], true); err != nil {
+				return nil, err
+			}
 		}
 
 
@@ -227,19 +374,45 @@ func initState() (initialBlock *protocol.Block, err error) {
 }
 
 func accStateChange(txSlice []*protocol.AccTx) error {
+	removedAccounts = make(map[[32]byte]removedAccount)
+
 	for _, tx := range txSlice {
 		if tx.Header != 2 {
 			newAcc := protocol.NewAccount(tx.PubKey, tx.Issuer, 0, false, [crypto.COMM_KEY_LENGTH]byte{}, tx.Contract, tx.ContractVariables)
+			newAcc.Threshold = tx.Threshold
+			newAcc.Cosigners = tx.Cosigners
 			newAccHash := newAcc.Hash()
 
+			if tx.Device != nil {
+				if err := checkDeviceOwner(tx.Device); err != nil {
+					return err
+				}
+				newAcc.Device = tx.Device
+			}
+
 			acc, _ := storage.GetAccount(newAccHash)
 			if acc != nil {
 				//Shouldn't happen, because this should have been prevented when adding an accTx to the block
 				return errors.New("Address already exists in the state.")
 			}
 
+			//The initial balance is transferred from the issuing root account, which funds it from its balance. Unlike
+			//fundsTxs signed by a root account, no coins are issued.
+			if tx.Amount > 0 {
+				rootAcc, err := storage.GetRootAccount(tx.Issuer)
+				if err != nil || rootAcc == nil {
+					return errors.New(fmt.Sprintf("Issuer %x of the initial balance is not a root account.", tx.Issuer[0:8]))
+				}
+
+				if tx.Amount > rootAcc.Balance {
+					return errors.New(fmt.Sprintf("Issuer %x does not have enough funds for the initial balance: Balance = %v, Amount = %v.", tx.Issuer[0:8], rootAcc.Balance, tx.Amount))
+				}
+				rootAcc.Balance -= tx.Amount
+				newAcc.Balance = tx.Amount
+			}
+
 			//If acc does not exist, write to state
-			storage.State[newAccHash] = &newAcc
+			storage.State.Set(newAccHash, &newAcc)
 
 			if tx.Header == 1 {
 				//First bit set, given account will be a new root account
@@ -248,20 +421,64 @@ func accStateChange(txSlice []*protocol.AccTx) error {
 				storage.RootKeys[newAccHash] = &newAcc
 			}
 		} else if tx.Header == 2 {
-			accHash := protocol.SerializeHashContent(tx.PubKey)
-			_, err := storage.GetAccount(accHash)
-			if err != nil {
+			//Second bit set, the account is removed from the state
+			if err := removeAccount(tx); err != nil {
 				return err
 			}
-
-			//Second bit set, delete account from root account
-			delete(storage.RootKeys, accHash)
 		}
 	}
 
 	return nil
 }
 
+//Accounts removed by the accTxs of the block being validated. They are written to disk together with the block (see
+//postValidate), so they can be restored if the block is rolled back.
+type removedAccount struct {
+	acc    protocol.Account
+	isRoot bool
+}
+
+var removedAccounts = make(map[[32]byte]removedAccount)
+
+//Removes the account from the state to reclaim its space. The remaining balance is swept to the issuing root account,
+//which signed the tx. Validators have to leave the validator set before, and root accounts can not remove themselves.
+//Replaying txs of a removed (and recreated) account is not possible, since blocks with closed txs are rejected.
+func removeAccount(tx *protocol.AccTx) error {
+	accHash := protocol.SerializeHashContent(tx.PubKey)
+	acc, err := storage.GetAccount(accHash)
+	if err != nil {
+		return err
+	}
+
+	if acc.IsStaking {
+		return errors.New(fmt.Sprintf("Account %x is staking and can not be removed.", accHash[0:8]))
+	}
+
+	if len(acc.Delegations) > 0 {
+		return errors.New(fmt.Sprintf("Account %x keeps delegations and can not be removed.", accHash[0:8]))
+	}
+
+	if accHash == tx.Issuer {
+		return errors.New("Root accounts can not remove themselves.")
+	}
+
+	rootAcc, err := storage.GetRootAccount(tx.Issuer)
+	if err != nil || rootAcc == nil {
+		return errors.New(fmt.Sprintf("Issuer %x of the account removal is not a root account.", tx.Issuer[0:8]))
+	}
+
+	if rootAcc.Balance+acc.Balance > MAX_MONEY {
+		return errors.New("Sweeping the balance would lead to balance overflow at the root account.")
+	}
+	rootAcc.Balance += acc.Balance
+
+	removedAccounts[tx.Hash()] = removedAccount{*acc, storage.IsRootKey(accHash)}
+	storage.State.Delete(accHash)
+	delete(storage.RootKeys, accHash)
+
+	return nil
+}
+
 func iotStateChange(txSlice []*protocol.IotTx) (err error) {
 	for _, tx := range txSlice {
 		var rootAcc *protocol.Account
@@ -283,12 +500,6 @@ func iotStateChange(txSlice []*protocol.IotTx) (err error) {
 		accSender, err = storage.GetAccount(tx.From)
 		accReceiver, err = storage.GetAccount(tx.To)
 
-		//Check transaction counter
-		//TODO: @ilecipi check again
-		//if tx.TxCnt != accSender.TxCnt {
-		//	err = errors.New(fmt.Sprintf("Sender txCnt does not match: %v (tx.txCnt) vs. %v (state txCnt).", tx.TxCnt, accSender.TxCnt))
-		//}
-
 		//Check sender balance
 		if (tx.Fee) > accSender.Balance {
 			err = errors.New(fmt.Sprintf("Sender does not have enough funds for the transaction: Balance = %v, Fee = %v.", accSender.Balance, tx.Fee))
@@ -321,42 +532,163 @@ func iotStateChange(txSlice []*protocol.IotTx) (err error) {
 	return nil
 }
 
+//The deployer pays the fee to the miner and the deployment gas, which is burned. The contract account is created with
+//the bytecode and the constructor parameters as its initial contract variables.
+func contractStateChange(txSlice []*protocol.ContractTx, minerHash [32]byte) error {
+	for _, tx := range txSlice {
+		accSender, err := storage.GetAccount(tx.From)
+		if err != nil {
+			return err
+		}
+
+		minerAcc, err := storage.GetAccount(minerHash)
+		if err != nil {
+			return err
+		}
+
+		if tx.Fee+tx.DeploymentGas() > accSender.Balance {
+			return errors.New(fmt.Sprintf("Sender does not have enough funds to deploy the contract: Balance = %v, Fee = %v, Gas = %v.", accSender.Balance, tx.Fee, tx.DeploymentGas()))
+		}
+
+		//After the deployment, account must still have more than the minimum staking amount
+		if accSender.IsStaking && tx.Fee+tx.DeploymentGas()+protocol.MIN_STAKING_MINIMUM > accSender.Balance {
+			return errors.New("Sender is staking and does not have enough funds in order to fulfill the required staking minimum.")
+		}
+
+		if minerAcc.Balance+tx.Fee > MAX_MONEY {
+			return errors.New("Fee amount would lead to balance overflow at the miner account.")
+		}
+
+		address := protocol.ContractAddress(tx.From, tx.TxCnt)
+		contractAcc := protocol.NewAccount(address, tx.From, 0, false, [crypto.COMM_KEY_LENGTH]byte{}, tx.Contract, tx.ContractVariables)
+		contractHash := contractAcc.Hash()
+		if acc, _ := storage.GetAccount(contractHash); acc != nil {
+			return errors.New(fmt.Sprintf("Contract account %x already exists.", contractHash[0:8]))
+		}
+
+		//We're manipulating pointers, no need to write back
+		accSender.Balance -= tx.Fee + tx.DeploymentGas()
+		accSender.TxCnt += 1
+		minerAcc.Balance += tx.Fee
+		storage.State.Set(contractHash, &contractAcc)
+	}
+
+	return nil
+}
+
 //this method does inititate the state change for aggregated Transactions. It does
-func aggTxStateChange(txSlice []*protocol.AggTx) (err error) {
+func aggTxStateChange(txSlice []*protocol.AggTx, height uint32) (err error) {
 	for _, tx1 := range txSlice {
-		var fundsFxSlice []*protocol.FundsTx
-		for _, tx2 := range tx1.AggregatedTxSlice {
-			//Fetch all aggregated open Funds transactions for state validation.
-			trx := storage.ReadOpenTx(tx2)
-			if trx == nil {
-				trx = storage.ReadClosedTx(tx2)
-			}
+		if err := fundsStateChange(aggregatedFundsTxs(tx1), height); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Fetch all aggregated open Funds transactions for state validation.
+func aggregatedFundsTxs(aggTx *protocol.AggTx) (fundsTxSlice []*protocol.FundsTx) {
+	for _, txHash := range aggTx.AggregatedTxSlice {
+		trx := storage.ReadOpenTx(txHash)
+		if trx == nil {
+			trx = storage.ReadClosedTx(txHash)
+		}
+
+		switch trx.(type) {
+		case *protocol.FundsTx:
+			fundsTxSlice = append(fundsTxSlice, trx.(*protocol.FundsTx))
+		}
+	}
 
-			switch trx.(type) {
-			case *protocol.FundsTx:
-				fundsFxSlice = append(fundsFxSlice, trx.(*protocol.FundsTx))
+	return fundsTxSlice
+}
+
+//Transaction counters need to match the state, preventing replay attacks. FundsTxs (standalone or aggregated),
+//iotTxs and contractTxs of a sender share the counter but are not necessarily in txCnt order within a block, because aggregation
+//groups them by sender or receiver. Therefore, they need to form a gapless sequence starting at the state's txCnt.
+func txCntCheck(fundsTxSlice []*protocol.FundsTx, aggTxSlice []*protocol.AggTx, iotTxSlice []*protocol.IotTx, contractTxSlice []*protocol.ContractTx) error {
+	txCnts := make(map[[32]byte]map[uint32]bool)
+	addTxCnt := func(sender [32]byte, txCnt uint32) error {
+		if txCnts[sender] == nil {
+			txCnts[sender] = make(map[uint32]bool)
+		}
+		if txCnts[sender][txCnt] {
+			return errors.New(fmt.Sprintf("Sender %x uses txCnt %v more than once.", sender[0:8], txCnt))
+		}
+		txCnts[sender][txCnt] = true
+		return nil
+	}
+
+	for _, tx := range fundsTxSlice {
+		if err := addTxCnt(tx.From, tx.TxCnt); err != nil {
+			return err
+		}
+	}
+
+	for _, aggTx := range aggTxSlice {
+		for _, tx := range aggregatedFundsTxs(aggTx) {
+			if err := addTxCnt(tx.From, tx.TxCnt); err != nil {
+				return err
 			}
-			//fundsFxSlice = append(fundsFxSlice, trx)
 		}
+	}
+
+	for _, tx := range iotTxSlice {
+		if err := addTxCnt(tx.From, tx.TxCnt); err != nil {
+			return err
+		}
+	}
 
-		if err := fundsStateChange(fundsFxSlice); err != nil {
+	for _, tx := range contractTxSlice {
+		if err := addTxCnt(tx.From, tx.TxCnt); err != nil {
 			return err
 		}
-		fundsFxSlice = nil
+	}
+
+	for sender, cnts := range txCnts {
+		accSender, err := storage.GetAccount(sender)
+		if err != nil {
+			return err
+		}
+
+		for txCnt := accSender.TxCnt; txCnt < accSender.TxCnt+uint32(len(cnts)); txCnt++ {
+			if !cnts[txCnt] {
+				return errors.New(fmt.Sprintf("Sender txCnt does not match: %v (tx.txCnt) missing, %v (state txCnt).", txCnt, accSender.TxCnt))
+			}
+		}
 	}
 
 	return nil
 }
 
-func fundsStateChange(txSlice []*protocol.FundsTx) (err error) {
+func fundsStateChange(txSlice []*protocol.FundsTx, height uint32) (err error) {
 	for _, tx := range txSlice {
+		//Delegation txs do not transfer the amount, see delegation.go.
+		if kind := delegationKind(tx, height); kind != 0 {
+			accSender, err := storage.GetAccount(tx.From)
+			if err != nil {
+				return err
+			}
+			accReceiver, err := storage.GetAccount(tx.To)
+			if err != nil {
+				return err
+			}
+			if err := checkDelegation(tx, kind, accSender, accReceiver, height); err != nil {
+				return err
+			}
+
+			applyDelegation(tx, kind, accSender, accReceiver)
+			continue
+		}
+
 		var rootAcc *protocol.Account
 		//Check if we have to issue new coins (in case a root account signed the tx)
 		if rootAcc, err = storage.GetRootAccount(tx.From); err != nil {
 			return err
 		}
 
-		if rootAcc != nil && rootAcc.Balance+tx.Amount+tx.Fee > MAX_MONEY {
+		if rootAcc != nil && rootAcc.Balance+tx.Amount+tx.Fee+tx.GasCost() > MAX_MONEY {
 			return errors.New("Transaction amount would lead to balance overflow at the receiver (root) account.")
 		}
 
@@ -364,27 +696,23 @@ func fundsStateChange(txSlice []*protocol.FundsTx) (err error) {
 		if rootAcc != nil {
 			rootAcc.Balance += tx.Amount
 			rootAcc.Balance += tx.Fee
+			rootAcc.Balance += tx.GasCost()
 		}
 
 		var accSender, accReceiver *protocol.Account
 		accSender, err = storage.GetAccount(tx.From)
 		accReceiver, err = storage.GetAccount(tx.To)
 
-		//Check transaction counter
-		//TODO @ilecipi revert check TxCnt
+		//Transaction counters are checked for the whole block in txCntCheck.
 
-		//if tx.TxCnt != accSender.TxCnt {
-		//	err = errors.New(fmt.Sprintf("Sender txCnt does not match: %v (tx.txCnt) vs. %v (state txCnt).", tx.TxCnt, accSender.TxCnt))
-		//}
-
-		//Check sender balance
-		if (tx.Amount + tx.Fee) > accSender.Balance {
-			err = errors.New(fmt.Sprintf("Sender does not have enough funds for the transaction: Balance = %v, Amount = %v, Fee = %v.", accSender.Balance, tx.Amount, tx.Fee))
+		//Check sender balance. The gas is charged in full, the contract's execution does not affect the state change.
+		if (tx.Amount + tx.Fee + tx.GasCost()) > accSender.Balance {
+			err = errors.New(fmt.Sprintf("Sender does not have enough funds for the transaction: Balance = %v, Amount = %v, Fee = %v, Gas = %v.", accSender.Balance, tx.Amount, tx.Fee, tx.GasCost()))
 		}
 
-		//After Tx fees, account must still have more than the minimum staking amount
-		if accSender.IsStaking && ((tx.Fee + protocol.MIN_STAKING_MINIMUM + tx.Amount) > accSender.Balance) {
-			err = errors.New("Sender is staking and does not have enough funds in order to fulfill the required staking minimum.")
+		//After Tx fees, the stake of a staking or unbonding account must still be there. Root accounts are exempt.
+		if rootAcc == nil && (tx.Fee+tx.GasCost()+lockedStake(accSender, height)+tx.Amount) > accSender.Balance {
+			err = errors.New("Sender is staking or unbonding and does not have enough funds in order to keep the stake locked.")
 		}
 
 		//Overflow protection
@@ -397,37 +725,97 @@ func fundsStateChange(txSlice []*protocol.FundsTx) (err error) {
 				//Rollback root's credits if error occurs
 				rootAcc.Balance -= tx.Amount
 				rootAcc.Balance -= tx.Fee
+				rootAcc.Balance -= tx.GasCost()
 			}
 
 			return err
 		}
 
-		//We're manipulating pointer, no need to write back
+		//The contract is executed before the amount is credited, as by the proposer (see addFundsTx).
+		var context *protocol.Context
+		if tx.Data != nil && accReceiver.Contract != nil {
+			context = callContract(tx)
+		}
+
+		//We're manipulating pointer, no need to write back. The gas goes to the miner with the fee (see collectTxFees).
 		accSender.TxCnt += 1
 		accSender.Balance -= tx.Amount
+		accSender.Balance -= tx.GasCost()
 		accReceiver.Balance += tx.Amount
+
+		if context != nil {
+			context.PersistChanges()
+		}
 	}
 
 	return nil
 }
 
+//The accounts loaded by the contract calls of the block being validated, as they were before the call, keyed by the
+//hash of the fundsTx. They are written to disk together with the block (see postValidate), so the calls can be
+//reverted if the block is rolled back.
+var calledContractStates = make(map[[32]byte]map[[32]byte]protocol.Account)
+
+//Logs emitted by the contract calls of the block being validated, see collectLogs.
+var contractLogs []*protocol.Log
+
+//Executes the contract called by the tx on the state. The contract variables of the loaded accounts are copied before
+//the call changes them, the recorded versions (and the ones kept by the state transition) stay unchanged. Returns nil
+//if the execution failed, like in addFundsTx the tx is valid nonetheless.
+func callContract(tx *protocol.FundsTx) *protocol.Context {
+	before := make(map[[32]byte]protocol.Account)
+	loadAccount := func(hash [32]byte) *protocol.Account {
+		acc, _ := storage.GetAccount(hash)
+		if acc == nil {
+			return nil
+		}
+		if _, loaded := before[hash]; !loaded {
+			before[hash] = *acc
+			acc.ContractVariables = append([]protocol.ByteArray(nil), acc.ContractVariables...)
+		}
+
+		return acc
+	}
+
+	context := protocol.NewContext(*loadAccount(tx.To), *tx, loadAccount)
+	virtualMachine := vm.NewVM(context)
+	if err := execContract(&virtualMachine, tx.Hash()); err != nil {
+		logger.Printf("Contract execution of tx %x failed after %v gas: %v\n", tx.Hash(), virtualMachine.GasUsed(), err)
+		return nil
+	}
+
+	calledContractStates[tx.Hash()] = before
+	contractLogs = append(contractLogs, context.GetLogs()...)
+
+	return context
+}
+
 //We accept config slices with unknown id, but don't act on the payload. This is in case we have not updated to a new
 //software with corresponding code to act on the configTx id/payload
-func configStateChange(configTxSlice []*protocol.ConfigTx, blockHash [32]byte) {
-	var newParameters Parameters
-	//Initialize it to state right now (before validating config txs)
-	newParameters = *activeParameters
+//Parameter changes apply Config_activation_delay blocks after the block containing the config txs, so validators
+//have time to adapt. Changes which are not active yet are part of the parameter slice already, later changes build
+//on them.
+func configStateChange(configTxSlice []*protocol.ConfigTx, blockHash [32]byte, height uint32) {
+	//Pending changes become active without a config tx in the block.
+	defer func() { activeParameters = parametersAt(height + 1) }()
 
 	if len(configTxSlice) == 0 {
 		return
 	}
 
+	//Initialize it to the latest parameters (before validating config txs), including pending changes
+	newParameters := parameterSlice[len(parameterSlice)-1]
+
 	//Only add a new parameter struct if a relevant system parameter changed
 	if CheckAndChangeParameters(&newParameters, &configTxSlice) {
 		newParameters.BlockHash = blockHash
+		newParameters.Height = height + uint32(parametersAt(height).Config_activation_delay)
+		//Keeps the slice in height order if the delay was shortened in the meantime.
+		if newParameters.Height < parameterSlice[len(parameterSlice)-1].Height {
+			newParameters.Height = parameterSlice[len(parameterSlice)-1].Height
+		}
 		parameterSlice = append(parameterSlice, newParameters)
-		activeParameters = &parameterSlice[len(parameterSlice)-1]
-		logger.Printf("Config parameters changed. New configuration: %v", *activeParameters)
+		logger.Printf("Config parameters changed, active above height %v. New configuration: %v", newParameters.Height, newParameters)
 	}
 }
 
@@ -442,7 +830,7 @@ func stakeStateChange(txSlice []*protocol.StakeTx, height uint32) (err error) {
 		}
 
 		//Check minimum amount
-		if tx.IsStaking && accSender.Balance < tx.Fee+activeParameters.Staking_minimum {
+		if tx.IsStaking && accSender.Balance < tx.Fee+parametersAt(height).Staking_minimum {
 			err = errors.New(fmt.Sprintf("Sender wants to stake but does not have enough funds (%v) in order to fulfill the required staking minimum (%v).", accSender.Balance, STAKING_MINIMUM))
 		}
 
@@ -451,6 +839,13 @@ func stakeStateChange(txSlice []*protocol.StakeTx, height uint32) (err error) {
 			err = errors.New(fmt.Sprintf("Sender does not have enough funds for the transaction: Balance = %v, Amount = %v, Fee = %v.", accSender.Balance, 0, tx.Fee))
 		}
 
+		//Check the kind of the commitment key
+		if tx.IsStaking {
+			if deadlineErr := checkCommitmentKey(tx.CommitmentKey, height); deadlineErr != nil {
+				err = deadlineErr
+			}
+		}
+
 		if err != nil {
 			return err
 		}
@@ -459,6 +854,9 @@ func stakeStateChange(txSlice []*protocol.StakeTx, height uint32) (err error) {
 		accSender.IsStaking = tx.IsStaking
 		accSender.CommitmentKey = tx.CommitmentKey
 		accSender.StakingBlockHeight = height
+		if !tx.IsStaking {
+			accSender.UnbondingHeight = unbondingHeight(height)
+		}
 	}
 
 	return nil
@@ -515,7 +913,7 @@ func collectTxFees(accTxSlice []*protocol.AccTx, fundsTxSlice []*protocol.FundsT
 	//subtract fees from sender (check if that is allowed has already been done in the block validation)
 	for _, tx := range fundsTxSlice {
 		//Prevent protocol account from overflowing
-		if minerAcc.Balance+tx.Fee > MAX_MONEY {
+		if minerAcc.Balance+tx.Fee+tx.GasCost() > MAX_MONEY {
 			err = errors.New("Fee amount would lead to balance overflow at the miner account.")
 		}
 
@@ -527,7 +925,9 @@ func collectTxFees(accTxSlice []*protocol.AccTx, fundsTxSlice []*protocol.FundsT
 			return err
 		}
 
+		//The gas has already been subtracted from the sender in fundsStateChange.
 		minerAcc.Balance += tx.Fee
+		minerAcc.Balance += tx.GasCost()
 		senderAcc.Balance -= tx.Fee
 		tmpFundsTx = append(tmpFundsTx, tx)
 	}
@@ -599,25 +999,50 @@ func collectBlockReward(reward uint64, minerHash [32]byte) (err error) {
 	return nil
 }
 
+//Stakes burned by the block being validated, keyed by the hash of the block, written to disk together with the block
+//(see postValidate).
+var slashedStakes = make(map[[32]byte]uint64)
+
+//Returns the stake burned when a validator with balance is slashed in the block at height: the Slash_fraction of the
+//balance, but at least the staking minimum.
+func slashedStake(balance uint64, height uint32) uint64 {
+	params := parametersAt(height)
+	stake := balance/100*params.Slash_fraction + balance%100*params.Slash_fraction/100
+	if stake < params.Staking_minimum {
+		stake = params.Staking_minimum
+	}
+	if stake > balance {
+		stake = balance
+	}
+
+	return stake
+}
+
 func collectSlashReward(reward uint64, block *protocol.Block) (err error) {
+	slashedStakes = make(map[[32]byte]uint64)
+
 	//Check if proof is provided. If proof was incorrect, prevalidation would already have failed.
 	if block.SlashedAddress != [32]byte{} || block.ConflictingBlockHash1 != [32]byte{} || block.ConflictingBlockHash2 != [32]byte{} || block.ConflictingBlockHashWithoutTx1 != [32]byte{} || block.ConflictingBlockHashWithoutTx2 != [32]byte{} {
 		var minerAcc, slashedAcc *protocol.Account
 		minerAcc, err = storage.GetAccount(block.Beneficiary)
-		slashedAcc, err = storage.GetAccount(block.SlashedAddress)
-
-		if minerAcc.Balance+reward > MAX_MONEY {
-			err = errors.New("Slash reward would lead to balance overflow at the miner account.")
+		if err != nil {
+			return err
 		}
-
+		slashedAcc, err = storage.GetAccount(block.SlashedAddress)
 		if err != nil {
 			return err
 		}
 
+		if minerAcc.Balance+reward > MAX_MONEY {
+			return errors.New("Slash reward would lead to balance overflow at the miner account.")
+		}
+
 		//Validator is rewarded with slashing reward for providing a valid slashing proof
 		minerAcc.Balance += reward
-		//Slashed account looses the minimum staking amount
-		slashedAcc.Balance -= activeParameters.Staking_minimum
+		//The stake of the slashed account is burned
+		stake := slashedStake(slashedAcc.Balance, block.Height)
+		slashedAcc.Balance -= stake
+		slashedStakes[block.Hash] = stake
 		//Slashed account is being removed from the validator set
 		slashedAcc.IsStaking = false
 	}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/ed25519"
 )
 
 //Testing state change, rollback and fee collection
@@ -24,7 +25,7 @@ func TestFundsTxStateChange(t *testing.T) {
 	var testSize uint32
 	testSize = 1000
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	var funds []*protocol.FundsTx
 
 	var feeA, feeB uint64
@@ -38,7 +39,7 @@ func TestFundsTxStateChange(t *testing.T) {
 
 	loopMax := int(randVar.Uint32()%testSize + 1)
 	for i := 0; i < loopMax+1; i++ {
-		ftx, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%1000000+1, randVar.Uint64()%100+1, uint32(i), accAHash, accBHash, PrivKeyAccA, nil, nil)
+		ftx, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%1000000+1, randVar.Uint64()%100+1, uint32(i), accAHash, accBHash, PrivKeyAccA, nil)
 		if addTx(b, ftx) == nil {
 			funds = append(funds, ftx)
 			balanceA -= ftx.Amount
@@ -47,7 +48,7 @@ func TestFundsTxStateChange(t *testing.T) {
 			balanceB += ftx.Amount
 		}
 
-		ftx2, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%1000+1, randVar.Uint64()%100+1, uint32(i), accAHash, accAHash, PrivKeyAccB, nil, nil)
+		ftx2, _ := protocol.ConstrFundsTx(0x01, randVar.Uint64()%1000+1, randVar.Uint64()%100+1, uint32(i), accAHash, accAHash, PrivKeyAccB, nil)
 		if addTx(b, ftx2) == nil {
 			funds = append(funds, ftx2)
 			balanceB -= ftx2.Amount
@@ -57,13 +58,13 @@ func TestFundsTxStateChange(t *testing.T) {
 		}
 	}
 
-	fundsStateChange(funds)
+	fundsStateChange(funds, 0)
 
 	if accA.Balance != balanceA || accB.Balance != balanceB {
 		t.Errorf("State update failed: %v != %v or %v != %v\n", accA.Balance, balanceA, accB.Balance, balanceB)
 	}
 
-	collectTxFees(nil, funds, nil, nil, minerAccHash)
+	collectTxFees(nil, funds, nil, nil, nil, nil, minerAccHash)
 	if feeA+feeB != validatorAcc.Balance-minerBal {
 		t.Error("Fee Collection failed!")
 	}
@@ -85,13 +86,13 @@ func TestAccountOverflow(t *testing.T) {
 
 	accA.Balance = MAX_MONEY
 	accA.TxCnt = 0
-	tx, err := protocol.ConstrFundsTx(0x01, 1, 1, 0, accBHash, accAHash, PrivKeyAccB, PrivKeyMultiSig, nil)
+	tx, err := protocol.ConstrFundsTx(0x01, 1, 1, 0, accBHash, accAHash, PrivKeyAccB, nil)
 	if !verifyFundsTx(tx) || err != nil {
 		t.Error("Failed to create reasonable fundsTx\n")
 		return
 	}
 	accSlice = append(accSlice, tx)
-	err = fundsStateChange(accSlice)
+	err = fundsStateChange(accSlice, 0)
 
 	//Err shouldn't be nil, because the tx can't have been successful
 	//Also, the balance of A shouldn't have changed
@@ -110,10 +111,10 @@ func TestAccTxStateChange(t *testing.T) {
 
 	var accs []*protocol.AccTx
 
-	nullAddress := [64]byte{}
+	nullAddress := [32]byte{}
 	loopMax := int(randVar.Uint32()%testSize) + 1
 	for i := 0; i < loopMax; i++ {
-		tx, _, _ := protocol.ConstrAccTx(0, randVar.Uint64()%1000, nullAddress, PrivKeyRoot, nil, nil)
+		tx, _, _ := protocol.ConstrAccTx(0, randVar.Uint64()%1000, 0, nullAddress, PrivKeyRoot, nil, nil)
 		accs = append(accs, tx)
 	}
 
@@ -121,7 +122,7 @@ func TestAccTxStateChange(t *testing.T) {
 
 	for _, acc := range accs {
 		accHash := protocol.SerializeHashContent(acc.PubKey)
-		acc := storage.State[accHash]
+		acc := storage.State.Get(accHash)
 		//make sure the previously created acc is in the state
 		if acc == nil {
 			t.Errorf("Account State failed to update for the following account: %v\n", acc)
@@ -130,9 +131,9 @@ func TestAccTxStateChange(t *testing.T) {
 
 	//Create a new root account, set the header to 0x01
 	var singleSlice []*protocol.AccTx
-	tx, _, _ := protocol.ConstrAccTx(0x01, randVar.Uint64()%1000, nullAddress, PrivKeyRoot, nil, nil)
+	tx, _, _ := protocol.ConstrAccTx(0x01, randVar.Uint64()%1000, 0, nullAddress, PrivKeyRoot, nil, nil)
 	singleSlice = append(singleSlice, tx)
-	var pubKeyTmp [64]byte
+	var pubKeyTmp [32]byte
 	copy(pubKeyTmp[:], tx.PubKey[:])
 
 	accStateChange(singleSlice)
@@ -152,6 +153,60 @@ func TestAccTxStateChange(t *testing.T) {
 	}
 }
 
+func TestAccTxInitialBalance(t *testing.T) {
+	rootPubKey, rootPrivKey, _ := ed25519.GenerateKey(nil)
+	var rootAddress [32]byte
+	copy(rootAddress[:], rootPubKey)
+	rootAcc := &protocol.Account{Address: rootAddress, Balance: 1500}
+	rootHash := rootAcc.Hash()
+	storage.State.Set(rootHash, rootAcc)
+	storage.RootKeys[rootHash] = rootAcc
+	defer storage.State.Delete(rootHash)
+	defer delete(storage.RootKeys, rootHash)
+
+	tx, _, _ := protocol.ConstrAccTx(0, 1, 1000, [32]byte{}, rootPrivKey, nil, nil)
+	if !verifyAccTx(tx) {
+		t.Fatal("AccTx with an initial balance signed by the issuer not verified.")
+	}
+
+	newAccHash := protocol.SerializeHashContent(tx.PubKey)
+	defer storage.State.Delete(newAccHash)
+	if err := accStateChange([]*protocol.AccTx{tx}); err != nil {
+		t.Fatalf("AccTx with an initial balance rejected: %v\n", err)
+	}
+
+	if storage.State.Get(newAccHash) == nil || storage.State.Get(newAccHash).Balance != 1000 || rootAcc.Balance != 500 {
+		t.Errorf("Initial balance not transferred: %v\n", storage.State.Get(newAccHash))
+	}
+
+	accStateChangeRollback([]*protocol.AccTx{tx})
+	if storage.State.Get(newAccHash) != nil || rootAcc.Balance != 1500 {
+		t.Errorf("Initial balance not returned to the issuer on rollback: %v\n", rootAcc.Balance)
+	}
+
+	//The root account can not endow more than its balance.
+	tooMuch, _, _ := protocol.ConstrAccTx(0, 1, 1501, [32]byte{}, rootPrivKey, nil, nil)
+	if err := accStateChange([]*protocol.AccTx{tooMuch}); err == nil {
+		storage.State.Delete(protocol.SerializeHashContent(tooMuch.PubKey))
+		t.Error("Initial balance above the balance of the issuer accepted.")
+	}
+
+	//The initial balance can only be funded by the root account which signed the tx.
+	forged, _, _ := protocol.ConstrAccTx(0, 1, 1000, [32]byte{}, rootPrivKey, nil, nil)
+	forged.Issuer = [32]byte{'f', 'o', 'r', 'g', 'e', 'd'}
+	forged.Sig = [64]byte{}
+	forgedHash := forged.Hash()
+	copy(forged.Sig[:], ed25519.Sign(rootPrivKey, forgedHash[:]))
+	if verifyAccTx(forged) {
+		t.Error("AccTx with an initial balance funded by another account verified.")
+	}
+
+	if err := accStateChange([]*protocol.AccTx{forged}); err == nil {
+		storage.State.Delete(protocol.SerializeHashContent(forged.PubKey))
+		t.Error("Initial balance funded by a non-root account accepted.")
+	}
+}
+
 func TestConfigTxStateChange(t *testing.T) {
 	cleanAndPrepare()
 
@@ -173,7 +228,7 @@ func TestConfigTxStateChange(t *testing.T) {
 
 	parameterSet := *activeParameters
 	tmpLen := len(parameterSlice)
-	configStateChange(configs, [32]byte{'0', '1'})
+	configStateChange(configs, [32]byte{'0', '1'}, 1)
 	parameterSet2 := *activeParameters
 	if tmpLen != len(parameterSlice)-1 || reflect.DeepEqual(parameterSet, parameterSet2) {
 		t.Errorf("Config State Change malfunctioned: %v != %v\n", tmpLen, len(parameterSlice)-1)
@@ -204,7 +259,7 @@ func TestConfigTxStateChange(t *testing.T) {
 	configs2 = append(configs2, tx9)
 	configs2 = append(configs2, tx10)
 
-	configStateChange(configs2, [32]byte{})
+	configStateChange(configs2, [32]byte{}, 1)
 	if activeParameters.Block_size != 1000 ||
 		activeParameters.Diff_interval != 2000 ||
 		activeParameters.Fee_minimum != 3000 ||
@@ -223,11 +278,11 @@ func TestConfigTxStateChange(t *testing.T) {
 func TestConfigTxStateChangeUnknown(t *testing.T) {
 	cleanAndPrepare()
 
-	//Issuing configTxs with unknown Id
+	//Issuing configTxs with unknown Id (below the ids of the root key changes)
 	var configs []*protocol.ConfigTx
-	tx, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), 11, 1000, rand.Uint64(), 0, PrivKeyRoot)
-	tx2, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), 11, 2000, rand.Uint64(), 0, PrivKeyRoot)
-	tx3, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), 11, 3000, rand.Uint64(), 0, PrivKeyRoot)
+	tx, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), 99, 1000, rand.Uint64(), 0, PrivKeyRoot)
+	tx2, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), 99, 2000, rand.Uint64(), 0, PrivKeyRoot)
+	tx3, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), 99, 3000, rand.Uint64(), 0, PrivKeyRoot)
 
 	//save parameter state
 	tmpParameter := parameterSlice[len(parameterSlice)-1]
@@ -236,13 +291,13 @@ func TestConfigTxStateChangeUnknown(t *testing.T) {
 	configs = append(configs, tx2)
 	configs = append(configs, tx3)
 
-	configStateChange(configs, [32]byte{'0', '1'})
+	configStateChange(configs, [32]byte{'0', '1'}, 1)
 
 	if !reflect.DeepEqual(tmpParameter, *activeParameters) {
 		t.Error("Parameter state changed even though it shouldn't have.")
 	}
 
-	configStateChangeRollback(configs, [32]byte{'0', '1'})
+	configStateChangeRollback(configs, [32]byte{'0', '1'}, 1)
 
 	if !reflect.DeepEqual(tmpParameter, *activeParameters) {
 		t.Error("Parameter state changed even though it shouldn't have.")
@@ -252,20 +307,20 @@ func TestConfigTxStateChangeUnknown(t *testing.T) {
 	tx4, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), 2, 3000, rand.Uint64(), 0, PrivKeyRoot)
 	configs = append(configs, tx4)
 
-	configStateChange(configs, [32]byte{'0', '1'})
+	configStateChange(configs, [32]byte{'0', '1'}, 1)
 
 	if reflect.DeepEqual(tmpParameter, *activeParameters) {
 		t.Error("Parameter state changed even though it shouldn't have.")
 	}
 
-	configStateChangeRollback(configs, [32]byte{'0', '1'})
+	configStateChangeRollback(configs, [32]byte{'0', '1'}, 1)
 
 	if !reflect.DeepEqual(tmpParameter, *activeParameters) {
 		t.Error("Parameter state changed even though it shouldn't have.")
 	}
 
-	configStateChange(configs, [32]byte{'0', '1'})
-	configStateChangeRollback(configs, [32]byte{'0'})
+	configStateChange(configs, [32]byte{'0', '1'}, 1)
+	configStateChangeRollback(configs, [32]byte{'0'}, 2)
 	//Only change if block hashes match
 	if reflect.DeepEqual(tmpParameter, *activeParameters) {
 		t.Error("Parameter state changed even though it shouldn't have.")
@@ -280,7 +335,7 @@ func TestStakeTxStateChange(t *testing.T) {
 
 	accAHash := protocol.SerializeHashContent(accA.Address)
 
-	b := newBlock([32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b := newBlock([32]byte{}, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 1)
 	var stake, stake2 []*protocol.StakeTx
 
 	accA.IsStaking = false
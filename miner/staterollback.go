@@ -6,74 +6,132 @@ import (
 )
 
 func accStateChangeRollback(txSlice []*protocol.AccTx) {
-	for _, tx := range txSlice {
-		if tx.Header == 0 || tx.Header == 1 || tx.Header == 2 {
-			accHash := protocol.SerializeHashContent(tx.PubKey)
+	//Rollback in reverse order than original state change
+	for cnt := len(txSlice) - 1; cnt >= 0; cnt-- {
+		tx := txSlice[cnt]
+		accHash := protocol.SerializeHashContent(tx.PubKey)
 
-			acc, err := storage.GetAccount(accHash)
-			if err != nil {
+		switch tx.Header {
+		case 0, 1:
+			if _, err := storage.GetAccount(accHash); err != nil {
 				logger.Fatal("CRITICAL: An account that should have been saved does not exist.")
 			}
 
-			delete(storage.State, accHash)
+			storage.State.Delete(accHash)
+			delete(storage.RootKeys, accHash)
+
+			if tx.Amount > 0 {
+				if rootAcc, _ := storage.GetRootAccount(tx.Issuer); rootAcc != nil {
+					rootAcc.Balance += tx.Amount
+				}
+			}
+		case 2:
+			acc, isRoot := storage.ReadRemovedAccount(tx.Hash())
+			if acc == nil {
+				logger.Fatal("CRITICAL: An account that has been removed was not saved.")
+			}
 
-			switch tx.Header {
-			case 1:
-				delete(storage.RootKeys, accHash)
-			case 2:
+			//Recorded in the state transition, so the restore is reverted as well if the rollback fails.
+			storage.GetAccount(accHash)
+			storage.State.Set(accHash, acc)
+			if isRoot {
 				storage.RootKeys[accHash] = acc
 			}
+
+			if rootAcc, _ := storage.GetRootAccount(tx.Issuer); rootAcc != nil {
+				rootAcc.Balance -= acc.Balance
+			}
 		}
 	}
 }
 
-func fundsStateChangeRollback(txSlice []*protocol.FundsTx) {
+func fundsStateChangeRollback(txSlice []*protocol.FundsTx, height uint32) {
 	//Rollback in reverse order than original state change
 	for cnt := len(txSlice) - 1; cnt >= 0; cnt-- {
 		tx := txSlice[cnt]
 
-		accSender, _ := storage.GetAccount(tx.From)
-		accReceiver, _ := storage.GetAccount(tx.To)
+		if kind := delegationKind(tx, height); kind != 0 {
+			accSender, _ := storage.GetAccount(tx.From)
+			accReceiver, _ := storage.GetAccount(tx.To)
+			applyDelegationRollback(tx, kind, accSender, accReceiver)
+			continue
+		}
 
-		accSender.TxCnt -= 1
-		accSender.Balance += tx.Amount
-		accReceiver.Balance -= tx.Amount
+		//The accounts loaded by a contract call are restored as they were before the call (see callContract). This
+		//also reverts the transfer of the tx for the sender or receiver if they were loaded.
+		restored := storage.ReadContractStates(tx.Hash())
+		for hash, before := range restored {
+			if acc, _ := storage.GetAccount(hash); acc != nil {
+				*acc = *before
+			}
+		}
+
+		if _, exists := restored[tx.From]; !exists {
+			accSender, _ := storage.GetAccount(tx.From)
+			accSender.TxCnt -= 1
+			accSender.Balance += tx.Amount
+			accSender.Balance += tx.GasCost()
+		}
+		if _, exists := restored[tx.To]; !exists {
+			accReceiver, _ := storage.GetAccount(tx.To)
+			accReceiver.Balance -= tx.Amount
+		}
 
 		//If new coins were issued, revert
 		if rootAcc, _ := storage.GetRootAccount(tx.From); rootAcc != nil {
 			rootAcc.Balance -= tx.Amount
 			rootAcc.Balance -= tx.Fee
+			rootAcc.Balance -= tx.GasCost()
 		}
 	}
 }
 
-func aggregatedSenderStateRollback(txSlice []*protocol.AggTx) {
+func aggregatedSenderStateRollback(txSlice []*protocol.AggTx, height uint32) {
 	//Rollback in reverse order than original state change
+	for cnt := len(txSlice) - 1; cnt >= 0; cnt-- {
+		fundsStateChangeRollback(aggregatedFundsTxs(txSlice[cnt]), height)
+	}
+}
 
-	var fundsTxSlice []*protocol.FundsTx
+func iotStateChangeRollback(txSlice []*protocol.IotTx) {
+	//Rollback in reverse order than original state change
 	for cnt := len(txSlice) - 1; cnt >= 0; cnt-- {
 		tx := txSlice[cnt]
 
-		//accSender, _ := storage.GetAccount(tx.From)
-
-		//Adding all Aggregated FundsTx in reverse order.
-		for _, txHash := range tx.AggregatedTxSlice {
-			fundsTxSlice = append([]*protocol.FundsTx{storage.ReadClosedTx(txHash).(*protocol.FundsTx)},fundsTxSlice...)
+		accSender, _ := storage.GetAccount(tx.From)
+		accSender.TxCnt -= 1
 
+		//If new coins were issued, revert
+		if rootAcc, _ := storage.GetRootAccount(tx.From); rootAcc != nil {
+			rootAcc.Balance -= tx.Fee
 		}
+	}
+}
+
+func contractStateChangeRollback(txSlice []*protocol.ContractTx, minerHash [32]byte) {
+	//Rollback in reverse order than original state change
+	for cnt := len(txSlice) - 1; cnt >= 0; cnt-- {
+		tx := txSlice[cnt]
 
-		//do normal rollback for fundsTx
-		fundsStateChange(fundsTxSlice)
+		accSender, _ := storage.GetAccount(tx.From)
+		accSender.Balance += tx.Fee + tx.DeploymentGas()
+		accSender.TxCnt -= 1
 
-		//If new coins were issued, revert
-		//if rootAcc, _ := storage.GetRootAccount(tx.From); rootAcc != nil {
-		//	rootAcc.Balance -= tx.Amount
-		//	rootAcc.Balance -= tx.Fee
-		//}
+		minerAcc, _ := storage.GetAccount(minerHash)
+		minerAcc.Balance -= tx.Fee
+
+		contractHash := protocol.SerializeHashContent(protocol.ContractAddress(tx.From, tx.TxCnt))
+		if _, err := storage.GetAccount(contractHash); err != nil {
+			logger.Fatal("CRITICAL: A contract account that should have been saved does not exist.")
+		}
+		storage.State.Delete(contractHash)
 	}
 }
 
-func configStateChangeRollback(txSlice []*protocol.ConfigTx, blockHash [32]byte) {
+func configStateChangeRollback(txSlice []*protocol.ConfigTx, blockHash [32]byte, height uint32) {
+	//The parameters of the block at height are active again, even if it contained no config txs.
+	defer func() { activeParameters = parametersAt(height) }()
+
 	if len(txSlice) == 0 {
 		return
 	}
@@ -87,8 +145,7 @@ func configStateChangeRollback(txSlice []*protocol.ConfigTx, blockHash [32]byte)
 
 	//remove the latest entry in the parameters slice$
 	parameterSlice = parameterSlice[:len(parameterSlice)-1]
-	activeParameters = &parameterSlice[len(parameterSlice)-1]
-	logger.Printf("Config parameters rolled back. New configuration: %v", *activeParameters)
+	logger.Printf("Config parameters rolled back. New configuration: %v", *parametersAt(height))
 }
 
 func stakeStateChangeRollback(txSlice []*protocol.StakeTx) {
@@ -97,8 +154,12 @@ func stakeStateChangeRollback(txSlice []*protocol.StakeTx) {
 		tx := txSlice[cnt]
 
 		accSender, _ := storage.GetAccount(tx.Account)
-		//Rolling back stakingBlockHeight not needed
+		//Rolling back stakingBlockHeight not needed. The stake of a staking account is locked anyway, rolling back an
+		//unstaking resets the unbonding.
 		accSender.IsStaking = !accSender.IsStaking
+		if accSender.IsStaking {
+			accSender.UnbondingHeight = 0
+		}
 	}
 }
 
@@ -113,6 +174,7 @@ func collectTxFeesRollback(accTx []*protocol.AccTx, fundsTx []*protocol.FundsTx,
 
 	for _, tx := range fundsTx {
 		minerAcc.Balance -= tx.Fee
+		minerAcc.Balance -= tx.GasCost()
 
 		senderAcc, _ := storage.GetAccount(tx.From)
 		senderAcc.Balance += tx.Fee
@@ -148,7 +210,12 @@ func collectSlashRewardRollback(reward uint64, block *protocol.Block) {
 		slashedAcc, _ := storage.GetAccount(block.SlashedAddress)
 
 		minerAcc.Balance -= reward
-		slashedAcc.Balance += activeParameters.Staking_minimum
+		//Blocks validated before the burned stake was kept burned the staking minimum.
+		stake, exists := storage.ReadSlashedStake(block.Hash)
+		if !exists {
+			stake = parametersAt(block.Height).Staking_minimum
+		}
+		slashedAcc.Balance += stake
 		slashedAcc.IsStaking = true
 	}
 }
@@ -0,0 +1,130 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Blocks commit to the state after their txs with the state root (see protocol/statetrie.go), light clients verify
+//accounts against it with a proof (getAccountProof, p2p.ACCOUNT_PROOF_REQ). storage.State stays the working state, the tree is built from it.
+//The proposer applies the block to the state to compute the root and reverts the changes, validators check the root
+//after the state changes of the block. Blocks without a state root are only accepted up to the State_root_height,
+//e.g., blocks of chains started before it was introduced. The height is only set by the genesis of new chains (see
+//build-genesis) or by a config tx, by default the state root is optional.
+
+//Checks the state root of the block against the current state. Called at the end of validateState. The stored blocks
+//replayed at startup (initialSetup) were accepted before, they are not rejected for a missing state root.
+func stateRootCheck(block *protocol.Block, initialSetup bool) error {
+	//The state root is optional for the blocks up to the State_root_height, e.g., of chains started before it existed.
+	if block.StateRoot == [32]byte{} {
+		if !initialSetup && uint64(block.Height) > parametersAt(block.Height).State_root_height {
+			countRejectedBlock(REJECTED_STATE_ROOT)
+			return errors.New(fmt.Sprintf("Block at height %v does not commit to the state root.", block.Height))
+		}
+		return nil
+	}
+
+	trie := protocol.NewStateTrie(storage.State.Snapshot())
+	if root := trie.Root(); root != block.StateRoot {
+		countRejectedBlock(REJECTED_STATE_ROOT)
+		return errors.New(fmt.Sprintf("State root %x does not match the state after the block (%x).", block.StateRoot[0:8], root[0:8]))
+	}
+
+	//Kept to serve account proofs, see storage.ReadAccountProof.
+	storage.WriteStateTrie(block.Hash, trie)
+
+	return nil
+}
+
+//Returns the root of the state after the block, which needs to be built on top of the last block. The state is not
+//changed.
+func computeStateRoot(block *protocol.Block) (root [32]byte, err error) {
+	blockValidation.Lock()
+	defer blockValidation.Unlock()
+
+	if lastBlock == nil || block.PrevHash != lastBlock.Hash {
+		return root, errors.New("Block is not built on top of the last block anymore.")
+	}
+
+	data, err := openBlockData(block)
+	if err != nil {
+		return root, err
+	}
+
+	if err := applyState(data); err != nil {
+		return root, err
+	}
+	defer storage.RevertStateTransition()
+
+	return protocol.NewStateTrie(storage.State.Snapshot()).Root(), nil
+}
+
+//Collects the txs of a block that is being proposed from the mempool.
+func openBlockData(block *protocol.Block) (data blockData, err error) {
+	data.block = block
+	txs := func(hashes [][32]byte) (txs []protocol.Transaction, err error) {
+		for _, txHash := range hashes {
+			tx := storage.ReadOpenTx(txHash)
+			if tx == nil {
+				return nil, errors.New(fmt.Sprintf("Tx %x of the block is not in the mempool anymore.", txHash[0:8]))
+			}
+			txs = append(txs, tx)
+		}
+		return txs, nil
+	}
+
+	var accTxs, fundsTxs, configTxs, stakeTxs, aggTxs, iotTxs, contractTxs []protocol.Transaction
+	for _, slice := range []struct {
+		txs    *[]protocol.Transaction
+		hashes [][32]byte
+	}{
+		{&accTxs, block.AccTxData},
+		{&fundsTxs, block.FundsTxData},
+		{&configTxs, block.ConfigTxData},
+		{&stakeTxs, block.StakeTxData},
+		{&aggTxs, block.AggTxData},
+		{&iotTxs, block.IoTTxData},
+		{&contractTxs, block.ContractTxData},
+	} {
+		if *slice.txs, err = txs(slice.hashes); err != nil {
+			return data, err
+		}
+	}
+
+	for _, tx := range accTxs {
+		data.accTxSlice = append(data.accTxSlice, tx.(*protocol.AccTx))
+	}
+	for _, tx := range fundsTxs {
+		data.fundsTxSlice = append(data.fundsTxSlice, tx.(*protocol.FundsTx))
+	}
+	for _, tx := range configTxs {
+		data.configTxSlice = append(data.configTxSlice, tx.(*protocol.ConfigTx))
+	}
+	for _, tx := range stakeTxs {
+		data.stakeTxSlice = append(data.stakeTxSlice, tx.(*protocol.StakeTx))
+	}
+	for _, tx := range aggTxs {
+		data.aggTxSlice = append(data.aggTxSlice, tx.(*protocol.AggTx))
+	}
+	for _, tx := range iotTxs {
+		data.iotTxSlice = append(data.iotTxSlice, tx.(*protocol.IotTx))
+	}
+	for _, tx := range contractTxs {
+		data.contractTxSlice = append(data.contractTxSlice, tx.(*protocol.ContractTx))
+	}
+
+	return data, nil
+}
+
+//Returns the proof of the account with the address in the state after the block at the height. The trie of the last
+//block is built if it is not kept yet, e.g., after a restart. The caller needs to hold blockValidation.
+func accountProof(address [32]byte, height uint32) (*protocol.AccountProof, error) {
+	if height == lastBlock.Height && storage.ReadStateTrie(lastBlock.Hash) == nil {
+		storage.WriteStateTrie(lastBlock.Hash, protocol.NewStateTrie(storage.State.Snapshot()))
+	}
+
+	return storage.ReadAccountProof(address, height)
+}
@@ -0,0 +1,119 @@
+package miner
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestStateRoot(t *testing.T) {
+	sender := &protocol.Account{Address: [32]byte{'s', 'r', 's'}, Balance: 100}
+	receiver := &protocol.Account{Address: [32]byte{'s', 'r', 'r'}}
+	beneficiary := &protocol.Account{Address: [32]byte{'s', 'r', 'b'}, IsStaking: true}
+	for _, acc := range []*protocol.Account{sender, receiver, beneficiary} {
+		storage.State.Set(acc.Hash(), acc)
+		defer storage.State.Delete(acc.Hash())
+	}
+
+	tx := &protocol.FundsTx{Amount: 10, Fee: 1, From: sender.Hash(), To: receiver.Hash()}
+	storage.WriteOpenTx(tx)
+	defer storage.DeleteOpenTx(tx)
+
+	prevLastBlock := lastBlock
+	defer func() { lastBlock = prevLastBlock }()
+	lastBlock = &protocol.Block{Hash: [32]byte{'s', 'r'}, Height: 1}
+
+	block := newBlock(lastBlock.Hash, [32]byte{}, [crypto.COMM_KEY_LENGTH]byte{}, 2)
+	block.Beneficiary = beneficiary.Hash()
+	block.FundsTxData = [][32]byte{tx.Hash()}
+	block.NrFundsTx = 1
+
+	stateBefore := protocol.NewStateTrie(storage.State.Snapshot()).Root()
+	root, err := computeStateRoot(block)
+	if err != nil {
+		t.Fatalf("State root could not be computed: %v\n", err)
+	}
+	if protocol.NewStateTrie(storage.State.Snapshot()).Root() != stateBefore || sender.Balance != 100 || root == stateBefore {
+		t.Fatal("Computing the state root should not change the state.")
+	}
+
+	//Validators accept the block with the root of the state after it and reject it with another root.
+	data := blockData{fundsTxSlice: []*protocol.FundsTx{tx}, block: block}
+	block.StateRoot = stateBefore
+	if err := validateState(data, false); err == nil {
+		storage.RevertStateTransition()
+		t.Fatal("Block with a wrong state root should be rejected.")
+	}
+
+	//Blocks without a state root are only accepted up to the State_root_height, which is not set by default.
+	block.StateRoot = [32]byte{}
+	err = validateState(data, false)
+	storage.RevertStateTransition()
+	if err != nil {
+		t.Fatalf("Block without state root should be accepted without a State_root_height: %v\n", err)
+	}
+
+	parameterSlice[0].State_root_height = 0
+	defer func() { parameterSlice[0].State_root_height = STATE_ROOT_HEIGHT }()
+	if err := validateState(data, false); err == nil {
+		storage.RevertStateTransition()
+		t.Fatal("Block without state root should be rejected.")
+	}
+
+	//The stored blocks replayed at startup were accepted before.
+	err = validateState(data, true)
+	storage.RevertStateTransition()
+	if err != nil {
+		t.Fatalf("Stored block without state root should be accepted on replay: %v\n", err)
+	}
+
+	parameterSlice[0].State_root_height = uint64(block.Height)
+	err = validateState(data, false)
+	storage.RevertStateTransition()
+	parameterSlice[0].State_root_height = 0
+	if err != nil {
+		t.Fatalf("Block without state root below the State_root_height should be accepted: %v\n", err)
+	}
+
+	block.StateRoot = root
+	block.Hash = [32]byte{'s', 'r', 2}
+	if err := validateState(data, false); err != nil {
+		t.Fatalf("Block with the state root of the state after it should be accepted: %v\n", err)
+	}
+	storage.CommitStateTransition()
+	lastBlock = block
+	storage.WriteBlockHeight(block.Height, block.Hash)
+	defer storage.DeleteBlockHeight(block.Height)
+
+	//Light clients verify the accounts against the state root of a header.
+	receiverHash := receiver.Hash()
+	params, _ := json.Marshal(rpcGetAccountProofParams{Address: hex.EncodeToString(receiverHash[:])})
+	response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getAccountProof", Params: params, Id: 1})
+	if response.Error != nil {
+		t.Fatalf("Querying account proof failed: %v\n", response.Error)
+	}
+	result := response.Result.(rpcAccountProof)
+
+	encodedAcc, _ := hex.DecodeString(result.Account)
+	encodedProof, _ := hex.DecodeString(result.Proof)
+	var stateProof *protocol.StateProof
+	proof := &protocol.AccountProof{BlockHash: block.Hash, Height: result.Height, Account: encodedAcc}
+	if stateProof, _ = stateProof.Decode(encodedProof); stateProof != nil {
+		proof.Proof = *stateProof
+	}
+	acc, err := protocol.VerifyAccountProof(block, receiverHash, proof)
+	if err != nil || acc == nil || acc.Balance != 10 {
+		t.Errorf("Account proof does not verify against the state root: %v, %v\n", result, err)
+	}
+
+	//Proofs are served for the blocks whose state is kept.
+	height := uint32(1)
+	params, _ = json.Marshal(rpcGetAccountProofParams{Address: hex.EncodeToString(receiverHash[:]), Height: &height})
+	if response := processRPCRequest(&rpcRequest{JsonRPC: RPC_VERSION, Method: "getAccountProof", Params: params, Id: 1}); response.Error == nil {
+		t.Error("Account proof of a block whose state is not kept should fail.")
+	}
+}
@@ -0,0 +1,48 @@
+package miner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestIsUnlocked(t *testing.T) {
+	tx := &protocol.FundsTx{}
+	if !tx.IsUnlocked(0, 0) {
+		t.Error("Tx without lock should be unlocked.")
+	}
+
+	tx.LockUntil = 10
+	if tx.IsUnlocked(9, time.Now().Unix()) || !tx.IsUnlocked(10, 0) {
+		t.Error("Lock below the threshold should be a block height.")
+	}
+
+	tx.LockUntil = protocol.LOCK_TIME_THRESHOLD + 10
+	if tx.IsUnlocked(protocol.LOCK_TIME_THRESHOLD+20, protocol.LOCK_TIME_THRESHOLD+9) || !tx.IsUnlocked(0, protocol.LOCK_TIME_THRESHOLD+10) {
+		t.Error("Lock above the threshold should be a timestamp.")
+	}
+}
+
+func TestLockCheck(t *testing.T) {
+	block := &protocol.Block{Height: 5, Timestamp: time.Now().Unix()}
+	unlocked := &protocol.FundsTx{Amount: 1, LockUntil: 5}
+	locked := &protocol.FundsTx{Amount: 2, LockUntil: 6}
+	lockedByTime := &protocol.FundsTx{Amount: 3, LockUntil: uint64(block.Timestamp + 60)}
+
+	if err := lockCheck(block, []*protocol.FundsTx{unlocked}, nil); err != nil {
+		t.Errorf("Unlocked tx was rejected: %v\n", err)
+	}
+
+	if lockCheck(block, []*protocol.FundsTx{unlocked, locked}, nil) == nil || lockCheck(block, []*protocol.FundsTx{lockedByTime}, nil) == nil {
+		t.Error("Block including a locked tx should be rejected.")
+	}
+
+	if isLocked(block, unlocked) || !isLocked(block, locked) || !isLocked(block, lockedByTime) {
+		t.Error("Locked txs should be kept out of new blocks.")
+	}
+
+	if err := addFundsTx(block, locked); err == nil {
+		t.Error("Locked tx was added to the block.")
+	}
+}
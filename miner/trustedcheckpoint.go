@@ -0,0 +1,107 @@
+package miner
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//Operators can configure trusted checkpoints (block height and hash), e.g., published with a release. The blocks of a
+//chain up to a trusted checkpoint it passes through are only checked to be hash-chained to the checkpoint, their
+//commitment proofs and proofs of stake are not verified. This speeds up the sync of a new node considerably. The state
+//changes of these blocks are validated as usual. Chains conflicting with a trusted checkpoint are rejected.
+
+var (
+	trustedCheckpoints = make(map[uint32][32]byte)
+
+	//The height up to which the blocks currently being validated are covered by a trusted checkpoint, set during
+	//validation like uptodate.
+	trustedHeight uint32
+)
+
+//Parses a comma separated list of checkpoints in the format height:hash, e.g., "1000:3f2a...". An empty list removes
+//all checkpoints.
+func SetTrustedCheckpoints(checkpoints string) error {
+	parsed := make(map[uint32][32]byte)
+
+	for _, checkpoint := range strings.Split(checkpoints, ",") {
+		checkpoint = strings.TrimSpace(checkpoint)
+		if checkpoint == "" {
+			continue
+		}
+
+		parts := strings.Split(checkpoint, ":")
+		if len(parts) != 2 {
+			return errors.New(fmt.Sprintf("Invalid trusted checkpoint %v, expected height:hash.", checkpoint))
+		}
+
+		height, err := strconv.ParseUint(parts[0], 10, 32)
+		if err != nil || height == 0 {
+			return errors.New(fmt.Sprintf("Invalid height of trusted checkpoint %v.", checkpoint))
+		}
+
+		decoded, err := hex.DecodeString(parts[1])
+		if err != nil || len(decoded) != 32 {
+			return errors.New(fmt.Sprintf("Invalid hash of trusted checkpoint %v.", checkpoint))
+		}
+
+		if _, exists := parsed[uint32(height)]; exists {
+			return errors.New(fmt.Sprintf("Multiple trusted checkpoints at height %v.", height))
+		}
+
+		var hash [32]byte
+		copy(hash[:], decoded)
+		parsed[uint32(height)] = hash
+	}
+
+	trustedCheckpoints = parsed
+
+	return nil
+}
+
+//Returns the height of the highest trusted checkpoint among the blocks, 0 if there is none. The blocks must form a
+//chain, the blocks below the checkpoint are its ancestors then.
+func trustedCheckpointHeight(blocks []*protocol.Block) (height uint32, err error) {
+	for _, block := range blocks {
+		hash, exists := trustedCheckpoints[block.Height]
+		if !exists {
+			continue
+		}
+
+		//Aggregated blocks are only identified by their hash without tx.
+		if block.Hash != hash && block.HashWithoutTx != hash {
+			countRejectedBlock(REJECTED_CHECKPOINT)
+			return 0, errors.New(fmt.Sprintf("Block (%x) conflicts with the trusted checkpoint at height %v (%x).", block.Hash[0:8], block.Height, hash[0:8]))
+		}
+
+		if block.Height > height {
+			height = block.Height
+		}
+	}
+
+	return height, nil
+}
+
+//Headers of light clients and blocks covered by a trusted checkpoint are only linked to the chain by their hashes,
+//which therefore have to match the content.
+func verifyBlockHashes(block *protocol.Block) error {
+	if binary.BigEndian.Uint64(block.Nonce[:]) != uint64(block.Timestamp) {
+		return errors.New("Nonce does not match the timestamp.")
+	}
+
+	hash, hashWithoutTx := blockHashes(block)
+	if hashWithoutTx != block.HashWithoutTx {
+		return errors.New("Hash without tx is incorrect.")
+	}
+
+	if !block.Aggregated && hash != block.Hash {
+		return errors.New("Hash is incorrect.")
+	}
+
+	return nil
+}
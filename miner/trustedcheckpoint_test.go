@@ -0,0 +1,130 @@
+package miner
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"golang.org/x/crypto/sha3"
+)
+
+func TestSetTrustedCheckpoints(t *testing.T) {
+	defer SetTrustedCheckpoints("")
+
+	hash := protocol.SerializeHashContent("checkpoint")
+	if err := SetTrustedCheckpoints(fmt.Sprintf("10:%x, 20:%x", hash, hash)); err != nil {
+		t.Fatalf("Valid trusted checkpoints were rejected: %v\n", err)
+	}
+	if len(trustedCheckpoints) != 2 || trustedCheckpoints[10] != hash || trustedCheckpoints[20] != hash {
+		t.Errorf("Trusted checkpoints not parsed: %v\n", trustedCheckpoints)
+	}
+
+	for _, invalid := range []string{
+		"10",
+		"0:" + hex.EncodeToString(hash[:]),
+		"x:" + hex.EncodeToString(hash[:]),
+		"10:" + hex.EncodeToString(hash[:31]),
+		fmt.Sprintf("10:%x,10:%x", hash, hash),
+	} {
+		if err := SetTrustedCheckpoints(invalid); err == nil {
+			t.Errorf("Invalid trusted checkpoints %v were accepted.\n", invalid)
+		}
+	}
+}
+
+func TestTrustedCheckpointHeight(t *testing.T) {
+	defer SetTrustedCheckpoints("")
+
+	chain := []*protocol.Block{
+		{Height: 1, Hash: [32]byte{0x01}},
+		{Height: 2, Hash: [32]byte{0x02}, HashWithoutTx: [32]byte{0x12}},
+		{Height: 3, Hash: [32]byte{0x03}},
+	}
+
+	SetTrustedCheckpoints(fmt.Sprintf("2:%x,5:%x", [32]byte{0x12}, [32]byte{0x05}))
+	if height, err := trustedCheckpointHeight(chain); err != nil || height != 2 {
+		t.Errorf("Trusted checkpoint not found in the chain: %v, %v\n", height, err)
+	}
+
+	SetTrustedCheckpoints(fmt.Sprintf("3:%x", [32]byte{0x04}))
+	if _, err := trustedCheckpointHeight(chain); err == nil {
+		t.Error("Chain conflicting with a trusted checkpoint was accepted.")
+	}
+}
+
+func TestPreValidateTrustedBlock(t *testing.T) {
+	prevUptodate := uptodate
+	defer func() { trustedHeight, uptodate = 0, prevUptodate }()
+	uptodate = false
+
+	defer storeValidatorSet(0)
+	acc := &protocol.Account{Address: [32]byte{0x0b}, Balance: 1000, IsStaking: true}
+	storage.State.Set(acc.Hash(), acc)
+	defer storage.State.Delete(acc.Hash())
+	//The proposer needs to be part of the validator set of the block's epoch.
+	storeValidatorSet(0)
+
+	//The commitment proof is forged, the block is only accepted below a trusted checkpoint if its hashes are correct.
+	b := &protocol.Block{PrevHash: [32]byte{0x01}, Height: 100, Beneficiary: acc.Hash(), Timestamp: time.Now().Unix()}
+	binary.BigEndian.PutUint64(b.Nonce[:], uint64(b.Timestamp))
+	copy(b.CommitmentProof[:], protocol.RandomBytesWithLength(crypto.COMM_PROOF_LENGTH))
+	b.Hash, b.HashWithoutTx = blockHashes(b)
+
+	if _, _, _, _, _, _, _, err := preValidate(context.Background(), b, false); err == nil {
+		t.Error("Forged commitment proof was accepted without a trusted checkpoint.")
+	}
+
+	trustedHeight = b.Height
+	if _, _, _, _, _, _, _, err := preValidate(context.Background(), b, false); err != nil {
+		t.Errorf("Block covered by a trusted checkpoint was rejected: %v\n", err)
+	}
+
+	b.Hash = [32]byte{0x01}
+	if _, _, _, _, _, _, _, err := preValidate(context.Background(), b, false); err == nil {
+		t.Error("Block with an incorrect hash was accepted below a trusted checkpoint.")
+	}
+}
+
+//The hashes of the blocks we produce have to pass the verification, otherwise they are rejected as orphans or below
+//trusted checkpoints.
+func TestVerifyFinalizedBlockHashes(t *testing.T) {
+	cleanAndPrepare()
+
+	b := newBlock(genesisBlock.Hash, genesisBlock.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	if err := finalizeBlock(b); err != nil {
+		t.Fatalf("Block finalization failed: %v\n", err)
+	}
+
+	if err := verifyBlockHashes(b); err != nil {
+		t.Errorf("Hashes of a finalized block rejected: %v\n", err)
+	}
+
+	//Up to the Block_hash_height, the hashes are computed as by the earlier versions.
+	legacy := *b
+	legacy.Timestamp, legacy.CommitmentProof = 0, [crypto.COMM_KEY_LENGTH]byte{}
+	if partialHash := legacy.HashBlock(); sha3.Sum256(append(b.Nonce[:], partialHash[:]...)) != b.Hash {
+		t.Error("Hash of a block up to the Block_hash_height covers the timestamp and commitment proof.")
+	}
+
+	parameterSlice[0].Block_hash_height = 0
+	defer func() { parameterSlice[0].Block_hash_height = BLOCK_HASH_HEIGHT }()
+
+	b.Hash, b.HashWithoutTx = blockHashes(b)
+	if b.Hash == legacy.Hash {
+		t.Error("Hash of a block above the Block_hash_height does not cover the timestamp and commitment proof.")
+	}
+	if err := verifyBlockHashes(b); err != nil {
+		t.Errorf("Hashes of a finalized block above the Block_hash_height rejected: %v\n", err)
+	}
+
+	b.CommitmentProof[0]++
+	if err := verifyBlockHashes(b); err == nil {
+		t.Error("Hashes not covering the commitment proof accepted.")
+	}
+}
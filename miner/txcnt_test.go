@@ -0,0 +1,57 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestTxCntCheck(t *testing.T) {
+	sender := [32]byte{'t', 'x', 'c', 'n', 't'}
+	storage.State.Set(sender, &protocol.Account{Address: sender, TxCnt: 3})
+	defer storage.State.Delete(sender)
+
+	fundsTx := func(txCnt uint32) *protocol.FundsTx {
+		return &protocol.FundsTx{Amount: 1, Fee: 1, TxCnt: txCnt, From: sender}
+	}
+	iotTx := &protocol.IotTx{TxCnt: 4, From: sender}
+
+	//Order within the block doesn't matter.
+	if err := txCntCheck([]*protocol.FundsTx{fundsTx(5), fundsTx(3)}, nil, []*protocol.IotTx{iotTx}, nil); err != nil {
+		t.Errorf("Gapless txCnts should be accepted: %v\n", err)
+	}
+
+	//Replay of an already used txCnt.
+	if err := txCntCheck([]*protocol.FundsTx{fundsTx(2)}, nil, nil, nil); err == nil {
+		t.Error("Txs with txCnt lower than the state should be rejected.")
+	}
+
+	//Gap in the sequence.
+	if err := txCntCheck([]*protocol.FundsTx{fundsTx(3), fundsTx(5)}, nil, nil, nil); err == nil {
+		t.Error("Txs with gap in txCnt should be rejected.")
+	}
+
+	//Same txCnt twice.
+	if err := txCntCheck([]*protocol.FundsTx{fundsTx(3), fundsTx(4)}, nil, []*protocol.IotTx{iotTx}, nil); err == nil {
+		t.Error("Txs using the same txCnt twice should be rejected.")
+	}
+}
+
+func TestIsOutOfOrder(t *testing.T) {
+	sender := [32]byte{'o', 'r', 'd', 'e', 'r'}
+	block := new(protocol.Block)
+	block.StateCopy = map[[32]byte]*protocol.Account{sender: {Address: sender, TxCnt: 2}}
+
+	if isOutOfOrder(block, &protocol.FundsTx{TxCnt: 1, From: sender}) {
+		t.Error("Tx with lower txCnt is not out of order but invalid.")
+	}
+
+	if isOutOfOrder(block, &protocol.FundsTx{TxCnt: 2, From: sender}) {
+		t.Error("Tx with matching txCnt is not out of order.")
+	}
+
+	if !isOutOfOrder(block, &protocol.IotTx{TxCnt: 3, From: sender}) {
+		t.Error("Tx with higher txCnt should be out of order.")
+	}
+}
@@ -0,0 +1,102 @@
+package miner
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Blocks list their txs in a canonical order, so the block built from a set of open txs is reproducible and validators
+//reject blocks of which the proposer reordered txs. Funds, IoT and contract txs must stay in the txCnt order of their
+//sender, they are ordered by their position in the sender's sequence first: all txs next in line come first, then the
+//ones after them and so on. Within the same position, and for all other txs, txs with a higher fee come first, ties are
+//broken by the tx hash.
+//Agg txs are not ordered, their order follows from the aggregation of the proposer (see splitSortedAggregatableTransactions).
+
+type txOrderKey struct {
+	//False for txs without txCnt (acc, config, stake and agg txs).
+	ordered  bool
+	sequence uint32
+	fee      uint64
+	hash     [32]byte
+}
+
+//The sequence is relative to the txCnt of the sender before the block, i.e., the state the block is built on.
+func newTxOrderKey(tx protocol.Transaction) txOrderKey {
+	key := txOrderKey{fee: tx.TxFee(), hash: tx.Hash()}
+
+	var sender [32]byte
+	var txCnt uint32
+	if sender, txCnt, key.ordered = orderedTxCnt(tx); key.ordered {
+		key.sequence = txCnt
+		if acc := storage.State.Get(sender); acc != nil {
+			if txCnt >= acc.TxCnt {
+				key.sequence = txCnt - acc.TxCnt
+			} else {
+				key.sequence = 0
+			}
+		}
+	}
+
+	return key
+}
+
+func (key txOrderKey) less(other txOrderKey) bool {
+	if key.sequence != other.sequence {
+		return key.sequence < other.sequence
+	}
+
+	if key.fee != other.fee {
+		return key.fee > other.fee
+	}
+
+	return bytes.Compare(key.hash[:], other.hash[:]) < 0
+}
+
+//Checks that the txs of each type are listed in canonical order in the block.
+func txOrderCheck(accTxSlice []*protocol.AccTx, fundsTxSlice []*protocol.FundsTx, configTxSlice []*protocol.ConfigTx, stakeTxSlice []*protocol.StakeTx, iotTxSlice []*protocol.IotTx, contractTxSlice []*protocol.ContractTx) error {
+	var accTxs, fundsTxs, configTxs, stakeTxs, iotTxs, contractTxs []protocol.Transaction
+	for _, tx := range accTxSlice {
+		accTxs = append(accTxs, tx)
+	}
+	for _, tx := range fundsTxSlice {
+		fundsTxs = append(fundsTxs, tx)
+	}
+	for _, tx := range configTxSlice {
+		configTxs = append(configTxs, tx)
+	}
+	for _, tx := range stakeTxSlice {
+		stakeTxs = append(stakeTxs, tx)
+	}
+	for _, tx := range iotTxSlice {
+		iotTxs = append(iotTxs, tx)
+	}
+	for _, tx := range contractTxSlice {
+		contractTxs = append(contractTxs, tx)
+	}
+
+	for _, slice := range []struct {
+		name string
+		txs  []protocol.Transaction
+	}{
+		{"Account", accTxs},
+		{"Funds", fundsTxs},
+		{"Config", configTxs},
+		{"Stake", stakeTxs},
+		{"IoT", iotTxs},
+		{"Contract", contractTxs},
+	} {
+		var prevKey txOrderKey
+		for i, tx := range slice.txs {
+			key := newTxOrderKey(tx)
+			if i > 0 && key.less(prevKey) {
+				return errors.New(fmt.Sprintf("%v transactions are not in canonical order: %x listed after %x.", slice.name, key.hash[0:8], prevKey.hash[0:8]))
+			}
+			prevKey = key
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package miner
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestSortOpenTxs(t *testing.T) {
+	sender := &protocol.Account{Address: [32]byte{0x71}, TxCnt: 5}
+	storage.State.Set([32]byte{0x71}, sender)
+	defer storage.State.Delete([32]byte{0x71})
+
+	//The second tx of the sender pays the highest fee but has to wait for the first one.
+	first := newMempoolTestTx([32]byte{0x71}, 5, 1)
+	second := newMempoolTestTx([32]byte{0x71}, 6, 10)
+	other := newMempoolTestTx([32]byte{0x72}, 0, 3)
+	accTx := &protocol.AccTx{Fee: 1, PubKey: [32]byte{0x73}}
+	configTx := &protocol.ConfigTx{Fee: 2, Id: 1}
+
+	txs := []protocol.Transaction{second, first, accTx, other, configTx}
+	sortOpenTxs(txs)
+
+	expected := []protocol.Transaction{configTx, accTx, other, first, second}
+	for i := range expected {
+		if txs[i] != expected[i] {
+			t.Fatalf("Tx %v not in canonical order: %v\n", i, txs[i])
+		}
+	}
+
+	if err := txOrderCheck(nil, []*protocol.FundsTx{other, first, second}, nil, nil, nil, nil); err != nil {
+		t.Errorf("Canonical order rejected: %v\n", err)
+	}
+
+	if err := txOrderCheck(nil, []*protocol.FundsTx{first, other, second}, nil, nil, nil, nil); err == nil {
+		t.Error("Lower fee listed first accepted.\n")
+	}
+
+	if err := txOrderCheck(nil, []*protocol.FundsTx{second, first, other}, nil, nil, nil, nil); err == nil {
+		t.Error("Txs of the sender not in txCnt order accepted.\n")
+	}
+}
+
+func TestTxOrderTieBreak(t *testing.T) {
+	tx1 := &protocol.ConfigTx{Fee: 2, Id: 1}
+	tx2 := &protocol.ConfigTx{Fee: 2, Id: 2}
+	hash1, hash2 := tx1.Hash(), tx2.Hash()
+	if hash2[0] < hash1[0] {
+		tx1, tx2 = tx2, tx1
+	}
+
+	if err := txOrderCheck(nil, nil, []*protocol.ConfigTx{tx1, tx2}, nil, nil, nil); err != nil {
+		t.Errorf("Equal fees not ordered by hash: %v\n", err)
+	}
+	if err := txOrderCheck(nil, nil, []*protocol.ConfigTx{tx2, tx1}, nil, nil, nil); err == nil {
+		t.Error("Equal fees in reverse hash order accepted.\n")
+	}
+
+	//The most common sender is the one with the lowest address if several are equally common.
+	for i := 0; i < 10; i++ {
+		max, address := getMaxKeyAndValueFormMap(map[[32]byte]uint32{{0x03}: 2, {0x01}: 2, {0x02}: 2, {0x04}: 1})
+		if max != 2 || address != [32]byte{0x01} {
+			t.Fatalf("Most common key not deterministic: %x (%v)\n", address[0:1], max)
+		}
+	}
+}
+
+//Blocks up to the Tx_order_height are not checked for the order of their txs, by default no block is.
+func TestTxOrderActivation(t *testing.T) {
+	cleanAndPrepare()
+	defer func() { parameterSlice[0].Tx_order_height = TX_ORDER_HEIGHT }()
+
+	first := newMempoolTestTx([32]byte{0x74}, 0, 1)
+	other := newMempoolTestTx([32]byte{0x75}, 0, 3)
+	for _, tx := range []*protocol.FundsTx{first, other} {
+		storage.WriteOpenTx(tx)
+		defer storage.DeleteOpenTx(tx)
+	}
+
+	b := newBlock(genesisBlock.Hash, genesisBlock.HashWithoutTx, [crypto.COMM_KEY_LENGTH]byte{}, 1)
+	b.FundsTxData = [][32]byte{first.Hash(), other.Hash()}
+	b.NrFundsTx = 2
+	b.Timestamp = time.Now().Unix()
+
+	countBefore := GetRejectedBlockCount(REJECTED_TX_ORDER)
+	for _, height := range []uint64{TX_ORDER_HEIGHT, 1} {
+		parameterSlice[0].Tx_order_height = height
+		preValidate(context.Background(), b, false)
+		if GetRejectedBlockCount(REJECTED_TX_ORDER) != countBefore {
+			t.Errorf("Order of a block up to the Tx_order_height %v checked.\n", height)
+		}
+	}
+
+	parameterSlice[0].Tx_order_height = 0
+	if _, _, _, _, _, _, _, err := preValidate(context.Background(), b, false); err == nil || GetRejectedBlockCount(REJECTED_TX_ORDER) != countBefore+1 {
+		t.Errorf("Block with reordered txs above the Tx_order_height not rejected: %v\n", err)
+	}
+}
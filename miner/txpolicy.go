@@ -0,0 +1,351 @@
+package miner
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"io"
+	"os"
+	"plugin"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+//Tx policies let operators apply their own rules (e.g., for compliance) to the transactions they accept into their
+//mempool and include in their blocks, without forking the miner. Policies are local: they are never applied when
+//validating blocks of other miners, consensus must not depend on them.
+//A policy is either a Go plugin or a rules file interpreted by the built-in rules engine (see parsePolicyRules).
+
+const (
+	POLICY_STAGE_ADMISSION = "admission"
+	POLICY_STAGE_BLOCK     = "block"
+)
+
+type PolicyVerdict struct {
+	Reject      bool
+	Reason      string
+	Annotations []string
+}
+
+//Implemented by operator-supplied policies. Check is called for every transaction at mempool admission and again
+//when the transaction is about to be included in a block.
+type TxPolicy interface {
+	Check(tx protocol.Transaction, stage string) PolicyVerdict
+}
+
+var (
+	txPolicies     []TxPolicy
+	txPoliciesLock = &sync.Mutex{}
+)
+
+func RegisterTxPolicy(policy TxPolicy) {
+	txPoliciesLock.Lock()
+	defer txPoliciesLock.Unlock()
+
+	txPolicies = append(txPolicies, policy)
+}
+
+func ClearTxPolicies() {
+	txPoliciesLock.Lock()
+	defer txPoliciesLock.Unlock()
+
+	txPolicies = nil
+}
+
+//Loads a policy compiled with "go build -buildmode=plugin". The plugin needs to export a function
+//"func NewTxPolicy() miner.TxPolicy".
+func LoadTxPolicyPlugin(filename string) error {
+	p, err := plugin.Open(filename)
+	if err != nil {
+		return errors.New(fmt.Sprintf("Could not open policy plugin %v: %v", filename, err))
+	}
+
+	symbol, err := p.Lookup("NewTxPolicy")
+	if err != nil {
+		return errors.New(fmt.Sprintf("Policy plugin %v does not export NewTxPolicy: %v", filename, err))
+	}
+
+	newTxPolicy, ok := symbol.(func() TxPolicy)
+	if !ok {
+		return errors.New(fmt.Sprintf("NewTxPolicy of policy plugin %v has the wrong signature.", filename))
+	}
+
+	RegisterTxPolicy(newTxPolicy())
+
+	return nil
+}
+
+//Runs all registered policies. The first rejecting policy decides, the annotations of all policies are collected.
+func applyTxPolicies(tx protocol.Transaction, stage string) (annotations []string, err error) {
+	txPoliciesLock.Lock()
+	defer txPoliciesLock.Unlock()
+
+	for _, policy := range txPolicies {
+		verdict := policy.Check(tx, stage)
+		annotations = append(annotations, verdict.Annotations...)
+		if verdict.Reject {
+			txHash := tx.Hash()
+			logger.Printf("Tx (%x) rejected by policy at %v: %v\n", txHash[0:8], stage, verdict.Reason)
+			return annotations, errors.New(fmt.Sprintf("Rejected by policy: %v", verdict.Reason))
+		}
+	}
+
+	return annotations, nil
+}
+
+//Built-in rules engine. A rules file contains one rule per line, lines starting with # are comments. A rule consists
+//of an action followed by conditions, all of which need to match:
+//
+//	reject type=funds fee<5
+//	reject from=<hex encoded address>
+//	annotate:large-transfer amount>=1000000
+//	accept stage=block
+//
+//Actions are accept, reject and annotate:<label>. The first matching accept or reject rule decides, annotate rules
+//only add their label. Transactions not matching any accept or reject rule are accepted.
+//Conditions have the form <field><op><value> with the operators =, !=, <, >, <= and >=. The fields type (funds, acc,
+//config, stake, agg, iot), from, to (hex encoded addresses) and stage (admission, block) support = and != only, the
+//numeric fields fee, amount, size (encoded bytes) and txcnt support all operators.
+
+type RulesPolicy struct {
+	rules []policyRule
+}
+
+type policyRule struct {
+	action     string
+	label      string
+	conditions []policyCondition
+}
+
+type policyCondition struct {
+	field string
+	op    string
+	value string
+	num   uint64
+}
+
+var (
+	policyOperators     = []string{"!=", "<=", ">=", "=", "<", ">"}
+	policyStringFields  = map[string]bool{"type": true, "from": true, "to": true, "stage": true}
+	policyNumericFields = map[string]bool{"fee": true, "amount": true, "size": true, "txcnt": true}
+)
+
+func NewRulesPolicy(filename string) (*RulesPolicy, error) {
+	filehandle, err := os.Open(filename)
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("Could not open policy rules: %v", err))
+	}
+	defer filehandle.Close()
+
+	return parsePolicyRules(filehandle)
+}
+
+func parsePolicyRules(reader io.Reader) (*RulesPolicy, error) {
+	policy := new(RulesPolicy)
+	scanner := bufio.NewScanner(reader)
+
+	for lineNr := 1; scanner.Scan(); lineNr++ {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		rule, err := parsePolicyRule(strings.Fields(line))
+		if err != nil {
+			return nil, errors.New(fmt.Sprintf("Invalid policy rule in line %v: %v", lineNr, err))
+		}
+		policy.rules = append(policy.rules, rule)
+	}
+
+	return policy, scanner.Err()
+}
+
+func parsePolicyRule(tokens []string) (rule policyRule, err error) {
+	switch {
+	case tokens[0] == "accept" || tokens[0] == "reject":
+		rule.action = tokens[0]
+	case strings.HasPrefix(tokens[0], "annotate:") && len(tokens[0]) > len("annotate:"):
+		rule.action = "annotate"
+		rule.label = strings.TrimPrefix(tokens[0], "annotate:")
+	default:
+		return rule, errors.New(fmt.Sprintf("Unknown action: %v", tokens[0]))
+	}
+
+	for _, token := range tokens[1:] {
+		condition, err := parsePolicyCondition(token)
+		if err != nil {
+			return rule, err
+		}
+		rule.conditions = append(rule.conditions, condition)
+	}
+
+	return rule, nil
+}
+
+func parsePolicyCondition(token string) (condition policyCondition, err error) {
+	for _, op := range policyOperators {
+		if i := strings.Index(token, op); i > 0 {
+			condition = policyCondition{field: strings.ToLower(token[:i]), op: op, value: token[i+len(op):]}
+			break
+		}
+	}
+
+	if condition.op == "" {
+		return condition, errors.New(fmt.Sprintf("Missing operator: %v", token))
+	}
+
+	switch {
+	case policyStringFields[condition.field]:
+		if condition.op != "=" && condition.op != "!=" {
+			return condition, errors.New(fmt.Sprintf("Operator %v not supported for %v.", condition.op, condition.field))
+		}
+		if condition.field == "from" || condition.field == "to" {
+			address, err := hex.DecodeString(condition.value)
+			if err != nil || len(address) != 32 {
+				return condition, errors.New(fmt.Sprintf("Invalid address: %v", condition.value))
+			}
+			condition.value = strings.ToLower(condition.value)
+		}
+	case policyNumericFields[condition.field]:
+		if condition.num, err = strconv.ParseUint(condition.value, 10, 64); err != nil {
+			return condition, errors.New(fmt.Sprintf("Invalid number: %v", condition.value))
+		}
+	default:
+		return condition, errors.New(fmt.Sprintf("Unknown field: %v", condition.field))
+	}
+
+	return condition, nil
+}
+
+func (p *RulesPolicy) Check(tx protocol.Transaction, stage string) (verdict PolicyVerdict) {
+	for _, rule := range p.rules {
+		if !rule.matches(tx, stage) {
+			continue
+		}
+
+		switch rule.action {
+		case "annotate":
+			verdict.Annotations = append(verdict.Annotations, rule.label)
+		case "reject":
+			verdict.Reject = true
+			verdict.Reason = rule.String()
+			return verdict
+		case "accept":
+			return verdict
+		}
+	}
+
+	return verdict
+}
+
+func (rule policyRule) matches(tx protocol.Transaction, stage string) bool {
+	for _, condition := range rule.conditions {
+		if !condition.matches(tx, stage) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (condition policyCondition) matches(tx protocol.Transaction, stage string) bool {
+	if policyStringFields[condition.field] {
+		var value string
+		switch condition.field {
+		case "type":
+			value = rpcTxType(tx)
+		case "from":
+			from := policyTxFrom(tx)
+			value = hex.EncodeToString(from[:])
+		case "to":
+			to := policyTxTo(tx)
+			value = hex.EncodeToString(to[:])
+		case "stage":
+			value = stage
+		}
+
+		return (value == condition.value) == (condition.op == "=")
+	}
+
+	var value uint64
+	switch condition.field {
+	case "fee":
+		value = tx.TxFee()
+	case "amount":
+		value = policyTxAmount(tx)
+	case "size":
+		value = uint64(len(tx.Encode()))
+	case "txcnt":
+		_, txCnt, _ := orderedTxCnt(tx)
+		value = uint64(txCnt)
+	}
+
+	switch condition.op {
+	case "=":
+		return value == condition.num
+	case "!=":
+		return value != condition.num
+	case "<":
+		return value < condition.num
+	case ">":
+		return value > condition.num
+	case "<=":
+		return value <= condition.num
+	case ">=":
+		return value >= condition.num
+	}
+
+	return false
+}
+
+func (rule policyRule) String() string {
+	action := rule.action
+	if rule.action == "annotate" {
+		action += ":" + rule.label
+	}
+
+	conditions := []string{action}
+	for _, condition := range rule.conditions {
+		conditions = append(conditions, condition.field+condition.op+condition.value)
+	}
+
+	return strings.Join(conditions, " ")
+}
+
+func policyTxFrom(tx protocol.Transaction) [32]byte {
+	switch tx.(type) {
+	case *protocol.FundsTx:
+		return tx.(*protocol.FundsTx).From
+	case *protocol.IotTx:
+		return tx.(*protocol.IotTx).From
+	case *protocol.ContractTx:
+		return tx.(*protocol.ContractTx).From
+	case *protocol.StakeTx:
+		return tx.(*protocol.StakeTx).Account
+	}
+
+	return tx.Sender()
+}
+
+func policyTxTo(tx protocol.Transaction) [32]byte {
+	switch tx.(type) {
+	case *protocol.IotTx:
+		return tx.(*protocol.IotTx).To
+	}
+
+	return tx.Receiver()
+}
+
+func policyTxAmount(tx protocol.Transaction) uint64 {
+	switch tx.(type) {
+	case *protocol.FundsTx:
+		return tx.(*protocol.FundsTx).Amount
+	case *protocol.AggTx:
+		return tx.(*protocol.AggTx).Amount
+	}
+
+	return 0
+}
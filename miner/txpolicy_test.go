@@ -0,0 +1,94 @@
+package miner
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestParsePolicyRules(t *testing.T) {
+	blocked := [32]byte{'b', 'l', 'o', 'c', 'k', 'e', 'd'}
+	rules := `
+#Comments and empty lines are ignored
+
+annotate:large amount>=1000
+reject from=` + hex.EncodeToString(blocked[:]) + `
+accept type=funds fee>=5
+reject type=funds stage=admission
+`
+	policy, err := parsePolicyRules(strings.NewReader(rules))
+	if err != nil {
+		t.Fatalf("Parsing policy rules failed: %v\n", err)
+	}
+
+	verdict := policy.Check(&protocol.FundsTx{Amount: 5000, Fee: 5}, POLICY_STAGE_ADMISSION)
+	if verdict.Reject || len(verdict.Annotations) != 1 || verdict.Annotations[0] != "large" {
+		t.Errorf("Tx should be accepted and annotated: %+v\n", verdict)
+	}
+
+	verdict = policy.Check(&protocol.FundsTx{Amount: 1, Fee: 5, From: blocked}, POLICY_STAGE_ADMISSION)
+	if !verdict.Reject || len(verdict.Annotations) != 0 {
+		t.Errorf("Tx from blocked sender should be rejected: %+v\n", verdict)
+	}
+
+	verdict = policy.Check(&protocol.FundsTx{Amount: 1, Fee: 1}, POLICY_STAGE_ADMISSION)
+	if !verdict.Reject || verdict.Reason != "reject type=funds stage=admission" {
+		t.Errorf("Tx with low fee should be rejected at admission: %+v\n", verdict)
+	}
+
+	verdict = policy.Check(&protocol.FundsTx{Amount: 1, Fee: 1}, POLICY_STAGE_BLOCK)
+	if verdict.Reject {
+		t.Errorf("Tx not matching any rule should be accepted: %+v\n", verdict)
+	}
+
+	verdict = policy.Check(&protocol.IotTx{Fee: 1, From: blocked}, POLICY_STAGE_BLOCK)
+	if !verdict.Reject {
+		t.Errorf("IoT tx from blocked sender should be rejected: %+v\n", verdict)
+	}
+}
+
+func TestParsePolicyRulesInvalid(t *testing.T) {
+	invalid := []string{
+		"drop type=funds",
+		"annotate: fee>1",
+		"reject fee",
+		"reject color=red",
+		"reject fee>abc",
+		"reject type<funds",
+		"reject from=xyz",
+	}
+
+	for _, rule := range invalid {
+		if _, err := parsePolicyRules(strings.NewReader(rule)); err == nil {
+			t.Errorf("Invalid rule was accepted: %v\n", rule)
+		}
+	}
+}
+
+func TestTxPolicyAdmission(t *testing.T) {
+	policy, _ := parsePolicyRules(strings.NewReader("annotate:cheap fee<10\nreject fee<2"))
+	RegisterTxPolicy(policy)
+	defer ClearTxPolicies()
+
+	pool := NewMempool(MEMPOOL_DEFAULT_SIZE)
+	rejected := &protocol.FundsTx{Amount: 1, Fee: 1, From: [32]byte{'p'}}
+	accepted := &protocol.FundsTx{Amount: 1, Fee: 5, From: [32]byte{'p'}}
+
+	if err := pool.AddOrdered(rejected); err == nil || pool.Get(rejected.Hash()) != nil {
+		t.Error("Tx rejected by policy should not enter the mempool.")
+	}
+
+	if err := pool.AddOrdered(accepted); err != nil {
+		t.Errorf("Tx accepted by policy should enter the mempool: %v\n", err)
+	}
+
+	if annotations := pool.Annotations(accepted.Hash()); len(annotations) != 1 || annotations[0] != "cheap" {
+		t.Errorf("Tx should have been annotated by the policy: %v\n", annotations)
+	}
+
+	if pool.Stats().Rejected != 1 {
+		t.Errorf("Rejected tx should be counted: %+v\n", pool.Stats())
+	}
+}
@@ -0,0 +1,86 @@
+package miner
+
+import (
+	"bytes"
+	"container/heap"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"sort"
+)
+
+//If there are more open txs than fit into a block, the txs paying the highest fee per byte are selected, the others
+//wait for the next block. Txs bound to the txCnt of their sender can only be selected after the sender's previous txs,
+//so only the txs without txCnt and the next tx of each sender are selectable. Once a tx is selected, the following tx of
+//its sender becomes selectable. The selected txs are then added to the block in canonical order (see txorder.go).
+
+//Max-heap of the selectable txs ordered by fee per byte, ties are broken by the tx hash.
+type selectionHeap []*mempoolEntry
+
+//Returns at most capacity txs, all of them if they fit.
+func selectTxs(txs []protocol.Transaction, capacity int) []protocol.Transaction {
+	if len(txs) <= capacity {
+		return txs
+	}
+
+	//The txs of each sender in txCnt order, the first one is selectable.
+	queues := make(map[[32]byte][]*mempoolEntry)
+	var selectable selectionHeap
+	for _, tx := range txs {
+		entry := newMempoolEntry(tx)
+		if sender, _, ordered := orderedTxCnt(tx); ordered {
+			queues[sender] = append(queues[sender], entry)
+		} else {
+			selectable = append(selectable, entry)
+		}
+	}
+
+	for sender, queue := range queues {
+		sort.Slice(queue, func(i, j int) bool {
+			_, txCntI, _ := orderedTxCnt(queue[i].tx)
+			_, txCntJ, _ := orderedTxCnt(queue[j].tx)
+			return txCntI < txCntJ
+		})
+		selectable = append(selectable, queue[0])
+		queues[sender] = queue[1:]
+	}
+	heap.Init(&selectable)
+
+	selected := make([]protocol.Transaction, 0, capacity)
+	for len(selected) < capacity && selectable.Len() > 0 {
+		entry := heap.Pop(&selectable).(*mempoolEntry)
+		selected = append(selected, entry.tx)
+
+		if sender, _, ordered := orderedTxCnt(entry.tx); ordered && len(queues[sender]) > 0 {
+			heap.Push(&selectable, queues[sender][0])
+			queues[sender] = queues[sender][1:]
+		}
+	}
+
+	return selected
+}
+
+func (h selectionHeap) Len() int {
+	return len(h)
+}
+
+func (h selectionHeap) Less(i, j int) bool {
+	if h[i].feePerByte != h[j].feePerByte {
+		return h[i].feePerByte > h[j].feePerByte
+	}
+
+	return bytes.Compare(h[i].hash[:], h[j].hash[:]) < 0
+}
+
+func (h selectionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+}
+
+func (h *selectionHeap) Push(x interface{}) {
+	*h = append(*h, x.(*mempoolEntry))
+}
+
+func (h *selectionHeap) Pop() interface{} {
+	old := *h
+	entry := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return entry
+}
@@ -0,0 +1,60 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func selected(txs []protocol.Transaction, tx protocol.Transaction) bool {
+	for _, selectedTx := range txs {
+		if selectedTx == tx {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestSelectTxsByFee(t *testing.T) {
+	var txs []protocol.Transaction
+	for fee := uint64(1); fee <= 4; fee++ {
+		txs = append(txs, newMempoolTestTx([32]byte{byte(fee)}, 0, fee))
+	}
+
+	if all := selectTxs(txs, 4); len(all) != 4 {
+		t.Errorf("Txs fitting into the block were dropped: %v\n", len(all))
+	}
+
+	result := selectTxs(txs, 2)
+	if len(result) != 2 || !selected(result, txs[3]) || !selected(result, txs[2]) {
+		t.Errorf("Txs paying the highest fees not selected: %v\n", result)
+	}
+
+	//A large tx pays less per byte than a small one with a lower fee.
+	large := newMempoolTestTx([32]byte{0x10}, 0, 5)
+	large.Data = make([]byte, 500)
+	small := newMempoolTestTx([32]byte{0x11}, 0, 4)
+	if result := selectTxs([]protocol.Transaction{large, small}, 1); result[0] != small {
+		t.Errorf("Tx with the higher fee per byte not selected: %v\n", result[0])
+	}
+}
+
+func TestSelectTxsSenderOrder(t *testing.T) {
+	cheap := newMempoolTestTx([32]byte{0x20}, 0, 1)
+	expensive := newMempoolTestTx([32]byte{0x20}, 1, 100)
+	other := newMempoolTestTx([32]byte{0x21}, 0, 50)
+	configTx := &protocol.ConfigTx{Fee: 10, Id: 1}
+	txs := []protocol.Transaction{expensive, other, cheap, configTx}
+
+	//The expensive tx can only be included after the cheap one of the same sender.
+	result := selectTxs(txs, 2)
+	if len(result) != 2 || !selected(result, other) || !selected(result, configTx) {
+		t.Errorf("Unexpected selection: %v\n", result)
+	}
+
+	result = selectTxs(txs, 3)
+	if len(result) != 3 || !selected(result, cheap) || selected(result, expensive) {
+		t.Errorf("Tx selected before the previous tx of its sender: %v\n", result)
+	}
+}
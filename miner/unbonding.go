@@ -0,0 +1,24 @@
+package miner
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//A validator's stake (the staking minimum of its balance) cannot be spent while it is staking. After a StakeTx
+//stopped the staking, the stake stays locked for another Unbonding_period blocks, so a validator cannot move its stake
+//away right after producing conflicting blocks, before the slashing proof is included. The unbonding ends at the
+//account's UnbondingHeight, which is set by the StakeTx (see stakeStateChange).
+
+//Returns the part of the account's balance that cannot be spent in the block at height.
+func lockedStake(acc *protocol.Account, height uint32) uint64 {
+	if !acc.IsStaking && height >= acc.UnbondingHeight {
+		return 0
+	}
+
+	return parametersAt(height).Staking_minimum
+}
+
+//Returns the height at which the stake of an account unstaking in the block at height is unlocked.
+func unbondingHeight(height uint32) uint32 {
+	return height + uint32(parametersAt(height).Unbonding_period)
+}
@@ -0,0 +1,76 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestLockedStake(t *testing.T) {
+	prevParameterSlice := parameterSlice
+	defer func() { parameterSlice = prevParameterSlice }()
+
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	parameterSlice[0].Staking_minimum = 100
+	parameterSlice[0].Unbonding_period = 10
+
+	acc := &protocol.Account{Balance: 150, IsStaking: true}
+	if locked := lockedStake(acc, 5); locked != 100 {
+		t.Errorf("Expected the stake of a staking account to be locked, got %v\n", locked)
+	}
+
+	//Unstaking at height 5.
+	acc.IsStaking = false
+	acc.UnbondingHeight = unbondingHeight(5)
+	if locked := lockedStake(acc, 14); locked != 100 {
+		t.Errorf("Expected the stake to be locked during the unbonding, got %v\n", locked)
+	}
+	if locked := lockedStake(acc, 15); locked != 0 {
+		t.Errorf("Expected the stake to be unlocked after the unbonding, got %v\n", locked)
+	}
+}
+
+func TestUnbondingStateChange(t *testing.T) {
+	prevParameterSlice := parameterSlice
+	defer func() { parameterSlice = prevParameterSlice }()
+
+	parameterSlice = []Parameters{NewDefaultParameters()}
+	parameterSlice[0].Staking_minimum = 100
+	parameterSlice[0].Unbonding_period = 10
+
+	from := &protocol.Account{Address: [32]byte{'u', 'n', 'b', 'o', 'n', 'd'}, Balance: 150, IsStaking: true}
+	to := &protocol.Account{Address: [32]byte{'t', 'o'}}
+	fromHash, toHash := from.Hash(), to.Hash()
+	storage.State.Set(fromHash, from)
+	storage.State.Set(toHash, to)
+	defer storage.State.Delete(fromHash)
+	defer storage.State.Delete(toHash)
+
+	if err := stakeStateChange([]*protocol.StakeTx{{Account: fromHash, IsStaking: false}}, 5); err != nil {
+		t.Fatalf("Unstaking failed: %v\n", err)
+	}
+	if from.UnbondingHeight != 15 {
+		t.Errorf("Expected the unbonding to end at height 15, got %v\n", from.UnbondingHeight)
+	}
+
+	//The stake cannot be spent during the unbonding.
+	tx := &protocol.FundsTx{Amount: 100, Fee: 1, From: fromHash, To: toHash}
+	if err := fundsStateChange([]*protocol.FundsTx{tx}, 10); err == nil {
+		t.Error("Tx spending the stake during the unbonding was accepted.")
+	}
+
+	block := &protocol.Block{Height: 10, StateCopy: make(map[[32]byte]*protocol.Account)}
+	if err := addFundsTx(block, tx); err == nil {
+		t.Error("Tx spending the stake during the unbonding was added to the block.")
+	}
+
+	if err := fundsStateChange([]*protocol.FundsTx{tx}, 15); err != nil {
+		t.Errorf("Tx spending the stake after the unbonding was rejected: %v\n", err)
+	}
+
+	stakeStateChangeRollback([]*protocol.StakeTx{{Account: fromHash, IsStaking: false}})
+	if !from.IsStaking || from.UnbondingHeight != 0 {
+		t.Errorf("Unstaking not rolled back: %v\n", from)
+	}
+}
@@ -10,3 +10,12 @@ func InvertBlockArray(array []*protocol.Block) []*protocol.Block {
 	}
 	return array
 }
+
+func containsBlock(array []*protocol.Block, hash [32]byte) bool {
+	for _, block := range array {
+		if block.Hash == hash {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,74 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//The proposers of the blocks of an epoch are validated against the validator set determined after the last block of
+//the previous epoch (see protocol/validatorset.go), not against the current state. Staking, unstaking and balance
+//changes within an epoch take effect in the next one, so the sortition of an epoch is known when it starts. The sets
+//are persisted, rolling back the last block of an epoch deletes the set determined after it.
+
+const VALIDATOR_EPOCH_LENGTH = 100 //Blocks
+
+//Returns the height of the last block of the previous epoch of the block at height, the validator set of the block's
+//epoch is determined after it.
+func validatorSetHeight(height uint32) uint32 {
+	if height == 0 {
+		return 0
+	}
+
+	return (height - 1) / VALIDATOR_EPOCH_LENGTH * VALIDATOR_EPOCH_LENGTH
+}
+
+//Returns the staking accounts with at least the staking minimum, except the ones penalized for missing their blocks
+//(see liveness.go). The stake delegated to a validator adds to its balance. The state needs to be the state after the block at height. The liveness evidence of the epoch
+//ending at height is passed if it is not stored yet, nil otherwise.
+func computeValidatorSet(height uint32, evidence *protocol.LivenessEvidence) *protocol.ValidatorSet {
+	minimum := parametersAt(height + 1).Staking_minimum
+
+	var validators []*protocol.Validator
+	for hash, acc := range storage.State.Snapshot() {
+		if acc.IsStaking && acc.Balance >= minimum && !isLivenessPenalized(hash, height, evidence) {
+			validators = append(validators, &protocol.Validator{Address: hash, Balance: acc.Balance + acc.DelegatedStake(), CommitmentKey: acc.CommitmentKey})
+		}
+	}
+
+	return protocol.NewValidatorSet(height, validators)
+}
+
+//Stores the validator set of the next epoch if the block at height was the epoch's last block.
+func storeValidatorSet(height uint32) error {
+	batch := storage.NewBatch()
+	writeValidatorSet(batch, height, nil)
+
+	return batch.Commit()
+}
+
+//Like storeValidatorSet, the set is written with the block though (see postValidate). Without the set, none of the
+//blocks of the next epoch could be validated.
+func writeValidatorSet(batch *storage.Batch, height uint32, evidence *protocol.LivenessEvidence) {
+	if height%VALIDATOR_EPOCH_LENGTH != 0 {
+		return
+	}
+
+	batch.WriteValidatorSet(computeValidatorSet(height, evidence))
+}
+
+//Returns the validator of the block at height with the given address.
+func getValidator(address [32]byte, height uint32) (*protocol.Validator, error) {
+	set := storage.ReadValidatorSet(validatorSetHeight(height))
+	if set == nil {
+		return nil, errors.New(fmt.Sprintf("No validator set for the epoch of height %v.", height))
+	}
+
+	validator := set.Get(address)
+	if validator == nil {
+		return nil, errors.New("Validator is not part of the validator set.")
+	}
+
+	return validator, nil
+}
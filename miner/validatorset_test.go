@@ -0,0 +1,50 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestValidatorSetHeight(t *testing.T) {
+	for height, expected := range map[uint32]uint32{0: 0, 1: 0, VALIDATOR_EPOCH_LENGTH: 0, VALIDATOR_EPOCH_LENGTH + 1: VALIDATOR_EPOCH_LENGTH} {
+		if setHeight := validatorSetHeight(height); setHeight != expected {
+			t.Errorf("Expected the validator set of height %v to be determined at %v, got %v\n", height, expected, setHeight)
+		}
+	}
+}
+
+func TestValidatorSetEpoch(t *testing.T) {
+	defer storage.DeleteValidatorSet(VALIDATOR_EPOCH_LENGTH)
+
+	acc := &protocol.Account{Address: [32]byte{'e', 'p', 'o', 'c', 'h'}, Balance: activeParameters.Staking_minimum, IsStaking: true}
+	storage.State.Set(acc.Hash(), acc)
+	defer storage.State.Delete(acc.Hash())
+
+	//Only the last block of an epoch determines the next validator set.
+	storeValidatorSet(VALIDATOR_EPOCH_LENGTH - 1)
+	if _, err := getValidator(acc.Hash(), VALIDATOR_EPOCH_LENGTH+1); err == nil {
+		t.Fatal("Validator set stored within an epoch.")
+	}
+
+	if err := storeValidatorSet(VALIDATOR_EPOCH_LENGTH); err != nil {
+		t.Fatalf("Validator set could not be stored: %v\n", err)
+	}
+
+	//Balance changes and unstaking within the epoch do not affect the set.
+	acc.Balance, acc.IsStaking = 1, false
+	validator, err := getValidator(acc.Hash(), VALIDATOR_EPOCH_LENGTH+1)
+	if err != nil || validator.Balance != activeParameters.Staking_minimum {
+		t.Errorf("Validator not found in the epoch's set: %v, %v\n", validator, err)
+	}
+	if _, err := getValidator(acc.Hash(), 2*VALIDATOR_EPOCH_LENGTH+1); err == nil {
+		t.Error("Validator set of the next epoch found before it was determined.")
+	}
+
+	//Accounts below the staking minimum are not part of the set.
+	acc.Balance, acc.IsStaking = activeParameters.Staking_minimum-1, true
+	if computeValidatorSet(VALIDATOR_EPOCH_LENGTH, nil).Get(acc.Hash()) != nil {
+		t.Error("Validator below the staking minimum is part of the set.")
+	}
+}
@@ -1,11 +1,12 @@
 package miner
 
 import (
+	"errors"
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"github.com/bazo-blockchain/bazo-miner/logging"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
 	"github.com/bazo-blockchain/bazo-miner/storage"
-	"golang.org/x/crypto/ed25519"
 	"math/big"
 )
 
@@ -29,6 +30,8 @@ func verify(tx protocol.Transaction) bool {
 		verified = verifyAggTx(tx.(*protocol.AggTx))
 	case *protocol.IotTx:
 		verified = verifyIotTx(tx.(*protocol.IotTx))
+	case *protocol.ContractTx:
+		verified = verifyContractTx(tx.(*protocol.ContractTx))
 	}
 
 	return verified
@@ -39,14 +42,21 @@ func verifyIotTx(tx *protocol.IotTx) bool {
 		return false
 	}
 
+	//Compressed data must decompress within the size limit, otherwise readers of the tx would fail or run out of memory.
+	//Encrypted data can not be read, only its size is checked.
+	if _, err := tx.Payload(); err != nil {
+		logger.Printf("%v\n", err)
+		return false
+	}
+
 	//fundsTx only makes sense if amount > 0
 	//if tx.Amount == 0 || tx.Amount > MAX_MONEY {
 	//	logger.Printf("Invalid transaction amount: %v\n", tx.Amount)
 	//	return false
 	//}
 	//Check if accounts are present in the actual state
-	accFrom := storage.State[tx.From]
-	accTo := storage.State[tx.To]
+	accFrom := storage.State.Get(tx.From)
+	accTo := storage.State.Get(tx.To)
 	//Accounts non existent
 	if accTo == nil || accFrom == nil {
 		//logger.Printf("Account non existent. From: %v\nTo: %v\n", accFrom, accTo)
@@ -57,14 +67,12 @@ func verifyIotTx(tx *protocol.IotTx) bool {
 	copy(tx.From[:], accFromHash[:]);
 	copy(tx.To[:], accToHash[:]);
 	txHash := tx.Hash()
-	pubKey := accFrom.Address[:]
-	if ed25519.Verify(pubKey, txHash[:], tx.Sig[:]) && tx.From != tx.To {
+	if verifySignature(accFrom.Address, txHash, tx.Sig) && tx.From != tx.To {
 		tx.From = protocol.SerializeHashContent(accFrom.Address);
 		tx.To = protocol.SerializeHashContent(accTo.Address);
 		return true
 	} else {
-		logger.Printf("Sig invalid. FromHash: %x\nToHash: %x\n", accFromHash[0:8], accToHash[0:8])
-		FileConnectionsLog.WriteString(fmt.Sprintf("Sig invalid. FromHash: %x\nToHash: %x\n", accFromHash[0:8], accToHash[0:8]))
+		logger.WithFields(logging.Fields{"txhash": txHash, "from": accFromHash, "to": accToHash}).Warnf("Sig invalid.")
 		return false
 	}
 }
@@ -74,14 +82,23 @@ func verifyAccTx(tx *protocol.AccTx) bool {
 		return false
 	}
 
+	if (tx.Threshold > 0 || len(tx.Cosigners) > 0) && !verifyCosigners(tx) {
+		return false
+	}
+
+	if tx.Device != nil && !verifyDevice(tx) {
+		return false
+	}
+
 	for _, rootAcc := range storage.RootKeys {
 
-		pubKey := crypto.GetPubKeyFromAddressED(rootAcc.Address)
 		txHash := tx.Hash()
 
 		//Only the hash of the pubkey is hashed and verified here
-		if ed25519.Verify(pubKey, txHash[:], tx.Sig[:]) == true {
-			return true
+		if verifySignature(rootAcc.Address, txHash, tx.Sig) == true {
+			//The initial balance is funded by the issuer and the balance of a removed account is swept to it, so it must
+			//be the root account that signed the tx.
+			return (tx.Amount == 0 && tx.Header != 2) || protocol.SerializeHashContent(rootAcc.Address) == tx.Issuer
 		}
 	}
 
@@ -93,21 +110,42 @@ func verifyConfigTx(tx *protocol.ConfigTx) bool {
 		return false
 	}
 
-	//account creation can only be done with a valid priv/pub key which is hard-coded
-	r, s := new(big.Int), new(big.Int)
+	//The signature and the cosignatures have to be from Config_quorum distinct root keys.
+	txHash := tx.Hash()
+	signers := make(map[[32]byte]bool)
 
-	r.SetBytes(tx.Sig[:32])
-	s.SetBytes(tx.Sig[32:])
+	for rootHash, rootAcc := range storage.RootKeys {
+		if verifySignature(rootAcc.Address, txHash, tx.Sig) == true {
+			signers[rootHash] = true
+			break
+		}
+	}
 
-	for _, rootAcc := range storage.RootKeys {
-		pubKey := crypto.GetPubKeyFromAddressED(rootAcc.Address)
-		txHash := tx.Hash()
-		if ed25519.Verify(pubKey, txHash[:], tx.Sig[:]) == true {
-			return true
+	if len(signers) == 0 {
+		return false
+	}
+
+	for _, cosig := range tx.Cosigs {
+		rootAcc := storage.RootKeys[cosig.Root]
+		if rootAcc == nil || signers[cosig.Root] {
+			continue
+		}
+
+		if verifySignature(rootAcc.Address, txHash, cosig.Sig) {
+			signers[cosig.Root] = true
 		}
 	}
 
-	return false
+	if uint64(len(signers)) < activeParameters.Config_quorum {
+		return false
+	}
+
+	//The quorum can not exceed the number of root keys.
+	if tx.Id == protocol.CONFIG_QUORUM_ID && tx.Payload > uint64(len(storage.RootKeys)) {
+		return false
+	}
+
+	return true
 }
 
 func verifyStakeTx(tx *protocol.StakeTx) bool {
@@ -117,7 +155,7 @@ func verifyStakeTx(tx *protocol.StakeTx) bool {
 	}
 
 	//Check if account is present in the actual state
-	acc := storage.State[tx.Account]
+	acc := storage.State.Get(tx.Account)
 	if acc == nil {
 		// TODO: Requires a Mutex?
 		newAcc := protocol.NewAccount(tx.Account, [32]byte{}, 0, false, [crypto.COMM_KEY_LENGTH]byte{}, nil, nil)
@@ -134,27 +172,65 @@ func verifyStakeTx(tx *protocol.StakeTx) bool {
 
 	txHash := tx.Hash()
 
-	pubKey := crypto.GetPubKeyFromAddressED(acc.Address)
-
-	return ed25519.Verify(pubKey, txHash[:], tx.Sig[:])
+	return verifySignature(acc.Address, txHash, tx.Sig)
 }
 
+//AggTxs are not signed by whoever aggregated them. Instead, an aggTx is authenticated by the fundsTxs it aggregates:
+//all of them have to be known and signed by their senders, and the aggTx must match the one derived from them (amount,
+//fee, senders and receivers), so its hash commits to the aggregated txs. A forged aggTx fails at least one of these checks.
 func verifyAggTx(tx *protocol.AggTx) bool {
 	if tx == nil {
 		logger.Println("Transactions does not exist.")
 		return false
 	}
 
-	//Check if accounts are existent
-	//accSender, err := storage.GetAccount(tx.From)
-	//if tx.From //!= protocol.SerializeHashContent(accSender.Address) || tx.To == nil || err != nil {
-	//	logger.Printf("Account non existent. From: %v\nTo: %v\n%v", tx.From, tx.To, err)
-	//	return false
-	//}
+	if err := verifyAggregation(tx); err != nil {
+		logger.WithField("txhash", tx.Hash()).Warnf("%v", err)
+		return false
+	}
 
 	return true
 }
 
+func verifyAggregation(tx *protocol.AggTx) error {
+	if len(tx.AggregatedTxSlice) < 2 {
+		return errors.New(fmt.Sprintf("AggTx aggregates %v txs, at least 2 required.", len(tx.AggregatedTxSlice)))
+	}
+
+	var fundsTxs []*protocol.FundsTx
+	aggregated := make(map[[32]byte]bool)
+	for _, txHash := range tx.AggregatedTxSlice {
+		if aggregated[txHash] {
+			return errors.New(fmt.Sprintf("AggTx aggregates tx %x more than once.", txHash[0:8]))
+		}
+		aggregated[txHash] = true
+
+		trx := storage.ReadOpenTx(txHash)
+		if trx == nil {
+			trx = storage.ReadClosedTx(txHash)
+		}
+
+		fundsTx, ok := trx.(*protocol.FundsTx)
+		if !ok {
+			return errors.New(fmt.Sprintf("Aggregated tx %x is not a known fundsTx.", txHash[0:8]))
+		}
+		if !verifyFundsTx(fundsTx) {
+			return errors.New(fmt.Sprintf("Aggregated tx %x could not be verified.", txHash[0:8]))
+		}
+		fundsTxs = append(fundsTxs, fundsTx)
+	}
+
+	derived, err := aggregate(fundsTxs)
+	if err != nil {
+		return err
+	}
+	if derived.Hash() != tx.Hash() {
+		return errors.New("AggTx does not match its aggregated txs.")
+	}
+
+	return nil
+}
+
 func verifyFundsTx(tx *protocol.FundsTx) bool {
 	if tx == nil {
 		return false
@@ -165,9 +241,16 @@ func verifyFundsTx(tx *protocol.FundsTx) bool {
 		logger.Printf("Invalid transaction amount: %v\n", tx.Amount)
 		return false
 	}
+
+	//Gas is only bought at a price, free gas would allow unbounded contract executions.
+	if tx.HasGas() && (tx.GasLimit == 0 || tx.GasPrice == 0 || tx.GasLimit > MAX_MONEY/tx.GasPrice) {
+		logger.Printf("Invalid transaction gas: %v (limit) at %v (price)\n", tx.GasLimit, tx.GasPrice)
+		return false
+	}
+
 	//Check if accounts are present in the actual state
-	accFrom := storage.State[tx.From]
-	accTo := storage.State[tx.To]
+	accFrom := storage.State.Get(tx.From)
+	accTo := storage.State.Get(tx.To)
 
 	//Accounts non existent
 	if accFrom == nil || accTo == nil {
@@ -179,18 +262,107 @@ func verifyFundsTx(tx *protocol.FundsTx) bool {
 
 	txHash := tx.Hash()
 
-	pubKey := crypto.GetPubKeyFromAddressED(accFrom.Address)
 	tx.From = accFromHash
 	tx.To = accToHash
-	if ed25519.Verify(pubKey, txHash[:], tx.Sig[:]) && tx.From != tx.To {
+
+	if accFrom.IsMultiSig() {
+		if verifyCosignatures(accFrom, txHash, tx.Cosigs) && tx.From != tx.To {
+			return true
+		}
+		logger.WithFields(logging.Fields{"txhash": txHash, "from": accFromHash, "to": accToHash}).Warnf("Cosignatures invalid.")
+		return false
+	}
+
+	if len(tx.Cosigs) > 0 {
+		logger.WithFields(logging.Fields{"txhash": txHash, "from": accFromHash}).Warnf("Cosignatures for an account without cosigners.")
+		return false
+	}
+
+	if verifySignature(accFrom.Address, txHash, tx.Sig) && tx.From != tx.To {
 		return true
 	} else {
-		logger.Printf("Sig invalid. FromHash: %x\nToHash: %x\n", accFromHash[0:8], accToHash[0:8])
-		FileConnectionsLog.WriteString(fmt.Sprintf("Sig invalid. FromHash: %x\nToHash: %x\n", tx.From[0:8], tx.To[0:8]))
+		logger.WithFields(logging.Fields{"txhash": txHash, "from": accFromHash, "to": accToHash}).Warnf("Sig invalid.")
 		return false
 	}
 }
 
+func verifyContractTx(tx *protocol.ContractTx) bool {
+	if tx == nil {
+		return false
+	}
+
+	if len(tx.Contract) == 0 {
+		logger.Printf("Contract tx %x without contract.\n", tx.Hash())
+		return false
+	}
+
+	//The gas limit is signed, the deployer is never charged more than agreed to.
+	if tx.DeploymentGas() > tx.GasLimit {
+		logger.Printf("Deployment gas %v exceeds the gas limit %v.\n", tx.DeploymentGas(), tx.GasLimit)
+		return false
+	}
+
+	accFrom := storage.State.Get(tx.From)
+	if accFrom == nil {
+		logger.Printf("Account non existent. From: %x\n", tx.From)
+		return false
+	}
+
+	//Contract txs carry a single signature, multi-signature accounts can not deploy contracts.
+	if accFrom.IsMultiSig() {
+		return false
+	}
+
+	txHash := tx.Hash()
+	if !verifySignature(accFrom.Address, txHash, tx.Sig) {
+		logger.WithFields(logging.Fields{"txhash": txHash, "from": tx.From}).Warnf("Sig invalid.")
+		return false
+	}
+
+	return true
+}
+
+//Multi-signature accounts can not be root accounts, their address is not a public key a root could sign with.
+func verifyCosigners(tx *protocol.AccTx) bool {
+	if tx.Header != 0 || tx.Threshold == 0 || int(tx.Threshold) > len(tx.Cosigners) || len(tx.Cosigners) > protocol.MAX_COSIGNERS {
+		logger.Printf("Invalid threshold %v for %v cosigners.\n", tx.Threshold, len(tx.Cosigners))
+		return false
+	}
+
+	cosigners := make(map[[32]byte]bool)
+	for _, cosigner := range tx.Cosigners {
+		if cosigners[cosigner] {
+			logger.Printf("Cosigner %x appears twice.\n", cosigner[0:8])
+			return false
+		}
+		cosigners[cosigner] = true
+	}
+
+	return tx.PubKey == protocol.MultiSigAddress(tx.Threshold, tx.Cosigners)
+}
+
+//Returns true if at least the account's threshold of distinct cosigners signed the tx hash. Every cosignature has to be
+//valid, so a tx can not be padded with garbage.
+func verifyCosignatures(acc *protocol.Account, txHash [32]byte, cosigs []protocol.Cosignature) bool {
+	if len(cosigs) < int(acc.Threshold) || len(cosigs) > len(acc.Cosigners) {
+		return false
+	}
+
+	signed := make(map[uint8]bool)
+	for _, cosig := range cosigs {
+		if int(cosig.Index) >= len(acc.Cosigners) || signed[cosig.Index] {
+			return false
+		}
+
+		if !verifySignature(acc.Cosigners[cosig.Index], txHash, cosig.Sig) {
+			return false
+		}
+		signed[cosig.Index] = true
+	}
+
+	return true
+}
+
 //Returns true if id is in the list of possible ids and rational value for payload parameter.
 //Some values just don't make any sense and have to be restricted accordingly
 func parameterBoundsChecking(id uint8, payload uint64) bool {
@@ -235,6 +407,70 @@ func parameterBoundsChecking(id uint8, payload uint64) bool {
 		if payload >= protocol.MIN_SLASHING_REWARD && payload <= protocol.MAX_SLASHING_REWARD {
 			return true
 		}
+	case protocol.BLOCK_TRIGGER_TXS_ID:
+		if payload >= protocol.MIN_BLOCK_TRIGGER_TXS && payload <= protocol.MAX_BLOCK_TRIGGER_TXS {
+			return true
+		}
+	case protocol.BLOCK_TRIGGER_TIME_ID:
+		if payload >= protocol.MIN_BLOCK_TRIGGER_TIME && payload <= protocol.MAX_BLOCK_TRIGGER_TIME {
+			return true
+		}
+	case protocol.IOT_RATE_CAPACITY_ID:
+		if payload >= protocol.MIN_IOT_RATE_CAPACITY && payload <= protocol.MAX_IOT_RATE_CAPACITY {
+			return true
+		}
+	case protocol.IOT_RATE_REFILL_ID:
+		if payload >= protocol.MIN_IOT_RATE_REFILL && payload <= protocol.MAX_IOT_RATE_REFILL {
+			return true
+		}
+	case protocol.UNBONDING_PERIOD_ID:
+		if payload >= protocol.MIN_UNBONDING_PERIOD && payload <= protocol.MAX_UNBONDING_PERIOD {
+			return true
+		}
+	case protocol.RSA_COMMITMENT_DEADLINE_ID:
+		if payload >= protocol.MIN_RSA_COMMITMENT_DEADLINE && payload <= protocol.MAX_RSA_COMMITMENT_DEADLINE {
+			return true
+		}
+	case protocol.SLASH_FRACTION_ID:
+		if payload >= protocol.MIN_SLASH_FRACTION && payload <= protocol.MAX_SLASH_FRACTION {
+			return true
+		}
+	case protocol.CONFIG_QUORUM_ID:
+		if payload >= protocol.MIN_CONFIG_QUORUM && payload <= protocol.MAX_CONFIG_QUORUM {
+			return true
+		}
+	case protocol.CONFIG_ACTIVATION_DELAY_ID:
+		if payload >= protocol.MIN_CONFIG_ACTIVATION_DELAY && payload <= protocol.MAX_CONFIG_ACTIVATION_DELAY {
+			return true
+		}
+	case protocol.DIFF_EMA_WINDOW_ID:
+		if payload >= protocol.MIN_DIFF_EMA_WINDOW && payload <= protocol.MAX_DIFF_EMA_WINDOW {
+			return true
+		}
+	case protocol.STATE_ROOT_HEIGHT_ID:
+		if payload >= protocol.MIN_STATE_ROOT_HEIGHT && payload <= protocol.MAX_STATE_ROOT_HEIGHT {
+			return true
+		}
+	case protocol.TX_ORDER_HEIGHT_ID:
+		if payload >= protocol.MIN_TX_ORDER_HEIGHT && payload <= protocol.MAX_TX_ORDER_HEIGHT {
+			return true
+		}
+	case protocol.AGG_TX_AUTH_HEIGHT_ID:
+		if payload >= protocol.MIN_AGG_TX_AUTH_HEIGHT && payload <= protocol.MAX_AGG_TX_AUTH_HEIGHT {
+			return true
+		}
+	case protocol.MEDIAN_TIME_HEIGHT_ID:
+		if payload >= protocol.MIN_MEDIAN_TIME_HEIGHT && payload <= protocol.MAX_MEDIAN_TIME_HEIGHT {
+			return true
+		}
+	case protocol.BLOCK_HASH_HEIGHT_ID:
+		if payload >= protocol.MIN_BLOCK_HASH_HEIGHT && payload <= protocol.MAX_BLOCK_HASH_HEIGHT {
+			return true
+		}
+	case protocol.DELEGATION_HEIGHT_ID:
+		if payload >= protocol.MIN_DELEGATION_HEIGHT && payload <= protocol.MAX_DELEGATION_HEIGHT {
+			return true
+		}
 	}
 
 	return false
@@ -0,0 +1,48 @@
+package miner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/bazo-blockchain/bazo-miner/vm"
+)
+
+type contractTrace struct {
+	Success bool
+	//Error message of a failed execution
+	Error   string
+	GasUsed uint64
+	Steps   []vm.TraceStep
+}
+
+//Replays the contract call of the fundsTx against the current state with tracing enabled. The replay does not change
+//the state. The outcome may differ from the execution in the block if the contract's state changed
+//since.
+func traceContractCall(txHash [32]byte) (*contractTrace, error) {
+	tx, ok := storage.ReadClosedTx(txHash).(*protocol.FundsTx)
+	if !ok || tx == nil {
+		if tx, ok = storage.ReadOpenTx(txHash).(*protocol.FundsTx); !ok || tx == nil {
+			return nil, errors.New(fmt.Sprintf("FundsTx %x not found.", txHash[0:8]))
+		}
+	}
+
+	contractAcc := storage.State.Get(tx.To)
+	if tx.Data == nil || contractAcc == nil || contractAcc.Contract == nil {
+		return nil, errors.New(fmt.Sprintf("Tx %x does not call a contract.", txHash[0:8]))
+	}
+
+	virtualMachine := vm.NewVM(protocol.NewContext(*contractAcc, *tx, readStateAccount))
+	tracer := virtualMachine.EnableTracing()
+
+	trace := &contractTrace{Success: true}
+	if err := execContract(&virtualMachine, txHash); err != nil {
+		trace.Success = false
+		trace.Error = err.Error()
+	}
+	trace.GasUsed = virtualMachine.GasUsed()
+	trace.Steps = tracer.Steps
+
+	return trace, nil
+}
@@ -0,0 +1,47 @@
+package miner
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"github.com/bazo-blockchain/bazo-miner/vm"
+)
+
+func TestTraceContractCall(t *testing.T) {
+	contractAcc := &protocol.Account{Address: [32]byte{'t', 'r', 'a', 'c', 'e'}, Contract: []byte{vm.PUSH, 0, 4, vm.SSTORE, 0, vm.HALT}, ContractVariables: []protocol.ByteArray{{0}}}
+	contract := contractAcc.Hash()
+	storage.State.Set(contract, contractAcc)
+	defer storage.State.Delete(contract)
+
+	tx := &protocol.FundsTx{Amount: 1, To: contract, Data: []byte{0x01}, GasLimit: 5000, GasPrice: 1}
+	storage.WriteClosedTx(tx)
+	defer storage.DeleteClosedTx(tx)
+
+	trace, err := traceContractCall(tx.Hash())
+	if err != nil || !trace.Success || len(trace.Steps) != 3 {
+		t.Fatalf("Invalid trace: %v, %v\n", trace, err)
+	}
+	if trace.Steps[1].OpCode != "sstore" || len(trace.Steps[1].Stack) != 1 || trace.Steps[1].StorageWrite == nil || trace.Steps[1].StorageWrite.Value[0] != 4 {
+		t.Errorf("Storage write not traced: %v\n", trace.Steps[1])
+	}
+	if contractAcc.ContractVariables[0][0] != 0 {
+		t.Error("Traced execution changed the contract variables.")
+	}
+
+	outOfGas := &protocol.FundsTx{Amount: 2, To: contract, Data: []byte{0x01}, GasLimit: 1, GasPrice: 1}
+	storage.WriteClosedTx(outOfGas)
+	defer storage.DeleteClosedTx(outOfGas)
+
+	if trace, err = traceContractCall(outOfGas.Hash()); err != nil || trace.Success || trace.Error != "vm.exec(): out of gas" || trace.GasUsed != 1 {
+		t.Errorf("Invalid trace of failed execution: %v, %v\n", trace, err)
+	}
+
+	transfer := &protocol.FundsTx{Amount: 3, To: contract}
+	storage.WriteClosedTx(transfer)
+	defer storage.DeleteClosedTx(transfer)
+
+	if _, err = traceContractCall(transfer.Hash()); err == nil {
+		t.Error("Tx not calling a contract was traced.")
+	}
+}
@@ -0,0 +1,288 @@
+package miner
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+//Minimal WebSocket (RFC 6455) endpoint served at /ws next to the JSON-RPC interface. Subscribers get every validated
+//block, incoming fundsTx/iotTx and rolled back block pushed as a JSON message instead of having to poll the node.
+//The server only sends, messages from the client are read to notice when the connection gets closed.
+
+const (
+	WS_EVENT_BLOCK    = "block"
+	WS_EVENT_TX       = "tx"
+	WS_EVENT_ROLLBACK = "rollback"
+
+	//Events queued per subscriber. A subscriber that can't keep up loses events instead of stalling the miner.
+	WS_SUBSCRIBER_BUFFER = 100
+
+	wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+	//Clients only send control frames, whose payload is limited to 125 bytes (RFC 6455, section 5.5).
+	wsMaxClientPayload = 125
+
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+)
+
+type wsEvent struct {
+	Event string      `json:"event"`
+	Data  interface{} `json:"data"`
+}
+
+type wsRollback struct {
+	Hash   string `json:"hash"`
+	Height uint32 `json:"height"`
+}
+
+type wsSubscriber struct {
+	ch     chan []byte
+	topics map[string]bool
+}
+
+var (
+	wsSubscribers      = make(map[*wsSubscriber]bool)
+	wsSubscribersMutex = &sync.Mutex{}
+)
+
+//A subscriber without topics receives all events.
+func newWSSubscriber(topics []string) *wsSubscriber {
+	s := &wsSubscriber{ch: make(chan []byte, WS_SUBSCRIBER_BUFFER), topics: make(map[string]bool)}
+	for _, topic := range topics {
+		if topic != "" {
+			s.topics[topic] = true
+		}
+	}
+
+	return s
+}
+
+func (s *wsSubscriber) wants(event string) bool {
+	return len(s.topics) == 0 || s.topics[event]
+}
+
+func subscribe(s *wsSubscriber) {
+	wsSubscribersMutex.Lock()
+	defer wsSubscribersMutex.Unlock()
+
+	wsSubscribers[s] = true
+}
+
+func unsubscribe(s *wsSubscriber) {
+	wsSubscribersMutex.Lock()
+	defer wsSubscribersMutex.Unlock()
+
+	if wsSubscribers[s] {
+		delete(wsSubscribers, s)
+		close(s.ch)
+	}
+}
+
+func publishEvent(event string, data interface{}) {
+	wsSubscribersMutex.Lock()
+	defer wsSubscribersMutex.Unlock()
+
+	if len(wsSubscribers) == 0 {
+		return
+	}
+
+	msg, err := json.Marshal(wsEvent{event, data})
+	if err != nil {
+		logger.Printf("Encoding %v event failed: %v\n", event, err)
+		return
+	}
+
+	for s := range wsSubscribers {
+		if !s.wants(event) {
+			continue
+		}
+		select {
+		case s.ch <- msg:
+		default:
+			//Subscriber is too slow, drop the event.
+		}
+	}
+}
+
+func publishBlock(block *protocol.Block) {
+	publishEvent(WS_EVENT_BLOCK, newRPCBlock(block))
+}
+
+func publishRollback(block *protocol.Block) {
+	publishEvent(WS_EVENT_ROLLBACK, wsRollback{hex.EncodeToString(block.Hash[:]), block.Height})
+}
+
+//Transactions received from the network are handed over by the p2p package.
+func forwardTxEvents() {
+	for tx := range p2p.TxEventOut {
+		publishEvent(WS_EVENT_TX, newRPCTx(tx))
+	}
+}
+
+//The accept key proves to the client that the server understood the WebSocket handshake.
+func wsAcceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+//Server to client frames are never masked.
+func encodeWSFrame(opcode byte, payload []byte) []byte {
+	var frame []byte
+	frame = append(frame, 0x80|opcode)
+
+	switch length := len(payload); {
+	case length < 126:
+		frame = append(frame, byte(length))
+	case length <= 0xFFFF:
+		var lenBuf [2]byte
+		binary.BigEndian.PutUint16(lenBuf[:], uint16(length))
+		frame = append(frame, 126)
+		frame = append(frame, lenBuf[:]...)
+	default:
+		var lenBuf [8]byte
+		binary.BigEndian.PutUint64(lenBuf[:], uint64(length))
+		frame = append(frame, 127)
+		frame = append(frame, lenBuf[:]...)
+	}
+
+	return append(frame, payload...)
+}
+
+//Reads a single (client to server, hence masked) frame.
+func readWSFrame(reader io.Reader) (opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(reader, head[:]); err != nil {
+		return 0, nil, err
+	}
+
+	opcode = head[0] & 0x0F
+	masked := head[1]&0x80 != 0
+	length := uint64(head[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var lenBuf [2]byte
+		if _, err = io.ReadFull(reader, lenBuf[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(lenBuf[:]))
+	case 127:
+		var lenBuf [8]byte
+		if _, err = io.ReadFull(reader, lenBuf[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(lenBuf[:])
+	}
+
+	if length > wsMaxClientPayload {
+		return 0, nil, errors.New(fmt.Sprintf("WebSocket frame too large: %v bytes", length))
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(reader, mask[:]); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(reader, payload); err != nil {
+		return 0, nil, err
+	}
+
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return opcode, payload, nil
+}
+
+//Subscribe with ws://IP:PORT/ws, optionally restricted to some events, e.g. /ws?events=block,rollback
+func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "WebSocket upgrade expected.", http.StatusBadRequest)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "WebSocket not supported.", http.StatusInternalServerError)
+		return
+	}
+
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		logger.Printf("WebSocket upgrade failed: %v\n", err)
+		return
+	}
+	defer conn.Close()
+
+	rw.WriteString("HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + wsAcceptKey(key) + "\r\n\r\n")
+	if err := rw.Flush(); err != nil {
+		return
+	}
+
+	s := newWSSubscriber(strings.Split(r.URL.Query().Get("events"), ","))
+	subscribe(s)
+	defer unsubscribe(s)
+
+	writeMutex := &sync.Mutex{}
+	go readWSConn(rw.Reader, rw.Writer, writeMutex, s)
+
+	for msg := range s.ch {
+		writeMutex.Lock()
+		rw.Write(encodeWSFrame(wsOpText, msg))
+		err := rw.Flush()
+		writeMutex.Unlock()
+		if err != nil {
+			return
+		}
+	}
+}
+
+//Answers pings and ends the subscription as soon as the client closes the connection.
+func readWSConn(reader *bufio.Reader, writer *bufio.Writer, writeMutex *sync.Mutex, s *wsSubscriber) {
+	defer unsubscribe(s)
+
+	for {
+		opcode, payload, err := readWSFrame(reader)
+		if err != nil {
+			return
+		}
+
+		switch opcode {
+		case wsOpClose:
+			writeMutex.Lock()
+			writer.Write(encodeWSFrame(wsOpClose, nil))
+			writer.Flush()
+			writeMutex.Unlock()
+			return
+		case wsOpPing:
+			writeMutex.Lock()
+			writer.Write(encodeWSFrame(wsOpPong, payload))
+			writer.Flush()
+			writeMutex.Unlock()
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package miner
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestWSAcceptKey(t *testing.T) {
+	//Example from RFC 6455, section 1.3
+	if key := wsAcceptKey("dGhlIHNhbXBsZSBub25jZQ=="); key != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Errorf("Accept key is wrong: %v\n", key)
+	}
+}
+
+func TestWSFrameEncoding(t *testing.T) {
+	for _, length := range []int{0, 125} {
+		payload := bytes.Repeat([]byte{0x42}, length)
+
+		opcode, decoded, err := readWSFrame(bytes.NewReader(encodeWSFrame(wsOpText, payload)))
+		if err != nil || opcode != wsOpText || !bytes.Equal(decoded, payload) {
+			t.Errorf("Frame with payload length %v could not be decoded: %v\n", length, err)
+		}
+	}
+
+	//Events sent to the client are bigger, their length is extended to 16 or 64 bits.
+	for _, test := range []struct {
+		length int
+		header []byte
+	}{
+		{126, []byte{0x80 | wsOpText, 126, 0, 126}},
+		{0xFFFF, []byte{0x80 | wsOpText, 126, 0xFF, 0xFF}},
+		{0x10000, []byte{0x80 | wsOpText, 127, 0, 0, 0, 0, 0, 1, 0, 0}},
+	} {
+		payload := bytes.Repeat([]byte{0x42}, test.length)
+		frame := encodeWSFrame(wsOpText, payload)
+		if !bytes.Equal(frame[:len(test.header)], test.header) || !bytes.Equal(frame[len(test.header):], payload) {
+			t.Errorf("Frame with payload length %v is encoded wrong: %x\n", test.length, frame[:len(test.header)])
+		}
+
+		//Clients only send control frames.
+		if _, _, err := readWSFrame(bytes.NewReader(frame)); err == nil {
+			t.Errorf("Client frame with payload length %v accepted.\n", test.length)
+		}
+	}
+
+	//Client frames are masked.
+	mask := []byte{1, 2, 3, 4}
+	maskedFrame := []byte{0x80 | wsOpPing, 0x80 | 3, mask[0], mask[1], mask[2], mask[3], 'a' ^ 1, 'b' ^ 2, 'c' ^ 3}
+	opcode, payload, err := readWSFrame(bytes.NewReader(maskedFrame))
+	if err != nil || opcode != wsOpPing || string(payload) != "abc" {
+		t.Errorf("Masked frame could not be decoded: %v %v %v\n", opcode, payload, err)
+	}
+}
+
+func TestWSPublishEvent(t *testing.T) {
+	all := newWSSubscriber(nil)
+	rollbacksOnly := newWSSubscriber([]string{WS_EVENT_ROLLBACK})
+	subscribe(all)
+	subscribe(rollbacksOnly)
+	defer unsubscribe(all)
+	defer unsubscribe(rollbacksOnly)
+
+	block := new(protocol.Block)
+	block.Height = 7
+	publishBlock(block)
+
+	var event wsEvent
+	select {
+	case msg := <-all.ch:
+		json.Unmarshal(msg, &event)
+		if event.Event != WS_EVENT_BLOCK {
+			t.Errorf("Expected a block event, got: %v\n", event.Event)
+		}
+	default:
+		t.Errorf("Block event has not been published\n")
+	}
+
+	if len(rollbacksOnly.ch) != 0 {
+		t.Errorf("Subscriber received an event it did not subscribe to\n")
+	}
+
+	publishRollback(block)
+	if len(rollbacksOnly.ch) != 1 || len(all.ch) != 1 {
+		t.Errorf("Rollback event has not been published to all subscribers\n")
+	}
+}
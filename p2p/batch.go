@@ -0,0 +1,173 @@
+package p2p
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Txs of the same type are requested in batches instead of one message per tx. The request holds the tx request type
+//(e.g., FUNDSTX_REQ) followed by the tx hashes, the response the same type followed by the length prefixed txs found.
+//Received txs are delivered to the request waiting for their hash, concurrent requests do not get each other's txs.
+
+const MAX_BATCH_TX_REQ = 256
+
+var (
+	batchTxWaiters = make(map[[32]byte]chan protocol.Transaction)
+	batchTxMutex   = &sync.Mutex{}
+)
+
+//The txs are delivered through the returned channel as they arrive, CancelBatchTxReq must be called if the caller
+//stops waiting before all txs have been received.
+func BatchTxReq(hashes [][32]byte, reqType uint8) (chan protocol.Transaction, error) {
+	if len(hashes) == 0 || len(hashes) > MAX_BATCH_TX_REQ {
+		return nil, errors.New(fmt.Sprintf("A batch request must contain between 1 and %v txs.", MAX_BATCH_TX_REQ))
+	}
+
+	if len(peers.minerConns) == 0 {
+		return nil, errors.New("Couldn't get a connection, request not transmitted.")
+	}
+
+	txChan := make(chan protocol.Transaction, len(hashes))
+	payload := []byte{reqType}
+
+	batchTxMutex.Lock()
+	for _, hash := range hashes {
+		batchTxWaiters[hash] = txChan
+		payload = append(payload, hash[:]...)
+	}
+	batchTxMutex.Unlock()
+
+	//Like TxReq, the request is sent to several miners so that the possibility of an answer is higher.
+	packet := BuildPacket(BATCH_TX_REQ, payload)
+	for _, p := range peers.getFetchPeers() {
+		sendData(p, packet)
+	}
+
+	return txChan, nil
+}
+
+func CancelBatchTxReq(hashes [][32]byte, txChan chan protocol.Transaction) {
+	batchTxMutex.Lock()
+	defer batchTxMutex.Unlock()
+
+	for _, hash := range hashes {
+		if batchTxWaiters[hash] == txChan {
+			delete(batchTxWaiters, hash)
+		}
+	}
+}
+
+func batchTxRes(p *peer, payload []byte) {
+	if len(payload) <= 1 || (len(payload)-1)%32 != 0 || (len(payload)-1)/32 > MAX_BATCH_TX_REQ {
+		return
+	}
+
+	response := []byte{payload[0]}
+	found := 0
+	for i := 1; i < len(payload); i += 32 {
+		var txHash [32]byte
+		copy(txHash[:], payload[i:i+32])
+
+		tx := storage.ReadOpenTx(txHash)
+		if tx == nil {
+			//Pruned IoT txs do not match their hash anymore.
+			if tx = storage.ReadClosedTx(txHash); tx == nil || tx.Hash() != txHash {
+				continue
+			}
+		}
+
+		encodedTx := tx.Encode()
+		var txLen [4]byte
+		binary.BigEndian.PutUint32(txLen[:], uint32(len(encodedTx)))
+		response = append(response, txLen[:]...)
+		response = append(response, encodedTx...)
+		found++
+	}
+
+	if found == 0 {
+		sendData(p, BuildPacket(NOT_FOUND, nil))
+		return
+	}
+
+	sendData(p, BuildPacket(BATCH_TX_RES, response))
+}
+
+func processBatchTxRes(p *peer, payload []byte) {
+	if len(payload) < 1 {
+		return
+	}
+
+	reqType := payload[0]
+	for i := 1; i+4 <= len(payload); {
+		txLen := int(binary.BigEndian.Uint32(payload[i : i+4]))
+		i += 4
+		if txLen > len(payload)-i {
+			return
+		}
+
+		if tx := decodeBatchTx(reqType, payload[i:i+txLen]); tx != nil {
+			deliverBatchTx(tx)
+		}
+		i += txLen
+	}
+}
+
+func deliverBatchTx(tx protocol.Transaction) {
+	txHash := tx.Hash()
+
+	batchTxMutex.Lock()
+	defer batchTxMutex.Unlock()
+
+	//Every hash is delivered once, the channel has room for all txs of its request.
+	if txChan := batchTxWaiters[txHash]; txChan != nil {
+		delete(batchTxWaiters, txHash)
+		txChan <- tx
+	}
+}
+
+func decodeBatchTx(reqType uint8, encoded []byte) protocol.Transaction {
+	switch reqType {
+	case FUNDSTX_REQ:
+		var tx *protocol.FundsTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case ACCTX_REQ:
+		var tx *protocol.AccTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case CONFIGTX_REQ:
+		var tx *protocol.ConfigTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case STAKETX_REQ:
+		var tx *protocol.StakeTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case AGGTX_REQ:
+		var tx *protocol.AggTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case IOTTX_REQ:
+		var tx *protocol.IotTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case CONTRACTTX_REQ:
+		var tx *protocol.ContractTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package p2p
+
+import (
+	"bufio"
+	"encoding/binary"
+	"net"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestProcessBatchTxRes(t *testing.T) {
+	tx1 := &protocol.FundsTx{Amount: 1, TxCnt: 1, To: [32]byte{0x01}}
+	tx2 := &protocol.FundsTx{Amount: 2, TxCnt: 2, To: [32]byte{0x02}}
+	unrequested := &protocol.FundsTx{Amount: 3, TxCnt: 3, To: [32]byte{0x03}}
+	hashes := [][32]byte{tx1.Hash(), tx2.Hash()}
+
+	txChan := make(chan protocol.Transaction, len(hashes))
+	batchTxMutex.Lock()
+	for _, hash := range hashes {
+		batchTxWaiters[hash] = txChan
+	}
+	batchTxMutex.Unlock()
+	defer CancelBatchTxReq(hashes, txChan)
+
+	payload := []byte{FUNDSTX_REQ}
+	for _, tx := range []*protocol.FundsTx{tx2, unrequested, tx1, tx2} {
+		encodedTx := tx.Encode()
+		var txLen [4]byte
+		binary.BigEndian.PutUint32(txLen[:], uint32(len(encodedTx)))
+		payload = append(append(payload, txLen[:]...), encodedTx...)
+	}
+	//A truncated tx at the end must be ignored.
+	payload = append(payload, 0, 0, 1, 0, 0x01)
+	processBatchTxRes(nil, payload)
+
+	if len(txChan) != 2 {
+		t.Fatalf("Expected the 2 requested txs to be delivered once, got %v\n", len(txChan))
+	}
+	if tx := <-txChan; tx.Hash() != tx2.Hash() {
+		t.Errorf("Txs not delivered in the order received: %v\n", tx)
+	}
+	if tx := <-txChan; tx.Hash() != tx1.Hash() {
+		t.Errorf("Txs not delivered in the order received: %v\n", tx)
+	}
+}
+
+func TestBatchTxRes(t *testing.T) {
+	conn, remote := net.Pipe()
+	defer remote.Close()
+	p := newPeer(conn, "8000", PEERTYPE_MINER)
+
+	//Malformed requests are dropped, unknown txs are answered with not found.
+	batchTxRes(p, []byte{FUNDSTX_REQ, 0x01})
+	go batchTxRes(p, append([]byte{FUNDSTX_REQ}, make([]byte, 64)...))
+
+	header, err := ReadHeader(bufio.NewReader(remote))
+	if err != nil || header.TypeID != NOT_FOUND {
+		t.Errorf("Unknown txs were not answered with not found: %v, %v\n", header, err)
+	}
+
+	if _, err := BatchTxReq(make([][32]byte, MAX_BATCH_TX_REQ+1), FUNDSTX_REQ); err == nil {
+		t.Error("Batch request exceeding the maximum size was sent.")
+	}
+}
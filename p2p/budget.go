@@ -0,0 +1,168 @@
+package p2p
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+//The length of a message is announced by the peer in the header. Without limits, a single header announcing a huge
+//payload makes the node allocate that much memory. Every message type has a maximum size, and the payloads of all
+//messages received from a peer but not yet processed must fit into the memory budget of that peer. A peer exceeding
+//its budget is disconnected, the others are not affected. The payload is read in chunks, so memory is only allocated
+//for data actually received. Payloads queued for the miner stay charged to the peer until the miner takes them.
+
+const (
+	MAX_TX_MSG_SIZE_DEFAULT    = 1 << 20
+	MAX_BLOCK_MSG_SIZE_DEFAULT = 16 << 20
+	MEMORY_BUDGET_DEFAULT      = 256 << 20
+	RCV_CHUNK_SIZE             = 64 << 10
+)
+
+var (
+	maxTxMsgSize    uint32 = MAX_TX_MSG_SIZE_DEFAULT
+	maxBlockMsgSize uint32 = MAX_BLOCK_MSG_SIZE_DEFAULT
+	memoryBudget    uint64 = MEMORY_BUDGET_DEFAULT
+	//Sum of the memory in use of all peers, guarded by budgetMutex as well as peer.memoryInUse
+	memoryInUse     uint64
+	budgetMutex     = &sync.Mutex{}
+)
+
+//Sets the maximum payload size of tx messages and of all other messages (blocks, headers etc.) and the total size of
+//payloads of a single peer being processed at the same time. Snapshots are only limited by the memory budget. 0 keeps the current value.
+func SetMemoryBudget(txMsgSize uint32, blockMsgSize uint32, budget uint64) {
+	budgetMutex.Lock()
+	defer budgetMutex.Unlock()
+
+	if txMsgSize > 0 {
+		maxTxMsgSize = txMsgSize
+	}
+	if blockMsgSize > 0 {
+		maxBlockMsgSize = blockMsgSize
+	}
+	if budget > 0 {
+		memoryBudget = budget
+	}
+}
+
+func maxPayloadSize(typeID uint8) uint64 {
+	budgetMutex.Lock()
+	defer budgetMutex.Unlock()
+
+	switch typeID {
+	case FUNDSTX_BRDCST, ACCTX_BRDCST, CONFIGTX_BRDCST, STAKETX_BRDCST, AGGTX_BRDCST, IOTTX_BRDCST, CONTRACTTX_BRDCST,
+		SLASHING_PROOF_BRDCST, FUNDSTX_RES, ACCTX_RES, CONFIGTX_RES, STAKETX_RES, AGGTX_RES, IOTTX_RES, CONTRACTTX_RES:
+		return uint64(maxTxMsgSize)
+	case SNAPSHOT_RES:
+		return memoryBudget
+	}
+
+	return uint64(maxBlockMsgSize)
+}
+
+//Returns false if the payload does not fit into the remaining budget of the peer.
+func reserveMemory(p *peer, size uint32) bool {
+	budgetMutex.Lock()
+	defer budgetMutex.Unlock()
+
+	if p.memoryInUse+uint64(size) > memoryBudget {
+		return false
+	}
+	p.memoryInUse += uint64(size)
+	memoryInUse += uint64(size)
+
+	return true
+}
+
+func releaseMemory(p *peer, size uint32) {
+	budgetMutex.Lock()
+	defer budgetMutex.Unlock()
+
+	if uint64(size) > p.memoryInUse {
+		size = uint32(p.memoryInUse)
+	}
+	p.memoryInUse -= uint64(size)
+	memoryInUse -= uint64(size)
+}
+
+//Returns the size of the payloads of all peers currently being processed.
+func MemoryInUse() uint64 {
+	budgetMutex.Lock()
+	defer budgetMutex.Unlock()
+
+	return memoryInUse
+}
+
+//Checks the announced length against the limit of the message type and reads the payload chunk by chunk. Memory is
+//reserved for every chunk before it is read, the caller has to release len(payload) once the message is processed.
+func readPayload(p *peer, reader *bufio.Reader, header *Header) (payload []byte, err error) {
+	if maxSize := maxPayloadSize(header.TypeID); uint64(header.Len) > maxSize {
+		return nil, errors.New(fmt.Sprintf("Payload of %v bytes exceeds the limit of %v bytes for %v.", header.Len, maxSize, LogMapping[header.TypeID]))
+	}
+
+	//The buffer grows with the data received, not with the announced length.
+	payload = make([]byte, 0, minLength(header.Len, RCV_CHUNK_SIZE))
+	chunk := make([]byte, minLength(header.Len, RCV_CHUNK_SIZE))
+	for remaining := header.Len; remaining > 0; {
+		chunkSize := minLength(remaining, RCV_CHUNK_SIZE)
+		if !reserveMemory(p, chunkSize) {
+			releaseMemory(p, uint32(len(payload)))
+			return nil, errors.New(fmt.Sprintf("Memory budget exhausted while receiving %v bytes.", header.Len))
+		}
+
+		if _, err := io.ReadFull(reader, chunk[:chunkSize]); err != nil {
+			releaseMemory(p, uint32(len(payload))+chunkSize)
+			return nil, err
+		}
+		payload = append(payload, chunk[:chunkSize]...)
+		remaining -= chunkSize
+	}
+
+	return payload, nil
+}
+
+//Payloads the miner may not be waiting for are queued up to the capacity of the queue, the rest is dropped. The
+//channel the miner reads is unbuffered, a payload is released once the miner received it from the channel.
+type payloadQueue struct {
+	items chan queuedPayload
+	out   chan []byte
+}
+
+type queuedPayload struct {
+	p       *peer
+	payload []byte
+}
+
+func newPayloadQueue(out chan []byte, capacity int) *payloadQueue {
+	queue := &payloadQueue{make(chan queuedPayload, capacity), out}
+	go queue.forward()
+
+	return queue
+}
+
+//Returns false if the queue is full, the caller has to release the dropped payload.
+func (queue *payloadQueue) push(p *peer, payload []byte) bool {
+	select {
+	case queue.items <- queuedPayload{p, payload}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (queue *payloadQueue) forward() {
+	for item := range queue.items {
+		queue.out <- item.payload
+		releaseMemory(item.p, uint32(len(item.payload)))
+	}
+}
+
+func minLength(a uint32, b uint32) uint32 {
+	if a < b {
+		return a
+	}
+
+	return b
+}
@@ -0,0 +1,119 @@
+package p2p
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+	"time"
+)
+
+func newBudgetTestReader(typeID uint8, announced uint32, payload []byte) (*bufio.Reader, *Header) {
+	//The header is built directly, ReadHeader would already reject lengths above MAX_BLOCK_SIZE.
+	return bufio.NewReader(bytes.NewReader(payload)), &Header{Len: announced, TypeID: typeID}
+}
+
+func TestReadPayloadLimits(t *testing.T) {
+	defer SetMemoryBudget(MAX_TX_MSG_SIZE_DEFAULT, MAX_BLOCK_MSG_SIZE_DEFAULT, MEMORY_BUDGET_DEFAULT)
+	SetMemoryBudget(100, 3*RCV_CHUNK_SIZE, 4*RCV_CHUNK_SIZE)
+	inUse := MemoryInUse()
+	p := newPeer(nil, "", PEERTYPE_MINER)
+
+	//A huge announced length is rejected before anything is allocated or read.
+	reader, header := newBudgetTestReader(BLOCK_BRDCST, 1<<31, nil)
+	if _, err := readPayload(p, reader, header); err == nil {
+		t.Error("Payload exceeding the message limit accepted.\n")
+	}
+
+	reader, header = newBudgetTestReader(FUNDSTX_BRDCST, 101, make([]byte, 101))
+	if _, err := readPayload(p, reader, header); err == nil {
+		t.Error("Tx exceeding the tx message limit accepted.\n")
+	}
+
+	//The peer announces more than it sends, only the data received is kept and released again.
+	reader, header = newBudgetTestReader(BLOCK_BRDCST, 2*RCV_CHUNK_SIZE, make([]byte, 10))
+	if _, err := readPayload(p, reader, header); err == nil {
+		t.Error("Truncated payload accepted.\n")
+	}
+	if MemoryInUse() != inUse {
+		t.Errorf("Memory of a failed read not released: %v instead of %v.\n", MemoryInUse(), inUse)
+	}
+
+	block := bytes.Repeat([]byte{0x01}, 2*RCV_CHUNK_SIZE+5)
+	reader, header = newBudgetTestReader(BLOCK_BRDCST, uint32(len(block)), block)
+	payload, err := readPayload(p, reader, header)
+	if err != nil || !bytes.Equal(payload, block) {
+		t.Fatalf("Payload not read correctly: %v\n", err)
+	}
+	if MemoryInUse() != inUse+uint64(len(block)) {
+		t.Errorf("Memory in use %v instead of %v.\n", MemoryInUse(), inUse+uint64(len(block)))
+	}
+
+	//Another block does not fit into the remaining budget until the first one is released.
+	reader, header = newBudgetTestReader(BLOCK_BRDCST, uint32(len(block)), block)
+	if _, err := readPayload(p, reader, header); err == nil {
+		t.Error("Payload exceeding the memory budget accepted.\n")
+	}
+
+	//The budget is per peer, other peers are not affected.
+	other := newPeer(nil, "", PEERTYPE_MINER)
+	reader, header = newBudgetTestReader(BLOCK_BRDCST, uint32(len(block)), block)
+	otherPayload, err := readPayload(other, reader, header)
+	if err != nil {
+		t.Errorf("Payload of another peer rejected: %v\n", err)
+	}
+	releaseMemory(other, uint32(len(otherPayload)))
+
+	releaseMemory(p, uint32(len(payload)))
+	reader, header = newBudgetTestReader(BLOCK_BRDCST, uint32(len(block)), block)
+	if payload, err = readPayload(p, reader, header); err != nil {
+		t.Errorf("Payload not accepted after releasing memory: %v\n", err)
+	}
+	releaseMemory(p, uint32(len(payload)))
+
+	if MemoryInUse() != inUse {
+		t.Errorf("Memory in use %v instead of %v.\n", MemoryInUse(), inUse)
+	}
+}
+
+func TestPayloadQueueRelease(t *testing.T) {
+	inUse := MemoryInUse()
+	p := newPeer(nil, "", PEERTYPE_MINER)
+	out := make(chan []byte)
+	queue := newPayloadQueue(out, 1)
+
+	//Queued payloads stay charged to the peer until the miner takes them.
+	payloads := [][]byte{make([]byte, 10), make([]byte, 20), make([]byte, 30)}
+	for _, payload := range payloads {
+		if !reserveMemory(p, uint32(len(payload))) {
+			t.Fatal("Memory could not be reserved.\n")
+		}
+	}
+	//One payload is held by the forwarder, one in the queue, the third one is dropped.
+	if !queue.push(p, payloads[0]) {
+		t.Fatal("Payload not queued.\n")
+	}
+	for i := 0; i < 100 && len(queue.items) > 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !queue.push(p, payloads[1]) {
+		t.Fatal("Payload not queued.\n")
+	}
+	if queue.push(p, payloads[2]) {
+		t.Fatal("Payload queued beyond the capacity of the queue.\n")
+	}
+	releaseMemory(p, uint32(len(payloads[2])))
+	if MemoryInUse() != inUse+30 {
+		t.Errorf("Memory in use %v instead of %v while payloads are queued.\n", MemoryInUse(), inUse+30)
+	}
+
+	for range payloads[:2] {
+		<-out
+	}
+	//The forwarder releases the memory after handing the payload over.
+	for i := 0; i < 100 && MemoryInUse() != inUse; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if MemoryInUse() != inUse {
+		t.Errorf("Memory in use %v instead of %v after the payloads were taken.\n", MemoryInUse(), inUse)
+	}
+}
@@ -0,0 +1,51 @@
+package p2p
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//Blocks are broadcast as compact blocks (see protocol.CompactBlock). The receiver reconstructs the tx hashes from its
+//mempool, the validation fetches the txs not in the mempool with the usual tx requests. If a short tx id can not be
+//resolved at all, the full block is requested from the relaying peer, which sends it as a regular block broadcast.
+
+func compactBlockPacket(encodedBlock []byte) []byte {
+	var block *protocol.Block
+	if block, _ = block.Decode(encodedBlock); block == nil {
+		return BuildPacket(BLOCK_BRDCST, encodedBlock)
+	}
+
+	return BuildPacket(COMPACTBLOCK_BRDCST, protocol.NewCompactBlock(block).Encode())
+}
+
+func processCompactBlockBrdcst(p *peer, payload []byte) {
+	var compactBlock *protocol.CompactBlock
+	compactBlock, err := compactBlock.Decode(payload)
+	if err != nil {
+		logDroppedMessage(p, COMPACTBLOCK_BRDCST, err)
+		return
+	}
+
+	//The miner only looks at the hash of blocks it has already validated, no need to reconstruct them.
+	if storage.ReadClosedBlock(compactBlock.Header.Hash) != nil {
+		forwardBlockToMiner(p, compactBlock.Header.Encode())
+		return
+	}
+
+	block, err := compactBlock.Reconstruct(mempoolTxHashes())
+	if err != nil {
+		logger.Printf("Compact block %x could not be reconstructed, requesting the full block: %v\n", compactBlock.Header.Hash[:8], err)
+		sendData(p, BuildPacket(FULLBLOCK_REQ, compactBlock.Header.Hash[:]))
+		return
+	}
+
+	forwardBlockToMiner(p, block.Encode())
+}
+
+func mempoolTxHashes() (txHashes [][32]byte) {
+	for _, tx := range storage.ReadAllOpenTxs() {
+		txHashes = append(txHashes, tx.Hash())
+	}
+
+	return txHashes
+}
@@ -0,0 +1,33 @@
+package p2p
+
+import (
+	"bufio"
+	"net"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestProcessCompactBlockBrdcst(t *testing.T) {
+	conn, remote := net.Pipe()
+	defer remote.Close()
+	p := newPeer(conn, "8000", PEERTYPE_MINER)
+
+	//Without txs, nothing needs to be looked up in the mempool.
+	block := &protocol.Block{Hash: [32]byte{0x01}, Height: 5}
+	go processCompactBlockBrdcst(p, protocol.NewCompactBlock(block).Encode())
+	var received *protocol.Block
+	if received, _ = received.Decode(<-BlockIn); received == nil || received.Hash != block.Hash {
+		t.Fatalf("Compact block was not forwarded to the miner: %v\n", received)
+	}
+
+	//The tx is not in the mempool, the full block is requested.
+	block = &protocol.Block{Hash: [32]byte{0x02}, Height: 6, NrFundsTx: 1, FundsTxData: [][32]byte{{0x03}}}
+	go processCompactBlockBrdcst(p, protocol.NewCompactBlock(block).Encode())
+
+	reader := bufio.NewReader(remote)
+	header, err := ReadHeader(reader)
+	if err != nil || header.TypeID != FULLBLOCK_REQ || header.Len != 32 {
+		t.Fatalf("Full block was not requested: %v, %v\n", header, err)
+	}
+}
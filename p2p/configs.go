@@ -16,6 +16,20 @@ const (
 	TIME_BRDCST_INTERVAL = 60
 	//Calculate system time every UPDATE_SYS_TIME seconds
 	UPDATE_SYS_TIME = 90
+	//Upper bound of outbound miner connections sharing the same /16 network prefix. Spreading the connections over
+	//distinct networks makes it harder for a single attacker to occupy all our connection slots
+	MAX_PEERS_PER_PREFIX = 2
+	//Minimal amount of connected miners that recently relayed a block which passed validation. As long as there are
+	//less, the system keeps looking for new miner peers
+	MIN_BEACON_PEERS = 2
+	//Seconds a peer counts as beacon peer after relaying a valid block
+	BEACON_TIMEOUT = 600
+	//Upper bound of received blocks waiting for their validation result, see eclipse.go
+	MAX_BLOCK_RELAYS = 1000
+	//Seconds after which a received block without validation result is not tracked anymore
+	BLOCK_RELAY_TIMEOUT = 600
+	//Upper bound in bytes of the broadcasts queued on disk while we are not connected to any miner, see partition.go
+	MAX_QUEUED_BROADCAST_SIZE = 64 << 20
 
 	//Protocol constants
 	IPV4ADDR_SIZE = 4
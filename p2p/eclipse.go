@@ -0,0 +1,198 @@
+package p2p
+
+import (
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/bazo-blockchain/bazo-miner/storage"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+//A new node syncing the chain trusts whatever its peers tell it. If all outbound connections end up at the same
+//attacker, the node is eclipsed and can be fed an arbitrary chain. Outbound connections are therefore spread over
+//distinct network prefixes and the node keeps looking for peers until enough of them relayed valid blocks recently.
+
+var (
+	//Keeps track of the peer a block has been received from first, until the miner reports its validation result.
+	blockRelays      = make(map[[32]byte]blockRelay)
+	blockRelaysMutex = &sync.Mutex{}
+)
+
+//Returns the /16 network prefix of the ip address. Loopback addresses return an empty prefix, such that local test
+//networks running all miners on the same machine are not restricted.
+func getPrefix(ipport string) string {
+	ip := net.ParseIP(strings.Split(ipport, ":")[0])
+	if ip == nil || ip.IsLoopback() || ip.To4() == nil {
+		return ""
+	}
+
+	ipv4 := ip.To4()
+
+	return fmt.Sprintf("%d.%d", ipv4[0], ipv4[1])
+}
+
+//Counts the connected miners within the given network prefix.
+func prefixCount(prefix string) (cnt int) {
+	for _, p := range peers.getAllPeers(PEERTYPE_MINER) {
+		if getPrefix(p.getIPPort()) == prefix {
+			cnt++
+		}
+	}
+
+	return cnt
+}
+
+//Checks whether a new outbound connection to ipport keeps our connections diverse enough. The bootstrap server is
+//always accepted.
+func checkOutboundDiversity(ipport string) error {
+	if ipport == storage.Bootstrap_Server {
+		return nil
+	}
+
+	prefix := getPrefix(ipport)
+	if prefix == "" {
+		return nil
+	}
+
+	if prefixCount(prefix) >= MAX_PEERS_PER_PREFIX {
+		return errors.New(fmt.Sprintf("Already %v miners connected within network prefix %v.", MAX_PEERS_PER_PREFIX, prefix))
+	}
+
+	return nil
+}
+
+type blockRelay struct {
+	p        *peer
+	received int64
+}
+
+func (p *peer) isBeaconPeer() bool {
+	p.l.Lock()
+	defer p.l.Unlock()
+
+	return p.lastBeacon != 0 && time.Now().Unix()-p.lastBeacon < BEACON_TIMEOUT
+}
+
+//Counts the connected miners that recently relayed a valid block.
+func beaconPeerCount() (cnt int) {
+	for _, p := range peers.getAllPeers(PEERTYPE_MINER) {
+		if p.isBeaconPeer() {
+			cnt++
+		}
+	}
+
+	return cnt
+}
+
+//Remembers the relaying peer of an incoming block broadcast. Blocks the miner never reported back (e.g., dropped
+//during shutdown) expire after BLOCK_RELAY_TIMEOUT seconds and at most MAX_BLOCK_RELAYS blocks are tracked at once.
+func rememberBlockRelay(p *peer, payload []byte) {
+	var block *protocol.Block
+	block, _ = block.Decode(payload)
+	if block == nil {
+		return
+	}
+
+	p.l.Lock()
+	if block.Height > p.announcedHeight {
+		p.announcedHeight = block.Height
+	}
+	p.l.Unlock()
+
+	blockRelaysMutex.Lock()
+	defer blockRelaysMutex.Unlock()
+
+	if _, exists := blockRelays[block.Hash]; exists {
+		return
+	}
+
+	now := time.Now().Unix()
+	if len(blockRelays) >= MAX_BLOCK_RELAYS {
+		for hash, relay := range blockRelays {
+			if now-relay.received >= BLOCK_RELAY_TIMEOUT {
+				delete(blockRelays, hash)
+			}
+		}
+	}
+
+	if len(blockRelays) < MAX_BLOCK_RELAYS {
+		blockRelays[block.Hash] = blockRelay{p, now}
+	}
+}
+
+//Called by the miner after a received block has been validated. If the block passed, the relaying peer is marked as
+//beacon peer and its height is recorded. Either way, the block is not tracked anymore.
+func BlockRelayValidated(blockHash [32]byte, height uint32, valid bool) {
+	blockRelaysMutex.Lock()
+	p := blockRelays[blockHash].p
+	delete(blockRelays, blockHash)
+	blockRelaysMutex.Unlock()
+
+	if p == nil || !valid {
+		return
+	}
+
+	p.l.Lock()
+	p.lastBeacon = time.Now().Unix()
+	if height > p.beaconHeight {
+		p.beaconHeight = height
+	}
+	p.l.Unlock()
+}
+
+func MinerPeerCount() int {
+	return peers.len(PEERTYPE_MINER)
+}
+
+//Returns the highest block height relayed by the current beacon peers. Known is false if there are no beacon peers.
+func BeaconTipHeight() (height uint32, known bool) {
+	for _, p := range peers.getAllPeers(PEERTYPE_MINER) {
+		if !p.isBeaconPeer() {
+			continue
+		}
+
+		p.l.Lock()
+		if p.beaconHeight > height {
+			height = p.beaconHeight
+		}
+		p.l.Unlock()
+		known = true
+	}
+
+	return height, known
+}
+
+//A node far behind cannot validate the blocks relayed to it until it has caught up, so the beacon peers alone never
+//report a tip ahead of us. The heights announced by relayed blocks are therefore taken into account too. As these are
+//not validated, a single peer announcing a made-up height must not stall us: the MIN_BEACON_PEERS-th highest
+//announcement counts (or the lowest one, if fewer peers announced a block). Known is false if no peer relayed a block.
+func NetworkTipHeight() (height uint32, known bool) {
+	var announced []uint32
+	for _, p := range peers.getAllPeers(PEERTYPE_MINER) {
+		p.l.Lock()
+		if p.announcedHeight > 0 {
+			announced = append(announced, p.announcedHeight)
+		}
+		p.l.Unlock()
+	}
+
+	height, known = BeaconTipHeight()
+	if len(announced) == 0 {
+		return height, known
+	}
+
+	sort.Slice(announced, func(i, j int) bool { return announced[i] > announced[j] })
+	k := MIN_BEACON_PEERS
+	if len(announced) < k {
+		k = len(announced)
+	}
+	if announced[k-1] > height {
+		height = announced[k-1]
+	}
+
+	return height, true
+}
@@ -0,0 +1,164 @@
+package p2p
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"net"
+	"testing"
+	"time"
+)
+
+//net.Pipe does not provide a meaningful remote address, so we override it.
+type addrConn struct {
+	net.Conn
+	addr net.Addr
+}
+
+func (c addrConn) RemoteAddr() net.Addr {
+	return c.addr
+}
+
+func newTestPeer(t *testing.T, ipport string) *peer {
+	addr, err := net.ResolveTCPAddr("tcp", ipport)
+	if err != nil {
+		t.Fatalf("Could not resolve %v: %v\n", ipport, err)
+	}
+
+	conn, _ := net.Pipe()
+
+	return newPeer(addrConn{conn, addr}, ipport[len(addr.IP.String())+1:], PEERTYPE_MINER)
+}
+
+func TestGetPrefix(t *testing.T) {
+	if prefix := getPrefix("23.24.122.66:8000"); prefix != "23.24" {
+		t.Errorf("Extracting network prefix failed: %v\n", prefix)
+	}
+	if prefix := getPrefix("127.0.0.1:8000"); prefix != "" {
+		t.Errorf("Loopback addresses should not have a network prefix: %v\n", prefix)
+	}
+	if prefix := getPrefix("invalid:8000"); prefix != "" {
+		t.Errorf("Invalid addresses should not have a network prefix: %v\n", prefix)
+	}
+}
+
+func TestCheckOutboundDiversity(t *testing.T) {
+	p1 := newTestPeer(t, "23.24.1.1:8000")
+	p2 := newTestPeer(t, "23.24.2.2:8000")
+	peers.add(p1)
+	peers.add(p2)
+	defer peers.delete(p1)
+	defer peers.delete(p2)
+
+	if err := checkOutboundDiversity("23.24.3.3:8000"); err == nil {
+		t.Errorf("Connection to a crowded network prefix was not prevented\n")
+	}
+	if err := checkOutboundDiversity("23.25.3.3:8000"); err != nil {
+		t.Errorf("Connection to a distinct network prefix was prevented: %v\n", err)
+	}
+}
+
+func TestBlockRelayValidated(t *testing.T) {
+	p1 := newTestPeer(t, "23.26.1.1:8000")
+	p2 := newTestPeer(t, "23.27.1.1:8000")
+
+	validHash := [32]byte{1}
+	invalidHash := [32]byte{2}
+	blockRelays[validHash] = blockRelay{p1, time.Now().Unix()}
+	blockRelays[invalidHash] = blockRelay{p2, time.Now().Unix()}
+
+	BlockRelayValidated(validHash, 7, true)
+	BlockRelayValidated(invalidHash, 9, false)
+
+	if !p1.isBeaconPeer() {
+		t.Errorf("Peer relaying a valid block was not marked as beacon peer\n")
+	}
+	if p2.isBeaconPeer() {
+		t.Errorf("Peer relaying an invalid block was marked as beacon peer\n")
+	}
+	if len(blockRelays) != 0 {
+		t.Errorf("Validated blocks are still tracked: %v\n", len(blockRelays))
+	}
+}
+
+func TestBlockRelayLimit(t *testing.T) {
+	p := newTestPeer(t, "23.30.1.1:8000")
+	defer func() { blockRelays = make(map[[32]byte]blockRelay) }()
+
+	block := protocol.NewBlock([32]byte{}, 1)
+	block.Hash = [32]byte{5}
+	payload := block.Encode()
+
+	//Relays the miner never reported back fill up the map.
+	for i := 0; i < MAX_BLOCK_RELAYS; i++ {
+		blockRelays[[32]byte{6, byte(i), byte(i >> 8)}] = blockRelay{p, time.Now().Unix()}
+	}
+	rememberBlockRelay(p, payload)
+	if _, exists := blockRelays[block.Hash]; exists || len(blockRelays) != MAX_BLOCK_RELAYS {
+		t.Errorf("More than %v blocks are tracked: %v\n", MAX_BLOCK_RELAYS, len(blockRelays))
+	}
+
+	//Once expired, the old relays make room for new ones.
+	for hash := range blockRelays {
+		blockRelays[hash] = blockRelay{p, time.Now().Unix() - BLOCK_RELAY_TIMEOUT}
+	}
+	rememberBlockRelay(p, payload)
+	if _, exists := blockRelays[block.Hash]; !exists || len(blockRelays) != 1 {
+		t.Errorf("Expired relays were not removed: %v blocks tracked\n", len(blockRelays))
+	}
+}
+
+func TestBeaconTipHeight(t *testing.T) {
+	p1 := newTestPeer(t, "23.28.1.1:8000")
+	p2 := newTestPeer(t, "23.29.1.1:8000")
+	peers.add(p1)
+	peers.add(p2)
+	defer peers.delete(p1)
+	defer peers.delete(p2)
+
+	if _, known := BeaconTipHeight(); known {
+		t.Errorf("Tip known without beacon peers\n")
+	}
+
+	blockRelays[[32]byte{3}] = blockRelay{p1, time.Now().Unix()}
+	blockRelays[[32]byte{4}] = blockRelay{p2, time.Now().Unix()}
+	BlockRelayValidated([32]byte{3}, 12, true)
+	BlockRelayValidated([32]byte{4}, 15, true)
+
+	if height, known := BeaconTipHeight(); !known || height != 15 {
+		t.Errorf("Wrong tip height: %v (known: %v) instead of 15\n", height, known)
+	}
+}
+
+func TestNetworkTipHeight(t *testing.T) {
+	p1 := newTestPeer(t, "23.31.1.1:8000")
+	p2 := newTestPeer(t, "23.32.1.1:8000")
+	p3 := newTestPeer(t, "23.33.1.1:8000")
+	peers.add(p1)
+	peers.add(p2)
+	peers.add(p3)
+	defer peers.delete(p1)
+	defer peers.delete(p2)
+	defer peers.delete(p3)
+	defer func() { blockRelays = make(map[[32]byte]blockRelay) }()
+
+	if _, known := NetworkTipHeight(); known {
+		t.Errorf("Tip known without relayed blocks\n")
+	}
+
+	//Blocks far ahead cannot be validated yet, but their height is announced nonetheless.
+	for i, p := range []*peer{p1, p2, p3} {
+		block := protocol.NewBlock([32]byte{}, uint32(100*(i+1)))
+		block.Hash = [32]byte{7, byte(i)}
+		rememberBlockRelay(p, block.Encode())
+	}
+
+	//The highest announcement of p3 alone does not count.
+	if height, known := NetworkTipHeight(); !known || height != 200 {
+		t.Errorf("Wrong tip height: %v (known: %v) instead of 200\n", height, known)
+	}
+
+	blockRelays[[32]byte{8}] = blockRelay{p1, time.Now().Unix()}
+	BlockRelayValidated([32]byte{8}, 250, true)
+	if height, known := NetworkTipHeight(); !known || height != 250 {
+		t.Errorf("Validated beacon height ignored: %v (known: %v) instead of 250\n", height, known)
+	}
+}
@@ -0,0 +1,125 @@
+package p2p
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+)
+
+//Txs and blocks the miner misses while validating a block are requested from fetchPeers random miners at once, the
+//first response is delivered and the later ones are dropped. A single slow or unresponsive peer thus does not make the
+//whole validation time out, without sending every request to all miners. The requested hashes are remembered until
+//the first response arrives, responses nobody asked for are dropped as well. Requests which are never answered are
+//forgotten after PENDING_FETCH_TTL.
+
+const (
+	FETCH_PEERS_DEFAULT = 3
+	PENDING_FETCH_TTL   = 2 * time.Minute
+)
+
+var (
+	fetchPeers = FETCH_PEERS_DEFAULT
+
+	pendingFetches = make(map[[32]byte]time.Time)
+	//Number of LastBlockReq not answered yet, any block is accepted for them.
+	pendingLastBlocks = 0
+	//Miners asked for a snapshot which have not answered yet.
+	pendingSnapshots = make(map[*peer]bool)
+	//Guards fetchPeers as well.
+	pendingFetchMutex = &sync.Mutex{}
+)
+
+//Sets how many miners a tx or block is requested from, 0 requests it from all miners.
+func SetFetchPeers(n int) {
+	pendingFetchMutex.Lock()
+	defer pendingFetchMutex.Unlock()
+
+	fetchPeers = n
+}
+
+//Returns up to fetchPeers distinct miners in random order.
+func (peers *peersStruct) getFetchPeers() []*peer {
+	pendingFetchMutex.Lock()
+	n := fetchPeers
+	pendingFetchMutex.Unlock()
+
+	peerList := peers.getAllPeers(PEERTYPE_MINER)
+	if n <= 0 || len(peerList) <= n {
+		return peerList
+	}
+
+	rand.Shuffle(len(peerList), func(i, j int) {
+		peerList[i], peerList[j] = peerList[j], peerList[i]
+	})
+
+	return peerList[:n]
+}
+
+func addPendingFetch(hashes ...[32]byte) {
+	pendingFetchMutex.Lock()
+	defer pendingFetchMutex.Unlock()
+
+	now := time.Now()
+	for hash, requested := range pendingFetches {
+		if now.Sub(requested) > PENDING_FETCH_TTL {
+			delete(pendingFetches, hash)
+		}
+	}
+	for _, hash := range hashes {
+		pendingFetches[hash] = now
+	}
+}
+
+//Returns true for the first response to a pending request, the hashes are not pending anymore afterwards.
+func takePendingFetch(hashes ...[32]byte) (pending bool) {
+	pendingFetchMutex.Lock()
+	defer pendingFetchMutex.Unlock()
+
+	for _, hash := range hashes {
+		if _, exists := pendingFetches[hash]; exists {
+			delete(pendingFetches, hash)
+			pending = true
+		}
+	}
+
+	return pending
+}
+
+func addPendingLastBlock() {
+	pendingFetchMutex.Lock()
+	defer pendingFetchMutex.Unlock()
+
+	pendingLastBlocks++
+}
+
+func takePendingLastBlock() bool {
+	pendingFetchMutex.Lock()
+	defer pendingFetchMutex.Unlock()
+
+	if pendingLastBlocks == 0 {
+		return false
+	}
+	pendingLastBlocks--
+
+	return true
+}
+
+func addPendingSnapshot(p *peer) {
+	pendingFetchMutex.Lock()
+	defer pendingFetchMutex.Unlock()
+
+	pendingSnapshots[p] = true
+}
+
+//Returns true for the first snapshot of a peer it has been requested from.
+func takePendingSnapshot(p *peer) bool {
+	pendingFetchMutex.Lock()
+	defer pendingFetchMutex.Unlock()
+
+	if !pendingSnapshots[p] {
+		return false
+	}
+	delete(pendingSnapshots, p)
+
+	return true
+}
@@ -0,0 +1,65 @@
+package p2p
+
+import (
+	"testing"
+)
+
+func TestGetFetchPeers(t *testing.T) {
+	defer SetFetchPeers(FETCH_PEERS_DEFAULT)
+
+	p1 := newTestPeer(t, "23.30.1.1:8000")
+	p2 := newTestPeer(t, "23.31.1.1:8000")
+	p3 := newTestPeer(t, "23.32.1.1:8000")
+	for _, p := range []*peer{p1, p2, p3} {
+		peers.add(p)
+		defer peers.delete(p)
+	}
+
+	SetFetchPeers(2)
+	if fetchList := peers.getFetchPeers(); len(fetchList) != 2 || fetchList[0] == fetchList[1] {
+		t.Errorf("Expected 2 distinct peers, got %v\n", fetchList)
+	}
+
+	SetFetchPeers(0)
+	if fetchList := peers.getFetchPeers(); len(fetchList) != peers.len(PEERTYPE_MINER) {
+		t.Errorf("Expected all %v miners, got %v\n", peers.len(PEERTYPE_MINER), len(fetchList))
+	}
+}
+
+func TestFirstResponseWins(t *testing.T) {
+	hash := [32]byte{'f', 'i', 'r', 's', 't'}
+	if takePendingFetch(hash) {
+		t.Fatal("Response to a tx nobody requested was accepted.")
+	}
+
+	addPendingFetch(hash)
+	if !takePendingFetch(hash) {
+		t.Error("First response was dropped.")
+	}
+	if takePendingFetch(hash) {
+		t.Error("Second response was accepted.")
+	}
+
+	//A block is requested by both of its hashes, the response is taken once for either of them.
+	hashWithoutTx := [32]byte{'w', 'o', 't', 'x'}
+	addPendingFetch(hash, hashWithoutTx)
+	if !takePendingFetch(hash, hashWithoutTx) || takePendingFetch(hashWithoutTx) {
+		t.Error("Block response not taken exactly once.")
+	}
+
+	addPendingLastBlock()
+	if !takePendingLastBlock() || takePendingLastBlock() {
+		t.Error("Last block response not taken exactly once.")
+	}
+}
+
+func TestOneSnapshotPerPeer(t *testing.T) {
+	p, other := &peer{}, &peer{}
+	addPendingSnapshot(p)
+	if takePendingSnapshot(other) {
+		t.Error("Snapshot of a peer nobody asked was accepted.")
+	}
+	if !takePendingSnapshot(p) || takePendingSnapshot(p) {
+		t.Error("Snapshot of the requested peer not taken exactly once.")
+	}
+}
@@ -1,7 +1,17 @@
 package p2p
 
-//All incoming messages are processed here and acted upon accordingly
-func processIncomingMsg(p *peer, header *Header, payload []byte) {
+import (
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crash"
+	"golang.org/x/crypto/sha3"
+)
+
+//All incoming messages are processed here and acted upon accordingly. Returns true if the payload has been queued for
+//the miner, it is released once the miner takes it instead of by the caller (see budget.go).
+func processIncomingMsg(p *peer, header *Header, payload []byte) (queued bool) {
+	//A malformed message must not take the node down, the message is dropped instead.
+	payloadHash := sha3.Sum256(payload)
+	defer crash.Recover("p2p", payloadHash[:], fmt.Sprintf("%v from %v", LogMapping[header.TypeID], p.getIPPort()))
 
 	switch header.TypeID {
 	//BROADCASTING
@@ -15,8 +25,14 @@ func processIncomingMsg(p *peer, header *Header, payload []byte) {
 		processTxBrdcst(p, payload, STAKETX_BRDCST)
 	case AGGTX_BRDCST:
 		processTxBrdcst(p, payload, AGGTX_BRDCST)
+	case CONTRACTTX_BRDCST:
+		processTxBrdcst(p, payload, CONTRACTTX_BRDCST)
 	case BLOCK_BRDCST:
 		forwardBlockToMiner(p, payload)
+	case COMPACTBLOCK_BRDCST:
+		processCompactBlockBrdcst(p, payload)
+	case SLASHING_PROOF_BRDCST:
+		queued = forwardSlashingProofToMiner(p, payload)
 	case TIME_BRDCST:
 		processTimeRes(p, payload)
 	case IOTTX_BRDCST:
@@ -34,8 +50,14 @@ func processIncomingMsg(p *peer, header *Header, payload []byte) {
 		txRes(p, payload, AGGTX_REQ)
 	case IOTTX_REQ:
 		txRes(p, payload, IOTTX_REQ)
+	case CONTRACTTX_REQ:
+		txRes(p, payload, CONTRACTTX_REQ)
 	case BLOCK_REQ:
 		blockRes(p, payload)
+	case FULLBLOCK_REQ:
+		fullBlockRes(p, payload)
+	case BATCH_TX_REQ:
+		batchTxRes(p, payload)
 	case BLOCK_HEADER_REQ:
 		blockHeaderRes(p, payload)
 	case ACC_REQ:
@@ -50,6 +72,14 @@ func processIncomingMsg(p *peer, header *Header, payload []byte) {
 		neighborRes(p)
 	case INTERMEDIATE_NODES_REQ:
 		intermediateNodesRes(p, payload)
+	case IOTTX_ACK_REQ:
+		iotAckRes(p, payload)
+	case SNAPSHOT_REQ:
+		snapshotRes(p)
+	case MERKLE_PROOF_REQ:
+		merkleProofRes(p, payload)
+	case ACCOUNT_PROOF_REQ:
+		accountProofRes(p, payload)
 
 
 		//RESPONSES
@@ -69,6 +99,19 @@ func processIncomingMsg(p *peer, header *Header, payload []byte) {
 		forwardTxReqToMiner(p, payload, AGGTX_RES)
 	case IOTTX_RES:
 		forwardTxReqToMiner(p, payload, IOTTX_RES)
+	case CONTRACTTX_RES:
+		forwardTxReqToMiner(p, payload, CONTRACTTX_RES)
+	case BATCH_TX_RES:
+		processBatchTxRes(p, payload)
+	case SNAPSHOT_RES:
+		queued = forwardSnapshotReqToMiner(p, payload)
+	case BlOCK_HEADER_RES:
+		queued = forwardLightClientResToMiner(p, blockHeaderQueue, payload)
+	case MERKLE_PROOF_RES:
+		queued = forwardLightClientResToMiner(p, merkleProofQueue, payload)
+	case ACCOUNT_PROOF_RES:
+		queued = forwardLightClientResToMiner(p, accountProofQueue, payload)
 	}
 
+	return queued
 }
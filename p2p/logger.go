@@ -1,18 +1,15 @@
 package p2p
 
 import (
-	"github.com/bazo-blockchain/bazo-miner/storage"
-	"log"
+	"github.com/bazo-blockchain/bazo-miner/logging"
 )
 
 var (
 	LogMapping map[uint8]string
-	logger     *log.Logger
+	logger     = logging.GetLogger("p2p")
 )
 
 func InitLogging() {
-	logger = storage.InitLogger()
-
 	//Instead of logging just the integer, we log the corresponding semantic meaning, makes scrolling through
 	//the log file more comfortable
 	LogMapping = make(map[uint8]string)
@@ -25,6 +22,9 @@ func InitLogging() {
 	LogMapping[7]  = "BLOCK_HEADER_BRDCST"
 	LogMapping[8]  = "TX_BRDCST_ACK"
 	LogMapping[9]  = "AGGTX_BRDCST"
+	LogMapping[10] = "CONTRACTTX_BRDCST"
+	LogMapping[11] = "COMPACTBLOCK_BRDCST"
+	LogMapping[12] = "SLASHING_PROOF_BRDCST"
 
 	LogMapping[20] = "FUNDSTX_REQ"
 	LogMapping[21] = "ACCTX_REQ"
@@ -36,6 +36,12 @@ func InitLogging() {
 	LogMapping[27] = "ROOTACC_REQ"
 	LogMapping[28] = "INTERMEDIATE_NODES_REQ"
 	LogMapping[29] = "AGGTX_REQ"
+	LogMapping[30] = "SNAPSHOT_REQ"
+	LogMapping[31] = "MERKLE_PROOF_REQ"
+	LogMapping[32] = "CONTRACTTX_REQ"
+	LogMapping[33] = "FULLBLOCK_REQ"
+	LogMapping[34] = "BATCH_TX_REQ"
+	LogMapping[35] = "ACCOUNT_PROOF_REQ"
 
 	LogMapping[40] = "FUNDSTX_RES"
 	LogMapping[41] = "ACCTX_RES"
@@ -47,10 +53,17 @@ func InitLogging() {
 	LogMapping[47] = "ROOTACC_RES"
 	LogMapping[48] = "INTERMEDIATE_NODES_RES"
 	LogMapping[49] = "AGGTX_RES"
+	LogMapping[50] = "SNAPSHOT_RES"
+	LogMapping[51] = "MERKLE_PROOF_RES"
+	LogMapping[52] = "CONTRACTTX_RES"
+	LogMapping[53] = "BATCH_TX_RES"
+	LogMapping[54] = "ACCOUNT_PROOF_RES"
 
 	LogMapping[105] = "IOTTX_BRDCST"
 	LogMapping[106] = "IOTTX_REQ"
 	LogMapping[107] = "IOTTX_RES"
+	LogMapping[108] = "IOTTX_ACK_REQ"
+	LogMapping[109] = "IOTTX_ACK_RES"
 
 	LogMapping[130] = "NEIGHBOR_REQ"
 	LogMapping[140] = "NEIGHBOR_RES"
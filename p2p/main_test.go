@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"github.com/bazo-blockchain/bazo-miner/storage"
 	"os"
 	"testing"
 )
@@ -9,12 +10,19 @@ var (
 	MINER_IPPORT = "127.0.0.1:8000"
 )
 
+const (
+	TestDBFileName = "test.db"
+)
+
 //Corresponds largely to server.go -> Init(...)
 func TestMain(m *testing.M) {
 	//Used for some tests, the bootstarp server is listening at 8000 at the same time
 	Ipport = "127.0.0.1:9000"
 	InitLogging()
 
+	//Broadcasts are queued in the database while no miner is connected
+	storage.Init(TestDBFileName, "")
+
 	peers.minerConns = make(map[*peer]bool)
 	peers.clientConns = make(map[*peer]bool)
 
@@ -36,5 +44,9 @@ func TestMain(m *testing.M) {
 	//Bootstrap server
 	go listener("127.0.0.1:8000")
 
-	os.Exit(m.Run())
+	retCode := m.Run()
+
+	storage.TearDown()
+	os.Remove(TestDBFileName)
+	os.Exit(retCode)
 }
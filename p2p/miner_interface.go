@@ -2,7 +2,6 @@ package p2p
 
 import (
 	"github.com/bazo-blockchain/bazo-miner/protocol"
-	"sync"
 )
 
 var (
@@ -22,23 +21,38 @@ var (
 	StakeTxChan  		= make(chan *protocol.StakeTx)
 	AggTxChan    	= make(chan *protocol.AggTx)
 	IoTTxChan    		= make(chan *protocol.IotTx)
+	ContractTxChan		= make(chan *protocol.ContractTx)
 
 
 	BlockReqChan = make(chan []byte)
 
-	receivedTXStash = make([]*protocol.FundsTx, 0)
-	receivedAggTxStash = make([]*protocol.AggTx, 0)
-
-	fundsTxSashMutex = &sync.Mutex{}
-	aggTxSashMutex = &sync.Mutex{}
+	//Snapshots are only requested when bootstrapping, responses nobody waits for anymore are dropped.
+	SnapshotReqChan = make(chan []byte)
+	snapshotQueue   = newPayloadQueue(SnapshotReqChan, FETCH_PEERS_DEFAULT)
+
+	//Requested by light clients, same as for snapshots, responses nobody waits for anymore are dropped.
+	BlockHeaderReqChan = make(chan []byte)
+	MerkleProofReqChan = make(chan []byte)
+	AccountProofReqChan = make(chan []byte)
+	blockHeaderQueue    = newPayloadQueue(BlockHeaderReqChan, 1)
+	merkleProofQueue    = newPayloadQueue(MerkleProofReqChan, 1)
+	accountProofQueue   = newPayloadQueue(AccountProofReqChan, 1)
+
+	//FundsTx and IotTx received from the network, the miner publishes them to its subscribers. Buffered and written
+	//without blocking, a slow consumer must never stall the processing of broadcasts.
+	TxEventOut = make(chan protocol.Transaction, 100)
+
+	//Slashing proofs gossiped by other miners, the miner verifies them before relaying them with
+	//BroadcastSlashingProof. Proofs arriving while the queue is full are dropped.
+	SlashingProofIn    = make(chan []byte)
+	slashingProofQueue = newPayloadQueue(SlashingProofIn, 10)
 )
 
 //This is for blocks and txs that the miner successfully validated.
 func forwardBlockBrdcstToMiner() {
 	for {
 		block := <-BlockOut
-		toBrdcst := BuildPacket(BLOCK_BRDCST, block)
-		minerBrdcstMsg <- toBrdcst
+		minerBrdcstMsg <- compactBlockPacket(block)
 	}
 }
 
@@ -56,29 +70,31 @@ func forwardVerifiedTxsToMiner() {
 	}
 }
 
-func forwardBlockToMiner(p *peer, payload []byte) {
-	BlockIn <- payload
+func notifyTxEvent(tx protocol.Transaction) {
+	select {
+	case TxEventOut <- tx:
+	default:
+	}
 }
 
-//Checks if Tx Is in the received stash. If true, we received the transaction with a request already.
-func txAlreadyInStash(slice []*protocol.FundsTx, newTXHash [32]byte) bool {
-	for _, txInStash := range slice {
-		if txInStash.Hash() == newTXHash {
-			return true
-		}
-	}
-	return false
+//Transactions submitted to the miner directly (e.g. via RPC), to the network
+func BroadcastTx(payload []byte, brdcstType uint8) {
+	minerBrdcstMsg <- BuildPacket(brdcstType, payload)
 }
 
-func aggTxAlreadyInStash(slice []*protocol.AggTx, newTXHash [32]byte) bool {
-	for _, txInStash := range slice {
-		if txInStash.Hash() == newTXHash {
-			return true
-		}
-	}
-	return false
+func BroadcastSlashingProof(payload []byte) {
+	minerBrdcstMsg <- BuildPacket(SLASHING_PROOF_BRDCST, payload)
 }
 
+//Returns true if the payload is queued, see budget.go.
+func forwardSlashingProofToMiner(p *peer, payload []byte) bool {
+	return slashingProofQueue.push(p, payload)
+}
+
+func forwardBlockToMiner(p *peer, payload []byte) {
+	rememberBlockRelay(p, payload)
+	BlockIn <- payload
+}
 
 //These are transactions the miner specifically requested.
 func forwardTxReqToMiner(p *peer, payload []byte, txType uint8) {
@@ -86,81 +102,118 @@ func forwardTxReqToMiner(p *peer, payload []byte, txType uint8) {
 		return
 	}
 
+	//Only the first response to a request is delivered, see fetchpeers.go.
 	switch txType {
 	case FUNDSTX_RES:
 		var fundsTx *protocol.FundsTx
-	fundsTx = fundsTx.Decode(payload)
-	if fundsTx == nil {
-		return
+		fundsTx, err := fundsTx.Decode(payload)
+		if err != nil {
+			logDroppedMessage(p, txType, err)
+			return
 		}
-	// If TX is not received with the last 1000 Transaction, send it through the channel to the TX_FETCH.
-	// Otherwise send nothing. This means, that the TX was sent before and we ensure, that only one TX per Broadcast
-	// request is going through to the FETCH Request. This should prevent the "Received txHash did not correspond to
-	// our request." error
-	// The Mutex Lock is needed, because sometimes the execution is too fast. And even with the stash transactions
-	// are sent multiple times through the channel.
-		// The same concept is used for the AggTx below.
-		fundsTxSashMutex.Lock()
-		if !txAlreadyInStash(receivedTXStash, fundsTx.Hash()) {
-			receivedTXStash = append(receivedTXStash, fundsTx)
-			FundsTxChan <- fundsTx
-			if len(receivedTXStash) > 1000 {
-				receivedTXStash = append(receivedTXStash[:0], receivedTXStash[1:]...)
-			}
+		if !takePendingFetch(fundsTx.Hash()) {
+			return
 		}
-		fundsTxSashMutex.Unlock()
+		FundsTxChan <- fundsTx
 	case ACCTX_RES:
 		var accTx *protocol.AccTx
-		accTx = accTx.Decode(payload)
-		if accTx == nil {
+		accTx, err := accTx.Decode(payload)
+		if err != nil {
+			logDroppedMessage(p, txType, err)
+			return
+		}
+		if !takePendingFetch(accTx.Hash()) {
 			return
 		}
 		AccTxChan <- accTx
 	case CONFIGTX_RES:
 		var configTx *protocol.ConfigTx
-		configTx = configTx.Decode(payload)
-		if configTx == nil {
+		configTx, err := configTx.Decode(payload)
+		if err != nil {
+			logDroppedMessage(p, txType, err)
+			return
+		}
+		if !takePendingFetch(configTx.Hash()) {
 			return
 		}
 		ConfigTxChan <- configTx
 	case STAKETX_RES:
 		var stakeTx *protocol.StakeTx
-		stakeTx = stakeTx.Decode(payload)
-		if stakeTx == nil {
+		stakeTx, err := stakeTx.Decode(payload)
+		if err != nil {
+			logDroppedMessage(p, txType, err)
+			return
+		}
+		if !takePendingFetch(stakeTx.Hash()) {
 			return
 		}
 		StakeTxChan <- stakeTx
 	case AGGTX_RES:
 		var aggTx *protocol.AggTx
-		aggTx = aggTx.Decode(payload)
-		if aggTx == nil {
+		aggTx, err := aggTx.Decode(payload)
+		if err != nil {
+			logDroppedMessage(p, txType, err)
 			return
 		}
-
-		aggTxSashMutex.Lock()
-		if !aggTxAlreadyInStash(receivedAggTxStash, aggTx.Hash()) {
-			receivedAggTxStash = append(receivedAggTxStash, aggTx)
-			AggTxChan <- aggTx
-			if len(receivedAggTxStash) > 1000 {
-				receivedAggTxStash = append(receivedAggTxStash[:0], receivedAggTxStash[1:]...)
-			}
+		if !takePendingFetch(aggTx.Hash()) {
+			return
 		}
-		aggTxSashMutex.Unlock()
+		AggTxChan <- aggTx
 	case IOTTX_RES:
 		var IoTTx *protocol.IotTx
-		IoTTx = IoTTx.Decode(payload)
-		if IoTTx == nil {
+		IoTTx, err := IoTTx.Decode(payload)
+		if err != nil {
+			logDroppedMessage(p, txType, err)
+			return
+		}
+		if !takePendingFetch(IoTTx.Hash()) {
 			return
 		}
 		IoTTxChan <- IoTTx
+	case CONTRACTTX_RES:
+		var contractTx *protocol.ContractTx
+		contractTx, err := contractTx.Decode(payload)
+		if err != nil {
+			logDroppedMessage(p, txType, err)
+			return
+		}
+		if !takePendingFetch(contractTx.Hash()) {
+			return
+		}
+		ContractTxChan <- contractTx
 	}
 
 }
 
 func forwardBlockReqToMiner(p *peer, payload []byte) {
+	var block *protocol.Block
+	block, err := block.Decode(payload)
+	if err != nil {
+		logDroppedMessage(p, BLOCK_RES, err)
+		return
+	}
+	if !takePendingFetch(block.Hash, block.HashWithoutTx) && !takePendingLastBlock() {
+		return
+	}
+
 	BlockReqChan <- payload
 }
 
+//Returns true if the payload is queued, see budget.go.
+func forwardSnapshotReqToMiner(p *peer, payload []byte) bool {
+	//Every requested peer counts once, a peer sending several snapshots could otherwise confirm its own.
+	if !takePendingSnapshot(p) {
+		return false
+	}
+
+	return snapshotQueue.push(p, payload)
+}
+
+//Returns true if the payload is queued, see budget.go.
+func forwardLightClientResToMiner(p *peer, queue *payloadQueue, payload []byte) bool {
+	return queue.push(p, payload)
+}
+
 func ReadSystemTime() int64 {
 	return systemTime
 }
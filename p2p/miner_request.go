@@ -1,6 +1,7 @@
 package p2p
 
 import (
+	"encoding/binary"
 	"errors"
 )
 
@@ -14,13 +15,10 @@ func BlockReq(hash [32]byte, hashWithoutTx [32]byte) error {
 
 	payload = append(payload, payloadTEMP...)
 
-	// Block Request with a Broadcast request. This does rise the possibility of a valid answer.
-	for p := range peers.minerConns {
+	// Block Request to several miners. This does rise the possibility of a valid answer, see fetchpeers.go.
+	addPendingFetch(hash, hashWithoutTx)
+	for _, p := range peers.getFetchPeers() {
 		//Write to the channel, which the peerBroadcast(*peer) running in a seperate goroutine consumes right away.
-
-		if p == nil {
-			return errors.New("Couldn't get a connection, request not transmitted.")
-		}
 		packet := BuildPacket(BLOCK_REQ, payload)
 		sendData(p, packet)
 	}
@@ -35,24 +33,92 @@ func LastBlockReq() error {
 		return errors.New("Couldn't get a connection, request not transmitted.")
 	}
 
+	addPendingLastBlock()
 	packet := BuildPacket(BLOCK_REQ, nil)
 	sendData(p, packet)
 	return nil
 }
 
+//Requests the latest state snapshot of several miners (see fetchpeers.go), used to bootstrap without replaying the
+//whole chain. The miner only trusts a snapshot served by more than one of them, returns the number of miners asked.
+func SnapshotReq() (int, error) {
+
+	snapshotPeers := peers.getFetchPeers()
+	if len(snapshotPeers) == 0 {
+		return 0, errors.New("Couldn't get a connection, request not transmitted.")
+	}
+
+	packet := BuildPacket(SNAPSHOT_REQ, nil)
+	for _, p := range snapshotPeers {
+		addPendingSnapshot(p)
+		sendData(p, packet)
+	}
+	return len(snapshotPeers), nil
+}
+
 //Request specific transaction
 func TxReq(hash [32]byte, reqType uint8) error {
 
-	// Tx Request also as brodcast so that teh possibility of an answer is higher.
-	for p := range peers.minerConns {
+	// Tx Request to several miners so that the possibility of an answer is higher, see fetchpeers.go.
+	addPendingFetch(hash)
+	for _, p := range peers.getFetchPeers() {
 		//Write to the channel, which the peerBroadcast(*peer) running in a seperate goroutine consumes right away.
-
-		if p == nil {
-			return errors.New("Couldn't get a connection, request not transmitted.")
-		}
 		packet := BuildPacket(reqType, hash[:])
 		sendData(p, packet)
 	}
 
 	return nil
 }
+
+//Requests the header of a specific block. Used by light clients.
+func BlockHeaderReq(hash [32]byte, hashWithoutTx [32]byte) error {
+
+	p := peers.getRandomPeer(PEERTYPE_MINER)
+	if p == nil {
+		return errors.New("Couldn't get a connection, request not transmitted.")
+	}
+
+	packet := BuildPacket(BLOCK_HEADER_REQ, append(hash[:], hashWithoutTx[:]...))
+	sendData(p, packet)
+	return nil
+}
+
+func LastBlockHeaderReq() error {
+
+	p := peers.getRandomPeer(PEERTYPE_MINER)
+	if p == nil {
+		return errors.New("Couldn't get a connection, request not transmitted.")
+	}
+
+	packet := BuildPacket(BLOCK_HEADER_REQ, nil)
+	sendData(p, packet)
+	return nil
+}
+
+//Requests the proof of an account after the block at the height. Used by light clients.
+func AccountProofReq(address [32]byte, height uint32) error {
+
+	p := peers.getRandomPeer(PEERTYPE_MINER)
+	if p == nil {
+		return errors.New("Couldn't get a connection, request not transmitted.")
+	}
+
+	var encodedHeight [4]byte
+	binary.BigEndian.PutUint32(encodedHeight[:], height)
+	packet := BuildPacket(ACCOUNT_PROOF_REQ, append(address[:], encodedHeight[:]...))
+	sendData(p, packet)
+	return nil
+}
+
+//Requests the Merkle proof of a tx in a block. Used by light clients.
+func MerkleProofReq(blockHash [32]byte, txHash [32]byte) error {
+
+	p := peers.getRandomPeer(PEERTYPE_MINER)
+	if p == nil {
+		return errors.New("Couldn't get a connection, request not transmitted.")
+	}
+
+	packet := BuildPacket(MERKLE_PROOF_REQ, append(blockHash[:], txHash[:]...))
+	sendData(p, packet)
+	return nil
+}
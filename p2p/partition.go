@@ -0,0 +1,53 @@
+package p2p
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+//If we lose all miner connections, outgoing block and tx broadcasts are written to disk instead of being dropped.
+//As soon as a miner connects again, they are replayed in the original order. Otherwise, the blocks a briefly isolated
+//validator produced would never reach the network. The queue is bounded by MAX_QUEUED_BROADCAST_SIZE, during a long
+//partition the oldest broadcasts are dropped first.
+
+//Only blocks and txs are worth keeping, everything else (e.g., time broadcasts) is outdated after a partition anyway.
+func isQueueableBroadcast(packet []byte) bool {
+	if len(packet) < HEADER_LEN {
+		return false
+	}
+
+	switch extractHeader(packet).TypeID {
+	case BLOCK_BRDCST, COMPACTBLOCK_BRDCST, FUNDSTX_BRDCST, ACCTX_BRDCST, CONFIGTX_BRDCST, STAKETX_BRDCST, AGGTX_BRDCST, IOTTX_BRDCST, CONTRACTTX_BRDCST:
+		return true
+	}
+
+	return false
+}
+
+func queueBroadcast(packet []byte) {
+	if !isQueueableBroadcast(packet) {
+		return
+	}
+
+	dropped, err := storage.WriteOutboundBroadcast(packet, MAX_QUEUED_BROADCAST_SIZE)
+	if err != nil {
+		logger.Printf("Queueing broadcast failed: %v\n", err)
+	}
+	if dropped > 0 {
+		logger.Printf("Broadcast queue full, dropped the %v oldest broadcasts.\n", dropped)
+	}
+}
+
+func replayQueuedBroadcasts() {
+	packets := storage.ReadAllOutboundBroadcasts()
+	if len(packets) == 0 {
+		return
+	}
+
+	storage.DeleteAllOutboundBroadcasts()
+	logger.Printf("Replaying %v broadcasts queued while disconnected.\n", len(packets))
+
+	//If we get disconnected again, the broadcast service queues them once more
+	for _, packet := range packets {
+		minerBrdcstMsg <- packet
+	}
+}
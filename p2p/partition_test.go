@@ -0,0 +1,64 @@
+package p2p
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/storage"
+)
+
+func TestIsQueueableBroadcast(t *testing.T) {
+	if !isQueueableBroadcast(BuildPacket(BLOCK_BRDCST, []byte{1, 2, 3})) {
+		t.Errorf("Block broadcast should be queued\n")
+	}
+	if !isQueueableBroadcast(BuildPacket(FUNDSTX_BRDCST, []byte{1, 2, 3})) {
+		t.Errorf("Tx broadcast should be queued\n")
+	}
+	if isQueueableBroadcast(BuildPacket(TIME_BRDCST, getTime())) {
+		t.Errorf("Time broadcast should not be queued\n")
+	}
+	if isQueueableBroadcast([]byte{0, 1}) {
+		t.Errorf("Malformed packet should not be queued\n")
+	}
+}
+
+func TestQueueBroadcast(t *testing.T) {
+	storage.DeleteAllOutboundBroadcasts()
+
+	blockPacket := BuildPacket(BLOCK_BRDCST, []byte{1, 2, 3})
+	txPacket := BuildPacket(FUNDSTX_BRDCST, []byte{4, 5, 6})
+
+	queueBroadcast(blockPacket)
+	queueBroadcast(BuildPacket(TIME_BRDCST, getTime()))
+	queueBroadcast(txPacket)
+
+	queued := storage.ReadAllOutboundBroadcasts()
+	if !reflect.DeepEqual(queued, [][]byte{blockPacket, txPacket}) {
+		t.Errorf("Queued broadcasts do not match: %v\n", queued)
+	}
+
+	storage.DeleteAllOutboundBroadcasts()
+	if queued = storage.ReadAllOutboundBroadcasts(); len(queued) != 0 {
+		t.Errorf("Queued broadcasts have not been deleted: %v\n", queued)
+	}
+}
+
+func TestQueueBroadcastLimit(t *testing.T) {
+	storage.DeleteAllOutboundBroadcasts()
+	defer storage.DeleteAllOutboundBroadcasts()
+
+	var packets [][]byte
+	for i := 0; i < 4; i++ {
+		packet := BuildPacket(BLOCK_BRDCST, make([]byte, 10))
+		packet[HEADER_LEN] = byte(i)
+		packets = append(packets, packet)
+		if _, err := storage.WriteOutboundBroadcast(packet, uint64(3*len(packet))); err != nil {
+			t.Fatalf("Queueing broadcast failed: %v\n", err)
+		}
+	}
+
+	//Only the three most recent broadcasts fit into the queue.
+	if queued := storage.ReadAllOutboundBroadcasts(); !reflect.DeepEqual(queued, packets[1:]) {
+		t.Errorf("Queued broadcasts do not match: %v\n", queued)
+	}
+}
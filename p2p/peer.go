@@ -22,6 +22,13 @@ type peer struct {
 	listenerPort string
 	time         int64
 	peerType     uint
+	//Unix time and height of the last block relayed by this peer that passed validation
+	lastBeacon   int64
+	beaconHeight uint32
+	//Height of the highest block relayed by this peer, validated or not
+	announcedHeight uint32
+	//Size of the payloads received from this peer but not yet processed, see budget.go
+	memoryInUse uint64
 }
 
 //Block constructor, argument is the previous block in the blockchain.
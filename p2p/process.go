@@ -19,50 +19,48 @@ func processTxBrdcst(p *peer, payload []byte, brdcstType uint8) {
 	defer processTxBroadcastMutex.Unlock()
 
 	var tx protocol.Transaction
+	var err error
 	//Make sure the transaction can be properly decoded, verification is done at a later stage to reduce latency
 	switch brdcstType {
 	case FUNDSTX_BRDCST:
 		var fTx *protocol.FundsTx
-		fTx = fTx.Decode(payload)
-		if fTx == nil {
-			return
+		if fTx, err = fTx.Decode(payload); err == nil {
+			tx = fTx
 		}
-		tx = fTx
 	case ACCTX_BRDCST:
 		var aTx *protocol.AccTx
-		aTx = aTx.Decode(payload)
-		if aTx == nil {
-			return
+		if aTx, err = aTx.Decode(payload); err == nil {
+			tx = aTx
 		}
-		tx = aTx
 	case CONFIGTX_BRDCST:
 		var cTx *protocol.ConfigTx
-		cTx = cTx.Decode(payload)
-		if cTx == nil {
-			return
+		if cTx, err = cTx.Decode(payload); err == nil {
+			tx = cTx
 		}
-		tx = cTx
 	case STAKETX_BRDCST:
 		var sTx *protocol.StakeTx
-		sTx = sTx.Decode(payload)
-		if sTx == nil {
-			return
+		if sTx, err = sTx.Decode(payload); err == nil {
+			tx = sTx
 		}
-		tx = sTx
 	case AGGTX_BRDCST:
 		var fTx *protocol.AggTx
-		fTx = fTx.Decode(payload)
-		if fTx == nil {
-			return
+		if fTx, err = fTx.Decode(payload); err == nil {
+			tx = fTx
 		}
-		tx = fTx
 	case IOTTX_BRDCST:
 		var iTx *protocol.IotTx
-		iTx = iTx.Decode(payload)
-		if iTx == nil {
-			return
+		if iTx, err = iTx.Decode(payload); err == nil {
+			tx = iTx
+		}
+	case CONTRACTTX_BRDCST:
+		var cTx *protocol.ContractTx
+		if cTx, err = cTx.Decode(payload); err == nil {
+			tx = cTx
 		}
-		tx = iTx
+	}
+	if err != nil {
+		logDroppedMessage(p, brdcstType, err)
+		return
 	}
 
 	//Response tx acknowledgment if the peer is a client
@@ -75,14 +73,23 @@ func processTxBrdcst(p *peer, payload []byte, brdcstType uint8) {
 		//logger.Printf("Received transaction (%x) already in the mempool.\n", tx.Hash())
 		return
 	}
-	if storage.ReadClosedTx(tx.Hash()) != nil {
+	if storage.IsClosedTx(tx.Hash()) {
 		//logger.Printf("Received transaction (%x) already validated.\n", tx.Hash())
 		return
 	}
+	if storage.IsParkedTx(tx) {
+		return
+	}
 
 	//Write to mempool and rebroadcast
 	//logger.Printf("Writing transaction (%x) in the mempool.\n", tx.Hash())
-	storage.WriteOpenTx(tx)
+	if err := storage.WriteOpenTxOrdered(tx); err != nil {
+		logger.WithField("txhash", tx.Hash()).Warnf("Received transaction rejected: %v", err)
+		return
+	}
+	if brdcstType == FUNDSTX_BRDCST {
+		notifyTxEvent(tx)
+	}
 	toBrdcst := BuildPacket(brdcstType, payload)
 	minerBrdcstMsg <- toBrdcst
 }
@@ -93,8 +100,9 @@ func processIotTxBrdcst(p *peer, payload []byte, brdcstType uint8) {
 	switch brdcstType {
 	case IOTTX_BRDCST:
 		var sTx *protocol.IotTx
-		sTx = sTx.Decode(payload)
-		if sTx == nil {
+		sTx, err := sTx.Decode(payload)
+		if err != nil {
+			logDroppedMessage(p, brdcstType, err)
 			return
 		}
 		tx = sTx
@@ -111,16 +119,22 @@ func processIotTxBrdcst(p *peer, payload []byte, brdcstType uint8) {
 		logger.Printf("Received  IoT transaction (%x) already in the mempool.\n", tx.Hash())
 		return
 	}
-	if storage.ReadClosedTx(tx.Hash()) != nil {
+	if storage.IsClosedTx(tx.Hash()) {
 		logger.Printf("Received  IoT transaction (%x) already validated.\n", tx.Hash())
 		return
 	}
+	if storage.IsParkedTx(tx) {
+		return
+	}
 
 	//Write to mempool and rebroadcast
 	//logger.Printf("Writing IoT transaction (%x) in the mempool.\n", tx.Hash())
 
-	storage.WriteOpenTx(tx)
-
+	if err := storage.WriteOpenTxOrdered(tx); err != nil {
+		logger.WithField("txhash", tx.Hash()).Warnf("Received IoT transaction rejected: %v", err)
+		return
+	}
+	notifyTxEvent(tx)
 
 	toBrdcst := BuildPacket(brdcstType, payload)
 	minerBrdcstMsg <- toBrdcst
@@ -171,3 +185,8 @@ func _processNeighborRes(payload []byte) (ipportList []string) {
 
 	return ipportList
 }
+
+//Messages which can not be decoded or contain out-of-range fields are dropped as a whole, never processed partially.
+func logDroppedMessage(p *peer, typeID uint8, err error) {
+	logger.WithField("peer", p.getIPPort()).Warnf("Dropped %v: %v", LogMapping[typeID], err)
+}
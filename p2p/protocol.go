@@ -15,6 +15,9 @@ const (
 	BLOCK_HEADER_BRDCST		= 7
 	TX_BRDCST_ACK      		= 8
 	AGGTX_BRDCST      = 9
+	CONTRACTTX_BRDCST = 10
+	COMPACTBLOCK_BRDCST = 11
+	SLASHING_PROOF_BRDCST = 12
 
 	FUNDSTX_REQ            	= 20
 	ACCTX_REQ              	= 21
@@ -26,6 +29,12 @@ const (
 	ROOTACC_REQ            	= 27
 	INTERMEDIATE_NODES_REQ 	= 28
 	AGGTX_REQ			= 29
+	SNAPSHOT_REQ			= 30
+	MERKLE_PROOF_REQ		= 31
+	CONTRACTTX_REQ			= 32
+	FULLBLOCK_REQ			= 33
+	BATCH_TX_REQ			= 34
+	ACCOUNT_PROOF_REQ		= 35
 
 
 	FUNDSTX_RES            	= 40
@@ -38,6 +47,11 @@ const (
 	ROOTACC_RES            	= 47
 	INTERMEDIATE_NODES_RES 	= 48
 	AGGTX_RES			= 49
+	SNAPSHOT_RES			= 50
+	MERKLE_PROOF_RES		= 51
+	CONTRACTTX_RES			= 52
+	BATCH_TX_RES			= 53
+	ACCOUNT_PROOF_RES		= 54
 
 	NEIGHBOR_REQ = 130
 	NEIGHBOR_RES = 140
@@ -52,6 +66,8 @@ const (
 	IOTTX_BRDCST	= 105
 	IOTTX_REQ		= 106
 	IOTTX_RES		= 107
+	IOTTX_ACK_REQ	= 108
+	IOTTX_ACK_RES	= 109
 
 	//Used to signal error
 	NOT_FOUND = 110
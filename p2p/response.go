@@ -25,8 +25,9 @@ func txRes(p *peer, payload []byte, txKind uint8) {
 		tx = closedTx
 	}
 
-	//In case it was not found, send a corresponding message back
-	if tx == nil {
+	//In case it was not found, send a corresponding message back. IoT txs whose data has been pruned do not match their
+	//hash anymore and can't be served either.
+	if tx == nil || tx.Hash() != txHash {
 		packet := BuildPacket(NOT_FOUND, nil)
 		sendData(p, packet)
 		return
@@ -46,6 +47,8 @@ func txRes(p *peer, payload []byte, txKind uint8) {
 		packet = BuildPacket(AGGTX_RES, tx.Encode())
 	case IOTTX_REQ:
 		packet = BuildPacket(IOTTX_RES, tx.Encode())
+	case CONTRACTTX_REQ:
+		packet = BuildPacket(CONTRACTTX_RES, tx.Encode())
 	}
 
 	sendData(p, packet)
@@ -83,15 +86,41 @@ func blockRes(p *peer, payload []byte) {
 	sendData(p, packet)
 }
 
+//Sent to peers that could not reconstruct a compact block. The block is answered as a block broadcast, the peer did
+//not request it for the block fetching of the miner (see BlockReq).
+func fullBlockRes(p *peer, payload []byte) {
+	if len(payload) != 32 {
+		return
+	}
+
+	var blockHash [32]byte
+	copy(blockHash[:], payload)
+
+	block := storage.ReadClosedBlock(blockHash)
+	if block == nil {
+		if block = storage.ReadOpenBlock(blockHash); block == nil {
+			return
+		}
+	}
+
+	sendData(p, BuildPacket(BLOCK_BRDCST, block.Encode()))
+}
+
 //Response the requested block SPV header
 func blockHeaderRes(p *peer, payload []byte) {
 	var encodedHeader, packet []byte
 
-	//If no specific header is requested, send latest
+	//If no specific header is requested, send latest. The hash without tx is optional, it is needed to find
+	//aggregated blocks.
 	if len(payload) > 0 {
-		var blockHash [32]byte
+		var blockHash, blockHashWithoutTx [32]byte
 		copy(blockHash[:], payload[:32])
-		if block := storage.ReadClosedBlock(blockHash); block != nil {
+		block := storage.ReadClosedBlock(blockHash)
+		if block == nil && len(payload) >= 64 {
+			copy(blockHashWithoutTx[:], payload[32:64])
+			block = storage.ReadClosedBlockWithoutTx(blockHashWithoutTx)
+		}
+		if block != nil {
 			block.InitBloomFilter(append(storage.GetTxPubKeys(block)))
 			encodedHeader = block.EncodeHeader()
 		}
@@ -246,3 +275,74 @@ func intermediateNodesRes(p *peer, payload []byte) {
 
 	sendData(p, packet)
 }
+
+//Sends the Merkle proof of a tx, the payload consists of the block hash followed by the tx hash. The proof can be
+//verified against the Merkle root of the block header.
+func merkleProofRes(p *peer, payload []byte) {
+	var blockHash, txHash [32]byte
+	var packet []byte
+
+	if len(payload) >= 64 {
+		copy(blockHash[:], payload[:32])
+		copy(txHash[:], payload[32:64])
+	}
+
+	block := storage.ReadClosedBlock(blockHash)
+	if proof, err := protocol.BuildMerkleTree(block).GetMerkleProof(txHash); err == nil {
+		packet = BuildPacket(MERKLE_PROOF_RES, proof.Encode())
+	} else {
+		packet = BuildPacket(NOT_FOUND, nil)
+	}
+
+	sendData(p, packet)
+}
+
+//Sends the proof of an account against the state root of a block, the payload consists of the account's hash followed
+//by the height of the block (see storage.ReadAccountProof). Only the most recent blocks can be served.
+func accountProofRes(p *peer, payload []byte) {
+	var address [32]byte
+	var packet []byte
+
+	if len(payload) >= 36 {
+		copy(address[:], payload[:32])
+		if proof, err := storage.ReadAccountProof(address, binary.BigEndian.Uint32(payload[32:36])); err == nil {
+			packet = BuildPacket(ACCOUNT_PROOF_RES, proof.Encode())
+		}
+	}
+	if packet == nil {
+		packet = BuildPacket(NOT_FOUND, nil)
+	}
+
+	sendData(p, packet)
+}
+
+//Sends the confirmation bundle of a closed IoT tx to the gateway that submitted it.
+func iotAckRes(p *peer, payload []byte) {
+	var txHash [32]byte
+	var packet []byte
+
+	if len(payload) >= 32 {
+		copy(txHash[:], payload[:32])
+	}
+
+	if ack, err := storage.ReadIotAck(txHash); err == nil {
+		packet = BuildPacket(IOTTX_ACK_RES, ack.Encode())
+	} else {
+		packet = BuildPacket(NOT_FOUND, nil)
+	}
+
+	sendData(p, packet)
+}
+
+//Sends the latest state snapshot, snapshots exceeding the maximum packet size can not be transferred.
+func snapshotRes(p *peer) {
+	var packet []byte
+
+	if encodedSnapshot := storage.ReadSnapshot(); encodedSnapshot != nil && len(encodedSnapshot) <= protocol.MAX_BLOCK_SIZE {
+		packet = BuildPacket(SNAPSHOT_RES, encodedSnapshot)
+	} else {
+		packet = BuildPacket(NOT_FOUND, nil)
+	}
+
+	sendData(p, packet)
+}
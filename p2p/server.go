@@ -73,6 +73,10 @@ func initiateNewMinerConnection(dial string) (*peer, error) {
 		return nil, errors.New(fmt.Sprintf("Cannot self-connect %v.", dial))
 	}
 
+	if err := checkOutboundDiversity(dial); err != nil {
+		return nil, err
+	}
+
 	//Open up a tcp dial and instantiate a peer struct, wait for adding it to the peerStruct before we finalize
 	//the handshake
 	conn, err := net.Dial("tcp", dial)
@@ -95,7 +99,10 @@ func initiateNewMinerConnection(dial string) (*peer, error) {
 	conn.Write(packet)
 
 	//Wait for the other party to finish the handshake with the corresponding message
-	header, _, err := RcvData(p)
+	header, payload, err := RcvData(p)
+	if err == nil {
+		releaseMemory(p, uint32(len(payload)))
+	}
 	if err != nil || header.TypeID != MINER_PONG {
 		return nil, errors.New(fmt.Sprintf("Failed to complete miner handshake: %v", err))
 	}
@@ -121,17 +128,27 @@ func listener(ipport string) {
 		return
 	}
 
+	shutdownMutex.Lock()
+	serverListener = listener
+	if shuttingDown {
+		listener.Close()
+	}
+	shutdownMutex.Unlock()
+
 	for {
 		conn, err := listener.Accept()
-
-		conn.(*net.TCPConn).SetKeepAlive(true)
-		conn.(*net.TCPConn).SetKeepAlivePeriod(1 * time.Minute)
-
 		if err != nil {
+			//The listener is closed on shutdown.
+			if IsShuttingDown() {
+				return
+			}
 			logger.Printf("%v\n", err)
 			continue
 		}
 
+		conn.(*net.TCPConn).SetKeepAlive(true)
+		conn.(*net.TCPConn).SetKeepAlivePeriod(1 * time.Minute)
+
 		p := newPeer(conn, "", 0)
 		go handleNewConn(p)
 	}
@@ -146,12 +163,14 @@ func handleNewConn(p *peer) {
 		return
 	}
 
-	processIncomingMsg(p, header, payload)
+	if !processIncomingMsg(p, header, payload) {
+		releaseMemory(p, uint32(len(payload)))
+	}
 }
 
 func peerConn(p *peer) {
 	if p.peerType == PEERTYPE_MINER {
-		logger.Printf("Adding a new miner: %v\n", p.getIPPort())
+		logger.WithField("peer", p.getIPPort()).Infof("Adding a new miner.")
 	} else if p.peerType == PEERTYPE_CLIENT {
 		//logger.Printf("Adding a new client: %v\n", p.getIPPort())
 	}
@@ -167,7 +186,7 @@ func peerConn(p *peer) {
 		header, payload, err := RcvData(p)
 		if err != nil {
 			if p.peerType == PEERTYPE_MINER {
-				logger.Printf("Miner disconnected: %v\n", err)
+				logger.WithField("peer", p.getIPPort()).Infof("Miner disconnected: %v", err)
 			} else if p.peerType == PEERTYPE_CLIENT {
 				//logger.Printf("Client disconnected: %v\n", err)
 			}
@@ -177,6 +196,10 @@ func peerConn(p *peer) {
 			return
 		}
 
-		go processIncomingMsg(p, header, payload)
+		go func() {
+			if !processIncomingMsg(p, header, payload) {
+				releaseMemory(p, uint32(len(payload)))
+			}
+		}()
 	}
 }
@@ -11,7 +11,12 @@ func peerService() {
 	for {
 		select {
 		case p := <-register:
+			//First miner after a partition, the broadcasts queued in the meantime can be sent now
+			reconnected := p.peerType == PEERTYPE_MINER && len(peers.minerConns) == 0
 			peers.add(p)
+			if reconnected {
+				go replayQueuedBroadcasts()
+			}
 		case p := <-disconnect:
 			peers.delete(p)
 			close(p.ch)
@@ -27,6 +32,10 @@ func broadcastService() {
 		select {
 		//Broadcasting all messages.
 		case msg := <-minerBrdcstMsg:
+			//Nobody would receive the broadcast, keep it for later
+			if len(peers.minerConns) == 0 {
+				queueBroadcast(msg)
+			}
 			for p := range peers.minerConns {
 				//Write to the channel, which the peerBroadcast(*peer) running in a seperate goroutine consumes right away.
 				if peers.contains(p.getIPPort(),PEERTYPE_MINER) {
@@ -59,6 +68,10 @@ func checkHealthService() {
 	for {
 		time.Sleep(HEALTH_CHECK_INTERVAL * time.Second)
 
+		if IsShuttingDown() {
+			return
+		}
+
 		if Ipport != storage.Bootstrap_Server && !peers.contains(storage.Bootstrap_Server, PEERTYPE_MINER) {
 			p, err := initiateNewMinerConnection(storage.Bootstrap_Server)
 			if p == nil || err != nil {
@@ -68,8 +81,10 @@ func checkHealthService() {
 			}
 		}
 
-		//Periodically check if we are well-connected
-		if len(peers.minerConns) >= MIN_MINERS {
+		//Periodically check if we are well-connected. Enough connections are not sufficient, some of them need to
+		//have relayed valid blocks recently. Otherwise we might be surrounded by a single attacker's peers.
+		if len(peers.minerConns) >= MAX_MINERS ||
+			(len(peers.minerConns) >= MIN_MINERS && beaconPeerCount() >= MIN_BEACON_PEERS) {
 			continue
 		}
 
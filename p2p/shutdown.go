@@ -0,0 +1,38 @@
+package p2p
+
+import (
+	"net"
+	"sync"
+)
+
+var (
+	serverListener net.Listener
+	shuttingDown   bool
+	shutdownMutex  = &sync.Mutex{}
+)
+
+func IsShuttingDown() bool {
+	shutdownMutex.Lock()
+	defer shutdownMutex.Unlock()
+
+	return shuttingDown
+}
+
+//Stops accepting new connections, stops reconnecting to peers and closes all open connections. The peers notice the
+//closed connection and drop us, no message is cut off halfway because every message is written under the peer's lock.
+func Shutdown() {
+	shutdownMutex.Lock()
+	shuttingDown = true
+	if serverListener != nil {
+		serverListener.Close()
+	}
+	shutdownMutex.Unlock()
+
+	for _, p := range append(peers.getAllPeers(PEERTYPE_MINER), peers.getAllPeers(PEERTYPE_CLIENT)...) {
+		p.l.Lock()
+		p.conn.Close()
+		p.l.Unlock()
+	}
+
+	logger.Printf("Closed all peer connections.\n")
+}
@@ -17,7 +17,7 @@ func Connect(connectionString string) *net.TCPConn {
 	conn, err := net.DialTCP("tcp", nil, tcpAddr)
 
 	if err != nil {
-		logger.Printf("Connection to %v failed.\n", connectionString)
+		logger.WithField("peer", connectionString).Warnf("Connection failed: %v", err)
 		return nil
 	}
 
@@ -35,14 +35,10 @@ func RcvData(p *peer) (header *Header, payload []byte, err error) {
 		return nil, nil, errors.New(fmt.Sprintf("Connection to %v aborted: %v", p.getIPPort(), err))
 	}
 
-	payload = make([]byte, header.Len)
-
-	for cnt := 0; cnt < int(header.Len); cnt++ {
-		payload[cnt], err = reader.ReadByte()
-		if err != nil {
-			p.conn.Close()
-			return nil, nil, errors.New(fmt.Sprintf("Connection to %v aborted: %v", p.getIPPort(), err))
-		}
+	payload, err = readPayload(p, reader, header)
+	if err != nil {
+		p.conn.Close()
+		return nil, nil, errors.New(fmt.Sprintf("Connection to %v aborted: %v", p.getIPPort(), err))
 	}
 
 	//logger.Printf("Receive message:\nSender: %v\nType: %v\nPayload length: %v\n", p.getIPPort(), LogMapping[header.TypeID], len(payload))
@@ -57,15 +53,14 @@ func RcvData_(c net.Conn) (header *Header, payload []byte, err error) {
 		c.Close()
 		return nil, nil, errors.New(fmt.Sprintf("Connection to aborted: (%v)\n", err))
 	}
-	payload = make([]byte, header.Len)
-
-	for cnt := 0; cnt < int(header.Len); cnt++ {
-		payload[cnt], err = reader.ReadByte()
-		if err != nil {
-			c.Close()
-			return nil, nil, errors.New(fmt.Sprintf("Connection to aborted: %v\n", err))
-		}
+	p := newPeer(c, "", 0)
+	payload, err = readPayload(p, reader, header)
+	if err != nil {
+		c.Close()
+		return nil, nil, errors.New(fmt.Sprintf("Connection to aborted: %v\n", err))
 	}
+	//The payload is not tracked once returned.
+	releaseMemory(p, uint32(len(payload)))
 
 	return header, payload, nil
 }
@@ -1,8 +1,6 @@
 package protocol
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
 )
@@ -17,6 +15,21 @@ type Account struct {
 	StakingBlockHeight uint32                // 4 Byte
 	Contract           []byte                // Arbitrary length
 	ContractVariables  []ByteArray           // Arbitrary length
+	Threshold          uint8                 // 1 Byte, only set for multi-signature accounts
+	Cosigners          [][32]byte            // Arbitrary length, only set for multi-signature accounts
+	Device             *DeviceInfo           // Only set for IoT devices
+	IotTokens          uint32                // 4 Byte, low fee IoT txs left in the rate limit bucket
+	IotRefillHeight    uint32                // 4 Byte, height of the last refill of the bucket, 0 if never used
+	UnbondingHeight    uint32                // 4 Byte, the stake is locked below this height after unstaking
+	Delegations        []Delegation          // Arbitrary length, only set for validators stake is delegated to
+}
+
+//Stake delegated to a validator (see miner/delegation.go). The rewards are the block rewards and fees paid out to the
+//delegator so far.
+type Delegation struct {
+	Delegator [32]byte // 32 Byte
+	Amount    uint64   // 8 Byte
+	Rewards   uint64   // 8 Byte
 }
 
 func NewAccount(address [32]byte,
@@ -37,6 +50,13 @@ func NewAccount(address [32]byte,
 		0,
 		contract,
 		contractVariables,
+		0,
+		nil,
+		nil,
+		0,
+		0,
+		0,
+		nil,
 	}
 
 	return newAcc
@@ -50,34 +70,124 @@ func (acc *Account) Hash() [32]byte {
 	return SerializeHashContent(acc.Address)
 }
 
+func (acc *Account) IsMultiSig() bool {
+	return acc.Threshold > 0
+}
+
+//Returns the sum of the stake delegated to the account.
+func (acc *Account) DelegatedStake() (stake uint64) {
+	for _, delegation := range acc.Delegations {
+		stake += delegation.Amount
+	}
+
+	return stake
+}
+
+//Returns the delegation of the delegator, nil if it has not delegated to the account.
+func (acc *Account) GetDelegation(delegator [32]byte) *Delegation {
+	for i := range acc.Delegations {
+		if acc.Delegations[i].Delegator == delegator {
+			return &acc.Delegations[i]
+		}
+	}
+
+	return nil
+}
+
 func (acc *Account) Encode() []byte {
 	if acc == nil {
 		return nil
 	}
 
-	encoded := Account{
-		Address:            acc.Address,
-		Issuer:             acc.Issuer,
-		Balance:            acc.Balance,
-		TxCnt:              acc.TxCnt,
-		IsStaking:          acc.IsStaking,
-		CommitmentKey:   	acc.CommitmentKey,
-		StakingBlockHeight: acc.StakingBlockHeight,
-		Contract:           acc.Contract,
-		ContractVariables:  acc.ContractVariables,
+	enc := newEncoder()
+	enc.array(acc.Address[:])
+	enc.array(acc.Issuer[:])
+	enc.uint64(acc.Balance)
+	enc.uint32(acc.TxCnt)
+	enc.bool(acc.IsStaking)
+	enc.array(acc.CommitmentKey[:])
+	enc.uint32(acc.StakingBlockHeight)
+	enc.bytes(acc.Contract)
+	enc.byteArrays(acc.ContractVariables)
+	delegated := len(acc.Delegations) > 0
+	if acc.IsMultiSig() || acc.IsDevice() || acc.IotRefillHeight > 0 || acc.UnbondingHeight > 0 || delegated {
+		enc.uint8(acc.Threshold)
+		enc.hashes(acc.Cosigners)
+	}
+	if acc.IsDevice() || acc.IotRefillHeight > 0 || acc.UnbondingHeight > 0 || delegated {
+		enc.device(acc.Device)
+	}
+	if acc.IotRefillHeight > 0 || acc.UnbondingHeight > 0 || delegated {
+		enc.uint32(acc.IotTokens)
+		enc.uint32(acc.IotRefillHeight)
+	}
+	if acc.UnbondingHeight > 0 || delegated {
+		enc.uint32(acc.UnbondingHeight)
+	}
+	if delegated {
+		enc.uint32(uint32(len(acc.Delegations)))
+		for _, delegation := range acc.Delegations {
+			enc.array(delegation.Delegator[:])
+			enc.uint64(delegation.Amount)
+			enc.uint64(delegation.Rewards)
+		}
 	}
 
-	buffer := new(bytes.Buffer)
-	gob.NewEncoder(buffer).Encode(encoded)
-	return buffer.Bytes()
+	return enc.Bytes()
 }
 
-func (*Account) Decode(encoded []byte) (acc *Account) {
+func (*Account) Decode(encoded []byte) (*Account, error) {
 	var decoded Account
-	buffer := bytes.NewBuffer(encoded)
-	decoder := gob.NewDecoder(buffer)
-	decoder.Decode(&decoded)
-	return &decoded
+	if !IsBinaryEncoded(encoded) {
+		if err := decodeGob(encoded, &decoded); err != nil {
+			return nil, err
+		}
+		return &decoded, nil
+	}
+
+	dec := newDecoder(encoded)
+	dec.array(decoded.Address[:])
+	dec.array(decoded.Issuer[:])
+	decoded.Balance = dec.uint64()
+	decoded.TxCnt = dec.uint32()
+	decoded.IsStaking = dec.bool()
+	dec.array(decoded.CommitmentKey[:])
+	decoded.StakingBlockHeight = dec.uint32()
+	decoded.Contract = dec.bytes()
+	decoded.ContractVariables = dec.byteArrays()
+	if dec.more() {
+		decoded.Threshold = dec.uint8()
+		decoded.Cosigners = dec.hashes()
+	}
+	if dec.more() {
+		decoded.Device = dec.device()
+	}
+	if dec.more() {
+		decoded.IotTokens = dec.uint32()
+		decoded.IotRefillHeight = dec.uint32()
+	}
+	if dec.more() {
+		decoded.UnbondingHeight = dec.uint32()
+	}
+	if dec.more() {
+		//Not preallocated, a count beyond the data makes the decoding fail.
+		for i, n := uint32(0), dec.uint32(); dec.err == nil && i < n; i++ {
+			var delegation Delegation
+			dec.array(delegation.Delegator[:])
+			delegation.Amount = dec.uint64()
+			delegation.Rewards = dec.uint64()
+			decoded.Delegations = append(decoded.Delegations, delegation)
+		}
+	}
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	if err := checkMultisigRange(decoded.Threshold, decoded.Cosigners); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
 }
 
 func (acc Account) String() string {
@@ -92,7 +202,13 @@ func (acc Account) String() string {
 			"CommitmentKey: %x, " +
 			"StakingBlockHeight: %v, " +
 			"Contract: %v, " +
-			"ContractVariables: %v",
+			"ContractVariables: %v, " +
+			"Threshold: %v, " +
+			"Cosigners: %v, " +
+			"Device: %v, " +
+			"IotTokens: %v, " +
+			"UnbondingHeight: %v, " +
+			"Delegations: %v",
 		addressHash[0:8],
 		acc.Address[0:8],
 		acc.Issuer[0:8],
@@ -102,5 +218,11 @@ func (acc Account) String() string {
 		acc.CommitmentKey[0:8],
 		acc.StakingBlockHeight,
 		acc.Contract,
-		acc.ContractVariables)
+		acc.ContractVariables,
+		acc.Threshold,
+		len(acc.Cosigners),
+		acc.Device,
+		acc.IotTokens,
+		acc.UnbondingHeight,
+		len(acc.Delegations))
 }
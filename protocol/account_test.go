@@ -39,7 +39,7 @@ func TestAccountCreation(t *testing.T) {
 }
 
 func TestAccountHash(t *testing.T) {
-	var address [64]byte
+	var address [32]byte
 	rand.Read(address[:])
 
 	hash1 := accA.Hash()
@@ -66,9 +66,38 @@ func TestAccountSerialization(t *testing.T) {
 
 	var compareAcc *Account
 	encodedAcc := accA.Encode()
-	compareAcc = compareAcc.Decode(encodedAcc)
+	compareAcc, _ = compareAcc.Decode(encodedAcc)
 
 	if !reflect.DeepEqual(accA, compareAcc) {
 		t.Error("Account encoding/decoding failed!")
 	}
 }
+
+func TestAccountDelegationSerialization(t *testing.T) {
+	acc := &Account{Address: [32]byte{0x01}, Balance: 1000, IsStaking: true}
+	plain := acc.Encode()
+
+	acc.Delegations = []Delegation{{Delegator: [32]byte{0x02}, Amount: 300, Rewards: 7}, {Delegator: [32]byte{0x03}, Amount: 200}}
+	encoded := acc.Encode()
+
+	var decoded *Account
+	decoded, err := decoded.Decode(encoded)
+	if err != nil || !reflect.DeepEqual(acc, decoded) {
+		t.Errorf("Account with delegations not decoded: %v\n", err)
+	}
+	if decoded.DelegatedStake() != 500 || decoded.GetDelegation([32]byte{0x02}).Rewards != 7 || decoded.GetDelegation([32]byte{0x04}) != nil {
+		t.Errorf("Delegations not read back: %v\n", decoded.Delegations)
+	}
+
+	//Accounts without delegations are encoded as before.
+	acc.Delegations = nil
+	if !reflect.DeepEqual(acc.Encode(), plain) {
+		t.Error("Encoding of an account without delegations changed.")
+	}
+
+	//The count of the delegations must not exceed the data.
+	truncated := encoded[:len(encoded)-48]
+	if _, err := decoded.Decode(truncated); err == nil {
+		t.Error("Account with a truncated delegation accepted.")
+	}
+}
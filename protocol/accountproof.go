@@ -0,0 +1,82 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+)
+
+//An account proof answers a light client what an account (balance, TxCnt, ...) was after the block at a given height.
+//It consists of the encoded account and its proof against the state root of the block (see statetrie.go). The light
+//client verifies it against a header it has validated itself, the block hash and height only tell which header that
+//is. A proof without account proves that the account did not exist.
+
+type AccountProof struct {
+	BlockHash [32]byte
+	Height    uint32
+
+	//Encoded account, empty if there is none
+	Account []byte
+	Proof   StateProof
+}
+
+//Checks the proof against the header and returns the account, nil if the proof shows that it does not exist.
+func VerifyAccountProof(header *Block, address [32]byte, proof *AccountProof) (*Account, error) {
+	if header == nil || proof == nil {
+		return nil, errors.New("Header or account proof missing.")
+	}
+	if header.Hash != proof.BlockHash || header.Height != proof.Height {
+		return nil, errors.New(fmt.Sprintf("Account proof is for block (%x) at height %v, not for block (%x) at height %v.", proof.BlockHash[0:8], proof.Height, header.Hash[0:8], header.Height))
+	}
+	if header.StateRoot == [32]byte{} {
+		return nil, errors.New(fmt.Sprintf("Block (%x) has no state root.", header.Hash[0:8]))
+	}
+
+	var acc *Account
+	if len(proof.Account) > 0 {
+		var err error
+		if acc, err = acc.Decode(proof.Account); err != nil {
+			return nil, errors.New(fmt.Sprintf("Account of the proof could not be decoded: %v", err))
+		}
+	}
+
+	if err := proof.Proof.Verify(header.StateRoot, address, acc); err != nil {
+		return nil, err
+	}
+
+	return acc, nil
+}
+
+func (proof *AccountProof) Encode() []byte {
+	if proof == nil {
+		return nil
+	}
+
+	enc := newEncoder()
+	enc.array(proof.BlockHash[:])
+	enc.uint32(proof.Height)
+	enc.bytes(proof.Account)
+	enc.bytes(proof.Proof.Encode())
+
+	return enc.Bytes()
+}
+
+func (*AccountProof) Decode(encoded []byte) (*AccountProof, error) {
+	var decoded AccountProof
+	dec := newDecoder(encoded)
+	dec.array(decoded.BlockHash[:])
+	decoded.Height = dec.uint32()
+	decoded.Account = dec.bytes()
+	encodedProof := dec.bytes()
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	var stateProof *StateProof
+	stateProof, err := stateProof.Decode(encodedProof)
+	if err != nil {
+		return nil, err
+	}
+	decoded.Proof = *stateProof
+
+	return &decoded, nil
+}
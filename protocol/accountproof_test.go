@@ -0,0 +1,40 @@
+package protocol
+
+import (
+	"testing"
+)
+
+func TestVerifyAccountProof(t *testing.T) {
+	acc := &Account{Address: [32]byte{'a', 'c', 'c'}, Balance: 42, TxCnt: 3}
+	other := &Account{Address: [32]byte{'o', 't', 'h', 'e', 'r'}, Balance: 1}
+	trie := NewStateTrie(map[[32]byte]*Account{acc.Hash(): acc, other.Hash(): other})
+	header := &Block{Hash: [32]byte{'b'}, Height: 7, StateRoot: trie.Root()}
+
+	var proof *AccountProof
+	proof, _ = proof.Decode((&AccountProof{BlockHash: header.Hash, Height: 7, Account: acc.Encode(), Proof: *trie.Proof(acc.Hash())}).Encode())
+	if proof == nil {
+		t.Fatal("Account proof could not be decoded.")
+	}
+
+	verified, err := VerifyAccountProof(header, acc.Hash(), proof)
+	if err != nil || verified == nil || verified.Balance != 42 || verified.TxCnt != 3 {
+		t.Errorf("Account proof not verified: %v, %v\n", verified, err)
+	}
+
+	if _, err := VerifyAccountProof(&Block{Hash: [32]byte{'c'}, Height: 7, StateRoot: trie.Root()}, acc.Hash(), proof); err == nil {
+		t.Error("Account proof of another block should not be verified.")
+	}
+
+	proof.Account = other.Encode()
+	if _, err := VerifyAccountProof(header, acc.Hash(), proof); err == nil {
+		t.Error("Account proof with another account should not be verified.")
+	}
+
+	//Accounts which do not exist are proven by a proof without account.
+	missing := [32]byte{'m', 'i', 's', 's', 'i', 'n', 'g'}
+	absence := &AccountProof{BlockHash: header.Hash, Height: 7, Proof: *trie.Proof(missing)}
+	absence, _ = absence.Decode(absence.Encode())
+	if verified, err := VerifyAccountProof(header, missing, absence); err != nil || verified != nil {
+		t.Errorf("Proof of absence not verified: %v, %v\n", verified, err)
+	}
+}
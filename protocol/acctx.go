@@ -1,31 +1,40 @@
 package protocol
 
 import (
-	"bytes"
 	"crypto/rand"
-	"encoding/gob"
 	"fmt"
 	"golang.org/x/crypto/ed25519"
 )
 
 const (
-	ACCTX_SIZE = 169
+	ACCTX_SIZE = 177
 )
 
+//If Amount is set, the new account is endowed with this balance by the issuing root account, the amount is debited from
+//the root account's balance. The account is funded atomically with its creation and no separate fundsTx (which may
+//arrive before the account exists) is needed.
+//If Threshold is set, the new account is a multi-signature account controlled by the Cosigners (see multisig.go).
+//If Device is set, the new account is an IoT device (see device.go).
+
 type AccTx struct {
 	Header            byte
 	Issuer            [32]byte
 	Fee               uint64
 	PubKey            [32]byte
 	Sig               [64]byte
+	Amount            uint64
 	Contract          []byte
 	ContractVariables []ByteArray
+	Threshold         uint8
+	Cosigners         [][32]byte
+	Device            *DeviceInfo
 }
 
-func ConstrAccTx(header byte, fee uint64, address [32]byte, rootPrivKey ed25519.PrivateKey, contract []byte, contractVariables []ByteArray) (tx *AccTx, privKey ed25519.PrivateKey, err error) {
+func ConstrAccTx(header byte, fee uint64, amount uint64, address [32]byte, rootPrivKey ed25519.PrivateKey, contract []byte, contractVariables []ByteArray) (tx *AccTx, privKey ed25519.PrivateKey, err error) {
 	tx = new(AccTx)
 	tx.Header = header
 	tx.Fee = fee
+	tx.Amount = amount
 	tx.Contract = contract
 	tx.ContractVariables = contractVariables
 	var tmpPrivKey ed25519.PrivateKey
@@ -65,6 +74,87 @@ func (tx *AccTx) Hash() [32]byte {
 		return [32]byte{}
 	}
 
+	if tx.Device != nil {
+		txHash := struct {
+			Header            byte
+			Issuer            [32]byte
+			Fee               uint64
+			PubKey            [32]byte
+			Amount            uint64
+			Contract          []byte
+			ContractVariables []ByteArray
+			Threshold         uint8
+			Cosigners         [][32]byte
+			DeviceType        string
+			DeviceOwner       [32]byte
+			DeviceRateLimit   uint32
+		}{
+			tx.Header,
+			tx.Issuer,
+			tx.Fee,
+			tx.PubKey,
+			tx.Amount,
+			tx.Contract,
+			tx.ContractVariables,
+			tx.Threshold,
+			tx.Cosigners,
+			tx.Device.Type,
+			tx.Device.Owner,
+			tx.Device.RateLimit,
+		}
+
+		return SerializeHashContent(txHash)
+	}
+
+	if tx.Threshold > 0 || len(tx.Cosigners) > 0 {
+		txHash := struct {
+			Header            byte
+			Issuer            [32]byte
+			Fee               uint64
+			PubKey            [32]byte
+			Amount            uint64
+			Contract          []byte
+			ContractVariables []ByteArray
+			Threshold         uint8
+			Cosigners         [][32]byte
+		}{
+			tx.Header,
+			tx.Issuer,
+			tx.Fee,
+			tx.PubKey,
+			tx.Amount,
+			tx.Contract,
+			tx.ContractVariables,
+			tx.Threshold,
+			tx.Cosigners,
+		}
+
+		return SerializeHashContent(txHash)
+	}
+
+	//The amount is only hashed if set, so the hashes of accTxs without an initial balance do not change.
+	if tx.Amount > 0 {
+		txHash := struct {
+			Header            byte
+			Issuer            [32]byte
+			Fee               uint64
+			PubKey            [32]byte
+			Amount            uint64
+			Contract          []byte
+			ContractVariables []ByteArray
+		}{
+			tx.Header,
+			tx.Issuer,
+			tx.Fee,
+			tx.PubKey,
+			tx.Amount,
+			tx.Contract,
+			tx.ContractVariables,
+		}
+
+		return SerializeHashContent(txHash)
+	}
+
 	txHash := struct {
 		Header            byte
 		Issuer            [32]byte
@@ -89,30 +179,68 @@ func (tx *AccTx) Encode() []byte {
 		return nil
 	}
 
-	encoded := AccTx{
-		Header: tx.Header,
-		Issuer: tx.Issuer,
-		Fee:    tx.Fee,
-		PubKey: tx.PubKey,
-		Sig:    tx.Sig,
+	enc := newEncoder()
+	enc.uint8(tx.Header)
+	enc.array(tx.Issuer[:])
+	enc.uint64(tx.Fee)
+	enc.array(tx.PubKey[:])
+	enc.array(tx.Sig[:])
+	enc.uint64(tx.Amount)
+	if tx.Threshold > 0 || len(tx.Cosigners) > 0 || tx.Device != nil {
+		enc.uint8(tx.Threshold)
+		enc.hashes(tx.Cosigners)
+	}
+	if tx.Device != nil {
+		enc.device(tx.Device)
 	}
 
-	buffer := new(bytes.Buffer)
-	gob.NewEncoder(buffer).Encode(encoded)
-	return buffer.Bytes()
+	return enc.Bytes()
 }
 
-func (*AccTx) Decode(encoded []byte) (tx *AccTx) {
+func (*AccTx) Decode(encoded []byte) (*AccTx, error) {
 	var decoded AccTx
-	buffer := bytes.NewBuffer(encoded)
-	decoder := gob.NewDecoder(buffer)
-	decoder.Decode(&decoded)
-	return &decoded
+	if !IsBinaryEncoded(encoded) {
+		if err := decodeGob(encoded, &decoded); err != nil {
+			return nil, err
+		}
+		return &decoded, nil
+	}
+
+	dec := newDecoder(encoded)
+	decoded.Header = dec.uint8()
+	dec.array(decoded.Issuer[:])
+	decoded.Fee = dec.uint64()
+	dec.array(decoded.PubKey[:])
+	dec.array(decoded.Sig[:])
+	decoded.Amount = dec.uint64()
+	if dec.more() {
+		decoded.Threshold = dec.uint8()
+		decoded.Cosigners = dec.hashes()
+	}
+	if dec.more() {
+		decoded.Device = dec.device()
+	}
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	if err := checkMultisigRange(decoded.Threshold, decoded.Cosigners); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
 }
 
 func (tx *AccTx) TxFee() uint64 { return tx.Fee }
 
-func (tx *AccTx) Size() uint64 { return ACCTX_SIZE }
+func (tx *AccTx) Size() uint64 {
+	size := ACCTX_SIZE + uint64(len(tx.Cosigners))*32
+	if tx.Device != nil {
+		size += DEVICE_INFO_SIZE + uint64(len(tx.Device.Type))
+	}
+
+	return size
+}
 
 func (tx *AccTx) Sender() [32]byte { return tx.Issuer}
 func (tx *AccTx) Receiver() [32]byte { return [32]byte{}}
@@ -126,14 +254,22 @@ func (tx AccTx) String() string {
 			"Fee: %v\n"+
 			"PubKey: %x\n"+
 			"Sig: %x\n"+
+			"Amount: %v\n"+
 			"Contract: %v\n"+
-			"ContractVariables: %v\n",
+			"ContractVariables: %v\n"+
+			"Threshold: %v\n"+
+			"Cosigners: %v\n"+
+			"Device: %v\n",
 		tx.Header,
 		tx.Issuer[0:8],
 		tx.Fee,
 		tx.PubKey[0:8],
 		tx.Sig[0:8],
+		tx.Amount,
 		tx.Contract[:],
 		tx.ContractVariables[:],
+		tx.Threshold,
+		len(tx.Cosigners),
+		tx.Device,
 	)
 }
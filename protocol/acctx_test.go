@@ -1,7 +1,7 @@
 package protocol
 
 import (
-	"crypto/ecdsa"
+	"golang.org/x/crypto/ed25519"
 	"reflect"
 	"testing"
 )
@@ -9,7 +9,7 @@ import (
 func TestAccTxCreation(t *testing.T) {
 	header := byte(0)
 	fee := uint64(1)
-	tx, newKey, _ := ConstrAccTx(header, fee, accA.Address, RootPrivKey, nil, nil)
+	tx, newKey, _ := ConstrAccTx(header, fee, 0, accA.Address, RootPrivKey, nil, nil)
 
 	if !reflect.DeepEqual(tx.Header, header) {
 		t.Errorf("Header does not match the given one: %x vs. %x\n", tx.Header, header)
@@ -23,11 +23,11 @@ func TestAccTxCreation(t *testing.T) {
 		t.Errorf("Public key does not match the given one: %x vs. %x\n", tx.PubKey, accA.Address)
 	}
 
-	if !reflect.DeepEqual(tx.Issuer, SerializeHashContent(getAddressFromPubKey(&RootPrivKey.PublicKey))) {
+	if !reflect.DeepEqual(tx.Issuer, SerializeHashContent(getAddressFromPubKey(RootPrivKey.Public().(ed25519.PublicKey)))) {
 		t.Errorf("Issuer does not match the given root key: %x vs. %x\n", tx.Issuer, RootPrivKey)
 	}
 
-	var nilPointer *ecdsa.PrivateKey
+	var nilPointer ed25519.PrivateKey
 	if !reflect.DeepEqual(newKey, nilPointer) {
 		t.Errorf("New key pointer should be nil.")
 	}
@@ -38,7 +38,7 @@ func TestAccTxCreation(t *testing.T) {
 
 	header = byte(1)
 	fee = uint64(2)
-	tx, newKey, _ = ConstrAccTx(header, fee, [64]byte{}, RootPrivKey, nil, nil)
+	tx, newKey, _ = ConstrAccTx(header, fee, 0, [32]byte{}, RootPrivKey, nil, nil)
 
 	if !reflect.DeepEqual(tx.Header, header) {
 		t.Errorf("Header does not match the given one: %x vs. %x\n", tx.Header, header)
@@ -48,11 +48,11 @@ func TestAccTxCreation(t *testing.T) {
 		t.Errorf("Fee does not match the given one: %x vs. %x\n", tx.Fee, fee)
 	}
 
-	if reflect.DeepEqual(tx.PubKey, [64]byte{}) {
+	if reflect.DeepEqual(tx.PubKey, [32]byte{}) {
 		t.Errorf("Public key should not be empty.")
 	}
 
-	if !reflect.DeepEqual(tx.Issuer, SerializeHashContent(getAddressFromPubKey(&RootPrivKey.PublicKey))) {
+	if !reflect.DeepEqual(tx.Issuer, SerializeHashContent(getAddressFromPubKey(RootPrivKey.Public().(ed25519.PublicKey)))) {
 		t.Errorf("Issuer does not match the given root key: %x vs. %x\n", tx.Issuer, RootPrivKey)
 	}
 
@@ -68,7 +68,7 @@ func TestAccTxCreation(t *testing.T) {
 func TestAccTxHash(t *testing.T) {
 	header := byte(0)
 	fee := uint64(1)
-	tx, _, _ := ConstrAccTx(header, fee, accA.Address, RootPrivKey, nil, nil)
+	tx, _, _ := ConstrAccTx(header, fee, 0, accA.Address, RootPrivKey, nil, nil)
 
 	hash1 := tx.Hash()
 
@@ -78,7 +78,7 @@ func TestAccTxHash(t *testing.T) {
 
 	header = byte(1)
 	fee = uint64(2)
-	tx, _, _ = ConstrAccTx(header, fee, [64]byte{}, RootPrivKey, nil, nil)
+	tx, _, _ = ConstrAccTx(header, fee, 0, [32]byte{}, RootPrivKey, nil, nil)
 
 	hash2 := tx.Hash()
 
@@ -90,11 +90,11 @@ func TestAccTxHash(t *testing.T) {
 func TestAccTxSerialization(t *testing.T) {
 	header := byte(0)
 	fee := uint64(1)
-	tx, _, _ := ConstrAccTx(header, fee, accA.Address, RootPrivKey, nil, nil)
+	tx, _, _ := ConstrAccTx(header, fee, 0, accA.Address, RootPrivKey, nil, nil)
 
 	var decodedTx *AccTx
 	encodedTx := tx.Encode()
-	decodedTx = decodedTx.Decode(encodedTx)
+	decodedTx, _ = decodedTx.Decode(encodedTx)
 
 	if !reflect.DeepEqual(tx, decodedTx) {
 		t.Errorf("AccTx serialization failed: %v vs. %v\n", tx, decodedTx)
@@ -102,19 +102,18 @@ func TestAccTxSerialization(t *testing.T) {
 
 	header = byte(1)
 	fee = uint64(2)
-	tx, _, _ = ConstrAccTx(header, fee, accA.Address, RootPrivKey, nil, nil)
+	tx, _, _ = ConstrAccTx(header, fee, 0, accA.Address, RootPrivKey, nil, nil)
 
 	encodedTx = tx.Encode()
-	decodedTx = decodedTx.Decode(encodedTx)
+	decodedTx, _ = decodedTx.Decode(encodedTx)
 
 	if !reflect.DeepEqual(tx, decodedTx) {
 		t.Errorf("AccTx serialization failed: %v vs. %v\n", tx, decodedTx)
 	}
 }
 
-func getAddressFromPubKey(pubKey *ecdsa.PublicKey) (address [64]byte) {
-	copy(address[:32], pubKey.X.Bytes())
-	copy(address[32:], pubKey.Y.Bytes())
+func getAddressFromPubKey(pubKey ed25519.PublicKey) (address [32]byte) {
+	copy(address[:], pubKey)
 
 	return address
 }
\ No newline at end of file
@@ -1,8 +1,6 @@
 package protocol
 
 import (
-	"bytes"
-	"encoding/gob"
 	"fmt"
 	"sync"
 )
@@ -68,26 +66,36 @@ func (tx *AggTx) Hash() (hash [32]byte) {
 //when we serialize the struct with binary.Write, unexported field get serialized as well, undesired
 //behavior. Therefore, writing own encoder/decoder
 func (tx *AggTx) Encode() (encodedTx []byte) {
-	// Encode
-	encodeData := AggTx{
-		Amount: 				tx.Amount,
-		Fee:    				tx.Fee,
-		From:					tx.From,
-		To:    					tx.To,
-		AggregatedTxSlice: 		tx.AggregatedTxSlice,
-		//Aggregated:				tx.Aggregated,
-	}
-	buffer := new(bytes.Buffer)
-	gob.NewEncoder(buffer).Encode(encodeData)
-	return buffer.Bytes()
+	enc := newEncoder()
+	enc.uint64(tx.Amount)
+	enc.uint64(tx.Fee)
+	enc.hashes(tx.From)
+	enc.hashes(tx.To)
+	enc.hashes(tx.AggregatedTxSlice)
+
+	return enc.Bytes()
 }
 
-func (*AggTx) Decode(encodedTx []byte) *AggTx {
+func (*AggTx) Decode(encodedTx []byte) (*AggTx, error) {
 	var decoded AggTx
-	buffer := bytes.NewBuffer(encodedTx)
-	decoder := gob.NewDecoder(buffer)
-	decoder.Decode(&decoded)
-	return &decoded
+	if !IsBinaryEncoded(encodedTx) {
+		if err := decodeGob(encodedTx, &decoded); err != nil {
+			return nil, err
+		}
+		return &decoded, nil
+	}
+
+	dec := newDecoder(encodedTx)
+	decoded.Amount = dec.uint64()
+	decoded.Fee = dec.uint64()
+	decoded.From = dec.hashes()
+	decoded.To = dec.hashes()
+	decoded.AggregatedTxSlice = dec.hashes()
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
 }
 
 func (tx *AggTx) TxFee() uint64 { return tx.Fee }
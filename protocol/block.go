@@ -1,11 +1,12 @@
 package protocol
 
 import (
-	"bytes"
-	"encoding/gob"
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
 	"github.com/willf/bloom"
+	"golang.org/x/crypto/sha3"
 	"reflect"
 )
 
@@ -31,6 +32,7 @@ type Block struct {
 	Height       		uint32
 	Beneficiary  		[32]byte
 	Aggregated			bool				//Indicates if All transactions are aggregated with a boolean.
+	StateRoot			[32]byte			//Root of the state after the block (see statetrie.go), zero in older blocks.
 
 
 	//Body
@@ -42,6 +44,7 @@ type Block struct {
 	NrStakeTx             uint16
 	NrAggTx         	  uint16
 	NrIoTTx         	  uint16
+	NrContractTx          uint16
 
 	SlashedAddress        [32]byte
 	CommitmentProof       [crypto.COMM_PROOF_LENGTH]byte
@@ -58,6 +61,7 @@ type Block struct {
 	AggTxData  	 		 [][32]byte
 	IoTTxData  	 		 [][32]byte
 	SizeIoTData			 uint64
+	ContractTxData		 [][32]byte
 
 }
 
@@ -104,7 +108,7 @@ func (block *Block) HashBlock() [32]byte {
 		block.ConflictingBlockHashWithoutTx2,
 		false,
 	}
-	return SerializeHashContent(blockHash)
+	return withStateRoot(SerializeHashContent(blockHash), block.StateRoot)
 }
 
 func (block *Block) HashBlockWithoutMerkleRoot() [32]byte {
@@ -137,7 +141,16 @@ func (block *Block) HashBlockWithoutMerkleRoot() [32]byte {
 		block.ConflictingBlockHashWithoutTx2,
 		true,
 	}
-	return SerializeHashContent(blockHash)
+	return withStateRoot(SerializeHashContent(blockHash), block.StateRoot)
+}
+
+//The state root was added later, the hashes of blocks without it are computed as before.
+func withStateRoot(hash [32]byte, stateRoot [32]byte) [32]byte {
+	if stateRoot == [32]byte{} {
+		return hash
+	}
+
+	return sha3.Sum256(append(hash[:], stateRoot[:]...))
 }
 
 func (block *Block) InitBloomFilter(txPubKeys [][32]byte) {
@@ -175,7 +188,8 @@ func (block *Block) GetHeaderSize() uint64 {
 		reflect.TypeOf(block.NrElementsBF).Size() +
 		reflect.TypeOf(block.Height).Size() +
 		reflect.TypeOf(block.Beneficiary).Size() +
-		reflect.TypeOf(block.Aggregated).Size())
+		reflect.TypeOf(block.Aggregated).Size() +
+		reflect.TypeOf(block.StateRoot).Size())
 
 	size += int(block.GetBloomFilterSize())
 
@@ -191,6 +205,7 @@ func (block *Block) GetBodySize() uint64 {
 		reflect.TypeOf(block.NrStakeTx).Size() +
 		reflect.TypeOf(block.NrAggTx).Size() +
 		reflect.TypeOf(block.NrIoTTx).Size() +
+		reflect.TypeOf(block.NrContractTx).Size() +
 		reflect.TypeOf(block.SlashedAddress).Size() +
 		reflect.TypeOf(block.CommitmentProof).Size() +
 		reflect.TypeOf(block.ConflictingBlockHash1).Size() +
@@ -210,7 +225,8 @@ func (block *Block) GetTxDataSize() uint64 {
 		int(block.NrConfigTx)*HASH_LEN +
 		int(block.NrStakeTx)*HASH_LEN +
 		int(block.NrAggTx)*HASH_LEN +
-		int(block.NrIoTTx)*HASH_LEN
+		int(block.NrIoTTx)*HASH_LEN +
+		int(block.NrContractTx)*HASH_LEN
 
 	return uint64(size)
 }
@@ -230,54 +246,74 @@ func (block *Block) Encode() []byte {
 		return nil
 	}
 
-	encoded := Block{
-		Header:                			block.Header,
-		Hash:                  			block.Hash,
-		PrevHash:              			block.PrevHash,
-		HashWithoutTx:         			block.HashWithoutTx,
-		PrevHashWithoutTx:     			block.PrevHashWithoutTx,
-		Aggregated:			   			block.Aggregated,
-		Nonce:                 			block.Nonce,
-		Timestamp:             			block.Timestamp,
-		MerkleRoot:            			block.MerkleRoot,
-		Beneficiary:           			block.Beneficiary,
-		NrAccTx:               			block.NrAccTx,
-		NrFundsTx:             			block.NrFundsTx,
-		NrConfigTx:            			block.NrConfigTx,
-		NrStakeTx:             			block.NrStakeTx,
-		NrAggTx:         				block.NrAggTx,
-		NrIoTTx:						block.NrIoTTx,
-		NrElementsBF:          			block.NrElementsBF,
-		BloomFilter:           			block.BloomFilter,
-		SlashedAddress:        			block.SlashedAddress,
-		Height:                			block.Height,
-		CommitmentProof:	   			block.CommitmentProof,
-		ConflictingBlockHash1: 			block.ConflictingBlockHash1,
-		ConflictingBlockHash2: 			block.ConflictingBlockHash2,
-		ConflictingBlockHashWithoutTx1: block.ConflictingBlockHashWithoutTx1,
-		ConflictingBlockHashWithoutTx2: block.ConflictingBlockHashWithoutTx2,
-
-		AccTxData:    		   			block.AccTxData,
-		FundsTxData:  		   			block.FundsTxData,
-		ConfigTxData: 		   			block.ConfigTxData,
-		StakeTxData:  		   			block.StakeTxData,
-		AggTxData:	   					block.AggTxData,
-		IoTTxData:	   					block.IoTTxData,
-		SizeIoTData:					block.SizeIoTData,
-
+	//Blocks with a VRF proof are encoded with the compact proof.
+	compactProof := crypto.IsVRFCommitmentProof(block.CommitmentProof)
+	enc := newEncoder()
+	if compactProof {
+		enc = newEncoderVersion(CODEC_VERSION_COMPACT_PROOF)
+	}
+	enc.uint8(block.Header)
+	enc.array(block.Hash[:])
+	enc.array(block.PrevHash[:])
+	enc.array(block.HashWithoutTx[:])
+	enc.array(block.PrevHashWithoutTx[:])
+	enc.bool(block.Aggregated)
+	enc.array(block.Nonce[:])
+	enc.int64(block.Timestamp)
+	enc.array(block.MerkleRoot[:])
+	enc.array(block.Beneficiary[:])
+	enc.uint16(block.NrAccTx)
+	enc.uint16(block.NrFundsTx)
+	enc.uint8(block.NrConfigTx)
+	enc.uint16(block.NrStakeTx)
+	enc.uint16(block.NrAggTx)
+	enc.uint16(block.NrIoTTx)
+	enc.uint16(block.NrElementsBF)
+	if block.BloomFilter != nil {
+		encodedBF, _ := block.BloomFilter.GobEncode()
+		enc.bytes(encodedBF)
+	} else {
+		enc.bytes(nil)
+	}
+	enc.array(block.SlashedAddress[:])
+	enc.uint32(block.Height)
+	if compactProof {
+		enc.array(block.CommitmentProof[:crypto.VRF_PROOF_LENGTH])
+	} else {
+		enc.array(block.CommitmentProof[:])
+	}
+	enc.array(block.ConflictingBlockHash1[:])
+	enc.array(block.ConflictingBlockHash2[:])
+	enc.array(block.ConflictingBlockHashWithoutTx1[:])
+	enc.array(block.ConflictingBlockHashWithoutTx2[:])
+
+	enc.hashes(block.AccTxData)
+	enc.hashes(block.FundsTxData)
+	enc.hashes(block.ConfigTxData)
+	enc.hashes(block.StakeTxData)
+	enc.hashes(block.AggTxData)
+	enc.hashes(block.IoTTxData)
+	enc.uint64(block.SizeIoTData)
+	//Contract txs and the state root were added later, blocks without them are encoded as before.
+	hasStateRoot := block.StateRoot != [32]byte{}
+	if block.NrContractTx > 0 || len(block.ContractTxData) > 0 || hasStateRoot {
+		enc.uint16(block.NrContractTx)
+		enc.hashes(block.ContractTxData)
+	}
+	if hasStateRoot {
+		enc.array(block.StateRoot[:])
 	}
 
-	buffer := new(bytes.Buffer)
-	gob.NewEncoder(buffer).Encode(encoded)
-	return buffer.Bytes()
+	return enc.Bytes()
 }
 
+//The header uses the same encoding as the block, with the tx counters and tx hashes left empty.
 func (block *Block) EncodeHeader() []byte {
 	if block == nil {
 		return nil
 	}
 
-	encoded := Block{
+	header := Block{
 		Header:       		block.Header,
 		Hash:         		block.Hash,
 		PrevHash:     		block.PrevHash,
@@ -289,23 +325,214 @@ func (block *Block) EncodeHeader() []byte {
 		Height:       		block.Height,
 		Beneficiary:  		block.Beneficiary,
 		Aggregated:			block.Aggregated,
+		MerkleRoot:			block.MerkleRoot,
+		StateRoot:			block.StateRoot,
+
+		//Needed by light clients to validate the hashes and the proof of stake of the header.
+		Nonce:							block.Nonce,
+		Timestamp:						block.Timestamp,
+		CommitmentProof:				block.CommitmentProof,
+		SlashedAddress:					block.SlashedAddress,
+		ConflictingBlockHash1:			block.ConflictingBlockHash1,
+		ConflictingBlockHash2:			block.ConflictingBlockHash2,
+		ConflictingBlockHashWithoutTx1:	block.ConflictingBlockHashWithoutTx1,
+		ConflictingBlockHashWithoutTx2:	block.ConflictingBlockHashWithoutTx2,
 	}
 
-	buffer := new(bytes.Buffer)
-	gob.NewEncoder(buffer).Encode(encoded)
-	return buffer.Bytes()
+	return header.Encode()
 }
 
-func (block *Block) Decode(encoded []byte) (b *Block) {
+func (block *Block) Decode(encoded []byte) (*Block, error) {
 	if encoded == nil {
-		return nil
+		return nil, errors.New("Empty block encoding.")
+	}
+
+	if !IsBinaryEncoded(encoded) {
+		return decodeGobBlock(encoded)
 	}
 
 	var decoded Block
-	buffer := bytes.NewBuffer(encoded)
-	decoder := gob.NewDecoder(buffer)
-	decoder.Decode(&decoded)
-	return &decoded
+
+	dec := newDecoderVersion(encoded, CODEC_VERSION_COMPACT_PROOF)
+	decoded.Header = dec.uint8()
+	dec.array(decoded.Hash[:])
+	dec.array(decoded.PrevHash[:])
+	dec.array(decoded.HashWithoutTx[:])
+	dec.array(decoded.PrevHashWithoutTx[:])
+	decoded.Aggregated = dec.bool()
+	dec.array(decoded.Nonce[:])
+	decoded.Timestamp = dec.int64()
+	dec.array(decoded.MerkleRoot[:])
+	dec.array(decoded.Beneficiary[:])
+	decoded.NrAccTx = dec.uint16()
+	decoded.NrFundsTx = dec.uint16()
+	decoded.NrConfigTx = dec.uint8()
+	decoded.NrStakeTx = dec.uint16()
+	decoded.NrAggTx = dec.uint16()
+	decoded.NrIoTTx = dec.uint16()
+	decoded.NrElementsBF = dec.uint16()
+	if encodedBF := dec.bytes(); encodedBF != nil {
+		var err error
+		if decoded.BloomFilter, err = decodeBloomFilter(encodedBF); err != nil {
+			return nil, err
+		}
+	}
+	dec.array(decoded.SlashedAddress[:])
+	decoded.Height = dec.uint32()
+	if dec.version == CODEC_VERSION_COMPACT_PROOF {
+		if dec.array(decoded.CommitmentProof[:crypto.VRF_PROOF_LENGTH]); dec.err == nil && !crypto.IsVRFCommitmentProof(decoded.CommitmentProof) {
+			return nil, errors.New("Compact encoding of a block without a VRF commitment proof.")
+		}
+	} else {
+		dec.array(decoded.CommitmentProof[:])
+	}
+	dec.array(decoded.ConflictingBlockHash1[:])
+	dec.array(decoded.ConflictingBlockHash2[:])
+	dec.array(decoded.ConflictingBlockHashWithoutTx1[:])
+	dec.array(decoded.ConflictingBlockHashWithoutTx2[:])
+
+	decoded.AccTxData = dec.hashes()
+	decoded.FundsTxData = dec.hashes()
+	decoded.ConfigTxData = dec.hashes()
+	decoded.StakeTxData = dec.hashes()
+	decoded.AggTxData = dec.hashes()
+	decoded.IoTTxData = dec.hashes()
+	decoded.SizeIoTData = dec.uint64()
+	if dec.more() {
+		decoded.NrContractTx = dec.uint16()
+		decoded.ContractTxData = dec.hashes()
+	}
+	if dec.more() {
+		dec.array(decoded.StateRoot[:])
+	}
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
+
+//The bit set of a bloom filter announces its length before the data and the bitset package allocates memory for
+//whatever length is announced. The length is checked against the data first, so a malformed filter received from
+//the network can not exhaust the memory.
+func decodeBloomFilter(encoded []byte) (*bloom.BloomFilter, error) {
+	//m and k of the filter and the length of the bit set (uint64 each), followed by the words of the bit set.
+	if len(encoded) < 24 || len(encoded)%8 != 0 {
+		return nil, errors.New("Invalid bloom filter encoding.")
+	}
+
+	length := binary.BigEndian.Uint64(encoded[16:24])
+	words := length / 64
+	if length%64 != 0 {
+		words++
+	}
+	if words != uint64(len(encoded)-24)/8 {
+		return nil, errors.New(fmt.Sprintf("Bloom filter of %v bits does not match %v bytes of data.", length, len(encoded)-24))
+	}
+
+	filter := new(bloom.BloomFilter)
+	if err := filter.GobDecode(encoded); err != nil {
+		return nil, err
+	}
+
+	return filter, nil
+}
+
+//Blocks encoded with gob by earlier versions. The bloom filter is kept encoded until its length has been checked.
+type gobBlock struct {
+	Header                         byte
+	Hash                           [32]byte
+	PrevHash                       [32]byte
+	HashWithoutTx                  [32]byte
+	PrevHashWithoutTx              [32]byte
+	NrConfigTx                     uint8
+	NrElementsBF                   uint16
+	BloomFilter                    gobBloomFilter
+	Height                         uint32
+	Beneficiary                    [32]byte
+	Aggregated                     bool
+	Nonce                          [8]byte
+	Timestamp                      int64
+	MerkleRoot                     [32]byte
+	NrAccTx                        uint16
+	NrFundsTx                      uint16
+	NrStakeTx                      uint16
+	NrAggTx                        uint16
+	NrIoTTx                        uint16
+	SlashedAddress                 [32]byte
+	CommitmentProof                [crypto.COMM_PROOF_LENGTH]byte
+	ConflictingBlockHash1          [32]byte
+	ConflictingBlockHash2          [32]byte
+	ConflictingBlockHashWithoutTx1 [32]byte
+	ConflictingBlockHashWithoutTx2 [32]byte
+	AccTxData                      [][32]byte
+	FundsTxData                    [][32]byte
+	ConfigTxData                   [][32]byte
+	StakeTxData                    [][32]byte
+	AggTxData                      [][32]byte
+	IoTTxData                      [][32]byte
+	SizeIoTData                    uint64
+}
+
+type gobBloomFilter []byte
+
+func (filter gobBloomFilter) GobEncode() ([]byte, error) {
+	return filter, nil
+}
+
+func (filter *gobBloomFilter) GobDecode(encoded []byte) error {
+	*filter = append([]byte{}, encoded...)
+	return nil
+}
+
+func decodeGobBlock(encoded []byte) (*Block, error) {
+	var decoded gobBlock
+	if err := decodeGob(encoded, &decoded); err != nil {
+		return nil, err
+	}
+
+	block := &Block{
+		Header:                         decoded.Header,
+		Hash:                           decoded.Hash,
+		PrevHash:                       decoded.PrevHash,
+		HashWithoutTx:                  decoded.HashWithoutTx,
+		PrevHashWithoutTx:              decoded.PrevHashWithoutTx,
+		NrConfigTx:                     decoded.NrConfigTx,
+		NrElementsBF:                   decoded.NrElementsBF,
+		Height:                         decoded.Height,
+		Beneficiary:                    decoded.Beneficiary,
+		Aggregated:                     decoded.Aggregated,
+		Nonce:                          decoded.Nonce,
+		Timestamp:                      decoded.Timestamp,
+		MerkleRoot:                     decoded.MerkleRoot,
+		NrAccTx:                        decoded.NrAccTx,
+		NrFundsTx:                      decoded.NrFundsTx,
+		NrStakeTx:                      decoded.NrStakeTx,
+		NrAggTx:                        decoded.NrAggTx,
+		NrIoTTx:                        decoded.NrIoTTx,
+		SlashedAddress:                 decoded.SlashedAddress,
+		CommitmentProof:                decoded.CommitmentProof,
+		ConflictingBlockHash1:          decoded.ConflictingBlockHash1,
+		ConflictingBlockHash2:          decoded.ConflictingBlockHash2,
+		ConflictingBlockHashWithoutTx1: decoded.ConflictingBlockHashWithoutTx1,
+		ConflictingBlockHashWithoutTx2: decoded.ConflictingBlockHashWithoutTx2,
+		AccTxData:                      decoded.AccTxData,
+		FundsTxData:                    decoded.FundsTxData,
+		ConfigTxData:                   decoded.ConfigTxData,
+		StakeTxData:                    decoded.StakeTxData,
+		AggTxData:                      decoded.AggTxData,
+		IoTTxData:                      decoded.IoTTxData,
+		SizeIoTData:                    decoded.SizeIoTData,
+	}
+
+	if decoded.BloomFilter != nil {
+		var err error
+		if block.BloomFilter, err = decodeBloomFilter(decoded.BloomFilter); err != nil {
+			return nil, err
+		}
+	}
+
+	return block, nil
 }
 
 func (block Block) String() string {
@@ -323,6 +550,7 @@ func (block Block) String() string {
 		"Amount of stakeTx: %v --> %x\n"+
 		"Amount of aggTx: %v --> %x\n"+
 		"Amount of IoTTx: %v --> %x\n"+
+		"Amount of contractTx: %v --> %x\n"+
 		"Total Transactions in this block: %v\n"+
 		"Height: %d\n"+
 		"Commitment Proof: %x\n"+
@@ -342,8 +570,9 @@ func (block Block) String() string {
 		block.NrStakeTx, block.StakeTxData,
 		block.NrAggTx, block.AggTxData,
 		block.NrIoTTx, block.IoTTxData,
+		block.NrContractTx, block.ContractTxData,
 
-		uint16(block.NrFundsTx) + uint16(block.NrAccTx) + uint16(block.NrConfigTx) + uint16(block.NrStakeTx) + uint16(block.NrAggTx )+ uint16(block.NrIoTTx),
+		uint16(block.NrFundsTx) + uint16(block.NrAccTx) + uint16(block.NrConfigTx) + uint16(block.NrStakeTx) + uint16(block.NrAggTx )+ uint16(block.NrIoTTx) + uint16(block.NrContractTx),
 		block.Height,
 		block.CommitmentProof[0:8],
 		block.SlashedAddress[0:8],
@@ -77,7 +77,8 @@ func TestBlockSerialization(t *testing.T) {
 
 	var compareBlock Block
 	encodedBlock := block.Encode()
-	compareBlock = *compareBlock.Decode(encodedBlock)
+	decodedBlock, _ := compareBlock.Decode(encodedBlock)
+	compareBlock = *decodedBlock
 
 	if !reflect.DeepEqual(block, compareBlock) {
 		t.Error("Block encoding/decoding failed!")
@@ -107,7 +108,8 @@ func TestBlockHeaderSerialization(t *testing.T) {
 
 	var compareBlockHeader Block
 	encodedBlock := blockHeader.EncodeHeader()
-	compareBlockHeader = *compareBlockHeader.Decode(encodedBlock)
+	decodedHeader, _ := compareBlockHeader.Decode(encodedBlock)
+	compareBlockHeader = *decodedHeader
 
 	if !reflect.DeepEqual(blockHeader, compareBlockHeader) {
 		t.Error("Block encoding/decoding failed!")
@@ -140,3 +142,29 @@ func TestGetSize(t *testing.T) {
 		fmt.Printf("Miscalculated block size: %v vs. %v\n", b.GetSize(), uint64(txAmount)*32+MIN_BLOCK_SIZE)
 	}
 }
+
+func TestBlockStateRoot(t *testing.T) {
+	block := Block{Height: 5}
+	rand.Read(block.PrevHash[:])
+	hashWithoutRoot := block.HashBlock()
+
+	//Blocks without state root keep their hash and encoding.
+	var decoded *Block
+	if decoded, _ = decoded.Decode(block.Encode()); decoded == nil || decoded.StateRoot != [32]byte{} || decoded.HashBlock() != hashWithoutRoot {
+		t.Fatal("Block without state root changed by encoding.")
+	}
+
+	rand.Read(block.StateRoot[:])
+	if block.HashBlock() == hashWithoutRoot || block.HashBlockWithoutMerkleRoot() == decoded.HashBlockWithoutMerkleRoot() {
+		t.Error("State root is not part of the block hashes.")
+	}
+
+	if decoded, _ = decoded.Decode(block.Encode()); decoded == nil || decoded.StateRoot != block.StateRoot || decoded.NrContractTx != 0 {
+		t.Errorf("State root not decoded: %v\n", decoded)
+	}
+
+	var header *Block
+	if header, _ = header.Decode(block.EncodeHeader()); header == nil || header.StateRoot != block.StateRoot {
+		t.Error("State root not part of the header.")
+	}
+}
@@ -0,0 +1,259 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"errors"
+	"fmt"
+)
+
+//Binary encoding of blocks, accounts and the txs which used to be encoded with gob. Unlike gob, the encoding only
+//depends on the values of the fields, so equal values always result in equal bytes and clients written in other
+//languages can decode it without a gob implementation.
+//
+//Every encoding starts with CODEC_MAGIC and CODEC_VERSION, followed by the fields in a fixed order: integers big
+//endian, bools as a single byte (0 or 1), arrays as they are and byte slices and slices of hashes prefixed with their
+//length as uint32. CODEC_MAGIC is never the first byte of a gob stream (which starts with the length of the first
+//message), therefore data encoded with gob by earlier versions can still be told apart and is decoded as before.
+//Config and stake txs already have a fixed binary layout and are not prefixed.
+
+const (
+	CODEC_MAGIC   = 0xba
+	CODEC_VERSION = 1
+	//Blocks with a VRF commitment proof only contain the VRF proof instead of the whole proof field, see Block.Encode.
+	CODEC_VERSION_COMPACT_PROOF = 2
+)
+
+//Returns true if encoded was produced by the binary codec rather than gob.
+func IsBinaryEncoded(encoded []byte) bool {
+	return len(encoded) >= 2 && encoded[0] == CODEC_MAGIC
+}
+
+type encoder struct {
+	buffer bytes.Buffer
+}
+
+func newEncoder() *encoder {
+	return newEncoderVersion(CODEC_VERSION)
+}
+
+func newEncoderVersion(version uint8) *encoder {
+	enc := new(encoder)
+	enc.buffer.WriteByte(CODEC_MAGIC)
+	enc.buffer.WriteByte(version)
+
+	return enc
+}
+
+func (enc *encoder) uint8(value uint8) {
+	enc.buffer.WriteByte(value)
+}
+
+func (enc *encoder) uint16(value uint16) {
+	var buf [2]byte
+	binary.BigEndian.PutUint16(buf[:], value)
+	enc.buffer.Write(buf[:])
+}
+
+func (enc *encoder) uint32(value uint32) {
+	var buf [4]byte
+	binary.BigEndian.PutUint32(buf[:], value)
+	enc.buffer.Write(buf[:])
+}
+
+func (enc *encoder) uint64(value uint64) {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], value)
+	enc.buffer.Write(buf[:])
+}
+
+func (enc *encoder) int64(value int64) {
+	enc.uint64(uint64(value))
+}
+
+func (enc *encoder) bool(value bool) {
+	if value {
+		enc.buffer.WriteByte(1)
+	} else {
+		enc.buffer.WriteByte(0)
+	}
+}
+
+//Arrays are written without a length, the decoder knows their size.
+func (enc *encoder) array(value []byte) {
+	enc.buffer.Write(value)
+}
+
+func (enc *encoder) bytes(value []byte) {
+	enc.uint32(uint32(len(value)))
+	enc.buffer.Write(value)
+}
+
+func (enc *encoder) hashes(value [][32]byte) {
+	enc.uint32(uint32(len(value)))
+	for _, hash := range value {
+		enc.buffer.Write(hash[:])
+	}
+}
+
+func (enc *encoder) byteArrays(value []ByteArray) {
+	enc.uint32(uint32(len(value)))
+	for _, element := range value {
+		enc.bytes(element)
+	}
+}
+
+func (enc *encoder) Bytes() []byte {
+	return enc.buffer.Bytes()
+}
+
+//The decoder stops at the first error, all subsequent reads return zero values. The error is returned by finish.
+type decoder struct {
+	data    []byte
+	err     error
+	version uint8
+}
+
+func newDecoder(encoded []byte) *decoder {
+	return newDecoderVersion(encoded, CODEC_VERSION)
+}
+
+//Accepts the versions up to maxVersion, the version of the encoding is kept in the decoder.
+func newDecoderVersion(encoded []byte, maxVersion uint8) *decoder {
+	dec := &decoder{data: encoded}
+	if !IsBinaryEncoded(encoded) {
+		dec.err = errors.New("Missing codec prefix.")
+		return dec
+	}
+
+	if encoded[1] < CODEC_VERSION || encoded[1] > maxVersion {
+		dec.err = errors.New(fmt.Sprintf("Unsupported codec version %v.", encoded[1]))
+		return dec
+	}
+	dec.version = encoded[1]
+	dec.data = encoded[2:]
+
+	return dec
+}
+
+func (dec *decoder) next(n uint64) []byte {
+	if dec.err != nil {
+		return nil
+	}
+
+	if uint64(len(dec.data)) < n {
+		dec.err = errors.New(fmt.Sprintf("Unexpected end of data, %v bytes missing.", n-uint64(len(dec.data))))
+		return nil
+	}
+
+	value := dec.data[:n]
+	dec.data = dec.data[n:]
+
+	return value
+}
+
+func (dec *decoder) uint8() uint8 {
+	if value := dec.next(1); value != nil {
+		return value[0]
+	}
+
+	return 0
+}
+
+func (dec *decoder) uint16() uint16 {
+	if value := dec.next(2); value != nil {
+		return binary.BigEndian.Uint16(value)
+	}
+
+	return 0
+}
+
+func (dec *decoder) uint32() uint32 {
+	if value := dec.next(4); value != nil {
+		return binary.BigEndian.Uint32(value)
+	}
+
+	return 0
+}
+
+func (dec *decoder) uint64() uint64 {
+	if value := dec.next(8); value != nil {
+		return binary.BigEndian.Uint64(value)
+	}
+
+	return 0
+}
+
+func (dec *decoder) int64() int64 {
+	return int64(dec.uint64())
+}
+
+func (dec *decoder) bool() bool {
+	switch dec.uint8() {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+
+	if dec.err == nil {
+		dec.err = errors.New("Invalid bool value.")
+	}
+
+	return false
+}
+
+func (dec *decoder) array(value []byte) {
+	copy(value, dec.next(uint64(len(value))))
+}
+
+//Empty slices are decoded as nil, like gob does.
+func (dec *decoder) bytes() []byte {
+	length := dec.uint32()
+	if length == 0 {
+		return nil
+	}
+
+	return append([]byte{}, dec.next(uint64(length))...)
+}
+
+func (dec *decoder) hashes() (value [][32]byte) {
+	length := uint64(dec.uint32())
+	encoded := dec.next(length * 32)
+	for i := uint64(0); encoded != nil && i < length; i++ {
+		var hash [32]byte
+		copy(hash[:], encoded[i*32:])
+		value = append(value, hash)
+	}
+
+	return value
+}
+
+func (dec *decoder) byteArrays() (value []ByteArray) {
+	length := dec.uint32()
+	for i := uint32(0); dec.err == nil && i < length; i++ {
+		value = append(value, dec.bytes())
+	}
+
+	return value
+}
+
+//Fields appended by later versions are optional, encodings produced before (or without them) end before these fields.
+func (dec *decoder) more() bool {
+	return dec.err == nil && len(dec.data) > 0
+}
+
+//Returns the first error that occurred while decoding, trailing bytes are treated as an error as well.
+func (dec *decoder) finish() error {
+	if dec.err == nil && len(dec.data) > 0 {
+		dec.err = errors.New(fmt.Sprintf("%v unexpected trailing bytes.", len(dec.data)))
+	}
+
+	return dec.err
+}
+
+//Decodes data encoded with gob by earlier versions.
+func decodeGob(encoded []byte, value interface{}) error {
+	return gob.NewDecoder(bytes.NewBuffer(encoded)).Decode(value)
+}
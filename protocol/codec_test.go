@@ -0,0 +1,314 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"reflect"
+	"testing"
+)
+
+func newCodecTestBlock() *Block {
+	block := &Block{
+		Header:      0x01,
+		Hash:        [32]byte{0x01},
+		PrevHash:    [32]byte{0x02},
+		Aggregated:  true,
+		Nonce:       [8]byte{0x03},
+		Timestamp:   -5,
+		Beneficiary: [32]byte{0x04},
+		NrFundsTx:   2,
+		NrIoTTx:     1,
+		Height:      300,
+		FundsTxData: [][32]byte{{0x05}, {0x06}},
+		IoTTxData:   [][32]byte{{0x07}},
+		SizeIoTData: 12,
+	}
+	block.CommitmentProof[0] = 0x08
+	block.InitBloomFilter([][32]byte{{0x09}, {0x0a}})
+
+	return block
+}
+
+func TestCodecRoundTrip(t *testing.T) {
+	fundsTx := &FundsTx{Header: 0x01, Amount: 10, Fee: 1, TxCnt: 3, From: [32]byte{0x01}, To: [32]byte{0x02}, Sig: [64]byte{0x03}, Data: []byte("data")}
+	var decodedFundsTx *FundsTx
+	if decodedFundsTx, _ = decodedFundsTx.Decode(fundsTx.Encode()); !reflect.DeepEqual(fundsTx, decodedFundsTx) {
+		t.Errorf("FundsTx round trip failed: %v vs. %v\n", fundsTx, decodedFundsTx)
+	}
+
+	accTx := &AccTx{Header: 0x02, Issuer: [32]byte{0x01}, Fee: 1, PubKey: [32]byte{0x02}, Sig: [64]byte{0x03}, Amount: 50}
+	var decodedAccTx *AccTx
+	if decodedAccTx, _ = decodedAccTx.Decode(accTx.Encode()); !reflect.DeepEqual(accTx, decodedAccTx) {
+		t.Errorf("AccTx round trip failed: %v vs. %v\n", accTx, decodedAccTx)
+	}
+
+	aggTx := &AggTx{Amount: 10, Fee: 2, From: [][32]byte{{0x01}}, To: [][32]byte{{0x02}, {0x03}}, AggregatedTxSlice: [][32]byte{{0x04}}}
+	var decodedAggTx *AggTx
+	if decodedAggTx, _ = decodedAggTx.Decode(aggTx.Encode()); !reflect.DeepEqual(aggTx, decodedAggTx) {
+		t.Errorf("AggTx round trip failed: %v vs. %v\n", aggTx, decodedAggTx)
+	}
+
+	iotTx := &IotTx{Header: 0x01, TxCnt: 4, From: [32]byte{0x01}, To: [32]byte{0x02}, Sig: [64]byte{0x03}, Data: []byte{0x04}, Fee: 1, Sequence: 7}
+	var decodedIotTx *IotTx
+	if decodedIotTx, _ = decodedIotTx.Decode(iotTx.Encode()); !reflect.DeepEqual(iotTx, decodedIotTx) {
+		t.Errorf("IotTx round trip failed: %v vs. %v\n", iotTx, decodedIotTx)
+	}
+
+	acc := &Account{Address: [32]byte{0x01}, Issuer: [32]byte{0x02}, Balance: 100, TxCnt: 2, IsStaking: true, StakingBlockHeight: 5, Contract: []byte{0x03}, ContractVariables: []ByteArray{{0x04}, {0x05, 0x06}}}
+	acc.CommitmentKey[0] = 0x07
+	var decodedAcc *Account
+	if decodedAcc, _ = decodedAcc.Decode(acc.Encode()); !reflect.DeepEqual(acc, decodedAcc) {
+		t.Errorf("Account round trip failed: %v vs. %v\n", acc, decodedAcc)
+	}
+
+	acc.UnbondingHeight = 42
+	if decodedAcc, _ = decodedAcc.Decode(acc.Encode()); !reflect.DeepEqual(acc, decodedAcc) {
+		t.Errorf("Unbonding account round trip failed: %v vs. %v\n", acc, decodedAcc)
+	}
+
+	multiSigTx := &FundsTx{Amount: 10, Fee: 1, From: [32]byte{0x01}, To: [32]byte{0x02}, Cosigs: []Cosignature{{0, [64]byte{0x03}}, {2, [64]byte{0x04}}}}
+	if decodedFundsTx, _ = decodedFundsTx.Decode(multiSigTx.Encode()); !reflect.DeepEqual(multiSigTx, decodedFundsTx) {
+		t.Errorf("FundsTx with cosignatures round trip failed: %v vs. %v\n", multiSigTx, decodedFundsTx)
+	}
+
+	lockedTx := &FundsTx{Amount: 10, Fee: 1, From: [32]byte{0x01}, To: [32]byte{0x02}, LockUntil: 100}
+	if decodedFundsTx, _ = decodedFundsTx.Decode(lockedTx.Encode()); !reflect.DeepEqual(lockedTx, decodedFundsTx) {
+		t.Errorf("Locked FundsTx round trip failed: %v vs. %v\n", lockedTx, decodedFundsTx)
+	}
+
+	multiSigTx.LockUntil = LOCK_TIME_THRESHOLD
+	if decodedFundsTx, _ = decodedFundsTx.Decode(multiSigTx.Encode()); !reflect.DeepEqual(multiSigTx, decodedFundsTx) {
+		t.Errorf("Locked FundsTx with cosignatures round trip failed: %v vs. %v\n", multiSigTx, decodedFundsTx)
+	}
+
+	gasTx := &FundsTx{Amount: 10, Fee: 1, From: [32]byte{0x01}, To: [32]byte{0x02}, Data: []byte{0x03}, GasLimit: 500, GasPrice: 2}
+	if decodedFundsTx, _ = decodedFundsTx.Decode(gasTx.Encode()); !reflect.DeepEqual(gasTx, decodedFundsTx) {
+		t.Errorf("FundsTx with gas round trip failed: %v vs. %v\n", gasTx, decodedFundsTx)
+	}
+	if gasTx.Hash() == (&FundsTx{Amount: 10, Fee: 1, From: [32]byte{0x01}, To: [32]byte{0x02}, Data: []byte{0x03}}).Hash() {
+		t.Error("Gas of FundsTx not hashed.\n")
+	}
+
+	multiSigAccTx := &AccTx{Issuer: [32]byte{0x01}, Fee: 1, PubKey: [32]byte{0x02}, Threshold: 2, Cosigners: [][32]byte{{0x03}, {0x04}}}
+	if decodedAccTx, _ = decodedAccTx.Decode(multiSigAccTx.Encode()); !reflect.DeepEqual(multiSigAccTx, decodedAccTx) {
+		t.Errorf("AccTx with cosigners round trip failed: %v vs. %v\n", multiSigAccTx, decodedAccTx)
+	}
+
+	multiSigAcc := &Account{Address: [32]byte{0x01}, Threshold: 1, Cosigners: [][32]byte{{0x02}}}
+	if decodedAcc, _ = decodedAcc.Decode(multiSigAcc.Encode()); !reflect.DeepEqual(multiSigAcc, decodedAcc) {
+		t.Errorf("Account with cosigners round trip failed: %v vs. %v\n", multiSigAcc, decodedAcc)
+	}
+
+	deviceAccTx := &AccTx{Issuer: [32]byte{0x01}, Fee: 1, PubKey: [32]byte{0x02}, Device: &DeviceInfo{"thermometer", [32]byte{0x03}, 5}}
+	if decodedAccTx, _ = decodedAccTx.Decode(deviceAccTx.Encode()); !reflect.DeepEqual(deviceAccTx, decodedAccTx) {
+		t.Errorf("AccTx with device round trip failed: %v vs. %v\n", deviceAccTx, decodedAccTx)
+	}
+	if deviceAccTx.Hash() == (&AccTx{Issuer: [32]byte{0x01}, Fee: 1, PubKey: [32]byte{0x02}}).Hash() {
+		t.Error("Device of AccTx not hashed.\n")
+	}
+
+	deviceAcc := &Account{Address: [32]byte{0x01}, Device: &DeviceInfo{"thermometer", [32]byte{0x02}, 0}}
+	if decodedAcc, _ = decodedAcc.Decode(deviceAcc.Encode()); !reflect.DeepEqual(deviceAcc, decodedAcc) {
+		t.Errorf("Account with device round trip failed: %v vs. %v\n", deviceAcc, decodedAcc)
+	}
+
+	bucketAcc := &Account{Address: [32]byte{0x01}, IotTokens: 3, IotRefillHeight: 50}
+	if decodedAcc, _ = decodedAcc.Decode(bucketAcc.Encode()); !reflect.DeepEqual(bucketAcc, decodedAcc) {
+		t.Errorf("Account with IoT bucket round trip failed: %v vs. %v\n", bucketAcc, decodedAcc)
+	}
+
+	contractTx := &ContractTx{Header: 0x01, From: [32]byte{0x01}, TxCnt: 2, Fee: 1, GasLimit: 5000, Contract: []byte{0x02, 0x03}, ContractVariables: []ByteArray{{0x04}}, Sig: [64]byte{0x05}}
+	var decodedContractTx *ContractTx
+	if decodedContractTx, _ = decodedContractTx.Decode(contractTx.Encode()); !reflect.DeepEqual(contractTx, decodedContractTx) {
+		t.Errorf("ContractTx round trip failed: %v vs. %v\n", contractTx, decodedContractTx)
+	}
+
+	logs := []*Log{{Contract: [32]byte{0x01}, TxHash: [32]byte{0x02}, Height: 3, Index: 4, Topics: []ByteArray{{0x05}}, Data: []byte{0x06}}, {Contract: [32]byte{0x01}, Index: 5}}
+	if decodedLogs, _ := DecodeLogs(EncodeLogs(logs)); !reflect.DeepEqual(logs, decodedLogs) {
+		t.Errorf("Logs round trip failed: %v vs. %v\n", logs, decodedLogs)
+	}
+
+	block := newCodecTestBlock()
+	var decodedBlock *Block
+	decodedBlock, _ = decodedBlock.Decode(block.Encode())
+	if txPubKey := [32]byte{0x09}; decodedBlock == nil || !decodedBlock.BloomFilter.Test(txPubKey[:]) {
+		t.Fatalf("Block bloom filter not decoded.\n")
+	}
+	decodedBlock.BloomFilter, block.BloomFilter = nil, nil
+	if !reflect.DeepEqual(block, decodedBlock) {
+		t.Errorf("Block round trip failed: %v vs. %v\n", block, decodedBlock)
+	}
+
+	//Blocks without contract txs are encoded as before.
+	withoutContractTxs := block.Encode()
+	block.NrContractTx, block.ContractTxData = 1, [][32]byte{{0x0b}}
+	if decodedBlock, _ = decodedBlock.Decode(block.Encode()); decodedBlock == nil || !reflect.DeepEqual(block.ContractTxData, decodedBlock.ContractTxData) {
+		t.Errorf("Block with contract txs round trip failed: %v\n", decodedBlock)
+	}
+	if len(block.Encode()) != len(withoutContractTxs)+2+4+32 {
+		t.Errorf("Encoding of blocks without contract txs changed.\n")
+	}
+}
+
+func TestCodecDeterministic(t *testing.T) {
+	//Gob encodes zero values differently depending on the fields set, the binary encoding has a fixed layout.
+	if !bytes.Equal(newCodecTestBlock().Encode(), newCodecTestBlock().Encode()) {
+		t.Error("Equal blocks encoded differently.\n")
+	}
+
+	empty := (&FundsTx{}).Encode()
+	if !IsBinaryEncoded(empty) || len(empty) != 2+1+8+8+4+32+32+64+4 {
+		t.Errorf("Unexpected encoding of an empty FundsTx: %x\n", empty)
+	}
+
+	header := newCodecTestBlock()
+	var decodedHeader *Block
+	if decodedHeader, _ = decodedHeader.Decode(header.EncodeHeader()); decodedHeader == nil || decodedHeader.FundsTxData != nil || decodedHeader.Height != header.Height {
+		t.Errorf("Header not decoded correctly: %v\n", decodedHeader)
+	}
+}
+
+func TestCodecInvalid(t *testing.T) {
+	encoded := (&FundsTx{Data: []byte("data")}).Encode()
+
+	var tx *FundsTx
+	if _, err := tx.Decode(encoded[:len(encoded)-1]); err == nil {
+		t.Error("Truncated tx decoded.\n")
+	}
+
+	if _, err := tx.Decode(append(encoded, 0x00)); err == nil {
+		t.Error("Tx with trailing bytes decoded.\n")
+	}
+
+	//An empty list of cosignatures is only encoded if followed by a lock.
+	if _, err := tx.Decode(append(append([]byte{}, encoded...), 0x00, 0x00, 0x00, 0x00)); err == nil {
+		t.Error("Tx with a non-canonical encoding decoded.\n")
+	}
+
+	//A zero lock is only encoded if followed by the gas.
+	if _, err := tx.Decode(append(append([]byte{}, encoded...), 0x00, 0x00, 0x00, 0x00, 0, 0, 0, 0, 0, 0, 0, 0)); err == nil {
+		t.Error("Tx with a non-canonical lock decoded.\n")
+	}
+
+	unsupported := append([]byte{}, encoded...)
+	unsupported[1] = CODEC_VERSION + 1
+	if _, err := tx.Decode(unsupported); err == nil {
+		t.Error("Tx with an unsupported codec version decoded.\n")
+	}
+
+	//The length prefix of Data claims more bytes than available.
+	oversized := append([]byte{}, encoded...)
+	oversized[len(oversized)-8] = 0xff
+	if _, err := tx.Decode(oversized); err == nil {
+		t.Error("Tx with an invalid length decoded.\n")
+	}
+
+	acc := (&Account{}).Encode()
+	acc[2+32+32+8+4] = 0x02
+	var decodedAcc *Account
+	if _, err := decodedAcc.Decode(acc); err == nil {
+		t.Error("Account with an invalid bool decoded.\n")
+	}
+
+	//Out-of-range fields are rejected as well.
+	if _, err := decodedAcc.Decode((&Account{Threshold: 3, Cosigners: [][32]byte{{1}, {2}}}).Encode()); err == nil {
+		t.Error("Account with a threshold above its cosigners decoded.\n")
+	}
+
+	if _, err := tx.Decode((&FundsTx{Cosigs: []Cosignature{{Index: MAX_COSIGNERS}}}).Encode()); err == nil {
+		t.Error("Tx with an out-of-range cosigner index decoded.\n")
+	}
+
+	stakeTx := (&StakeTx{IsStaking: true}).Encode()
+	stakeTx[9] = 2
+	var decodedStakeTx *StakeTx
+	if _, err := decodedStakeTx.Decode(stakeTx); err == nil {
+		t.Error("Stake tx with an invalid isStaking value decoded.\n")
+	}
+}
+
+func TestCodecLegacyGob(t *testing.T) {
+	fundsTx := FundsTx{Header: 0x01, Amount: 10, Fee: 1, TxCnt: 3, From: [32]byte{0x01}, To: [32]byte{0x02}, Sig: [64]byte{0x03}, Data: []byte("data")}
+	buffer := new(bytes.Buffer)
+	gob.NewEncoder(buffer).Encode(fundsTx)
+
+	if IsBinaryEncoded(buffer.Bytes()) {
+		t.Fatal("Gob encoding detected as binary encoding.\n")
+	}
+
+	var decoded *FundsTx
+	if decoded, _ = decoded.Decode(buffer.Bytes()); decoded == nil || !reflect.DeepEqual(&fundsTx, decoded) {
+		t.Errorf("Gob encoded tx not decoded: %v\n", decoded)
+	}
+
+	block := newCodecTestBlock()
+	buffer.Reset()
+	gob.NewEncoder(buffer).Encode(block)
+
+	var decodedBlock *Block
+	if decodedBlock, _ = decodedBlock.Decode(buffer.Bytes()); decodedBlock == nil || decodedBlock.Hash != block.Hash || len(decodedBlock.FundsTxData) != 2 {
+		t.Errorf("Gob encoded block not decoded: %v\n", decodedBlock)
+	}
+
+	if _, err := decoded.Decode([]byte{0x01, 0x02}); err == nil {
+		t.Error("Invalid gob data decoded.\n")
+	}
+}
+
+func TestCodecBloomFilterLength(t *testing.T) {
+	block := newCodecTestBlock()
+	encodedBF, _ := block.BloomFilter.GobEncode()
+
+	//The bit set announces 2^60 bits without providing the data.
+	malicious := append([]byte{}, encodedBF...)
+	binary.BigEndian.PutUint64(malicious[16:24], 1<<60)
+	if _, err := decodeBloomFilter(malicious); err == nil {
+		t.Error("Bloom filter with an invalid length decoded.\n")
+	}
+
+	if filter, err := decodeBloomFilter(encodedBF); err != nil || filter.Cap() != block.BloomFilter.Cap() {
+		t.Errorf("Valid bloom filter not decoded: %v\n", err)
+	}
+
+	//Blocks encoded with gob are checked as well.
+	legacy := &gobBlock{Hash: block.Hash, BloomFilter: malicious}
+	buffer := new(bytes.Buffer)
+	gob.NewEncoder(buffer).Encode(legacy)
+	var decoded *Block
+	if _, err := decoded.Decode(buffer.Bytes()); err == nil {
+		t.Error("Gob encoded block with an invalid bloom filter decoded.\n")
+	}
+}
+
+func TestCodecCompactCommitmentProof(t *testing.T) {
+	block := newCodecTestBlock()
+	block.CommitmentProof[crypto.VRF_PROOF_LENGTH-1] = 0x01
+	compact := block.Encode()
+	if compact[1] != CODEC_VERSION_COMPACT_PROOF {
+		t.Errorf("Block with a VRF proof not encoded with the compact proof: version %v\n", compact[1])
+	}
+
+	var decoded *Block
+	if decoded, _ = decoded.Decode(compact); !reflect.DeepEqual(block, decoded) {
+		t.Errorf("Block with a VRF proof round trip failed: %v vs. %v\n", block, decoded)
+	}
+
+	block.CommitmentProof[crypto.COMM_PROOF_LENGTH-1] = 0x01
+	full := block.Encode()
+	if full[1] != CODEC_VERSION || len(full) != len(compact)+crypto.COMM_PROOF_LENGTH-crypto.VRF_PROOF_LENGTH {
+		t.Errorf("Block with an RSA proof not encoded with the full proof: version %v, length %v\n", full[1], len(full))
+	}
+	if decoded, _ = decoded.Decode(full); !reflect.DeepEqual(block, decoded) {
+		t.Errorf("Block with an RSA proof round trip failed: %v vs. %v\n", block, decoded)
+	}
+
+	//Only blocks can be encoded with the compact proof.
+	tx := (&FundsTx{}).Encode()
+	tx[1] = CODEC_VERSION_COMPACT_PROOF
+	var decodedTx *FundsTx
+	if _, err := decodedTx.Decode(tx); err == nil {
+		t.Error("Tx with the compact proof version decoded.\n")
+	}
+}
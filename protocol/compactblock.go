@@ -0,0 +1,171 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+
+	"golang.org/x/crypto/sha3"
+)
+
+//Blocks are relayed as compact blocks, the tx hashes of the block are replaced by short tx ids. Peers already know
+//most of the txs from their mempool and reconstruct the block from it. The short ids are salted with the block hash,
+//an attacker can not prepare txs colliding with the txs of a block before the block exists.
+
+const SHORT_TX_ID_LEN = 6
+
+type ShortTxID [SHORT_TX_ID_LEN]byte
+
+type CompactBlock struct {
+	//The block without tx hashes
+	Header *Block
+
+	AccTxIDs      []ShortTxID
+	FundsTxIDs    []ShortTxID
+	ConfigTxIDs   []ShortTxID
+	StakeTxIDs    []ShortTxID
+	AggTxIDs      []ShortTxID
+	IoTTxIDs      []ShortTxID
+	ContractTxIDs []ShortTxID
+}
+
+func NewShortTxID(blockHash [32]byte, txHash [32]byte) (id ShortTxID) {
+	hash := sha3.Sum256(append(blockHash[:], txHash[:]...))
+	copy(id[:], hash[:])
+
+	return id
+}
+
+func NewCompactBlock(block *Block) *CompactBlock {
+	header := *block
+	header.StateCopy = nil
+	header.AccTxData = nil
+	header.FundsTxData = nil
+	header.ConfigTxData = nil
+	header.StakeTxData = nil
+	header.AggTxData = nil
+	header.IoTTxData = nil
+	header.ContractTxData = nil
+
+	return &CompactBlock{
+		Header:        &header,
+		AccTxIDs:      shortTxIDs(block.Hash, block.AccTxData),
+		FundsTxIDs:    shortTxIDs(block.Hash, block.FundsTxData),
+		ConfigTxIDs:   shortTxIDs(block.Hash, block.ConfigTxData),
+		StakeTxIDs:    shortTxIDs(block.Hash, block.StakeTxData),
+		AggTxIDs:      shortTxIDs(block.Hash, block.AggTxData),
+		IoTTxIDs:      shortTxIDs(block.Hash, block.IoTTxData),
+		ContractTxIDs: shortTxIDs(block.Hash, block.ContractTxData),
+	}
+}
+
+func shortTxIDs(blockHash [32]byte, txHashes [][32]byte) (ids []ShortTxID) {
+	for _, txHash := range txHashes {
+		ids = append(ids, NewShortTxID(blockHash, txHash))
+	}
+
+	return ids
+}
+
+//Rebuilds the block from the hashes of the known txs (e.g., the mempool). Fails if a short id does not match exactly
+//one known tx, the caller then has to fetch the full block.
+func (cb *CompactBlock) Reconstruct(knownTxHashes [][32]byte) (*Block, error) {
+	candidates := make(map[ShortTxID][32]byte)
+	ambiguous := make(map[ShortTxID]bool)
+	for _, txHash := range knownTxHashes {
+		id := NewShortTxID(cb.Header.Hash, txHash)
+		if existing, exists := candidates[id]; exists && existing != txHash {
+			ambiguous[id] = true
+		}
+		candidates[id] = txHash
+	}
+
+	missing := 0
+	resolve := func(ids []ShortTxID) (txHashes [][32]byte) {
+		for _, id := range ids {
+			txHash, exists := candidates[id]
+			if !exists || ambiguous[id] {
+				missing++
+				continue
+			}
+			txHashes = append(txHashes, txHash)
+		}
+
+		return txHashes
+	}
+
+	block := *cb.Header
+	block.AccTxData = resolve(cb.AccTxIDs)
+	block.FundsTxData = resolve(cb.FundsTxIDs)
+	block.ConfigTxData = resolve(cb.ConfigTxIDs)
+	block.StakeTxData = resolve(cb.StakeTxIDs)
+	block.AggTxData = resolve(cb.AggTxIDs)
+	block.IoTTxData = resolve(cb.IoTTxIDs)
+	block.ContractTxData = resolve(cb.ContractTxIDs)
+
+	if missing > 0 {
+		return nil, errors.New(fmt.Sprintf("%v txs of the block are unknown.", missing))
+	}
+
+	//The merkle root of aggregated blocks does not cover the aggregated txs anymore, see validate.
+	if !block.Aggregated && BuildMerkleTree(&block).MerkleRoot() != block.MerkleRoot {
+		return nil, errors.New("Reconstructed txs do not match the merkle root.")
+	}
+
+	return &block, nil
+}
+
+func (cb *CompactBlock) Encode() []byte {
+	if cb == nil || cb.Header == nil {
+		return nil
+	}
+
+	enc := newEncoder()
+	enc.bytes(cb.Header.Encode())
+	for _, ids := range cb.idLists() {
+		encodedIDs := make([]byte, 0, len(*ids)*SHORT_TX_ID_LEN)
+		for _, id := range *ids {
+			encodedIDs = append(encodedIDs, id[:]...)
+		}
+		enc.bytes(encodedIDs)
+	}
+
+	return enc.Bytes()
+}
+
+func (*CompactBlock) Decode(encoded []byte) (*CompactBlock, error) {
+	if encoded == nil {
+		return nil, errors.New("Empty compact block encoding.")
+	}
+
+	var decoded CompactBlock
+	var err error
+	dec := newDecoder(encoded)
+	encodedHeader := dec.bytes()
+	if dec.err != nil {
+		return nil, dec.err
+	}
+	if decoded.Header, err = decoded.Header.Decode(encodedHeader); err != nil {
+		return nil, err
+	}
+	for _, ids := range decoded.idLists() {
+		encodedIDs := dec.bytes()
+		if len(encodedIDs)%SHORT_TX_ID_LEN != 0 {
+			return nil, errors.New(fmt.Sprintf("Short tx ids of %v bytes.", len(encodedIDs)))
+		}
+		for i := 0; i < len(encodedIDs); i += SHORT_TX_ID_LEN {
+			var id ShortTxID
+			copy(id[:], encodedIDs[i:])
+			*ids = append(*ids, id)
+		}
+	}
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
+
+//In the order of the tx hashes of the block encoding.
+func (cb *CompactBlock) idLists() []*[]ShortTxID {
+	return []*[]ShortTxID{&cb.AccTxIDs, &cb.FundsTxIDs, &cb.ConfigTxIDs, &cb.StakeTxIDs, &cb.AggTxIDs, &cb.IoTTxIDs, &cb.ContractTxIDs}
+}
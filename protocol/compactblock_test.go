@@ -0,0 +1,42 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCompactBlock(t *testing.T) {
+	block := newCodecTestBlock()
+	block.Aggregated = false
+	block.AccTxData = [][32]byte{{0x11}}
+	block.MerkleRoot = BuildMerkleTree(block).MerkleRoot()
+
+	var compactBlock *CompactBlock
+	compactBlock, _ = compactBlock.Decode(NewCompactBlock(block).Encode())
+	if compactBlock == nil || len(compactBlock.FundsTxIDs) != 2 || len(compactBlock.AccTxIDs) != 1 || compactBlock.Header.FundsTxData != nil {
+		t.Fatalf("Compact block round trip failed: %v\n", compactBlock)
+	}
+	if len(compactBlock.Encode()) >= len(block.Encode()) {
+		t.Errorf("Compact block is not smaller than the block: %v vs. %v\n", len(compactBlock.Encode()), len(block.Encode()))
+	}
+
+	known := [][32]byte{{0x07}, {0x06}, {0x11}, {0x05}, {0x42}}
+	reconstructed, err := compactBlock.Reconstruct(known)
+	if err != nil {
+		t.Fatalf("Compact block could not be reconstructed: %v\n", err)
+	}
+	if !reflect.DeepEqual(reconstructed.FundsTxData, block.FundsTxData) || !reflect.DeepEqual(reconstructed.AccTxData, block.AccTxData) ||
+		!reflect.DeepEqual(reconstructed.IoTTxData, block.IoTTxData) || reconstructed.Hash != block.Hash {
+		t.Errorf("Reconstructed block does not match: %v vs. %v\n", reconstructed, block)
+	}
+
+	if _, err := compactBlock.Reconstruct(known[1:]); err == nil {
+		t.Error("Compact block with an unknown tx was reconstructed.")
+	}
+
+	//A short id resolving to a different tx than the one of the block is detected by the merkle root.
+	compactBlock.IoTTxIDs[0] = NewShortTxID(block.Hash, [32]byte{0x42})
+	if _, err := compactBlock.Reconstruct(known); err == nil {
+		t.Error("Compact block not matching the merkle root was reconstructed.")
+	}
+}
@@ -3,23 +3,52 @@ package protocol
 import (
 	"bytes"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"golang.org/x/crypto/ed25519"
 )
 
+//A config tx is signed by a root key (Sig). If the Config_quorum requires several root keys, the signatures of the
+//other root keys are added with Cosign, each one referencing the root account by its hash. Like Sig, the
+//cosignatures are not covered by the tx hash.
+
+//Besides the system parameters, config txs add and remove root keys (ROOT_KEY_ADD_ID and ROOT_KEY_REMOVE_ID). Instead
+//of a payload they carry the hash of the root account, which is encoded after the fixed part of the tx.
+
 const (
-	CONFIGTX_SIZE = 83
-
-	BLOCK_SIZE_ID           = 1
-	DIFF_INTERVAL_ID        = 2
-	FEE_MINIMUM_ID          = 3
-	BLOCK_INTERVAL_ID       = 4
-	BLOCK_REWARD_ID         = 5
-	STAKING_MINIMUM_ID      = 6
-	WAITING_MINIMUM_ID      = 7
-	ACCEPTANCE_TIME_DIFF_ID = 8
-	SLASHING_WINDOW_SIZE_ID = 9
-	SLASHING_REWARD_ID      = 10
+	CONFIGTX_SIZE     = 83
+	CONFIG_COSIG_SIZE = 96
+	MAX_CONFIG_COSIGS = 16
+
+	BLOCK_SIZE_ID              = 1
+	DIFF_INTERVAL_ID           = 2
+	FEE_MINIMUM_ID             = 3
+	BLOCK_INTERVAL_ID          = 4
+	BLOCK_REWARD_ID            = 5
+	STAKING_MINIMUM_ID         = 6
+	WAITING_MINIMUM_ID         = 7
+	ACCEPTANCE_TIME_DIFF_ID    = 8
+	SLASHING_WINDOW_SIZE_ID    = 9
+	SLASHING_REWARD_ID         = 10
+	BLOCK_TRIGGER_TXS_ID       = 11
+	BLOCK_TRIGGER_TIME_ID      = 12
+	IOT_RATE_CAPACITY_ID       = 13
+	IOT_RATE_REFILL_ID         = 14
+	UNBONDING_PERIOD_ID        = 15
+	RSA_COMMITMENT_DEADLINE_ID = 16
+	SLASH_FRACTION_ID          = 17
+	CONFIG_QUORUM_ID           = 18
+	CONFIG_ACTIVATION_DELAY_ID = 19
+	DIFF_EMA_WINDOW_ID         = 20
+	STATE_ROOT_HEIGHT_ID       = 21
+	TX_ORDER_HEIGHT_ID         = 22
+	AGG_TX_AUTH_HEIGHT_ID      = 23
+	MEDIAN_TIME_HEIGHT_ID      = 24
+	BLOCK_HASH_HEIGHT_ID       = 25
+	DELEGATION_HEIGHT_ID       = 26
+
+	ROOT_KEY_ADD_ID    = 100
+	ROOT_KEY_REMOVE_ID = 101
 
 	MIN_BLOCK_SIZE = 1000      //1KB
 	MAX_BLOCK_SIZE = 100000000 //100MB
@@ -50,6 +79,54 @@ const (
 
 	MIN_SLASHING_REWARD = 0                   // reward for providing a valid slashing proof
 	MAX_SLASHING_REWARD = 1152921504606846976 //2^60
+
+	MIN_BLOCK_TRIGGER_TXS = 0       //number of pending txs which trigger a block, 0 does not wait for txs
+	MAX_BLOCK_TRIGGER_TXS = 1000000
+
+	MIN_BLOCK_TRIGGER_TIME = 0     //seconds since the last block after which a block is produced anyway
+	MAX_BLOCK_TRIGGER_TIME = 86400 //24 hours
+
+	MIN_IOT_RATE_CAPACITY = 0       //low fee IoT txs a sender can send in a burst, 0 disables the rate limit
+	MAX_IOT_RATE_CAPACITY = 1000000
+
+	MIN_IOT_RATE_REFILL = 1       //blocks after which a sender gets another low fee IoT tx
+	MAX_IOT_RATE_REFILL = 1000000
+
+	MIN_UNBONDING_PERIOD = 1      //blocks the stake stays locked after a validator stopped staking
+	MAX_UNBONDING_PERIOD = 100000
+
+	MIN_RSA_COMMITMENT_DEADLINE = 1          //block height after which only VRF commitment proofs are accepted
+	MAX_RSA_COMMITMENT_DEADLINE = 4294967295 //2^32-1
+
+	MIN_SLASH_FRACTION = 0   //percent of the balance a slashed validator loses, at least the staking minimum
+	MAX_SLASH_FRACTION = 100
+
+	MIN_CONFIG_QUORUM = 1                     //number of root keys which have to sign a config tx
+	MAX_CONFIG_QUORUM = MAX_CONFIG_COSIGS + 1
+
+	MIN_CONFIG_ACTIVATION_DELAY = 0      //blocks after the block including a config tx until the change is active
+	MAX_CONFIG_ACTIVATION_DELAY = 100000
+
+	MIN_DIFF_EMA_WINDOW = 0     //blocks the block time average is taken over, 0 for interval-based retargeting
+	MAX_DIFF_EMA_WINDOW = 10000
+
+	MIN_STATE_ROOT_HEIGHT = 0          //block height above which blocks without state root are rejected
+	MAX_STATE_ROOT_HEIGHT = 4294967295 //2^32-1
+
+	MIN_TX_ORDER_HEIGHT = 0          //block height above which blocks with reordered txs are rejected
+	MAX_TX_ORDER_HEIGHT = 4294967295 //2^32-1
+
+	MIN_AGG_TX_AUTH_HEIGHT = 0          //block height above which blocks with unauthenticated aggTxs are rejected
+	MAX_AGG_TX_AUTH_HEIGHT = 4294967295 //2^32-1
+
+	MIN_MEDIAN_TIME_HEIGHT = 0          //block height above which blocks at or below the median time past are rejected
+	MAX_MEDIAN_TIME_HEIGHT = 4294967295 //2^32-1
+
+	MIN_BLOCK_HASH_HEIGHT = 0          //block height above which the block hashes cover the timestamp and commitment proof
+	MAX_BLOCK_HASH_HEIGHT = 4294967295 //2^32-1
+
+	MIN_DELEGATION_HEIGHT = 0          //block height above which funds txs can delegate stake to validators
+	MAX_DELEGATION_HEIGHT = 4294967295 //2^32-1
 )
 
 type ConfigTx struct {
@@ -59,6 +136,13 @@ type ConfigTx struct {
 	Fee     uint64
 	TxCnt   uint8
 	Sig     [64]byte
+	Cosigs  []ConfigCosignature
+	RootKey [32]byte //Hash of the root account added or removed, only set for root key changes
+}
+
+type ConfigCosignature struct {
+	Root [32]byte //Hash of the root account
+	Sig  [64]byte
 }
 
 func ConstrConfigTx(header byte, id uint8, payload uint64, fee uint64, txCnt uint8, rootPrivKey ed25519.PrivateKey) (tx *ConfigTx, err error) {
@@ -80,6 +164,48 @@ func ConstrConfigTx(header byte, id uint8, payload uint64, fee uint64, txCnt uin
 	return tx, nil
 }
 
+//Constructs a config tx adding (ROOT_KEY_ADD_ID) or removing (ROOT_KEY_REMOVE_ID) the root account with hash rootKey.
+func ConstrRootKeyTx(header byte, id uint8, rootKey [32]byte, fee uint64, txCnt uint8, rootPrivKey ed25519.PrivateKey) (tx *ConfigTx, err error) {
+	if id != ROOT_KEY_ADD_ID && id != ROOT_KEY_REMOVE_ID {
+		return nil, errors.New(fmt.Sprintf("Id %v is not a root key change.", id))
+	}
+
+	tx = new(ConfigTx)
+	tx.Header = header
+	tx.Id = id
+	tx.RootKey = rootKey
+	tx.Fee = fee
+	tx.TxCnt = txCnt
+
+	txHash := tx.Hash()
+	copy(tx.Sig[:], ed25519.Sign(rootPrivKey, txHash[:]))
+
+	return tx, nil
+}
+
+func (tx *ConfigTx) IsRootKeyChange() bool {
+	return tx.Id == ROOT_KEY_ADD_ID || tx.Id == ROOT_KEY_REMOVE_ID
+}
+
+//Adds the signature of another root key.
+func (tx *ConfigTx) Cosign(rootPrivKey ed25519.PrivateKey) {
+	var rootPublicKey [32]byte
+	copy(rootPublicKey[:], rootPrivKey[32:])
+	root := SerializeHashContent(rootPublicKey)
+
+	txHash := tx.Hash()
+	var sig [64]byte
+	copy(sig[:], ed25519.Sign(rootPrivKey, txHash[:]))
+
+	for i, cosig := range tx.Cosigs {
+		if cosig.Root == root {
+			tx.Cosigs[i].Sig = sig
+			return
+		}
+	}
+	tx.Cosigs = append(tx.Cosigs, ConfigCosignature{root, sig})
+}
+
 func (tx *ConfigTx) Hash() (hash [32]byte) {
 
 	if tx == nil {
@@ -99,6 +225,27 @@ func (tx *ConfigTx) Hash() (hash [32]byte) {
 		tx.Fee,
 		tx.TxCnt,
 	}
+
+	//The root key is only part of the hash of root key changes, the hash of parameter changes stays the same.
+	if tx.IsRootKeyChange() {
+		rootKeyTxHash := struct {
+			Header  byte
+			Id      uint8
+			Payload uint64
+			Fee     uint64
+			TxCnt   uint8
+			RootKey [32]byte
+		}{
+			tx.Header,
+			tx.Id,
+			tx.Payload,
+			tx.Fee,
+			tx.TxCnt,
+			tx.RootKey,
+		}
+		return SerializeHashContent(rootKeyTxHash)
+	}
+
 	return SerializeHashContent(txHash)
 }
 
@@ -119,7 +266,7 @@ func (tx *ConfigTx) Encode() (encodedTx []byte) {
 	copy(feeBuf[:], buf.Bytes())
 	buf.Reset()
 
-	encodedTx = make([]byte, CONFIGTX_SIZE)
+	encodedTx = make([]byte, tx.Size())
 	encodedTx[0] = tx.Header
 	encodedTx[1] = tx.Id
 	copy(encodedTx[2:10], payloadBuf[:])
@@ -127,41 +274,111 @@ func (tx *ConfigTx) Encode() (encodedTx []byte) {
 	encodedTx[18] = byte(tx.TxCnt)
 	copy(encodedTx[19:83], tx.Sig[:])
 
+	size := CONFIGTX_SIZE
+	if tx.IsRootKeyChange() {
+		copy(encodedTx[size:size+32], tx.RootKey[:])
+		size += 32
+	}
+
+	//The cosignatures are optional, the encoding of txs without them does not change.
+	if len(tx.Cosigs) > 0 {
+		encodedTx[size] = uint8(len(tx.Cosigs))
+		for i, cosig := range tx.Cosigs {
+			offset := size + 1 + i*CONFIG_COSIG_SIZE
+			copy(encodedTx[offset:offset+32], cosig.Root[:])
+			copy(encodedTx[offset+32:offset+CONFIG_COSIG_SIZE], cosig.Sig[:])
+		}
+	}
+
 	return encodedTx
 }
 
-func (*ConfigTx) Decode(encodedTx []byte) (tx *ConfigTx) {
+func (*ConfigTx) Decode(encodedTx []byte) (*ConfigTx, error) {
+	if len(encodedTx) < CONFIGTX_SIZE {
+		return nil, errors.New(fmt.Sprintf("Config tx too short (%v bytes).", len(encodedTx)))
+	}
 
-	if len(encodedTx) != CONFIGTX_SIZE {
-		return nil
+	size := CONFIGTX_SIZE
+	if id := encodedTx[1]; id == ROOT_KEY_ADD_ID || id == ROOT_KEY_REMOVE_ID {
+		size += 32
+	}
+	if len(encodedTx) < size {
+		return nil, errors.New(fmt.Sprintf("Root key change too short (%v bytes).", len(encodedTx)))
 	}
 
-	tx = new(ConfigTx)
+	//Only the canonical encoding is accepted, i.e., the list of cosignatures is omitted if empty.
+	var nrCosigs int
+	if len(encodedTx) > size {
+		nrCosigs = int(encodedTx[size])
+		if nrCosigs == 0 || nrCosigs > MAX_CONFIG_COSIGS || len(encodedTx) != size+1+nrCosigs*CONFIG_COSIG_SIZE {
+			return nil, errors.New(fmt.Sprintf("Invalid list of %v cosignatures.", nrCosigs))
+		}
+	}
+
+	tx := new(ConfigTx)
 	tx.Header = encodedTx[0]
 	tx.Id = encodedTx[1]
 	tx.Payload = binary.BigEndian.Uint64(encodedTx[2:10])
 	tx.Fee = binary.BigEndian.Uint64(encodedTx[10:18])
 	tx.TxCnt = uint8(encodedTx[18])
 	copy(tx.Sig[:], encodedTx[19:83])
+	if tx.IsRootKeyChange() {
+		copy(tx.RootKey[:], encodedTx[CONFIGTX_SIZE:size])
+	}
+	for i := 0; i < nrCosigs; i++ {
+		offset := size + 1 + i*CONFIG_COSIG_SIZE
+		var cosig ConfigCosignature
+		copy(cosig.Root[:], encodedTx[offset:offset+32])
+		copy(cosig.Sig[:], encodedTx[offset+32:offset+CONFIG_COSIG_SIZE])
+		tx.Cosigs = append(tx.Cosigs, cosig)
+	}
 
-	return tx
+	return tx, nil
 }
 
 func (tx *ConfigTx) TxFee() uint64 { return tx.Fee }
-func (tx *ConfigTx) Size() uint64  { return CONFIGTX_SIZE }
+func (tx *ConfigTx) Size() uint64 {
+	size := uint64(CONFIGTX_SIZE)
+	if tx.IsRootKeyChange() {
+		size += 32
+	}
+	if len(tx.Cosigs) == 0 {
+		return size
+	}
+
+	return size + 1 + uint64(len(tx.Cosigs))*CONFIG_COSIG_SIZE
+}
 func (tx *ConfigTx) Sender() [32]byte { return [32]byte{} } //Return empty because never needed.
 func (tx *ConfigTx) Receiver() [32]byte { return [32]byte{}}
 
 func (tx ConfigTx) String() string {
+	if tx.IsRootKeyChange() {
+		return fmt.Sprintf(
+			"\n"+
+				"Id: %v\n"+
+				"Root key: %x\n"+
+				"Fee: %v\n"+
+				"TxCnt: %v\n"+
+				"Cosigs: %v\n",
+			tx.Id,
+			tx.RootKey[0:8],
+			tx.Fee,
+			tx.TxCnt,
+			len(tx.Cosigs),
+		)
+	}
+
 	return fmt.Sprintf(
 		"\n"+
 			"Id: %v\n"+
 			"Payload: %v\n"+
 			"Fee: %v\n"+
-			"TxCnt: %v\n",
+			"TxCnt: %v\n"+
+			"Cosigs: %v\n",
 		tx.Id,
 		tx.Payload,
 		tx.Fee,
 		tx.TxCnt,
+		len(tx.Cosigs),
 	)
 }
@@ -1,6 +1,8 @@
 package protocol
 
 import (
+	crand "crypto/rand"
+	"golang.org/x/crypto/ed25519"
 	"math/rand"
 	"reflect"
 	"testing"
@@ -15,9 +17,73 @@ func TestConfigTxSerialization(t *testing.T) {
 		tx, err := ConstrConfigTx(uint8(rand.Uint32()%256), uint8(rand.Uint32()%256), rand.Uint64(), rand.Uint64(), uint8(i), RootPrivKey)
 		data := tx.Encode()
 		var decodedTx *ConfigTx
-		decodedTx = decodedTx.Decode(data)
+		decodedTx, _ = decodedTx.Decode(data)
 		if !reflect.DeepEqual(tx, decodedTx) || err != nil {
 			t.Errorf("ConfigTx Serialization failed (%v) vs. (%v)\n", tx, decodedTx)
 		}
 	}
 }
+
+func TestConfigTxCosignatures(t *testing.T) {
+	_, privKey, _ := ed25519.GenerateKey(crand.Reader)
+	_, cosignerKey, _ := ed25519.GenerateKey(crand.Reader)
+
+	tx, _ := ConstrConfigTx(0, 1, 5000, 1, 0, privKey)
+	hash := tx.Hash()
+	if len(tx.Encode()) != CONFIGTX_SIZE {
+		t.Errorf("ConfigTx without cosignatures has length %v\n", len(tx.Encode()))
+	}
+
+	//Cosigning twice with the same key replaces the cosignature.
+	tx.Cosign(cosignerKey)
+	tx.Cosign(cosignerKey)
+	if len(tx.Cosigs) != 1 || tx.Hash() != hash {
+		t.Errorf("Cosigning failed: %v cosignatures, hash %x vs. %x\n", len(tx.Cosigs), tx.Hash(), hash)
+	}
+
+	var cosignerAddress [32]byte
+	copy(cosignerAddress[:], cosignerKey[32:])
+	if tx.Cosigs[0].Root != SerializeHashContent(cosignerAddress) || !ed25519.Verify(cosignerKey.Public().(ed25519.PublicKey), hash[:], tx.Cosigs[0].Sig[:]) {
+		t.Error("Invalid cosignature.\n")
+	}
+
+	data := tx.Encode()
+	var decodedTx *ConfigTx
+	if decodedTx, _ = decodedTx.Decode(data); !reflect.DeepEqual(tx, decodedTx) || uint64(len(data)) != tx.Size() {
+		t.Errorf("ConfigTx with cosignatures serialization failed (%v) vs. (%v)\n", tx, decodedTx)
+	}
+
+	//An empty list of cosignatures and truncated cosignatures are rejected.
+	if _, err := decodedTx.Decode(append(data[:CONFIGTX_SIZE:CONFIGTX_SIZE], 0)); err == nil {
+		t.Error("Config tx with an empty list of cosignatures decoded.\n")
+	}
+	if _, err := decodedTx.Decode(data[:len(data)-1]); err == nil {
+		t.Error("Non-canonical ConfigTx decoded.\n")
+	}
+}
+
+func TestRootKeyTxSerialization(t *testing.T) {
+	_, privKey, _ := ed25519.GenerateKey(crand.Reader)
+
+	if _, err := ConstrRootKeyTx(0, BLOCK_SIZE_ID, [32]byte{'r'}, 1, 0, privKey); err == nil {
+		t.Error("Root key change with a parameter id constructed.\n")
+	}
+
+	tx, _ := ConstrRootKeyTx(0, ROOT_KEY_ADD_ID, [32]byte{'r'}, 1, 0, privKey)
+	removal, _ := ConstrRootKeyTx(0, ROOT_KEY_REMOVE_ID, [32]byte{'r'}, 1, 0, privKey)
+	other, _ := ConstrRootKeyTx(0, ROOT_KEY_ADD_ID, [32]byte{'o'}, 1, 0, privKey)
+	if tx.Hash() == removal.Hash() || tx.Hash() == other.Hash() {
+		t.Error("Root key changes with a different id or root key have the same hash.\n")
+	}
+
+	tx.Cosign(privKey)
+	data := tx.Encode()
+	var decodedTx *ConfigTx
+	if decodedTx, _ = decodedTx.Decode(data); !reflect.DeepEqual(tx, decodedTx) || uint64(len(data)) != tx.Size() {
+		t.Errorf("Root key change serialization failed (%v) vs. (%v)\n", tx, decodedTx)
+	}
+
+	if _, err := decodedTx.Decode(data[:CONFIGTX_SIZE]); err == nil {
+		t.Error("Root key change without the root key decoded.\n")
+	}
+}
@@ -0,0 +1,165 @@
+package protocol
+
+import (
+	"fmt"
+	"golang.org/x/crypto/ed25519"
+)
+
+//Deploys a smart contract. The contract account is created at an address derived from the deployer and its txCnt
+//(see ContractAddress), so every deployment gets a fresh address and no key pair is needed for it. Besides the fee
+//paid to the miner, the deployer is charged the deployment gas (see DeploymentGas), which is burned. The gas must
+//not exceed GasLimit, so the deployer knows the maximum amount charged when signing the tx.
+
+const (
+	CONTRACTTX_SIZE       = 1 + 32 + 4 + 8 + 8 + 64
+	CONTRACT_DEPLOY_GAS   = 1000
+	CONTRACT_GAS_PER_BYTE = 10
+)
+
+type ContractTx struct {
+	Header            byte
+	From              [32]byte
+	TxCnt             uint32
+	Fee               uint64
+	GasLimit          uint64
+	Contract          []byte
+	ContractVariables []ByteArray
+	Sig               [64]byte
+}
+
+func ConstrContractTx(header byte, fee uint64, gasLimit uint64, txCnt uint32, from [32]byte, contract []byte, contractVariables []ByteArray, sigKey ed25519.PrivateKey) (tx *ContractTx, err error) {
+	tx = new(ContractTx)
+	tx.Header = header
+	tx.From = from
+	tx.TxCnt = txCnt
+	tx.Fee = fee
+	tx.GasLimit = gasLimit
+	tx.Contract = contract
+	tx.ContractVariables = contractVariables
+
+	txHash := tx.Hash()
+	copy(tx.Sig[:], ed25519.Sign(sigKey, txHash[:]))
+
+	return tx, nil
+}
+
+//The address of the contract account deployed by the account with hash from with the given txCnt.
+func ContractAddress(from [32]byte, txCnt uint32) [32]byte {
+	address := struct {
+		From  [32]byte
+		TxCnt uint32
+	}{
+		from,
+		txCnt,
+	}
+
+	return SerializeHashContent(address)
+}
+
+//The gas charged for storing the contract and its constructor parameters in the state.
+func (tx *ContractTx) DeploymentGas() uint64 {
+	size := uint64(len(tx.Contract))
+	for _, variable := range tx.ContractVariables {
+		size += uint64(len(variable))
+	}
+
+	return CONTRACT_DEPLOY_GAS + size*CONTRACT_GAS_PER_BYTE
+}
+
+func (tx *ContractTx) Hash() [32]byte {
+	if tx == nil {
+		return [32]byte{}
+	}
+
+	txHash := struct {
+		Header            byte
+		From              [32]byte
+		TxCnt             uint32
+		Fee               uint64
+		GasLimit          uint64
+		Contract          []byte
+		ContractVariables []ByteArray
+	}{
+		tx.Header,
+		tx.From,
+		tx.TxCnt,
+		tx.Fee,
+		tx.GasLimit,
+		tx.Contract,
+		tx.ContractVariables,
+	}
+
+	return SerializeHashContent(txHash)
+}
+
+func (tx *ContractTx) Encode() []byte {
+	if tx == nil {
+		return nil
+	}
+
+	enc := newEncoder()
+	enc.uint8(tx.Header)
+	enc.array(tx.From[:])
+	enc.uint32(tx.TxCnt)
+	enc.uint64(tx.Fee)
+	enc.uint64(tx.GasLimit)
+	enc.bytes(tx.Contract)
+	enc.byteArrays(tx.ContractVariables)
+	enc.array(tx.Sig[:])
+
+	return enc.Bytes()
+}
+
+func (*ContractTx) Decode(encoded []byte) (*ContractTx, error) {
+	var decoded ContractTx
+
+	dec := newDecoder(encoded)
+	decoded.Header = dec.uint8()
+	dec.array(decoded.From[:])
+	decoded.TxCnt = dec.uint32()
+	decoded.Fee = dec.uint64()
+	decoded.GasLimit = dec.uint64()
+	decoded.Contract = dec.bytes()
+	decoded.ContractVariables = dec.byteArrays()
+	dec.array(decoded.Sig[:])
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
+
+func (tx *ContractTx) TxFee() uint64 { return tx.Fee }
+
+func (tx *ContractTx) Size() uint64 {
+	size := CONTRACTTX_SIZE + uint64(len(tx.Contract))
+	for _, variable := range tx.ContractVariables {
+		size += uint64(len(variable))
+	}
+
+	return size
+}
+
+func (tx *ContractTx) Sender() [32]byte   { return tx.From }
+func (tx *ContractTx) Receiver() [32]byte { return [32]byte{} }
+
+func (tx ContractTx) String() string {
+	return fmt.Sprintf(
+		"\nHeader: %x\n"+
+			"From: %x\n"+
+			"TxCnt: %v\n"+
+			"Fee: %v\n"+
+			"GasLimit: %v\n"+
+			"Contract: %v bytes\n"+
+			"ContractVariables: %v\n"+
+			"Sig: %x\n",
+		tx.Header,
+		tx.From[0:8],
+		tx.TxCnt,
+		tx.Fee,
+		tx.GasLimit,
+		len(tx.Contract),
+		tx.ContractVariables,
+		tx.Sig[0:8],
+	)
+}
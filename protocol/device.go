@@ -0,0 +1,99 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ed25519"
+)
+
+//IoT devices are onboarded with an accTx tagging the new account as a device. The tag carries metadata about the
+//device: its type (e.g., a model name), the account of its owner and a rate limit, the maximum number of IotTxs the
+//device may send per block. The rate limit is enforced by validators, so a compromised or faulty device can not flood
+//the chain. A limit of 0 means the device is not limited. Devices can not be root or multi-signature accounts.
+
+const (
+	MAX_DEVICE_TYPE_LENGTH = 32
+	DEVICE_INFO_SIZE       = 40 //Without the type
+)
+
+type DeviceInfo struct {
+	Type      string
+	Owner     [32]byte //Hash of the owner's account
+	RateLimit uint32   //IotTxs per block, 0 for no limit
+}
+
+func ConstrDeviceAccTx(header byte, fee uint64, amount uint64, address [32]byte, device DeviceInfo, rootPrivKey ed25519.PrivateKey) (tx *AccTx, err error) {
+	if err := device.Check(); err != nil {
+		return nil, err
+	}
+
+	tx = new(AccTx)
+	tx.Header = header
+	tx.Fee = fee
+	tx.Amount = amount
+	tx.PubKey = address
+	tx.Device = &device
+
+	var rootPublicKey [32]byte
+	copy(rootPublicKey[:], rootPrivKey[32:])
+	tx.Issuer = SerializeHashContent(rootPublicKey)
+
+	txHash := tx.Hash()
+	copy(tx.Sig[:], ed25519.Sign(rootPrivKey, txHash[:]))
+
+	return tx, nil
+}
+
+//Checks the metadata itself, whether the owner exists is checked against the state.
+func (device *DeviceInfo) Check() error {
+	if len(device.Type) == 0 || len(device.Type) > MAX_DEVICE_TYPE_LENGTH {
+		return errors.New(fmt.Sprintf("Device type must have between 1 and %v characters.", MAX_DEVICE_TYPE_LENGTH))
+	}
+
+	if device.Owner == [32]byte{} {
+		return errors.New("Device owner missing.")
+	}
+
+	return nil
+}
+
+//Returns true if the device may not send another IotTx in a block which already contains the given number of its txs.
+func (device *DeviceInfo) RateLimited(sent uint32) bool {
+	return device.RateLimit > 0 && sent >= device.RateLimit
+}
+
+func (acc *Account) IsDevice() bool {
+	return acc.Device != nil
+}
+
+//Accounts which are not devices but have fields encoded after the device are encoded with an empty device, which is
+//decoded to nil. Devices always have a type.
+func (enc *encoder) device(device *DeviceInfo) {
+	if device == nil {
+		device = new(DeviceInfo)
+	}
+
+	enc.bytes([]byte(device.Type))
+	enc.array(device.Owner[:])
+	enc.uint32(device.RateLimit)
+}
+
+func (dec *decoder) device() *DeviceInfo {
+	device := new(DeviceInfo)
+	device.Type = string(dec.bytes())
+	dec.array(device.Owner[:])
+	device.RateLimit = dec.uint32()
+	if len(device.Type) == 0 {
+		return nil
+	}
+
+	return device
+}
+
+func (device *DeviceInfo) String() string {
+	if device == nil {
+		return "none"
+	}
+
+	return fmt.Sprintf("{Type: %v, Owner: %x, RateLimit: %v}", device.Type, device.Owner[0:8], device.RateLimit)
+}
@@ -0,0 +1,187 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/sha3"
+)
+
+//The aggTxs of old blocks are aggregated once more, so the txs of the past do not need to be kept to prove that they
+//are part of the chain. The aggTxs of an epoch of blocks form a first level aggregate, its Merkle root commits to the
+//fundsTxs they aggregate. Consecutive aggregates of the same level are aggregated recursively into an aggregate of the
+//next level, whose Merkle root commits to the hashes of the lower level aggregates. The inclusion of a tx is proven
+//with an AggregationProof: a Merkle proof per level, from the tx up to the highest level aggregate.
+
+const EPOCHAGGREGATE_SIZE = 2 + 1 + 4 + 4 + 4 + 8 + 8 + 32
+
+type EpochAggregate struct {
+	Level       uint8
+	FirstHeight uint32
+	LastHeight  uint32
+	//Number of fundsTxs aggregated by the aggTxs of the blocks, on all levels.
+	NrTxs  uint32
+	Amount uint64
+	Fee    uint64
+	//Merkle root of the aggregated hashes, zero if there are none.
+	Root [32]byte
+}
+
+//Returns the first level aggregate of the aggTxs of the blocks from firstHeight to lastHeight and the hashes it commits
+//to, i.e., the hashes of the fundsTxs aggregated by the aggTxs.
+func NewEpochAggregate(firstHeight uint32, lastHeight uint32, aggTxs []*AggTx) (*EpochAggregate, [][32]byte, error) {
+	aggregate := &EpochAggregate{Level: 1, FirstHeight: firstHeight, LastHeight: lastHeight}
+
+	var leaves [][32]byte
+	for _, aggTx := range aggTxs {
+		if aggregate.Amount+aggTx.Amount < aggregate.Amount || aggregate.Fee+aggTx.Fee < aggregate.Fee {
+			return nil, nil, errors.New("Aggregated amount or fee overflows.")
+		}
+		aggregate.Amount += aggTx.Amount
+		aggregate.Fee += aggTx.Fee
+		leaves = append(leaves, aggTx.AggregatedTxSlice...)
+	}
+	aggregate.NrTxs = uint32(len(leaves))
+
+	return aggregate, leaves, aggregate.setRoot(leaves)
+}
+
+//Returns the aggregate of the next level of consecutive aggregates of the same level and the hashes it commits to.
+func AggregateEpochAggregates(aggregates []*EpochAggregate) (*EpochAggregate, [][32]byte, error) {
+	if len(aggregates) == 0 {
+		return nil, nil, errors.New("Cannot aggregate no aggregates.")
+	}
+
+	aggregate := &EpochAggregate{
+		Level:       aggregates[0].Level + 1,
+		FirstHeight: aggregates[0].FirstHeight,
+		LastHeight:  aggregates[len(aggregates)-1].LastHeight,
+	}
+
+	var leaves [][32]byte
+	for i, child := range aggregates {
+		if child.Level != aggregates[0].Level || (i > 0 && child.FirstHeight != aggregates[i-1].LastHeight+1) {
+			return nil, nil, errors.New(fmt.Sprintf("Aggregate %v-%v does not follow the previous one.", child.FirstHeight, child.LastHeight))
+		}
+		if aggregate.Amount+child.Amount < aggregate.Amount || aggregate.Fee+child.Fee < aggregate.Fee {
+			return nil, nil, errors.New("Aggregated amount or fee overflows.")
+		}
+		aggregate.NrTxs += child.NrTxs
+		aggregate.Amount += child.Amount
+		aggregate.Fee += child.Fee
+		leaves = append(leaves, child.Hash())
+	}
+
+	return aggregate, leaves, aggregate.setRoot(leaves)
+}
+
+func (aggregate *EpochAggregate) setRoot(leaves [][32]byte) error {
+	if len(leaves) == 0 {
+		return nil
+	}
+
+	tree, err := newTree(leaves)
+	if err != nil {
+		return err
+	}
+	aggregate.Root = tree.MerkleRoot()
+
+	return nil
+}
+
+//Returns the Merkle proof of the leaf, leaves are the hashes the aggregate commits to.
+func (aggregate *EpochAggregate) Proof(leaves [][32]byte, leaf [32]byte) (*MerkleProof, error) {
+	tree, err := newTree(leaves)
+	if err != nil {
+		return nil, err
+	}
+	if tree.MerkleRoot() != aggregate.Root {
+		return nil, errors.New("Leaves do not match the aggregate.")
+	}
+
+	return tree.GetMerkleProof(leaf)
+}
+
+func (aggregate *EpochAggregate) Hash() [32]byte {
+	return sha3.Sum256(aggregate.Encode())
+}
+
+func (aggregate *EpochAggregate) Encode() []byte {
+	if aggregate == nil {
+		return nil
+	}
+
+	enc := newEncoder()
+	enc.uint8(aggregate.Level)
+	enc.uint32(aggregate.FirstHeight)
+	enc.uint32(aggregate.LastHeight)
+	enc.uint32(aggregate.NrTxs)
+	enc.uint64(aggregate.Amount)
+	enc.uint64(aggregate.Fee)
+	enc.array(aggregate.Root[:])
+
+	return enc.Bytes()
+}
+
+func (*EpochAggregate) Decode(encoded []byte) (*EpochAggregate, error) {
+	var decoded EpochAggregate
+	dec := newDecoder(encoded)
+	decoded.Level = dec.uint8()
+	decoded.FirstHeight = dec.uint32()
+	decoded.LastHeight = dec.uint32()
+	decoded.NrTxs = dec.uint32()
+	decoded.Amount = dec.uint64()
+	decoded.Fee = dec.uint64()
+	dec.array(decoded.Root[:])
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
+
+func (aggregate EpochAggregate) String() string {
+	return fmt.Sprintf(
+		"\nLevel: %v\n"+
+			"Heights: %v-%v\n"+
+			"#Tx: %v\n"+
+			"Amount: %v\n"+
+			"Fee: %v\n"+
+			"Root: %x\n",
+		aggregate.Level,
+		aggregate.FirstHeight,
+		aggregate.LastHeight,
+		aggregate.NrTxs,
+		aggregate.Amount,
+		aggregate.Fee,
+		aggregate.Root,
+	)
+}
+
+type AggregationStep struct {
+	Aggregate EpochAggregate
+	Proof     MerkleProof
+}
+
+//Proves that the tx is aggregated by the aggregate of the last step. Step i holds the aggregate of level i+1.
+type AggregationProof struct {
+	TxHash [32]byte
+	Steps  []AggregationStep
+}
+
+//Returns the highest level aggregate if the proof is valid, nil otherwise. Whether the aggregate is the one of the
+//chain needs to be checked against the aggregates of a trusted node.
+func (proof *AggregationProof) Verify() *EpochAggregate {
+	if len(proof.Steps) == 0 {
+		return nil
+	}
+
+	hash := proof.TxHash
+	for i, step := range proof.Steps {
+		if int(step.Aggregate.Level) != i+1 || !step.Proof.Verify(hash, step.Aggregate.Root) {
+			return nil
+		}
+		hash = step.Aggregate.Hash()
+	}
+
+	return &proof.Steps[len(proof.Steps)-1].Aggregate
+}
@@ -0,0 +1,70 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEpochAggregateSerialization(t *testing.T) {
+	aggTx, _ := ConstrAggTx(30, 3, [][32]byte{{'a'}}, [][32]byte{{'b'}, {'c'}}, [][32]byte{{0x01}, {0x02}})
+	aggregate, leaves, err := NewEpochAggregate(10, 19, []*AggTx{aggTx})
+	if err != nil || aggregate.NrTxs != 2 || aggregate.Amount != 30 || aggregate.Fee != 3 || len(leaves) != 2 {
+		t.Fatalf("Unexpected aggregate: %v (%v)\n", aggregate, err)
+	}
+
+	var decoded *EpochAggregate
+	if decoded, _ = decoded.Decode(aggregate.Encode()); !reflect.DeepEqual(aggregate, decoded) {
+		t.Errorf("Aggregate serialization failed (%v) vs. (%v)\n", aggregate, decoded)
+	}
+
+	empty, leaves, err := NewEpochAggregate(20, 29, nil)
+	if err != nil || empty.Root != [32]byte{} || leaves != nil {
+		t.Errorf("Aggregate of an epoch without aggTxs should have no root: %v (%v)\n", empty, err)
+	}
+}
+
+func TestAggregationProof(t *testing.T) {
+	var children []*EpochAggregate
+	var childLeaves [][][32]byte
+	for i := uint32(0); i < 3; i++ {
+		aggTx, _ := ConstrAggTx(10, 1, [][32]byte{{'a'}}, [][32]byte{{'b'}}, [][32]byte{{byte(i), 0x01}, {byte(i), 0x02}, {byte(i), 0x03}})
+		child, leaves, _ := NewEpochAggregate(i*10, i*10+9, []*AggTx{aggTx})
+		children = append(children, child)
+		childLeaves = append(childLeaves, leaves)
+	}
+
+	parent, parentLeaves, err := AggregateEpochAggregates(children)
+	if err != nil || parent.Level != 2 || parent.FirstHeight != 0 || parent.LastHeight != 29 || parent.NrTxs != 9 {
+		t.Fatalf("Unexpected aggregate: %v (%v)\n", parent, err)
+	}
+
+	if _, _, err := AggregateEpochAggregates([]*EpochAggregate{children[0], children[2]}); err == nil {
+		t.Error("Aggregates with a gap should not be aggregated.")
+	}
+
+	txHash := [32]byte{1, 0x02}
+	childProof, err := children[1].Proof(childLeaves[1], txHash)
+	if err != nil {
+		t.Fatalf("Creating the proof failed: %v\n", err)
+	}
+	parentProof, err := parent.Proof(parentLeaves, children[1].Hash())
+	if err != nil {
+		t.Fatalf("Creating the proof failed: %v\n", err)
+	}
+
+	proof := &AggregationProof{TxHash: txHash, Steps: []AggregationStep{{*children[1], *childProof}, {*parent, *parentProof}}}
+	if top := proof.Verify(); top == nil || top.Hash() != parent.Hash() {
+		t.Errorf("Valid proof was rejected: %v\n", top)
+	}
+
+	proof.TxHash = [32]byte{1, 0x04}
+	if proof.Verify() != nil {
+		t.Error("Proof of another tx was accepted.")
+	}
+
+	proof.TxHash = txHash
+	proof.Steps[0].Aggregate.Amount++
+	if proof.Verify() != nil {
+		t.Error("Proof with a forged aggregate was accepted.")
+	}
+}
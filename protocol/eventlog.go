@@ -0,0 +1,90 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+)
+
+//Event emitted by a contract with the LOG opcode, e.g., to notify wallets of a token transfer without them having to
+//inspect the contract variables. Logs are queried by their topics, the data is arbitrary. Logs are not part of the
+//state, they are only kept if the contract's execution succeeded.
+
+const (
+	MAX_LOG_TOPICS     = 4
+	MAX_LOG_TOPIC_SIZE = 32
+	MAX_LOGS_PER_TX    = 64
+)
+
+type Log struct {
+	Contract [32]byte
+	TxHash   [32]byte
+	Height   uint32
+	//Position of the log among the logs of the block
+	Index  uint32
+	Topics []ByteArray
+	Data   []byte
+}
+
+func (log *Log) HasTopic(topic []byte) bool {
+	for _, logTopic := range log.Topics {
+		if bytes.Equal(logTopic, topic) {
+			return true
+		}
+	}
+
+	return false
+}
+
+//The logs of a contract in a block are stored together, see storage.WriteLogs.
+func EncodeLogs(logs []*Log) []byte {
+	enc := newEncoder()
+	enc.uint32(uint32(len(logs)))
+	for _, log := range logs {
+		enc.array(log.Contract[:])
+		enc.array(log.TxHash[:])
+		enc.uint32(log.Height)
+		enc.uint32(log.Index)
+		enc.byteArrays(log.Topics)
+		enc.bytes(log.Data)
+	}
+
+	return enc.Bytes()
+}
+
+func DecodeLogs(encoded []byte) ([]*Log, error) {
+	var logs []*Log
+	dec := newDecoder(encoded)
+	count := dec.uint32()
+	for i := uint32(0); i < count && dec.err == nil; i++ {
+		log := new(Log)
+		dec.array(log.Contract[:])
+		dec.array(log.TxHash[:])
+		log.Height = dec.uint32()
+		log.Index = dec.uint32()
+		log.Topics = dec.byteArrays()
+		log.Data = dec.bytes()
+		logs = append(logs, log)
+	}
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return logs, nil
+}
+
+func (log Log) String() string {
+	return fmt.Sprintf(
+		"\nContract: %x\n"+
+			"TxHash: %x\n"+
+			"Height: %v\n"+
+			"Index: %v\n"+
+			"Topics: %v\n"+
+			"Data: %x\n",
+		log.Contract[0:8],
+		log.TxHash[0:8],
+		log.Height,
+		log.Index,
+		log.Topics,
+		log.Data,
+	)
+}
@@ -1,14 +1,20 @@
 package protocol
 
 import (
-	"bytes"
-	"encoding/gob"
+	"errors"
 	"fmt"
 	"golang.org/x/crypto/ed25519"
 )
 
 const (
 	FUNDSTX_SIZE = 213
+
+	//LockUntil values below are block heights, values from here on are Unix timestamps (like Bitcoin's nLockTime).
+	LOCK_TIME_THRESHOLD = 500000000
+
+	//Header bits of txs delegating the amount to the receiver (a validator) or taking it back, see miner/delegation.go.
+	FUNDSTX_DELEGATE   = 0x10
+	FUNDSTX_UNDELEGATE = 0x20
 )
 
 //when we broadcast transactions we need a way to distinguish with a type
@@ -23,9 +29,24 @@ type FundsTx struct {
 	Sig  		[64]byte
 	Aggregated 	bool
 	Data   		[]byte
+	Cosigs 		[]Cosignature
+	LockUntil 	uint64
+	GasLimit 	uint64
+	GasPrice 	uint64
 }
 
 func ConstrFundsTx(header byte, amount uint64, fee uint64, txCnt uint32, from, to [32]byte, sigKey ed25519.PrivateKey, data []byte) (tx *FundsTx, err error) {
+	return ConstrFundsTxWithSigner(header, amount, fee, txCnt, from, to, NewKeySigner(sigKey), data)
+}
+
+//The signature is verified before it is returned, so a misbehaving signer (e.g., a faulty hardware wallet) is detected
+//before the tx is broadcast.
+func ConstrFundsTxWithSigner(header byte, amount uint64, fee uint64, txCnt uint32, from, to [32]byte, signer FundsTxSigner, data []byte) (tx *FundsTx, err error) {
+	return ConstrLockedFundsTx(header, amount, fee, txCnt, from, to, 0, signer, data)
+}
+
+//The tx can not be included in a block before lockUntil (a block height or a Unix timestamp, see LOCK_TIME_THRESHOLD).
+func ConstrLockedFundsTx(header byte, amount uint64, fee uint64, txCnt uint32, from, to [32]byte, lockUntil uint64, signer FundsTxSigner, data []byte) (tx *FundsTx, err error) {
 	tx = new(FundsTx)
 
 	tx.Header = header
@@ -36,16 +57,41 @@ func ConstrFundsTx(header byte, amount uint64, fee uint64, txCnt uint32, from, t
 	tx.TxCnt = txCnt
 	tx.Aggregated = false
 	tx.Data = data
+	tx.LockUntil = lockUntil
+
+	return signFundsTx(tx, signer)
+}
+
+//Calls the contract of the receiver with data. On top of the fee, the sender pays gasLimit*gasPrice for the execution
+//(see GasCost), whether or not the contract runs out of gas.
+func ConstrContractCallTx(header byte, amount uint64, fee uint64, gasLimit uint64, gasPrice uint64, txCnt uint32, from, to [32]byte, signer FundsTxSigner, data []byte) (tx *FundsTx, err error) {
+	tx = new(FundsTx)
+
+	tx.Header = header
+	tx.From = from
+	tx.To = to
+	tx.Amount = amount
+	tx.Fee = fee
+	tx.TxCnt = txCnt
+	tx.Aggregated = false
+	tx.Data = data
+	tx.GasLimit = gasLimit
+	tx.GasPrice = gasPrice
+
+	return signFundsTx(tx, signer)
+}
+
+func signFundsTx(tx *FundsTx, signer FundsTxSigner) (*FundsTx, error) {
+	signature, err := signer.SignFundsTx(tx)
+	if err != nil {
+		return nil, err
+	}
 
 	txHash := tx.Hash()
-	fmt.Println(sigKey[32:])
-	signature := ed25519.Sign(sigKey, txHash[:])
-	validation := ed25519.Verify(ed25519.PublicKey(tx.From[:]), txHash[:], signature)
-	fmt.Println(validation)
-	if signature == nil {
-		return tx, nil
+	if !ed25519.Verify(signer.PublicKey(), txHash[:], signature[:]) {
+		return nil, errors.New(fmt.Sprintf("Signature of tx %x is invalid.", txHash[0:8]))
 	}
-	copy(tx.Sig[:], signature[:])
+	tx.Sig = signature
 
 	return tx, nil
 }
@@ -56,6 +102,60 @@ func (tx *FundsTx) Hash() (hash [32]byte) {
 		return [32]byte{}
 	}
 
+	//The gas is only hashed if set, so the hashes of txs not calling a contract do not change.
+	if tx.HasGas() {
+		txHash := struct {
+			Header    byte
+			Amount    uint64
+			Fee       uint64
+			TxCnt     uint32
+			From      [32]byte
+			To        [32]byte
+			Data      []byte
+			LockUntil uint64
+			GasLimit  uint64
+			GasPrice  uint64
+		}{
+			tx.Header,
+			tx.Amount,
+			tx.Fee,
+			tx.TxCnt,
+			tx.From,
+			tx.To,
+			tx.Data,
+			tx.LockUntil,
+			tx.GasLimit,
+			tx.GasPrice,
+		}
+
+		return SerializeHashContent(txHash)
+	}
+
+	//LockUntil is only hashed if set, so the hashes of txs without a lock do not change.
+	if tx.LockUntil > 0 {
+		txHash := struct {
+			Header    byte
+			Amount    uint64
+			Fee       uint64
+			TxCnt     uint32
+			From      [32]byte
+			To        [32]byte
+			Data      []byte
+			LockUntil uint64
+		}{
+			tx.Header,
+			tx.Amount,
+			tx.Fee,
+			tx.TxCnt,
+			tx.From,
+			tx.To,
+			tx.Data,
+			tx.LockUntil,
+		}
+
+		return SerializeHashContent(txHash)
+	}
+
 	txHash := struct {
 		Header byte
 		Amount uint64
@@ -80,32 +180,121 @@ func (tx *FundsTx) Hash() (hash [32]byte) {
 //when we serialize the struct with binary.Write, unexported field get serialized as well, undesired
 //behavior. Therefore, writing own encoder/decoder
 func (tx *FundsTx) Encode() (encodedTx []byte) {
-	// Encode
-	encodeData := FundsTx{
-		Header: tx.Header,
-		Amount: tx.Amount,
-		Fee:    tx.Fee,
-		TxCnt:  tx.TxCnt,
-		From:   tx.From,
-		To:     tx.To,
-		Sig:   	tx.Sig,
-		Data:   tx.Data,
+	enc := newEncoder()
+	enc.uint8(tx.Header)
+	enc.uint64(tx.Amount)
+	enc.uint64(tx.Fee)
+	enc.uint32(tx.TxCnt)
+	enc.array(tx.From[:])
+	enc.array(tx.To[:])
+	enc.array(tx.Sig[:])
+	enc.bytes(tx.Data)
+	//Cosignatures, the lock and the gas are optional, the encoding of txs without them does not change. The
+	//cosignatures (possibly none) precede the lock, which (possibly zero) precedes the gas.
+	if len(tx.Cosigs) > 0 || tx.LockUntil > 0 || tx.HasGas() {
+		enc.uint32(uint32(len(tx.Cosigs)))
+		for _, cosig := range tx.Cosigs {
+			enc.uint8(cosig.Index)
+			enc.array(cosig.Sig[:])
+		}
 	}
-	buffer := new(bytes.Buffer)
-	gob.NewEncoder(buffer).Encode(encodeData)
-	return buffer.Bytes()
+	if tx.LockUntil > 0 || tx.HasGas() {
+		enc.uint64(tx.LockUntil)
+	}
+	if tx.HasGas() {
+		enc.uint64(tx.GasLimit)
+		enc.uint64(tx.GasPrice)
+	}
+
+	return enc.Bytes()
 }
 
-func (*FundsTx) Decode(encodedTx []byte) *FundsTx {
+func (*FundsTx) Decode(encodedTx []byte) (*FundsTx, error) {
 	var decoded FundsTx
-	buffer := bytes.NewBuffer(encodedTx)
-	decoder := gob.NewDecoder(buffer)
-	decoder.Decode(&decoded)
-	return &decoded
+	if !IsBinaryEncoded(encodedTx) {
+		if err := decodeGob(encodedTx, &decoded); err != nil {
+			return nil, err
+		}
+		return &decoded, nil
+	}
+
+	dec := newDecoder(encodedTx)
+	decoded.Header = dec.uint8()
+	decoded.Amount = dec.uint64()
+	decoded.Fee = dec.uint64()
+	decoded.TxCnt = dec.uint32()
+	dec.array(decoded.From[:])
+	dec.array(decoded.To[:])
+	dec.array(decoded.Sig[:])
+	decoded.Data = dec.bytes()
+	if dec.more() {
+		count := dec.uint32()
+		if count > MAX_COSIGNERS {
+			return nil, errors.New(fmt.Sprintf("%v cosignatures out of range.", count))
+		}
+		for i := uint32(0); i < count; i++ {
+			var cosig Cosignature
+			if cosig.Index = dec.uint8(); cosig.Index >= MAX_COSIGNERS {
+				return nil, errors.New(fmt.Sprintf("Cosigner index %v out of range.", cosig.Index))
+			}
+			dec.array(cosig.Sig[:])
+			decoded.Cosigs = append(decoded.Cosigs, cosig)
+		}
+
+		//Only the canonical encoding is accepted, i.e., an empty list of cosignatures must be followed by a lock and
+		//a zero lock by the gas.
+		if dec.more() {
+			decoded.LockUntil = dec.uint64()
+			if dec.more() {
+				decoded.GasLimit = dec.uint64()
+				decoded.GasPrice = dec.uint64()
+				if !decoded.HasGas() {
+					return nil, errors.New("Non-canonical encoding of a fundsTx without gas.")
+				}
+			} else if decoded.LockUntil == 0 {
+				return nil, errors.New("Non-canonical encoding of a fundsTx without lock.")
+			}
+		} else if count == 0 {
+			return nil, errors.New("Non-canonical encoding of a fundsTx without cosignatures.")
+		}
+	}
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
+
+//Returns true if the tx may be included in a block with the given height and timestamp.
+func (tx *FundsTx) IsUnlocked(height uint32, timestamp int64) bool {
+	if tx.LockUntil < LOCK_TIME_THRESHOLD {
+		return uint64(height) >= tx.LockUntil
+	}
+
+	return timestamp >= 0 && uint64(timestamp) >= tx.LockUntil
+}
+
+func (tx *FundsTx) HasGas() bool {
+	return tx.GasLimit > 0 || tx.GasPrice > 0
+}
+
+//The amount charged for executing the receiver's contract, on top of the fee. The VM stops once GasLimit is used up.
+func (tx *FundsTx) GasCost() uint64 {
+	return tx.GasLimit * tx.GasPrice
 }
 
 func (tx *FundsTx) TxFee() uint64 { return tx.Fee }
-func (tx *FundsTx) Size() uint64  { return FUNDSTX_SIZE }
+func (tx *FundsTx) Size() uint64 {
+	size := FUNDSTX_SIZE + uint64(len(tx.Cosigs))*COSIG_SIZE
+	if tx.LockUntil > 0 || tx.HasGas() {
+		size += 8
+	}
+	if tx.HasGas() {
+		size += 16
+	}
+
+	return size
+}
 
 func (tx *FundsTx) Sender() [32]byte { return tx.From }
 func (tx *FundsTx) Receiver() [32]byte { return tx.To }
@@ -119,7 +308,11 @@ func (tx FundsTx) String() string {
 			"From: %x\n"+
 			"To: %x\n"+
 			"Sig: %x\n"+
-			"Data: %v\n",
+			"Data: %v\n"+
+			"Cosigs: %v\n"+
+			"LockUntil: %v\n"+
+			"GasLimit: %v\n"+
+			"GasPrice: %v\n",
 		tx.Header,
 		tx.Amount,
 		tx.Fee,
@@ -128,5 +321,9 @@ func (tx FundsTx) String() string {
 		tx.To[0:8],
 		tx.Sig[0:8],
 		tx.Data,
+		len(tx.Cosigs),
+		tx.LockUntil,
+		tx.GasLimit,
+		tx.GasPrice,
 	)
 }
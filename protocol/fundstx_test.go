@@ -13,10 +13,10 @@ func TestFundsTxSerialization(t *testing.T) {
 	accBHash := SerializeHashContent(accB.Address)
 	loopMax := int(rand.Uint32() % 10000)
 	for i := 0; i < loopMax; i++ {
-		tx, _ := ConstrFundsTx(0x01, rand.Uint64()%100000+1, rand.Uint64()%10+1, uint32(i), accAHash, accBHash, PrivKeyA, PrivKeyA, nil)
+		tx, _ := ConstrFundsTx(0x01, rand.Uint64()%100000+1, rand.Uint64()%10+1, uint32(i), accAHash, accBHash, PrivKeyA, nil)
 		data := tx.Encode()
 		var decodedTx *FundsTx
-		decodedTx = decodedTx.Decode(data)
+		decodedTx, _ = decodedTx.Decode(data)
 
 		//this is done by verify() which is outside protocol package, we're just testing serialization here
 		decodedTx.From = accAHash
@@ -0,0 +1,144 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/sha3"
+)
+
+//Confirmation bundle of an IoT tx, requested by the submitting gateway once the tx is in a block. It is meant to be
+//stored on constrained devices as evidence that their data is anchored in the blockchain, therefore it is encoded in
+//a fixed binary layout instead of gob: the header of the block, the Merkle proof of the tx and a receipt.
+
+const IOTACK_MIN_SIZE = 32*3 + 4 + 8 + 4 + 32*3 + 4 + 8 + MERKLEPROOF_MIN_SIZE
+
+type IotAck struct {
+	//Header of the block containing the tx
+	BlockHash  [32]byte
+	PrevHash   [32]byte
+	MerkleRoot [32]byte
+	Height     uint32
+	Timestamp  int64
+
+	//Number of blocks on top of (and including) the block at the time the bundle was created
+	Confirmations uint32
+
+	//Receipt
+	TxHash   [32]byte
+	Device   [32]byte
+	DataHash [32]byte
+	TxCnt    uint32
+	Sequence uint64
+
+	Proof MerkleProof
+}
+
+func NewIotAck(block *Block, tx *IotTx, confirmations uint32) (*IotAck, error) {
+	return NewPrunedIotAck(block, tx, tx.Hash(), sha3.Sum256(tx.Data), confirmations)
+}
+
+//Creates the bundle of a tx whose data has been pruned, the hashes of the tx and its data are known from the time the
+//data was stored.
+func NewPrunedIotAck(block *Block, tx *IotTx, txHash [32]byte, dataHash [32]byte, confirmations uint32) (*IotAck, error) {
+	proof, err := BuildMerkleTree(block).GetMerkleProof(txHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &IotAck{
+		BlockHash:     block.Hash,
+		PrevHash:      block.PrevHash,
+		MerkleRoot:    block.MerkleRoot,
+		Height:        block.Height,
+		Timestamp:     block.Timestamp,
+		Confirmations: confirmations,
+		TxHash:        txHash,
+		Device:        tx.From,
+		DataHash:      dataHash,
+		TxCnt:         tx.TxCnt,
+		Sequence:      tx.Sequence,
+		Proof:         *proof,
+	}, nil
+}
+
+//Checks that the bundle belongs to the tx and the tx is included in the Merkle root of the block. Whether the block
+//is part of the longest chain needs to be checked against the block headers.
+func (ack *IotAck) Verify(tx *IotTx) bool {
+	return tx.Hash() == ack.TxHash &&
+		tx.From == ack.Device &&
+		tx.TxCnt == ack.TxCnt &&
+		tx.Sequence == ack.Sequence &&
+		sha3.Sum256(tx.Data) == ack.DataHash &&
+		ack.Proof.Verify(ack.TxHash, ack.MerkleRoot)
+}
+
+func (ack *IotAck) Encode() []byte {
+	if ack == nil {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, ack.BlockHash)
+	binary.Write(buf, binary.BigEndian, ack.PrevHash)
+	binary.Write(buf, binary.BigEndian, ack.MerkleRoot)
+	binary.Write(buf, binary.BigEndian, ack.Height)
+	binary.Write(buf, binary.BigEndian, ack.Timestamp)
+	binary.Write(buf, binary.BigEndian, ack.Confirmations)
+	binary.Write(buf, binary.BigEndian, ack.TxHash)
+	binary.Write(buf, binary.BigEndian, ack.Device)
+	binary.Write(buf, binary.BigEndian, ack.DataHash)
+	binary.Write(buf, binary.BigEndian, ack.TxCnt)
+	binary.Write(buf, binary.BigEndian, ack.Sequence)
+
+	return append(buf.Bytes(), ack.Proof.Encode()...)
+}
+
+func (*IotAck) Decode(encoded []byte) (*IotAck, error) {
+	if len(encoded) < IOTACK_MIN_SIZE {
+		return nil, errors.New(fmt.Sprintf("IoT ack too short (%v bytes).", len(encoded)))
+	}
+
+	ack := new(IotAck)
+	buf := bytes.NewReader(encoded)
+	binary.Read(buf, binary.BigEndian, &ack.BlockHash)
+	binary.Read(buf, binary.BigEndian, &ack.PrevHash)
+	binary.Read(buf, binary.BigEndian, &ack.MerkleRoot)
+	binary.Read(buf, binary.BigEndian, &ack.Height)
+	binary.Read(buf, binary.BigEndian, &ack.Timestamp)
+	binary.Read(buf, binary.BigEndian, &ack.Confirmations)
+	binary.Read(buf, binary.BigEndian, &ack.TxHash)
+	binary.Read(buf, binary.BigEndian, &ack.Device)
+	binary.Read(buf, binary.BigEndian, &ack.DataHash)
+	binary.Read(buf, binary.BigEndian, &ack.TxCnt)
+	binary.Read(buf, binary.BigEndian, &ack.Sequence)
+
+	var proof *MerkleProof
+	proof, err := proof.Decode(encoded[len(encoded)-buf.Len():])
+	if err != nil {
+		return nil, err
+	}
+	ack.Proof = *proof
+
+	return ack, nil
+}
+
+func (ack IotAck) String() string {
+	return fmt.Sprintf(
+		"\nBlock Hash: %x\n"+
+			"Height: %v\n"+
+			"Confirmations: %v\n"+
+			"Tx Hash: %x\n"+
+			"Device: %x\n"+
+			"Sequence: %v\n"+
+			"Proof Length: %v\n",
+		ack.BlockHash[0:8],
+		ack.Height,
+		ack.Confirmations,
+		ack.TxHash[0:8],
+		ack.Device[0:8],
+		ack.Sequence,
+		len(ack.Proof.Siblings),
+	)
+}
@@ -0,0 +1,63 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestIotAck(t *testing.T) {
+	for nrTxs := 1; nrTxs <= 11; nrTxs++ {
+		var iotTxs []*IotTx
+		b := new(Block)
+		for i := 0; i < nrTxs; i++ {
+			tx := &IotTx{TxCnt: uint32(i), From: [32]byte{0x01}, Data: []byte{byte(i)}, Sequence: uint64(i + 1)}
+			iotTxs = append(iotTxs, tx)
+			b.IoTTxData = append(b.IoTTxData, tx.Hash())
+		}
+		b.FundsTxData = [][32]byte{{0x02}}
+		b.MerkleRoot = BuildMerkleTree(b).MerkleRoot()
+
+		for _, tx := range iotTxs {
+			ack, err := NewIotAck(b, tx, 1)
+			if err != nil {
+				t.Fatalf("Could not create ack with %v txs: %v\n", nrTxs, err)
+			}
+
+			if !ack.Verify(tx) {
+				t.Errorf("Ack of tx %v with %v txs could not be verified.\n", tx.TxCnt, nrTxs)
+			}
+
+			decoded, _ := ack.Decode(ack.Encode())
+			if !reflect.DeepEqual(ack, decoded) {
+				t.Errorf("IotAck serialization failed (%v) vs. (%v)\n", ack, decoded)
+			}
+		}
+	}
+}
+
+func TestIotAckInvalid(t *testing.T) {
+	tx := &IotTx{From: [32]byte{0x01}, Data: []byte("reading"), Sequence: 1}
+	b := &Block{IoTTxData: [][32]byte{tx.Hash(), {0x02}, {0x03}}}
+	b.MerkleRoot = BuildMerkleTree(b).MerkleRoot()
+
+	ack, _ := NewIotAck(b, tx, 1)
+
+	tampered := *tx
+	tampered.Data = []byte("tampered")
+	if ack.Verify(&tampered) {
+		t.Error("Ack verified for a different tx.\n")
+	}
+
+	ack.Proof.Siblings[0][0] ^= 0xff
+	if ack.Verify(tx) {
+		t.Error("Ack with invalid proof verified.\n")
+	}
+
+	if _, err := NewIotAck(b, &IotTx{Sequence: 2}, 1); err == nil {
+		t.Error("Ack created for a tx not in the block.\n")
+	}
+
+	if _, err := ack.Decode(ack.Encode()[:IOTACK_MIN_SIZE]); err == nil {
+		t.Error("Truncated ack decoded.\n")
+	}
+}
@@ -0,0 +1,89 @@
+package protocol
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+//Compact encoding of IotTxs for constrained devices submitting txs over CoAP, where a tx should fit into a single
+//datagram. The counters and the fee are small in practice and written as unsigned varints, the data is not length
+//prefixed but takes the rest of the message:
+//
+//	version (1) | header (1) | txCnt (varint) | from (32) | to (32) | sig (64) | fee (varint) | sequence (varint) | data
+//
+//The encoding only changes how the tx is transmitted, the signature covers the same hash as for any other IotTx.
+
+const (
+	IOTTX_COMPACT_VERSION  = 1
+	IOTTX_COMPACT_MIN_SIZE = 1 + 1 + 1 + 32 + 32 + 64 + 1 + 1
+)
+
+func (tx *IotTx) EncodeCompact() []byte {
+	encoded := make([]byte, 0, IOTTX_COMPACT_MIN_SIZE+2*binary.MaxVarintLen64+len(tx.Data))
+	encoded = append(encoded, IOTTX_COMPACT_VERSION, tx.Header)
+	encoded = appendUvarint(encoded, uint64(tx.TxCnt))
+	encoded = append(encoded, tx.From[:]...)
+	encoded = append(encoded, tx.To[:]...)
+	encoded = append(encoded, tx.Sig[:]...)
+	encoded = appendUvarint(encoded, tx.Fee)
+	encoded = appendUvarint(encoded, tx.Sequence)
+
+	return append(encoded, tx.Data...)
+}
+
+func DecodeCompactIotTx(encoded []byte) (*IotTx, error) {
+	if len(encoded) < IOTTX_COMPACT_MIN_SIZE {
+		return nil, errors.New(fmt.Sprintf("Compact IoT tx too short (%v bytes).", len(encoded)))
+	}
+
+	if encoded[0] != IOTTX_COMPACT_VERSION {
+		return nil, errors.New(fmt.Sprintf("Unsupported compact IoT tx version %v.", encoded[0]))
+	}
+
+	tx := new(IotTx)
+	tx.Header = encoded[1]
+	rest := encoded[2:]
+
+	txCnt, rest, err := readUvarint(rest)
+	if err != nil || txCnt > uint64(^uint32(0)) {
+		return nil, errors.New("Invalid txCnt in compact IoT tx.")
+	}
+	tx.TxCnt = uint32(txCnt)
+
+	if len(rest) < 32+32+64 {
+		return nil, errors.New("Compact IoT tx too short.")
+	}
+	copy(tx.From[:], rest[0:32])
+	copy(tx.To[:], rest[32:64])
+	copy(tx.Sig[:], rest[64:128])
+	rest = rest[128:]
+
+	if tx.Fee, rest, err = readUvarint(rest); err != nil {
+		return nil, errors.New("Invalid fee in compact IoT tx.")
+	}
+	if tx.Sequence, rest, err = readUvarint(rest); err != nil {
+		return nil, errors.New("Invalid sequence in compact IoT tx.")
+	}
+
+	if len(rest) > MAX_IOT_DATA_SIZE {
+		return nil, errors.New(fmt.Sprintf("IoT data size (%v) exceeds the maximum (%v).", len(rest), MAX_IOT_DATA_SIZE))
+	}
+	tx.Data = append([]byte{}, rest...)
+
+	return tx, nil
+}
+
+func appendUvarint(encoded []byte, value uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	return append(encoded, buf[:binary.PutUvarint(buf[:], value)]...)
+}
+
+func readUvarint(encoded []byte) (value uint64, rest []byte, err error) {
+	value, n := binary.Uvarint(encoded)
+	if n <= 0 {
+		return 0, nil, errors.New("Invalid varint.")
+	}
+
+	return value, encoded[n:], nil
+}
@@ -0,0 +1,60 @@
+package protocol
+
+import (
+	"bytes"
+	"crypto/rand"
+	"reflect"
+	"testing"
+
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestIotCompactEncoding(t *testing.T) {
+	_, privKey, _ := ed25519.GenerateKey(rand.Reader)
+	tx, _ := ConstrIotTx(IOTTX_COMPRESSED, 2, 300, [32]byte{1}, [32]byte{2}, privKey, []byte("21.5"), 1234567)
+
+	encoded := tx.EncodeCompact()
+	if len(encoded) >= len(tx.Encode()) {
+		t.Errorf("Compact encoding (%v bytes) should be smaller than the regular encoding (%v bytes).\n", len(encoded), len(tx.Encode()))
+	}
+
+	decoded, err := DecodeCompactIotTx(encoded)
+	if err != nil {
+		t.Fatalf("Compact IotTx could not be decoded: %v\n", err)
+	}
+	if !reflect.DeepEqual(tx, decoded) || decoded.Hash() != tx.Hash() {
+		t.Errorf("Decoded IotTx does not match:\n%v\n%v\n", tx, decoded)
+	}
+
+	empty := &IotTx{}
+	if decoded, err := DecodeCompactIotTx(empty.EncodeCompact()); err != nil || len(decoded.Data) != 0 {
+		t.Errorf("IotTx without data could not be decoded: %v\n", err)
+	}
+}
+
+func TestIotCompactEncodingInvalid(t *testing.T) {
+	tx := &IotTx{TxCnt: 1, Data: []byte("21.5")}
+	encoded := tx.EncodeCompact()
+
+	if _, err := DecodeCompactIotTx(encoded[:IOTTX_COMPACT_MIN_SIZE-1]); err == nil {
+		t.Error("Truncated compact IotTx decoded.")
+	}
+
+	version := append([]byte{}, encoded...)
+	version[0] = IOTTX_COMPACT_VERSION + 1
+	if _, err := DecodeCompactIotTx(version); err == nil {
+		t.Error("Compact IotTx of an unknown version decoded.")
+	}
+
+	//A txCnt exceeding 32 bits.
+	txCnt := append([]byte{IOTTX_COMPACT_VERSION, 0}, appendUvarint(nil, 1<<32)...)
+	txCnt = append(txCnt, bytes.Repeat([]byte{0}, 130)...)
+	if _, err := DecodeCompactIotTx(txCnt); err == nil {
+		t.Error("Compact IotTx with an invalid txCnt decoded.")
+	}
+
+	oversized := &IotTx{Data: make([]byte, MAX_IOT_DATA_SIZE+1)}
+	if _, err := DecodeCompactIotTx(oversized.EncodeCompact()); err == nil {
+		t.Error("Compact IotTx exceeding the data size limit decoded.")
+	}
+}
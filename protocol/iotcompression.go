@@ -0,0 +1,77 @@
+package protocol
+
+import (
+	"bytes"
+	"compress/flate"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+//Sensor readings are often repetitive (e.g., JSON with the same keys in every reading) and compress well. Devices can
+//send the data of an IotTx deflate compressed and signal it with the IOTTX_COMPRESSED header bit. The compressed data
+//is what is signed, stored in blocks and broadcast, validators only check that it decompresses to at most
+//MAX_IOT_DATA_SIZE bytes. Without the limit, a tiny tx could decompress to gigabytes on every node reading it.
+
+const (
+	IOTTX_COMPRESSED = 0x01
+
+	MAX_IOT_DATA_SIZE = 64 * 1024
+)
+
+//Compresses the data for an IotTx, the header of the tx needs the IOTTX_COMPRESSED bit set.
+func CompressIotData(data []byte) ([]byte, error) {
+	if len(data) > MAX_IOT_DATA_SIZE {
+		return nil, errors.New(fmt.Sprintf("IoT data size (%v) exceeds the maximum (%v).", len(data), MAX_IOT_DATA_SIZE))
+	}
+
+	buffer := new(bytes.Buffer)
+	writer, err := flate.NewWriter(buffer, flate.BestCompression)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	return buffer.Bytes(), nil
+}
+
+func (tx *IotTx) IsCompressed() bool {
+	return tx.Header&IOTTX_COMPRESSED != 0
+}
+
+//Returns the data of the tx, decompressed if the tx is compressed. Encrypted data is returned as is, it can only be
+//decompressed after decrypting it (see DecryptPayload).
+func (tx *IotTx) Payload() ([]byte, error) {
+	if tx.IsEncrypted() {
+		return tx.encryptedPayload()
+	}
+
+	if !tx.IsCompressed() {
+		return tx.Data, nil
+	}
+
+	return decompressIotData(tx.Data)
+}
+
+func decompressIotData(compressed []byte) ([]byte, error) {
+	reader := flate.NewReader(bytes.NewReader(compressed))
+	defer reader.Close()
+
+	//Reading one byte more than allowed detects oversized data without decompressing all of it.
+	data, err := ioutil.ReadAll(io.LimitReader(reader, MAX_IOT_DATA_SIZE+1))
+	if err != nil {
+		return nil, errors.New(fmt.Sprintf("IoT data could not be decompressed: %v", err))
+	}
+
+	if len(data) > MAX_IOT_DATA_SIZE {
+		return nil, errors.New(fmt.Sprintf("Decompressed IoT data exceeds the maximum size (%v).", MAX_IOT_DATA_SIZE))
+	}
+
+	return data, nil
+}
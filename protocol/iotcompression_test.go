@@ -0,0 +1,62 @@
+package protocol
+
+import (
+	"bytes"
+	"compress/flate"
+	"testing"
+)
+
+func TestIotCompression(t *testing.T) {
+	reading := bytes.Repeat([]byte(`{"sensor":"temperature","value":21.5}`), 100)
+	compressed, err := CompressIotData(reading)
+	if err != nil {
+		t.Fatalf("Compressing IoT data failed: %v\n", err)
+	}
+
+	if len(compressed) >= len(reading) {
+		t.Errorf("Compressed data (%v bytes) should be smaller than the reading (%v bytes).\n", len(compressed), len(reading))
+	}
+
+	tx := &IotTx{Header: IOTTX_COMPRESSED, Data: compressed}
+	if payload, err := tx.Payload(); err != nil || !bytes.Equal(payload, reading) {
+		t.Errorf("Decompressed payload does not match the reading: %v\n", err)
+	}
+
+	//The compressed data is part of the encoding, the receiver decompresses it.
+	var decoded *IotTx
+	if decoded, _ = decoded.Decode(tx.Encode()); decoded == nil || !decoded.IsCompressed() {
+		t.Fatal("Compressed IotTx could not be decoded.")
+	}
+
+	uncompressed := &IotTx{Data: reading}
+	if payload, err := uncompressed.Payload(); err != nil || !bytes.Equal(payload, reading) {
+		t.Errorf("Payload of an uncompressed tx should be its data: %v\n", err)
+	}
+}
+
+func TestIotCompressionLimit(t *testing.T) {
+	if _, err := CompressIotData(make([]byte, MAX_IOT_DATA_SIZE+1)); err == nil {
+		t.Error("Data exceeding the maximum size should not be compressed.")
+	}
+
+	bomb := &IotTx{Header: IOTTX_COMPRESSED}
+	bomb.Data, _ = CompressIotData(make([]byte, MAX_IOT_DATA_SIZE))
+	if _, err := bomb.Payload(); err != nil {
+		t.Errorf("Data of the maximum size should be decompressed: %v\n", err)
+	}
+
+	//Compressed without CompressIotData, e.g., by a malicious device.
+	var buffer bytes.Buffer
+	writer, _ := flate.NewWriter(&buffer, flate.BestCompression)
+	writer.Write(make([]byte, 10*MAX_IOT_DATA_SIZE))
+	writer.Close()
+	bomb.Data = buffer.Bytes()
+	if _, err := bomb.Payload(); err == nil {
+		t.Error("Data decompressing beyond the maximum size should be rejected.")
+	}
+
+	bomb.Data = []byte("not deflate")
+	if _, err := bomb.Payload(); err == nil {
+		t.Error("Invalid compressed data should be rejected.")
+	}
+}
@@ -0,0 +1,70 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"golang.org/x/crypto/ed25519"
+)
+
+//The data of an IotTx with the IOTTX_ENCRYPTED header bit is encrypted to the receiver of the tx (see
+//crypto.EncryptIotData). Validators can not read it, they only check the signature and that the size of the encrypted
+//data is within MAX_IOT_DATA_SIZE plus the encryption overhead. If the tx is also compressed, the data was compressed
+//before it was encrypted, the decompressed size is checked by the receiver when decrypting.
+
+const IOTTX_ENCRYPTED = 0x02
+
+//Encrypts (and compresses, if requested) the data for an IotTx to the receiver, the header of the tx needs the
+//IOTTX_ENCRYPTED bit set, and IOTTX_COMPRESSED if compressed.
+func EncryptIotData(receiver [32]byte, data []byte, compress bool) (encrypted []byte, err error) {
+	if len(data) > MAX_IOT_DATA_SIZE {
+		return nil, errors.New(fmt.Sprintf("IoT data size (%v) exceeds the maximum (%v).", len(data), MAX_IOT_DATA_SIZE))
+	}
+
+	if compress {
+		if data, err = CompressIotData(data); err != nil {
+			return nil, err
+		}
+	}
+
+	return crypto.EncryptIotData(crypto.GetPubKeyFromAddressED(receiver), data)
+}
+
+func (tx *IotTx) IsEncrypted() bool {
+	return tx.Header&IOTTX_ENCRYPTED != 0
+}
+
+func (tx *IotTx) encryptedPayload() ([]byte, error) {
+	if len(tx.Data) < crypto.IOT_ENCRYPTION_OVERHEAD {
+		return nil, errors.New(fmt.Sprintf("Encrypted IoT data (%v bytes) is shorter than the encryption overhead (%v).", len(tx.Data), crypto.IOT_ENCRYPTION_OVERHEAD))
+	}
+
+	if len(tx.Data) > MAX_IOT_DATA_SIZE+crypto.IOT_ENCRYPTION_OVERHEAD {
+		return nil, errors.New(fmt.Sprintf("Encrypted IoT data exceeds the maximum size (%v).", MAX_IOT_DATA_SIZE+crypto.IOT_ENCRYPTION_OVERHEAD))
+	}
+
+	return tx.Data, nil
+}
+
+//Returns the data of an encrypted tx with the receiver's wallet key, decompressed if the tx is compressed.
+func (tx *IotTx) DecryptPayload(privKey ed25519.PrivateKey) ([]byte, error) {
+	if !tx.IsEncrypted() {
+		return nil, errors.New("IoT data is not encrypted.")
+	}
+
+	if _, err := tx.encryptedPayload(); err != nil {
+		return nil, err
+	}
+
+	data, err := crypto.DecryptIotData(privKey, tx.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !tx.IsCompressed() {
+		return data, nil
+	}
+
+	return decompressIotData(data)
+}
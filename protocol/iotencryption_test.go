@@ -0,0 +1,70 @@
+package protocol
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"golang.org/x/crypto/ed25519"
+)
+
+func TestIotEncryption(t *testing.T) {
+	receiverPub, receiverPriv, _ := ed25519.GenerateKey(rand.Reader)
+	senderPub, senderPriv, _ := ed25519.GenerateKey(rand.Reader)
+	receiver := crypto.GetAddressFromPubKeyED(receiverPub)
+
+	reading := bytes.Repeat([]byte(`{"sensor":"temperature","value":21.5}`), 10)
+	for _, compress := range []bool{false, true} {
+		var header byte = IOTTX_ENCRYPTED
+		if compress {
+			header |= IOTTX_COMPRESSED
+		}
+
+		data, err := EncryptIotData(receiver, reading, compress)
+		if err != nil {
+			t.Fatalf("Encrypting IoT data failed: %v\n", err)
+		}
+
+		tx, _ := ConstrIotTx(header, 1, 0, crypto.GetAddressFromPubKeyED(senderPub), receiver, senderPriv, data, 0)
+		txHash := tx.Hash()
+		if !ed25519.Verify(senderPub, txHash[:], tx.Sig[:]) {
+			t.Error("Signature of the encrypted tx could not be verified.")
+		}
+
+		//Validators only see the encrypted data.
+		if payload, err := tx.Payload(); err != nil || !bytes.Equal(payload, data) {
+			t.Errorf("Payload of an encrypted tx should be the encrypted data: %v\n", err)
+		}
+
+		if payload, err := tx.DecryptPayload(receiverPriv); err != nil || !bytes.Equal(payload, reading) {
+			t.Errorf("Decrypted payload (compressed: %v) does not match the reading: %v\n", compress, err)
+		}
+
+		if _, err := tx.DecryptPayload(senderPriv); err == nil {
+			t.Error("Payload was decrypted with the sender's key.")
+		}
+	}
+
+	plain := &IotTx{Data: reading}
+	if _, err := plain.DecryptPayload(receiverPriv); err == nil {
+		t.Error("Unencrypted payload should not be decrypted.")
+	}
+}
+
+func TestIotEncryptionLimit(t *testing.T) {
+	tx := &IotTx{Header: IOTTX_ENCRYPTED, Data: make([]byte, crypto.IOT_ENCRYPTION_OVERHEAD-1)}
+	if _, err := tx.Payload(); err == nil {
+		t.Error("Encrypted data shorter than the overhead should be rejected.")
+	}
+
+	tx.Data = make([]byte, MAX_IOT_DATA_SIZE+crypto.IOT_ENCRYPTION_OVERHEAD)
+	if _, err := tx.Payload(); err != nil {
+		t.Errorf("Encrypted data of the maximum size should be accepted: %v\n", err)
+	}
+
+	tx.Data = append(tx.Data, 0)
+	if _, err := tx.Payload(); err == nil {
+		t.Error("Encrypted data exceeding the maximum size should be rejected.")
+	}
+}
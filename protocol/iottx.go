@@ -2,7 +2,6 @@ package protocol
 
 import (
 	"bytes"
-	"encoding/gob"
 	"fmt"
 	"golang.org/x/crypto/ed25519"
 	"unsafe"
@@ -21,17 +20,21 @@ type IotTx struct {
 	Sig    [64]byte
 	Data   []byte
 	Fee    		uint64
+	//Device-supplied sequence number (e.g., a counter or unix timestamp) of the reading, used to detect retransmitted
+	//readings. 0 means the device does not provide one.
+	Sequence	uint64
 
 }
 
-func ConstrIotTx(header byte, fee uint64, txCnt uint32, from, to [32]byte, sigKey ed25519.PrivateKey, data []byte) (tx *IotTx, err error) {
+func ConstrIotTx(header byte, fee uint64, txCnt uint32, from, to [32]byte, sigKey ed25519.PrivateKey, data []byte, sequence uint64) (tx *IotTx, err error) {
 	tx = new(IotTx)
 	tx.Header = header
-	tx.Fee = tx.TxFee()
+	tx.Fee = fee
 	tx.From = from
 	tx.To = to
 	tx.TxCnt = txCnt
 	tx.Data = data
+	tx.Sequence = sequence
 	txHash := tx.Hash()
 
 	signature := ed25519.Sign(sigKey, txHash[:])
@@ -57,6 +60,10 @@ func (tx *IotTx) Hash() (hash [32]byte) {
 	binary.Write(buf, binary.BigEndian, tx.TxFee());
 	binary.Write(buf, binary.BigEndian, tx.Header);
 	binary.Write(buf, binary.BigEndian, tx.Data);
+	//Only part of the hash if set, so signatures of txs without a sequence stay valid
+	if tx.Sequence != 0 {
+		binary.Write(buf, binary.BigEndian, tx.Sequence);
+	}
 
 	return sha3.Sum256(buf.Bytes())
 }
@@ -69,28 +76,42 @@ func (tx *IotTx) Encode() (encodedTx []byte) {
 		return nil
 	}
 
-	// Encode
-	encodeData := IotTx{
-		tx.Header,
-		tx.TxCnt,
-		tx.From,
-		tx.To,
-		tx.Sig,
-		tx.Data,
-		tx.Fee,
-	}
-	buffer := new(bytes.Buffer)
-	gob.NewEncoder(buffer).Encode(encodeData)
-
-	return buffer.Bytes()
+	enc := newEncoder()
+	enc.uint8(tx.Header)
+	enc.uint32(tx.TxCnt)
+	enc.array(tx.From[:])
+	enc.array(tx.To[:])
+	enc.array(tx.Sig[:])
+	enc.bytes(tx.Data)
+	enc.uint64(tx.Fee)
+	enc.uint64(tx.Sequence)
+
+	return enc.Bytes()
 }
 
-func (*IotTx) Decode(encodedTx []byte) *IotTx {
+func (*IotTx) Decode(encodedTx []byte) (*IotTx, error) {
 	var decoded IotTx
-	buffer := bytes.NewBuffer(encodedTx)
-	decoder := gob.NewDecoder(buffer)
-	decoder.Decode(&decoded)
-	return &decoded
+	if !IsBinaryEncoded(encodedTx) {
+		if err := decodeGob(encodedTx, &decoded); err != nil {
+			return nil, err
+		}
+		return &decoded, nil
+	}
+
+	dec := newDecoder(encodedTx)
+	decoded.Header = dec.uint8()
+	decoded.TxCnt = dec.uint32()
+	dec.array(decoded.From[:])
+	dec.array(decoded.To[:])
+	dec.array(decoded.Sig[:])
+	decoded.Data = dec.bytes()
+	decoded.Fee = dec.uint64()
+	decoded.Sequence = dec.uint64()
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
 }
 
 
@@ -102,7 +123,8 @@ func (tx IotTx) String() string {
 			"To: %x\n"+
 			"Sig: %x\n"+
 			"Data: %v\n"+
-		"Fee: %v\n",
+		"Fee: %v\n"+
+		"Sequence: %v\n",
 
 		tx.Header,
 		tx.TxCnt,
@@ -111,6 +133,7 @@ func (tx IotTx) String() string {
 		tx.Sig[0:8],
 		tx.Data,
 		tx.Fee,
+		tx.Sequence,
 	)
 }
 
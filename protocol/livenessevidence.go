@@ -0,0 +1,60 @@
+package protocol
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+)
+
+//Liveness evidence records the validators of an epoch which did not produce a single block although their share of the
+//stake let them expect to produce several. It is derived from the blocks of the epoch by every node, validators missing
+//their blocks in consecutive epochs are left out of the validator set of the next epoch (see miner/liveness.go).
+
+type LivenessEvidence struct {
+	Height uint32     //Last block of the epoch
+	Missed [][32]byte //Sorted addresses of the validators which missed their blocks
+}
+
+func NewLivenessEvidence(height uint32, missed [][32]byte) *LivenessEvidence {
+	sort.Slice(missed, func(i, j int) bool {
+		return bytes.Compare(missed[i][:], missed[j][:]) < 0
+	})
+
+	return &LivenessEvidence{height, missed}
+}
+
+func (evidence *LivenessEvidence) Contains(address [32]byte) bool {
+	i := sort.Search(len(evidence.Missed), func(i int) bool {
+		return bytes.Compare(evidence.Missed[i][:], address[:]) >= 0
+	})
+
+	return i < len(evidence.Missed) && evidence.Missed[i] == address
+}
+
+func (evidence *LivenessEvidence) Encode() []byte {
+	if evidence == nil {
+		return nil
+	}
+
+	enc := newEncoder()
+	enc.uint32(evidence.Height)
+	enc.hashes(evidence.Missed)
+
+	return enc.Bytes()
+}
+
+func (*LivenessEvidence) Decode(encoded []byte) (*LivenessEvidence, error) {
+	var decoded LivenessEvidence
+	dec := newDecoder(encoded)
+	decoded.Height = dec.uint32()
+	decoded.Missed = dec.hashes()
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
+
+func (evidence LivenessEvidence) String() string {
+	return fmt.Sprintf("Height: %v, Missed: %v", evidence.Height, len(evidence.Missed))
+}
@@ -0,0 +1,27 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLivenessEvidence(t *testing.T) {
+	evidence := NewLivenessEvidence(200, [][32]byte{{0x03}, {0x01}})
+
+	if !evidence.Contains([32]byte{0x01}) || !evidence.Contains([32]byte{0x03}) {
+		t.Errorf("Missed validators not found: %v\n", evidence.Missed)
+	}
+	if evidence.Contains([32]byte{0x02}) {
+		t.Error("Validator which did not miss its blocks was found.")
+	}
+
+	var decoded *LivenessEvidence
+	if decoded, _ = decoded.Decode(evidence.Encode()); !reflect.DeepEqual(evidence, decoded) {
+		t.Errorf("LivenessEvidence serialization failed (%v) vs. (%v)\n", evidence, decoded)
+	}
+
+	encoded := evidence.Encode()
+	if _, err := decoded.Decode(encoded[:len(encoded)-1]); err == nil {
+		t.Error("Truncated liveness evidence was decoded.")
+	}
+}
@@ -2,6 +2,7 @@ package protocol
 
 import (
 	"bytes"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"golang.org/x/crypto/sha3"
@@ -77,6 +78,11 @@ func BuildMerkleTree(b *Block) *MerkleTree {
 			txHashes = append(txHashes, txHash)
 		}
 	}
+	if b.ContractTxData != nil {
+		for _, txHash := range b.ContractTxData {
+			txHashes = append(txHashes, txHash)
+		}
+	}
 
 	//Merkle root for no transactions is 0 hash
 	if len(txHashes) == 0 {
@@ -184,6 +190,94 @@ func (m *MerkleTree) VerifyTree() bool {
 	return false
 }
 
+//Sibling hashes on the path from a leaf to the Merkle root, allowing light clients to verify the inclusion of a tx
+//against the Merkle root of a block header. Bit i of Path is set if Siblings[i] is the left child.
+type MerkleProof struct {
+	Path     uint32
+	Siblings [][32]byte
+}
+
+const MERKLEPROOF_MIN_SIZE = 4 + 1
+
+func (m *MerkleTree) GetMerkleProof(txHash [32]byte) (*MerkleProof, error) {
+	if m == nil {
+		return nil, errors.New("Cannot build Merkle proof of an empty tree.")
+	}
+
+	leaf := GetLeaf(m, txHash)
+	if leaf == nil {
+		return nil, errors.New(fmt.Sprintf("Tx %x not in the Merkle tree.", txHash[0:8]))
+	}
+
+	proof := new(MerkleProof)
+	for node := leaf; node.Parent != nil; node = node.Parent {
+		if len(proof.Siblings) == 32 {
+			return nil, errors.New("Merkle tree too deep.")
+		}
+
+		if node.Parent.Left.Hash == node.Hash {
+			proof.Siblings = append(proof.Siblings, node.Parent.Right.Hash)
+		} else {
+			proof.Path |= 1 << uint(len(proof.Siblings))
+			proof.Siblings = append(proof.Siblings, node.Parent.Left.Hash)
+		}
+	}
+
+	return proof, nil
+}
+
+//Returns the Merkle root resulting from the leaf and the proof.
+func (proof *MerkleProof) Root(leafHash [32]byte) [32]byte {
+	hash := leafHash
+	for i, sibling := range proof.Siblings {
+		if proof.Path&(1<<uint(i)) != 0 {
+			hash = sha3.Sum256(append(sibling[:], hash[:]...))
+		} else {
+			hash = sha3.Sum256(append(hash[:], sibling[:]...))
+		}
+	}
+
+	return hash
+}
+
+func (proof *MerkleProof) Verify(txHash [32]byte, merkleRoot [32]byte) bool {
+	return proof.Root(txHash) == merkleRoot
+}
+
+//Path, number of siblings (one byte) and the siblings.
+func (proof *MerkleProof) Encode() []byte {
+	if proof == nil {
+		return nil
+	}
+
+	buf := new(bytes.Buffer)
+	binary.Write(buf, binary.BigEndian, proof.Path)
+	binary.Write(buf, binary.BigEndian, uint8(len(proof.Siblings)))
+	for _, sibling := range proof.Siblings {
+		binary.Write(buf, binary.BigEndian, sibling)
+	}
+
+	return buf.Bytes()
+}
+
+func (*MerkleProof) Decode(encoded []byte) (*MerkleProof, error) {
+	if len(encoded) < MERKLEPROOF_MIN_SIZE || len(encoded) != MERKLEPROOF_MIN_SIZE+int(encoded[4])*32 {
+		return nil, errors.New(fmt.Sprintf("Invalid merkle proof length (%v bytes).", len(encoded)))
+	}
+
+	proof := new(MerkleProof)
+	buf := bytes.NewReader(encoded)
+	binary.Read(buf, binary.BigEndian, &proof.Path)
+	buf.ReadByte()
+
+	proof.Siblings = make([][32]byte, encoded[4])
+	for i := range proof.Siblings {
+		binary.Read(buf, binary.BigEndian, &proof.Siblings[i])
+	}
+
+	return proof, nil
+}
+
 func GetLeaf(merkleTree *MerkleTree, leafHash [32]byte) *Node {
 	for _, leaf := range merkleTree.Leafs {
 		if leafHash == leaf.Hash {
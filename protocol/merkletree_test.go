@@ -1,11 +1,10 @@
 package protocol
 
 import (
-	"crypto/ecdsa"
-	"crypto/elliptic"
 	"crypto/rand"
 	"testing"
 
+	"golang.org/x/crypto/ed25519"
 	"golang.org/x/crypto/sha3"
 )
 
@@ -15,10 +14,10 @@ func TestBuildMerkleTree3N(t *testing.T) {
 	var tx *FundsTx
 
 	//Generating a private key and prepare data
-	privA, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privA, _ := ed25519.GenerateKey(rand.Reader)
 
 	for i := 0; i < 3; i++ {
-		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, privA, nil)
+		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, nil)
 		hashSlice = append(hashSlice, tx.Hash())
 	}
 
@@ -47,10 +46,10 @@ func TestBuildMerkleTree2N(t *testing.T) {
 	var tx *FundsTx
 
 	//Generating a private key and prepare data
-	privA, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privA, _ := ed25519.GenerateKey(rand.Reader)
 
 	for i := 0; i < 2; i++ {
-		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, privA, nil)
+		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, nil)
 		hashSlice = append(hashSlice, tx.Hash())
 	}
 
@@ -74,10 +73,10 @@ func TestBuildMerkleTree4N(t *testing.T) {
 	var tx *FundsTx
 
 	//Generating a private key and prepare data
-	privA, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privA, _ := ed25519.GenerateKey(rand.Reader)
 
 	for i := 0; i < 4; i++ {
-		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, privA, nil)
+		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, nil)
 		hashSlice = append(hashSlice, tx.Hash())
 	}
 
@@ -109,10 +108,10 @@ func TestBuildMerkleTree6N(t *testing.T) {
 	var tx *FundsTx
 
 	//Generating a private key and prepare data
-	privA, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privA, _ := ed25519.GenerateKey(rand.Reader)
 
 	for i := 0; i < 6; i++ {
-		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, privA, nil)
+		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, nil)
 		hashSlice = append(hashSlice, tx.Hash())
 	}
 
@@ -152,10 +151,10 @@ func TestBuildMerkleTree8N(t *testing.T) {
 	var tx *FundsTx
 
 	//Generating a private key and prepare data
-	privA, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privA, _ := ed25519.GenerateKey(rand.Reader)
 
 	for i := 0; i < 8; i++ {
-		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, privA, nil)
+		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, nil)
 		hashSlice = append(hashSlice, tx.Hash())
 	}
 
@@ -201,10 +200,10 @@ func TestBuildMerkleTree10N(t *testing.T) {
 	var tx *FundsTx
 
 	//Generating a private key and prepare data
-	privA, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privA, _ := ed25519.GenerateKey(rand.Reader)
 
 	for i := 0; i < 10; i++ {
-		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, privA, nil)
+		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, nil)
 		hashSlice = append(hashSlice, tx.Hash())
 	}
 
@@ -258,10 +257,10 @@ func TestBuildMerkleTree11N(t *testing.T) {
 	var tx *FundsTx
 
 	//Generating a private key and prepare data
-	privA, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privA, _ := ed25519.GenerateKey(rand.Reader)
 
 	for i := 0; i < 11; i++ {
-		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, privA, nil)
+		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, nil)
 		hashSlice = append(hashSlice, tx.Hash())
 	}
 
@@ -321,10 +320,10 @@ func TestGetIntermediate(t *testing.T) {
 	var tx *FundsTx
 
 	//Generating a private key and prepare data
-	privA, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, privA, _ := ed25519.GenerateKey(rand.Reader)
 
 	for i := 0; i < 11; i++ {
-		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, privA, nil)
+		tx, _ = ConstrFundsTx(0, 10, 1, uint32(i), [32]byte{'1'}, [32]byte{'2'}, privA, nil)
 		hashSlice = append(hashSlice, tx.Hash())
 	}
 
@@ -392,3 +391,44 @@ func TestGetIntermediate(t *testing.T) {
 		t.Errorf("Hashes don't match: %x != %x\n", intermediates[4].Hash, hash12345678)
 	}
 }
+
+func TestGetMerkleProof(t *testing.T) {
+	for nrTxs := 1; nrTxs <= 17; nrTxs++ {
+		var hashSlice [][32]byte
+		for i := 0; i < nrTxs; i++ {
+			hashSlice = append(hashSlice, sha3.Sum256([]byte{byte(i)}))
+		}
+
+		merkleTree := BuildMerkleTree(&Block{FundsTxData: hashSlice})
+
+		for _, txHash := range hashSlice {
+			proof, err := merkleTree.GetMerkleProof(txHash)
+			if err != nil {
+				t.Fatalf("Could not get Merkle proof with %v txs: %v\n", nrTxs, err)
+			}
+
+			if !proof.Verify(txHash, merkleTree.MerkleRoot()) {
+				t.Errorf("Merkle proof of %x with %v txs could not be verified.\n", txHash[0:8], nrTxs)
+			}
+
+			var decoded *MerkleProof
+			if decoded, _ = decoded.Decode(proof.Encode()); decoded == nil || !decoded.Verify(txHash, merkleTree.MerkleRoot()) {
+				t.Errorf("Merkle proof serialization failed with %v txs.\n", nrTxs)
+			}
+
+			if proof.Verify(sha3.Sum256([]byte("unknown")), merkleTree.MerkleRoot()) {
+				t.Errorf("Merkle proof verified for a different tx.\n")
+			}
+		}
+	}
+
+	merkleTree := BuildMerkleTree(&Block{FundsTxData: [][32]byte{{1}, {2}}})
+	if _, err := merkleTree.GetMerkleProof([32]byte{3}); err == nil {
+		t.Errorf("Merkle proof returned for a tx not in the tree.\n")
+	}
+
+	var emptyTree *MerkleTree
+	if _, err := emptyTree.GetMerkleProof([32]byte{1}); err == nil {
+		t.Errorf("Merkle proof returned for an empty tree.\n")
+	}
+}
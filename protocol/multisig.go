@@ -0,0 +1,91 @@
+package protocol
+
+import (
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/ed25519"
+)
+
+//Multi-signature accounts are controlled by a set of n cosigners (ed25519 public keys), m of which have to sign a
+//fundsTx spending from the account. They are created with an accTx carrying the threshold m and the cosigners, the
+//account's address is derived from both (see MultiSigAddress), so there is no private key for it. FundsTxs from such
+//an account carry the signatures of the cosigners in Cosigs instead of Sig, each one referencing the cosigner by its
+//index in the account's cosigner set. Like Sig, the cosignatures are not covered by the tx hash.
+
+const (
+	MAX_COSIGNERS = 16
+	COSIG_SIZE    = 65
+)
+
+type Cosignature struct {
+	Index uint8
+	Sig   [64]byte
+}
+
+//The address of the multi-signature account with the given threshold and cosigners.
+func MultiSigAddress(threshold uint8, cosigners [][32]byte) [32]byte {
+	address := struct {
+		Threshold uint8
+		Cosigners [][32]byte
+	}{
+		threshold,
+		cosigners,
+	}
+
+	return SerializeHashContent(address)
+}
+
+//Decoded thresholds and cosigner sets are rejected if out of range, whether they are valid is up to the verification.
+func checkMultisigRange(threshold uint8, cosigners [][32]byte) error {
+	if int(threshold) > len(cosigners) || len(cosigners) > MAX_COSIGNERS {
+		return errors.New(fmt.Sprintf("Threshold %v for %v cosigners out of range.", threshold, len(cosigners)))
+	}
+
+	return nil
+}
+
+func ConstrMultiSigAccTx(header byte, fee uint64, amount uint64, threshold uint8, cosigners [][32]byte, rootPrivKey ed25519.PrivateKey) (tx *AccTx, err error) {
+	if threshold == 0 || int(threshold) > len(cosigners) || len(cosigners) > MAX_COSIGNERS {
+		return nil, errors.New(fmt.Sprintf("Invalid threshold %v for %v cosigners.", threshold, len(cosigners)))
+	}
+
+	tx = new(AccTx)
+	tx.Header = header
+	tx.Fee = fee
+	tx.Amount = amount
+	tx.Threshold = threshold
+	tx.Cosigners = cosigners
+	tx.PubKey = MultiSigAddress(threshold, cosigners)
+
+	var rootPublicKey [32]byte
+	copy(rootPublicKey[:], rootPrivKey[32:])
+	tx.Issuer = SerializeHashContent(rootPublicKey)
+
+	txHash := tx.Hash()
+	copy(tx.Sig[:], ed25519.Sign(rootPrivKey, txHash[:]))
+
+	return tx, nil
+}
+
+//Adds the signature of the cosigner with the given index, the signature is verified before it is added.
+func (tx *FundsTx) Cosign(index uint8, signer FundsTxSigner) error {
+	signature, err := signer.SignFundsTx(tx)
+	if err != nil {
+		return err
+	}
+
+	txHash := tx.Hash()
+	if !ed25519.Verify(signer.PublicKey(), txHash[:], signature[:]) {
+		return errors.New(fmt.Sprintf("Cosignature of tx %x is invalid.", txHash[0:8]))
+	}
+
+	for i, cosig := range tx.Cosigs {
+		if cosig.Index == index {
+			tx.Cosigs[i].Sig = signature
+			return nil
+		}
+	}
+	tx.Cosigs = append(tx.Cosigs, Cosignature{index, signature})
+
+	return nil
+}
@@ -0,0 +1,2030 @@
+// Protobuf definitions of the Bazo transactions and blocks and the gRPC service of the miner, for clients which do not
+// want to reimplement the gob encoding. Hashes, addresses and signatures are raw bytes (32 resp. 64 bytes).
+//
+// The Go code is generated with `go generate ./protocol/pb` (requires protoc, protoc-gen-go and protoc-gen-go-grpc).
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: bazo.proto
+
+package pb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type FundsTx struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Header        uint32                 `protobuf:"varint,1,opt,name=header,proto3" json:"header,omitempty"`
+	Amount        uint64                 `protobuf:"varint,2,opt,name=amount,proto3" json:"amount,omitempty"`
+	Fee           uint64                 `protobuf:"varint,3,opt,name=fee,proto3" json:"fee,omitempty"`
+	TxCnt         uint32                 `protobuf:"varint,4,opt,name=tx_cnt,json=txCnt,proto3" json:"tx_cnt,omitempty"`
+	From          []byte                 `protobuf:"bytes,5,opt,name=from,proto3" json:"from,omitempty"`
+	To            []byte                 `protobuf:"bytes,6,opt,name=to,proto3" json:"to,omitempty"`
+	Sig           []byte                 `protobuf:"bytes,7,opt,name=sig,proto3" json:"sig,omitempty"`
+	Aggregated    bool                   `protobuf:"varint,8,opt,name=aggregated,proto3" json:"aggregated,omitempty"`
+	Data          []byte                 `protobuf:"bytes,9,opt,name=data,proto3" json:"data,omitempty"`
+	Cosigs        []*Cosignature         `protobuf:"bytes,10,rep,name=cosigs,proto3" json:"cosigs,omitempty"`
+	LockUntil     uint64                 `protobuf:"varint,11,opt,name=lock_until,json=lockUntil,proto3" json:"lock_until,omitempty"`
+	GasLimit      uint64                 `protobuf:"varint,12,opt,name=gas_limit,json=gasLimit,proto3" json:"gas_limit,omitempty"`
+	GasPrice      uint64                 `protobuf:"varint,13,opt,name=gas_price,json=gasPrice,proto3" json:"gas_price,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *FundsTx) Reset() {
+	*x = FundsTx{}
+	mi := &file_bazo_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *FundsTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*FundsTx) ProtoMessage() {}
+
+func (x *FundsTx) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use FundsTx.ProtoReflect.Descriptor instead.
+func (*FundsTx) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *FundsTx) GetHeader() uint32 {
+	if x != nil {
+		return x.Header
+	}
+	return 0
+}
+
+func (x *FundsTx) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *FundsTx) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *FundsTx) GetTxCnt() uint32 {
+	if x != nil {
+		return x.TxCnt
+	}
+	return 0
+}
+
+func (x *FundsTx) GetFrom() []byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *FundsTx) GetTo() []byte {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *FundsTx) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *FundsTx) GetAggregated() bool {
+	if x != nil {
+		return x.Aggregated
+	}
+	return false
+}
+
+func (x *FundsTx) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *FundsTx) GetCosigs() []*Cosignature {
+	if x != nil {
+		return x.Cosigs
+	}
+	return nil
+}
+
+func (x *FundsTx) GetLockUntil() uint64 {
+	if x != nil {
+		return x.LockUntil
+	}
+	return 0
+}
+
+func (x *FundsTx) GetGasLimit() uint64 {
+	if x != nil {
+		return x.GasLimit
+	}
+	return 0
+}
+
+func (x *FundsTx) GetGasPrice() uint64 {
+	if x != nil {
+		return x.GasPrice
+	}
+	return 0
+}
+
+// Signature of a cosigner of a multi-signature account, index refers to the cosigners of the account.
+type Cosignature struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         uint32                 `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Sig           []byte                 `protobuf:"bytes,2,opt,name=sig,proto3" json:"sig,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Cosignature) Reset() {
+	*x = Cosignature{}
+	mi := &file_bazo_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Cosignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Cosignature) ProtoMessage() {}
+
+func (x *Cosignature) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Cosignature.ProtoReflect.Descriptor instead.
+func (*Cosignature) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Cosignature) GetIndex() uint32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Cosignature) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+type DeviceInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Type          string                 `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	Owner         []byte                 `protobuf:"bytes,2,opt,name=owner,proto3" json:"owner,omitempty"`
+	RateLimit     uint32                 `protobuf:"varint,3,opt,name=rate_limit,json=rateLimit,proto3" json:"rate_limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeviceInfo) Reset() {
+	*x = DeviceInfo{}
+	mi := &file_bazo_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceInfo) ProtoMessage() {}
+
+func (x *DeviceInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceInfo.ProtoReflect.Descriptor instead.
+func (*DeviceInfo) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *DeviceInfo) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *DeviceInfo) GetOwner() []byte {
+	if x != nil {
+		return x.Owner
+	}
+	return nil
+}
+
+func (x *DeviceInfo) GetRateLimit() uint32 {
+	if x != nil {
+		return x.RateLimit
+	}
+	return 0
+}
+
+type AccTx struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Header            uint32                 `protobuf:"varint,1,opt,name=header,proto3" json:"header,omitempty"`
+	Issuer            []byte                 `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	Fee               uint64                 `protobuf:"varint,3,opt,name=fee,proto3" json:"fee,omitempty"`
+	PubKey            []byte                 `protobuf:"bytes,4,opt,name=pub_key,json=pubKey,proto3" json:"pub_key,omitempty"`
+	Sig               []byte                 `protobuf:"bytes,5,opt,name=sig,proto3" json:"sig,omitempty"`
+	Contract          []byte                 `protobuf:"bytes,6,opt,name=contract,proto3" json:"contract,omitempty"`
+	ContractVariables [][]byte               `protobuf:"bytes,7,rep,name=contract_variables,json=contractVariables,proto3" json:"contract_variables,omitempty"`
+	Amount            uint64                 `protobuf:"varint,8,opt,name=amount,proto3" json:"amount,omitempty"`
+	Threshold         uint32                 `protobuf:"varint,9,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Cosigners         [][]byte               `protobuf:"bytes,10,rep,name=cosigners,proto3" json:"cosigners,omitempty"`
+	Device            *DeviceInfo            `protobuf:"bytes,11,opt,name=device,proto3" json:"device,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *AccTx) Reset() {
+	*x = AccTx{}
+	mi := &file_bazo_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AccTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AccTx) ProtoMessage() {}
+
+func (x *AccTx) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AccTx.ProtoReflect.Descriptor instead.
+func (*AccTx) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *AccTx) GetHeader() uint32 {
+	if x != nil {
+		return x.Header
+	}
+	return 0
+}
+
+func (x *AccTx) GetIssuer() []byte {
+	if x != nil {
+		return x.Issuer
+	}
+	return nil
+}
+
+func (x *AccTx) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *AccTx) GetPubKey() []byte {
+	if x != nil {
+		return x.PubKey
+	}
+	return nil
+}
+
+func (x *AccTx) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *AccTx) GetContract() []byte {
+	if x != nil {
+		return x.Contract
+	}
+	return nil
+}
+
+func (x *AccTx) GetContractVariables() [][]byte {
+	if x != nil {
+		return x.ContractVariables
+	}
+	return nil
+}
+
+func (x *AccTx) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *AccTx) GetThreshold() uint32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *AccTx) GetCosigners() [][]byte {
+	if x != nil {
+		return x.Cosigners
+	}
+	return nil
+}
+
+func (x *AccTx) GetDevice() *DeviceInfo {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+type ConfigTx struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Header        uint32                 `protobuf:"varint,1,opt,name=header,proto3" json:"header,omitempty"`
+	Id            uint32                 `protobuf:"varint,2,opt,name=id,proto3" json:"id,omitempty"`
+	Payload       uint64                 `protobuf:"varint,3,opt,name=payload,proto3" json:"payload,omitempty"`
+	Fee           uint64                 `protobuf:"varint,4,opt,name=fee,proto3" json:"fee,omitempty"`
+	TxCnt         uint32                 `protobuf:"varint,5,opt,name=tx_cnt,json=txCnt,proto3" json:"tx_cnt,omitempty"`
+	Sig           []byte                 `protobuf:"bytes,6,opt,name=sig,proto3" json:"sig,omitempty"`
+	Cosigs        []*ConfigCosignature   `protobuf:"bytes,7,rep,name=cosigs,proto3" json:"cosigs,omitempty"`
+	RootKey       []byte                 `protobuf:"bytes,8,opt,name=root_key,json=rootKey,proto3" json:"root_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigTx) Reset() {
+	*x = ConfigTx{}
+	mi := &file_bazo_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigTx) ProtoMessage() {}
+
+func (x *ConfigTx) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigTx.ProtoReflect.Descriptor instead.
+func (*ConfigTx) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ConfigTx) GetHeader() uint32 {
+	if x != nil {
+		return x.Header
+	}
+	return 0
+}
+
+func (x *ConfigTx) GetId() uint32 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ConfigTx) GetPayload() uint64 {
+	if x != nil {
+		return x.Payload
+	}
+	return 0
+}
+
+func (x *ConfigTx) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *ConfigTx) GetTxCnt() uint32 {
+	if x != nil {
+		return x.TxCnt
+	}
+	return 0
+}
+
+func (x *ConfigTx) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *ConfigTx) GetCosigs() []*ConfigCosignature {
+	if x != nil {
+		return x.Cosigs
+	}
+	return nil
+}
+
+func (x *ConfigTx) GetRootKey() []byte {
+	if x != nil {
+		return x.RootKey
+	}
+	return nil
+}
+
+// Signature of an additional root account, root is the hash of the root account.
+type ConfigCosignature struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Root          []byte                 `protobuf:"bytes,1,opt,name=root,proto3" json:"root,omitempty"`
+	Sig           []byte                 `protobuf:"bytes,2,opt,name=sig,proto3" json:"sig,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ConfigCosignature) Reset() {
+	*x = ConfigCosignature{}
+	mi := &file_bazo_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConfigCosignature) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConfigCosignature) ProtoMessage() {}
+
+func (x *ConfigCosignature) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConfigCosignature.ProtoReflect.Descriptor instead.
+func (*ConfigCosignature) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ConfigCosignature) GetRoot() []byte {
+	if x != nil {
+		return x.Root
+	}
+	return nil
+}
+
+func (x *ConfigCosignature) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+type StakeTx struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Header        uint32                 `protobuf:"varint,1,opt,name=header,proto3" json:"header,omitempty"`
+	Fee           uint64                 `protobuf:"varint,2,opt,name=fee,proto3" json:"fee,omitempty"`
+	IsStaking     bool                   `protobuf:"varint,3,opt,name=is_staking,json=isStaking,proto3" json:"is_staking,omitempty"`
+	Account       []byte                 `protobuf:"bytes,4,opt,name=account,proto3" json:"account,omitempty"`
+	Sig           []byte                 `protobuf:"bytes,5,opt,name=sig,proto3" json:"sig,omitempty"`
+	CommitmentKey []byte                 `protobuf:"bytes,6,opt,name=commitment_key,json=commitmentKey,proto3" json:"commitment_key,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StakeTx) Reset() {
+	*x = StakeTx{}
+	mi := &file_bazo_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StakeTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StakeTx) ProtoMessage() {}
+
+func (x *StakeTx) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StakeTx.ProtoReflect.Descriptor instead.
+func (*StakeTx) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *StakeTx) GetHeader() uint32 {
+	if x != nil {
+		return x.Header
+	}
+	return 0
+}
+
+func (x *StakeTx) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *StakeTx) GetIsStaking() bool {
+	if x != nil {
+		return x.IsStaking
+	}
+	return false
+}
+
+func (x *StakeTx) GetAccount() []byte {
+	if x != nil {
+		return x.Account
+	}
+	return nil
+}
+
+func (x *StakeTx) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *StakeTx) GetCommitmentKey() []byte {
+	if x != nil {
+		return x.CommitmentKey
+	}
+	return nil
+}
+
+type AggTx struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Amount        uint64                 `protobuf:"varint,1,opt,name=amount,proto3" json:"amount,omitempty"`
+	Fee           uint64                 `protobuf:"varint,2,opt,name=fee,proto3" json:"fee,omitempty"`
+	From          [][]byte               `protobuf:"bytes,3,rep,name=from,proto3" json:"from,omitempty"`
+	To            [][]byte               `protobuf:"bytes,4,rep,name=to,proto3" json:"to,omitempty"`
+	AggregatedTxs [][]byte               `protobuf:"bytes,5,rep,name=aggregated_txs,json=aggregatedTxs,proto3" json:"aggregated_txs,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AggTx) Reset() {
+	*x = AggTx{}
+	mi := &file_bazo_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AggTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AggTx) ProtoMessage() {}
+
+func (x *AggTx) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AggTx.ProtoReflect.Descriptor instead.
+func (*AggTx) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *AggTx) GetAmount() uint64 {
+	if x != nil {
+		return x.Amount
+	}
+	return 0
+}
+
+func (x *AggTx) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *AggTx) GetFrom() [][]byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *AggTx) GetTo() [][]byte {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *AggTx) GetAggregatedTxs() [][]byte {
+	if x != nil {
+		return x.AggregatedTxs
+	}
+	return nil
+}
+
+type IotTx struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Header        uint32                 `protobuf:"varint,1,opt,name=header,proto3" json:"header,omitempty"`
+	TxCnt         uint32                 `protobuf:"varint,2,opt,name=tx_cnt,json=txCnt,proto3" json:"tx_cnt,omitempty"`
+	From          []byte                 `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	To            []byte                 `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	Sig           []byte                 `protobuf:"bytes,5,opt,name=sig,proto3" json:"sig,omitempty"`
+	Data          []byte                 `protobuf:"bytes,6,opt,name=data,proto3" json:"data,omitempty"`
+	Fee           uint64                 `protobuf:"varint,7,opt,name=fee,proto3" json:"fee,omitempty"`
+	Sequence      uint64                 `protobuf:"varint,8,opt,name=sequence,proto3" json:"sequence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IotTx) Reset() {
+	*x = IotTx{}
+	mi := &file_bazo_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IotTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IotTx) ProtoMessage() {}
+
+func (x *IotTx) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IotTx.ProtoReflect.Descriptor instead.
+func (*IotTx) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *IotTx) GetHeader() uint32 {
+	if x != nil {
+		return x.Header
+	}
+	return 0
+}
+
+func (x *IotTx) GetTxCnt() uint32 {
+	if x != nil {
+		return x.TxCnt
+	}
+	return 0
+}
+
+func (x *IotTx) GetFrom() []byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *IotTx) GetTo() []byte {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+func (x *IotTx) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+func (x *IotTx) GetData() []byte {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *IotTx) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *IotTx) GetSequence() uint64 {
+	if x != nil {
+		return x.Sequence
+	}
+	return 0
+}
+
+type ContractTx struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Header            uint32                 `protobuf:"varint,1,opt,name=header,proto3" json:"header,omitempty"`
+	From              []byte                 `protobuf:"bytes,2,opt,name=from,proto3" json:"from,omitempty"`
+	TxCnt             uint32                 `protobuf:"varint,3,opt,name=tx_cnt,json=txCnt,proto3" json:"tx_cnt,omitempty"`
+	Fee               uint64                 `protobuf:"varint,4,opt,name=fee,proto3" json:"fee,omitempty"`
+	GasLimit          uint64                 `protobuf:"varint,5,opt,name=gas_limit,json=gasLimit,proto3" json:"gas_limit,omitempty"`
+	Contract          []byte                 `protobuf:"bytes,6,opt,name=contract,proto3" json:"contract,omitempty"`
+	ContractVariables [][]byte               `protobuf:"bytes,7,rep,name=contract_variables,json=contractVariables,proto3" json:"contract_variables,omitempty"`
+	Sig               []byte                 `protobuf:"bytes,8,opt,name=sig,proto3" json:"sig,omitempty"`
+	unknownFields     protoimpl.UnknownFields
+	sizeCache         protoimpl.SizeCache
+}
+
+func (x *ContractTx) Reset() {
+	*x = ContractTx{}
+	mi := &file_bazo_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ContractTx) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ContractTx) ProtoMessage() {}
+
+func (x *ContractTx) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ContractTx.ProtoReflect.Descriptor instead.
+func (*ContractTx) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ContractTx) GetHeader() uint32 {
+	if x != nil {
+		return x.Header
+	}
+	return 0
+}
+
+func (x *ContractTx) GetFrom() []byte {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *ContractTx) GetTxCnt() uint32 {
+	if x != nil {
+		return x.TxCnt
+	}
+	return 0
+}
+
+func (x *ContractTx) GetFee() uint64 {
+	if x != nil {
+		return x.Fee
+	}
+	return 0
+}
+
+func (x *ContractTx) GetGasLimit() uint64 {
+	if x != nil {
+		return x.GasLimit
+	}
+	return 0
+}
+
+func (x *ContractTx) GetContract() []byte {
+	if x != nil {
+		return x.Contract
+	}
+	return nil
+}
+
+func (x *ContractTx) GetContractVariables() [][]byte {
+	if x != nil {
+		return x.ContractVariables
+	}
+	return nil
+}
+
+func (x *ContractTx) GetSig() []byte {
+	if x != nil {
+		return x.Sig
+	}
+	return nil
+}
+
+type Transaction struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Tx:
+	//
+	//	*Transaction_Funds
+	//	*Transaction_Acc
+	//	*Transaction_Config
+	//	*Transaction_Stake
+	//	*Transaction_Agg
+	//	*Transaction_Iot
+	//	*Transaction_Contract
+	Tx            isTransaction_Tx `protobuf_oneof:"tx"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Transaction) Reset() {
+	*x = Transaction{}
+	mi := &file_bazo_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Transaction) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Transaction) ProtoMessage() {}
+
+func (x *Transaction) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Transaction.ProtoReflect.Descriptor instead.
+func (*Transaction) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *Transaction) GetTx() isTransaction_Tx {
+	if x != nil {
+		return x.Tx
+	}
+	return nil
+}
+
+func (x *Transaction) GetFunds() *FundsTx {
+	if x != nil {
+		if x, ok := x.Tx.(*Transaction_Funds); ok {
+			return x.Funds
+		}
+	}
+	return nil
+}
+
+func (x *Transaction) GetAcc() *AccTx {
+	if x != nil {
+		if x, ok := x.Tx.(*Transaction_Acc); ok {
+			return x.Acc
+		}
+	}
+	return nil
+}
+
+func (x *Transaction) GetConfig() *ConfigTx {
+	if x != nil {
+		if x, ok := x.Tx.(*Transaction_Config); ok {
+			return x.Config
+		}
+	}
+	return nil
+}
+
+func (x *Transaction) GetStake() *StakeTx {
+	if x != nil {
+		if x, ok := x.Tx.(*Transaction_Stake); ok {
+			return x.Stake
+		}
+	}
+	return nil
+}
+
+func (x *Transaction) GetAgg() *AggTx {
+	if x != nil {
+		if x, ok := x.Tx.(*Transaction_Agg); ok {
+			return x.Agg
+		}
+	}
+	return nil
+}
+
+func (x *Transaction) GetIot() *IotTx {
+	if x != nil {
+		if x, ok := x.Tx.(*Transaction_Iot); ok {
+			return x.Iot
+		}
+	}
+	return nil
+}
+
+func (x *Transaction) GetContract() *ContractTx {
+	if x != nil {
+		if x, ok := x.Tx.(*Transaction_Contract); ok {
+			return x.Contract
+		}
+	}
+	return nil
+}
+
+type isTransaction_Tx interface {
+	isTransaction_Tx()
+}
+
+type Transaction_Funds struct {
+	Funds *FundsTx `protobuf:"bytes,1,opt,name=funds,proto3,oneof"`
+}
+
+type Transaction_Acc struct {
+	Acc *AccTx `protobuf:"bytes,2,opt,name=acc,proto3,oneof"`
+}
+
+type Transaction_Config struct {
+	Config *ConfigTx `protobuf:"bytes,3,opt,name=config,proto3,oneof"`
+}
+
+type Transaction_Stake struct {
+	Stake *StakeTx `protobuf:"bytes,4,opt,name=stake,proto3,oneof"`
+}
+
+type Transaction_Agg struct {
+	Agg *AggTx `protobuf:"bytes,5,opt,name=agg,proto3,oneof"`
+}
+
+type Transaction_Iot struct {
+	Iot *IotTx `protobuf:"bytes,6,opt,name=iot,proto3,oneof"`
+}
+
+type Transaction_Contract struct {
+	Contract *ContractTx `protobuf:"bytes,7,opt,name=contract,proto3,oneof"`
+}
+
+func (*Transaction_Funds) isTransaction_Tx() {}
+
+func (*Transaction_Acc) isTransaction_Tx() {}
+
+func (*Transaction_Config) isTransaction_Tx() {}
+
+func (*Transaction_Stake) isTransaction_Tx() {}
+
+func (*Transaction_Agg) isTransaction_Tx() {}
+
+func (*Transaction_Iot) isTransaction_Tx() {}
+
+func (*Transaction_Contract) isTransaction_Tx() {}
+
+type Block struct {
+	state                          protoimpl.MessageState `protogen:"open.v1"`
+	Header                         uint32                 `protobuf:"varint,1,opt,name=header,proto3" json:"header,omitempty"`
+	Hash                           []byte                 `protobuf:"bytes,2,opt,name=hash,proto3" json:"hash,omitempty"`
+	PrevHash                       []byte                 `protobuf:"bytes,3,opt,name=prev_hash,json=prevHash,proto3" json:"prev_hash,omitempty"`
+	HashWithoutTx                  []byte                 `protobuf:"bytes,4,opt,name=hash_without_tx,json=hashWithoutTx,proto3" json:"hash_without_tx,omitempty"`
+	PrevHashWithoutTx              []byte                 `protobuf:"bytes,5,opt,name=prev_hash_without_tx,json=prevHashWithoutTx,proto3" json:"prev_hash_without_tx,omitempty"`
+	Height                         uint32                 `protobuf:"varint,6,opt,name=height,proto3" json:"height,omitempty"`
+	Beneficiary                    []byte                 `protobuf:"bytes,7,opt,name=beneficiary,proto3" json:"beneficiary,omitempty"`
+	Aggregated                     bool                   `protobuf:"varint,8,opt,name=aggregated,proto3" json:"aggregated,omitempty"`
+	Nonce                          []byte                 `protobuf:"bytes,9,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	Timestamp                      int64                  `protobuf:"varint,10,opt,name=timestamp,proto3" json:"timestamp,omitempty"`
+	MerkleRoot                     []byte                 `protobuf:"bytes,11,opt,name=merkle_root,json=merkleRoot,proto3" json:"merkle_root,omitempty"`
+	CommitmentProof                []byte                 `protobuf:"bytes,12,opt,name=commitment_proof,json=commitmentProof,proto3" json:"commitment_proof,omitempty"`
+	SlashedAddress                 []byte                 `protobuf:"bytes,13,opt,name=slashed_address,json=slashedAddress,proto3" json:"slashed_address,omitempty"`
+	ConflictingBlockHash1          []byte                 `protobuf:"bytes,14,opt,name=conflicting_block_hash1,json=conflictingBlockHash1,proto3" json:"conflicting_block_hash1,omitempty"`
+	ConflictingBlockHash2          []byte                 `protobuf:"bytes,15,opt,name=conflicting_block_hash2,json=conflictingBlockHash2,proto3" json:"conflicting_block_hash2,omitempty"`
+	ConflictingBlockHashWithoutTx1 []byte                 `protobuf:"bytes,16,opt,name=conflicting_block_hash_without_tx1,json=conflictingBlockHashWithoutTx1,proto3" json:"conflicting_block_hash_without_tx1,omitempty"`
+	ConflictingBlockHashWithoutTx2 []byte                 `protobuf:"bytes,17,opt,name=conflicting_block_hash_without_tx2,json=conflictingBlockHashWithoutTx2,proto3" json:"conflicting_block_hash_without_tx2,omitempty"`
+	AccTxData                      [][]byte               `protobuf:"bytes,18,rep,name=acc_tx_data,json=accTxData,proto3" json:"acc_tx_data,omitempty"`
+	FundsTxData                    [][]byte               `protobuf:"bytes,19,rep,name=funds_tx_data,json=fundsTxData,proto3" json:"funds_tx_data,omitempty"`
+	ConfigTxData                   [][]byte               `protobuf:"bytes,20,rep,name=config_tx_data,json=configTxData,proto3" json:"config_tx_data,omitempty"`
+	StakeTxData                    [][]byte               `protobuf:"bytes,21,rep,name=stake_tx_data,json=stakeTxData,proto3" json:"stake_tx_data,omitempty"`
+	AggTxData                      [][]byte               `protobuf:"bytes,22,rep,name=agg_tx_data,json=aggTxData,proto3" json:"agg_tx_data,omitempty"`
+	IotTxData                      [][]byte               `protobuf:"bytes,23,rep,name=iot_tx_data,json=iotTxData,proto3" json:"iot_tx_data,omitempty"`
+	StateRoot                      []byte                 `protobuf:"bytes,24,opt,name=state_root,json=stateRoot,proto3" json:"state_root,omitempty"`
+	ContractTxData                 [][]byte               `protobuf:"bytes,25,rep,name=contract_tx_data,json=contractTxData,proto3" json:"contract_tx_data,omitempty"`
+	unknownFields                  protoimpl.UnknownFields
+	sizeCache                      protoimpl.SizeCache
+}
+
+func (x *Block) Reset() {
+	*x = Block{}
+	mi := &file_bazo_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Block) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Block) ProtoMessage() {}
+
+func (x *Block) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Block.ProtoReflect.Descriptor instead.
+func (*Block) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *Block) GetHeader() uint32 {
+	if x != nil {
+		return x.Header
+	}
+	return 0
+}
+
+func (x *Block) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+func (x *Block) GetPrevHash() []byte {
+	if x != nil {
+		return x.PrevHash
+	}
+	return nil
+}
+
+func (x *Block) GetHashWithoutTx() []byte {
+	if x != nil {
+		return x.HashWithoutTx
+	}
+	return nil
+}
+
+func (x *Block) GetPrevHashWithoutTx() []byte {
+	if x != nil {
+		return x.PrevHashWithoutTx
+	}
+	return nil
+}
+
+func (x *Block) GetHeight() uint32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Block) GetBeneficiary() []byte {
+	if x != nil {
+		return x.Beneficiary
+	}
+	return nil
+}
+
+func (x *Block) GetAggregated() bool {
+	if x != nil {
+		return x.Aggregated
+	}
+	return false
+}
+
+func (x *Block) GetNonce() []byte {
+	if x != nil {
+		return x.Nonce
+	}
+	return nil
+}
+
+func (x *Block) GetTimestamp() int64 {
+	if x != nil {
+		return x.Timestamp
+	}
+	return 0
+}
+
+func (x *Block) GetMerkleRoot() []byte {
+	if x != nil {
+		return x.MerkleRoot
+	}
+	return nil
+}
+
+func (x *Block) GetCommitmentProof() []byte {
+	if x != nil {
+		return x.CommitmentProof
+	}
+	return nil
+}
+
+func (x *Block) GetSlashedAddress() []byte {
+	if x != nil {
+		return x.SlashedAddress
+	}
+	return nil
+}
+
+func (x *Block) GetConflictingBlockHash1() []byte {
+	if x != nil {
+		return x.ConflictingBlockHash1
+	}
+	return nil
+}
+
+func (x *Block) GetConflictingBlockHash2() []byte {
+	if x != nil {
+		return x.ConflictingBlockHash2
+	}
+	return nil
+}
+
+func (x *Block) GetConflictingBlockHashWithoutTx1() []byte {
+	if x != nil {
+		return x.ConflictingBlockHashWithoutTx1
+	}
+	return nil
+}
+
+func (x *Block) GetConflictingBlockHashWithoutTx2() []byte {
+	if x != nil {
+		return x.ConflictingBlockHashWithoutTx2
+	}
+	return nil
+}
+
+func (x *Block) GetAccTxData() [][]byte {
+	if x != nil {
+		return x.AccTxData
+	}
+	return nil
+}
+
+func (x *Block) GetFundsTxData() [][]byte {
+	if x != nil {
+		return x.FundsTxData
+	}
+	return nil
+}
+
+func (x *Block) GetConfigTxData() [][]byte {
+	if x != nil {
+		return x.ConfigTxData
+	}
+	return nil
+}
+
+func (x *Block) GetStakeTxData() [][]byte {
+	if x != nil {
+		return x.StakeTxData
+	}
+	return nil
+}
+
+func (x *Block) GetAggTxData() [][]byte {
+	if x != nil {
+		return x.AggTxData
+	}
+	return nil
+}
+
+func (x *Block) GetIotTxData() [][]byte {
+	if x != nil {
+		return x.IotTxData
+	}
+	return nil
+}
+
+func (x *Block) GetStateRoot() []byte {
+	if x != nil {
+		return x.StateRoot
+	}
+	return nil
+}
+
+func (x *Block) GetContractTxData() [][]byte {
+	if x != nil {
+		return x.ContractTxData
+	}
+	return nil
+}
+
+type Account struct {
+	state              protoimpl.MessageState `protogen:"open.v1"`
+	Address            []byte                 `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	Issuer             []byte                 `protobuf:"bytes,2,opt,name=issuer,proto3" json:"issuer,omitempty"`
+	Balance            uint64                 `protobuf:"varint,3,opt,name=balance,proto3" json:"balance,omitempty"`
+	TxCnt              uint32                 `protobuf:"varint,4,opt,name=tx_cnt,json=txCnt,proto3" json:"tx_cnt,omitempty"`
+	IsStaking          bool                   `protobuf:"varint,5,opt,name=is_staking,json=isStaking,proto3" json:"is_staking,omitempty"`
+	StakingBlockHeight uint32                 `protobuf:"varint,6,opt,name=staking_block_height,json=stakingBlockHeight,proto3" json:"staking_block_height,omitempty"`
+	IsRoot             bool                   `protobuf:"varint,7,opt,name=is_root,json=isRoot,proto3" json:"is_root,omitempty"`
+	CommitmentKey      []byte                 `protobuf:"bytes,8,opt,name=commitment_key,json=commitmentKey,proto3" json:"commitment_key,omitempty"`
+	Contract           []byte                 `protobuf:"bytes,9,opt,name=contract,proto3" json:"contract,omitempty"`
+	ContractVariables  [][]byte               `protobuf:"bytes,10,rep,name=contract_variables,json=contractVariables,proto3" json:"contract_variables,omitempty"`
+	Threshold          uint32                 `protobuf:"varint,11,opt,name=threshold,proto3" json:"threshold,omitempty"`
+	Cosigners          [][]byte               `protobuf:"bytes,12,rep,name=cosigners,proto3" json:"cosigners,omitempty"`
+	Device             *DeviceInfo            `protobuf:"bytes,13,opt,name=device,proto3" json:"device,omitempty"`
+	IotTokens          uint32                 `protobuf:"varint,14,opt,name=iot_tokens,json=iotTokens,proto3" json:"iot_tokens,omitempty"`
+	IotRefillHeight    uint32                 `protobuf:"varint,15,opt,name=iot_refill_height,json=iotRefillHeight,proto3" json:"iot_refill_height,omitempty"`
+	UnbondingHeight    uint32                 `protobuf:"varint,16,opt,name=unbonding_height,json=unbondingHeight,proto3" json:"unbonding_height,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Account) Reset() {
+	*x = Account{}
+	mi := &file_bazo_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Account) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Account) ProtoMessage() {}
+
+func (x *Account) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Account.ProtoReflect.Descriptor instead.
+func (*Account) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Account) GetAddress() []byte {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *Account) GetIssuer() []byte {
+	if x != nil {
+		return x.Issuer
+	}
+	return nil
+}
+
+func (x *Account) GetBalance() uint64 {
+	if x != nil {
+		return x.Balance
+	}
+	return 0
+}
+
+func (x *Account) GetTxCnt() uint32 {
+	if x != nil {
+		return x.TxCnt
+	}
+	return 0
+}
+
+func (x *Account) GetIsStaking() bool {
+	if x != nil {
+		return x.IsStaking
+	}
+	return false
+}
+
+func (x *Account) GetStakingBlockHeight() uint32 {
+	if x != nil {
+		return x.StakingBlockHeight
+	}
+	return 0
+}
+
+func (x *Account) GetIsRoot() bool {
+	if x != nil {
+		return x.IsRoot
+	}
+	return false
+}
+
+func (x *Account) GetCommitmentKey() []byte {
+	if x != nil {
+		return x.CommitmentKey
+	}
+	return nil
+}
+
+func (x *Account) GetContract() []byte {
+	if x != nil {
+		return x.Contract
+	}
+	return nil
+}
+
+func (x *Account) GetContractVariables() [][]byte {
+	if x != nil {
+		return x.ContractVariables
+	}
+	return nil
+}
+
+func (x *Account) GetThreshold() uint32 {
+	if x != nil {
+		return x.Threshold
+	}
+	return 0
+}
+
+func (x *Account) GetCosigners() [][]byte {
+	if x != nil {
+		return x.Cosigners
+	}
+	return nil
+}
+
+func (x *Account) GetDevice() *DeviceInfo {
+	if x != nil {
+		return x.Device
+	}
+	return nil
+}
+
+func (x *Account) GetIotTokens() uint32 {
+	if x != nil {
+		return x.IotTokens
+	}
+	return 0
+}
+
+func (x *Account) GetIotRefillHeight() uint32 {
+	if x != nil {
+		return x.IotRefillHeight
+	}
+	return 0
+}
+
+func (x *Account) GetUnbondingHeight() uint32 {
+	if x != nil {
+		return x.UnbondingHeight
+	}
+	return 0
+}
+
+// Aggregation txs are created by the miners and can not be submitted.
+type SubmitTxRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Tx:
+	//
+	//	*SubmitTxRequest_Funds
+	//	*SubmitTxRequest_Acc
+	//	*SubmitTxRequest_Config
+	//	*SubmitTxRequest_Stake
+	//	*SubmitTxRequest_Iot
+	//	*SubmitTxRequest_Contract
+	Tx            isSubmitTxRequest_Tx `protobuf_oneof:"tx"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitTxRequest) Reset() {
+	*x = SubmitTxRequest{}
+	mi := &file_bazo_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitTxRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitTxRequest) ProtoMessage() {}
+
+func (x *SubmitTxRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitTxRequest.ProtoReflect.Descriptor instead.
+func (*SubmitTxRequest) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *SubmitTxRequest) GetTx() isSubmitTxRequest_Tx {
+	if x != nil {
+		return x.Tx
+	}
+	return nil
+}
+
+func (x *SubmitTxRequest) GetFunds() *FundsTx {
+	if x != nil {
+		if x, ok := x.Tx.(*SubmitTxRequest_Funds); ok {
+			return x.Funds
+		}
+	}
+	return nil
+}
+
+func (x *SubmitTxRequest) GetAcc() *AccTx {
+	if x != nil {
+		if x, ok := x.Tx.(*SubmitTxRequest_Acc); ok {
+			return x.Acc
+		}
+	}
+	return nil
+}
+
+func (x *SubmitTxRequest) GetConfig() *ConfigTx {
+	if x != nil {
+		if x, ok := x.Tx.(*SubmitTxRequest_Config); ok {
+			return x.Config
+		}
+	}
+	return nil
+}
+
+func (x *SubmitTxRequest) GetStake() *StakeTx {
+	if x != nil {
+		if x, ok := x.Tx.(*SubmitTxRequest_Stake); ok {
+			return x.Stake
+		}
+	}
+	return nil
+}
+
+func (x *SubmitTxRequest) GetIot() *IotTx {
+	if x != nil {
+		if x, ok := x.Tx.(*SubmitTxRequest_Iot); ok {
+			return x.Iot
+		}
+	}
+	return nil
+}
+
+func (x *SubmitTxRequest) GetContract() *ContractTx {
+	if x != nil {
+		if x, ok := x.Tx.(*SubmitTxRequest_Contract); ok {
+			return x.Contract
+		}
+	}
+	return nil
+}
+
+type isSubmitTxRequest_Tx interface {
+	isSubmitTxRequest_Tx()
+}
+
+type SubmitTxRequest_Funds struct {
+	Funds *FundsTx `protobuf:"bytes,1,opt,name=funds,proto3,oneof"`
+}
+
+type SubmitTxRequest_Acc struct {
+	Acc *AccTx `protobuf:"bytes,2,opt,name=acc,proto3,oneof"`
+}
+
+type SubmitTxRequest_Config struct {
+	Config *ConfigTx `protobuf:"bytes,3,opt,name=config,proto3,oneof"`
+}
+
+type SubmitTxRequest_Stake struct {
+	Stake *StakeTx `protobuf:"bytes,4,opt,name=stake,proto3,oneof"`
+}
+
+type SubmitTxRequest_Iot struct {
+	Iot *IotTx `protobuf:"bytes,5,opt,name=iot,proto3,oneof"`
+}
+
+type SubmitTxRequest_Contract struct {
+	Contract *ContractTx `protobuf:"bytes,6,opt,name=contract,proto3,oneof"`
+}
+
+func (*SubmitTxRequest_Funds) isSubmitTxRequest_Tx() {}
+
+func (*SubmitTxRequest_Acc) isSubmitTxRequest_Tx() {}
+
+func (*SubmitTxRequest_Config) isSubmitTxRequest_Tx() {}
+
+func (*SubmitTxRequest_Stake) isSubmitTxRequest_Tx() {}
+
+func (*SubmitTxRequest_Iot) isSubmitTxRequest_Tx() {}
+
+func (*SubmitTxRequest_Contract) isSubmitTxRequest_Tx() {}
+
+type SubmitTxResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TxHash        []byte                 `protobuf:"bytes,1,opt,name=tx_hash,json=txHash,proto3" json:"tx_hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SubmitTxResponse) Reset() {
+	*x = SubmitTxResponse{}
+	mi := &file_bazo_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SubmitTxResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SubmitTxResponse) ProtoMessage() {}
+
+func (x *SubmitTxResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SubmitTxResponse.ProtoReflect.Descriptor instead.
+func (*SubmitTxResponse) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *SubmitTxResponse) GetTxHash() []byte {
+	if x != nil {
+		return x.TxHash
+	}
+	return nil
+}
+
+type HashRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Hash          []byte                 `protobuf:"bytes,1,opt,name=hash,proto3" json:"hash,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HashRequest) Reset() {
+	*x = HashRequest{}
+	mi := &file_bazo_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HashRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HashRequest) ProtoMessage() {}
+
+func (x *HashRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HashRequest.ProtoReflect.Descriptor instead.
+func (*HashRequest) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *HashRequest) GetHash() []byte {
+	if x != nil {
+		return x.Hash
+	}
+	return nil
+}
+
+type GetTipRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTipRequest) Reset() {
+	*x = GetTipRequest{}
+	mi := &file_bazo_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTipRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTipRequest) ProtoMessage() {}
+
+func (x *GetTipRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_bazo_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTipRequest.ProtoReflect.Descriptor instead.
+func (*GetTipRequest) Descriptor() ([]byte, []int) {
+	return file_bazo_proto_rawDescGZIP(), []int{16}
+}
+
+var File_bazo_proto protoreflect.FileDescriptor
+
+const file_bazo_proto_rawDesc = "" +
+	"\n" +
+	"\n" +
+	"bazo.proto\x12\x04bazo\"\xd0\x02\n" +
+	"\aFundsTx\x12\x16\n" +
+	"\x06header\x18\x01 \x01(\rR\x06header\x12\x16\n" +
+	"\x06amount\x18\x02 \x01(\x04R\x06amount\x12\x10\n" +
+	"\x03fee\x18\x03 \x01(\x04R\x03fee\x12\x15\n" +
+	"\x06tx_cnt\x18\x04 \x01(\rR\x05txCnt\x12\x12\n" +
+	"\x04from\x18\x05 \x01(\fR\x04from\x12\x0e\n" +
+	"\x02to\x18\x06 \x01(\fR\x02to\x12\x10\n" +
+	"\x03sig\x18\a \x01(\fR\x03sig\x12\x1e\n" +
+	"\n" +
+	"aggregated\x18\b \x01(\bR\n" +
+	"aggregated\x12\x12\n" +
+	"\x04data\x18\t \x01(\fR\x04data\x12)\n" +
+	"\x06cosigs\x18\n" +
+	" \x03(\v2\x11.bazo.CosignatureR\x06cosigs\x12\x1d\n" +
+	"\n" +
+	"lock_until\x18\v \x01(\x04R\tlockUntil\x12\x1b\n" +
+	"\tgas_limit\x18\f \x01(\x04R\bgasLimit\x12\x1b\n" +
+	"\tgas_price\x18\r \x01(\x04R\bgasPrice\"5\n" +
+	"\vCosignature\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\rR\x05index\x12\x10\n" +
+	"\x03sig\x18\x02 \x01(\fR\x03sig\"U\n" +
+	"\n" +
+	"DeviceInfo\x12\x12\n" +
+	"\x04type\x18\x01 \x01(\tR\x04type\x12\x14\n" +
+	"\x05owner\x18\x02 \x01(\fR\x05owner\x12\x1d\n" +
+	"\n" +
+	"rate_limit\x18\x03 \x01(\rR\trateLimit\"\xbd\x02\n" +
+	"\x05AccTx\x12\x16\n" +
+	"\x06header\x18\x01 \x01(\rR\x06header\x12\x16\n" +
+	"\x06issuer\x18\x02 \x01(\fR\x06issuer\x12\x10\n" +
+	"\x03fee\x18\x03 \x01(\x04R\x03fee\x12\x17\n" +
+	"\apub_key\x18\x04 \x01(\fR\x06pubKey\x12\x10\n" +
+	"\x03sig\x18\x05 \x01(\fR\x03sig\x12\x1a\n" +
+	"\bcontract\x18\x06 \x01(\fR\bcontract\x12-\n" +
+	"\x12contract_variables\x18\a \x03(\fR\x11contractVariables\x12\x16\n" +
+	"\x06amount\x18\b \x01(\x04R\x06amount\x12\x1c\n" +
+	"\tthreshold\x18\t \x01(\rR\tthreshold\x12\x1c\n" +
+	"\tcosigners\x18\n" +
+	" \x03(\fR\tcosigners\x12(\n" +
+	"\x06device\x18\v \x01(\v2\x10.bazo.DeviceInfoR\x06device\"\xd3\x01\n" +
+	"\bConfigTx\x12\x16\n" +
+	"\x06header\x18\x01 \x01(\rR\x06header\x12\x0e\n" +
+	"\x02id\x18\x02 \x01(\rR\x02id\x12\x18\n" +
+	"\apayload\x18\x03 \x01(\x04R\apayload\x12\x10\n" +
+	"\x03fee\x18\x04 \x01(\x04R\x03fee\x12\x15\n" +
+	"\x06tx_cnt\x18\x05 \x01(\rR\x05txCnt\x12\x10\n" +
+	"\x03sig\x18\x06 \x01(\fR\x03sig\x12/\n" +
+	"\x06cosigs\x18\a \x03(\v2\x17.bazo.ConfigCosignatureR\x06cosigs\x12\x19\n" +
+	"\broot_key\x18\b \x01(\fR\arootKey\"9\n" +
+	"\x11ConfigCosignature\x12\x12\n" +
+	"\x04root\x18\x01 \x01(\fR\x04root\x12\x10\n" +
+	"\x03sig\x18\x02 \x01(\fR\x03sig\"\xa5\x01\n" +
+	"\aStakeTx\x12\x16\n" +
+	"\x06header\x18\x01 \x01(\rR\x06header\x12\x10\n" +
+	"\x03fee\x18\x02 \x01(\x04R\x03fee\x12\x1d\n" +
+	"\n" +
+	"is_staking\x18\x03 \x01(\bR\tisStaking\x12\x18\n" +
+	"\aaccount\x18\x04 \x01(\fR\aaccount\x12\x10\n" +
+	"\x03sig\x18\x05 \x01(\fR\x03sig\x12%\n" +
+	"\x0ecommitment_key\x18\x06 \x01(\fR\rcommitmentKey\"|\n" +
+	"\x05AggTx\x12\x16\n" +
+	"\x06amount\x18\x01 \x01(\x04R\x06amount\x12\x10\n" +
+	"\x03fee\x18\x02 \x01(\x04R\x03fee\x12\x12\n" +
+	"\x04from\x18\x03 \x03(\fR\x04from\x12\x0e\n" +
+	"\x02to\x18\x04 \x03(\fR\x02to\x12%\n" +
+	"\x0eaggregated_txs\x18\x05 \x03(\fR\raggregatedTxs\"\xae\x01\n" +
+	"\x05IotTx\x12\x16\n" +
+	"\x06header\x18\x01 \x01(\rR\x06header\x12\x15\n" +
+	"\x06tx_cnt\x18\x02 \x01(\rR\x05txCnt\x12\x12\n" +
+	"\x04from\x18\x03 \x01(\fR\x04from\x12\x0e\n" +
+	"\x02to\x18\x04 \x01(\fR\x02to\x12\x10\n" +
+	"\x03sig\x18\x05 \x01(\fR\x03sig\x12\x12\n" +
+	"\x04data\x18\x06 \x01(\fR\x04data\x12\x10\n" +
+	"\x03fee\x18\a \x01(\x04R\x03fee\x12\x1a\n" +
+	"\bsequence\x18\b \x01(\x04R\bsequence\"\xdb\x01\n" +
+	"\n" +
+	"ContractTx\x12\x16\n" +
+	"\x06header\x18\x01 \x01(\rR\x06header\x12\x12\n" +
+	"\x04from\x18\x02 \x01(\fR\x04from\x12\x15\n" +
+	"\x06tx_cnt\x18\x03 \x01(\rR\x05txCnt\x12\x10\n" +
+	"\x03fee\x18\x04 \x01(\x04R\x03fee\x12\x1b\n" +
+	"\tgas_limit\x18\x05 \x01(\x04R\bgasLimit\x12\x1a\n" +
+	"\bcontract\x18\x06 \x01(\fR\bcontract\x12-\n" +
+	"\x12contract_variables\x18\a \x03(\fR\x11contractVariables\x12\x10\n" +
+	"\x03sig\x18\b \x01(\fR\x03sig\"\x9e\x02\n" +
+	"\vTransaction\x12%\n" +
+	"\x05funds\x18\x01 \x01(\v2\r.bazo.FundsTxH\x00R\x05funds\x12\x1f\n" +
+	"\x03acc\x18\x02 \x01(\v2\v.bazo.AccTxH\x00R\x03acc\x12(\n" +
+	"\x06config\x18\x03 \x01(\v2\x0e.bazo.ConfigTxH\x00R\x06config\x12%\n" +
+	"\x05stake\x18\x04 \x01(\v2\r.bazo.StakeTxH\x00R\x05stake\x12\x1f\n" +
+	"\x03agg\x18\x05 \x01(\v2\v.bazo.AggTxH\x00R\x03agg\x12\x1f\n" +
+	"\x03iot\x18\x06 \x01(\v2\v.bazo.IotTxH\x00R\x03iot\x12.\n" +
+	"\bcontract\x18\a \x01(\v2\x10.bazo.ContractTxH\x00R\bcontractB\x04\n" +
+	"\x02tx\"\xcb\a\n" +
+	"\x05Block\x12\x16\n" +
+	"\x06header\x18\x01 \x01(\rR\x06header\x12\x12\n" +
+	"\x04hash\x18\x02 \x01(\fR\x04hash\x12\x1b\n" +
+	"\tprev_hash\x18\x03 \x01(\fR\bprevHash\x12&\n" +
+	"\x0fhash_without_tx\x18\x04 \x01(\fR\rhashWithoutTx\x12/\n" +
+	"\x14prev_hash_without_tx\x18\x05 \x01(\fR\x11prevHashWithoutTx\x12\x16\n" +
+	"\x06height\x18\x06 \x01(\rR\x06height\x12 \n" +
+	"\vbeneficiary\x18\a \x01(\fR\vbeneficiary\x12\x1e\n" +
+	"\n" +
+	"aggregated\x18\b \x01(\bR\n" +
+	"aggregated\x12\x14\n" +
+	"\x05nonce\x18\t \x01(\fR\x05nonce\x12\x1c\n" +
+	"\ttimestamp\x18\n" +
+	" \x01(\x03R\ttimestamp\x12\x1f\n" +
+	"\vmerkle_root\x18\v \x01(\fR\n" +
+	"merkleRoot\x12)\n" +
+	"\x10commitment_proof\x18\f \x01(\fR\x0fcommitmentProof\x12'\n" +
+	"\x0fslashed_address\x18\r \x01(\fR\x0eslashedAddress\x126\n" +
+	"\x17conflicting_block_hash1\x18\x0e \x01(\fR\x15conflictingBlockHash1\x126\n" +
+	"\x17conflicting_block_hash2\x18\x0f \x01(\fR\x15conflictingBlockHash2\x12J\n" +
+	"\"conflicting_block_hash_without_tx1\x18\x10 \x01(\fR\x1econflictingBlockHashWithoutTx1\x12J\n" +
+	"\"conflicting_block_hash_without_tx2\x18\x11 \x01(\fR\x1econflictingBlockHashWithoutTx2\x12\x1e\n" +
+	"\vacc_tx_data\x18\x12 \x03(\fR\taccTxData\x12\"\n" +
+	"\rfunds_tx_data\x18\x13 \x03(\fR\vfundsTxData\x12$\n" +
+	"\x0econfig_tx_data\x18\x14 \x03(\fR\fconfigTxData\x12\"\n" +
+	"\rstake_tx_data\x18\x15 \x03(\fR\vstakeTxData\x12\x1e\n" +
+	"\vagg_tx_data\x18\x16 \x03(\fR\taggTxData\x12\x1e\n" +
+	"\viot_tx_data\x18\x17 \x03(\fR\tiotTxData\x12\x1d\n" +
+	"\n" +
+	"state_root\x18\x18 \x01(\fR\tstateRoot\x12(\n" +
+	"\x10contract_tx_data\x18\x19 \x03(\fR\x0econtractTxData\"\xa4\x04\n" +
+	"\aAccount\x12\x18\n" +
+	"\aaddress\x18\x01 \x01(\fR\aaddress\x12\x16\n" +
+	"\x06issuer\x18\x02 \x01(\fR\x06issuer\x12\x18\n" +
+	"\abalance\x18\x03 \x01(\x04R\abalance\x12\x15\n" +
+	"\x06tx_cnt\x18\x04 \x01(\rR\x05txCnt\x12\x1d\n" +
+	"\n" +
+	"is_staking\x18\x05 \x01(\bR\tisStaking\x120\n" +
+	"\x14staking_block_height\x18\x06 \x01(\rR\x12stakingBlockHeight\x12\x17\n" +
+	"\ais_root\x18\a \x01(\bR\x06isRoot\x12%\n" +
+	"\x0ecommitment_key\x18\b \x01(\fR\rcommitmentKey\x12\x1a\n" +
+	"\bcontract\x18\t \x01(\fR\bcontract\x12-\n" +
+	"\x12contract_variables\x18\n" +
+	" \x03(\fR\x11contractVariables\x12\x1c\n" +
+	"\tthreshold\x18\v \x01(\rR\tthreshold\x12\x1c\n" +
+	"\tcosigners\x18\f \x03(\fR\tcosigners\x12(\n" +
+	"\x06device\x18\r \x01(\v2\x10.bazo.DeviceInfoR\x06device\x12\x1d\n" +
+	"\n" +
+	"iot_tokens\x18\x0e \x01(\rR\tiotTokens\x12*\n" +
+	"\x11iot_refill_height\x18\x0f \x01(\rR\x0fiotRefillHeight\x12)\n" +
+	"\x10unbonding_height\x18\x10 \x01(\rR\x0funbondingHeight\"\x81\x02\n" +
+	"\x0fSubmitTxRequest\x12%\n" +
+	"\x05funds\x18\x01 \x01(\v2\r.bazo.FundsTxH\x00R\x05funds\x12\x1f\n" +
+	"\x03acc\x18\x02 \x01(\v2\v.bazo.AccTxH\x00R\x03acc\x12(\n" +
+	"\x06config\x18\x03 \x01(\v2\x0e.bazo.ConfigTxH\x00R\x06config\x12%\n" +
+	"\x05stake\x18\x04 \x01(\v2\r.bazo.StakeTxH\x00R\x05stake\x12\x1f\n" +
+	"\x03iot\x18\x05 \x01(\v2\v.bazo.IotTxH\x00R\x03iot\x12.\n" +
+	"\bcontract\x18\x06 \x01(\v2\x10.bazo.ContractTxH\x00R\bcontractB\x04\n" +
+	"\x02tx\"+\n" +
+	"\x10SubmitTxResponse\x12\x17\n" +
+	"\atx_hash\x18\x01 \x01(\fR\x06txHash\"!\n" +
+	"\vHashRequest\x12\x12\n" +
+	"\x04hash\x18\x01 \x01(\fR\x04hash\"\x0f\n" +
+	"\rGetTipRequest2\xf8\x01\n" +
+	"\x04Bazo\x129\n" +
+	"\bSubmitTx\x12\x15.bazo.SubmitTxRequest\x1a\x16.bazo.SubmitTxResponse\x12*\n" +
+	"\x06GetTip\x12\x13.bazo.GetTipRequest\x1a\v.bazo.Block\x12*\n" +
+	"\bGetBlock\x12\x11.bazo.HashRequest\x1a\v.bazo.Block\x12-\n" +
+	"\x05GetTx\x12\x11.bazo.HashRequest\x1a\x11.bazo.Transaction\x12.\n" +
+	"\n" +
+	"GetAccount\x12\x11.bazo.HashRequest\x1a\r.bazo.AccountB3Z1github.com/bazo-blockchain/bazo-miner/protocol/pbb\x06proto3"
+
+var (
+	file_bazo_proto_rawDescOnce sync.Once
+	file_bazo_proto_rawDescData []byte
+)
+
+func file_bazo_proto_rawDescGZIP() []byte {
+	file_bazo_proto_rawDescOnce.Do(func() {
+		file_bazo_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_bazo_proto_rawDesc), len(file_bazo_proto_rawDesc)))
+	})
+	return file_bazo_proto_rawDescData
+}
+
+var file_bazo_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
+var file_bazo_proto_goTypes = []any{
+	(*FundsTx)(nil),           // 0: bazo.FundsTx
+	(*Cosignature)(nil),       // 1: bazo.Cosignature
+	(*DeviceInfo)(nil),        // 2: bazo.DeviceInfo
+	(*AccTx)(nil),             // 3: bazo.AccTx
+	(*ConfigTx)(nil),          // 4: bazo.ConfigTx
+	(*ConfigCosignature)(nil), // 5: bazo.ConfigCosignature
+	(*StakeTx)(nil),           // 6: bazo.StakeTx
+	(*AggTx)(nil),             // 7: bazo.AggTx
+	(*IotTx)(nil),             // 8: bazo.IotTx
+	(*ContractTx)(nil),        // 9: bazo.ContractTx
+	(*Transaction)(nil),       // 10: bazo.Transaction
+	(*Block)(nil),             // 11: bazo.Block
+	(*Account)(nil),           // 12: bazo.Account
+	(*SubmitTxRequest)(nil),   // 13: bazo.SubmitTxRequest
+	(*SubmitTxResponse)(nil),  // 14: bazo.SubmitTxResponse
+	(*HashRequest)(nil),       // 15: bazo.HashRequest
+	(*GetTipRequest)(nil),     // 16: bazo.GetTipRequest
+}
+var file_bazo_proto_depIdxs = []int32{
+	1,  // 0: bazo.FundsTx.cosigs:type_name -> bazo.Cosignature
+	2,  // 1: bazo.AccTx.device:type_name -> bazo.DeviceInfo
+	5,  // 2: bazo.ConfigTx.cosigs:type_name -> bazo.ConfigCosignature
+	0,  // 3: bazo.Transaction.funds:type_name -> bazo.FundsTx
+	3,  // 4: bazo.Transaction.acc:type_name -> bazo.AccTx
+	4,  // 5: bazo.Transaction.config:type_name -> bazo.ConfigTx
+	6,  // 6: bazo.Transaction.stake:type_name -> bazo.StakeTx
+	7,  // 7: bazo.Transaction.agg:type_name -> bazo.AggTx
+	8,  // 8: bazo.Transaction.iot:type_name -> bazo.IotTx
+	9,  // 9: bazo.Transaction.contract:type_name -> bazo.ContractTx
+	2,  // 10: bazo.Account.device:type_name -> bazo.DeviceInfo
+	0,  // 11: bazo.SubmitTxRequest.funds:type_name -> bazo.FundsTx
+	3,  // 12: bazo.SubmitTxRequest.acc:type_name -> bazo.AccTx
+	4,  // 13: bazo.SubmitTxRequest.config:type_name -> bazo.ConfigTx
+	6,  // 14: bazo.SubmitTxRequest.stake:type_name -> bazo.StakeTx
+	8,  // 15: bazo.SubmitTxRequest.iot:type_name -> bazo.IotTx
+	9,  // 16: bazo.SubmitTxRequest.contract:type_name -> bazo.ContractTx
+	13, // 17: bazo.Bazo.SubmitTx:input_type -> bazo.SubmitTxRequest
+	16, // 18: bazo.Bazo.GetTip:input_type -> bazo.GetTipRequest
+	15, // 19: bazo.Bazo.GetBlock:input_type -> bazo.HashRequest
+	15, // 20: bazo.Bazo.GetTx:input_type -> bazo.HashRequest
+	15, // 21: bazo.Bazo.GetAccount:input_type -> bazo.HashRequest
+	14, // 22: bazo.Bazo.SubmitTx:output_type -> bazo.SubmitTxResponse
+	11, // 23: bazo.Bazo.GetTip:output_type -> bazo.Block
+	11, // 24: bazo.Bazo.GetBlock:output_type -> bazo.Block
+	10, // 25: bazo.Bazo.GetTx:output_type -> bazo.Transaction
+	12, // 26: bazo.Bazo.GetAccount:output_type -> bazo.Account
+	22, // [22:27] is the sub-list for method output_type
+	17, // [17:22] is the sub-list for method input_type
+	17, // [17:17] is the sub-list for extension type_name
+	17, // [17:17] is the sub-list for extension extendee
+	0,  // [0:17] is the sub-list for field type_name
+}
+
+func init() { file_bazo_proto_init() }
+func file_bazo_proto_init() {
+	if File_bazo_proto != nil {
+		return
+	}
+	file_bazo_proto_msgTypes[10].OneofWrappers = []any{
+		(*Transaction_Funds)(nil),
+		(*Transaction_Acc)(nil),
+		(*Transaction_Config)(nil),
+		(*Transaction_Stake)(nil),
+		(*Transaction_Agg)(nil),
+		(*Transaction_Iot)(nil),
+		(*Transaction_Contract)(nil),
+	}
+	file_bazo_proto_msgTypes[13].OneofWrappers = []any{
+		(*SubmitTxRequest_Funds)(nil),
+		(*SubmitTxRequest_Acc)(nil),
+		(*SubmitTxRequest_Config)(nil),
+		(*SubmitTxRequest_Stake)(nil),
+		(*SubmitTxRequest_Iot)(nil),
+		(*SubmitTxRequest_Contract)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_bazo_proto_rawDesc), len(file_bazo_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   17,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_bazo_proto_goTypes,
+		DependencyIndexes: file_bazo_proto_depIdxs,
+		MessageInfos:      file_bazo_proto_msgTypes,
+	}.Build()
+	File_bazo_proto = out.File
+	file_bazo_proto_goTypes = nil
+	file_bazo_proto_depIdxs = nil
+}
@@ -0,0 +1,278 @@
+// Protobuf definitions of the Bazo transactions and blocks and the gRPC service of the miner, for clients which do not
+// want to reimplement the gob encoding. Hashes, addresses and signatures are raw bytes (32 resp. 64 bytes).
+//
+// The Go code is generated with `go generate ./protocol/pb` (requires protoc, protoc-gen-go and protoc-gen-go-grpc).
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: bazo.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Bazo_SubmitTx_FullMethodName   = "/bazo.Bazo/SubmitTx"
+	Bazo_GetTip_FullMethodName     = "/bazo.Bazo/GetTip"
+	Bazo_GetBlock_FullMethodName   = "/bazo.Bazo/GetBlock"
+	Bazo_GetTx_FullMethodName      = "/bazo.Bazo/GetTx"
+	Bazo_GetAccount_FullMethodName = "/bazo.Bazo/GetAccount"
+)
+
+// BazoClient is the client API for Bazo service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type BazoClient interface {
+	SubmitTx(ctx context.Context, in *SubmitTxRequest, opts ...grpc.CallOption) (*SubmitTxResponse, error)
+	GetTip(ctx context.Context, in *GetTipRequest, opts ...grpc.CallOption) (*Block, error)
+	GetBlock(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Block, error)
+	GetTx(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Transaction, error)
+	GetAccount(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Account, error)
+}
+
+type bazoClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewBazoClient(cc grpc.ClientConnInterface) BazoClient {
+	return &bazoClient{cc}
+}
+
+func (c *bazoClient) SubmitTx(ctx context.Context, in *SubmitTxRequest, opts ...grpc.CallOption) (*SubmitTxResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SubmitTxResponse)
+	err := c.cc.Invoke(ctx, Bazo_SubmitTx_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bazoClient) GetTip(ctx context.Context, in *GetTipRequest, opts ...grpc.CallOption) (*Block, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Block)
+	err := c.cc.Invoke(ctx, Bazo_GetTip_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bazoClient) GetBlock(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Block, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Block)
+	err := c.cc.Invoke(ctx, Bazo_GetBlock_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bazoClient) GetTx(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Transaction, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Transaction)
+	err := c.cc.Invoke(ctx, Bazo_GetTx_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *bazoClient) GetAccount(ctx context.Context, in *HashRequest, opts ...grpc.CallOption) (*Account, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Account)
+	err := c.cc.Invoke(ctx, Bazo_GetAccount_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BazoServer is the server API for Bazo service.
+// All implementations must embed UnimplementedBazoServer
+// for forward compatibility.
+type BazoServer interface {
+	SubmitTx(context.Context, *SubmitTxRequest) (*SubmitTxResponse, error)
+	GetTip(context.Context, *GetTipRequest) (*Block, error)
+	GetBlock(context.Context, *HashRequest) (*Block, error)
+	GetTx(context.Context, *HashRequest) (*Transaction, error)
+	GetAccount(context.Context, *HashRequest) (*Account, error)
+	mustEmbedUnimplementedBazoServer()
+}
+
+// UnimplementedBazoServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedBazoServer struct{}
+
+func (UnimplementedBazoServer) SubmitTx(context.Context, *SubmitTxRequest) (*SubmitTxResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitTx not implemented")
+}
+func (UnimplementedBazoServer) GetTip(context.Context, *GetTipRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTip not implemented")
+}
+func (UnimplementedBazoServer) GetBlock(context.Context, *HashRequest) (*Block, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBlock not implemented")
+}
+func (UnimplementedBazoServer) GetTx(context.Context, *HashRequest) (*Transaction, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTx not implemented")
+}
+func (UnimplementedBazoServer) GetAccount(context.Context, *HashRequest) (*Account, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAccount not implemented")
+}
+func (UnimplementedBazoServer) mustEmbedUnimplementedBazoServer() {}
+func (UnimplementedBazoServer) testEmbeddedByValue()              {}
+
+// UnsafeBazoServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to BazoServer will
+// result in compilation errors.
+type UnsafeBazoServer interface {
+	mustEmbedUnimplementedBazoServer()
+}
+
+func RegisterBazoServer(s grpc.ServiceRegistrar, srv BazoServer) {
+	// If the following call pancis, it indicates UnimplementedBazoServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Bazo_ServiceDesc, srv)
+}
+
+func _Bazo_SubmitTx_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitTxRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BazoServer).SubmitTx(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bazo_SubmitTx_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BazoServer).SubmitTx(ctx, req.(*SubmitTxRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bazo_GetTip_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetTipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BazoServer).GetTip(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bazo_GetTip_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BazoServer).GetTip(ctx, req.(*GetTipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bazo_GetBlock_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BazoServer).GetBlock(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bazo_GetBlock_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BazoServer).GetBlock(ctx, req.(*HashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bazo_GetTx_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BazoServer).GetTx(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bazo_GetTx_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BazoServer).GetTx(ctx, req.(*HashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Bazo_GetAccount_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BazoServer).GetAccount(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Bazo_GetAccount_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BazoServer).GetAccount(ctx, req.(*HashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Bazo_ServiceDesc is the grpc.ServiceDesc for Bazo service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Bazo_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "bazo.Bazo",
+	HandlerType: (*BazoServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitTx",
+			Handler:    _Bazo_SubmitTx_Handler,
+		},
+		{
+			MethodName: "GetTip",
+			Handler:    _Bazo_GetTip_Handler,
+		},
+		{
+			MethodName: "GetBlock",
+			Handler:    _Bazo_GetBlock_Handler,
+		},
+		{
+			MethodName: "GetTx",
+			Handler:    _Bazo_GetTx_Handler,
+		},
+		{
+			MethodName: "GetAccount",
+			Handler:    _Bazo_GetAccount_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "bazo.proto",
+}
@@ -0,0 +1,6 @@
+//Package pb contains the Go code generated from bazo.proto. The generated files are checked in and have to be
+//regenerated after changing bazo.proto. The gRPC server of the miner is only built with the grpc build tag
+//(go build -tags grpc).
+package pb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative bazo.proto
@@ -0,0 +1,30 @@
+package protocol
+
+import (
+	"golang.org/x/crypto/ed25519"
+)
+
+//Signs funds transactions of an account. The private key is either held by the process (KeySigner) or never leaves
+//a hardware wallet (see package ledger).
+type FundsTxSigner interface {
+	PublicKey() ed25519.PublicKey
+	SignFundsTx(tx *FundsTx) ([64]byte, error)
+}
+
+type KeySigner struct {
+	privKey ed25519.PrivateKey
+}
+
+func NewKeySigner(privKey ed25519.PrivateKey) *KeySigner {
+	return &KeySigner{privKey}
+}
+
+func (signer *KeySigner) PublicKey() ed25519.PublicKey {
+	return signer.privKey.Public().(ed25519.PublicKey)
+}
+
+func (signer *KeySigner) SignFundsTx(tx *FundsTx) (sig [64]byte, err error) {
+	txHash := tx.Hash()
+	copy(sig[:], ed25519.Sign(signer.privKey, txHash[:]))
+	return sig, nil
+}
@@ -0,0 +1,47 @@
+package protocol
+
+//A slashing proof shows that a validator produced two blocks on competing chains within the slashing window. Proofs
+//are included in a block (see Block.SlashedAddress) by the next validator, until then they are gossiped between the
+//miners. Height is the lower height of the two conflicting blocks, the oldest proof is included first since it leaves
+//the slashing window first.
+
+type SlashingProof struct {
+	SlashedAddress                 [32]byte
+	ConflictingBlockHash1          [32]byte
+	ConflictingBlockHash2          [32]byte
+	ConflictingBlockHashWithoutTx1 [32]byte
+	ConflictingBlockHashWithoutTx2 [32]byte
+	Height                         uint32
+}
+
+func (proof *SlashingProof) Encode() []byte {
+	if proof == nil {
+		return nil
+	}
+
+	enc := newEncoder()
+	enc.array(proof.SlashedAddress[:])
+	enc.array(proof.ConflictingBlockHash1[:])
+	enc.array(proof.ConflictingBlockHash2[:])
+	enc.array(proof.ConflictingBlockHashWithoutTx1[:])
+	enc.array(proof.ConflictingBlockHashWithoutTx2[:])
+	enc.uint32(proof.Height)
+
+	return enc.Bytes()
+}
+
+func (*SlashingProof) Decode(encoded []byte) (*SlashingProof, error) {
+	var decoded SlashingProof
+	dec := newDecoder(encoded)
+	dec.array(decoded.SlashedAddress[:])
+	dec.array(decoded.ConflictingBlockHash1[:])
+	dec.array(decoded.ConflictingBlockHash2[:])
+	dec.array(decoded.ConflictingBlockHashWithoutTx1[:])
+	dec.array(decoded.ConflictingBlockHashWithoutTx2[:])
+	decoded.Height = dec.uint32()
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
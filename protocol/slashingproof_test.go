@@ -0,0 +1,28 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSlashingProofSerialization(t *testing.T) {
+	proof := &SlashingProof{
+		SlashedAddress:                 [32]byte{0x01},
+		ConflictingBlockHash1:          [32]byte{0x02},
+		ConflictingBlockHash2:          [32]byte{0x03},
+		ConflictingBlockHashWithoutTx1: [32]byte{0x04},
+		ConflictingBlockHashWithoutTx2: [32]byte{0x05},
+		Height:                         42,
+	}
+
+	var decoded *SlashingProof
+	decoded, _ = decoded.Decode(proof.Encode())
+	if !reflect.DeepEqual(proof, decoded) {
+		t.Errorf("SlashingProof serialization failed (%v) vs. (%v)\n", proof, decoded)
+	}
+
+	encoded := proof.Encode()
+	if _, err := decoded.Decode(encoded[:len(encoded)-1]); err == nil {
+		t.Error("Truncated slashing proof was decoded.\n")
+	}
+}
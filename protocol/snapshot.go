@@ -0,0 +1,138 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"golang.org/x/crypto/sha3"
+)
+
+//A snapshot contains everything a node needs to continue the chain from a given block without replaying it: the
+//account state after the block, the block itself and its predecessors needed for the proof of stake, and the system
+//parameters and difficulty in effect. Snapshots are not part of consensus and can not be verified against the chain,
+//nodes should only import snapshots from a source they trust.
+
+const SNAPSHOT_VERSION = 1
+
+type Snapshot struct {
+	Version   uint32
+	Height    uint32
+	BlockHash [32]byte
+
+	//Encoded blocks, the snapshot block first followed by its predecessors
+	Blocks [][]byte
+
+	//Encoded accounts and the hashes of the root accounts among them
+	Accounts [][]byte
+	RootKeys [][32]byte
+
+	Parameters SnapshotParameters
+
+	//Difficulty history and progress of the current difficulty interval
+	Target           []uint8
+	TargetTimeFirst  int64
+	GlobalBlockCount int64
+	LocalBlockCount  int64
+
+	//Encoded validator set of the epoch above the snapshot block, added later.
+	ValidatorSet []byte
+
+	//Encoded liveness evidence of the previous epochs needed for the next validator set, added later.
+	LivenessEvidence [][]byte
+
+	//Parameter changes which are not active yet at the snapshot's height, added later.
+	PendingParameters []SnapshotParameters
+
+	//Average block time in milliseconds if the difficulty follows an exponential moving average, added later.
+	BlockTimeEMA int64
+}
+
+type SnapshotParameters struct {
+	BlockHash             [32]byte
+	FeeMinimum            uint64
+	BlockSize             uint64
+	DiffInterval          uint64
+	BlockInterval         uint64
+	BlockReward           uint64
+	StakingMinimum        uint64
+	WaitingMinimum        uint64
+	AcceptedTimeDiff      uint64
+	SlashingWindowSize    uint64
+	SlashReward           uint64
+	NumIncludedPrevProofs int
+
+	//Added later, snapshots without them decode to 0 (the defaults).
+	BlockTriggerTxs       uint64
+	BlockTriggerTime      uint64
+	IotRateCapacity       uint64
+	IotRateRefill         uint64
+	UnbondingPeriod       uint64
+	RsaCommitmentDeadline uint64
+	SlashFraction         uint64
+	ConfigQuorum          uint64
+	ConfigActivationDelay uint64
+	DiffEmaWindow         uint64
+	StateRootHeight       uint64
+	TxOrderHeight         uint64
+	AggTxAuthHeight       uint64
+	MedianTimeHeight      uint64
+	BlockHashHeight       uint64
+	DelegationHeight      uint64
+	//Height above which the parameters apply, only set for pending parameters.
+	Height uint32
+}
+
+//The encoding is prefixed with the hash of the gob encoded snapshot to detect corrupted files and transfers.
+func (snapshot *Snapshot) Encode() []byte {
+	if snapshot == nil {
+		return nil
+	}
+
+	buffer := new(bytes.Buffer)
+	gob.NewEncoder(buffer).Encode(snapshot)
+	checksum := sha3.Sum256(buffer.Bytes())
+
+	return append(checksum[:], buffer.Bytes()...)
+}
+
+//Returns nil if the checksum does not match or the snapshot has an unsupported version.
+func (*Snapshot) Decode(encoded []byte) (*Snapshot, error) {
+	if len(encoded) <= 32 {
+		return nil, errors.New(fmt.Sprintf("Snapshot too short (%v bytes).", len(encoded)))
+	}
+
+	var checksum [32]byte
+	copy(checksum[:], encoded[:32])
+	if sha3.Sum256(encoded[32:]) != checksum {
+		return nil, errors.New("Snapshot checksum mismatch.")
+	}
+
+	var decoded Snapshot
+	if err := gob.NewDecoder(bytes.NewBuffer(encoded[32:])).Decode(&decoded); err != nil {
+		return nil, err
+	}
+
+	if decoded.Version != SNAPSHOT_VERSION {
+		return nil, errors.New(fmt.Sprintf("Unsupported snapshot version %v.", decoded.Version))
+	}
+
+	return &decoded, nil
+}
+
+func (snapshot Snapshot) String() string {
+	return fmt.Sprintf(
+		"\nVersion: %v\n"+
+			"Height: %v\n"+
+			"Block Hash: %x\n"+
+			"Blocks: %v\n"+
+			"Accounts: %v\n"+
+			"Root Keys: %v\n",
+		snapshot.Version,
+		snapshot.Height,
+		snapshot.BlockHash[0:8],
+		len(snapshot.Blocks),
+		len(snapshot.Accounts),
+		len(snapshot.RootKeys),
+	)
+}
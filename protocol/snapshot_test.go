@@ -0,0 +1,37 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSnapshotSerialization(t *testing.T) {
+	acc := Account{Address: [32]byte{0x01}, Balance: 100, TxCnt: 3}
+	block := Block{Hash: [32]byte{0x0b}, Height: 42}
+
+	snapshot := &Snapshot{
+		Version:    SNAPSHOT_VERSION,
+		Height:     block.Height,
+		BlockHash:  block.Hash,
+		Blocks:     [][]byte{block.Encode()},
+		Accounts:   [][]byte{acc.Encode()},
+		RootKeys:   [][32]byte{acc.Hash()},
+		Parameters: SnapshotParameters{FeeMinimum: 1, DiffInterval: 10},
+		Target:     []uint8{8, 9},
+	}
+
+	encoded := snapshot.Encode()
+	if decoded, _ := snapshot.Decode(encoded); !reflect.DeepEqual(snapshot, decoded) {
+		t.Errorf("Snapshot serialization failed (%v) vs. (%v)\n", snapshot, decoded)
+	}
+
+	encoded[len(encoded)-1] ^= 0xff
+	if _, err := snapshot.Decode(encoded); err == nil {
+		t.Error("Corrupted snapshot decoded.\n")
+	}
+
+	snapshot.Version = SNAPSHOT_VERSION + 1
+	if _, err := snapshot.Decode(snapshot.Encode()); err == nil {
+		t.Error("Snapshot with unsupported version decoded.\n")
+	}
+}
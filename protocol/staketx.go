@@ -3,6 +3,7 @@ package protocol
 import (
 	"crypto/rsa"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/crypto"
 	"golang.org/x/crypto/ed25519"
@@ -20,10 +21,21 @@ type StakeTx struct {
 	IsStaking     bool                  // 1 Byte
 	Account       [32]byte              // 32 Byte
 	Sig           [64]byte              // 64 Byte
-	CommitmentKey [crypto.COMM_KEY_LENGTH]byte // the modulus N of the RSA public key
+	CommitmentKey [crypto.COMM_KEY_LENGTH]byte // the modulus N of the RSA public key or the VRF key, see crypto/vrf.go
 }
 
 func ConstrStakeTx(header byte, fee uint64, isStaking bool, account [32]byte, signKey ed25519.PrivateKey, commPubKey *rsa.PublicKey) (tx *StakeTx, err error) {
+	var commKey [crypto.COMM_KEY_LENGTH]byte
+	copy(commKey[:], commPubKey.N.Bytes())
+
+	return constrStakeTx(header, fee, isStaking, account, signKey, commKey)
+}
+
+func ConstrVRFStakeTx(header byte, fee uint64, isStaking bool, account [32]byte, signKey ed25519.PrivateKey, vrfPubKey ed25519.PublicKey) (tx *StakeTx, err error) {
+	return constrStakeTx(header, fee, isStaking, account, signKey, crypto.VRFCommitmentKey(vrfPubKey))
+}
+
+func constrStakeTx(header byte, fee uint64, isStaking bool, account [32]byte, signKey ed25519.PrivateKey, commKey [crypto.COMM_KEY_LENGTH]byte) (tx *StakeTx, err error) {
 
 	tx = new(StakeTx)
 
@@ -31,8 +43,7 @@ func ConstrStakeTx(header byte, fee uint64, isStaking bool, account [32]byte, si
 	tx.Fee = fee
 	tx.IsStaking = isStaking
 	tx.Account = account
-
-	copy(tx.CommitmentKey[:], commPubKey.N.Bytes())
+	tx.CommitmentKey = commKey
 
 	txHash := tx.Hash()
 
@@ -96,29 +107,27 @@ func (tx *StakeTx) Encode() (encodedTx []byte) {
 	return encodedTx
 }
 
-func (*StakeTx) Decode(encodedTx []byte) (tx *StakeTx) {
-	tx = new(StakeTx)
-
+func (*StakeTx) Decode(encodedTx []byte) (*StakeTx, error) {
 	if len(encodedTx) != STAKETX_SIZE {
-		return nil
+		return nil, errors.New(fmt.Sprintf("Stake tx of %v bytes, expected %v.", len(encodedTx), STAKETX_SIZE))
 	}
 
-	var isStakingAsByte byte
-
+	tx := new(StakeTx)
 	tx.Header = encodedTx[0]
 	tx.Fee = binary.BigEndian.Uint64(encodedTx[1:9])
-	isStakingAsByte = encodedTx[9]
-	copy(tx.Account[:], encodedTx[10:42])
-	copy(tx.Sig[:], encodedTx[42:106])
-	copy(tx.CommitmentKey[:], encodedTx[106:106+crypto.COMM_KEY_LENGTH])
-
-	if isStakingAsByte == 0 {
+	switch encodedTx[9] {
+	case 0:
 		tx.IsStaking = false
-	} else {
+	case 1:
 		tx.IsStaking = true
+	default:
+		return nil, errors.New(fmt.Sprintf("Invalid isStaking value %v.", encodedTx[9]))
 	}
+	copy(tx.Account[:], encodedTx[10:42])
+	copy(tx.Sig[:], encodedTx[42:106])
+	copy(tx.CommitmentKey[:], encodedTx[106:106+crypto.COMM_KEY_LENGTH])
 
-	return tx
+	return tx, nil
 }
 
 func (tx *StakeTx) TxFee() uint64 { return tx.Fee }
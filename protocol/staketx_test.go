@@ -18,7 +18,7 @@ func TestStakeTxSerialization(t *testing.T) {
 		tx, _ := ConstrStakeTx(0x01, fee, isStaking, accAHash, PrivKeyA, &CommitmentKeyA.PublicKey)
 		data := tx.Encode()
 		var decodedTx *StakeTx
-		decodedTx = decodedTx.Decode(data)
+		decodedTx, _ = decodedTx.Decode(data)
 
 		//this is done by verify() which is outside protocol package, we're just testing serialization here
 		//decodedTx.Fee = fee
@@ -0,0 +1,205 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+
+	"golang.org/x/crypto/sha3"
+)
+
+//The state root commits to all accounts of the state with a sparse Merkle tree. The accounts are the leaves, placed by
+//the bits of their key (the hash of the address, i.e., the key of the state) from the most significant bit on. A
+//subtree holding a single account is replaced by the account's leaf, so leaves sit at the depth where their key prefix
+//becomes unique and proofs are about as long as the logarithm of the number of accounts. Empty subtrees hash to zero.
+//The root only depends on the set of accounts, not on the order in which they were added.
+//A StateProof proves that an account is part of the state, or that no account with the key exists.
+
+const (
+	stateLeafPrefix  = 0x00
+	stateInnerPrefix = 0x01
+)
+
+type StateTrie struct {
+	//Sorted keys, the hashes of their leaves and the encoded accounts.
+	keys     [][32]byte
+	leaves   map[[32]byte][32]byte
+	accounts map[[32]byte][]byte
+	root     [32]byte
+}
+
+func NewStateTrie(accounts map[[32]byte]*Account) *StateTrie {
+	trie := &StateTrie{leaves: make(map[[32]byte][32]byte, len(accounts)), accounts: make(map[[32]byte][]byte, len(accounts))}
+	for key, acc := range accounts {
+		encodedAcc := acc.Encode()
+		trie.keys = append(trie.keys, key)
+		trie.leaves[key] = stateLeafHashEncoded(key, encodedAcc)
+		trie.accounts[key] = encodedAcc
+	}
+	sort.Slice(trie.keys, func(i, j int) bool {
+		return bytes.Compare(trie.keys[i][:], trie.keys[j][:]) < 0
+	})
+	trie.root = trie.subtreeHash(trie.keys, 0)
+
+	return trie
+}
+
+func (trie *StateTrie) Root() [32]byte {
+	return trie.root
+}
+
+//Returns the account with the key as it was when the trie was built, nil if there is none.
+func (trie *StateTrie) Account(key [32]byte) *Account {
+	encodedAcc, exists := trie.accounts[key]
+	if !exists {
+		return nil
+	}
+
+	var acc *Account
+	acc, _ = acc.Decode(encodedAcc)
+	return acc
+}
+
+//Returns the proof of the account with the key, or the proof that there is none.
+func (trie *StateTrie) Proof(key [32]byte) *StateProof {
+	proof := new(StateProof)
+	keys := trie.keys
+	for depth := 0; len(keys) > 1; depth++ {
+		left, right := splitKeys(keys, depth)
+		if keyBit(key, depth) == 0 {
+			proof.Siblings = append(proof.Siblings, trie.subtreeHash(right, depth+1))
+			keys = left
+		} else {
+			proof.Siblings = append(proof.Siblings, trie.subtreeHash(left, depth+1))
+			keys = right
+		}
+	}
+
+	//The path of the key ends in an empty subtree or a leaf, which is the account itself or another one.
+	if len(keys) == 1 && keys[0] != key {
+		proof.OtherKey = keys[0]
+		proof.OtherLeaf = trie.leaves[keys[0]]
+		proof.HasOther = true
+	}
+
+	return proof
+}
+
+func (trie *StateTrie) subtreeHash(keys [][32]byte, depth int) [32]byte {
+	switch len(keys) {
+	case 0:
+		return [32]byte{}
+	case 1:
+		return trie.leaves[keys[0]]
+	}
+
+	left, right := splitKeys(keys, depth)
+	return stateInnerHash(trie.subtreeHash(left, depth+1), trie.subtreeHash(right, depth+1))
+}
+
+//Splits sorted keys sharing the first depth bits by the bit at depth.
+func splitKeys(keys [][32]byte, depth int) (left [][32]byte, right [][32]byte) {
+	i := sort.Search(len(keys), func(i int) bool {
+		return keyBit(keys[i], depth) == 1
+	})
+
+	return keys[:i], keys[i:]
+}
+
+func keyBit(key [32]byte, depth int) byte {
+	return (key[depth/8] >> uint(7-depth%8)) & 1
+}
+
+func stateLeafHash(key [32]byte, acc *Account) [32]byte {
+	return stateLeafHashEncoded(key, acc.Encode())
+}
+
+func stateLeafHashEncoded(key [32]byte, encodedAcc []byte) [32]byte {
+	accHash := sha3.Sum256(encodedAcc)
+	return sha3.Sum256(append(append([]byte{stateLeafPrefix}, key[:]...), accHash[:]...))
+}
+
+func stateInnerHash(left [32]byte, right [32]byte) [32]byte {
+	return sha3.Sum256(append(append([]byte{stateInnerPrefix}, left[:]...), right[:]...))
+}
+
+type StateProof struct {
+	//From the root down to the end of the key's path.
+	Siblings [][32]byte
+	//Set if the path ends in the leaf of another account, which proves that the key is not part of the state.
+	HasOther  bool
+	OtherKey  [32]byte
+	OtherLeaf [32]byte
+}
+
+//Checks that the account with the key is part of the state with the root. A nil account checks that there is none.
+func (proof *StateProof) Verify(root [32]byte, key [32]byte, acc *Account) error {
+	if len(proof.Siblings) > 256 {
+		return errors.New("State proof is too long.")
+	}
+
+	var hash [32]byte
+	switch {
+	case acc != nil && proof.HasOther:
+		return errors.New("State proof is a proof of absence.")
+	case acc != nil:
+		hash = stateLeafHash(key, acc)
+	case proof.HasOther:
+		if proof.OtherKey == key {
+			return errors.New("State proof contains the account.")
+		}
+		//The other leaf must be on the path of the key, otherwise it would not be where the account would be.
+		for depth := range proof.Siblings {
+			if keyBit(proof.OtherKey, depth) != keyBit(key, depth) {
+				return errors.New("State proof does not end on the path of the key.")
+			}
+		}
+		hash = proof.OtherLeaf
+	}
+
+	for depth := len(proof.Siblings) - 1; depth >= 0; depth-- {
+		if keyBit(key, depth) == 0 {
+			hash = stateInnerHash(hash, proof.Siblings[depth])
+		} else {
+			hash = stateInnerHash(proof.Siblings[depth], hash)
+		}
+	}
+
+	if hash != root {
+		return errors.New("State proof does not match the state root.")
+	}
+
+	return nil
+}
+
+func (proof *StateProof) Encode() []byte {
+	if proof == nil {
+		return nil
+	}
+
+	enc := newEncoder()
+	enc.hashes(proof.Siblings)
+	enc.bool(proof.HasOther)
+	if proof.HasOther {
+		enc.array(proof.OtherKey[:])
+		enc.array(proof.OtherLeaf[:])
+	}
+
+	return enc.Bytes()
+}
+
+func (*StateProof) Decode(encoded []byte) (*StateProof, error) {
+	var decoded StateProof
+	dec := newDecoder(encoded)
+	decoded.Siblings = dec.hashes()
+	decoded.HasOther = dec.bool()
+	if decoded.HasOther {
+		dec.array(decoded.OtherKey[:])
+		dec.array(decoded.OtherLeaf[:])
+	}
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+
+	return &decoded, nil
+}
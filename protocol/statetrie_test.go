@@ -0,0 +1,65 @@
+package protocol
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestStateTrie(t *testing.T) {
+	if root := NewStateTrie(nil).Root(); root != [32]byte{} {
+		t.Errorf("Root of the empty state should be zero: %x\n", root)
+	}
+
+	accounts := make(map[[32]byte]*Account)
+	for i := 0; i < 50; i++ {
+		acc := &Account{Balance: uint64(i), TxCnt: uint32(i)}
+		rand.Read(acc.Address[:])
+		accounts[acc.Hash()] = acc
+	}
+	trie := NewStateTrie(accounts)
+
+	//The root only depends on the accounts.
+	if NewStateTrie(accounts).Root() != trie.Root() {
+		t.Error("Root depends on the order of the accounts.")
+	}
+
+	for key, acc := range accounts {
+		proof := trie.Proof(key)
+		if err := proof.Verify(trie.Root(), key, acc); err != nil {
+			t.Fatalf("Proof of account %x not verified: %v\n", key[0:8], err)
+		}
+
+		changed := *acc
+		changed.Balance++
+		if proof.Verify(trie.Root(), key, &changed) == nil || proof.Verify(trie.Root(), key, nil) == nil {
+			t.Fatalf("Proof of account %x verified a different account.\n", key[0:8])
+		}
+
+		var decoded *StateProof
+		if decoded, _ = decoded.Decode(proof.Encode()); decoded == nil || decoded.Verify(trie.Root(), key, acc) != nil {
+			t.Fatalf("Proof of account %x changed by encoding.\n", key[0:8])
+		}
+	}
+
+	//Proofs of absence end in an empty subtree or in the leaf of another account.
+	for i := 0; i < 50; i++ {
+		var key [32]byte
+		rand.Read(key[:])
+		proof := trie.Proof(key)
+		if err := proof.Verify(trie.Root(), key, nil); err != nil {
+			t.Fatalf("Proof of absence of %x not verified: %v\n", key[0:8], err)
+		}
+		if proof.Verify(trie.Root(), key, &Account{}) == nil {
+			t.Fatalf("Proof of absence of %x verified an account.\n", key[0:8])
+		}
+	}
+
+	//An account can not be proven absent with the proof of another account.
+	var keys [][32]byte
+	for key := range accounts {
+		keys = append(keys, key)
+	}
+	if trie.Proof(keys[1]).Verify(trie.Root(), keys[0], nil) == nil {
+		t.Errorf("Account %x proven absent with the proof of %x.\n", keys[0][0:8], keys[1][0:8])
+	}
+}
@@ -0,0 +1,88 @@
+package protocol
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/crypto"
+	"sort"
+)
+
+//The validator set of an epoch of blocks: the accounts which were staking with at least the staking minimum after the
+//last block of the previous epoch. The proposers of the epoch's blocks are validated against it instead of the current
+//state, so who can propose a block and with which balance is fixed for the whole epoch.
+
+type Validator struct {
+	Address       [32]byte //Hash of the account
+	Balance       uint64
+	CommitmentKey [crypto.COMM_KEY_LENGTH]byte
+}
+
+type ValidatorSet struct {
+	//Height of the last block of the previous epoch, the state after it was used.
+	Height     uint32
+	Validators []*Validator //Sorted by address
+}
+
+func NewValidatorSet(height uint32, validators []*Validator) *ValidatorSet {
+	sort.Slice(validators, func(i, j int) bool {
+		return bytes.Compare(validators[i].Address[:], validators[j].Address[:]) < 0
+	})
+
+	return &ValidatorSet{height, validators}
+}
+
+//Returns nil if the address is not part of the set.
+func (set *ValidatorSet) Get(address [32]byte) *Validator {
+	i := sort.Search(len(set.Validators), func(i int) bool {
+		return bytes.Compare(set.Validators[i].Address[:], address[:]) >= 0
+	})
+	if i < len(set.Validators) && set.Validators[i].Address == address {
+		return set.Validators[i]
+	}
+
+	return nil
+}
+
+func (set *ValidatorSet) Encode() []byte {
+	if set == nil {
+		return nil
+	}
+
+	enc := newEncoder()
+	enc.uint32(set.Height)
+	enc.uint32(uint32(len(set.Validators)))
+	for _, validator := range set.Validators {
+		enc.array(validator.Address[:])
+		enc.uint64(validator.Balance)
+		enc.array(validator.CommitmentKey[:])
+	}
+
+	return enc.Bytes()
+}
+
+func (*ValidatorSet) Decode(encoded []byte) (*ValidatorSet, error) {
+	var decoded ValidatorSet
+	dec := newDecoder(encoded)
+	decoded.Height = dec.uint32()
+	nrValidators := dec.uint32()
+	for i := uint32(0); i < nrValidators && dec.more(); i++ {
+		validator := new(Validator)
+		dec.array(validator.Address[:])
+		validator.Balance = dec.uint64()
+		dec.array(validator.CommitmentKey[:])
+		decoded.Validators = append(decoded.Validators, validator)
+	}
+	if err := dec.finish(); err != nil {
+		return nil, err
+	}
+	if uint32(len(decoded.Validators)) != nrValidators {
+		return nil, errors.New(fmt.Sprintf("%v of %v validators encoded.", len(decoded.Validators), nrValidators))
+	}
+
+	return &decoded, nil
+}
+
+func (set ValidatorSet) String() string {
+	return fmt.Sprintf("Height: %v, Validators: %v", set.Height, len(set.Validators))
+}
@@ -0,0 +1,32 @@
+package protocol
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestValidatorSet(t *testing.T) {
+	set := NewValidatorSet(100, []*Validator{
+		{Address: [32]byte{0x03}, Balance: 3000},
+		{Address: [32]byte{0x01}, Balance: 1000},
+		{Address: [32]byte{0x02}, Balance: 2000},
+	})
+	set.Validators[0].CommitmentKey[0] = 0x04
+
+	if validator := set.Get([32]byte{0x02}); validator == nil || validator.Balance != 2000 {
+		t.Errorf("Validator not found: %v\n", validator)
+	}
+	if set.Get([32]byte{0x04}) != nil {
+		t.Error("Address not part of the set was found.")
+	}
+
+	var decoded *ValidatorSet
+	if decoded, _ = decoded.Decode(set.Encode()); !reflect.DeepEqual(set, decoded) {
+		t.Errorf("ValidatorSet serialization failed (%v) vs. (%v)\n", set, decoded)
+	}
+
+	encoded := set.Encode()
+	if _, err := decoded.Decode(encoded[:len(encoded)-1]); err == nil {
+		t.Error("Truncated validator set was decoded.")
+	}
+}
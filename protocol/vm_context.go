@@ -4,10 +4,21 @@ import (
 	"errors"
 )
 
+//Depth of nested contract calls, a contract calling itself (directly or not) is stopped here.
+const MAX_CALL_DEPTH = 8
+
 type Context struct {
 	Account
 	changes []Change
+	logs    []*Log
 	FundsTx
+	//Returns the accounts of the contracts that can be called, from the state copy of the block the tx is added to.
+	loadAccount func(hash [32]byte) *Account
+	//Balances changed by the calls of the tx (including the incoming amount), written to the state on PersistChanges.
+	balances map[[32]byte]uint64
+	calls    []*Context
+	depth    int
+	txHash   [32]byte
 }
 
 type Change struct {
@@ -23,15 +34,86 @@ func (c *Change) GetChange() (int, []byte) {
 	return c.index, c.value
 }
 
-func NewContext(account Account, fundsTx FundsTx) *Context {
+func NewContext(account Account, fundsTx FundsTx, loadAccount func(hash [32]byte) *Account) *Context {
 	newContext := Context{
-		Account: account,
-		changes: []Change{},
-		FundsTx: fundsTx,
+		Account:     account,
+		changes:     []Change{},
+		FundsTx:     fundsTx,
+		loadAccount: loadAccount,
+		balances:    map[[32]byte]uint64{fundsTx.To: account.Balance + fundsTx.Amount},
+		txHash:      fundsTx.Hash(),
 	}
 	return &newContext
 }
 
+//Creates the context of a call of the contract at address by this context's contract, transferring value from this
+//contract to the called one. The call is a snapshot, it only takes effect once committed (see Commit), so a failed
+//call leaves the state as it was before.
+func (c *Context) Call(address [32]byte, value uint64, data []byte, gasLimit uint64) (*Context, error) {
+	if c.depth+1 >= MAX_CALL_DEPTH {
+		return nil, errors.New("Call depth exceeded")
+	}
+
+	acc := c.account(address)
+	if acc == nil || acc.Contract == nil {
+		return nil, errors.New("Called account is not a contract")
+	}
+
+	if c.balance(c.To) < value {
+		return nil, errors.New("Insufficient balance for value transfer")
+	}
+
+	balances := make(map[[32]byte]uint64, len(c.balances)+1)
+	for hash, balance := range c.balances {
+		balances[hash] = balance
+	}
+
+	call := &Context{
+		Account:     *acc,
+		changes:     []Change{},
+		FundsTx:     FundsTx{From: c.To, To: address, Amount: value, Data: data, GasLimit: gasLimit},
+		loadAccount: c.loadAccount,
+		balances:    balances,
+		depth:       c.depth + 1,
+		txHash:      c.txHash,
+	}
+	//Read before debiting, the called contract may be the calling one.
+	calleeBalance := call.balance(address)
+	call.balances[c.To] = call.balance(c.To) - value
+	call.balances[address] = calleeBalance + value
+	if address == c.To {
+		call.balances[address] = calleeBalance
+	}
+
+	return call, nil
+}
+
+//Keeps the changes of a successful call, they are persisted with the ones of this context.
+func (c *Context) Commit(call *Context) {
+	c.balances = call.balances
+	c.logs = append(c.logs, call.logs...)
+	c.calls = append(c.calls, call)
+}
+
+func (c *Context) balance(hash [32]byte) uint64 {
+	if balance, exists := c.balances[hash]; exists {
+		return balance
+	}
+	if acc := c.account(hash); acc != nil {
+		return acc.Balance
+	}
+
+	return 0
+}
+
+func (c *Context) account(hash [32]byte) *Account {
+	if c.loadAccount == nil {
+		return nil
+	}
+
+	return c.loadAccount(hash)
+}
+
 func (c *Context) GetContract() []byte {
 	return c.Contract
 }
@@ -60,11 +142,43 @@ func (c *Context) SetContractVariable(index int, value []byte) error {
 	return nil
 }
 
+//The balances are only written by the context of the tx, after the tx's amount has been credited to the contract.
 func (c *Context) PersistChanges() {
 	for _, change := range c.changes {
 		i, value := change.GetChange()
 		c.ContractVariables[i] = value
 	}
+
+	for _, call := range c.calls {
+		call.PersistChanges()
+	}
+
+	if c.depth == 0 && len(c.calls) > 0 {
+		for hash, balance := range c.balances {
+			if acc := c.account(hash); acc != nil {
+				acc.Balance = balance
+			}
+		}
+	}
+}
+
+//Topics have been checked by the VM. The height and index of the logs are only known once the block is validated.
+func (c *Context) EmitLog(topics []ByteArray, data []byte) error {
+	if len(c.logs) >= MAX_LOGS_PER_TX {
+		return errors.New("Too many logs")
+	}
+
+	c.logs = append(c.logs, &Log{
+		Contract: c.To,
+		TxHash:   c.txHash,
+		Topics:   topics,
+		Data:     data,
+	})
+	return nil
+}
+
+func (c *Context) GetLogs() []*Log {
+	return c.logs
 }
 
 func (c *Context) GetAddress() [32]byte {
@@ -95,6 +209,10 @@ func (c *Context) GetFee() uint64 {
 	return c.Fee
 }
 
+func (c *Context) GetGasLimit() uint64 {
+	return c.GasLimit
+}
+
 func (c *Context) GetSig() [64]byte {
 	return c.Sig
 }
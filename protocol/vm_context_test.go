@@ -51,3 +51,26 @@ func TestVMContext_SetContractVariable_EncapsulationBreach(t *testing.T) {
 		t.Errorf("Expected result to be '%v' but was '%v'", expected, actual)
 	}
 }
+
+func TestVMContext_Call(t *testing.T) {
+	state := map[[32]byte]*Account{{0x02}: {Address: [32]byte{0x02}, Contract: []byte{0x00}}}
+	c := NewContext(Account{Address: [32]byte{0x01}}, FundsTx{To: [32]byte{0x01}, Amount: 10}, func(hash [32]byte) *Account { return state[hash] })
+
+	if _, err := c.Call([32]byte{0x02}, 11, nil, 0); err == nil {
+		t.Error("Expected call transferring more than the balance to fail")
+	}
+
+	if _, err := c.Call([32]byte{0x03}, 1, nil, 0); err == nil {
+		t.Error("Expected call of an unknown contract to fail")
+	}
+
+	call, _ := c.Call([32]byte{0x02}, 6, nil, 0)
+	if c.balance([32]byte{0x01}) != 10 {
+		t.Error("Expected uncommitted call not to change the balance")
+	}
+
+	c.Commit(call)
+	if _, err := c.Call([32]byte{0x02}, 5, nil, 0); err == nil {
+		t.Error("Expected committed transfer to reduce the balance")
+	}
+}
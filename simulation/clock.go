@@ -0,0 +1,85 @@
+package simulation
+
+import (
+	"container/heap"
+	"time"
+)
+
+//Time in a simulation only moves when it is advanced. Scheduled events fire in the order of their due time, events due
+//at the same time in the order they were scheduled, so a run is reproducible.
+
+type Clock struct {
+	now    time.Time
+	seq    uint64
+	events eventQueue
+}
+
+type event struct {
+	due time.Time
+	seq uint64
+	f   func()
+}
+
+func NewClock(start time.Time) *Clock {
+	return &Clock{now: start}
+}
+
+func (clock *Clock) Now() time.Time {
+	return clock.now
+}
+
+//Schedules f to be called once the clock has been advanced by d.
+func (clock *Clock) AfterFunc(d time.Duration, f func()) {
+	clock.seq++
+	heap.Push(&clock.events, &event{due: clock.now.Add(d), seq: clock.seq, f: f})
+}
+
+//Advances the clock by d and fires the events due until then, including the ones scheduled by fired events. Returns
+//the number of fired events.
+func (clock *Clock) Advance(d time.Duration) int {
+	end := clock.now.Add(d)
+
+	var fired int
+	for len(clock.events) > 0 && !clock.events[0].due.After(end) {
+		next := heap.Pop(&clock.events).(*event)
+		clock.now = next.due
+		next.f()
+		fired++
+	}
+	clock.now = end
+
+	return fired
+}
+
+//Returns the number of events which have not fired yet.
+func (clock *Clock) Pending() int {
+	return len(clock.events)
+}
+
+type eventQueue []*event
+
+func (queue eventQueue) Len() int {
+	return len(queue)
+}
+
+func (queue eventQueue) Less(i, j int) bool {
+	if queue[i].due.Equal(queue[j].due) {
+		return queue[i].seq < queue[j].seq
+	}
+	return queue[i].due.Before(queue[j].due)
+}
+
+func (queue eventQueue) Swap(i, j int) {
+	queue[i], queue[j] = queue[j], queue[i]
+}
+
+func (queue *eventQueue) Push(x interface{}) {
+	*queue = append(*queue, x.(*event))
+}
+
+func (queue *eventQueue) Pop() interface{} {
+	old := *queue
+	last := old[len(old)-1]
+	*queue = old[:len(old)-1]
+	return last
+}
@@ -0,0 +1,39 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+)
+
+func TestClockOrder(t *testing.T) {
+	clock := NewClock(time.Unix(0, 0))
+
+	var order []int
+	clock.AfterFunc(2*time.Second, func() { order = append(order, 3) })
+	clock.AfterFunc(time.Second, func() { order = append(order, 1) })
+	clock.AfterFunc(time.Second, func() {
+		order = append(order, 2)
+		//Due within the same advance.
+		clock.AfterFunc(500*time.Millisecond, func() { order = append(order, 4) })
+	})
+
+	if fired := clock.Advance(time.Second); fired != 2 || clock.Now() != time.Unix(1, 0) {
+		t.Errorf("Fired %v events at %v, expected 2 at 1s.\n", fired, clock.Now())
+	}
+
+	clock.Advance(time.Second)
+	expected := []int{1, 2, 4, 3}
+	if len(order) != len(expected) {
+		t.Fatalf("Fired %v, expected %v.\n", order, expected)
+	}
+	for i := range expected {
+		if order[i] != expected[i] {
+			t.Errorf("Fired %v, expected %v.\n", order, expected)
+			break
+		}
+	}
+
+	if clock.Pending() != 0 {
+		t.Errorf("%v events pending.\n", clock.Pending())
+	}
+}
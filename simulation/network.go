@@ -0,0 +1,140 @@
+package simulation
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+//An in-memory network of simulated nodes, driven by a Clock. Messages are p2p packets (see p2p.BuildPacket) and are
+//checked like the ones read from a connection. Every message is delivered after a latency drawn from a seeded source,
+//so the same seed and the same sequence of sends give the same run. Partitions drop the messages between the groups,
+//also the ones in flight when the partition starts.
+//The miner keeps its state in package variables, as do p2p and storage, so a process runs a single miner. The nodes of
+//a simulation are therefore handlers: one of them can hand the blocks to the miner of the process, the others produce
+//blocks for it (see miner/simulation_test.go).
+
+type Handler func(from string, header *p2p.Header, payload []byte)
+
+type Network struct {
+	clock      *Clock
+	rng        *rand.Rand
+	minLatency time.Duration
+	maxLatency time.Duration
+	nodes      map[string]Handler
+	//Nodes in the same group can communicate, all nodes are in group 0 if there is no partition.
+	groups    map[string]int
+	Delivered int
+	Dropped   int
+}
+
+func NewNetwork(clock *Clock, seed int64, minLatency, maxLatency time.Duration) *Network {
+	//The packet types are registered when p2p is initialized, which a simulation does not do.
+	if p2p.LogMapping == nil {
+		p2p.InitLogging()
+	}
+
+	return &Network{
+		clock:      clock,
+		rng:        rand.New(rand.NewSource(seed)),
+		minLatency: minLatency,
+		maxLatency: maxLatency,
+		nodes:      make(map[string]Handler),
+		groups:     make(map[string]int),
+	}
+}
+
+func (network *Network) Join(address string, handler Handler) {
+	network.nodes[address] = handler
+}
+
+func (network *Network) Leave(address string) {
+	delete(network.nodes, address)
+	delete(network.groups, address)
+}
+
+//Returns the addresses of all nodes in a fixed order.
+func (network *Network) Nodes() []string {
+	var addresses []string
+	for address := range network.nodes {
+		addresses = append(addresses, address)
+	}
+	sort.Strings(addresses)
+
+	return addresses
+}
+
+func (network *Network) Send(from, to string, packet []byte) error {
+	header, err := p2p.ReadHeader(bufio.NewReader(bytes.NewReader(packet)))
+	if err != nil {
+		return err
+	}
+
+	if int(header.Len) != len(packet)-p2p.HEADER_LEN {
+		return errors.New(fmt.Sprintf("Packet length %v does not match the header (%v).", len(packet)-p2p.HEADER_LEN, header.Len))
+	}
+
+	if _, exists := network.nodes[to]; !exists {
+		return errors.New(fmt.Sprintf("Unknown node %v.", to))
+	}
+
+	payload := packet[p2p.HEADER_LEN:]
+	network.clock.AfterFunc(network.latency(), func() {
+		handler, exists := network.nodes[to]
+		if !exists || !network.connected(from, to) {
+			network.Dropped++
+			return
+		}
+
+		network.Delivered++
+		handler(from, header, payload)
+	})
+
+	return nil
+}
+
+//Sends the packet to all other nodes.
+func (network *Network) Broadcast(from string, packet []byte) error {
+	for _, to := range network.Nodes() {
+		if to == from {
+			continue
+		}
+
+		if err := network.Send(from, to, packet); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+//Splits the network into the given groups, the nodes not listed form another group.
+func (network *Network) Partition(groups ...[]string) {
+	network.groups = make(map[string]int)
+	for i, group := range groups {
+		for _, address := range group {
+			network.groups[address] = i + 1
+		}
+	}
+}
+
+func (network *Network) Heal() {
+	network.groups = make(map[string]int)
+}
+
+func (network *Network) connected(from, to string) bool {
+	return network.groups[from] == network.groups[to]
+}
+
+func (network *Network) latency() time.Duration {
+	if network.maxLatency <= network.minLatency {
+		return network.minLatency
+	}
+
+	return network.minLatency + time.Duration(network.rng.Int63n(int64(network.maxLatency-network.minLatency)))
+}
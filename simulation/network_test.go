@@ -0,0 +1,117 @@
+package simulation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/p2p"
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//A node following the highest block it received.
+type testNode struct {
+	t        *testing.T
+	address  string
+	network  *Network
+	tip      *protocol.Block
+	received []uint32
+}
+
+func (node *testNode) handle(from string, header *p2p.Header, payload []byte) {
+	if header.TypeID != p2p.BLOCK_BRDCST {
+		return
+	}
+
+	block, err := new(protocol.Block).Decode(payload)
+	if err != nil {
+		node.t.Errorf("Block could not be decoded: %v\n", err)
+		return
+	}
+	node.received = append(node.received, block.Height)
+	if block.Height > node.tip.Height {
+		node.tip = block
+	}
+}
+
+func (node *testNode) produce() {
+	block := protocol.NewBlock(node.tip.Hash, node.tip.Height+1)
+	block.Hash = [32]byte{byte(block.Height), node.address[len(node.address)-1]}
+	node.tip = block
+	if err := node.network.Broadcast(node.address, p2p.BuildPacket(p2p.BLOCK_BRDCST, block.Encode())); err != nil {
+		node.t.Errorf("Block broadcast failed: %v\n", err)
+	}
+}
+
+func newTestNetwork(t *testing.T, seed int64, n int) (*Clock, *Network, []*testNode) {
+	clock := NewClock(time.Unix(0, 0))
+	network := NewNetwork(clock, seed, 10*time.Millisecond, 200*time.Millisecond)
+
+	var nodes []*testNode
+	for i := 0; i < n; i++ {
+		node := &testNode{t: t, address: fmt.Sprintf("node%v", i), network: network, tip: protocol.NewBlock([32]byte{}, 0)}
+		network.Join(node.address, node.handle)
+		nodes = append(nodes, node)
+	}
+
+	return clock, network, nodes
+}
+
+func TestNetworkDeterminism(t *testing.T) {
+	run := func(seed int64) [][]uint32 {
+		clock, _, nodes := newTestNetwork(t, seed, 4)
+		for i := 0; i < 3; i++ {
+			for _, node := range nodes {
+				node.produce()
+			}
+			clock.Advance(50 * time.Millisecond)
+		}
+		clock.Advance(time.Second)
+
+		var received [][]uint32
+		for _, node := range nodes {
+			received = append(received, node.received)
+		}
+		return received
+	}
+
+	received := run(1)
+	if len(received[0]) == 0 {
+		t.Fatal("No blocks delivered.\n")
+	}
+	if fmt.Sprint(received) != fmt.Sprint(run(1)) {
+		t.Error("Runs with the same seed differ.\n")
+	}
+}
+
+func TestNetworkPartition(t *testing.T) {
+	clock, network, nodes := newTestNetwork(t, 1, 3)
+
+	if err := network.Send("node0", "node1", []byte{1, 2}); err == nil {
+		t.Error("Malformed packet accepted.\n")
+	}
+
+	//node0 forks off and produces two blocks, the others one.
+	network.Partition([]string{"node0"})
+	nodes[0].produce()
+	nodes[0].produce()
+	nodes[1].produce()
+	clock.Advance(time.Second)
+
+	if nodes[0].tip.Height != 2 || nodes[1].tip.Height != 1 || nodes[2].tip.Height != 1 {
+		t.Errorf("Partition not respected, tips at %v, %v and %v.\n", nodes[0].tip.Height, nodes[1].tip.Height, nodes[2].tip.Height)
+	}
+	if network.Dropped != 5 || network.Delivered != 1 {
+		t.Errorf("Delivered %v and dropped %v messages, expected 1 and 5.\n", network.Delivered, network.Dropped)
+	}
+
+	//After healing, the next block of node0 reaches everybody.
+	network.Heal()
+	nodes[0].produce()
+	clock.Advance(time.Second)
+	for _, node := range nodes {
+		if node.tip.Height != 3 || node.tip.Hash != nodes[0].tip.Hash {
+			t.Errorf("%v is at height %v after healing.\n", node.address, node.tip.Height)
+		}
+	}
+}
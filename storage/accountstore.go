@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//The state is read by the p2p handlers, the RPC interface and the verification of incoming txs while blocks are
+//validated. The AccountStore guards the accounts with read-write locks, sharded by the first byte of the account hash
+//so lookups of different accounts do not contend. The locks protect the set of accounts, not their fields: accounts
+//are only changed by the state transitions of blocks (see journal.go), which hold the blockValidation lock of the
+//miner. Code running outside of it should iterate over a Snapshot instead of the store.
+
+const ACCOUNT_STORE_SHARDS = 16
+
+type AccountStore struct {
+	shards [ACCOUNT_STORE_SHARDS]accountShard
+}
+
+type accountShard struct {
+	accounts map[[32]byte]*protocol.Account
+	mutex    sync.RWMutex
+}
+
+//Creates a store holding the accounts, which may be nil.
+func NewAccountStore(accounts map[[32]byte]*protocol.Account) *AccountStore {
+	store := new(AccountStore)
+	for i := range store.shards {
+		store.shards[i].accounts = make(map[[32]byte]*protocol.Account)
+	}
+	for hash, acc := range accounts {
+		store.Set(hash, acc)
+	}
+
+	return store
+}
+
+func (store *AccountStore) shard(hash [32]byte) *accountShard {
+	return &store.shards[hash[0]%ACCOUNT_STORE_SHARDS]
+}
+
+//Returns nil if there is no account with the hash.
+func (store *AccountStore) Get(hash [32]byte) *protocol.Account {
+	shard := store.shard(hash)
+	shard.mutex.RLock()
+	defer shard.mutex.RUnlock()
+
+	return shard.accounts[hash]
+}
+
+//Setting nil deletes the account.
+func (store *AccountStore) Set(hash [32]byte, acc *protocol.Account) {
+	if acc == nil {
+		store.Delete(hash)
+		return
+	}
+
+	shard := store.shard(hash)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	shard.accounts[hash] = acc
+}
+
+func (store *AccountStore) Delete(hash [32]byte) {
+	shard := store.shard(hash)
+	shard.mutex.Lock()
+	defer shard.mutex.Unlock()
+
+	delete(shard.accounts, hash)
+}
+
+func (store *AccountStore) Len() (n int) {
+	for i := range store.shards {
+		store.shards[i].mutex.RLock()
+		n += len(store.shards[i].accounts)
+		store.shards[i].mutex.RUnlock()
+	}
+
+	return n
+}
+
+//Returns a copy of the set of accounts, the accounts themselves are shared with the store.
+func (store *AccountStore) Snapshot() map[[32]byte]*protocol.Account {
+	accounts := make(map[[32]byte]*protocol.Account)
+	for i := range store.shards {
+		store.shards[i].mutex.RLock()
+		for hash, acc := range store.shards[i].accounts {
+			accounts[hash] = acc
+		}
+		store.shards[i].mutex.RUnlock()
+	}
+
+	return accounts
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestAccountStore(t *testing.T) {
+	acc := &protocol.Account{Address: [32]byte{0x01}, Balance: 10}
+	store := NewAccountStore(map[[32]byte]*protocol.Account{acc.Address: acc})
+	if store.Get(acc.Address) != acc || store.Len() != 1 {
+		t.Fatal("Account passed to the constructor not stored.")
+	}
+
+	snapshot := store.Snapshot()
+	store.Set(acc.Address, nil)
+	if store.Get(acc.Address) != nil || store.Len() != 0 {
+		t.Error("Setting nil should delete the account.")
+	}
+	if snapshot[acc.Address] != acc {
+		t.Error("Snapshot should not change with the store.")
+	}
+}
+
+func TestAccountStoreConcurrentAccess(t *testing.T) {
+	store := NewAccountStore(nil)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 64; i++ {
+		wg.Add(1)
+		go func(i byte) {
+			defer wg.Done()
+			hash := [32]byte{i}
+			store.Set(hash, &protocol.Account{Address: hash})
+			store.Get([32]byte{i + 1})
+			store.Snapshot()
+		}(byte(i))
+	}
+	wg.Wait()
+
+	if store.Len() != 64 {
+		t.Errorf("Store holds %v accounts, expected 64.", store.Len())
+	}
+}
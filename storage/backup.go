@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+//Writes a consistent snapshot of the open database to w. The snapshot is taken within a read-only transaction, so
+//the node keeps processing blocks while the backup is written (hot backup).
+func WriteBackup(w io.Writer) (n int64, err error) {
+	if db == nil {
+		return 0, errors.New("Database is not initialized.")
+	}
+	boltDB, ok := db.(*boltKV)
+	if !ok {
+		return 0, errors.New(fmt.Sprintf("Hot backups are only supported by the %v backend.", BACKEND_BOLT))
+	}
+
+	err = boltDB.db.View(func(tx *bolt.Tx) error {
+		n, err = tx.WriteTo(w)
+		return err
+	})
+
+	return n, err
+}
+
+//Writes a snapshot of the database stored in dbname to w without initializing the storage package. Fails if the
+//database is held open by a running node, use WriteBackup (e.g., over the RPC interface) in that case.
+func WriteBackupFromFile(dbname string, w io.Writer) (n int64, err error) {
+	fileDB, err := bolt.Open(dbname, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Could not open database %v (is the node still running?): %v", dbname, err))
+	}
+	defer fileDB.Close()
+
+	err = fileDB.View(func(tx *bolt.Tx) error {
+		n, err = tx.WriteTo(w)
+		return err
+	})
+
+	return n, err
+}
+
+//Checks whether the file at dbname is a readable bolt database.
+func VerifyBackup(dbname string) error {
+	fileDB, err := bolt.Open(dbname, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return errors.New(fmt.Sprintf("Could not open database %v: %v", dbname, err))
+	}
+	defer fileDB.Close()
+
+	return fileDB.View(func(tx *bolt.Tx) error {
+		for err := range tx.Check() {
+			return err
+		}
+		if tx.Bucket([]byte("closedblocks")) == nil {
+			return errors.New(fmt.Sprintf("Database %v contains no blocks bucket.", dbname))
+		}
+		return nil
+	})
+}
+
+//Returns an error if the database stored in dbname is held open, e.g., by a running node. Bolt locks the file while it
+//is open, the lock is tried for a short time only.
+func CheckNotInUse(dbname string) error {
+	if _, err := os.Stat(dbname); os.IsNotExist(err) {
+		return nil
+	}
+
+	fileDB, err := bolt.Open(dbname, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err == bolt.ErrTimeout {
+		return errors.New(fmt.Sprintf("Database %v is in use, stop the node first.", dbname))
+	}
+	if err == nil {
+		fileDB.Close()
+	}
+
+	return nil
+}
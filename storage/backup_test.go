@@ -0,0 +1,72 @@
+package storage
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestWriteBackup(t *testing.T) {
+	block := new(protocol.Block)
+	block.Hash = [32]byte{'b', 'a', 'c', 'k', 'u', 'p'}
+	WriteClosedBlock(block)
+	defer DeleteClosedBlock(block.Hash)
+
+	var buf bytes.Buffer
+	n, err := WriteBackup(&buf)
+	if err != nil {
+		t.Fatalf("Writing backup failed: %v\n", err)
+	}
+
+	if n != int64(buf.Len()) {
+		t.Errorf("Backup size mismatch: %v vs. %v\n", n, buf.Len())
+	}
+
+	backupFile := "backup_test.db"
+	defer os.Remove(backupFile)
+	if err := ioutil.WriteFile(backupFile, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := VerifyBackup(backupFile); err != nil {
+		t.Errorf("Backup could not be verified: %v\n", err)
+	}
+
+	//The backup is a self-contained database which contains the written block.
+	var restored bytes.Buffer
+	if _, err := WriteBackupFromFile(backupFile, &restored); err != nil {
+		t.Errorf("Reading backup failed: %v\n", err)
+	}
+
+	if !bytes.Equal(buf.Bytes(), restored.Bytes()) {
+		t.Error("Backup changed after reading it.")
+	}
+
+	if err := VerifyBackup("nonexisting_test.db"); err == nil {
+		t.Error("Verifying a non-existing backup should fail.")
+	}
+	os.Remove("nonexisting_test.db")
+}
+
+func TestCheckNotInUse(t *testing.T) {
+	//The test database is held open by the storage package.
+	if err := CheckNotInUse(TestDBFileName); err == nil {
+		t.Error("Open database not detected.\n")
+	}
+
+	dir, err := ioutil.TempDir("", "inuse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := CheckNotInUse(filepath.Join(dir, "store.db")); err != nil {
+		t.Errorf("Missing database reported in use: %v\n", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "store.db")); !os.IsNotExist(err) {
+		t.Error("Database created by the check.\n")
+	}
+}
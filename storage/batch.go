@@ -0,0 +1,484 @@
+package storage
+
+import (
+	"encoding/binary"
+	"math/big"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"golang.org/x/crypto/sha3"
+)
+
+//The writes belonging to one block (closed txs, indexes, the block itself and the last closed block) are collected in
+//a batch and committed in a single database transaction. After a crash either all or none of them are on disk, the
+//database never contains a block without its txs or a last closed block that has not been written. There is no separate
+//write-ahead log, the database transaction is crash-safe and the in-memory state is rebuilt from the stored blocks at
+//start.
+type Batch struct {
+	ops []batchOp
+	//Changes of the closed tx statistics, applied once the batch is committed.
+	closedTxs    float32
+	closedTxSize float32
+}
+
+type batchOp struct {
+	bucket string
+	key    []byte
+	//Nil deletes the key.
+	value []byte
+	//Deletes all keys of the bucket.
+	clear bool
+}
+
+func NewBatch() *Batch {
+	return new(Batch)
+}
+
+func (batch *Batch) put(bucket string, key, value []byte) {
+	batch.ops = append(batch.ops, batchOp{bucket: bucket, key: key, value: value})
+}
+
+func (batch *Batch) delete(bucket string, key []byte) {
+	batch.ops = append(batch.ops, batchOp{bucket: bucket, key: key})
+}
+
+func (batch *Batch) clear(bucket string) {
+	batch.ops = append(batch.ops, batchOp{bucket: bucket, clear: true})
+}
+
+//Applies all writes of the batch in the order they were added. Nothing is written if one of them fails.
+func (batch *Batch) Commit() error {
+	if len(batch.ops) == 0 {
+		return nil
+	}
+
+	//Added before the txs are written, a tx must never be on disk without being in the filter.
+	for _, op := range batch.ops {
+		if op.value != nil && isClosedTxBucket(op.bucket) {
+			var hash [32]byte
+			copy(hash[:], op.key)
+			closedTxFilter.add(hash)
+		}
+	}
+
+	err := db.Update(func(tx KVTx) error {
+		for _, op := range batch.ops {
+			b := tx.Bucket([]byte(op.bucket))
+
+			var err error
+			switch {
+			case op.clear:
+				err = clearBucket(b)
+			case op.value == nil:
+				err = b.Delete(op.key)
+			default:
+				err = b.Put(op.key, op.value)
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	batch.updateClosedTxCache()
+	batch.updateClosedTxStatistics()
+
+	return nil
+}
+
+func (batch *Batch) updateClosedTxStatistics() {
+	if batch.closedTxs == 0 {
+		return
+	}
+
+	nrClosedTransactions = nrClosedTransactions + batch.closedTxs
+	totalTransactionSize = totalTransactionSize + batch.closedTxSize
+	averageTxSize = totalTransactionSize/nrClosedTransactions
+}
+
+func (batch *Batch) updateClosedTxCache() {
+	for _, op := range batch.ops {
+		if !isClosedTxBucket(op.bucket) {
+			continue
+		}
+
+		if op.clear {
+			closedTxCache.clear()
+			continue
+		}
+
+		var hash [32]byte
+		copy(hash[:], op.key)
+		closedTxCache.update(hash, op.bucket, op.value)
+	}
+}
+
+//Keys must not be deleted while iterating with ForEach.
+func clearBucket(b KVBucket) error {
+	var keys [][]byte
+	b.ForEach(func(k, v []byte) error {
+		keys = append(keys, append([]byte(nil), k...))
+		return nil
+	})
+
+	for _, key := range keys {
+		if err := b.Delete(key); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (batch *Batch) WriteClosedBlock(block *protocol.Block) {
+	batch.put("closedblocks", block.Hash[:], block.Encode())
+}
+
+func (batch *Batch) WriteClosedBlockWithoutTx(block *protocol.Block) {
+	batch.put("closedblockswithouttx", block.HashWithoutTx[:], block.Encode())
+}
+
+//Replaces the stored block with its header (see protocol.Block.EncodeHeader), the hashes of its txs are dropped.
+func (batch *Batch) PruneClosedBlock(block *protocol.Block) {
+	batch.put("closedblocks", block.Hash[:], block.EncodeHeader())
+}
+
+func (batch *Batch) PruneClosedBlockWithoutTx(block *protocol.Block) {
+	batch.put("closedblockswithouttx", block.HashWithoutTx[:], block.EncodeHeader())
+}
+
+func (batch *Batch) DeleteOpenBlock(hash [32]byte) {
+	batch.delete("openblocks", hash[:])
+}
+
+func (batch *Batch) DeleteClosedBlock(hash [32]byte) {
+	batch.delete("closedblocks", hash[:])
+}
+
+//Replaces the last closed block.
+func (batch *Batch) SetLastClosedBlock(block *protocol.Block) {
+	batch.clear("lastclosedblock")
+	batch.put("lastclosedblock", block.Hash[:], block.Encode())
+}
+
+func (batch *Batch) WriteClosedTx(transaction protocol.Transaction) {
+	hash := transaction.Hash()
+	batch.put(closedTxBucket(transaction), hash[:], transaction.Encode())
+	batch.put("closedtxhashes", hash[:], []byte{1})
+
+	batch.closedTxs++
+	batch.closedTxSize += float32(transaction.Size())
+}
+
+//Deletes the closed tx of a rolled back block, it can be included in another block.
+func (batch *Batch) DeleteClosedTx(transaction protocol.Transaction) {
+	hash := transaction.Hash()
+	batch.PruneClosedTx(transaction)
+	batch.delete("closedtxhashes", hash[:])
+}
+
+//Deletes the closed tx of a pruned block. Its hash is kept for replay protection (see IsClosedTx).
+func (batch *Batch) PruneClosedTx(transaction protocol.Transaction) {
+	hash := transaction.Hash()
+	batch.delete(closedTxBucket(transaction), hash[:])
+
+	batch.closedTxs--
+	batch.closedTxSize -= float32(transaction.Size())
+}
+
+func (batch *Batch) WriteIotTxBlock(txHash, blockHash [32]byte) {
+	batch.put("iottxblocks", txHash[:], blockHash[:])
+}
+
+func (batch *Batch) DeleteIotTxBlock(txHash [32]byte) {
+	batch.delete("iottxblocks", txHash[:])
+}
+
+//Replaces the closed IoT tx with a copy without data. The tx stays stored under its hash (which can not be computed
+//from the copy anymore), the hash of the data is kept to verify the data if it is restored from an archive.
+func (batch *Batch) PruneIotTx(txHash [32]byte, tx *protocol.IotTx) {
+	dataHash := sha3.Sum256(tx.Data)
+	pruned := *tx
+	pruned.Data = nil
+
+	batch.put("closediotts", txHash[:], pruned.Encode())
+	batch.put("prunediotdata", txHash[:], dataHash[:])
+}
+
+//Deletes an IoT tx pruned with PruneIotTx, which can not be deleted by its hash anymore.
+func (batch *Batch) DeletePrunedIotTx(txHash [32]byte) {
+	batch.delete("closediotts", txHash[:])
+	batch.delete("prunediotdata", txHash[:])
+}
+
+func (batch *Batch) WriteBeneficiaryBlock(beneficiary [32]byte, height uint32, blockHash [32]byte) {
+	batch.put("beneficiaryblocks", blockIndexKey(beneficiary, height), blockHash[:])
+}
+
+func (batch *Batch) WriteSlashingBlock(slashedAddress [32]byte, height uint32, blockHash [32]byte) {
+	batch.put("slashingblocks", blockIndexKey(slashedAddress, height), blockHash[:])
+}
+
+func (batch *Batch) DeleteBeneficiaryBlock(beneficiary [32]byte, height uint32) {
+	batch.delete("beneficiaryblocks", blockIndexKey(beneficiary, height))
+}
+
+func (batch *Batch) DeleteSlashingBlock(slashedAddress [32]byte, height uint32) {
+	batch.delete("slashingblocks", blockIndexKey(slashedAddress, height))
+}
+
+func (batch *Batch) WriteBlockHeight(height uint32, blockHash [32]byte) {
+	batch.put("blockheights", heightKey(height), blockHash[:])
+}
+
+//Keeps the hash of the block without txs after its hash, the block is found by its height once it has been moved.
+func (batch *Batch) WriteBlockHeightWithoutTx(height uint32, blockHash [32]byte, blockHashWithoutTx [32]byte) {
+	batch.put("blockheights", heightKey(height), append(blockHash[:], blockHashWithoutTx[:]...))
+}
+
+func (batch *Batch) DeleteBlockHeight(height uint32) {
+	batch.delete("blockheights", heightKey(height))
+}
+
+//The balance of the account after the block at the height, written for every block changing the balance.
+func (batch *Batch) WriteBalance(address [32]byte, height uint32, balance uint64) {
+	var encoded [8]byte
+	binary.BigEndian.PutUint64(encoded[:], balance)
+	batch.put("balances", blockIndexKey(address, height), encoded[:])
+}
+
+func (batch *Batch) DeleteBalance(address [32]byte, height uint32) {
+	batch.delete("balances", blockIndexKey(address, height))
+}
+
+//The aggregate is stored with the hashes it commits to, which are needed to create the proofs of its leaves.
+func (batch *Batch) WriteEpochAggregate(aggregate *protocol.EpochAggregate, leaves [][32]byte) {
+	key := epochAggregateKey(aggregate.Level, aggregate.FirstHeight)
+	batch.put("epochaggregates", key, aggregate.Encode())
+
+	encodedLeaves := make([]byte, 0, len(leaves)*32)
+	for _, leaf := range leaves {
+		encodedLeaves = append(encodedLeaves, leaf[:]...)
+	}
+	batch.put("epochleaves", key, encodedLeaves)
+}
+
+func (batch *Batch) DeleteEpochAggregate(level uint8, firstHeight uint32) {
+	batch.delete("epochaggregates", epochAggregateKey(level, firstHeight))
+	batch.delete("epochleaves", epochAggregateKey(level, firstHeight))
+}
+
+func (batch *Batch) DeleteEpochLeaves(level uint8, firstHeight uint32) {
+	batch.delete("epochleaves", epochAggregateKey(level, firstHeight))
+}
+
+func (batch *Batch) WriteAccountTx(address [32]byte, height uint32, txHash [32]byte) {
+	batch.put("accounttxs", accountTxKey(address, height, txHash), []byte{})
+}
+
+func (batch *Batch) DeleteAccountTx(address [32]byte, height uint32, txHash [32]byte) {
+	batch.delete("accounttxs", accountTxKey(address, height, txHash))
+}
+
+//The logs of a block are stored per contract, keyed by the height (big endian) followed by the contract, so the logs
+//are stored in height order and the logs of a contract in a block are read at once.
+func (batch *Batch) WriteLogs(logs []*protocol.Log) {
+	var contracts [][32]byte
+	logsByContract := make(map[[32]byte][]*protocol.Log)
+	for _, log := range logs {
+		if _, exists := logsByContract[log.Contract]; !exists {
+			contracts = append(contracts, log.Contract)
+		}
+		logsByContract[log.Contract] = append(logsByContract[log.Contract], log)
+	}
+
+	for _, contract := range contracts {
+		contractLogs := logsByContract[contract]
+		batch.put("logs", logKey(contractLogs[0].Height, contract), protocol.EncodeLogs(contractLogs))
+	}
+}
+
+func (batch *Batch) DeleteLogs(height uint32, contract [32]byte) {
+	batch.delete("logs", logKey(height, contract))
+}
+
+//Finality checkpoints are keyed by height (big endian), so the latest one is the last key.
+func (batch *Batch) WriteCheckpoint(height uint32, blockHash [32]byte) {
+	var key [4]byte
+	binary.BigEndian.PutUint32(key[:], height)
+	batch.put("checkpoints", key[:], blockHash[:])
+}
+
+//Removed accounts are kept (keyed by the hash of the removing accTx) to restore them if the block is rolled back. The
+//first byte tells whether the account was a root account.
+func (batch *Batch) WriteRemovedAccount(txHash [32]byte, acc *protocol.Account, isRoot bool) {
+	var flag byte
+	if isRoot {
+		flag = 1
+	}
+	batch.put("removedaccs", txHash[:], append([]byte{flag}, acc.Encode()...))
+}
+
+func (batch *Batch) DeleteRemovedAccount(txHash [32]byte) {
+	batch.delete("removedaccs", txHash[:])
+}
+
+//The accounts a contract call read or changed, as they were before the call, kept (keyed by the hash of the fundsTx) to
+//restore the contracts if the block is rolled back. Each account is stored as its hash, the length of the encoding and
+//the encoding.
+func (batch *Batch) WriteContractStates(txHash [32]byte, accounts map[[32]byte]protocol.Account) {
+	var encoded []byte
+	for hash, acc := range accounts {
+		accEncoded := acc.Encode()
+		var length [4]byte
+		binary.BigEndian.PutUint32(length[:], uint32(len(accEncoded)))
+		encoded = append(encoded, hash[:]...)
+		encoded = append(encoded, length[:]...)
+		encoded = append(encoded, accEncoded...)
+	}
+	batch.put("contractstates", txHash[:], encoded)
+}
+
+func (batch *Batch) DeleteContractStates(txHash [32]byte) {
+	batch.delete("contractstates", txHash[:])
+}
+
+//The IoT rate limit bucket of the sender before the IoT tx took a token from it, kept (keyed by the hash of the tx)
+//to restore the bucket if the block is rolled back.
+func (batch *Batch) WriteIotBucket(txHash [32]byte, tokens uint32, refillHeight uint32) {
+	var encoded [8]byte
+	binary.BigEndian.PutUint32(encoded[:4], tokens)
+	binary.BigEndian.PutUint32(encoded[4:], refillHeight)
+	batch.put("iotbuckets", txHash[:], encoded[:])
+}
+
+func (batch *Batch) DeleteIotBucket(txHash [32]byte) {
+	batch.delete("iotbuckets", txHash[:])
+}
+
+//The stake burned by the block slashing a validator, kept (keyed by the hash of the block) to restore the balance if
+//the block is rolled back.
+func (batch *Batch) WriteSlashedStake(blockHash [32]byte, stake uint64) {
+	var encoded [8]byte
+	binary.BigEndian.PutUint64(encoded[:], stake)
+	batch.put("slashedstakes", blockHash[:], encoded[:])
+}
+
+func (batch *Batch) DeleteSlashedStake(blockHash [32]byte) {
+	batch.delete("slashedstakes", blockHash[:])
+}
+
+//The difficulty the block was validated at and the accumulated difficulty of the chain up to the block, keyed by the
+//hash of the block. The weight is encoded big endian after the difficulty.
+func (batch *Batch) WriteBlockWeight(blockHash [32]byte, difficulty uint8, weight *big.Int) {
+	batch.put("blockweights", blockHash[:], append([]byte{difficulty}, weight.Bytes()...))
+}
+
+func (batch *Batch) DeleteBlockWeight(blockHash [32]byte) {
+	batch.delete("blockweights", blockHash[:])
+}
+
+//Slashing proofs not included in a block yet, keyed by the slashed address. Deleted with the block including them.
+func (batch *Batch) WriteSlashingProof(proof *protocol.SlashingProof) {
+	batch.put("slashingproofs", proof.SlashedAddress[:], proof.Encode())
+}
+
+func (batch *Batch) DeleteSlashingProof(slashedAddress [32]byte) {
+	batch.delete("slashingproofs", slashedAddress[:])
+}
+
+func (batch *Batch) WriteValidatorSet(set *protocol.ValidatorSet) {
+	batch.put("validatorsets", heightKey(set.Height), set.Encode())
+}
+
+func (batch *Batch) DeleteValidatorSet(height uint32) {
+	batch.delete("validatorsets", heightKey(height))
+}
+
+func (batch *Batch) WriteLivenessEvidence(evidence *protocol.LivenessEvidence) {
+	batch.put("livenessevidence", heightKey(evidence.Height), evidence.Encode())
+}
+
+func (batch *Batch) DeleteLivenessEvidence(height uint32) {
+	batch.delete("livenessevidence", heightKey(height))
+}
+
+//The closed tx buckets in the order they are searched by ReadClosedTx.
+var closedTxBuckets = []string{"closedfunds", "closedaccs", "closedconfigs", "closedstakes", "closedaggregations", "closediotts", "closedcontracts"}
+
+func isClosedTxBucket(bucket string) bool {
+	for _, name := range closedTxBuckets {
+		if name == bucket {
+			return true
+		}
+	}
+
+	return false
+}
+
+func decodeClosedTx(bucket string, encoded []byte) protocol.Transaction {
+	switch bucket {
+	case "closedfunds":
+		var tx *protocol.FundsTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case "closedaccs":
+		var tx *protocol.AccTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case "closedconfigs":
+		var tx *protocol.ConfigTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case "closedstakes":
+		var tx *protocol.StakeTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case "closedaggregations":
+		var tx *protocol.AggTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case "closediotts":
+		var tx *protocol.IotTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	case "closedcontracts":
+		var tx *protocol.ContractTx
+		if tx, _ = tx.Decode(encoded); tx != nil {
+			return tx
+		}
+	}
+
+	return nil
+}
+
+func closedTxBucket(transaction protocol.Transaction) string {
+	switch transaction.(type) {
+	case *protocol.FundsTx:
+		return "closedfunds"
+	case *protocol.AccTx:
+		return "closedaccs"
+	case *protocol.ConfigTx:
+		return "closedconfigs"
+	case *protocol.StakeTx:
+		return "closedstakes"
+	case *protocol.AggTx:
+		return "closedaggregations"
+	case *protocol.IotTx:
+		return "closediotts"
+	case *protocol.ContractTx:
+		return "closedcontracts"
+	}
+
+	return ""
+}
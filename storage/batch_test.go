@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/boltdb/bolt"
+)
+
+func TestBatch(t *testing.T) {
+	prev := &protocol.Block{Hash: [32]byte{0x81}, Height: 1}
+	block := &protocol.Block{Hash: [32]byte{0x82}, PrevHash: prev.Hash, Height: 2}
+	tx := &protocol.FundsTx{Amount: 10, Fee: 1, From: [32]byte{0x83}, To: [32]byte{0x84}}
+	defer func() {
+		DeleteClosedBlock(block.Hash)
+		DeleteClosedTx(tx)
+		DeleteAllLastClosedBlock()
+	}()
+	WriteLastClosedBlock(prev)
+	closedTxs := nrClosedTransactions
+
+	//A failing write discards the whole batch.
+	batch := NewBatch()
+	batch.WriteClosedTx(tx)
+	batch.WriteClosedBlock(block)
+	batch.SetLastClosedBlock(block)
+	batch.put("closedblocks", []byte{}, []byte{0x01})
+	if err := batch.Commit(); err != bolt.ErrKeyRequired {
+		t.Fatalf("Invalid write not rejected: %v\n", err)
+	}
+
+	if ReadClosedTx(tx.Hash()) != nil || ReadClosedBlock(block.Hash) != nil || ReadLastClosedBlock().Hash != prev.Hash {
+		t.Error("Writes of a failed batch committed.")
+	}
+	if nrClosedTransactions != closedTxs {
+		t.Error("Closed tx statistics changed by a failed batch.")
+	}
+
+	batch = NewBatch()
+	batch.WriteClosedTx(tx)
+	batch.WriteClosedBlock(block)
+	batch.SetLastClosedBlock(block)
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Batch not committed: %v\n", err)
+	}
+
+	//The previous last closed block sorts first and would be returned if it was not replaced.
+	if ReadClosedTx(tx.Hash()) == nil || ReadClosedBlock(block.Hash) == nil || ReadLastClosedBlock().Hash != block.Hash {
+		t.Error("Writes of the batch not committed.")
+	}
+	if nrClosedTransactions != closedTxs+1 {
+		t.Error("Closed tx statistics not updated by the batch.")
+	}
+}
+
+func TestBatchRemovedAccount(t *testing.T) {
+	txHash := [32]byte{'r', 'e', 'm', 'o', 'v', 'e', 'd'}
+	acc := &protocol.Account{Address: [32]byte{0x01}, Balance: 10, TxCnt: 2}
+
+	batch := NewBatch()
+	batch.WriteRemovedAccount(txHash, acc, true)
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed, isRoot := ReadRemovedAccount(txHash); removed == nil || removed.Balance != 10 || removed.TxCnt != 2 || !isRoot {
+		t.Errorf("Removed account not read back: %v\n", removed)
+	}
+
+	batch = NewBatch()
+	batch.DeleteRemovedAccount(txHash)
+	batch.Commit()
+	if removed, _ := ReadRemovedAccount(txHash); removed != nil {
+		t.Error("Removed account not deleted.")
+	}
+}
+
+func TestBatchContractStates(t *testing.T) {
+	txHash := [32]byte{'c', 'o', 'n', 't', 'r', 'a', 'c', 't'}
+	contract := protocol.Account{Address: [32]byte{0x01}, Balance: 10, Contract: []byte{0x01}, ContractVariables: []protocol.ByteArray{{0x02}}}
+	called := protocol.Account{Address: [32]byte{0x02}, Balance: 20}
+
+	batch := NewBatch()
+	batch.WriteContractStates(txHash, map[[32]byte]protocol.Account{{0x0a}: contract, {0x0b}: called})
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	accounts := ReadContractStates(txHash)
+	if len(accounts) != 2 || accounts[[32]byte{0x0a}].Balance != 10 || accounts[[32]byte{0x0b}].Balance != 20 {
+		t.Fatalf("Contract states not read back: %v\n", accounts)
+	}
+	if variables := accounts[[32]byte{0x0a}].ContractVariables; len(variables) != 1 || variables[0][0] != 0x02 {
+		t.Errorf("Contract variables not read back: %v\n", variables)
+	}
+
+	batch = NewBatch()
+	batch.DeleteContractStates(txHash)
+	batch.Commit()
+	if accounts := ReadContractStates(txHash); accounts != nil {
+		t.Error("Contract states not deleted.")
+	}
+}
+
+func TestBatchPruneClosedTx(t *testing.T) {
+	pruned := &protocol.FundsTx{Amount: 1, From: [32]byte{'p', 'r', 'u', 'n', 'e'}}
+	rolledBack := &protocol.FundsTx{Amount: 2, From: [32]byte{'r', 'o', 'l', 'l'}}
+	WriteClosedTx(pruned)
+	WriteClosedTx(rolledBack)
+	defer DeleteClosedTx(pruned)
+
+	batch := NewBatch()
+	batch.PruneClosedTx(pruned)
+	batch.DeleteClosedTx(rolledBack)
+	if err := batch.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if ReadClosedTx(pruned.Hash()) != nil || !IsClosedTx(pruned.Hash()) {
+		t.Error("Pruned tx should be deleted with its hash kept.")
+	}
+	if IsClosedTx(rolledBack.Hash()) {
+		t.Error("Hash of the rolled back tx should have been deleted.")
+	}
+}
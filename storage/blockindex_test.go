@@ -0,0 +1,175 @@
+package storage
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"reflect"
+	"testing"
+)
+
+func TestBeneficiaryBlocks(t *testing.T) {
+	beneficiary, other := [32]byte{0x01}, [32]byte{0x01, 0x01}
+	WriteBeneficiaryBlock(beneficiary, 300, [32]byte{0x03})
+	WriteBeneficiaryBlock(beneficiary, 2, [32]byte{0x02})
+	WriteBeneficiaryBlock(other, 1, [32]byte{0x04})
+	defer func() {
+		DeleteBeneficiaryBlock(beneficiary, 300)
+		DeleteBeneficiaryBlock(beneficiary, 2)
+		DeleteBeneficiaryBlock(other, 1)
+	}()
+
+	expected := []IndexedBlock{{2, [32]byte{0x02}}, {300, [32]byte{0x03}}}
+	if blocks := ReadBeneficiaryBlocks(beneficiary); !reflect.DeepEqual(blocks, expected) {
+		t.Errorf("Blocks of beneficiary %v instead of %v.\n", blocks, expected)
+	}
+
+	DeleteBeneficiaryBlock(beneficiary, 300)
+	if blocks := ReadBeneficiaryBlocks(beneficiary); len(blocks) != 1 || blocks[0].Height != 2 {
+		t.Errorf("Block not removed from the index: %v\n", blocks)
+	}
+
+	if blocks := ReadSlashingBlocks(beneficiary); len(blocks) != 0 {
+		t.Errorf("Slashing blocks found for beneficiary: %v\n", blocks)
+	}
+}
+
+func TestSlashingBlocks(t *testing.T) {
+	slashedAddress := [32]byte{0x05}
+	WriteSlashingBlock(slashedAddress, 7, [32]byte{0x07})
+	defer DeleteSlashingBlock(slashedAddress, 7)
+
+	if blocks := ReadSlashingBlocks(slashedAddress); len(blocks) != 1 || blocks[0] != (IndexedBlock{7, [32]byte{0x07}}) {
+		t.Errorf("Invalid slashing blocks: %v\n", blocks)
+	}
+}
+
+func TestBlockHeights(t *testing.T) {
+	for height := uint32(100); height < 105; height++ {
+		WriteBlockHeight(height, [32]byte{byte(height)})
+		defer DeleteBlockHeight(height)
+	}
+
+	if blockHash, exists := ReadBlockHashByHeight(102); !exists || blockHash != [32]byte{byte(102)} {
+		t.Errorf("Block at height 102 not found: %x\n", blockHash)
+	}
+
+	if _, exists := ReadBlockHashByHeight(105); exists {
+		t.Error("Block found above the tip.\n")
+	}
+
+	expected := []IndexedBlock{{103, [32]byte{byte(103)}}, {102, [32]byte{byte(102)}}}
+	if blocks := ReadBlockHeights(103, 2); !reflect.DeepEqual(blocks, expected) {
+		t.Errorf("Blocks %v instead of %v.\n", blocks, expected)
+	}
+
+	//Pages starting above the tip start at the tip.
+	if blocks := ReadBlockHeights(200, 1); len(blocks) != 1 || blocks[0].Height != 104 {
+		t.Errorf("Page not starting at the tip: %v\n", blocks)
+	}
+
+	DeleteBlockHeight(104)
+	if blocks := ReadBlockHeights(104, 10); len(blocks) == 0 || blocks[0].Height != 103 {
+		t.Errorf("Block not removed from the index: %v\n", blocks)
+	}
+}
+
+//Blocks moved to the blocks without txs are still found by their height.
+func TestBlockByHeight(t *testing.T) {
+	block := &protocol.Block{Hash: [32]byte{0x0c, 0x01}, HashWithoutTx: [32]byte{0x0c, 0x02}, Height: 110}
+	WriteClosedBlock(block)
+	WriteBlockHeight(block.Height, block.Hash)
+	defer DeleteBlockHeight(block.Height)
+
+	if indexed := ReadBlockByHeight(block.Height); indexed == nil || indexed.Hash != block.Hash {
+		t.Errorf("Block at height %v not found: %v\n", block.Height, indexed)
+	}
+
+	UpdateBlocksToBlocksWithoutTx(block)
+	defer func() {
+		batch := NewBatch()
+		batch.delete("closedblockswithouttx", block.HashWithoutTx[:])
+		batch.Commit()
+	}()
+
+	if indexed := ReadBlockByHeight(block.Height); indexed == nil || indexed.HashWithoutTx != block.HashWithoutTx || !indexed.Aggregated {
+		t.Errorf("Block without txs at height %v not found: %v\n", block.Height, indexed)
+	}
+	if blockHash, exists := ReadBlockHashByHeight(block.Height); !exists || blockHash != block.Hash {
+		t.Errorf("Hash %x at height %v instead of %x.\n", blockHash, block.Height, block.Hash)
+	}
+
+	if indexed := ReadBlockByHeight(block.Height + 1); indexed != nil {
+		t.Errorf("Block found at an unindexed height: %v\n", indexed)
+	}
+}
+
+func TestAccountTxs(t *testing.T) {
+	address, other := [32]byte{0x0a}, [32]byte{0x0b}
+	WriteAccountTx(address, 1, [32]byte{0x01})
+	WriteAccountTx(address, 2, [32]byte{0x02})
+	WriteAccountTx(address, 2, [32]byte{0x03})
+	WriteAccountTx(address, 3, [32]byte{0x04})
+	WriteAccountTx(other, 2, [32]byte{0x05})
+	defer func() {
+		DeleteAccountTx(address, 1, [32]byte{0x01})
+		DeleteAccountTx(address, 2, [32]byte{0x02})
+		DeleteAccountTx(address, 2, [32]byte{0x03})
+		DeleteAccountTx(address, 3, [32]byte{0x04})
+		DeleteAccountTx(other, 2, [32]byte{0x05})
+	}()
+
+	expected := []AccountTx{{3, [32]byte{0x04}}, {2, [32]byte{0x03}}, {2, [32]byte{0x02}}, {1, [32]byte{0x01}}}
+	if txs := ReadAccountTxs(address, ^uint32(0), 10); !reflect.DeepEqual(txs, expected) {
+		t.Errorf("Txs of address %v instead of %v.\n", txs, expected)
+	}
+
+	//The txs of a block are not split across pages.
+	if txs := ReadAccountTxs(address, 3, 2); !reflect.DeepEqual(txs, expected[:3]) {
+		t.Errorf("Page %v instead of %v.\n", txs, expected[:3])
+	}
+
+	if txs := ReadAccountTxs(address, 1, 10); !reflect.DeepEqual(txs, expected[3:]) {
+		t.Errorf("Page %v instead of %v.\n", txs, expected[3:])
+	}
+
+	if txs := ReadAccountTxs([32]byte{0x0c}, ^uint32(0), 10); len(txs) != 0 {
+		t.Errorf("Txs found for unknown address: %v\n", txs)
+	}
+}
+
+func TestBalanceAt(t *testing.T) {
+	address, other := [32]byte{0x0d}, [32]byte{0x0e}
+	batch := NewBatch()
+	batch.WriteBalance(address, 5, 100)
+	batch.WriteBalance(address, 9, 80)
+	batch.WriteBalance(other, 7, 1)
+	batch.Commit()
+	defer func() {
+		batch := NewBatch()
+		batch.DeleteBalance(address, 5)
+		batch.DeleteBalance(address, 9)
+		batch.DeleteBalance(other, 7)
+		batch.Commit()
+	}()
+
+	tests := []struct {
+		height  uint32
+		balance uint64
+		exists  bool
+	}{
+		{4, 0, false},
+		{5, 100, true},
+		{8, 100, true},
+		{9, 80, true},
+		{^uint32(0), 80, true},
+	}
+
+	for _, test := range tests {
+		if balance, exists := ReadBalanceAt(address, test.height); balance != test.balance || exists != test.exists {
+			t.Errorf("Balance at height %v: %v (%v) instead of %v (%v)\n", test.height, balance, exists, test.balance, test.exists)
+		}
+	}
+
+	if _, exists := ReadBalanceAt([32]byte{0x0f}, 10); exists {
+		t.Error("Balance found for an unknown address.\n")
+	}
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/boltdb/bolt"
+)
+
+//Blocks below the rollback horizon can not change anymore, copies of them which are not part of the chain the node is
+//on are garbage: full blocks whose emptied version is stored as well (left behind by versions which did not move
+//blocks within one transaction), closed blocks of forks which have not been rolled back and open blocks which have
+//never been validated. The garbage collection removes them, the miner runs it in the background.
+//Bolt never shrinks its file, the space of deleted entries is only reused. Compacting a database (node stopped)
+//collects the garbage and rewrites the database into a new file, which only takes the space of the remaining entries.
+
+//Removes the garbage below the horizon height from the open database and returns the number of removed entries.
+func CollectGarbage(horizon uint32) (removed int, err error) {
+	if db == nil {
+		return 0, errors.New("Database is not initialized.")
+	}
+
+	return collectGarbage(db, horizon)
+}
+
+func collectGarbage(db KV, horizon uint32) (removed int, err error) {
+	err = db.Update(func(tx KVTx) error {
+		heights := tx.Bucket([]byte("blockheights"))
+		emptied := tx.Bucket([]byte("closedblockswithouttx"))
+		if heights == nil || emptied == nil {
+			return errors.New("Database without a block height index, start the node once to create it.")
+		}
+
+		//The first 32 bytes of an index entry are the hash of the block on the chain.
+		forked := func(block *protocol.Block) bool {
+			indexed := heights.Get(heightKey(block.Height))
+			return block.Height > 0 && len(indexed) >= 32 && !bytes.Equal(indexed[:32], block.Hash[:])
+		}
+
+		collected := map[string]func(block *protocol.Block) bool{
+			"closedblocks": func(block *protocol.Block) bool {
+				return (block.HashWithoutTx != [32]byte{} && emptied.Get(block.HashWithoutTx[:]) != nil) || forked(block)
+			},
+			"closedblockswithouttx": forked,
+			"openblocks": func(block *protocol.Block) bool {
+				return true
+			},
+		}
+
+		for name, isGarbage := range collected {
+			b := tx.Bucket([]byte(name))
+			if b == nil {
+				continue
+			}
+
+			//Buckets must not be modified while iterating over them.
+			var garbage [][]byte
+			b.ForEach(func(k, v []byte) error {
+				var block *protocol.Block
+				if block, _ = block.Decode(v); block != nil && block.Height < horizon && isGarbage(block) {
+					garbage = append(garbage, append([]byte{}, k...))
+				}
+				return nil
+			})
+
+			for _, k := range garbage {
+				if err := b.Delete(k); err != nil {
+					return err
+				}
+			}
+			removed += len(garbage)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	return removed, nil
+}
+
+//Collects the garbage of the bolt database stored in dbname more than keptBlocks blocks below its last block and
+//rewrites it, the node must be stopped. Returns the number of removed entries and the file sizes before and after.
+func Compact(dbname string, keptBlocks uint32) (removed int, before int64, after int64, err error) {
+	info, err := os.Stat(dbname)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	before = info.Size()
+
+	srcDB, err := bolt.Open(dbname, 0600, &bolt.Options{Timeout: 1 * time.Second, ReadOnly: true})
+	if err != nil {
+		return 0, 0, 0, errors.New(fmt.Sprintf("Could not open database %v (is the node still running?): %v", dbname, err))
+	}
+	defer srcDB.Close()
+
+	compacted := dbname + ".compact"
+	os.Remove(compacted)
+	dstDB, err := bolt.Open(compacted, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	if err = copyDatabase(srcDB, dstDB); err == nil {
+		if height, exists := lastClosedHeight(dstDB); exists && height > keptBlocks {
+			removed, err = collectGarbage(&boltKV{dstDB}, height-keptBlocks)
+		}
+	}
+	dstDB.Close()
+	if err != nil {
+		os.Remove(compacted)
+		return 0, 0, 0, err
+	}
+
+	srcDB.Close()
+	if err = os.Rename(compacted, dbname); err != nil {
+		return 0, 0, 0, err
+	}
+
+	if info, err = os.Stat(dbname); err != nil {
+		return 0, 0, 0, err
+	}
+
+	return removed, before, info.Size(), nil
+}
+
+//Copies the buckets one transaction each, so the whole database does not have to be held in memory at once.
+func copyDatabase(srcDB *bolt.DB, dstDB *bolt.DB) error {
+	var names [][]byte
+	srcDB.View(func(tx *bolt.Tx) error {
+		return tx.ForEach(func(name []byte, _ *bolt.Bucket) error {
+			names = append(names, append([]byte{}, name...))
+			return nil
+		})
+	})
+
+	for _, name := range names {
+		err := srcDB.View(func(srcTx *bolt.Tx) error {
+			return dstDB.Update(func(dstTx *bolt.Tx) error {
+				dst, err := dstTx.CreateBucket(name)
+				if err != nil {
+					return err
+				}
+				return copyBucket(srcTx.Bucket(name), dst)
+			})
+		})
+		if err != nil {
+			return errors.New(fmt.Sprintf("Could not copy bucket %v: %v", string(name), err))
+		}
+	}
+
+	return nil
+}
+
+func copyBucket(src *bolt.Bucket, dst *bolt.Bucket) error {
+	if err := dst.SetSequence(src.Sequence()); err != nil {
+		return err
+	}
+
+	return src.ForEach(func(k, v []byte) error {
+		//Nested buckets have no value.
+		if v == nil {
+			nested, err := dst.CreateBucket(k)
+			if err != nil {
+				return err
+			}
+			return copyBucket(src.Bucket(k), nested)
+		}
+
+		return dst.Put(k, v)
+	})
+}
+
+func lastClosedHeight(fileDB *bolt.DB) (height uint32, exists bool) {
+	fileDB.View(func(tx *bolt.Tx) error {
+		b := tx.Bucket([]byte("lastclosedblock"))
+		if b == nil {
+			return nil
+		}
+
+		_, encoded := b.Cursor().First()
+		var block *protocol.Block
+		if block, _ = block.Decode(encoded); block != nil {
+			height, exists = block.Height, true
+		}
+		return nil
+	})
+
+	return height, exists
+}
@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/boltdb/bolt"
+)
+
+//Blocks at heights 1 to 4: the chain, an emptied block still stored in full, a fork and a block never validated.
+func writeGarbage(t *testing.T, db KV) (chain []*protocol.Block, garbage map[string]*protocol.Block) {
+	emptied := &protocol.Block{Hash: [32]byte{0x9a, 0x01}, HashWithoutTx: [32]byte{0x9b, 0x01}, Height: 1, Aggregated: true}
+	fork := &protocol.Block{Hash: [32]byte{0x9c, 0x02}, HashWithoutTx: [32]byte{0x9b, 0x12}, Height: 2}
+	chain = []*protocol.Block{
+		emptied,
+		{Hash: [32]byte{0x9a, 0x02}, HashWithoutTx: [32]byte{0x9b, 0x02}, Height: 2},
+		{Hash: [32]byte{0x9a, 0x03}, HashWithoutTx: [32]byte{0x9b, 0x03}, Height: 3},
+		{Hash: [32]byte{0x9a, 0x04}, HashWithoutTx: [32]byte{0x9b, 0x04}, Height: 4},
+	}
+	garbage = map[string]*protocol.Block{
+		"closedblocks":          emptied,
+		"closedblockswithouttx": fork,
+		"openblocks":            {Hash: [32]byte{0x9d, 0x01}, HashWithoutTx: [32]byte{0x9b, 0x21}, Height: 1},
+	}
+
+	err := db.Update(func(tx KVTx) error {
+		for _, block := range chain[1:] {
+			tx.Bucket([]byte("closedblocks")).Put(block.Hash[:], block.Encode())
+			tx.Bucket([]byte("blockheights")).Put(heightKey(block.Height), block.Hash[:])
+		}
+		tx.Bucket([]byte("closedblockswithouttx")).Put(emptied.HashWithoutTx[:], emptied.Encode())
+		tx.Bucket([]byte("blockheights")).Put(heightKey(emptied.Height), append(emptied.Hash[:], emptied.HashWithoutTx[:]...))
+		tx.Bucket([]byte("lastclosedblock")).Put(chain[3].Hash[:], chain[3].Encode())
+
+		tx.Bucket([]byte("closedblocks")).Put(emptied.Hash[:], emptied.Encode())
+		tx.Bucket([]byte("closedblockswithouttx")).Put(fork.HashWithoutTx[:], fork.Encode())
+		return tx.Bucket([]byte("openblocks")).Put(garbage["openblocks"].Hash[:], garbage["openblocks"].Encode())
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return chain, garbage
+}
+
+func checkGarbageCollected(t *testing.T, db KV, chain []*protocol.Block, garbage map[string]*protocol.Block) {
+	db.View(func(tx KVTx) error {
+		for name, block := range garbage {
+			key := block.Hash
+			if name == "closedblockswithouttx" {
+				key = block.HashWithoutTx
+			}
+			if tx.Bucket([]byte(name)).Get(key[:]) != nil {
+				t.Errorf("Block %x not removed from %v.\n", block.Hash[0:2], name)
+			}
+		}
+
+		if tx.Bucket([]byte("closedblockswithouttx")).Get(chain[0].HashWithoutTx[:]) == nil {
+			t.Error("Emptied block removed.\n")
+		}
+		for _, block := range chain[1:] {
+			if tx.Bucket([]byte("closedblocks")).Get(block.Hash[:]) == nil {
+				t.Errorf("Block %x of the chain removed.\n", block.Hash[0:2])
+			}
+		}
+		return nil
+	})
+}
+
+func TestCollectGarbage(t *testing.T) {
+	chain, garbage := writeGarbage(t, db)
+	defer func() {
+		for _, block := range chain {
+			DeleteClosedBlock(block.Hash)
+			DeleteBlockHeight(block.Height)
+		}
+		batch := NewBatch()
+		batch.delete("closedblockswithouttx", chain[0].HashWithoutTx[:])
+		batch.Commit()
+	}()
+
+	//Nothing is removed above the horizon.
+	if _, err := CollectGarbage(1); err != nil {
+		t.Fatal(err)
+	}
+	if ReadClosedBlock(chain[0].Hash) == nil {
+		t.Error("Block above the horizon removed.\n")
+	}
+
+	if removed, err := CollectGarbage(3); err != nil || removed < len(garbage) {
+		t.Errorf("Removed %v blocks (%v), expected at least %v.\n", removed, err, len(garbage))
+	}
+	checkGarbageCollected(t, db, chain, garbage)
+}
+
+func TestCompact(t *testing.T) {
+	dir, err := ioutil.TempDir("", "compact")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	dbname := filepath.Join(dir, "store.db")
+
+	fileDB, err := bolt.Open(dbname, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fileDB.Update(func(tx *bolt.Tx) error {
+		for _, name := range []string{"closedblocks", "closedblockswithouttx", "openblocks", "blockheights", "lastclosedblock", "closedfunds"} {
+			tx.CreateBucket([]byte(name))
+		}
+		return nil
+	})
+	chain, garbage := writeGarbage(t, &boltKV{fileDB})
+
+	//Entries deleted by an earlier garbage collection leave free pages behind.
+	filler := make([]byte, 1<<20)
+	fileDB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("closedfunds")).Put([]byte{0x01}, filler)
+	})
+	fileDB.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket([]byte("closedfunds")).Delete([]byte{0x01})
+	})
+	fileDB.Close()
+
+	removed, before, after, err := Compact(dbname, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != len(garbage) || after >= before {
+		t.Errorf("Removed %v blocks and compacted from %v to %v bytes, expected %v blocks and a smaller file.\n", removed, before, after, len(garbage))
+	}
+
+	if fileDB, err = bolt.Open(dbname, 0600, &bolt.Options{Timeout: 1 * time.Second}); err != nil {
+		t.Fatal(err)
+	}
+	defer fileDB.Close()
+	checkGarbageCollected(t, &boltKV{fileDB}, chain, garbage)
+}
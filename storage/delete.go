@@ -2,36 +2,39 @@ package storage
 
 import (
 	"github.com/bazo-blockchain/bazo-miner/protocol"
-	"github.com/boltdb/bolt"
 )
 
 //There exist open/closed buckets and closed tx buckets for all types (open txs are in volatile storage)
 func DeleteOpenBlock(hash [32]byte) {
-	db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("openblocks"))
-		err := b.Delete(hash[:])
-		return err
-	})
+	batch := NewBatch()
+	batch.DeleteOpenBlock(hash)
+	batch.Commit()
 }
 
 func DeleteClosedBlock(hash [32]byte) {
-	db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closedblocks"))
-		err := b.Delete(hash[:])
-		return err
-	})
+	batch := NewBatch()
+	batch.DeleteClosedBlock(hash)
+	batch.Commit()
 }
 
 func DeleteLastClosedBlock(hash [32]byte) {
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("lastclosedblock"))
 		err := b.Delete(hash[:])
 		return err
 	})
 }
 
+func DeleteGenesis() {
+	db.Update(func(tx KVTx) error {
+		b := tx.Bucket([]byte("genesis"))
+		err := b.Delete([]byte("genesis"))
+		return err
+	})
+}
+
 func DeleteAllLastClosedBlock() {
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("lastclosedblock"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -41,18 +44,6 @@ func DeleteAllLastClosedBlock() {
 	})
 }
 
-func DeleteOpenTx(transaction protocol.Transaction) {
-	openTxMutex.Lock()
-	defer openTxMutex.Unlock()
-	delete(txMemPool, transaction.Hash())
-}
-
-func DeleteOpenTxWithHash(transactionHash [32]byte) {
-	openTxMutex.Lock()
-	defer openTxMutex.Unlock()
-	delete(txMemPool, transactionHash)
-}
-
 func DeleteINVALIDOpenTx(transaction protocol.Transaction) {
 	delete(txINVALIDMemPool, transaction.Hash())
 }
@@ -72,49 +63,144 @@ func DeleteAllFundsTxBeforeAggregation(){
 }
 
 func DeleteClosedTx(transaction protocol.Transaction) {
-	var bucket string
-	switch transaction.(type) {
-	case *protocol.FundsTx:
-		bucket = "closedfunds"
-	case *protocol.AccTx:
-		bucket = "closedaccs"
-	case *protocol.ConfigTx:
-		bucket = "closedconfigs"
-	case *protocol.StakeTx:
-		bucket = "closedstakes"
-	case *protocol.AggTx:
-		bucket = "closedaggregations"
-	case *protocol.IotTx:
-		bucket = "closediotts"
-	}
+	batch := NewBatch()
+	batch.DeleteClosedTx(transaction)
+	batch.Commit()
+}
 
-	hash := transaction.Hash()
-	db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucket))
-		err := b.Delete(hash[:])
+func DeleteEpochAggregate(level uint8, firstHeight uint32) {
+	batch := NewBatch()
+	batch.DeleteEpochAggregate(level, firstHeight)
+	batch.Commit()
+}
+
+func DeleteEpochLeaves(level uint8, firstHeight uint32) {
+	batch := NewBatch()
+	batch.DeleteEpochLeaves(level, firstHeight)
+	batch.Commit()
+}
+
+func DeleteIotTxBlock(txHash [32]byte) {
+	batch := NewBatch()
+	batch.DeleteIotTxBlock(txHash)
+	batch.Commit()
+}
+
+func DeleteBeneficiaryBlock(beneficiary [32]byte, height uint32) {
+	batch := NewBatch()
+	batch.DeleteBeneficiaryBlock(beneficiary, height)
+	batch.Commit()
+}
+
+func DeleteSlashingBlock(slashedAddress [32]byte, height uint32) {
+	batch := NewBatch()
+	batch.DeleteSlashingBlock(slashedAddress, height)
+	batch.Commit()
+}
+
+func DeleteAccountTx(address [32]byte, height uint32, txHash [32]byte) {
+	batch := NewBatch()
+	batch.DeleteAccountTx(address, height, txHash)
+	batch.Commit()
+}
+
+func DeleteBlockHeight(height uint32) {
+	batch := NewBatch()
+	batch.DeleteBlockHeight(height)
+	batch.Commit()
+}
+
+func DeleteLogs(height uint32, contract [32]byte) {
+	batch := NewBatch()
+	batch.DeleteLogs(height, contract)
+	batch.Commit()
+}
+
+func DeleteSlashingProof(slashedAddress [32]byte) {
+	batch := NewBatch()
+	batch.DeleteSlashingProof(slashedAddress)
+	batch.Commit()
+}
+
+func DeleteValidatorSet(height uint32) {
+	batch := NewBatch()
+	batch.DeleteValidatorSet(height)
+	batch.Commit()
+}
+
+func DeleteLivenessEvidence(height uint32) {
+	batch := NewBatch()
+	batch.DeleteLivenessEvidence(height)
+	batch.Commit()
+}
+
+func DeleteSlashedStake(blockHash [32]byte) {
+	batch := NewBatch()
+	batch.DeleteSlashedStake(blockHash)
+	batch.Commit()
+}
+
+func DeleteBlockWeight(blockHash [32]byte) {
+	batch := NewBatch()
+	batch.DeleteBlockWeight(blockHash)
+	batch.Commit()
+}
+
+func DeleteSnapshot() {
+	db.Update(func(tx KVTx) error {
+		b := tx.Bucket([]byte("snapshot"))
+		err := b.Delete([]byte("latest"))
 		return err
 	})
-
-	nrClosedTransactions = nrClosedTransactions - 1
-	totalTransactionSize = totalTransactionSize - float32(transaction.Size())
-	averageTxSize = totalTransactionSize/nrClosedTransactions
 }
 
 func DeleteBootstrapReceivedMempool() {
 	//Delete in-memory storage
-	for key := range txMemPool {
-		delete(bootstrapReceivedMemPool, key)
+	for _, tx := range ReadAllOpenTxs() {
+		delete(bootstrapReceivedMemPool, tx.Hash())
 	}
 }
 
+//Recreating the bucket also resets its sequence number.
+func DeleteAllOutboundBroadcasts() {
+	db.Update(func(tx KVTx) error {
+		if err := tx.DeleteBucket([]byte("outboundbroadcasts")); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte("outboundbroadcasts"))
+		return err
+	})
+}
+
+func DeleteAllPersistedOpenTxs() {
+	db.Update(func(tx KVTx) error {
+		if err := tx.DeleteBucket([]byte("opentxs")); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte("opentxs"))
+		return err
+	})
+}
+
+func DeleteAllCheckpoints() {
+	db.Update(func(tx KVTx) error {
+		if err := tx.DeleteBucket([]byte("checkpoints")); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket([]byte("checkpoints"))
+		return err
+	})
+}
+
 func DeleteAll() {
 	//Delete in-memory storage
-	for key := range txMemPool {
-		delete(txMemPool, key)
+	if memPool != nil {
+		memPool.Clear()
 	}
 
 	//Delete disk-based storage
-	db.Update(func(tx *bolt.Tx) error {
+	closedTxCache.clear()
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("openblocks"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -122,7 +208,7 @@ func DeleteAll() {
 		})
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("closedblocks"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -130,7 +216,7 @@ func DeleteAll() {
 		})
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("closedblockswithouttx"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -138,7 +224,7 @@ func DeleteAll() {
 		})
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("closedfunds"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -146,7 +232,7 @@ func DeleteAll() {
 		})
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("closedaccs"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -154,7 +240,7 @@ func DeleteAll() {
 		})
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("closedconfigs"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -162,7 +248,7 @@ func DeleteAll() {
 		})
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("closedstakes"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -170,7 +256,7 @@ func DeleteAll() {
 		})
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("lastclosedblock"))
 		b.ForEach(func(k, v []byte) error {
 			b.Delete(k)
@@ -178,4 +264,27 @@ func DeleteAll() {
 		})
 		return nil
 	})
+	db.Update(func(tx KVTx) error {
+		b := tx.Bucket([]byte("iottxblocks"))
+		b.ForEach(func(k, v []byte) error {
+			b.Delete(k)
+			return nil
+		})
+		return nil
+	})
+	for _, bucket := range []string{"beneficiaryblocks", "slashingblocks", "blockheights", "accounttxs", "balances", "epochaggregates", "epochleaves", "removedaccs", "contractstates", "closedtxhashes", "closedcontracts", "logs", "slashingproofs", "validatorsets", "livenessevidence", "slashedstakes", "blockweights", "genesis"} {
+		db.Update(func(tx KVTx) error {
+			b := tx.Bucket([]byte(bucket))
+			b.ForEach(func(k, v []byte) error {
+				b.Delete(k)
+				return nil
+			})
+			return nil
+		})
+	}
+	DeleteSnapshot()
+	DeleteAllOutboundBroadcasts()
+	DeleteAllPersistedOpenTxs()
+	DeleteAllCheckpoints()
+	closedTxFilter.rebuild()
 }
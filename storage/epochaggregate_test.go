@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestEpochAggregates(t *testing.T) {
+	first := &protocol.EpochAggregate{Level: 1, FirstHeight: 0, LastHeight: 9, NrTxs: 2}
+	second := &protocol.EpochAggregate{Level: 1, FirstHeight: 10, LastHeight: 19}
+	parent := &protocol.EpochAggregate{Level: 2, FirstHeight: 0, LastHeight: 19, NrTxs: 2}
+	WriteEpochAggregate(first, [][32]byte{{0x01}, {0x02}})
+	WriteEpochAggregate(second, nil)
+	WriteEpochAggregate(parent, [][32]byte{first.Hash(), second.Hash()})
+	defer DeleteEpochAggregate(1, 0)
+	defer DeleteEpochAggregate(1, 10)
+	defer DeleteEpochAggregate(2, 0)
+
+	if aggregate := ReadEpochAggregate(1, 15); aggregate == nil || *aggregate != *second {
+		t.Errorf("Aggregate covering height 15 not found: %v\n", aggregate)
+	}
+	if aggregate := ReadEpochAggregate(1, 20); aggregate != nil {
+		t.Errorf("Aggregate found for a height not aggregated: %v\n", aggregate)
+	}
+	if aggregate := ReadEpochAggregate(2, 5); aggregate == nil || *aggregate != *parent {
+		t.Errorf("Level 2 aggregate covering height 5 not found: %v\n", aggregate)
+	}
+
+	if aggregate := ReadLastEpochAggregate(1); aggregate == nil || *aggregate != *second {
+		t.Errorf("Last level 1 aggregate not found: %v\n", aggregate)
+	}
+	if aggregate := ReadLastEpochAggregate(3); aggregate != nil {
+		t.Errorf("Level 3 aggregate found: %v\n", aggregate)
+	}
+
+	if aggregates := ReadEpochAggregates(1, 0, 10); len(aggregates) != 2 || *aggregates[1] != *second {
+		t.Errorf("Unexpected level 1 aggregates: %v\n", aggregates)
+	}
+
+	if aggregate, leaves := FindEpochLeaf([32]byte{0x02}); aggregate == nil || *aggregate != *first || len(leaves) != 2 {
+		t.Errorf("Aggregate of the leaf not found: %v\n", aggregate)
+	}
+
+	DeleteEpochLeaves(1, 0)
+	if aggregate, _ := FindEpochLeaf([32]byte{0x02}); aggregate != nil || ReadEpochLeaves(1, 0) != nil || ReadEpochAggregate(1, 0) == nil {
+		t.Error("Only the leaves of the aggregate should have been deleted.")
+	}
+}
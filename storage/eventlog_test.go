@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestLogs(t *testing.T) {
+	contract, other := [32]byte{0x01}, [32]byte{0x02}
+	WriteLogs([]*protocol.Log{
+		{Contract: other, Height: 5, Index: 0, Topics: []protocol.ByteArray{{0x0a}}},
+		{Contract: contract, Height: 5, Index: 1, Topics: []protocol.ByteArray{{0x0a}, {0x0b}}, Data: []byte{0x03}},
+		{Contract: other, Height: 5, Index: 2},
+	})
+	WriteLogs([]*protocol.Log{{Contract: contract, Height: 300, Topics: []protocol.ByteArray{{0x0b}}}})
+	defer func() {
+		DeleteLogs(5, contract)
+		DeleteLogs(5, other)
+		DeleteLogs(300, contract)
+	}()
+
+	if logs := ReadLogs([32]byte{}, nil, 0, 1000); len(logs) != 4 || logs[0].Index != 0 || logs[1].Index != 1 || logs[2].Index != 2 || logs[3].Height != 300 {
+		t.Errorf("Logs not read in order: %v\n", logs)
+	}
+
+	if logs := ReadLogs(contract, nil, 0, 1000); len(logs) != 2 || logs[0].Contract != contract || string(logs[0].Data) != "\x03" {
+		t.Errorf("Invalid logs of contract: %v\n", logs)
+	}
+
+	if logs := ReadLogs([32]byte{}, []byte{0x0a}, 0, 1000); len(logs) != 2 {
+		t.Errorf("Invalid logs with topic: %v\n", logs)
+	}
+
+	if logs := ReadLogs(contract, []byte{0x0b}, 6, 300); len(logs) != 1 || logs[0].Height != 300 {
+		t.Errorf("Invalid logs in height range: %v\n", logs)
+	}
+
+	DeleteLogs(5, other)
+	if logs := ReadLogs([32]byte{}, nil, 5, 5); len(logs) != 1 || logs[0].Contract != contract {
+		t.Errorf("Logs not deleted: %v\n", logs)
+	}
+}
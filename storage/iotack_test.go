@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestReadIotAck(t *testing.T) {
+	tx := &protocol.IotTx{From: [32]byte{0x01}, Data: []byte("reading"), Sequence: 1}
+	txHash := tx.Hash()
+
+	if _, err := ReadIotAck(txHash); err == nil {
+		t.Error("Ack returned for an unconfirmed tx.\n")
+	}
+
+	block := &protocol.Block{Hash: [32]byte{0x0b}, Height: 5, IoTTxData: [][32]byte{txHash, {0x02}}}
+	block.MerkleRoot = protocol.BuildMerkleTree(block).MerkleRoot()
+	lastBlock := &protocol.Block{Hash: [32]byte{0x0c}, Height: 7}
+
+	WriteClosedBlock(block)
+	WriteClosedTx(tx)
+	WriteIotTxBlock(txHash, block.Hash)
+	DeleteAllLastClosedBlock()
+	WriteLastClosedBlock(lastBlock)
+	defer func() {
+		DeleteClosedBlock(block.Hash)
+		DeleteClosedTx(tx)
+		DeleteIotTxBlock(txHash)
+		DeleteAllLastClosedBlock()
+	}()
+
+	ack, err := ReadIotAck(txHash)
+	if err != nil {
+		t.Fatalf("Could not read ack: %v\n", err)
+	}
+
+	if ack.BlockHash != block.Hash || ack.Confirmations != 3 || !ack.Verify(tx) {
+		t.Errorf("Invalid ack: %v\n", ack)
+	}
+
+	DeleteIotTxBlock(txHash)
+	if _, err := ReadIotAck(txHash); err == nil {
+		t.Error("Ack returned after the tx was rolled back.\n")
+	}
+}
+
+func TestReadPrunedIotAck(t *testing.T) {
+	tx := &protocol.IotTx{From: [32]byte{0x01}, Data: []byte("pruned reading"), Sequence: 2}
+	txHash := tx.Hash()
+
+	block := &protocol.Block{Hash: [32]byte{0x0d}, Height: 5, IoTTxData: [][32]byte{txHash}}
+	block.MerkleRoot = protocol.BuildMerkleTree(block).MerkleRoot()
+
+	WriteClosedBlock(block)
+	WriteClosedTx(tx)
+	WriteIotTxBlock(txHash, block.Hash)
+	DeleteAllLastClosedBlock()
+	WriteLastClosedBlock(block)
+	defer func() {
+		DeleteClosedBlock(block.Hash)
+		DeleteClosedTx(tx)
+		DeleteIotTxBlock(txHash)
+		DeleteAllLastClosedBlock()
+	}()
+
+	batch := NewBatch()
+	batch.PruneIotTx(txHash, tx)
+	if err := batch.Commit(); err != nil {
+		t.Fatalf("Could not prune tx: %v\n", err)
+	}
+
+	if pruned, ok := ReadClosedTx(txHash).(*protocol.IotTx); !ok || pruned.Data != nil || pruned.Sequence != tx.Sequence {
+		t.Errorf("Pruned tx should be stored without data: %v\n", pruned)
+	}
+
+	ack, err := ReadIotAck(txHash)
+	if err != nil {
+		t.Fatalf("Could not read ack of pruned tx: %v\n", err)
+	}
+
+	//The data restored from an archive still verifies.
+	if ack.TxHash != txHash || !ack.Verify(tx) {
+		t.Errorf("Ack of pruned tx does not verify the original tx: %v\n", ack)
+	}
+}
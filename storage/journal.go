@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//The state transition of a block consists of many changes to the accounts. While a transition is open, the previous
+//version of every account accessed through GetAccount is recorded, so the transition can be reverted as a whole if
+//the block turns out to be invalid or the validation panics halfway. All changes to State and RootKeys during a
+//transition need to be made through accounts fetched with GetAccount.
+
+type journalEntry struct {
+	acc    *protocol.Account
+	value  protocol.Account
+	isRoot bool
+}
+
+var (
+	journal      map[[32]byte]*journalEntry
+	journalMutex = &sync.Mutex{}
+)
+
+//Starts recording the state changes, an open transition is committed first.
+func BeginStateTransition() {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	journal = make(map[[32]byte]*journalEntry)
+}
+
+//Keeps the state changes made since BeginStateTransition.
+func CommitStateTransition() {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	journal = nil
+}
+
+//Restores the accounts (and root keys) to their state at BeginStateTransition. Accounts created during the transition
+//are removed. Does nothing if no transition is open, so it can be deferred.
+func RevertStateTransition() {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	for hash, entry := range journal {
+		if entry.acc == nil {
+			State.Delete(hash)
+			delete(RootKeys, hash)
+			continue
+		}
+
+		//The account is restored in place, other references (e.g., in RootKeys) stay valid.
+		*entry.acc = entry.value
+		State.Set(hash, entry.acc)
+		if entry.isRoot {
+			RootKeys[hash] = entry.acc
+		} else {
+			delete(RootKeys, hash)
+		}
+	}
+
+	journal = nil
+}
+
+//Returns the current balances of the accounts whose balance changed in the open transition, accounts removed during the
+//transition have a balance of 0.
+func ChangedBalances() map[[32]byte]uint64 {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	balances := make(map[[32]byte]uint64)
+	for hash, entry := range journal {
+		var before, after uint64
+		if entry.acc != nil {
+			before = entry.value.Balance
+		}
+		if acc := State.Get(hash); acc != nil {
+			after = acc.Balance
+		}
+
+		if before != after || (entry.acc == nil) != (State.Get(hash) == nil) {
+			balances[hash] = after
+		}
+	}
+
+	return balances
+}
+
+func IsStateTransitionOpen() bool {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	return journal != nil
+}
+
+//Records the account before it is changed for the first time in the open transition.
+func recordAccount(hash [32]byte) {
+	journalMutex.Lock()
+	defer journalMutex.Unlock()
+
+	if journal == nil {
+		return
+	}
+	if _, recorded := journal[hash]; recorded {
+		return
+	}
+
+	entry := &journalEntry{acc: State.Get(hash), isRoot: IsRootKey(hash)}
+	if entry.acc != nil {
+		entry.value = copyAccount(entry.acc)
+	}
+	journal[hash] = entry
+}
+
+//The contract and its variables are copied as well, since contract executions change them in place.
+func copyAccount(acc *protocol.Account) protocol.Account {
+	value := *acc
+	value.Contract = append([]byte(nil), acc.Contract...)
+
+	if acc.ContractVariables != nil {
+		value.ContractVariables = make([]protocol.ByteArray, len(acc.ContractVariables))
+		for i, variable := range acc.ContractVariables {
+			value.ContractVariables[i] = append(protocol.ByteArray(nil), variable...)
+		}
+	}
+
+	return value
+}
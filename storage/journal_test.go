@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestStateTransition(t *testing.T) {
+	changed := &protocol.Account{Address: [32]byte{0x71}, Balance: 100, Contract: []byte{0x01}}
+	root := &protocol.Account{Address: [32]byte{0x72}, Balance: 200}
+	State.Set(changed.Address, changed)
+	State.Set(root.Address, root)
+	RootKeys[root.Address] = root
+	defer func() {
+		State.Delete(changed.Address)
+		State.Delete(root.Address)
+		delete(RootKeys, root.Address)
+	}()
+
+	BeginStateTransition()
+
+	acc, _ := GetAccount(changed.Address)
+	acc.Balance += 50
+	acc.TxCnt++
+	acc.Contract[0] = 0x02
+
+	GetAccount(root.Address)
+	delete(RootKeys, root.Address)
+
+	created := [32]byte{0x73}
+	GetAccount(created)
+	State.Set(created, &protocol.Account{Address: created})
+
+	RevertStateTransition()
+
+	if changed.Balance != 100 || changed.TxCnt != 0 || changed.Contract[0] != 0x01 || State.Get(changed.Address) != changed {
+		t.Errorf("Changed account not reverted: %v\n", changed)
+	}
+	if RootKeys[root.Address] != root {
+		t.Error("Root key not restored.")
+	}
+	if State.Get(created) != nil {
+		t.Error("Account created during the transition not removed.")
+	}
+
+	//Committed changes are kept, reverting without an open transition has no effect.
+	BeginStateTransition()
+	acc, _ = GetAccount(changed.Address)
+	acc.Balance = 10
+	CommitStateTransition()
+	RevertStateTransition()
+
+	if changed.Balance != 10 || IsStateTransitionOpen() {
+		t.Errorf("Committed change reverted: %v\n", changed)
+	}
+}
+
+func TestChangedBalances(t *testing.T) {
+	paid := &protocol.Account{Address: [32]byte{0x74}, Balance: 100}
+	touched := &protocol.Account{Address: [32]byte{0x75}, Balance: 200}
+	removed := &protocol.Account{Address: [32]byte{0x76}, Balance: 300}
+	State.Set(paid.Address, paid)
+	State.Set(touched.Address, touched)
+	State.Set(removed.Address, removed)
+	defer func() {
+		State.Delete(paid.Address)
+		State.Delete(touched.Address)
+		State.Delete(removed.Address)
+	}()
+
+	BeginStateTransition()
+	defer RevertStateTransition()
+
+	acc, _ := GetAccount(paid.Address)
+	acc.Balance -= 40
+
+	//Only the txCnt changes.
+	acc, _ = GetAccount(touched.Address)
+	acc.TxCnt++
+
+	GetAccount(removed.Address)
+	State.Delete(removed.Address)
+
+	created := [32]byte{0x77}
+	GetAccount(created)
+	State.Set(created, &protocol.Account{Address: created, Balance: 5})
+
+	balances := ChangedBalances()
+	if len(balances) != 3 || balances[paid.Address] != 60 || balances[removed.Address] != 0 || balances[created] != 5 {
+		t.Errorf("Unexpected changed balances: %v\n", balances)
+	}
+}
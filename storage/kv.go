@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boltdb/bolt"
+)
+
+//The storage package accesses the database through the KV interface, which follows the model of BoltDB: named
+//buckets of sorted keys, read-only (View) and read-write (Update) transactions and cursors to iterate over a bucket.
+//BoltDB is the default backend. It allows a single writer at a time, under heavy block validation and IoT tx
+//ingestion the LevelDB backend (kv_leveldb.go, built with -tags leveldb) can be used instead. The backend is chosen
+//with SetBackend before Init, a database can only be opened with the backend that created it.
+//Values returned by Get and the cursors are only valid within the transaction.
+
+const (
+	BACKEND_BOLT    = "bolt"
+	BACKEND_LEVELDB = "leveldb"
+)
+
+type KV interface {
+	View(fn func(tx KVTx) error) error
+	Update(fn func(tx KVTx) error) error
+	Close() error
+}
+
+type KVTx interface {
+	//Returns nil if the bucket does not exist.
+	Bucket(name []byte) KVBucket
+	CreateBucket(name []byte) (KVBucket, error)
+	DeleteBucket(name []byte) error
+}
+
+type KVBucket interface {
+	//Returns nil if the key does not exist.
+	Get(key []byte) []byte
+	Put(key []byte, value []byte) error
+	Delete(key []byte) error
+	//Calls fn for every key in order, keys must not be added or deleted within fn.
+	ForEach(fn func(k, v []byte) error) error
+	Cursor() KVCursor
+	NextSequence() (uint64, error)
+}
+
+//The methods return a nil key once the cursor moved past the first or the last key of the bucket.
+type KVCursor interface {
+	First() (key []byte, value []byte)
+	Last() (key []byte, value []byte)
+	Seek(seek []byte) (key []byte, value []byte)
+	Next() (key []byte, value []byte)
+	Prev() (key []byte, value []byte)
+}
+
+var (
+	backend = BACKEND_BOLT
+
+	backends = map[string]func(dbname string) (KV, error){
+		BACKEND_BOLT:    openBolt,
+		BACKEND_LEVELDB: openLevelDB,
+	}
+)
+
+//Sets the backend used by Init.
+func SetBackend(name string) error {
+	if _, exists := backends[name]; !exists {
+		return errors.New(fmt.Sprintf("Unknown database backend %v, expected %v or %v.", name, BACKEND_BOLT, BACKEND_LEVELDB))
+	}
+	backend = name
+
+	return nil
+}
+
+func openKV(dbname string) (KV, error) {
+	return backends[backend](dbname)
+}
+
+type boltKV struct {
+	db *bolt.DB
+}
+
+type boltTx struct {
+	tx *bolt.Tx
+}
+
+type boltBucket struct {
+	*bolt.Bucket
+}
+
+func openBolt(dbname string) (KV, error) {
+	db, err := bolt.Open(dbname, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, err
+	}
+
+	return &boltKV{db}, nil
+}
+
+func (kv *boltKV) View(fn func(tx KVTx) error) error {
+	return kv.db.View(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (kv *boltKV) Update(fn func(tx KVTx) error) error {
+	return kv.db.Update(func(tx *bolt.Tx) error {
+		return fn(boltTx{tx})
+	})
+}
+
+func (kv *boltKV) Close() error {
+	return kv.db.Close()
+}
+
+func (tx boltTx) Bucket(name []byte) KVBucket {
+	//A nil *bolt.Bucket must not end up in a non-nil interface.
+	if b := tx.tx.Bucket(name); b != nil {
+		return boltBucket{b}
+	}
+
+	return nil
+}
+
+func (tx boltTx) CreateBucket(name []byte) (KVBucket, error) {
+	b, err := tx.tx.CreateBucket(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return boltBucket{b}, nil
+}
+
+func (tx boltTx) DeleteBucket(name []byte) error {
+	return tx.tx.DeleteBucket(name)
+}
+
+func (b boltBucket) Cursor() KVCursor {
+	return b.Bucket.Cursor()
+}
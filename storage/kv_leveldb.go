@@ -0,0 +1,253 @@
+//go:build leveldb
+// +build leveldb
+
+package storage
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+//LevelDB has a single sorted key space, the buckets are mapped to key prefixes: 'm' followed by the name marks an
+//existing bucket, 'd', the name and a zero byte prefix the keys of the bucket and 's' followed by the name holds the
+//sequence of the bucket. Read-only transactions read from a snapshot, read-write transactions use LevelDB
+//transactions, which see their own writes and are applied atomically on commit.
+
+type levelKV struct {
+	db *leveldb.DB
+}
+
+//Implemented by snapshots and transactions.
+type levelReader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+type levelTx struct {
+	reader levelReader
+	//Nil in read-only transactions.
+	writer *leveldb.Transaction
+	//Released at the end of the transaction.
+	iterators []iterator.Iterator
+}
+
+type levelBucket struct {
+	tx   *levelTx
+	name []byte
+}
+
+type levelCursor struct {
+	prefix []byte
+	it     iterator.Iterator
+}
+
+func openLevelDB(dbname string) (KV, error) {
+	db, err := leveldb.OpenFile(dbname, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return &levelKV{db}, nil
+}
+
+func (kv *levelKV) View(fn func(tx KVTx) error) error {
+	snapshot, err := kv.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snapshot.Release()
+
+	tx := &levelTx{reader: snapshot}
+	defer tx.release()
+
+	return fn(tx)
+}
+
+//The transaction is discarded if fn fails or panics.
+func (kv *levelKV) Update(fn func(tx KVTx) error) error {
+	transaction, err := kv.db.OpenTransaction()
+	if err != nil {
+		return err
+	}
+	defer transaction.Discard()
+
+	tx := &levelTx{reader: transaction, writer: transaction}
+	err = fn(tx)
+	tx.release()
+	if err != nil {
+		return err
+	}
+
+	return transaction.Commit()
+}
+
+func (kv *levelKV) Close() error {
+	return kv.db.Close()
+}
+
+func (tx *levelTx) release() {
+	for _, it := range tx.iterators {
+		it.Release()
+	}
+	tx.iterators = nil
+}
+
+func (tx *levelTx) has(key []byte) bool {
+	_, err := tx.reader.Get(key, nil)
+	return err == nil
+}
+
+func (tx *levelTx) Bucket(name []byte) KVBucket {
+	if !tx.has(levelMarkerKey(name)) {
+		return nil
+	}
+
+	return &levelBucket{tx, append([]byte(nil), name...)}
+}
+
+func (tx *levelTx) CreateBucket(name []byte) (KVBucket, error) {
+	if tx.writer == nil {
+		return nil, errors.New("Transaction is read-only.")
+	}
+	if tx.has(levelMarkerKey(name)) {
+		return nil, errors.New("Bucket already exists.")
+	}
+	if err := tx.writer.Put(levelMarkerKey(name), []byte{}, nil); err != nil {
+		return nil, err
+	}
+
+	return &levelBucket{tx, append([]byte(nil), name...)}, nil
+}
+
+func (tx *levelTx) DeleteBucket(name []byte) error {
+	b := tx.Bucket(name)
+	if b == nil {
+		return errors.New("Bucket not found.")
+	}
+	if err := clearBucket(b); err != nil {
+		return err
+	}
+	if err := tx.writer.Delete(levelSequenceKey(name), nil); err != nil {
+		return err
+	}
+
+	return tx.writer.Delete(levelMarkerKey(name), nil)
+}
+
+func (b *levelBucket) Get(key []byte) []byte {
+	value, err := b.tx.reader.Get(append(levelDataPrefix(b.name), key...), nil)
+	if err != nil {
+		return nil
+	}
+
+	return value
+}
+
+func (b *levelBucket) Put(key []byte, value []byte) error {
+	if b.tx.writer == nil {
+		return errors.New("Transaction is read-only.")
+	}
+	if len(key) == 0 {
+		return errors.New("Key required.")
+	}
+
+	return b.tx.writer.Put(append(levelDataPrefix(b.name), key...), value, nil)
+}
+
+func (b *levelBucket) Delete(key []byte) error {
+	if b.tx.writer == nil {
+		return errors.New("Transaction is read-only.")
+	}
+
+	return b.tx.writer.Delete(append(levelDataPrefix(b.name), key...), nil)
+}
+
+func (b *levelBucket) ForEach(fn func(k, v []byte) error) error {
+	c := b.Cursor()
+	for k, v := c.First(); k != nil; k, v = c.Next() {
+		if err := fn(k, v); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (b *levelBucket) Cursor() KVCursor {
+	prefix := levelDataPrefix(b.name)
+	it := b.tx.reader.NewIterator(util.BytesPrefix(prefix), nil)
+	b.tx.iterators = append(b.tx.iterators, it)
+
+	return &levelCursor{prefix, it}
+}
+
+func (b *levelBucket) NextSequence() (uint64, error) {
+	if b.tx.writer == nil {
+		return 0, errors.New("Transaction is read-only.")
+	}
+
+	var seq uint64
+	if encoded, err := b.tx.reader.Get(levelSequenceKey(b.name), nil); err == nil && len(encoded) == 8 {
+		seq = binary.BigEndian.Uint64(encoded)
+	}
+	seq++
+
+	var encoded [8]byte
+	binary.BigEndian.PutUint64(encoded[:], seq)
+	if err := b.tx.writer.Put(levelSequenceKey(b.name), encoded[:], nil); err != nil {
+		return 0, err
+	}
+
+	return seq, nil
+}
+
+func (c *levelCursor) First() ([]byte, []byte) {
+	return c.entry(c.it.First())
+}
+
+func (c *levelCursor) Last() ([]byte, []byte) {
+	return c.entry(c.it.Last())
+}
+
+func (c *levelCursor) Seek(seek []byte) ([]byte, []byte) {
+	return c.entry(c.it.Seek(append(append([]byte(nil), c.prefix...), seek...)))
+}
+
+func (c *levelCursor) Next() ([]byte, []byte) {
+	return c.entry(c.it.Next())
+}
+
+func (c *levelCursor) Prev() ([]byte, []byte) {
+	return c.entry(c.it.Prev())
+}
+
+//The iterator reuses its buffers, the entry is copied.
+func (c *levelCursor) entry(valid bool) ([]byte, []byte) {
+	if !valid {
+		return nil, nil
+	}
+
+	key := make([]byte, len(c.it.Key())-len(c.prefix))
+	copy(key, c.it.Key()[len(c.prefix):])
+	value := make([]byte, len(c.it.Value()))
+	copy(value, c.it.Value())
+
+	return key, value
+}
+
+func levelMarkerKey(name []byte) []byte {
+	return append([]byte{'m'}, name...)
+}
+
+func levelSequenceKey(name []byte) []byte {
+	return append([]byte{'s'}, name...)
+}
+
+func levelDataPrefix(name []byte) []byte {
+	return append(append([]byte{'d'}, name...), 0x00)
+}
@@ -0,0 +1,13 @@
+//go:build !leveldb
+// +build !leveldb
+
+package storage
+
+import (
+	"errors"
+)
+
+//Without the leveldb build tag, the LevelDB dependency is not compiled in.
+func openLevelDB(dbname string) (KV, error) {
+	return nil, errors.New("Node built without LevelDB support, build with -tags leveldb.")
+}
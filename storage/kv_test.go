@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestKVCursor(t *testing.T) {
+	db.Update(func(tx KVTx) error {
+		b := tx.Bucket([]byte("blockheights"))
+		for _, height := range []uint32{3, 1, 2} {
+			b.Put(heightKey(height), []byte{byte(height)})
+		}
+		return nil
+	})
+	defer func() {
+		for height := uint32(1); height <= 3; height++ {
+			DeleteBlockHeight(height)
+		}
+	}()
+
+	var heights []byte
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("blockheights")).Cursor()
+		for k, v := c.Seek(heightKey(2)); k != nil; k, v = c.Next() {
+			heights = append(heights, v[0])
+		}
+		_, v := c.Last()
+		heights = append(heights, v[0])
+		return nil
+	})
+	if string(heights) != string([]byte{2, 3, 3}) {
+		t.Errorf("Cursor returned %v instead of [2 3 3].\n", heights)
+	}
+
+	db.View(func(tx KVTx) error {
+		if tx.Bucket([]byte("unknown")) != nil {
+			t.Error("Unknown bucket should be nil.")
+		}
+		return nil
+	})
+
+	if err := SetBackend("unknown"); err == nil || backend != BACKEND_BOLT {
+		t.Error("Unknown backend should be rejected.")
+	}
+}
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//Open (not yet validated) transactions are held in the miner's mempool, which enforces size limits, eviction and
+//txCnt ordering. It is registered here so all packages (the p2p package in particular) access it the same way.
+type Mempool interface {
+	Add(tx protocol.Transaction) error
+	AddOrdered(tx protocol.Transaction) error
+	Get(hash [32]byte) protocol.Transaction
+	Remove(hash [32]byte)
+	All() []protocol.Transaction
+	IsParked(tx protocol.Transaction) bool
+	Clear()
+}
+
+var memPool Mempool
+
+func SetMempool(pool Mempool) {
+	memPool = pool
+}
+
+//Changing the "tx" shortcut here and using "transaction" to distinguish between bolt's transactions
+func WriteOpenTx(transaction protocol.Transaction) error {
+	if memPool == nil {
+		return errors.New("Mempool not initialized.")
+	}
+
+	return memPool.Add(transaction)
+}
+
+//Writes the transaction to the mempool if it is the next one of its sender, parks it if there is a gap in the txCnt
+//and rejects it if its txCnt has already been used.
+func WriteOpenTxOrdered(transaction protocol.Transaction) error {
+	if memPool == nil {
+		return errors.New("Mempool not initialized.")
+	}
+
+	return memPool.AddOrdered(transaction)
+}
+
+func ReadOpenTx(hash [32]byte) (transaction protocol.Transaction) {
+	if memPool == nil {
+		return nil
+	}
+
+	return memPool.Get(hash)
+}
+
+//Needed for the miner to prepare a new block
+func ReadAllOpenTxs() (allOpenTxs []protocol.Transaction) {
+	if memPool == nil {
+		return nil
+	}
+
+	return memPool.All()
+}
+
+func IsParkedTx(transaction protocol.Transaction) bool {
+	return memPool != nil && memPool.IsParked(transaction)
+}
+
+func DeleteOpenTx(transaction protocol.Transaction) {
+	DeleteOpenTxWithHash(transaction.Hash())
+}
+
+func DeleteOpenTxWithHash(transactionHash [32]byte) {
+	if memPool != nil {
+		memPool.Remove(transactionHash)
+	}
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+	"github.com/boltdb/bolt"
+)
+
+//Databases written by earlier versions contain blocks and txs encoded with gob. They are still decoded transparently,
+//the migration rewrites them with the binary codec of the protocol package. Config and stake txs have always been
+//encoded in binary and are left as they are.
+
+var migratedBuckets = map[string]func(encoded []byte) []byte{
+	"openblocks":            reencodeBlock,
+	"closedblocks":          reencodeBlock,
+	"closedblockswithouttx": reencodeBlock,
+	"lastclosedblock":       reencodeBlock,
+	"closedfunds":           reencodeFundsTx,
+	"closedaccs":            reencodeAccTx,
+	"closedaggregations":    reencodeAggTx,
+	"closediotts":           reencodeIotTx,
+}
+
+//Migrates the bolt database stored in dbname, the node must be stopped. Returns the number of rewritten entries. The
+//other backends were added after the binary codec and need no migration.
+func MigrateEncoding(dbname string) (migrated int, err error) {
+	fileDB, err := bolt.Open(dbname, 0600, &bolt.Options{Timeout: 1 * time.Second})
+	if err != nil {
+		return 0, errors.New(fmt.Sprintf("Could not open database %v (is the node still running?): %v", dbname, err))
+	}
+	defer fileDB.Close()
+
+	return migrateEncoding(&boltKV{fileDB})
+}
+
+//All entries are migrated within a single transaction, an entry which can not be decoded aborts the migration and
+//leaves the database untouched.
+func migrateEncoding(db KV) (migrated int, err error) {
+	err = db.Update(func(tx KVTx) error {
+		for name, reencode := range migratedBuckets {
+			b := tx.Bucket([]byte(name))
+			if b == nil {
+				continue
+			}
+
+			//Buckets must not be modified while iterating over them.
+			updates := make(map[string][]byte)
+			err := b.ForEach(func(k, v []byte) error {
+				if protocol.IsBinaryEncoded(v) {
+					return nil
+				}
+
+				encoded := reencode(v)
+				if encoded == nil {
+					return errors.New(fmt.Sprintf("Could not decode entry %x in bucket %v.", k, name))
+				}
+				updates[string(k)] = encoded
+
+				return nil
+			})
+			if err != nil {
+				return err
+			}
+
+			for k, encoded := range updates {
+				if err := b.Put([]byte(k), encoded); err != nil {
+					return err
+				}
+			}
+			migrated += len(updates)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, err
+	}
+	closedTxCache.clear()
+	closedTxFilter.rebuild()
+
+	return migrated, nil
+}
+
+func reencodeBlock(encoded []byte) []byte {
+	var block *protocol.Block
+	if block, _ = block.Decode(encoded); block == nil {
+		return nil
+	}
+
+	return block.Encode()
+}
+
+func reencodeFundsTx(encoded []byte) []byte {
+	var tx *protocol.FundsTx
+	if tx, _ = tx.Decode(encoded); tx == nil {
+		return nil
+	}
+
+	return tx.Encode()
+}
+
+func reencodeAccTx(encoded []byte) []byte {
+	var tx *protocol.AccTx
+	if tx, _ = tx.Decode(encoded); tx == nil {
+		return nil
+	}
+
+	return tx.Encode()
+}
+
+func reencodeAggTx(encoded []byte) []byte {
+	var tx *protocol.AggTx
+	if tx, _ = tx.Decode(encoded); tx == nil {
+		return nil
+	}
+
+	return tx.Encode()
+}
+
+func reencodeIotTx(encoded []byte) []byte {
+	var tx *protocol.IotTx
+	if tx, _ = tx.Decode(encoded); tx == nil {
+		return nil
+	}
+
+	return tx.Encode()
+}
@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/gob"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestMigrateEncoding(t *testing.T) {
+	block := &protocol.Block{Hash: [32]byte{0x01}, Height: 3, FundsTxData: [][32]byte{{0x02}}}
+	tx := &protocol.FundsTx{Amount: 10, From: [32]byte{0x03}, To: [32]byte{0x04}}
+	txHash := tx.Hash()
+
+	//Legacy entries as written by earlier versions.
+	legacyBlock, legacyTx := new(bytes.Buffer), new(bytes.Buffer)
+	gob.NewEncoder(legacyBlock).Encode(block)
+	gob.NewEncoder(legacyTx).Encode(tx)
+	db.Update(func(kvTx KVTx) error {
+		kvTx.Bucket([]byte("closedblocks")).Put(block.Hash[:], legacyBlock.Bytes())
+		return kvTx.Bucket([]byte("closedfunds")).Put(txHash[:], legacyTx.Bytes())
+	})
+	defer DeleteClosedBlock(block.Hash)
+	defer DeleteClosedTx(tx)
+
+	if migrated, err := migrateEncoding(db); err != nil || migrated != 2 {
+		t.Fatalf("Migrated %v entries (%v) instead of 2.\n", migrated, err)
+	}
+
+	db.View(func(kvTx KVTx) error {
+		if !protocol.IsBinaryEncoded(kvTx.Bucket([]byte("closedblocks")).Get(block.Hash[:])) {
+			t.Error("Block not migrated.\n")
+		}
+		if !protocol.IsBinaryEncoded(kvTx.Bucket([]byte("closedfunds")).Get(txHash[:])) {
+			t.Error("Tx not migrated.\n")
+		}
+		return nil
+	})
+
+	if migratedBlock := ReadClosedBlock(block.Hash); migratedBlock == nil || migratedBlock.Height != 3 || len(migratedBlock.FundsTxData) != 1 {
+		t.Errorf("Migrated block not readable: %v\n", migratedBlock)
+	}
+	if migratedTx := ReadClosedTx(txHash); migratedTx == nil || migratedTx.Hash() != txHash {
+		t.Errorf("Migrated tx not readable: %v\n", migratedTx)
+	}
+
+	if migrated, err := migrateEncoding(db); err != nil || migrated != 0 {
+		t.Errorf("Migrated %v entries (%v) on the second run.\n", migrated, err)
+	}
+
+	//An entry which can not be decoded aborts the migration without changing anything.
+	db.Update(func(kvTx KVTx) error {
+		return kvTx.Bucket([]byte("closedaccs")).Put([]byte{0x05}, []byte{0x01, 0x02})
+	})
+	defer db.Update(func(kvTx KVTx) error {
+		return kvTx.Bucket([]byte("closedaccs")).Delete([]byte{0x05})
+	})
+	if _, err := migrateEncoding(db); err == nil {
+		t.Error("Invalid entry migrated.\n")
+	}
+}
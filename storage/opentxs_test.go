@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestWriteReadPersistedOpenTxs(t *testing.T) {
+	defer DeleteAllPersistedOpenTxs()
+
+	txs := []protocol.Transaction{
+		&protocol.FundsTx{Amount: 100, Fee: 1, TxCnt: 2, From: [32]byte{'a'}, To: [32]byte{'b'}},
+		&protocol.FundsTx{Amount: 100, Fee: 1, TxCnt: 1, From: [32]byte{'a'}, To: [32]byte{'b'}},
+		&protocol.StakeTx{Fee: 1, IsStaking: true, Account: [32]byte{'c'}},
+		&protocol.AccTx{Fee: 1, Issuer: [32]byte{'d'}, Amount: 50},
+	}
+
+	if err := WriteOpenTxs(txs); err != nil {
+		t.Fatalf("Failed to persist open txs: %v\n", err)
+	}
+
+	persisted := ReadAllPersistedOpenTxs()
+	if len(persisted) != len(txs) {
+		t.Fatalf("Read %v persisted txs instead of %v.\n", len(persisted), len(txs))
+	}
+
+	//The order and the type of the txs is preserved.
+	for i, tx := range txs {
+		if reflect.TypeOf(persisted[i]) != reflect.TypeOf(tx) || persisted[i].Hash() != tx.Hash() {
+			t.Errorf("Persisted tx %v does not match: %v vs. %v\n", i, persisted[i], tx)
+		}
+	}
+
+	DeleteAllPersistedOpenTxs()
+
+	if len(ReadAllPersistedOpenTxs()) != 0 {
+		t.Error("Failed to delete the persisted open txs.\n")
+	}
+}
@@ -1,8 +1,12 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
-	"github.com/boltdb/bolt"
+	"math/big"
 	"sort"
 )
 
@@ -10,7 +14,7 @@ import (
 func ReadOpenBlock(hash [32]byte) (block *protocol.Block) {
 
 	var encodedBlock []byte
-	db.View(func(tx *bolt.Tx) error {
+	db.View(func(tx KVTx) error {
 		b := tx.Bucket([]byte("openblocks"))
 		encodedBlock = b.Get(hash[:])
 		return nil
@@ -20,15 +24,16 @@ func ReadOpenBlock(hash [32]byte) (block *protocol.Block) {
 		return nil
 	}
 
-	return block.Decode(encodedBlock)
+	block, _ = block.Decode(encodedBlock)
+	return block
 }
 
 func ReadClosedBlock(hash [32]byte) (block *protocol.Block) {
 
-	db.View(func(tx *bolt.Tx) error {
+	db.View(func(tx KVTx) error {
 		b := tx.Bucket([]byte("closedblocks"))
 		encodedBlock := b.Get(hash[:])
-		block = block.Decode(encodedBlock)
+		block, _ = block.Decode(encodedBlock)
 		return nil
 	})
 
@@ -42,10 +47,10 @@ func ReadClosedBlock(hash [32]byte) (block *protocol.Block) {
 //This function does read all blocks without transactions inside.
 func ReadClosedBlockWithoutTx(hash [32]byte) (block *protocol.Block) {
 
-	db.View(func(tx *bolt.Tx) error {
+	db.View(func(tx KVTx) error {
 		b := tx.Bucket([]byte("closedblockswithouttx"))
 		encodedBlock := b.Get(hash[:])
-		block = block.Decode(encodedBlock)
+		block, _ = block.Decode(encodedBlock)
 		return nil
 	})
 
@@ -58,11 +63,11 @@ func ReadClosedBlockWithoutTx(hash [32]byte) (block *protocol.Block) {
 
 func ReadLastClosedBlock() (block *protocol.Block) {
 
-	db.View(func(tx *bolt.Tx) error {
+	db.View(func(tx KVTx) error {
 		b := tx.Bucket([]byte("lastclosedblock"))
 		cb := b.Cursor()
 		_, encodedBlock := cb.First()
-		block = block.Decode(encodedBlock)
+		block, _ = block.Decode(encodedBlock)
 		return nil
 	})
 
@@ -80,12 +85,12 @@ func ReadAllClosedBlocks() (allClosedBlocks []*protocol.Block) {
 	//They are not ordered at teh request, but this does actually not matter. Because it will be ordered below
 	block := ReadLastClosedBlock()
 	if  block != nil {
-		db.View(func(tx *bolt.Tx) error {
+		db.View(func(tx KVTx) error {
 			b := tx.Bucket([]byte("closedblocks"))
 			b.ForEach(func(k, v []byte) error {
 				if v != nil {
 					encodedBlock := v
-					block = block.Decode(encodedBlock)
+					block, _ = block.Decode(encodedBlock)
 					allClosedBlocks = append(allClosedBlocks, block)
 				}
 				return nil
@@ -95,7 +100,7 @@ func ReadAllClosedBlocks() (allClosedBlocks []*protocol.Block) {
 			b.ForEach(func(k, v []byte) error {
 				if v != nil {
 					encodedBlock := v
-					block = block.Decode(encodedBlock)
+					block, _ = block.Decode(encodedBlock)
 					allClosedBlocks = append(allClosedBlocks, block)
 				}
 				return nil
@@ -123,12 +128,6 @@ func ReadReceivedBlockStash() (receivedBlocks []*protocol.Block){
 	return receivedBlockStash
 }
 
-func ReadOpenTx(hash [32]byte) (transaction protocol.Transaction) {
-	openTxMutex.Lock()
-	defer openTxMutex.Unlock()
-	return txMemPool[hash]
-}
-
 func ReadFundsTxBeforeAggregation() ([]*protocol.FundsTx){
 	openFundsTxBeforeAggregationMutex.Lock()
 	defer openFundsTxBeforeAggregationMutex.Unlock()
@@ -142,7 +141,7 @@ func ReadBootstrapReceivedTransactions(hash [32]byte) (transaction protocol.Tran
 func ReadAllBootstrapReceivedTransactions() (allOpenTxs []protocol.Transaction) {
 
 	for key := range bootstrapReceivedMemPool {
-		allOpenTxs = append(allOpenTxs, txMemPool[key])
+		allOpenTxs = append(allOpenTxs, ReadOpenTx(key))
 	}
 	return
 }
@@ -152,79 +151,464 @@ func ReadINVALIDOpenTx(hash [32]byte) (transaction protocol.Transaction) {
 	return txINVALIDMemPool[hash]
 }
 
-//Needed for the miner to prepare a new block
-func ReadAllOpenTxs() (allOpenTxs []protocol.Transaction) {
+//Returns true if the tx is in a closed block, also once the block has been pruned and the tx deleted.
+func IsClosedTx(hash [32]byte) bool {
+	var exists bool
+	db.View(func(tx KVTx) error {
+		exists = tx.Bucket([]byte("closedtxhashes")).Get(hash[:]) != nil
+		return nil
+	})
 
-	for key := range txMemPool {
-		allOpenTxs = append(allOpenTxs, txMemPool[key])
-	}
-	return
+	//Txs closed before the hashes were kept are only found in the closed tx buckets.
+	return exists || ReadClosedTx(hash) != nil
 }
 
 //Personally I like it better to test (which tx type it is) here, and get returned the interface. Simplifies the code
 func ReadClosedTx(hash [32]byte) (transaction protocol.Transaction) {
-	var encodedTx []byte
-	var fundstx *protocol.FundsTx
-	db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closedfunds"))
-		encodedTx = b.Get(hash[:])
+	if !closedTxFilter.mayContain(hash) {
 		return nil
-	})
+	}
+
+	bucket, encodedTx, generation := closedTxCache.get(hash)
 	if encodedTx != nil {
-		return fundstx.Decode(encodedTx)
+		return decodeClosedTx(bucket, encodedTx)
 	}
 
-	var acctx *protocol.AccTx
-	db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closedaccs"))
-		encodedTx = b.Get(hash[:])
+	//The buckets are searched in one read transaction.
+	db.View(func(tx KVTx) error {
+		for _, name := range closedTxBuckets {
+			if encoded := tx.Bucket([]byte(name)).Get(hash[:]); encoded != nil {
+				bucket, encodedTx = name, append([]byte(nil), encoded...)
+				return nil
+			}
+		}
 		return nil
 	})
-	if encodedTx != nil {
-		return acctx.Decode(encodedTx)
+	if encodedTx == nil {
+		return nil
 	}
 
-	var configtx *protocol.ConfigTx
-	db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closedconfigs"))
-		encodedTx = b.Get(hash[:])
+	closedTxCache.add(hash, bucket, encodedTx, generation)
+	return decodeClosedTx(bucket, encodedTx)
+}
+
+func ReadIotTxBlock(txHash [32]byte) (blockHash [32]byte) {
+	db.View(func(tx KVTx) error {
+		b := tx.Bucket([]byte("iottxblocks"))
+		copy(blockHash[:], b.Get(txHash[:]))
 		return nil
 	})
-	if encodedTx != nil {
-		return configtx.Decode(encodedTx)
-	}
 
-	var staketx *protocol.StakeTx
-	db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closedstakes"))
-		encodedTx = b.Get(hash[:])
+	return blockHash
+}
+
+//Returns the hash of the data of a closed IoT tx whose data has been pruned.
+func ReadPrunedIotDataHash(txHash [32]byte) (dataHash [32]byte, pruned bool) {
+	db.View(func(tx KVTx) error {
+		if encoded := tx.Bucket([]byte("prunediotdata")).Get(txHash[:]); encoded != nil {
+			copy(dataHash[:], encoded)
+			pruned = true
+		}
 		return nil
 	})
-	if encodedTx != nil {
-		return staketx.Decode(encodedTx)
+
+	return dataHash, pruned
+}
+
+//Builds the confirmation bundle of a closed IoT tx.
+func ReadIotAck(txHash [32]byte) (*protocol.IotAck, error) {
+	blockHash := ReadIotTxBlock(txHash)
+	if blockHash == [32]byte{} {
+		return nil, errors.New(fmt.Sprintf("IoT tx %x not confirmed.", txHash[0:8]))
 	}
 
-	var aggTx *protocol.AggTx
-	db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closedaggregations"))
-		encodedTx = b.Get(hash[:])
+	block := ReadClosedBlock(blockHash)
+	iotTx, ok := ReadClosedTx(txHash).(*protocol.IotTx)
+	lastBlock := ReadLastClosedBlock()
+	if block == nil || !ok || lastBlock == nil {
+		return nil, errors.New(fmt.Sprintf("Block %x of IoT tx %x not found.", blockHash[0:8], txHash[0:8]))
+	}
+
+	confirmations := uint32(1)
+	if lastBlock.Height > block.Height {
+		confirmations += lastBlock.Height - block.Height
+	}
+
+	if dataHash, pruned := ReadPrunedIotDataHash(txHash); pruned {
+		return protocol.NewPrunedIotAck(block, iotTx, txHash, dataHash, confirmations)
+	}
+
+	return protocol.NewIotAck(block, iotTx, confirmations)
+}
+
+//Entry of the beneficiary, slashing and height indexes.
+type IndexedBlock struct {
+	Height    uint32
+	BlockHash [32]byte
+}
+
+//Returns the blocks produced by the beneficiary in height order.
+func ReadBeneficiaryBlocks(beneficiary [32]byte) []IndexedBlock {
+	return readBlockIndex("beneficiaryblocks", beneficiary)
+}
+
+//Returns the blocks slashing the address in height order.
+func ReadSlashingBlocks(slashedAddress [32]byte) []IndexedBlock {
+	return readBlockIndex("slashingblocks", slashedAddress)
+}
+
+func readBlockIndex(bucket string, address [32]byte) (blocks []IndexedBlock) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte(bucket)).Cursor()
+		for k, v := c.Seek(address[:]); k != nil && bytes.HasPrefix(k, address[:]); k, v = c.Next() {
+			entry := IndexedBlock{Height: binary.BigEndian.Uint32(k[32:])}
+			copy(entry.BlockHash[:], v)
+			blocks = append(blocks, entry)
+		}
 		return nil
 	})
-	if encodedTx != nil {
-		return aggTx.Decode(encodedTx)
-	}
 
-	var ioTTx *protocol.IotTx
-	db.View(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closediotts"))
-		encodedTx = b.Get(hash[:])
+	return blocks
+}
+
+//Returns the balance of the account after the block at the height. Exists is false if the balance never changed up to
+//the height.
+func ReadBalanceAt(address [32]byte, height uint32) (balance uint64, exists bool) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("balances")).Cursor()
+
+		//The entry at the height or the last one before it.
+		key := blockIndexKey(address, height)
+		k, v := c.Seek(key)
+		if k == nil {
+			k, v = c.Last()
+		} else if !bytes.Equal(k, key) {
+			k, v = c.Prev()
+		}
+
+		if k != nil && bytes.HasPrefix(k, address[:]) {
+			balance, exists = binary.BigEndian.Uint64(v), true
+		}
 		return nil
 	})
-	if encodedTx != nil {
-		return ioTTx.Decode(encodedTx)
-	}
 
-	return nil
+	return balance, exists
+}
+
+type AccountTx struct {
+	Height uint32
+	TxHash [32]byte
+}
+
+//Returns the txs affecting the address at height to and below, newest first. A page always ends at a block boundary,
+//so it contains more than limit txs if the txs of its last block do not fit.
+func ReadAccountTxs(address [32]byte, to uint32, limit int) (txs []AccountTx) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("accounttxs")).Cursor()
+
+		//Seek to the first key above height to and step back.
+		var k []byte
+		if to == ^uint32(0) {
+			next := bytes.Repeat([]byte{0xff}, 32+4+32)
+			copy(next, address[:])
+			k, _ = c.Seek(next)
+		} else {
+			k, _ = c.Seek(accountTxKey(address, to+1, [32]byte{}))
+		}
+		if k == nil {
+			k, _ = c.Last()
+		} else {
+			k, _ = c.Prev()
+		}
+
+		for ; k != nil && bytes.HasPrefix(k, address[:]); k, _ = c.Prev() {
+			entry := AccountTx{Height: binary.BigEndian.Uint32(k[32:36])}
+			if len(txs) >= limit && entry.Height != txs[len(txs)-1].Height {
+				break
+			}
+			copy(entry.TxHash[:], k[36:])
+			txs = append(txs, entry)
+		}
+		return nil
+	})
+
+	return txs
+}
+
+//Returns the hash of the block at the height of the chain the node is on.
+func ReadBlockHashByHeight(height uint32) (blockHash [32]byte, exists bool) {
+	db.View(func(tx KVTx) error {
+		if encoded := tx.Bucket([]byte("blockheights")).Get(heightKey(height)); encoded != nil {
+			copy(blockHash[:], encoded)
+			exists = true
+		}
+		return nil
+	})
+
+	return blockHash, exists
+}
+
+//Returns the block at the height of the chain the node is on, also once it has been moved to the blocks without txs.
+func ReadBlockByHeight(height uint32) (block *protocol.Block) {
+	db.View(func(tx KVTx) error {
+		indexed := tx.Bucket([]byte("blockheights")).Get(heightKey(height))
+		if len(indexed) < 32 {
+			return nil
+		}
+
+		block, _ = block.Decode(tx.Bucket([]byte("closedblocks")).Get(indexed[:32]))
+		if block == nil && len(indexed) == 64 {
+			block, _ = block.Decode(tx.Bucket([]byte("closedblockswithouttx")).Get(indexed[32:]))
+		}
+		return nil
+	})
+
+	return block
+}
+
+//Returns the validator set determined after the block at height, nil if there is none.
+func ReadValidatorSet(height uint32) (set *protocol.ValidatorSet) {
+	db.View(func(tx KVTx) error {
+		set, _ = set.Decode(tx.Bucket([]byte("validatorsets")).Get(heightKey(height)))
+		return nil
+	})
+
+	return set
+}
+
+//Returns the liveness evidence of the epoch ending with the block at height, nil if there is none.
+func ReadLivenessEvidence(height uint32) (evidence *protocol.LivenessEvidence) {
+	db.View(func(tx KVTx) error {
+		evidence, _ = evidence.Decode(tx.Bucket([]byte("livenessevidence")).Get(heightKey(height)))
+		return nil
+	})
+
+	return evidence
+}
+
+//Returns up to limit blocks of the chain the node is on, starting at height to and going down.
+func ReadBlockHeights(to uint32, limit int) (blocks []IndexedBlock) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("blockheights")).Cursor()
+		k, v := c.Seek(heightKey(to))
+		if k == nil || binary.BigEndian.Uint32(k) > to {
+			k, v = c.Prev()
+		}
+		for ; k != nil && len(blocks) < limit; k, v = c.Prev() {
+			entry := IndexedBlock{Height: binary.BigEndian.Uint32(k)}
+			copy(entry.BlockHash[:], v)
+			blocks = append(blocks, entry)
+		}
+		return nil
+	})
+
+	return blocks
+}
+
+//Returns the logs emitted between the heights from and to (both inclusive) in the order they were emitted. Only the
+//logs of the contract are returned if it is set, only the logs with the topic if it is not nil.
+func ReadLogs(contract [32]byte, topic []byte, from, to uint32) (logs []*protocol.Log) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("logs")).Cursor()
+		for k, v := c.Seek(logKey(from, [32]byte{})); k != nil && binary.BigEndian.Uint32(k[:4]) <= to; k, v = c.Next() {
+			if contract != [32]byte{} && !bytes.Equal(k[4:], contract[:]) {
+				continue
+			}
+			blockLogs, _ := protocol.DecodeLogs(v)
+			for _, log := range blockLogs {
+				if topic == nil || log.HasTopic(topic) {
+					logs = append(logs, log)
+				}
+			}
+		}
+		return nil
+	})
+
+	//Logs of different contracts in the same block are stored by contract.
+	sort.SliceStable(logs, func(i, j int) bool {
+		if logs[i].Height != logs[j].Height {
+			return logs[i].Height < logs[j].Height
+		}
+		return logs[i].Index < logs[j].Index
+	})
+
+	return logs
+}
+
+//Returns the account removed by the accTx with the given hash, see Batch.WriteRemovedAccount.
+func ReadRemovedAccount(txHash [32]byte) (acc *protocol.Account, isRoot bool) {
+	db.View(func(tx KVTx) error {
+		encoded := tx.Bucket([]byte("removedaccs")).Get(txHash[:])
+		if len(encoded) > 1 {
+			isRoot = encoded[0] == 1
+			acc, _ = acc.Decode(append([]byte(nil), encoded[1:]...))
+		}
+		return nil
+	})
+
+	return acc, isRoot
+}
+
+//Returns the accounts saved by Batch.WriteContractStates, nil if the tx did not call a contract.
+func ReadContractStates(txHash [32]byte) (accounts map[[32]byte]*protocol.Account) {
+	db.View(func(tx KVTx) error {
+		encoded := tx.Bucket([]byte("contractstates")).Get(txHash[:])
+		for len(encoded) >= 36 {
+			var hash [32]byte
+			copy(hash[:], encoded[:32])
+			length := binary.BigEndian.Uint32(encoded[32:36])
+			if uint32(len(encoded)-36) < length {
+				break
+			}
+
+			var acc *protocol.Account
+			if acc, _ = acc.Decode(append([]byte(nil), encoded[36:36+length]...)); acc != nil {
+				if accounts == nil {
+					accounts = make(map[[32]byte]*protocol.Account)
+				}
+				accounts[hash] = acc
+			}
+			encoded = encoded[36+length:]
+		}
+		return nil
+	})
+
+	return accounts
+}
+
+//Returns the bucket saved by Batch.WriteIotBucket, exists is false if the tx did not take a token.
+func ReadIotBucket(txHash [32]byte) (tokens uint32, refillHeight uint32, exists bool) {
+	db.View(func(tx KVTx) error {
+		if encoded := tx.Bucket([]byte("iotbuckets")).Get(txHash[:]); len(encoded) == 8 {
+			tokens = binary.BigEndian.Uint32(encoded[:4])
+			refillHeight = binary.BigEndian.Uint32(encoded[4:])
+			exists = true
+		}
+		return nil
+	})
+
+	return tokens, refillHeight, exists
+}
+
+//Returns the values saved by Batch.WriteBlockWeight, weight is nil if the block has none (e.g., it has been validated
+//before the weights were stored).
+func ReadBlockWeight(blockHash [32]byte) (difficulty uint8, weight *big.Int) {
+	db.View(func(tx KVTx) error {
+		if encoded := tx.Bucket([]byte("blockweights")).Get(blockHash[:]); len(encoded) > 0 {
+			difficulty = encoded[0]
+			weight = new(big.Int).SetBytes(encoded[1:])
+		}
+		return nil
+	})
+
+	return difficulty, weight
+}
+
+//Returns the stake saved by Batch.WriteSlashedStake, exists is false if the block did not slash a validator.
+func ReadSlashedStake(blockHash [32]byte) (stake uint64, exists bool) {
+	db.View(func(tx KVTx) error {
+		if encoded := tx.Bucket([]byte("slashedstakes")).Get(blockHash[:]); len(encoded) == 8 {
+			stake = binary.BigEndian.Uint64(encoded)
+			exists = true
+		}
+		return nil
+	})
+
+	return stake, exists
+}
+
+func ReadGenesis() (encodedGenesis []byte) {
+	db.View(func(tx KVTx) error {
+		b := tx.Bucket([]byte("genesis"))
+		//Bolt's values are only valid during the transaction
+		if encoded := b.Get([]byte("genesis")); encoded != nil {
+			encodedGenesis = make([]byte, len(encoded))
+			copy(encodedGenesis, encoded)
+		}
+		return nil
+	})
+
+	return encodedGenesis
+}
+
+func ReadSnapshot() (encodedSnapshot []byte) {
+	db.View(func(tx KVTx) error {
+		b := tx.Bucket([]byte("snapshot"))
+		//Bolt's values are only valid during the transaction
+		if encoded := b.Get([]byte("latest")); encoded != nil {
+			encodedSnapshot = make([]byte, len(encoded))
+			copy(encodedSnapshot, encoded)
+		}
+		return nil
+	})
+
+	return encodedSnapshot
+}
+
+//Returns the queued broadcasts in the order they were written
+func ReadAllOutboundBroadcasts() (packets [][]byte) {
+	db.View(func(tx KVTx) error {
+		b := tx.Bucket([]byte("outboundbroadcasts"))
+		b.ForEach(func(k, v []byte) error {
+			//Bolt's values are only valid during the transaction
+			packet := make([]byte, len(v))
+			copy(packet, v)
+			packets = append(packets, packet)
+			return nil
+		})
+		return nil
+	})
+
+	return packets
+}
+
+//Returns the pending slashing proofs, ordered by slashed address.
+func ReadAllSlashingProofs() (proofs []*protocol.SlashingProof) {
+	db.View(func(tx KVTx) error {
+		b := tx.Bucket([]byte("slashingproofs"))
+		b.ForEach(func(k, v []byte) error {
+			var proof *protocol.SlashingProof
+			if proof, _ = proof.Decode(v); proof != nil {
+				proofs = append(proofs, proof)
+			}
+			return nil
+		})
+		return nil
+	})
+
+	return proofs
+}
+
+//Returns the latest finality checkpoint, exists is false if no checkpoint has been written yet.
+func ReadLastCheckpoint() (height uint32, blockHash [32]byte, exists bool) {
+	db.View(func(tx KVTx) error {
+		b := tx.Bucket([]byte("checkpoints"))
+		key, value := b.Cursor().Last()
+		if key != nil {
+			height = binary.BigEndian.Uint32(key)
+			copy(blockHash[:], value)
+			exists = true
+		}
+		return nil
+	})
+
+	return height, blockHash, exists
+}
+
+//Returns the open transactions persisted on the last shutdown in the order they were written.
+func ReadAllPersistedOpenTxs() (transactions []protocol.Transaction) {
+	db.View(func(tx KVTx) error {
+		b := tx.Bucket([]byte("opentxs"))
+		b.ForEach(func(k, v []byte) error {
+			if transaction := decodeTypedTx(v); transaction != nil {
+				transactions = append(transactions, transaction)
+			}
+			return nil
+		})
+		return nil
+	})
+
+	return transactions
 }
 
 func ReadMempool(){
@@ -233,8 +617,111 @@ func ReadMempool(){
 		//logger.Printf("%x", tx)
 	//}
 	logger.Printf("________________")
-	logger.Printf("Mempool_Size: %v", len(txMemPool))
+	logger.Printf("Mempool_Size: %v", len(ReadAllOpenTxs()))
 	logger.Printf("________________")
 
 }
 
+
+//Returns the aggregate of the level covering the height, nil if there is none.
+func ReadEpochAggregate(level uint8, height uint32) (aggregate *protocol.EpochAggregate) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("epochaggregates")).Cursor()
+
+		//The aggregate starting at the height or the last one before it.
+		key := epochAggregateKey(level, height)
+		k, v := c.Seek(key)
+		if k == nil {
+			k, v = c.Last()
+		} else if !bytes.Equal(k, key) {
+			k, v = c.Prev()
+		}
+
+		if k != nil && k[0] == level {
+			aggregate, _ = aggregate.Decode(v)
+		}
+		return nil
+	})
+
+	if aggregate == nil || aggregate.LastHeight < height {
+		return nil
+	}
+
+	return aggregate
+}
+
+//Returns the aggregate of the level with the highest heights, nil if there is none.
+func ReadLastEpochAggregate(level uint8) (aggregate *protocol.EpochAggregate) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("epochaggregates")).Cursor()
+
+		k, v := c.Seek([]byte{level + 1})
+		if k == nil {
+			k, v = c.Last()
+		} else {
+			k, v = c.Prev()
+		}
+
+		if k != nil && k[0] == level {
+			aggregate, _ = aggregate.Decode(v)
+		}
+		return nil
+	})
+
+	return aggregate
+}
+
+//Returns up to limit aggregates of the level, starting with the one starting at the height.
+func ReadEpochAggregates(level uint8, firstHeight uint32, limit int) (aggregates []*protocol.EpochAggregate) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("epochaggregates")).Cursor()
+		for k, v := c.Seek(epochAggregateKey(level, firstHeight)); k != nil && k[0] == level && len(aggregates) < limit; k, v = c.Next() {
+			var aggregate *protocol.EpochAggregate
+			if aggregate, _ = aggregate.Decode(v); aggregate != nil {
+				aggregates = append(aggregates, aggregate)
+			}
+		}
+		return nil
+	})
+
+	return aggregates
+}
+
+//Returns the hashes the aggregate commits to, nil if they have been pruned.
+func ReadEpochLeaves(level uint8, firstHeight uint32) (leaves [][32]byte) {
+	db.View(func(tx KVTx) error {
+		leaves = decodeEpochLeaves(tx.Bucket([]byte("epochleaves")).Get(epochAggregateKey(level, firstHeight)))
+		return nil
+	})
+
+	return leaves
+}
+
+//Returns the first level aggregate committing to the hash and its leaves, nil if the hash is not aggregated (anymore).
+func FindEpochLeaf(hash [32]byte) (aggregate *protocol.EpochAggregate, leaves [][32]byte) {
+	db.View(func(tx KVTx) error {
+		c := tx.Bucket([]byte("epochleaves")).Cursor()
+		for k, v := c.Seek([]byte{1}); k != nil && k[0] == 1; k, v = c.Next() {
+			for i := 0; i+32 <= len(v); i += 32 {
+				if bytes.Equal(v[i:i+32], hash[:]) {
+					leaves = decodeEpochLeaves(v)
+					aggregate, _ = aggregate.Decode(tx.Bucket([]byte("epochaggregates")).Get(k))
+					return nil
+				}
+			}
+		}
+		return nil
+	})
+
+	return aggregate, leaves
+}
+
+func decodeEpochLeaves(encoded []byte) (leaves [][32]byte) {
+	for i := 0; i+32 <= len(encoded); i += 32 {
+		var leaf [32]byte
+		copy(leaf[:], encoded[i:i+32])
+		leaves = append(leaves, leaf)
+	}
+
+	return leaves
+}
@@ -0,0 +1,26 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestSlashingProofs(t *testing.T) {
+	proof1 := &protocol.SlashingProof{SlashedAddress: [32]byte{0x01}, ConflictingBlockHash1: [32]byte{0x02}, Height: 3}
+	proof2 := &protocol.SlashingProof{SlashedAddress: [32]byte{0x04}, ConflictingBlockHash1: [32]byte{0x05}, Height: 6}
+
+	WriteSlashingProof(proof1)
+	WriteSlashingProof(proof2)
+	defer DeleteSlashingProof(proof2.SlashedAddress)
+
+	if proofs := ReadAllSlashingProofs(); len(proofs) != 2 {
+		t.Fatalf("Expected 2 pending proofs, got %v\n", len(proofs))
+	}
+
+	DeleteSlashingProof(proof1.SlashedAddress)
+	proofs := ReadAllSlashingProofs()
+	if len(proofs) != 1 || *proofs[0] != *proof2 {
+		t.Errorf("Expected only (%v) to be pending, got %v\n", proof2, proofs)
+	}
+}
@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+//The state is only kept after the last block. To serve account proofs at earlier heights, the state tries of the most
+//recent blocks are kept in memory, keyed by block hash. Tries of blocks that have been rolled back are not removed,
+//they are never found through the chain and get evicted like the others.
+
+const STATE_TRIES_KEPT = 32
+
+var (
+	stateTries      = make(map[[32]byte]*protocol.StateTrie)
+	stateTrieHashes [][32]byte
+	stateTriesMutex = &sync.Mutex{}
+)
+
+func WriteStateTrie(blockHash [32]byte, trie *protocol.StateTrie) {
+	stateTriesMutex.Lock()
+	defer stateTriesMutex.Unlock()
+
+	if _, exists := stateTries[blockHash]; !exists {
+		stateTrieHashes = append(stateTrieHashes, blockHash)
+	}
+	stateTries[blockHash] = trie
+
+	//Evict the oldest trie.
+	if len(stateTrieHashes) > STATE_TRIES_KEPT {
+		delete(stateTries, stateTrieHashes[0])
+		stateTrieHashes = append(stateTrieHashes[:0], stateTrieHashes[1:]...)
+	}
+}
+
+func ReadStateTrie(blockHash [32]byte) *protocol.StateTrie {
+	stateTriesMutex.Lock()
+	defer stateTriesMutex.Unlock()
+
+	return stateTries[blockHash]
+}
+
+//Returns the proof of the account after the block at the height, the trie of the block needs to be kept.
+func ReadAccountProof(address [32]byte, height uint32) (*protocol.AccountProof, error) {
+	blockHash, exists := ReadBlockHashByHeight(height)
+	if !exists {
+		return nil, errors.New(fmt.Sprintf("No block at height %v.", height))
+	}
+
+	trie := ReadStateTrie(blockHash)
+	if trie == nil {
+		return nil, errors.New(fmt.Sprintf("State of block (%x) at height %v is not kept anymore.", blockHash[0:8], height))
+	}
+
+	proof := &protocol.AccountProof{BlockHash: blockHash, Height: height, Proof: *trie.Proof(address)}
+	if acc := trie.Account(address); acc != nil {
+		proof.Account = acc.Encode()
+	}
+
+	return proof, nil
+}
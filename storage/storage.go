@@ -2,20 +2,17 @@ package storage
 
 import (
 	"fmt"
-	"log"
 	"sync"
-	"time"
 
+	"github.com/bazo-blockchain/bazo-miner/logging"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
-	"github.com/boltdb/bolt"
 )
 
 var (
-	db                 				*bolt.DB
-	logger             				*log.Logger
-	State              				= make(map[[32]byte]*protocol.Account)
+	db                 				KV
+	logger             				= logging.GetLogger("storage")
+	State              				= NewAccountStore(nil)
 	RootKeys           				= make(map[[32]byte]*protocol.Account)
-	txMemPool          				= make(map[[32]byte]protocol.Transaction)
 	txINVALIDMemPool   				= make(map[[32]byte]protocol.Transaction)
 	bootstrapReceivedMemPool		= make(map[[32]byte]protocol.Transaction)
 	DifferentSenders   				= make(map[[32]byte]uint32)
@@ -27,7 +24,6 @@ var (
 	averageTxSize float32 				= 0
 	totalTransactionSize float32 		= 0
 	nrClosedTransactions float32 		= 0
-	openTxMutex 						= &sync.Mutex{}
 	openFundsTxBeforeAggregationMutex	= &sync.Mutex{}
 )
 
@@ -38,17 +34,16 @@ const (
 //Entry function for the storage package
 func Init(dbname string, bootstrapIpport string) {
 	Bootstrap_Server = bootstrapIpport
-	logger = InitLogger()
 
 	var err error
-	db, err = bolt.Open(dbname, 0600, &bolt.Options{Timeout: 5 * time.Second})
+	db, err = openKV(dbname)
 	if err != nil {
 		logger.Fatal(ERROR_MSG, err)
 	}
 
 	//Check if db file is empty for all non-bootstraping miners
 	//if ipport != BOOTSTRAP_SERVER_PORT {
-	//	err := db.View(func(tx *bolt.Tx) error {
+	//	err := db.View(func(tx KVTx) error {
 	//		err := tx.ForEach(func(name []byte, bkt *bolt.Bucket) error {
 	//			err := bkt.ForEach(func(k, v []byte) error {
 	//				if k != nil && v != nil {
@@ -66,76 +61,253 @@ func Init(dbname string, bootstrapIpport string) {
 	//	}
 	//}
 
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("openblocks"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("closedblocks"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("closedblockswithouttx"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("closedfunds"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("closedaccs"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("closedstakes"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("closedaggregations"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("closedconfigs"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("lastclosedblock"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
-	db.Update(func(tx *bolt.Tx) error {
+	db.Update(func(tx KVTx) error {
 		_, err = tx.CreateBucket([]byte("closediotts"))
 		if err != nil {
 			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
 		}
 		return nil
 	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("iottxblocks"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("beneficiaryblocks"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("slashingblocks"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("snapshot"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("genesis"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("outboundbroadcasts"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("opentxs"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("checkpoints"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("removedaccs"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("closedtxhashes"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("contractstates"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("closedcontracts"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("logs"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("prunediotdata"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("iotbuckets"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("blockheights"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("accounttxs"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("balances"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("epochaggregates"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("epochleaves"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("slashingproofs"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("validatorsets"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("livenessevidence"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("slashedstakes"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+	db.Update(func(tx KVTx) error {
+		_, err = tx.CreateBucket([]byte("blockweights"))
+		if err != nil {
+			return fmt.Errorf(ERROR_MSG+"Create bucket: %s", err)
+		}
+		return nil
+	})
+
+	closedTxFilter.rebuild()
 }
 
 func TearDown() {
@@ -28,15 +28,15 @@ func TestReadWriteDeleteTx(t *testing.T) {
 
 	loopMax := testsize
 	for i := 0; i < loopMax; i++ {
-		tx, _ := protocol.ConstrFundsTx(0x01, rand.Uint64()%100000+1, rand.Uint64()%10+1, uint32(i), accAHash, accBHash, &PrivKeyA, nil, nil)
+		tx, _ := protocol.ConstrFundsTx(0x01, rand.Uint64()%100000+1, rand.Uint64()%10+1, uint32(i), accAHash, accBHash, PrivKeyA, nil)
 		WriteOpenTx(tx)
 		hashFundsSlice = append(hashFundsSlice, tx)
 	}
 
 	loopMax = testsize
-	nullAddress := [64]byte{}
+	nullAddress := [32]byte{}
 	for i := 0; i < 1000; i++ {
-		tx, _, _ := protocol.ConstrAccTx(0, rand.Uint64()%100+1, nullAddress, &RootPrivKey, nil, nil)
+		tx, _, _ := protocol.ConstrAccTx(0, rand.Uint64()%100+1, 0, nullAddress, RootPrivKey, nil, nil)
 		WriteOpenTx(tx)
 		hashAccSlice = append(hashAccSlice, tx)
 	}
@@ -44,7 +44,7 @@ func TestReadWriteDeleteTx(t *testing.T) {
 	//Restricted to 256, because the number of configTxs is stored in a uint8 in blocks
 	loopMax = 256
 	for cnt := 0; cnt < loopMax; cnt++ {
-		tx, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), uint8(rand.Uint32()%5+1), rand.Uint64()%2342873423, rand.Uint64()%1000+1, uint8(cnt), &RootPrivKey)
+		tx, _ := protocol.ConstrConfigTx(uint8(rand.Uint32()%256), uint8(rand.Uint32()%5+1), rand.Uint64()%2342873423, rand.Uint64()%1000+1, uint8(cnt), RootPrivKey)
 		hashConfigSlice = append(hashConfigSlice, tx)
 		WriteOpenTx(tx)
 	}
@@ -55,7 +55,7 @@ func TestReadWriteDeleteTx(t *testing.T) {
 		if math.Mod(float64(cnt), 2.00) == 1 {
 			isStaking = true
 		}
-		tx, _ := protocol.ConstrStakeTx(0, uint64(cnt), isStaking, accAHash, &PrivKeyA, &CommitmentKeyA.PublicKey)
+		tx, _ := protocol.ConstrStakeTx(0, uint64(cnt), isStaking, accAHash, PrivKeyA, &CommitmentKeyA.PublicKey)
 		hashStakeSlice = append(hashStakeSlice, tx)
 		WriteOpenTx(tx)
 	}
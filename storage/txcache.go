@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"container/list"
+	"sync"
+)
+
+//Closed txs are looked up for every tx of a block during validation, mostly the txs closed by the last few blocks.
+//The encoded txs read or written most recently are kept in an LRU cache in front of the closed tx buckets, so these
+//lookups do not hit the database. Batches update the cache once they are committed. Txs which are not found are not
+//cached, the cache only holds what is on disk.
+
+const CLOSED_TX_CACHE_SIZE = 4096
+
+type cachedTx struct {
+	hash    [32]byte
+	bucket  string
+	encoded []byte
+}
+
+type txCache struct {
+	entries map[[32]byte]*list.Element
+	//Most recently used first.
+	order *list.List
+	size  int
+	//Incremented by every update, a value read from the database is only added if no update happened in between.
+	generation uint64
+	mutex      sync.Mutex
+}
+
+var closedTxCache = newTxCache(CLOSED_TX_CACHE_SIZE)
+
+func newTxCache(size int) *txCache {
+	return &txCache{entries: make(map[[32]byte]*list.Element), order: list.New(), size: size}
+}
+
+func (cache *txCache) get(hash [32]byte) (bucket string, encoded []byte, generation uint64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if element, exists := cache.entries[hash]; exists {
+		cache.order.MoveToFront(element)
+		entry := element.Value.(*cachedTx)
+		return entry.bucket, entry.encoded, cache.generation
+	}
+
+	return "", nil, cache.generation
+}
+
+//Adds a tx read from the database at the generation returned by get.
+func (cache *txCache) add(hash [32]byte, bucket string, encoded []byte, generation uint64) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	if generation == cache.generation {
+		cache.put(hash, bucket, encoded)
+	}
+}
+
+//Called with the committed writes of a batch, a nil value deletes the tx.
+func (cache *txCache) update(hash [32]byte, bucket string, encoded []byte) {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.generation++
+	if encoded == nil {
+		cache.remove(hash)
+	} else {
+		cache.put(hash, bucket, encoded)
+	}
+}
+
+func (cache *txCache) clear() {
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	cache.generation++
+	cache.entries = make(map[[32]byte]*list.Element)
+	cache.order.Init()
+}
+
+func (cache *txCache) put(hash [32]byte, bucket string, encoded []byte) {
+	if element, exists := cache.entries[hash]; exists {
+		element.Value = &cachedTx{hash, bucket, encoded}
+		cache.order.MoveToFront(element)
+		return
+	}
+
+	cache.entries[hash] = cache.order.PushFront(&cachedTx{hash, bucket, encoded})
+	if cache.order.Len() > cache.size {
+		cache.remove(cache.order.Back().Value.(*cachedTx).hash)
+	}
+}
+
+func (cache *txCache) remove(hash [32]byte) {
+	if element, exists := cache.entries[hash]; exists {
+		cache.order.Remove(element)
+		delete(cache.entries, hash)
+	}
+}
@@ -0,0 +1,41 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestClosedTxCache(t *testing.T) {
+	tx := &protocol.FundsTx{Amount: 5, From: [32]byte{'c', 'a', 'c', 'h', 'e'}}
+	WriteClosedTx(tx)
+	if _, encoded, _ := closedTxCache.get(tx.Hash()); encoded == nil {
+		t.Fatal("Written tx should be cached.")
+	}
+
+	DeleteClosedTx(tx)
+	if ReadClosedTx(tx.Hash()) != nil {
+		t.Fatal("Deleted tx should not be read from the cache.")
+	}
+
+	//A tx read at an older generation is not added, it might have been changed in the meantime.
+	_, _, generation := closedTxCache.get(tx.Hash())
+	closedTxCache.update([32]byte{0x01}, "closedfunds", nil)
+	closedTxCache.add(tx.Hash(), "closedfunds", tx.Encode(), generation)
+	if _, encoded, _ := closedTxCache.get(tx.Hash()); encoded != nil {
+		t.Error("Tx read before an update should not be cached.")
+	}
+
+	cache := newTxCache(2)
+	for i := byte(1); i <= 3; i++ {
+		cache.update([32]byte{i}, "closedfunds", []byte{i})
+	}
+	cache.get([32]byte{2})
+	cache.update([32]byte{4}, "closedfunds", []byte{4})
+	if _, encoded, _ := cache.get([32]byte{2}); encoded == nil || len(cache.entries) != 2 {
+		t.Error("Recently used tx should be kept.")
+	}
+	if _, encoded, _ := cache.get([32]byte{3}); encoded != nil {
+		t.Error("Least recently used tx should be evicted.")
+	}
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"sync"
+
+	"github.com/willf/bloom"
+)
+
+//Most closed tx lookups during validation are for txs which have not been closed yet, the check that a tx has not been
+//seen before. An in-memory bloom filter over the hashes of all closed txs answers them without hitting the database:
+//if the filter does not contain a hash, the tx is not closed. The filter is built from the database by Init and the
+//hashes of closed txs are added before the batch writing them is committed. Txs deleted later (rolled back or pruned)
+//stay in the filter and only cause false positives, which fall back to the database. Once more hashes have been added
+//than the filter was sized for, it is rebuilt from the database with twice the capacity.
+
+const (
+	CLOSED_TX_FILTER_MIN_CAPACITY = 1 << 16
+	CLOSED_TX_FILTER_ERROR_RATE   = 0.01
+)
+
+type txFilter struct {
+	//Nil until built, every hash may be contained then.
+	filter   *bloom.BloomFilter
+	capacity uint
+	added    uint
+	mutex    sync.RWMutex
+}
+
+var closedTxFilter = new(txFilter)
+
+func (f *txFilter) mayContain(hash [32]byte) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	return f.filter == nil || f.filter.Test(hash[:])
+}
+
+func (f *txFilter) add(hash [32]byte) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.filter == nil {
+		return
+	}
+
+	f.filter.Add(hash[:])
+	f.added++
+	if f.added > f.capacity {
+		f.build(2 * f.capacity)
+	}
+}
+
+//Builds the filter from the closed tx buckets, sized for at least twice the number of closed txs.
+func (f *txFilter) rebuild() {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.build(CLOSED_TX_FILTER_MIN_CAPACITY)
+}
+
+func (f *txFilter) build(capacity uint) {
+	var hashes [][]byte
+	db.View(func(tx KVTx) error {
+		for _, name := range closedTxBuckets {
+			tx.Bucket([]byte(name)).ForEach(func(k, v []byte) error {
+				hashes = append(hashes, append([]byte(nil), k...))
+				return nil
+			})
+		}
+		return nil
+	})
+
+	if capacity < 2*uint(len(hashes)) {
+		capacity = 2 * uint(len(hashes))
+	}
+
+	f.filter = bloom.NewWithEstimates(capacity, CLOSED_TX_FILTER_ERROR_RATE)
+	for _, hash := range hashes {
+		f.filter.Add(hash)
+	}
+	f.capacity = capacity
+	f.added = uint(len(hashes))
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"testing"
+
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+func TestClosedTxFilter(t *testing.T) {
+	tx := &protocol.FundsTx{Amount: 7, From: [32]byte{'f', 'i', 'l', 't', 'e', 'r'}}
+	WriteClosedTx(tx)
+	defer DeleteClosedTx(tx)
+
+	if !closedTxFilter.mayContain(tx.Hash()) || ReadClosedTx(tx.Hash()) == nil {
+		t.Fatal("Closed tx should be in the filter.")
+	}
+
+	//Rebuilt filters contain the txs on disk.
+	closedTxFilter.rebuild()
+	if !closedTxFilter.mayContain(tx.Hash()) {
+		t.Error("Closed tx should be in the rebuilt filter.")
+	}
+
+	//The filter grows once more txs are added than it was sized for.
+	filter := new(txFilter)
+	filter.rebuild()
+	capacity := filter.capacity
+	for i := uint(0); i <= capacity; i++ {
+		filter.add([32]byte{byte(i), byte(i >> 8), byte(i >> 16), 'x'})
+	}
+	if filter.capacity <= capacity || !filter.mayContain(tx.Hash()) {
+		t.Errorf("Filter should have been rebuilt with a larger capacity: %v\n", filter.capacity)
+	}
+
+	var missing int
+	for i := 0; i < 1000; i++ {
+		if !filter.mayContain([32]byte{byte(i), byte(i >> 8), 'm', 'i', 's', 's'}) {
+			missing++
+		}
+	}
+	if missing < 900 {
+		t.Errorf("Only %v of 1000 txs which were never closed are filtered out.\n", missing)
+	}
+}
@@ -10,9 +10,14 @@ func UpdateBlocksToBlocksWithoutTx(block *protocol.Block) (err error){
 	if BlockReadyToAggregate(block) {
 		block.Aggregated = true
 		logger.Printf("UPDATE: Write (%x) into emptyBlockBucket as (%x)", block.Hash[0:8], block.HashWithoutTx[0:8])
-		WriteClosedBlockWithoutTx(block)
-		DeleteClosedBlock(block.Hash)
-		return err
+		//Moved in one transaction, so the block is never lost (or kept twice) on a crash.
+		batch := NewBatch()
+		batch.WriteClosedBlockWithoutTx(block)
+		batch.DeleteClosedBlock(block.Hash)
+		if indexed, exists := ReadBlockHashByHeight(block.Height); exists && indexed == block.Hash {
+			batch.WriteBlockHeightWithoutTx(block.Height, block.Hash, block.HashWithoutTx)
+		}
+		return batch.Commit()
 	}
 	return
 }
@@ -1,30 +1,16 @@
 package storage
 
 import (
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
-	"io"
-	"log"
-	"os"
 )
 
-func InitLogger() *log.Logger {
-
-	//Create a Log-file (Logger.Miner.log) and write all logger.printf(...) Statements into it. 
-	LogFile, err := os.OpenFile("LoggerMiner.log", os.O_RDWR|os.O_CREATE|os.O_APPEND, 0666)
-	if err != nil {
-		log.Fatalf("error opening file: %v", err)
-	}
-
-	wrt := io.MultiWriter(os.Stdout, LogFile)
-	log.SetOutput(wrt)
-	return log.New(wrt, "INFO: ", log.Ldate|log.Lmicroseconds|log.Lshortfile)
-}
-
 //Needed by miner and p2p package
 func GetAccount(hash [32]byte) (acc *protocol.Account, err error) {
-	if acc = State[hash]; acc != nil {
+	recordAccount(hash)
+	if acc = State.Get(hash); acc != nil {
 		return acc, nil
 	} else {
 		return nil, errors.New(fmt.Sprintf("Acc (%x) not in the state.", hash[0:8]))
@@ -90,3 +76,129 @@ func GetFundsTxPubKeys(fundsTxData [][32]byte) (fundsTxPubKeys [][32]byte) {
 
 	return fundsTxPubKeys
 }
+
+func blockIndexKey(address [32]byte, height uint32) []byte {
+	key := make([]byte, 32+4)
+	copy(key[:32], address[:])
+	binary.BigEndian.PutUint32(key[32:], height)
+
+	return key
+}
+
+func accountTxKey(address [32]byte, height uint32, txHash [32]byte) []byte {
+	key := make([]byte, 32+4+32)
+	copy(key[:32], address[:])
+	binary.BigEndian.PutUint32(key[32:36], height)
+	copy(key[36:], txHash[:])
+
+	return key
+}
+
+func heightKey(height uint32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, height)
+
+	return key
+}
+
+//Aggregates are stored per level in height order.
+func epochAggregateKey(level uint8, firstHeight uint32) []byte {
+	key := make([]byte, 1+4)
+	key[0] = level
+	binary.BigEndian.PutUint32(key[1:], firstHeight)
+
+	return key
+}
+
+func logKey(height uint32, contract [32]byte) []byte {
+	key := make([]byte, 4+32)
+	binary.BigEndian.PutUint32(key[:4], height)
+	copy(key[4:], contract[:])
+
+	return key
+}
+
+//Transaction types of the persisted open transactions, each encoding is prefixed with its type.
+const (
+	TYPE_FUNDSTX    = 1
+	TYPE_ACCTX      = 2
+	TYPE_CONFIGTX   = 3
+	TYPE_STAKETX    = 4
+	TYPE_AGGTX      = 5
+	TYPE_IOTTX      = 6
+	TYPE_CONTRACTTX = 7
+)
+
+func encodeTypedTx(transaction protocol.Transaction) []byte {
+	var txType byte
+	switch transaction.(type) {
+	case *protocol.FundsTx:
+		txType = TYPE_FUNDSTX
+	case *protocol.AccTx:
+		txType = TYPE_ACCTX
+	case *protocol.ConfigTx:
+		txType = TYPE_CONFIGTX
+	case *protocol.StakeTx:
+		txType = TYPE_STAKETX
+	case *protocol.AggTx:
+		txType = TYPE_AGGTX
+	case *protocol.IotTx:
+		txType = TYPE_IOTTX
+	case *protocol.ContractTx:
+		txType = TYPE_CONTRACTTX
+	default:
+		return nil
+	}
+
+	return append([]byte{txType}, transaction.Encode()...)
+}
+
+func decodeTypedTx(encoded []byte) protocol.Transaction {
+	if len(encoded) < 1 {
+		return nil
+	}
+
+	//Bolt's values are only valid during the transaction
+	encodedTx := make([]byte, len(encoded)-1)
+	copy(encodedTx, encoded[1:])
+
+	switch encoded[0] {
+	case TYPE_FUNDSTX:
+		var tx *protocol.FundsTx
+		if tx, _ = tx.Decode(encodedTx); tx != nil {
+			return tx
+		}
+	case TYPE_ACCTX:
+		var tx *protocol.AccTx
+		if tx, _ = tx.Decode(encodedTx); tx != nil {
+			return tx
+		}
+	case TYPE_CONFIGTX:
+		var tx *protocol.ConfigTx
+		if tx, _ = tx.Decode(encodedTx); tx != nil {
+			return tx
+		}
+	case TYPE_STAKETX:
+		var tx *protocol.StakeTx
+		if tx, _ = tx.Decode(encodedTx); tx != nil {
+			return tx
+		}
+	case TYPE_AGGTX:
+		var tx *protocol.AggTx
+		if tx, _ = tx.Decode(encodedTx); tx != nil {
+			return tx
+		}
+	case TYPE_IOTTX:
+		var tx *protocol.IotTx
+		if tx, _ = tx.Decode(encodedTx); tx != nil {
+			return tx
+		}
+	case TYPE_CONTRACTTX:
+		var tx *protocol.ContractTx
+		if tx, _ = tx.Decode(encodedTx); tx != nil {
+			return tx
+		}
+	}
+
+	return nil
+}
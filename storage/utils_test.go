@@ -3,14 +3,11 @@ package storage
 import (
 	"fmt"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
-	"math/big"
 	"testing"
 )
 
 func TestSerializeHashContent(t *testing.T) {
 	var data []byte
-	pubKeyInt, _ := new(big.Int).SetString(PubA1+PubA2, 16)
-	copy(data, pubKeyInt.Bytes())
 
 	hash := protocol.SerializeHashContent(data)
 
@@ -1,13 +1,13 @@
 package storage
 
 import (
+	"encoding/binary"
 	"github.com/bazo-blockchain/bazo-miner/protocol"
-	"github.com/boltdb/bolt"
 )
 
 func WriteOpenBlock(block *protocol.Block) (err error) {
 
-	err = db.Update(func(tx *bolt.Tx) error {
+	err = db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("openblocks"))
 		err := b.Put(block.Hash[:], block.Encode())
 		return err
@@ -17,30 +17,20 @@ func WriteOpenBlock(block *protocol.Block) (err error) {
 }
 
 func WriteClosedBlock(block *protocol.Block) (err error) {
-
-	err = db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closedblocks"))
-		err := b.Put(block.Hash[:], block.Encode())
-		return err
-	})
-
-	return err
+	batch := NewBatch()
+	batch.WriteClosedBlock(block)
+	return batch.Commit()
 }
 
 func WriteClosedBlockWithoutTx(block *protocol.Block) (err error) {
-
-	err = db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte("closedblockswithouttx"))
-		err := b.Put(block.HashWithoutTx[:], block.Encode())
-		return err
-	})
-
-	return err
+	batch := NewBatch()
+	batch.WriteClosedBlockWithoutTx(block)
+	return batch.Commit()
 }
 
 func WriteLastClosedBlock(block *protocol.Block) (err error) {
 
-	err = db.Update(func(tx *bolt.Tx) error {
+	err = db.Update(func(tx KVTx) error {
 		b := tx.Bucket([]byte("lastclosedblock"))
 		err := b.Put(block.Hash[:], block.Encode())
 		return err
@@ -49,13 +39,6 @@ func WriteLastClosedBlock(block *protocol.Block) (err error) {
 	return err
 }
 
-//Changing the "tx" shortcut here and using "transaction" to distinguish between bolt's transactions
-func WriteOpenTx(transaction protocol.Transaction) {
-	openTxMutex.Lock()
-	defer openTxMutex.Unlock()
-	txMemPool[transaction.Hash()] = transaction
-}
-
 func WriteFundsTxBeforeAggregation(transaction *protocol.FundsTx) {
 	openFundsTxBeforeAggregationMutex.Lock()
 	defer openFundsTxBeforeAggregationMutex.Unlock()
@@ -94,36 +77,172 @@ func blockAlreadyInStash(slice []*protocol.Block, newBlockHash [32]byte) bool {
 }
 
 func WriteClosedTx(transaction protocol.Transaction) (err error) {
+	batch := NewBatch()
+	batch.WriteClosedTx(transaction)
+	return batch.Commit()
+}
 
-	var bucket string
-	switch transaction.(type) {
-	case *protocol.FundsTx:
-		bucket = "closedfunds"
-	case *protocol.AccTx:
-		bucket = "closedaccs"
-	case *protocol.ConfigTx:
-		bucket = "closedconfigs"
-	case *protocol.StakeTx:
-		bucket = "closedstakes"
-	case *protocol.AggTx:
-		bucket = "closedaggregations"
-	case *protocol.IotTx:
-		bucket = "closediotts"
-	}
+//Index of the block an IoT tx was confirmed in, needed to hand out confirmation bundles (see ReadIotAck)
+func WriteIotTxBlock(txHash, blockHash [32]byte) (err error) {
+	batch := NewBatch()
+	batch.WriteIotTxBlock(txHash, blockHash)
+	return batch.Commit()
+}
+
+//Indexes of the blocks produced by a beneficiary and of the blocks slashing an address. The key is the address
+//followed by the block height (big endian), so the blocks of an address are stored next to each other in height order.
+func WriteBeneficiaryBlock(beneficiary [32]byte, height uint32, blockHash [32]byte) (err error) {
+	batch := NewBatch()
+	batch.WriteBeneficiaryBlock(beneficiary, height, blockHash)
+	return batch.Commit()
+}
+
+func WriteSlashingBlock(slashedAddress [32]byte, height uint32, blockHash [32]byte) (err error) {
+	batch := NewBatch()
+	batch.WriteSlashingBlock(slashedAddress, height, blockHash)
+	return batch.Commit()
+}
+
+//Index of the txs affecting an account. The key is the address followed by the block height (big endian) and the tx
+//hash, so the history of an address is stored next to each other in height order.
+func WriteAccountTx(address [32]byte, height uint32, txHash [32]byte) (err error) {
+	batch := NewBatch()
+	batch.WriteAccountTx(address, height, txHash)
+	return batch.Commit()
+}
+
+//Index of the blocks of the chain the node is on by height, the key is the height (big endian).
+func WriteBlockHeight(height uint32, blockHash [32]byte) (err error) {
+	batch := NewBatch()
+	batch.WriteBlockHeight(height, blockHash)
+	return batch.Commit()
+}
+
+func WriteSlashingProof(proof *protocol.SlashingProof) (err error) {
+	batch := NewBatch()
+	batch.WriteSlashingProof(proof)
+	return batch.Commit()
+}
+
+func WriteValidatorSet(set *protocol.ValidatorSet) (err error) {
+	batch := NewBatch()
+	batch.WriteValidatorSet(set)
+	return batch.Commit()
+}
+
+func WriteLivenessEvidence(evidence *protocol.LivenessEvidence) (err error) {
+	batch := NewBatch()
+	batch.WriteLivenessEvidence(evidence)
+	return batch.Commit()
+}
 
+func WriteLogs(logs []*protocol.Log) (err error) {
+	batch := NewBatch()
+	batch.WriteLogs(logs)
+	return batch.Commit()
+}
+
+//The genesis specification the database was created with.
+func WriteGenesis(encodedGenesis []byte) (err error) {
 
-	hash := transaction.Hash()
-	err = db.Update(func(tx *bolt.Tx) error {
-		b := tx.Bucket([]byte(bucket))
-		err := b.Put(hash[:], transaction.Encode())
+	err = db.Update(func(tx KVTx) error {
+		b := tx.Bucket([]byte("genesis"))
+		err := b.Put([]byte("genesis"), encodedGenesis)
 		return err
 	})
-	nrClosedTransactions = nrClosedTransactions + 1
-	totalTransactionSize = totalTransactionSize + float32(transaction.Size())
-	averageTxSize = totalTransactionSize/nrClosedTransactions
+
+	return err
+}
+
+//Only the latest state snapshot is kept.
+func WriteSnapshot(encodedSnapshot []byte) (err error) {
+
+	err = db.Update(func(tx KVTx) error {
+		b := tx.Bucket([]byte("snapshot"))
+		err := b.Put([]byte("latest"), encodedSnapshot)
+		return err
+	})
+
 	return err
 }
 
 func WriteAccount(account *protocol.Account) {
-	State[account.Address] = account
-}
\ No newline at end of file
+	recordAccount(account.Address)
+	State.Set(account.Address, account)
+}
+
+//Broadcasts that could not be sent because we were not connected to any miner. The key is a sequence number such
+//that the broadcasts can be replayed in the order they were queued. The queued broadcasts must not exceed maxSize
+//bytes, the oldest ones are dropped to make room. Returns the number of dropped broadcasts.
+func WriteOutboundBroadcast(packet []byte, maxSize uint64) (dropped int, err error) {
+
+	err = db.Update(func(tx KVTx) error {
+		b := tx.Bucket([]byte("outboundbroadcasts"))
+		seq, err := b.NextSequence()
+		if err != nil {
+			return err
+		}
+
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], seq)
+		if err := b.Put(key[:], packet); err != nil {
+			return err
+		}
+
+		var size uint64
+		var keys [][]byte
+		var sizes []uint64
+		b.ForEach(func(k, v []byte) error {
+			size += uint64(len(v))
+			keys = append(keys, append([]byte{}, k...))
+			sizes = append(sizes, uint64(len(v)))
+			return nil
+		})
+
+		for ; size > maxSize && dropped < len(keys); dropped++ {
+			if err := b.Delete(keys[dropped]); err != nil {
+				return err
+			}
+			size -= sizes[dropped]
+		}
+
+		return nil
+	})
+
+	return dropped, err
+}
+
+//Persists the open transactions on shutdown, they are restored to the mempool on the next start. The transactions are
+//stored in the given order (keyed by sequence), so the txCnt order of each sender is preserved.
+func WriteOpenTxs(transactions []protocol.Transaction) (err error) {
+
+	err = db.Update(func(tx KVTx) error {
+		b := tx.Bucket([]byte("opentxs"))
+		for _, transaction := range transactions {
+			encodedTx := encodeTypedTx(transaction)
+			if encodedTx == nil {
+				continue
+			}
+
+			seq, err := b.NextSequence()
+			if err != nil {
+				return err
+			}
+
+			var key [8]byte
+			binary.BigEndian.PutUint64(key[:], seq)
+			if err := b.Put(key[:], encodedTx); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+
+	return err
+}
+
+func WriteEpochAggregate(aggregate *protocol.EpochAggregate, leaves [][32]byte) error {
+	batch := NewBatch()
+	batch.WriteEpochAggregate(aggregate, leaves)
+	return batch.Commit()
+}
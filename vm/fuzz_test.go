@@ -9,11 +9,11 @@ import (
 
 // Function generates random bytes, if an exception occurs, it is catched and printed out with the random bytes,
 // so the specific failing test can be recreated
-func Fuzz() {
+func fuzz() {
 	code := protocol.RandomBytes()
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 10000
+	mc.GasLimit = 10000
 	vm.context = mc
 
 	defer func() {
@@ -27,6 +27,6 @@ func Fuzz() {
 
 func TestFuzz(t *testing.T) {
 	for i := 0; i <= 5000000; i++ {
-		Fuzz()
+		fuzz()
 	}
 }
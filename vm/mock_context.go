@@ -11,7 +11,7 @@ type MockContext struct {
 func NewMockContext(byteCode []byte) *MockContext {
 	mc := MockContext{}
 	mc.Contract = byteCode
-	mc.Fee = 50
+	mc.GasLimit = 50
 	return &mc
 }
 
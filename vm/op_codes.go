@@ -52,6 +52,7 @@ const (
 	CHECKSIG
 	ERRHALT
 	HALT
+	LOG // Emits a log with the number of topics given as argument
 	//	MAPCONTAINSKEY
 )
 
@@ -123,4 +124,5 @@ var OpCodes = []OpCode{
 	{CHECKSIG, "checksig", 0, nil, 1, 2},
 	{ERRHALT, "errhalt", 0, nil, 0, 1},
 	{HALT, "halt", 0, nil, 0, 1},
+	{LOG, "log", 1, []int{BYTE}, 100, 2},
 }
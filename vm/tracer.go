@@ -0,0 +1,53 @@
+package vm
+
+import (
+	"github.com/bazo-blockchain/bazo-miner/protocol"
+)
+
+// Records every instruction executed by a VM (and the contracts it calls), so contract developers can follow the
+// execution of a tx step by step. Tracing is deterministic, it does not change the outcome or the gas of the execution.
+type Tracer struct {
+	Steps []TraceStep
+}
+
+type TraceStep struct {
+	Depth  int // Depth of the contract call, 0 for the contract called by the tx
+	Pc     int
+	OpCode string
+	Gas    uint64 // Gas left before the instruction
+	// Evaluation stack before the instruction, the top of the stack last
+	Stack        []protocol.ByteArray
+	StorageWrite *StorageWrite
+}
+
+type StorageWrite struct {
+	Index int
+	Value []byte
+}
+
+// Records the execution of the following Exec calls in the returned tracer
+func (vm *VM) EnableTracing() *Tracer {
+	vm.tracer = new(Tracer)
+	return vm.tracer
+}
+
+func (tracer *Tracer) recordStep(vm *VM, pc int, opCode OpCode) {
+	stack := make([]protocol.ByteArray, len(vm.evaluationStack.Stack))
+	for i, element := range vm.evaluationStack.Stack {
+		stack[i] = append([]byte{}, element...)
+	}
+
+	tracer.Steps = append(tracer.Steps, TraceStep{
+		Depth:  vm.depth,
+		Pc:     pc,
+		OpCode: opCode.Name,
+		Gas:    vm.gas,
+		Stack:  stack,
+	})
+}
+
+func (tracer *Tracer) recordStorageWrite(index int, value []byte) {
+	if len(tracer.Steps) > 0 {
+		tracer.Steps[len(tracer.Steps)-1].StorageWrite = &StorageWrite{index, append([]byte{}, value...)}
+	}
+}
@@ -59,6 +59,27 @@ func BoolToByteArray(value bool) []byte {
 	return []byte{result}
 }
 
+// Values are little endian as pushed by CALLVAL and BALANCE, at most 8 bytes
+func valueToUInt64(element []byte) uint64 {
+	padded := make([]byte, 8)
+	copy(padded, element)
+	return binary.LittleEndian.Uint64(padded)
+}
+
+// Encodes the function hash and the arguments as parameters of the transaction data, see CALLDATA
+func encodeCallData(functionHash []byte, args [][]byte) ([]byte, error) {
+	var data []byte
+	for _, param := range append([][]byte{functionHash}, args...) {
+		if len(param) == 0 || len(param) > 256 {
+			return nil, errors.New("Invalid argument length")
+		}
+		data = append(data, byte(len(param)-1))
+		data = append(data, param...)
+	}
+
+	return data, nil
+}
+
 func ByteArrayToBool(ba []byte) bool {
 	return ba[0] == 1
 }
@@ -106,3 +127,9 @@ func SignedByteArrayConversion(bi big.Int) []byte {
 }
 
 
+
+// Encodes a signed big integer as the arguments of PUSH: the amount of bytes - 1 followed by the signed bytes
+func BigIntToPushableBytes(element big.Int) []byte {
+	signed := SignedByteArrayConversion(element)
+	return append([]byte{byte(len(signed) - 1)}, signed...)
+}
@@ -20,24 +20,31 @@ type Context interface {
 	GetSender() [32]byte
 	GetAmount() uint64
 	GetTransactionData() []byte
-	GetFee() uint64
+	GetGasLimit() uint64
 	GetSig() [64]byte
+	EmitLog(topics []protocol.ByteArray, data []byte) error
+	Call(address [32]byte, value uint64, data []byte, gasLimit uint64) (*protocol.Context, error)
+	Commit(call *protocol.Context)
 }
 
 type VM struct {
 	code            []byte
 	pc              int // Program counter
-	fee             uint64
+	gasLimit        uint64
+	gas             uint64 // Gas left
 	evaluationStack *Stack
 	callStack       *CallStack
 	context         Context
+	depth           int     // Depth of the contract call, see CALLEXT
+	tracer          *Tracer // Nil unless tracing is enabled
 }
 
 func NewVM(context Context) VM {
 	return VM{
 		code:            []byte{},
 		pc:              0,
-		fee:             0,
+		gasLimit:        0,
+		gas:             0,
 		evaluationStack: NewStack(),
 		callStack:       NewCallStack(),
 		context:         context,
@@ -48,7 +55,8 @@ func NewTestVM(byteCode []byte) VM {
 	return VM{
 		code:            []byte{},
 		pc:              0,
-		fee:             0,
+		gasLimit:        0,
+		gas:             0,
 		evaluationStack: NewStack(),
 		callStack:       NewCallStack(),
 		context:         NewMockContext(byteCode),
@@ -122,7 +130,8 @@ func (vm *VM) trace() {
 func (vm *VM) Exec(trace bool) bool {
 
 	vm.code = vm.context.GetContract()
-	vm.fee = vm.context.GetFee()
+	vm.gasLimit = vm.context.GetGasLimit()
+	vm.gas = vm.gasLimit
 
 	if len(vm.code) > 100000 {
 		vm.evaluationStack.Push([]byte("vm.exec(): Instruction set to big"))
@@ -149,12 +158,17 @@ func (vm *VM) Exec(trace bool) bool {
 		}
 
 		opCode := OpCodes[byteCode]
+		if vm.tracer != nil {
+			vm.tracer.recordStep(vm, vm.pc-1, opCode)
+		}
+
 		// Subtract gas used for operation
-		if vm.fee < opCode.gasPrice {
+		if vm.gas < opCode.gasPrice {
+			vm.gas = 0
 			vm.evaluationStack.Push([]byte("vm.exec(): out of gas"))
 			return false
 		} else {
-			vm.fee -= opCode.gasPrice
+			vm.gas -= opCode.gasPrice
 		}
 
 		// Decode
@@ -526,8 +540,58 @@ func (vm *VM) Exec(trace bool) bool {
 				return false
 			}
 
-			fmt.Sprint("CALLEXT", transactionAddress, functionHash, argsToLoad)
-			//TODO: Invoke new transaction with function hash and arguments, waiting for integration in bazo blockchain to finish
+			// The arguments are popped first, then the value transferred to the called contract
+			args := make([][]byte, argsToLoad)
+			for i := int(argsToLoad) - 1; i >= 0; i-- {
+				args[i], err = vm.PopBytes(opCode)
+				if err != nil {
+					vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
+					return false
+				}
+			}
+
+			valueBytes, err := vm.PopBytes(opCode)
+			if err != nil || len(valueBytes) > 8 {
+				vm.evaluationStack.Push([]byte(opCode.Name + ": Invalid value"))
+				return false
+			}
+
+			data, err := encodeCallData(functionHash, args)
+			if err != nil {
+				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
+				return false
+			}
+
+			var address [32]byte
+			copy(address[:], transactionAddress)
+
+			// A failed call (e.g., out of gas) does not fail the calling contract, its changes are discarded and
+			// false is pushed instead of true. The return value is the ToS of the called contract (empty if none).
+			returnValue, success := []byte{}, false
+			call, err := vm.context.Call(address, valueToUInt64(valueBytes), data, vm.gas)
+			if err == nil {
+				callVM := NewVM(call)
+				callVM.depth = vm.depth + 1
+				callVM.tracer = vm.tracer
+				success = callVM.Exec(false)
+				vm.gas -= callVM.GasUsed()
+
+				if success {
+					if tos, err := callVM.evaluationStack.PeekBytes(); err == nil {
+						returnValue = tos
+					}
+					vm.context.Commit(call)
+				}
+			}
+
+			err = vm.evaluationStack.Push(returnValue)
+			if err == nil {
+				err = vm.evaluationStack.Push(BoolToByteArray(success))
+			}
+			if err != nil {
+				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
+				return false
+			}
 
 		case RET:
 			callstackTos, err := vm.callStack.Peek()
@@ -567,6 +631,10 @@ func (vm *VM) Exec(trace bool) bool {
 				return false
 			}
 
+			if vm.tracer != nil {
+				vm.tracer.recordStorageWrite(int(index), value)
+			}
+
 		case STORE:
 			address, errArgs := vm.fetch(opCode.Name)
 			right, errStack := vm.PopSignedBigInt(opCode)
@@ -1066,6 +1134,38 @@ func (vm *VM) Exec(trace bool) bool {
 			copy(pubKeySig[:], signature[:])
 
 
+		case LOG:
+			nrOfTopics, errArgs := vm.fetch(opCode.Name)
+			data, errStack := vm.PopBytes(opCode)
+			if !vm.checkErrors(opCode.Name, errArgs, errStack) {
+				return false
+			}
+
+			if int(nrOfTopics) > protocol.MAX_LOG_TOPICS {
+				vm.evaluationStack.Push([]byte(opCode.Name + ": Too many topics"))
+				return false
+			}
+
+			topics := make([]protocol.ByteArray, nrOfTopics)
+			for i := range topics {
+				topic, err := vm.PopBytes(opCode)
+				if err != nil {
+					vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
+					return false
+				}
+				if len(topic) > protocol.MAX_LOG_TOPIC_SIZE {
+					vm.evaluationStack.Push([]byte(opCode.Name + ": Topic too long"))
+					return false
+				}
+				topics[i] = topic
+			}
+
+			err = vm.context.EmitLog(topics, data)
+			if err != nil {
+				vm.evaluationStack.Push([]byte(opCode.Name + ": " + err.Error()))
+				return false
+			}
+
 		case ERRHALT:
 			return false
 
@@ -1114,11 +1214,12 @@ func (vm *VM) PopBytes(opCode OpCode) (elements []byte, err error) {
 	elementSize := (len(bytes) + 64 - 1) / 64
 
 	gasCost := opCode.gasFactor * uint64(elementSize)
-	if int64(vm.fee-gasCost) < 0 {
+	if vm.gas < gasCost {
+		vm.gas = 0
 		return nil, errors.New("Out of gas")
 	}
 
-	vm.fee -= gasCost
+	vm.gas -= gasCost
 
 	return bytes, nil
 }
@@ -1132,11 +1233,12 @@ func (vm *VM) PopSignedBigInt(opCode OpCode) (bigInt big.Int, err error) {
 	elementSize := (len(bytes) + 64 - 1) / 64
 
 	gasCost := opCode.gasFactor * uint64(elementSize)
-	if int64(vm.fee-gasCost) < 0 {
+	if vm.gas < gasCost {
+		vm.gas = 0
 		return *big.NewInt(0), errors.New("Out of gas")
 	}
 
-	vm.fee -= gasCost
+	vm.gas -= gasCost
 
 	result, err := SignedBigIntConversion(bytes, err)
 	return result, err
@@ -1151,16 +1253,23 @@ func (vm *VM) PopUnsignedBigInt(opCode OpCode) (bigInt big.Int, err error) {
 	elementSize := (len(bytes) + 64 - 1) / 64
 
 	gasCost := opCode.gasFactor * uint64(elementSize)
-	if int64(vm.fee-gasCost) < 0 {
+	if vm.gas < gasCost {
+		vm.gas = 0
 		return *big.NewInt(0), errors.New("Out of gas")
 	}
 
-	vm.fee -= gasCost
+	vm.gas -= gasCost
 
 	result, err := UnsignedBigIntConversion(bytes, err)
 	return result, err
 }
 
+//The gas consumed by the last Exec call. A failed execution may have used less than the gas limit, running out of gas
+//consumes all of it.
+func (vm *VM) GasUsed() uint64 {
+	return vm.gasLimit - vm.gas
+}
+
 func (vm *VM) GetErrorMsg() string {
 	tos, err := vm.evaluationStack.PeekBytes()
 	if err != nil {
@@ -33,7 +33,7 @@ func TestVM_Exec_GasConsumption(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 30
+	mc.GasLimit = 30
 	vm.context = mc
 
 	vm.Exec(false)
@@ -744,7 +744,7 @@ func TestVM_Exec_Sstore(t *testing.T) {
 	mc := NewMockContext(code)
 	mc.ContractVariables = []protocol.ByteArray{[]byte("Something")}
 	vm.context = mc
-	mc.Fee = 100000
+	mc.GasLimit = 100000
 	vm.Exec(false)
 	mc.PersistChanges()
 
@@ -763,18 +763,18 @@ func TestVM_Exec_Address(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	ba := [64]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	ba := [32]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
 	mc.Address = ba
 	vm.context = mc
 
 	vm.Exec(false)
 	tos, _ := vm.evaluationStack.Pop()
 
-	if len(tos) != 64 {
-		t.Errorf("Expected TOS size to be 64, but got %v", len(tos))
+	if len(tos) != 32 {
+		t.Errorf("Expected TOS size to be 32, but got %v", len(tos))
 	}
 
-	//This just tests 1/8 of the address as Uint64 are 64 bits and the address is 64 bytes
+	//This just tests 1/4 of the address as Uint64 are 64 bits and the address is 32 bytes
 	actual := binary.LittleEndian.Uint64(tos)
 	var expected uint64 = 18446744073709551615
 
@@ -871,7 +871,7 @@ func TestVM_Exec_Calldata(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 50
+	mc.GasLimit = 50
 
 	td := []byte{
 		0, 0x02,
@@ -1131,7 +1131,7 @@ func TestVM_Exec_MapGetVAL(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 300
+	mc.GasLimit = 300
 	vm.context = mc
 
 	exec := vm.Exec(false)
@@ -1174,7 +1174,7 @@ func TestVM_Exec_MapSetVal(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 300
+	mc.GasLimit = 300
 	vm.context = mc
 	exec := vm.Exec(false)
 
@@ -1230,7 +1230,7 @@ func TestVM_Exec_MapRemove(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 300
+	mc.GasLimit = 300
 	vm.context = mc
 
 	exec := vm.Exec(false)
@@ -1335,7 +1335,7 @@ func TestVM_Exec_ArrInsert(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 300
+	mc.GasLimit = 300
 	vm.context = mc
 	exec := vm.Exec(false)
 	if !exec {
@@ -1377,7 +1377,7 @@ func TestVM_Exec_ArrRemove(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 300
+	mc.GasLimit = 300
 	vm.context = mc
 	exec := vm.Exec(false)
 
@@ -1435,7 +1435,7 @@ func TestVM_Exec_ArrAt(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 200
+	mc.GasLimit = 200
 	vm.context = mc
 	exec := vm.Exec(false)
 
@@ -1505,7 +1505,7 @@ func TestVM_Exec_PopOnEmptyStack(t *testing.T) {
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
 	vm.context = mc
-	mc.Fee = 100
+	mc.GasLimit = 100
 	vm.Exec(false)
 
 	tos, _ := vm.evaluationStack.Pop()
@@ -1545,7 +1545,7 @@ func TestVM_Exec_FuzzReproduction_InstructionSetOutOfBounds2(t *testing.T) {
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
 	vm.context = mc
-	mc.Fee = 100000
+	mc.GasLimit = 100000
 	vm.Exec(false)
 
 	tos, _ := vm.evaluationStack.Pop()
@@ -1584,7 +1584,7 @@ func TestVM_Exec_FuzzReproduction_IndexOutOfBounds2(t *testing.T) {
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
 	vm.context = mc
-	mc.Fee = 100000
+	mc.GasLimit = 100000
 	vm.Exec(false)
 
 	tos, _ := vm.evaluationStack.Pop()
@@ -1708,7 +1708,7 @@ func TestVM_Exec_FuzzReproduction_ContextOpCode1(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 200
+	mc.GasLimit = 200
 	vm.context = mc
 	vm.Exec(false)
 
@@ -1728,7 +1728,7 @@ func TestVM_Exec_FuzzReproduction_ContextOpCode2(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 200
+	mc.GasLimit = 200
 	vm.context = mc
 	vm.Exec(false)
 
@@ -1743,7 +1743,7 @@ func TestVM_Exec_FuzzReproduction_ContextOpCode2(t *testing.T) {
 
 func TestVM_Exec_FuzzReproduction_EdgecaseLastOpcodePlusOne(t *testing.T) {
 	code := []byte{
-		HALT + 1,
+		LOG + 1,
 	}
 
 	vm := NewTestVM([]byte{})
@@ -1770,7 +1770,7 @@ func TestVM_PopBytes(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 11
+	mc.GasLimit = 11
 	vm.context = mc
 
 	vm.Exec(false)
@@ -1784,7 +1784,7 @@ func TestVM_PopBytes(t *testing.T) {
 	}
 
 	expectedFee := 4
-	actualFee := vm.fee
+	actualFee := vm.gas
 
 	if int(actualFee) != expectedFee {
 		t.Errorf("Expected actual fee to be '%v' but was '%v'", expected, actual)
@@ -1798,7 +1798,7 @@ func TestVM_FuzzTest_Reproduction(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 11
+	mc.GasLimit = 11
 	vm.context = mc
 
 	vm.Exec(false)
@@ -1811,7 +1811,7 @@ func TestVM_FuzzTest_Reproduction_IndexOutOfRange(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 100
+	mc.GasLimit = 100
 	vm.context = mc
 
 	vm.Exec(false)
@@ -1829,19 +1829,165 @@ func TestVM_GasCalculation(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 11
+	mc.GasLimit = 11
 	vm.context = mc
 
 	vm.Exec(false)
 
 	expectedFee := 2
-	actualFee := vm.fee
+	actualFee := vm.gas
 
 	if int(actualFee) != expectedFee {
 		t.Errorf("Expected actual fee to be '%v' but was '%v'", expectedFee, actualFee)
 	}
 }
 
+func TestVM_GasUsed(t *testing.T) {
+	code := []byte{
+		PUSH, 1, 0, 8,
+		PUSH, 1, 0, 8,
+		ADD,
+		HALT,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.GasLimit = 11
+	vm.context = mc
+
+	if !vm.Exec(false) || vm.GasUsed() != 7 {
+		t.Errorf("Expected gas used to be '%v' but was '%v'", 7, vm.GasUsed())
+	}
+
+	vm = NewTestVM([]byte{})
+	mc.GasLimit = 5
+	vm.context = mc
+
+	if vm.Exec(false) || vm.GasUsed() != 5 {
+		t.Errorf("Expected out of gas to use all '%v' gas but was '%v'", 5, vm.GasUsed())
+	}
+}
+
+func TestVM_Log(t *testing.T) {
+	code := []byte{
+		PUSH, 0, 2,
+		PUSH, 0, 1,
+		PUSH, 1, 0, 8,
+		LOG, 2,
+		HALT,
+	}
+
+	vm := NewTestVM([]byte{})
+	mc := NewMockContext(code)
+	mc.GasLimit = 300
+	vm.context = mc
+
+	if !vm.Exec(false) {
+		t.Fatalf("Expected execution to succeed but failed with '%v'", vm.GetErrorMsg())
+	}
+
+	logs := mc.GetLogs()
+	if len(logs) != 1 || !bytes.Equal(logs[0].Data, []byte{0, 8}) || len(logs[0].Topics) != 2 || !logs[0].HasTopic([]byte{1}) || !logs[0].HasTopic([]byte{2}) {
+		t.Errorf("Expected a log with two topics but was '%v'", logs)
+	}
+
+	code = []byte{
+		PUSH, 0, 1,
+		LOG, protocol.MAX_LOG_TOPICS + 1,
+		HALT,
+	}
+
+	vm = NewTestVM([]byte{})
+	vm.context = NewMockContext(code)
+
+	if vm.Exec(false) {
+		t.Error("Expected log with too many topics to fail")
+	}
+}
+
+func TestVM_CallExt(t *testing.T) {
+	callee := &protocol.Account{Address: [32]byte{0x02}, Contract: []byte{
+		CALLDATA,
+		SSTORE, 0,
+		PUSH, 0, 7,
+		HALT,
+	}, ContractVariables: []protocol.ByteArray{{0}}}
+	failing := &protocol.Account{Address: [32]byte{0x03}, Contract: []byte{
+		CALLDATA,
+		SSTORE, 0,
+		ERRHALT,
+	}, ContractVariables: []protocol.ByteArray{{0}}}
+	state := map[[32]byte]*protocol.Account{{0x02}: callee, {0x03}: failing}
+	loadAccount := func(hash [32]byte) *protocol.Account { return state[hash] }
+
+	callCode := func(address byte) []byte {
+		code := []byte{PUSH, 0, 5, PUSH, 0, 3, CALLEXT, address}
+		code = append(code, make([]byte, 31)...)
+		return append(code, 0, 0, 0, 1, 1, HALT)
+	}
+
+	caller := protocol.Account{Address: [32]byte{0x01}, Contract: callCode(0x02)}
+	state[[32]byte{0x01}] = &caller
+	context := protocol.NewContext(caller, protocol.FundsTx{To: [32]byte{0x01}, Amount: 10, GasLimit: 10000}, loadAccount)
+	vm := NewVM(context)
+
+	if !vm.Exec(false) {
+		t.Fatalf("Expected execution to succeed but failed with '%v'", vm.GetErrorMsg())
+	}
+
+	success, _ := vm.evaluationStack.Pop()
+	returnValue, _ := vm.evaluationStack.Pop()
+	if !ByteArrayToBool(success) || !bytes.Equal(returnValue, []byte{7}) {
+		t.Errorf("Expected call to return '%v' but was '%v' (success: %v)", []byte{7}, returnValue, success)
+	}
+
+	caller.Balance = 10
+	context.PersistChanges()
+	if callee.Balance != 5 || caller.Balance != 5 || !bytes.Equal(callee.ContractVariables[0], []byte{3}) {
+		t.Errorf("Expected value and argument to be transferred but was '%v', '%v'", callee.Balance, callee.ContractVariables)
+	}
+
+	caller = protocol.Account{Address: [32]byte{0x01}, Contract: callCode(0x03)}
+	state[[32]byte{0x01}] = &caller
+	context = protocol.NewContext(caller, protocol.FundsTx{To: [32]byte{0x01}, Amount: 10, GasLimit: 10000}, loadAccount)
+	vm = NewVM(context)
+
+	if !vm.Exec(false) {
+		t.Fatalf("Expected failed call not to fail the caller but failed with '%v'", vm.GetErrorMsg())
+	}
+
+	success, _ = vm.evaluationStack.Pop()
+	if ByteArrayToBool(success) {
+		t.Error("Expected failed call to push false")
+	}
+
+	caller.Balance = 10
+	context.PersistChanges()
+	if failing.Balance != 0 || caller.Balance != 10 || !bytes.Equal(failing.ContractVariables[0], []byte{0}) {
+		t.Errorf("Expected changes of failed call to be discarded but was '%v', '%v'", failing.Balance, failing.ContractVariables)
+	}
+}
+
+func TestVM_CallExtDepth(t *testing.T) {
+	// Calls itself without value until the maximum call depth is reached
+	code := []byte{PUSH, 0, 0, CALLEXT, 0x01}
+	code = append(code, make([]byte, 31)...)
+	code = append(code, 0, 0, 0, 1, 0, HALT)
+
+	contract := &protocol.Account{Address: [32]byte{0x01}, Contract: code}
+	loadAccount := func(hash [32]byte) *protocol.Account { return contract }
+	context := protocol.NewContext(*contract, protocol.FundsTx{To: [32]byte{0x01}, GasLimit: 100000}, loadAccount)
+	vm := NewVM(context)
+
+	if !vm.Exec(false) {
+		t.Fatalf("Expected execution to succeed but failed with '%v'", vm.GetErrorMsg())
+	}
+
+	if vm.GasUsed() <= protocol.MAX_CALL_DEPTH*1000 {
+		t.Errorf("Expected gas of nested calls to be charged to the caller but was '%v'", vm.GasUsed())
+	}
+}
+
 func TestVM_PopBytesOutOfGas(t *testing.T) {
 	code := []byte{
 		PUSH, 1, 0, 8,
@@ -1852,7 +1998,7 @@ func TestVM_PopBytesOutOfGas(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 3
+	mc.GasLimit = 3
 	vm.context = mc
 
 	vm.Exec(false)
@@ -1866,7 +2012,7 @@ func TestVM_PopBytesOutOfGas(t *testing.T) {
 	}
 
 	expectedFee := 0
-	actualFee := vm.fee
+	actualFee := vm.gas
 
 	if int(actualFee) != expectedFee {
 		t.Errorf("Expected actual fee to be '%v' but was '%v'", expected, actual)
@@ -1928,7 +2074,7 @@ func BenchmarkVM_Exec_ModularExponentiation_ContractImplementation(b *testing.B)
 
 				vm := NewTestVM([]byte{})
 				mc := NewMockContext(contract)
-				mc.Fee = 1000000000000
+				mc.GasLimit = 1000000000000
 				vm.context = mc
 
 				if vm.Exec(false) != true {
@@ -1937,7 +2083,7 @@ func BenchmarkVM_Exec_ModularExponentiation_ContractImplementation(b *testing.B)
 					b.Fail()
 				}
 				vm.pc = 0
-				mc.Fee = 10000000000000
+				mc.GasLimit = 10000000000000
 			}
 
 			b.ReportAllocs()
@@ -2065,7 +2211,7 @@ func TestVm_Exec_Loop(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 1000
+	mc.GasLimit = 1000
 	vm.context = mc
 	vm.Exec(false)
 
@@ -2135,7 +2281,7 @@ func TestVm_Exec_ModularExponentiation_ContractImplementation(t *testing.T) {
 
 	vm := NewTestVM([]byte{})
 	mc := NewMockContext(code)
-	mc.Fee = 1000
+	mc.GasLimit = 1000
 	vm.context = mc
 	vm.Exec(false)
 